@@ -17,11 +17,15 @@ package apply
 import (
 	"fmt"
 
+	"github.com/asteris-llc/converge/errcode"
 	"github.com/asteris-llc/converge/executor"
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/helpers/logging"
 	"github.com/asteris-llc/converge/plan"
 	"github.com/asteris-llc/converge/render"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/module"
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 )
@@ -65,12 +69,25 @@ func WithNotify(ctx context.Context, in *graph.Graph, notify *graph.Notifier) (*
 func execPipeline(ctx context.Context, in *graph.Graph, pipelineF MkPipelineF, renderingPlant *render.Factory, notify *graph.Notifier) (*graph.Graph, error) {
 	var hasErrors error
 
+	ctx = resource.WithClaimLocker(ctx, resource.NewClaimLocker())
+	inTransactions := newTransactions()
+
 	out, err := in.Transform(ctx,
 		notify.Transform(func(meta *node.Node, out *graph.Graph) error {
 			renderingPlant.Graph = out
 			pipeline := pipelineF(out, meta.ID)
 
-			val, pipelineError := pipeline.Exec(ctx, meta.Value())
+			// let the user know about nodes that take a while, along with
+			// what they last printed
+			progress := new(resource.Progress)
+			nodeCtx := resource.WithProgress(ctx, progress)
+			if transaction, ok := meta.LookupMetadata(node.MetaTransaction); ok {
+				nodeCtx = resource.WithTransaction(nodeCtx, fmt.Sprint(transaction))
+			}
+
+			stopHeartbeat := notify.StartHeartbeat(meta, progress.Last)
+			val, pipelineError := pipeline.Exec(nodeCtx, meta.Value())
+			stopHeartbeat()
 
 			if pipelineError != nil {
 				hasErrors = ErrTreeContainsErrors
@@ -84,15 +101,109 @@ func execPipeline(ctx context.Context, in *graph.Graph, pipelineF MkPipelineF, r
 			if nil != asResult.Error() {
 				hasErrors = ErrTreeContainsErrors
 			}
+			inTransactions.record(ctx, meta, asResult)
 
-			out.Add(meta.WithValue(asResult))
+			// nodes prepared from thunks were rendered here
+			updated := meta.WithValue(asResult)
+			render.RecordProvenance(updated, renderingPlant.Provenance(meta.ID))
+			out.Add(updated)
 			return nil
 		}),
 	)
 
+	if out != nil {
+		cleanup(ctx, out)
+	}
+
+	if ctx.Err() != nil {
+		if interruptErr := interrupt(out, notify); interruptErr != nil {
+			return out, interruptErr
+		}
+		hasErrors = ErrTreeContainsErrors
+		if err == ctx.Err() {
+			err = nil
+		}
+	}
+
 	if err != nil {
 		return out, err
 	}
 
 	return out, hasErrors
 }
+
+// cleanup runs the cleanup phase of every task that was applied. It still runs
+// when the run failed or was interrupted, so failures are only logged.
+func cleanup(ctx context.Context, g *graph.Graph) {
+	logger := logging.GetLogger(ctx).WithField("function", "cleanup")
+
+	for _, meta := range g.Nodes() {
+		result, ok := meta.Value().(*Result)
+		if !ok || !result.Ran {
+			continue
+		}
+
+		task, ok := resource.ResolveTask(result.Task)
+		if !ok {
+			continue
+		}
+
+		cleaner, ok := task.(resource.Cleaner)
+		if !ok {
+			continue
+		}
+
+		// the run's context may already be cancelled, but cleanup should still
+		// happen
+		if err := cleaner.Cleanup(context.Background()); err != nil {
+			logger.WithError(err).WithField("id", meta.ID).Warn("cleanup failed")
+			continue
+		}
+		logger.WithField("id", meta.ID).Debug("cleaned up")
+	}
+}
+
+// interrupt marks every node that was not applied before the run was
+// cancelled so that partial results can be reported
+func interrupt(g *graph.Graph, notify *graph.Notifier) error {
+	for _, meta := range g.Nodes() {
+		if _, ok := meta.Value().(*Result); ok {
+			continue
+		}
+
+		var updated *node.Node
+		switch value := meta.Value().(type) {
+		case *plan.Result:
+			updated = meta.WithValue(interrupted(value.Task, value))
+		case resource.Task:
+			updated = meta.WithValue(interrupted(value, nil))
+		default:
+			updated = meta.WithValue(interrupted(nil, nil))
+		}
+		g.Add(updated)
+
+		if notify != nil && notify.Post != nil {
+			if err := notify.Post(updated); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// interrupted creates the result for a node that was not applied because the
+// run was cancelled
+func interrupted(task resource.Task, planned *plan.Result) *Result {
+	status := &resource.Status{Level: resource.StatusWillChange}
+	if !module.IsModule(task) {
+		status.AddWarning(resource.WarningSkipped, "not applied because the run was interrupted")
+	}
+
+	return &Result{
+		Ran:    false,
+		Task:   task,
+		Plan:   planned,
+		Status: status,
+		Err:    errcode.Errorf(errcode.ApplyInterrupted, "interrupted before applying"),
+	}
+}
@@ -15,14 +15,20 @@
 package apply_test
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/asteris-llc/converge/apply"
+	"github.com/asteris-llc/converge/changewindow"
+	"github.com/asteris-llc/converge/errcode"
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/helpers/faketask"
+	"github.com/asteris-llc/converge/helpers/testing/graphutils"
 	"github.com/asteris-llc/converge/helpers/logging"
 	"github.com/asteris-llc/converge/plan"
+	"github.com/asteris-llc/converge/render"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -42,9 +48,10 @@ func TestApplyNoOp(t *testing.T) {
 	applied, err := apply.Apply(context.Background(), g)
 	assert.NoError(t, err)
 
-	result := getResult(t, applied, "root")
+	result := graphutils.GetApplyResult(t, applied, "root")
 	assert.Equal(t, task.Status, result.Status.Messages()[0])
 	assert.True(t, result.Ran)
+	assert.Equal(t, resource.OutcomeChanged, result.Outcome())
 }
 
 func TestApplyNoRun(t *testing.T) {
@@ -60,8 +67,9 @@ func TestApplyNoRun(t *testing.T) {
 	applied, err := apply.Apply(context.Background(), g)
 	assert.NoError(t, err)
 
-	result := getResult(t, applied, "root")
+	result := graphutils.GetApplyResult(t, applied, "root")
 	assert.False(t, result.Ran)
+	assert.Equal(t, resource.OutcomeNone, result.Outcome())
 }
 
 func TestApplyErrorsBelow(t *testing.T) {
@@ -86,12 +94,18 @@ func TestApplyErrorsBelow(t *testing.T) {
 	errNode, ok := errMeta.Value().(*apply.Result)
 	require.True(t, ok)
 	assert.EqualError(t, errNode.Error(), "error")
+	assert.Equal(t, resource.OutcomeFailed, errNode.Outcome())
 
 	rootMeta, ok := out.Get("root")
 	require.True(t, ok, `"root" was not present in the graph`)
 	rootNode, ok := rootMeta.Value().(*apply.Result)
 	require.True(t, ok)
 	assert.EqualError(t, rootNode.Error(), `error in dependency "root/err"`)
+	assert.Equal(
+		t,
+		[]resource.Warning{{Kind: resource.WarningSkipped, Message: `not applied because dependency "root/err" failed`}},
+		rootNode.Warnings(),
+	)
 }
 
 func TestApplyStillChange(t *testing.T) {
@@ -104,10 +118,19 @@ func TestApplyStillChange(t *testing.T) {
 
 	// applying should result in an error since the task will report that it still
 	// needs to change
-	_, err := apply.Apply(context.Background(), g)
+	applied, err := apply.Apply(context.Background(), g)
 	if assert.Error(t, err) {
 		assert.Equal(t, err, apply.ErrTreeContainsErrors)
 	}
+
+	// the status of applying is kept, and the check afterwards is reported
+	// separately
+	result := graphutils.GetApplyResult(t, applied, "root")
+	assert.Equal(t, []string{"changed"}, result.Messages())
+	assert.Equal(t, resource.OutcomeDrifted, result.Outcome())
+	if assert.NotNil(t, result.PostCheck) {
+		assert.True(t, result.PostCheck.HasChanges())
+	}
 }
 
 // TestApplyNilError test for panics in apply/pipeline.go
@@ -125,12 +148,378 @@ func TestApplyNilError(t *testing.T) {
 	assert.NotNil(t, out)
 }
 
-func getResult(t *testing.T, src *graph.Graph, key string) *apply.Result {
-	meta, ok := src.Get(key)
-	require.True(t, ok, "%q was not present in the graph", key)
+// TestApplyPanic tests that a panic in Apply fails only the panicking node
+func TestApplyPanic(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	g := graph.New()
+	g.Add(node.New("root", &plan.Result{Status: &resource.Status{Level: resource.StatusWillChange}, Task: faketask.Panic()}))
+
+	require.NoError(t, g.Validate())
+
+	applied, err := apply.Apply(context.Background(), g)
+	assert.Equal(t, apply.ErrTreeContainsErrors, err)
+
+	result := graphutils.GetApplyResult(t, applied, "root")
+	assert.True(t, result.Ran)
+	if assert.Error(t, result.Error()) {
+		assert.Contains(t, result.Error().Error(), "panic: task panicked")
+	}
+}
+
+// TestApplyInterrupted tests that nodes are reported as interrupted when the
+// context is cancelled before they are applied
+func TestApplyInterrupted(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	g := graph.New()
+	g.Add(node.New("root", &plan.Result{Status: &resource.Status{Level: resource.StatusWillChange}, Task: faketask.NoOp()}))
+	g.Add(node.New("root/a", &plan.Result{Status: &resource.Status{Level: resource.StatusWillChange}, Task: faketask.Swapper()}))
+	g.ConnectParent("root", "root/a")
+
+	require.NoError(t, g.Validate())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	applied, err := apply.Apply(ctx, g)
+	assert.Equal(t, apply.ErrTreeContainsErrors, err)
+
+	for _, id := range []string{"root", "root/a"} {
+		result := graphutils.GetApplyResult(t, applied, id)
+		assert.False(t, result.Ran)
+
+		code, ok := errcode.Of(result.Error())
+		assert.True(t, ok)
+		assert.Equal(t, errcode.ApplyInterrupted, code)
+	}
+}
+
+// TestApplyClaims tests that tasks with overlapping claims are not run at the
+// same time, even without dependencies between them
+func TestApplyClaims(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	counter := new(faketask.ConcurrencyCounter)
+
+	g := graph.New()
+	g.Add(node.New("root", faketask.NoOp()))
+	for _, id := range []string{"root/a", "root/b", "root/c"} {
+		g.Add(node.New(id, faketask.Claiming(resource.ClaimPackageDB, counter)))
+		g.ConnectParent("root", id)
+	}
+
+	require.NoError(t, g.Validate())
+
+	_, err := apply.PlanAndApply(context.Background(), g)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, counter.Max())
+}
+
+// operationHook records the operations it's called around
+type operationHook struct {
+	lock sync.Mutex
+	ops  []string
+}
+
+func (h *operationHook) Before(ctx context.Context, op *resource.Operation) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h *operationHook) After(ctx context.Context, op *resource.Operation, status resource.TaskStatus, err error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.ops = append(h.ops, fmt.Sprintf("%s %s: %s", op.Kind, op.ID, status.StatusCode()))
+}
+
+// TestApplyHooks tests that hooks are called around each check and apply,
+// including the check after applying
+func TestApplyHooks(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	g := graph.New()
+	g.Add(node.New("root", faketask.Swapper()))
+
+	require.NoError(t, g.Validate())
+
+	hook := new(operationHook)
+	ctx := resource.WithHooks(context.Background(), hook)
+
+	_, err := apply.PlanAndApply(ctx, g)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"check root: will change",
+		"apply root: no change",
+		"check root: no change",
+	}, hook.ops)
+}
+
+// TestApplyCleanup tests that tasks which were applied are cleaned up at the
+// end of the run
+func TestApplyCleanup(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	applied := faketask.Cleaning(faketask.Swapper())
+	skipped := faketask.Cleaning(faketask.NoOp())
+
+	g := graph.New()
+	g.Add(node.New("root", faketask.NoOp()))
+	g.Add(node.New("root/applied", applied))
+	g.Add(node.New("root/skipped", skipped))
+	g.ConnectParent("root", "root/applied")
+	g.ConnectParent("root", "root/skipped")
+
+	require.NoError(t, g.Validate())
+
+	_, err := apply.PlanAndApply(context.Background(), g)
+	assert.NoError(t, err)
+	assert.True(t, applied.CleanedUp)
+	assert.False(t, skipped.CleanedUp)
+}
+
+// TestApplyTransaction tests that the members of a transaction applied before
+// a failing member are rolled back in reverse order, and that restarts are
+// reapplied after the rest
+func TestApplyTransaction(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	var order []string
+
+	g := graph.New()
+	g.Add(node.New("root", faketask.NoOp()))
+	for id, task := range map[string]resource.Task{
+		"root/config":  faketask.RollingBack(faketask.Swapper(), "config", &order),
+		"root/restart": faketask.Reapplying(faketask.Swapper(), "restart", &order),
+		"root/cache":   faketask.Swapper(),
+		"root/reload":  faketask.RollingBack(faketask.Swapper(), "reload", &order),
+		"root/verify":  faketask.Error(),
+		"root/after":   faketask.RollingBack(faketask.Swapper(), "after", &order),
+		"root/outside": faketask.RollingBack(faketask.Swapper(), "outside", &order),
+	} {
+		member := node.New(id, task)
+		if id != "root/outside" {
+			member.AddMetadata(node.MetaTransaction, "service")
+		}
+		g.Add(member)
+		g.ConnectParent("root", id)
+	}
+	g.Connect("root/restart", "root/config")
+	g.Connect("root/cache", "root/restart")
+	g.Connect("root/reload", "root/cache")
+	g.Connect("root/verify", "root/reload")
+	g.Connect("root/after", "root/verify")
+
+	require.NoError(t, g.Validate())
+
+	out, err := apply.PlanAndApply(context.Background(), g)
+	assert.Equal(t, apply.ErrTreeContainsErrors, err)
+
+	assert.Equal(t, []string{"reload", "config", "restart"}, order)
+
+	verify := graphutils.GetApplyResult(t, out, "root/verify")
+	assert.Equal(
+		t,
+		[]string{
+			"rolled back root/reload",
+			"root/cache can't be rolled back",
+			"rolled back root/config",
+			"reapplied root/restart",
+		},
+		verify.Rollbacks(),
+	)
+
+	after := graphutils.GetApplyResult(t, out, "root/after")
+	assert.False(t, after.Ran)
+	assert.Empty(t, after.Rollbacks())
+}
+
+// TestApplyDestructive tests that destructive changes are only applied when
+// they are allowed for the run or on the node
+func TestApplyDestructive(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	destructive := func() *graph.Graph {
+		status := &resource.Status{Level: resource.StatusWillChange}
+		status.MarkDestructive("everything will be deleted")
+
+		g := graph.New()
+		g.Add(node.New("root", &plan.Result{Status: status, Task: faketask.Swapper()}))
+		require.NoError(t, g.Validate())
+		return g
+	}
+
+	t.Run("refused", func(t *testing.T) {
+		applied, err := apply.Apply(context.Background(), destructive())
+		assert.Equal(t, apply.ErrTreeContainsErrors, err)
+
+		result := graphutils.GetApplyResult(t, applied, "root")
+		assert.False(t, result.Ran)
+		assert.Equal(t, []string{"everything will be deleted"}, result.Destructive())
+
+		code, ok := errcode.Of(result.Error())
+		assert.True(t, ok)
+		assert.Equal(t, errcode.ApplyDestructive, code)
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		ctx := apply.WithAllowDestructive(context.Background())
+		applied, err := apply.Apply(ctx, destructive())
+		assert.NoError(t, err)
+		assert.True(t, graphutils.GetApplyResult(t, applied, "root").Ran)
+	})
+
+	t.Run("allowed on node", func(t *testing.T) {
+		g := destructive()
+		meta, _ := g.Get("root")
+		require.NoError(t, meta.AddMetadata(node.MetaAllowDestructive, true))
+
+		applied, err := apply.Apply(context.Background(), g)
+		assert.NoError(t, err)
+		assert.True(t, graphutils.GetApplyResult(t, applied, "root").Ran)
+	})
+}
+
+// TestApplyChangeWindow tests that changes are deferred while the node's change
+// window is closed
+func TestApplyChangeWindow(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	windowed := func(expr string) *graph.Graph {
+		status := &resource.Status{Level: resource.StatusWillChange}
+		status.AddDifference("state", "stopped", "running", "")
+
+		g := graph.New()
+		g.Add(node.New("root", &plan.Result{Status: status, Task: faketask.Swapper()}))
+		require.NoError(t, g.Validate())
+
+		window, err := changewindow.Parse(expr)
+		require.NoError(t, err)
+		meta, _ := g.Get("root")
+		require.NoError(t, meta.AddMetadata(node.MetaChangeWindow, window))
+		return g
+	}
+
+	t.Run("open", func(t *testing.T) {
+		applied, err := apply.Apply(context.Background(), windowed("* * * * *"))
+		assert.NoError(t, err)
+		assert.True(t, graphutils.GetApplyResult(t, applied, "root").Ran)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		// February 31st never comes
+		applied, err := apply.Apply(context.Background(), windowed("0 0 31 feb *"))
+		assert.NoError(t, err)
+
+		result := graphutils.GetApplyResult(t, applied, "root")
+		assert.False(t, result.Ran)
+		assert.NoError(t, result.Error())
+		assert.True(t, resource.AnyChanges(result.Changes()))
+		assert.Equal(
+			t,
+			[]resource.Warning{{
+				Kind:    resource.WarningDeferred,
+				Message: "not applied because change window 0 0 31 feb * is closed",
+			}},
+			result.Warnings(),
+		)
+	})
+}
+
+// generator exports an ID only once it has been applied
+type generator struct {
+	ID string `export:"id"`
+
+	// Label is empty but not computed, so lookups of it aren't deferred
+	Label string `export:"label"`
+}
+
+func (g *generator) Prepare(context.Context, resource.Renderer) (resource.Task, error) {
+	return g, nil
+}
+
+func (g *generator) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	if g.ID == "" {
+		return &resource.Status{Level: resource.StatusWillChange}, nil
+	}
+	return &resource.Status{Level: resource.StatusNoChange}, nil
+}
+
+func (g *generator) Apply(context.Context) (resource.TaskStatus, error) {
+	g.ID = "generated"
+	return &resource.Status{Level: resource.StatusWillChange}, nil
+}
+
+func (g *generator) ComputedFields() []string {
+	return []string{"id"}
+}
+
+// consumer records the value it was prepared with
+type consumer struct {
+	Value string `hcl:"value" export:"value"`
+
+	applied bool
+}
+
+func (c *consumer) Prepare(context.Context, resource.Renderer) (resource.Task, error) {
+	return &consumer{Value: c.Value}, nil
+}
 
-	result, ok := meta.Value().(*apply.Result)
-	require.True(t, ok, "needed a %T for %q, got a %T", result, key, meta.Value())
+func (c *consumer) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	if c.applied {
+		return &resource.Status{Level: resource.StatusNoChange}, nil
+	}
+	return &resource.Status{Level: resource.StatusWillChange}, nil
+}
+
+func (c *consumer) Apply(context.Context) (resource.TaskStatus, error) {
+	c.applied = true
+	return &resource.Status{Level: resource.StatusWillChange}, nil
+}
+
+// TestApplyDeferredLookups tests that lookups of values which only exist once
+// a dependency is applied are deferred while planning, and rendered at apply
+// time
+func TestApplyDeferredLookups(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	g := graph.New()
+	g.Add(node.New("root", nil))
+	g.Add(node.New("root/generator.x", resource.NewPreparerWithSource(new(generator), map[string]interface{}{})))
+	g.Add(node.New("root/consumer.y", resource.NewPreparerWithSource(
+		new(consumer),
+		map[string]interface{}{"value": "{{lookup `generator.x.id`}}"},
+	)))
+	g.Add(node.New("root/consumer.z", resource.NewPreparerWithSource(
+		new(consumer),
+		map[string]interface{}{"value": "label: {{lookup `generator.x.label`}}"},
+	)))
+	g.ConnectParent("root", "root/generator.x")
+	g.ConnectParent("root", "root/consumer.y")
+	g.ConnectParent("root", "root/consumer.z")
+	g.Connect("root/consumer.y", "root/generator.x")
+	g.Connect("root/consumer.z", "root/generator.x")
 
-	return result
+	rendered, err := render.Render(context.Background(), g, render.Values{})
+	require.NoError(t, err)
+
+	planned, err := plan.Plan(context.Background(), rendered)
+	require.NoError(t, err)
+
+	meta, ok := planned.Get("root/consumer.y")
+	require.True(t, ok)
+	result := meta.Value().(*plan.Result)
+	assert.Equal(t, []string{"value"}, result.Deferred)
+	assert.Equal(t, render.KnownAfterApply, result.Changes()["value"].Current())
+
+	meta, ok = planned.Get("root/consumer.z")
+	require.True(t, ok)
+	assert.Empty(t, meta.Value().(*plan.Result).Deferred)
+
+	applied, err := apply.Apply(context.Background(), planned)
+	require.NoError(t, err)
+
+	task, ok := resource.ResolveTask(graphutils.GetApplyResult(t, applied, "root/consumer.y").Task)
+	require.True(t, ok)
+	assert.Equal(t, "generated", task.(*consumer).Value)
 }
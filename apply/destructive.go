@@ -0,0 +1,32 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import "golang.org/x/net/context"
+
+type allowDestructiveKey struct{}
+
+// WithAllowDestructive returns a context in which destructive changes are
+// applied on every node, not only those configured with `force = true`
+func WithAllowDestructive(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allowDestructiveKey{}, true)
+}
+
+// destructiveAllowed reports whether destructive changes were allowed for the
+// whole run
+func destructiveAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(allowDestructiveKey{}).(bool)
+	return allowed
+}
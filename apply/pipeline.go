@@ -16,12 +16,18 @@ package apply
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/asteris-llc/converge/changewindow"
+	"github.com/asteris-llc/converge/errcode"
 	"github.com/asteris-llc/converge/executor"
 	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/plan"
 	"github.com/asteris-llc/converge/render"
 	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/module"
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 )
@@ -43,6 +49,8 @@ func Pipeline(g *graph.Graph, id string, factory *render.Factory) executor.Pipel
 		AndThen(gen.GetTask).
 		AndThen(gen.DependencyCheck).
 		AndThen(gen.maybeSkipApplication).
+		AndThen(gen.maybeDefer).
+		AndThen(gen.maybeRefuseDestructive).
 		AndThen(gen.applyNode).
 		AndThen(gen.maybeRunFinalCheck)
 }
@@ -50,10 +58,24 @@ func Pipeline(g *graph.Graph, id string, factory *render.Factory) executor.Pipel
 // GetResult returns Right resultWrapper if the value is a *plan.Result, or Left
 // Error if not
 func (g *pipelineGen) GetTask(ctx context.Context, idi interface{}) (interface{}, error) {
-	if plan, ok := idi.(*plan.Result); ok {
-		return resultWrapper{Plan: plan}, nil
+	planned, ok := idi.(*plan.Result)
+	if !ok {
+		return nil, fmt.Errorf("expected plan.Result but got %T", idi)
+	}
+
+	// lookups deferred while planning are rendered now that the nodes they
+	// refer to have been applied
+	if len(planned.Deferred) > 0 {
+		val, err := plan.Pipeline(ctx, g.Graph, g.ID, g.RenderingPlant).Exec(ctx, planned.Task)
+		if err != nil {
+			return nil, err
+		}
+		if planned, ok = val.(*plan.Result); !ok {
+			return nil, fmt.Errorf("expected plan.Result but got %T", val)
+		}
 	}
-	return nil, fmt.Errorf("expected plan.Result but got %T", idi)
+
+	return resultWrapper{Plan: planned}, nil
 }
 
 // DependencyCheck looks for failing dependency nodes.  If an error is
@@ -78,10 +100,17 @@ func (g *pipelineGen) DependencyCheck(ctx context.Context, taskI interface{}) (i
 			return nil, fmt.Errorf("apply.DependencyCheck: expected %s to have type executor.Status but got type %T", depID, elem)
 		}
 		if err := dep.Error(); err != nil {
+			status := &resource.Status{Level: resource.StatusWillChange}
+			// modules depend on all of their children, so reporting them as
+			// skipped would only repeat the failure
+			if !module.IsModule(result.Plan.Task) {
+				status.AddWarning(resource.WarningSkipped, fmt.Sprintf("not applied because dependency %q failed", depID))
+			}
+
 			errResult := &Result{
 				Ran:    false,
-				Status: &resource.Status{Level: resource.StatusWillChange},
-				Err:    fmt.Errorf("error in dependency %q", depID),
+				Status: status,
+				Err:    errcode.Errorf(errcode.ApplyDependency, "error in dependency %q", depID),
 			}
 			return errResult, nil
 		}
@@ -112,6 +141,107 @@ func (g *pipelineGen) maybeSkipApplication(ctx context.Context, resultI interfac
 	return asPlan, nil
 }
 
+// maybeDefer passes through a *Result, and returns an unapplied result instead
+// of a resultWrapper when the node has a change window that is closed. The
+// planned changes are kept in the result so they are reported as deferred.
+func (g *pipelineGen) maybeDefer(ctx context.Context, val interface{}) (interface{}, error) {
+	if asResult, ok := val.(*Result); ok {
+		return asResult, nil
+	}
+	twrapper, ok := val.(resultWrapper)
+	if !ok {
+		return nil, fmt.Errorf("expected *Result or resultWrapper but got type %T", val)
+	}
+
+	window := g.changeWindow()
+	now := time.Now()
+	if window == nil || window.Contains(now) {
+		return twrapper, nil
+	}
+
+	message := fmt.Sprintf("not applied because change window %s is closed", window)
+	if next, ok := window.Next(now); ok {
+		message += fmt.Sprintf(", it opens next at %s", next.Format("2006-01-02 15:04 MST"))
+	}
+
+	planned := twrapper.Plan.Status
+	status := &resource.Status{
+		Level:       planned.StatusCode(),
+		Differences: planned.Diffs(),
+		Output:      planned.Messages(),
+	}
+	status.AddWarning(resource.WarningDeferred, message)
+
+	return &Result{
+		Ran:    false,
+		Status: status,
+		Task:   twrapper.Plan.Task,
+		Plan:   twrapper.Plan,
+	}, nil
+}
+
+// changeWindow returns the window the node's changes are limited to, if any
+func (g *pipelineGen) changeWindow() *changewindow.Window {
+	meta, ok := g.Graph.Get(g.ID)
+	if !ok {
+		return nil
+	}
+	raw, _ := meta.LookupMetadata(node.MetaChangeWindow)
+	window, _ := raw.(*changewindow.Window)
+	return window
+}
+
+// maybeRefuseDestructive passes through a *Result, and returns a failed result
+// instead of a resultWrapper whose planned changes are destructive, unless
+// they were allowed for the run or on the node
+func (g *pipelineGen) maybeRefuseDestructive(ctx context.Context, val interface{}) (interface{}, error) {
+	if asResult, ok := val.(*Result); ok {
+		return asResult, nil
+	}
+	twrapper, ok := val.(resultWrapper)
+	if !ok {
+		return nil, fmt.Errorf("expected *Result or resultWrapper but got type %T", val)
+	}
+
+	reasons := twrapper.Plan.Destructive()
+	if len(reasons) == 0 || destructiveAllowed(ctx) || g.allowsDestructive() {
+		return twrapper, nil
+	}
+
+	return &Result{
+		Ran:    false,
+		Status: twrapper.Plan.Status,
+		Task:   twrapper.Plan.Task,
+		Plan:   twrapper.Plan,
+		Err: errcode.Errorf(
+			errcode.ApplyDestructive,
+			"refusing destructive changes (%s), use --allow-destructive or set allow_destructive = true",
+			strings.Join(reasons, "; "),
+		),
+	}, nil
+}
+
+// allowsDestructive reports whether the node was configured with
+// `allow_destructive = true`
+func (g *pipelineGen) allowsDestructive() bool {
+	meta, ok := g.Graph.Get(g.ID)
+	if !ok {
+		return false
+	}
+	raw, _ := meta.LookupMetadata(node.MetaAllowDestructive)
+	allowed, _ := raw.(bool)
+	return allowed
+}
+
+// operation describes applying the node to hooks
+func (g *pipelineGen) operation(task resource.Task) *resource.Operation {
+	op := &resource.Operation{Kind: resource.OperationApply, ID: g.ID, Task: task}
+	if meta, ok := g.Graph.Get(g.ID); ok {
+		op.Metadata = meta.Metadata()
+	}
+	return op
+}
+
 // applyNode runs apply on the node, it takes an Either *apply.Result
 // *plan.Result and, if the input value is Left, returns it as a Right value,
 // otherwise it attempts to run apply on the *plan.Result.Task and returns an
@@ -125,7 +255,21 @@ func (g *pipelineGen) applyNode(ctx context.Context, val interface{}) (interface
 		return nil, fmt.Errorf("apply expected a resultWrappert but got %T", val)
 	}
 
-	status, err := twrapper.Plan.Task.Apply(ctx)
+	// the run may have been cancelled between planning and applying this node
+	if ctx.Err() != nil {
+		return interrupted(twrapper.Plan.Task, twrapper.Plan), nil
+	}
+
+	// wait for tasks claiming the same system objects to finish first
+	release, err := resource.AcquireClaims(ctx, twrapper.Plan.Task)
+	if err != nil {
+		return interrupted(twrapper.Plan.Task, twrapper.Plan), nil
+	}
+
+	status, err := resource.RunHooks(ctx, g.operation(twrapper.Plan.Task), func(ctx context.Context) (resource.TaskStatus, error) {
+		return resource.SafeApply(ctx, twrapper.Plan.Task)
+	})
+	release()
 
 	if status == nil {
 		status = &resource.Status{}
@@ -142,24 +286,40 @@ func (g *pipelineGen) applyNode(ctx context.Context, val interface{}) (interface
 		inner.SetError(err)
 	}
 
-	return &Result{
+	// statuses which can't hold an error would otherwise drop it
+	applyErr := status.Error()
+	if applyErr == nil {
+		applyErr = err
+	}
+
+	result := &Result{
 		Ran:    true,
 		Status: status,
 		Task:   twrapper.Plan.Task,
 		Plan:   twrapper.Plan,
-		Err:    status.Error(),
-	}, nil
+		Err:    errcode.Wrap(errcode.ApplyFailed, applyErr),
+		failed: applyErr != nil,
+	}
+	if ctx.Err() != nil {
+		result.Err = errcode.Wrap(errcode.ApplyInterrupted, applyErr)
+	}
+	if reporter, ok := status.(resource.UsageReporter); ok {
+		result.usage = reporter.Usage()
+	}
+
+	return result, nil
 }
 
 // maybeRunFinalCheck :: *Result -> Either error *Result; looks to see if the
 // current result ran, and if so it re-runs plan and sets PostCheck to the
-// resulting status.
+// resulting status. The status of applying is kept, so the result has both
+// the changes that were applied and any that remain.
 func (g *pipelineGen) maybeRunFinalCheck(ctx context.Context, resultI interface{}) (interface{}, error) {
 	result, ok := resultI.(*Result)
 	if !ok {
 		return nil, fmt.Errorf("expected *Result but got %T", resultI)
 	}
-	if !result.Ran {
+	if !result.Ran || ctx.Err() != nil {
 		return result, nil
 	}
 	task := result.Plan.Task
@@ -173,11 +333,13 @@ func (g *pipelineGen) maybeRunFinalCheck(ctx context.Context, resultI interface{
 	}
 	result.PostCheck = planned.Status
 	if planned.HasChanges() {
-		result.Status = planned.Status
 		if result.Err != nil {
-			result.Err = errors.Wrap(result.Err, fmt.Sprintf("%s still has changes after apply", g.ID))
+			result.Err = &errcode.Error{
+				Code: errcode.ApplyStillChanges,
+				Err:  errors.Wrap(result.Err, fmt.Sprintf("%s still has changes after apply", g.ID)),
+			}
 		} else {
-			result.Err = fmt.Errorf("%s still has changes after apply", g.ID)
+			result.Err = errcode.Errorf(errcode.ApplyStillChanges, "%s still has changes after apply", g.ID)
 		}
 	}
 	return result, nil
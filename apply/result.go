@@ -25,9 +25,20 @@ type Result struct {
 	Status resource.TaskStatus
 	Err    error
 
-	Ran       bool
-	Plan      *plan.Result
+	Ran  bool
+	Plan *plan.Result
+
+	// PostCheck is the status of checking the node again after it was applied
 	PostCheck resource.TaskStatus
+
+	// failed is set if applying the node returned an error
+	failed bool
+
+	// rollbacks describe how the transaction the node failed in was rolled
+	// back
+	rollbacks []string
+
+	usage *resource.Usage
 }
 
 // Messages returns any result status messages supplied by the task
@@ -38,6 +49,9 @@ func (r *Result) Messages() []string {
 	return nil
 }
 
+// Rollbacks describes how the transaction the node failed in was rolled back
+func (r *Result) Rollbacks() []string { return r.rollbacks }
+
 // Changes returns the fields that changed
 func (r *Result) Changes() map[string]resource.Diff {
 	if r.Status != nil {
@@ -64,6 +78,31 @@ func (r *Result) Warning() string {
 	return ""
 }
 
+// Warnings returns all categorized warnings assigned to this Result
+func (r *Result) Warnings() []resource.Warning {
+	return resource.StatusWarnings(r.Status)
+}
+
+// Destructive returns why the planned changes are destructive, if they are
+func (r *Result) Destructive() []string {
+	if r.Plan != nil {
+		return r.Plan.Destructive()
+	}
+	return nil
+}
+
+// Impact returns how disruptive the planned changes were
+func (r *Result) Impact() resource.Impact {
+	if r.Plan != nil {
+		return r.Plan.Impact()
+	}
+	return resource.ImpactNone
+}
+
+// Usage returns the resources consumed while applying, if the task reported
+// them
+func (r *Result) Usage() *resource.Usage { return r.usage }
+
 // GetStatus returns the current task status
 func (r *Result) GetStatus() resource.TaskStatus { return r.Status }
 
@@ -74,3 +113,36 @@ func (r *Result) GetTask() (resource.Task, bool) {
 	}
 	return nil, false
 }
+
+// Outcome reports whether the node changed as planned or still had changes
+// after it was applied
+func (r *Result) Outcome() resource.Outcome {
+	switch {
+	case !r.Ran:
+		return resource.OutcomeNone
+	case r.failed:
+		return resource.OutcomeFailed
+	case r.PostCheck != nil && r.PostCheck.HasChanges():
+		return resource.OutcomeDrifted
+	case r.Err != nil:
+		return resource.OutcomeFailed
+	default:
+		return resource.OutcomeChanged
+	}
+}
+
+// RemainingChanges returns the changes found when the node was checked after
+// it was applied
+func (r *Result) RemainingChanges() map[string]resource.Diff {
+	if r.PostCheck == nil || !r.PostCheck.HasChanges() {
+		return nil
+	}
+
+	remaining := map[string]resource.Diff{}
+	for key, diff := range r.PostCheck.Diffs() {
+		if diff.Changes() {
+			remaining[key] = diff
+		}
+	}
+	return remaining
+}
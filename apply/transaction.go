@@ -0,0 +1,134 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"fmt"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/resource"
+	"golang.org/x/net/context"
+)
+
+// transactions tracks the members of each transaction applied so far in a
+// run, so they can be rolled back when a later member fails
+type transactions struct {
+	lock sync.Mutex
+
+	// applied holds the members of each transaction that made changes, in
+	// the order they were applied
+	applied map[string][]appliedMember
+
+	// failed transactions have already been rolled back
+	failed map[string]bool
+}
+
+type appliedMember struct {
+	id   string
+	task resource.Task
+}
+
+func newTransactions() *transactions {
+	return &transactions{
+		applied: make(map[string][]appliedMember),
+		failed:  make(map[string]bool),
+	}
+}
+
+// record notes how a node was applied. When the node is the first member of
+// its transaction to fail, the members applied before it are rolled back in
+// reverse order and the result records what happened to them. Members that
+// are reapplied instead, like restarts, run last and in their original order,
+// so they see what the rollback restored.
+func (t *transactions) record(ctx context.Context, meta *node.Node, result *Result) {
+	raw, ok := meta.LookupMetadata(node.MetaTransaction)
+	if !ok {
+		return
+	}
+	name, _ := raw.(string)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if result.Error() == nil {
+		if result.Ran && !t.failed[name] {
+			t.applied[name] = append(t.applied[name], appliedMember{id: meta.ID, task: result.Task})
+		}
+		return
+	}
+
+	if t.failed[name] {
+		return
+	}
+	t.failed[name] = true
+
+	logger := logging.GetLogger(ctx).WithField("function", "transactions.record").WithField("transaction", name)
+
+	members := t.applied[name]
+	var reapply []appliedMember
+	for i := len(members) - 1; i >= 0; i-- {
+		if task, ok := resource.ResolveTask(members[i].task); ok {
+			if _, ok := task.(resource.Reapplier); ok {
+				reapply = append([]appliedMember{members[i]}, reapply...)
+				continue
+			}
+		}
+		result.rollbacks = append(result.rollbacks, rollback(logger, members[i]))
+	}
+	for _, member := range reapply {
+		result.rollbacks = append(result.rollbacks, reapplied(logger, member))
+	}
+	delete(t.applied, name)
+}
+
+// rollback undoes a member of a failed transaction, describing the outcome
+func rollback(logger *log.Entry, member appliedMember) string {
+	task, ok := resource.ResolveTask(member.task)
+	if !ok {
+		return fmt.Sprintf("%s can't be rolled back", member.id)
+	}
+
+	rollbacker, ok := task.(resource.Rollbacker)
+	if !ok {
+		return fmt.Sprintf("%s can't be rolled back", member.id)
+	}
+
+	// the run's context may already be cancelled, but the transaction should
+	// still be undone
+	if err := rollbacker.Rollback(context.Background()); err != nil {
+		logger.WithError(err).WithField("id", member.id).Warn("rollback failed")
+		return fmt.Sprintf("could not roll back %s: %s", member.id, err)
+	}
+
+	logger.WithField("id", member.id).Info("rolled back")
+	return fmt.Sprintf("rolled back %s", member.id)
+}
+
+// reapplied applies a member of a failed transaction again after the others
+// were rolled back, describing the outcome
+func reapplied(logger *log.Entry, member appliedMember) string {
+	task, _ := resource.ResolveTask(member.task)
+
+	if err := task.(resource.Reapplier).Reapply(context.Background()); err != nil {
+		logger.WithError(err).WithField("id", member.id).Warn("reapplying failed")
+		return fmt.Sprintf("could not reapply %s: %s", member.id, err)
+	}
+
+	logger.WithField("id", member.id).Info("reapplied")
+	return fmt.Sprintf("reapplied %s", member.id)
+}
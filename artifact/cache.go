@@ -0,0 +1,271 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package artifact caches files downloaded over HTTP, so a fleet of agents
+// can fetch artifacts through one server instead of each going to the
+// internet. Once an artifact is cached it is served as-is, so every agent gets
+// the same bytes even if the upstream file changes, until the cache entry
+// expires or is removed.
+package artifact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// Entry describes a cached artifact
+type Entry struct {
+	URL         string    `json:"url"`
+	SHA256      string    `json:"sha256"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"contentType,omitempty"`
+	Fetched     time.Time `json:"fetched"`
+}
+
+// Cache keeps artifacts in a directory, keyed by their URL
+type Cache struct {
+	dir string
+
+	// ttl is how long entries are served before they are fetched again. They
+	// never expire when it is zero.
+	ttl time.Duration
+
+	// hosts are the upstream hosts artifacts may be fetched from
+	hosts []string
+
+	client *http.Client
+
+	// locks keep an artifact from being downloaded more than once at a time
+	locksMu sync.Mutex
+	locks   map[string]*sync.Mutex
+}
+
+// New creates a cache in dir. Entries are fetched again once they are older
+// than ttl, or never if ttl is zero. Artifacts are only fetched from hosts,
+// which are host names, optionally with a port, or patterns like
+// "*.example.com" that match any subdomain.
+func New(dir string, ttl time.Duration, hosts []string) (*Cache, error) {
+	if ttl < 0 {
+		return nil, errors.New("artifact cache ttl cannot be negative")
+	}
+	if len(hosts) == 0 {
+		return nil, errors.New("artifact cache needs at least one upstream host")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrap(err, "could not create artifact cache")
+	}
+
+	c := &Cache{
+		dir:   dir,
+		ttl:   ttl,
+		hosts: hosts,
+		locks: make(map[string]*sync.Mutex),
+	}
+	c.client = &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			if !c.Allows(req.URL) {
+				return fmt.Errorf("redirected to %s, which is not an allowed upstream host", req.URL.Host)
+			}
+			return nil
+		},
+	}
+	return c, nil
+}
+
+// Allows reports whether artifacts may be fetched from u
+func (c *Cache) Allows(u *neturl.URL) bool {
+	host := strings.ToLower(u.Host)
+	name := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		name = h
+	}
+
+	for _, pattern := range c.hosts {
+		pattern = strings.ToLower(pattern)
+		switch {
+		case pattern == host || pattern == name:
+			return true
+		case strings.HasPrefix(pattern, "*.") && strings.HasSuffix(name, pattern[1:]):
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the entry for url, downloading it first if it isn't cached or
+// has expired. An expired entry is still served if it can't be downloaded
+// again.
+func (c *Cache) Get(ctx context.Context, url string) (*Entry, error) {
+	key := cacheKey(url)
+	lock := c.lock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	cached, err := c.read(key)
+	if err == nil && (c.ttl == 0 || time.Since(cached.Fetched) < c.ttl) {
+		return cached, nil
+	}
+
+	fetched, fetchErr := c.fetch(ctx, key, url)
+	if fetchErr != nil {
+		if cached != nil {
+			return cached, nil
+		}
+		return nil, fetchErr
+	}
+	return fetched, nil
+}
+
+// Open opens the content of a cached entry
+func (c *Cache) Open(entry *Entry) (*os.File, error) {
+	return os.Open(c.dataPath(cacheKey(entry.URL)))
+}
+
+// Entries lists what is in the cache, sorted by URL
+func (c *Cache) Entries() ([]*Entry, error) {
+	matches, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	for _, match := range matches {
+		entry, err := c.read(strings.TrimSuffix(filepath.Base(match), ".json"))
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Sort(entriesByURL(entries))
+	return entries, nil
+}
+
+// fetch downloads url into the cache. The content is written to a temporary
+// file and moved into place, so a failed download never replaces a good entry.
+func (c *Cache) fetch(ctx context.Context, key, url string) (*Entry, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ctxhttp.Do(ctx, c.client, req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not download %s", url)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not download %s: %s", url, resp.Status)
+	}
+
+	tmp, err := ioutil.TempFile(c.dir, ".download")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	hsh := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hsh), resp.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not download %s", url)
+	}
+
+	entry := &Entry{
+		URL:         url,
+		SHA256:      hex.EncodeToString(hsh.Sum(nil)),
+		Size:        size,
+		ContentType: resp.Header.Get("Content-Type"),
+		Fetched:     time.Now().UTC(),
+	}
+
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), c.dataPath(key)); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(c.metaPath(key), meta, 0644); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (c *Cache) read(key string) (*Entry, error) {
+	blob, err := ioutil.ReadFile(c.metaPath(key))
+	if err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(blob, &entry); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(c.dataPath(key)); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (c *Cache) lock(key string) *sync.Mutex {
+	c.locksMu.Lock()
+	defer c.locksMu.Unlock()
+
+	lock, ok := c.locks[key]
+	if !ok {
+		lock = new(sync.Mutex)
+		c.locks[key] = lock
+	}
+	return lock
+}
+
+func (c *Cache) dataPath(key string) string { return filepath.Join(c.dir, key) }
+func (c *Cache) metaPath(key string) string { return filepath.Join(c.dir, key+".json") }
+
+// cacheKey names the files of the entry for url
+func cacheKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+type entriesByURL []*Entry
+
+func (e entriesByURL) Len() int           { return len(e) }
+func (e entriesByURL) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+func (e entriesByURL) Less(i, j int) bool { return e[i].URL < e[j].URL }
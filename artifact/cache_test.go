@@ -0,0 +1,332 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifact_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/artifact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// upstream serves body, counting the requests it gets
+type upstream struct {
+	body     atomic.Value
+	requests int32
+	fail     int32
+}
+
+func (u *upstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&u.requests, 1)
+	if atomic.LoadInt32(&u.fail) != 0 {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprint(w, u.body.Load().(string))
+}
+
+func (u *upstream) count() int { return int(atomic.LoadInt32(&u.requests)) }
+
+func newUpstream(body string) (*upstream, *httptest.Server) {
+	u := new(upstream)
+	u.body.Store(body)
+	return u, httptest.NewServer(u)
+}
+
+func newCache(t *testing.T, ttl time.Duration) (*artifact.Cache, func()) {
+	dir, err := ioutil.TempDir("", "converge-artifact")
+	require.NoError(t, err)
+
+	cache, err := artifact.New(dir, ttl, []string{"127.0.0.1"})
+	require.NoError(t, err)
+
+	return cache, func() { os.RemoveAll(dir) }
+}
+
+func sum(content string) string {
+	s := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(s[:])
+}
+
+func TestCacheGet(t *testing.T) {
+	t.Parallel()
+
+	t.Run("downloads once", func(t *testing.T) {
+		up, srv := newUpstream("hello")
+		defer srv.Close()
+		cache, cleanup := newCache(t, 0)
+		defer cleanup()
+
+		for i := 0; i < 3; i++ {
+			entry, err := cache.Get(context.Background(), srv.URL+"/hello.txt")
+			require.NoError(t, err)
+			assert.Equal(t, sum("hello"), entry.SHA256)
+			assert.Equal(t, int64(5), entry.Size)
+			assert.Equal(t, "text/plain", entry.ContentType)
+		}
+		assert.Equal(t, 1, up.count())
+	})
+
+	t.Run("serves the same bytes until expired", func(t *testing.T) {
+		up, srv := newUpstream("one")
+		defer srv.Close()
+		cache, cleanup := newCache(t, 50*time.Millisecond)
+		defer cleanup()
+
+		entry, err := cache.Get(context.Background(), srv.URL+"/file")
+		require.NoError(t, err)
+		assert.Equal(t, sum("one"), entry.SHA256)
+
+		up.body.Store("two")
+		entry, err = cache.Get(context.Background(), srv.URL+"/file")
+		require.NoError(t, err)
+		assert.Equal(t, sum("one"), entry.SHA256)
+
+		time.Sleep(60 * time.Millisecond)
+		entry, err = cache.Get(context.Background(), srv.URL+"/file")
+		require.NoError(t, err)
+		assert.Equal(t, sum("two"), entry.SHA256)
+		assert.Equal(t, 2, up.count())
+	})
+
+	t.Run("serves expired entries when upstream fails", func(t *testing.T) {
+		up, srv := newUpstream("one")
+		defer srv.Close()
+		cache, cleanup := newCache(t, time.Nanosecond)
+		defer cleanup()
+
+		_, err := cache.Get(context.Background(), srv.URL+"/file")
+		require.NoError(t, err)
+
+		atomic.StoreInt32(&up.fail, 1)
+		entry, err := cache.Get(context.Background(), srv.URL+"/file")
+		require.NoError(t, err)
+		assert.Equal(t, sum("one"), entry.SHA256)
+	})
+
+	t.Run("upstream error", func(t *testing.T) {
+		up, srv := newUpstream("one")
+		defer srv.Close()
+		atomic.StoreInt32(&up.fail, 1)
+		cache, cleanup := newCache(t, 0)
+		defer cleanup()
+
+		_, err := cache.Get(context.Background(), srv.URL+"/file")
+		assert.EqualError(t, err, fmt.Sprintf("could not download %s/file: 503 Service Unavailable", srv.URL))
+
+		entries, err := cache.Entries()
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
+
+func TestCacheHandler(t *testing.T) {
+	t.Parallel()
+
+	up, srv := newUpstream("content")
+	defer srv.Close()
+	cache, cleanup := newCache(t, 0)
+	defer cleanup()
+
+	notFound := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "next", http.StatusTeapot)
+	})
+	server := httptest.NewServer(cache.Handler(notFound))
+	defer server.Close()
+
+	source := srv.URL + "/dir/app.tar.gz"
+
+	get := func(t *testing.T, u string) (*http.Response, string) {
+		resp, err := http.Get(u)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	t.Run("artifact", func(t *testing.T) {
+		proxied, err := artifact.ProxyURL(server.URL, "", source)
+		require.NoError(t, err)
+
+		resp, body := get(t, proxied)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "content", body)
+		assert.Equal(t, sum("content"), resp.Header.Get(artifact.SHA256Header))
+		assert.Equal(t, `"`+sum("content")+`"`, resp.Header.Get("ETag"))
+
+		resp, body = get(t, proxied)
+		assert.Equal(t, "content", body)
+		assert.Equal(t, 1, up.count())
+	})
+
+	t.Run("pinned", func(t *testing.T) {
+		proxied, err := artifact.ProxyURL(server.URL+"?sha256="+sum("content"), "", source)
+		require.NoError(t, err)
+
+		resp, body := get(t, proxied)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "content", body)
+	})
+
+	t.Run("pin mismatch", func(t *testing.T) {
+		proxied, err := artifact.ProxyURL(server.URL+"?sha256=abc", "", source)
+		require.NoError(t, err)
+
+		resp, _ := get(t, proxied)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("conditional", func(t *testing.T) {
+		proxied, err := artifact.ProxyURL(server.URL, "", source)
+		require.NoError(t, err)
+
+		req, err := http.NewRequest("GET", proxied, nil)
+		require.NoError(t, err)
+		req.Header.Set("If-None-Match", `"`+sum("content")+`"`)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	})
+
+	t.Run("forward proxy", func(t *testing.T) {
+		proxy, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxy)}}
+
+		resp, err := client.Get(source)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "content", string(body))
+		assert.Equal(t, 1, up.count())
+	})
+
+	t.Run("list", func(t *testing.T) {
+		resp, body := get(t, server.URL+artifact.Prefix)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var entries []*artifact.Entry
+		require.NoError(t, json.Unmarshal([]byte(body), &entries))
+		require.Len(t, entries, 1)
+		assert.Equal(t, source, entries[0].URL)
+	})
+
+	t.Run("bad source", func(t *testing.T) {
+		resp, _ := get(t, server.URL+artifact.Prefix+"x?url="+url.QueryEscape("file:///etc/passwd"))
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("other hosts", func(t *testing.T) {
+		resp, _ := get(t, server.URL+artifact.Prefix+"x?url="+url.QueryEscape("http://example.com/x"))
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("other requests", func(t *testing.T) {
+		resp, _ := get(t, server.URL+"/api/v1/info")
+		assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+	})
+}
+
+func TestProxyURL(t *testing.T) {
+	t.Parallel()
+
+	t.Run("http", func(t *testing.T) {
+		proxied, err := artifact.ProxyURL("https://cache:4774?sha256=abc", "token", "http://example.com/a/consul.zip?v=1")
+		require.NoError(t, err)
+		assert.Equal(t, "https://cache:4774/artifacts/consul.zip?jwt=token&sha256=abc&url=http%3A%2F%2Fexample.com%2Fa%2Fconsul.zip%3Fv%3D1", proxied)
+	})
+
+	t.Run("no path", func(t *testing.T) {
+		proxied, err := artifact.ProxyURL("http://cache:4774/", "", "https://example.com")
+		require.NoError(t, err)
+		assert.Equal(t, "http://cache:4774/artifacts/index?url=https%3A%2F%2Fexample.com", proxied)
+	})
+
+	t.Run("other schemes", func(t *testing.T) {
+		proxied, err := artifact.ProxyURL("http://cache:4774", "token", "ftp://example.com/file")
+		require.NoError(t, err)
+		assert.Equal(t, "ftp://example.com/file", proxied)
+	})
+}
+
+func TestProxyContext(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "", artifact.ProxyFrom(context.Background()))
+	assert.Equal(t, "http://cache:4774", artifact.ProxyFrom(artifact.WithProxy(context.Background(), "http://cache:4774")))
+
+	assert.Nil(t, artifact.ProxyTokenFrom(context.Background()))
+	sign := artifact.ProxyTokenFrom(artifact.WithProxyToken(context.Background(), func() (string, error) { return "token", nil }))
+	require.NotNil(t, sign)
+	token, err := sign()
+	require.NoError(t, err)
+	assert.Equal(t, "token", token)
+}
+
+func TestCacheAllows(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-artifact")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cache, err := artifact.New(dir, 0, []string{"releases.example.com", "*.mirror.example.com", "localhost:8080"})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://releases.example.com/a.zip", true},
+		{"https://RELEASES.example.com:8443/a.zip", true},
+		{"http://eu.mirror.example.com/a.zip", true},
+		{"http://mirror.example.com/a.zip", false},
+		{"http://evilmirror.example.com/a.zip", false},
+		{"http://localhost:8080/a.zip", true},
+		{"http://localhost:9090/a.zip", false},
+		{"http://example.com/a.zip", false},
+	} {
+		u, err := url.Parse(tc.url)
+		require.NoError(t, err)
+		assert.Equal(t, tc.allowed, cache.Allows(u), tc.url)
+	}
+}
+
+func TestNewWithoutHosts(t *testing.T) {
+	t.Parallel()
+
+	_, err := artifact.New(os.TempDir(), 0, nil)
+	assert.EqualError(t, err, "artifact cache needs at least one upstream host")
+}
@@ -0,0 +1,126 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifact
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+const (
+	// Prefix is where the cache serves artifacts. Artifacts are requested as
+	// Prefix + name + "?url=" + the upstream URL. The name is only there so
+	// the path ends like the upstream file, which tools use to tell what kind
+	// of file it is.
+	Prefix = "/artifacts/"
+
+	// SHA256Header carries the checksum of a served artifact
+	SHA256Header = "X-Converge-Artifact-Sha256"
+)
+
+// Handler serves artifacts from the cache, passing other requests to next.
+// Besides requests under Prefix, plain HTTP proxy requests are served from
+// the cache too, so package managers can be pointed at it as their HTTP
+// proxy. HTTPS can't be cached that way, since proxies only see an encrypted
+// tunnel. Only artifacts from the allowed upstream hosts are proxied. A
+// request for Prefix alone lists the cached artifacts.
+//
+// A "sha256" query parameter pins an artifact: it is only served if its
+// checksum matches.
+func (c *Cache) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var source, pin string
+		switch {
+		case r.URL.IsAbs():
+			source = r.URL.String()
+		case r.URL.Path == Prefix || r.URL.Path+"/" == Prefix:
+			c.serveList(w, r)
+			return
+		case strings.HasPrefix(r.URL.Path, Prefix):
+			source = r.URL.Query().Get("url")
+			pin = r.URL.Query().Get("sha256")
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if r.Method != "GET" && r.Method != "HEAD" {
+			http.Error(w, "artifacts can only be fetched with GET or HEAD", http.StatusMethodNotAllowed)
+			return
+		}
+
+		upstream, err := url.Parse(source)
+		if err != nil || (upstream.Scheme != "http" && upstream.Scheme != "https") || upstream.Host == "" {
+			http.Error(w, fmt.Sprintf("%q is not an http or https URL", source), http.StatusBadRequest)
+			return
+		}
+		if !c.Allows(upstream) {
+			http.Error(w, fmt.Sprintf("%s is not an allowed upstream host", upstream.Host), http.StatusForbidden)
+			return
+		}
+
+		logger := log.WithField("component", "artifact").WithField("url", source)
+
+		// the download isn't tied to the request, so other agents waiting on
+		// the same artifact still get it if this one goes away
+		entry, err := c.Get(context.Background(), source)
+		if err != nil {
+			logger.WithError(err).Warn("could not cache artifact")
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		if pin != "" && !strings.EqualFold(pin, entry.SHA256) {
+			http.Error(w, fmt.Sprintf("cached artifact has sha256 %s, not %s", entry.SHA256, pin), http.StatusConflict)
+			return
+		}
+
+		content, err := c.Open(entry)
+		if err != nil {
+			logger.WithError(err).Error("could not open cached artifact")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer content.Close()
+
+		w.Header().Set("ETag", fmt.Sprintf(`"%s"`, entry.SHA256))
+		w.Header().Set(SHA256Header, entry.SHA256)
+		if entry.ContentType != "" {
+			w.Header().Set("Content-Type", entry.ContentType)
+		}
+		http.ServeContent(w, r, "", entry.Fetched, content)
+	})
+}
+
+// serveList lists the cached artifacts as JSON
+func (c *Cache) serveList(w http.ResponseWriter, r *http.Request) {
+	entries, err := c.Entries()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []*Entry{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
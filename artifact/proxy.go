@@ -0,0 +1,89 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifact
+
+import (
+	"net/url"
+	"path"
+
+	"golang.org/x/net/context"
+)
+
+// TokenParam is the query parameter a token for the artifact cache is sent
+// in, since downloads can't set headers
+const TokenParam = "jwt"
+
+type proxyKey struct{}
+
+type proxyTokenKey struct{}
+
+// WithProxy sets the artifact cache that HTTP downloads go through. Downloads
+// go straight to their source when it is empty.
+func WithProxy(ctx context.Context, proxy string) context.Context {
+	return context.WithValue(ctx, proxyKey{}, proxy)
+}
+
+// ProxyFrom gets the artifact cache that HTTP downloads go through, if any
+func ProxyFrom(ctx context.Context) string {
+	proxy, _ := ctx.Value(proxyKey{}).(string)
+	return proxy
+}
+
+// WithProxyToken sets how tokens for the artifact cache are signed, for a
+// cache that requires them. A token is signed for each download, since they
+// are only valid for a short time.
+func WithProxyToken(ctx context.Context, sign func() (string, error)) context.Context {
+	return context.WithValue(ctx, proxyTokenKey{}, sign)
+}
+
+// ProxyTokenFrom gets how tokens for the artifact cache are signed, if they
+// are needed
+func ProxyTokenFrom(ctx context.Context) func() (string, error) {
+	sign, _ := ctx.Value(proxyTokenKey{}).(func() (string, error))
+	return sign
+}
+
+// ProxyURL is the URL source is fetched from through the cache at proxy,
+// carrying token if it is set. Sources that aren't http or https are returned
+// as they are. Query parameters on proxy are kept.
+func ProxyURL(proxy, token, source string) (string, error) {
+	upstream, err := url.Parse(source)
+	if err != nil {
+		return "", err
+	}
+	if upstream.Scheme != "http" && upstream.Scheme != "https" {
+		return source, nil
+	}
+
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return "", err
+	}
+
+	name := path.Base(upstream.Path)
+	if name == "/" || name == "." {
+		name = "index"
+	}
+	u.Path = path.Join(u.Path, Prefix, name)
+
+	values := u.Query()
+	values.Set("url", source)
+	if token != "" {
+		values.Set(TokenParam, token)
+	}
+	u.RawQuery = values.Encode()
+
+	return u.String(), nil
+}
@@ -0,0 +1,118 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package baseline records the state of a module's resources as observed by
+// checking them, so hosts can later be compared against it without applying
+// anything.
+package baseline
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/prettyprinters/human"
+	"github.com/pkg/errors"
+)
+
+// Baseline is the observed state of the resources in a module
+type Baseline struct {
+	Location string    `json:"location"`
+	Host     string    `json:"host"`
+	Created  time.Time `json:"created"`
+
+	// Nodes are keyed by ID
+	Nodes map[string]*Node `json:"nodes"`
+}
+
+// Node is the observed state of a single node
+type Node struct {
+	HasChanges bool   `json:"hasChanges,omitempty"`
+	Error      string `json:"error,omitempty"`
+
+	// Observed holds the value of each field the node reported, as it was
+	// before any change
+	Observed map[string]string `json:"observed,omitempty"`
+}
+
+// New starts an empty baseline for the module at location, observed on this
+// host
+func New(location string) *Baseline {
+	host, _ := os.Hostname()
+
+	return &Baseline{
+		Location: location,
+		Host:     host,
+		Created:  time.Now().UTC(),
+		Nodes:    map[string]*Node{},
+	}
+}
+
+// Add records what checking a node observed. The root is left out, since it
+// doesn't observe anything.
+func (b *Baseline) Add(id string, printable human.Printable) {
+	if graph.IsRoot(id) {
+		return
+	}
+
+	node := &Node{HasChanges: printable.HasChanges()}
+	if err := printable.Error(); err != nil {
+		node.Error = err.Error()
+	}
+
+	for field, diff := range printable.Changes() {
+		if node.Observed == nil {
+			node.Observed = map[string]string{}
+		}
+		node.Observed[field] = diff.Original()
+	}
+
+	b.Nodes[id] = node
+}
+
+// Write serializes the baseline as indented JSON
+func (b *Baseline) Write(w io.Writer) error {
+	blob, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "could not serialize baseline")
+	}
+
+	_, err = w.Write(append(blob, '\n'))
+	return errors.Wrap(err, "could not write baseline")
+}
+
+// Read deserializes a baseline
+func Read(r io.Reader) (*Baseline, error) {
+	b := new(Baseline)
+	if err := json.NewDecoder(r).Decode(b); err != nil {
+		return nil, errors.Wrap(err, "could not read baseline")
+	}
+	if b.Nodes == nil {
+		b.Nodes = map[string]*Node{}
+	}
+	return b, nil
+}
+
+// ReadFile reads the baseline at path
+func ReadFile(path string) (*Baseline, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open baseline")
+	}
+	defer file.Close()
+
+	return Read(file)
+}
@@ -0,0 +1,114 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseline_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/baseline"
+	"github.com/asteris-llc/converge/plan"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaselineRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	changed := resource.NewStatus()
+	changed.AddDifference("mode", "0644", "0600", "")
+	changed.AddDifference("content", "x", "x", "")
+	changed.RaiseLevel(resource.StatusWillChange)
+
+	b := baseline.New("test.hcl")
+	b.Add("root", &plan.Result{Status: resource.NewStatus()})
+	b.Add("root/file.content.a", &plan.Result{Status: changed})
+	b.Add("root/task.b", &plan.Result{Status: resource.NewStatus(), Err: errors.New("exit status 1")})
+
+	assert.Equal(t, map[string]*baseline.Node{
+		"root/file.content.a": {
+			HasChanges: true,
+			Observed:   map[string]string{"mode": "0644", "content": "x"},
+		},
+		"root/task.b": {Error: "exit status 1"},
+	}, b.Nodes)
+
+	var buf bytes.Buffer
+	require.NoError(t, b.Write(&buf))
+
+	read, err := baseline.Read(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, b.Location, read.Location)
+	assert.Equal(t, b.Host, read.Host)
+	assert.True(t, b.Created.Equal(read.Created))
+	assert.Equal(t, b.Nodes, read.Nodes)
+}
+
+func TestReadInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := baseline.Read(strings.NewReader("{"))
+	assert.Error(t, err)
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	want := &baseline.Baseline{Nodes: map[string]*baseline.Node{
+		"root/file.content.a": {Observed: map[string]string{"mode": "0644", "content": "x"}},
+		"root/task.b":         {},
+		"root/task.c":         {HasChanges: true},
+	}}
+
+	t.Run("same", func(t *testing.T) {
+		assert.Empty(t, baseline.Compare(want, want))
+		assert.Equal(t, "No differences from baseline\n", baseline.Report(nil))
+	})
+
+	t.Run("different", func(t *testing.T) {
+		got := &baseline.Baseline{Nodes: map[string]*baseline.Node{
+			"root/file.content.a": {Observed: map[string]string{"mode": "0600", "owner": "root"}},
+			"root/task.c":         {Error: "exit status 1"},
+			"root/task.d":         {},
+		}}
+
+		diffs := baseline.Compare(want, got)
+		assert.Equal(t, []baseline.Difference{
+			{ID: "root/file.content.a", Field: "content", Baseline: "x", Observed: "<absent>"},
+			{ID: "root/file.content.a", Field: "mode", Baseline: "0644", Observed: "0600"},
+			{ID: "root/file.content.a", Field: "owner", Baseline: "<absent>", Observed: "root"},
+			{ID: "root/task.b", Baseline: "present", Observed: "<absent>"},
+			{ID: "root/task.c", Field: "<error>", Baseline: "", Observed: "exit status 1"},
+			{ID: "root/task.c", Field: "<has changes>", Baseline: "true", Observed: "false"},
+			{ID: "root/task.d", Baseline: "<absent>", Observed: "present"},
+		}, diffs)
+
+		assert.Equal(
+			t,
+			"Differences from baseline: 7\n"+
+				"  root/file.content.a content: \"x\" => \"<absent>\"\n"+
+				"  root/file.content.a mode: \"0644\" => \"0600\"\n"+
+				"  root/file.content.a owner: \"<absent>\" => \"root\"\n"+
+				"  root/task.b: \"present\" => \"<absent>\"\n"+
+				"  root/task.c <error>: \"\" => \"exit status 1\"\n"+
+				"  root/task.c <has changes>: \"true\" => \"false\"\n"+
+				"  root/task.d: \"<absent>\" => \"present\"\n",
+			baseline.Report(diffs),
+		)
+	})
+}
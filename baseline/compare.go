@@ -0,0 +1,129 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseline
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// absent stands in for a node or field that one side doesn't have
+const absent = "<absent>"
+
+// Difference is a way a host differs from a baseline. Field is empty when the
+// whole node differs.
+type Difference struct {
+	ID       string
+	Field    string
+	Baseline string
+	Observed string
+}
+
+// String describes the difference
+func (d Difference) String() string {
+	name := d.ID
+	if d.Field != "" {
+		name += " " + d.Field
+	}
+	return fmt.Sprintf("%s: %q => %q", name, d.Baseline, d.Observed)
+}
+
+// Compare finds the differences between a baseline and what was observed on a
+// host, sorted by ID and field
+func Compare(baseline, observed *Baseline) []Difference {
+	var diffs []Difference
+
+	for id, want := range baseline.Nodes {
+		got, ok := observed.Nodes[id]
+		if !ok {
+			diffs = append(diffs, Difference{ID: id, Baseline: "present", Observed: absent})
+			continue
+		}
+		diffs = append(diffs, compareNode(id, want, got)...)
+	}
+
+	for id := range observed.Nodes {
+		if _, ok := baseline.Nodes[id]; !ok {
+			diffs = append(diffs, Difference{ID: id, Baseline: absent, Observed: "present"})
+		}
+	}
+
+	sort.Sort(differencesByID(diffs))
+	return diffs
+}
+
+// compareNode finds the differences between the baseline and observed state
+// of a single node
+func compareNode(id string, want, got *Node) []Difference {
+	var diffs []Difference
+
+	if want.HasChanges != got.HasChanges {
+		diffs = append(diffs, Difference{
+			ID:       id,
+			Field:    "<has changes>",
+			Baseline: strconv.FormatBool(want.HasChanges),
+			Observed: strconv.FormatBool(got.HasChanges),
+		})
+	}
+
+	if want.Error != got.Error {
+		diffs = append(diffs, Difference{ID: id, Field: "<error>", Baseline: want.Error, Observed: got.Error})
+	}
+
+	for field, value := range want.Observed {
+		current, ok := got.Observed[field]
+		if !ok {
+			current = absent
+		}
+		if current != value {
+			diffs = append(diffs, Difference{ID: id, Field: field, Baseline: value, Observed: current})
+		}
+	}
+
+	for field, value := range got.Observed {
+		if _, ok := want.Observed[field]; !ok {
+			diffs = append(diffs, Difference{ID: id, Field: field, Baseline: absent, Observed: value})
+		}
+	}
+
+	return diffs
+}
+
+// Report describes the differences from a baseline, one per line
+func Report(diffs []Difference) string {
+	if len(diffs) == 0 {
+		return "No differences from baseline\n"
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "Differences from baseline: %d\n", len(diffs))
+	for _, diff := range diffs {
+		fmt.Fprintf(&out, "  %s\n", diff)
+	}
+	return out.String()
+}
+
+type differencesByID []Difference
+
+func (d differencesByID) Len() int      { return len(d) }
+func (d differencesByID) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
+func (d differencesByID) Less(i, j int) bool {
+	if d[i].ID != d[j].ID {
+		return d[i].ID < d[j].ID
+	}
+	return d[i].Field < d[j].Field
+}
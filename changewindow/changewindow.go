@@ -0,0 +1,268 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package changewindow limits when nodes may be applied. A window is a
+// cron-like expression of five fields:
+//
+//	minute hour day-of-month month day-of-week
+//
+// A time is inside the window when its minute matches every field, so
+// "* 2-4 * * sat,sun" is open from 02:00 to 04:59 on weekends. Fields take
+// "*", numbers, ranges like "1-5", steps like "*/15" or "0-30/10", and lists
+// of those separated by commas. Months and days of the week can be given by
+// their first three letters, and Sunday is both 0 and 7. As in cron, when
+// both the day of the month and the day of the week are restricted, a day
+// matching either is inside the window. Times are in the host's local time
+// zone.
+//
+// Windows can also be referred to by name, when the names are configured for
+// the run with WithNamed.
+package changewindow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Window is a set of minutes during which changes may be applied
+type Window struct {
+	// Name is the name the window was referred to by, if any
+	Name string
+
+	// Expr is the expression of the window
+	Expr string
+
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+
+	anyDay     bool
+	anyWeekday bool
+}
+
+// field describes one field of an expression
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	monthNames = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+
+	weekdayNames = map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+
+	fields = []field{
+		{name: "minute", min: 0, max: 59},
+		{name: "hour", min: 0, max: 23},
+		{name: "day of month", min: 1, max: 31},
+		{name: "month", min: 1, max: 12, names: monthNames},
+		{name: "day of week", min: 0, max: 7, names: weekdayNames},
+	}
+)
+
+// Parse parses a window expression
+func Parse(expr string) (*Window, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("window %q must have %d fields (minute hour day-of-month month day-of-week), got %d", expr, len(fields), len(parts))
+	}
+
+	var sets [5]uint64
+	for i, part := range parts {
+		set, err := fields[i].parse(part)
+		if err != nil {
+			return nil, fmt.Errorf("window %q: %s", expr, err)
+		}
+		sets[i] = set
+	}
+
+	w := &Window{
+		Expr:       strings.Join(parts, " "),
+		minutes:    sets[0],
+		hours:      sets[1],
+		days:       sets[2],
+		months:     sets[3],
+		weekdays:   sets[4],
+		anyDay:     parts[2] == "*",
+		anyWeekday: parts[4] == "*",
+	}
+
+	// Sunday is both 0 and 7
+	if w.weekdays&(1<<7) != 0 {
+		w.weekdays |= 1
+	}
+
+	return w, nil
+}
+
+// Resolve parses spec as a window expression, or looks it up in named if it's
+// a single word
+func Resolve(spec string, named map[string]string) (*Window, error) {
+	spec = strings.TrimSpace(spec)
+	if len(strings.Fields(spec)) != 1 {
+		return Parse(spec)
+	}
+
+	expr, ok := named[spec]
+	if !ok {
+		return nil, fmt.Errorf("unknown change window %q", spec)
+	}
+
+	w, err := Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("change window %q: %s", spec, err)
+	}
+	w.Name = spec
+	return w, nil
+}
+
+// Contains is true when t is inside the window
+func (w *Window) Contains(t time.Time) bool {
+	if !has(w.minutes, t.Minute()) || !has(w.hours, t.Hour()) || !has(w.months, int(t.Month())) {
+		return false
+	}
+
+	day, weekday := has(w.days, t.Day()), has(w.weekdays, int(t.Weekday()))
+	switch {
+	case w.anyDay && w.anyWeekday:
+		return true
+	case w.anyDay:
+		return weekday
+	case w.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+// Next returns the first minute at or after t that is inside the window. It
+// looks up to a year ahead, and returns false if the window doesn't open in
+// that time.
+func (w *Window) Next(t time.Time) (time.Time, bool) {
+	t = t.Truncate(time.Minute)
+	end := t.AddDate(1, 0, 1)
+
+	for t.Before(end) {
+		if w.Contains(t) {
+			return t, true
+		}
+
+		// skip whole hours that can't match
+		if !has(w.hours, t.Hour()) {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		t = t.Add(time.Minute)
+	}
+
+	return time.Time{}, false
+}
+
+// String describes the window, with its name if it has one
+func (w *Window) String() string {
+	if w.Name != "" {
+		return fmt.Sprintf("%s (%s)", w.Name, w.Expr)
+	}
+	return w.Expr
+}
+
+// parse parses a field into a set of values
+func (f field) parse(in string) (uint64, error) {
+	var set uint64
+
+	for _, item := range strings.Split(in, ",") {
+		rng, step := item, 1
+		if idx := strings.Index(item, "/"); idx >= 0 {
+			var err error
+			rng = item[:idx]
+			step, err = strconv.Atoi(item[idx+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("%s %q has an invalid step", f.name, item)
+			}
+		}
+
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if lo, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = f.value(bounds[1]); err != nil {
+					return 0, err
+				}
+			} else if step != 1 {
+				// "5/15" means from 5 to the end, every 15
+				hi = f.max
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%s %q starts after it ends", f.name, item)
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+
+	return set, nil
+}
+
+// value parses a single value of a field
+func (f field) value(in string) (int, error) {
+	if v, ok := f.names[strings.ToLower(in)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(in)
+	if err != nil {
+		return 0, fmt.Errorf("%s %q is not a number", f.name, in)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s %d is out of range %d-%d", f.name, v, f.min, f.max)
+	}
+	return v, nil
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
+
+type namedKey struct{}
+
+// WithNamed returns a context in which windows can be referred to by the
+// names in named
+func WithNamed(ctx context.Context, named map[string]string) context.Context {
+	return context.WithValue(ctx, namedKey{}, named)
+}
+
+// Named returns the named windows of the context
+func Named(ctx context.Context) map[string]string {
+	named, _ := ctx.Value(namedKey{}).(map[string]string)
+	return named
+}
@@ -0,0 +1,157 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changewindow_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/changewindow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// at returns a local time in October 2016, when the 1st was a Saturday
+func at(day, hour, minute int) time.Time {
+	return time.Date(2016, time.October, day, hour, minute, 0, 0, time.Local)
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		w, err := changewindow.Parse("  */15   2-4 * * sat,sun ")
+		require.NoError(t, err)
+		assert.Equal(t, "*/15 2-4 * * sat,sun", w.Expr)
+		assert.Equal(t, "*/15 2-4 * * sat,sun", w.String())
+	})
+
+	for in, msg := range map[string]string{
+		"* * * *":       `window "* * * *" must have 5 fields (minute hour day-of-month month day-of-week), got 4`,
+		"60 * * * *":    `window "60 * * * *": minute 60 is out of range 0-59`,
+		"* x * * *":     `window "* x * * *": hour "x" is not a number`,
+		"* 4-2 * * *":   `window "* 4-2 * * *": hour "4-2" starts after it ends`,
+		"*/0 * * * *":   `window "*/0 * * * *": minute "*/0" has an invalid step`,
+		"* * 0 * *":     `window "* * 0 * *": day of month 0 is out of range 1-31`,
+		"* * * foo *":   `window "* * * foo *": month "foo" is not a number`,
+		"* * * * mon-x": `window "* * * * mon-x": day of week "x" is not a number`,
+	} {
+		in, msg := in, msg
+		t.Run(in, func(t *testing.T) {
+			_, err := changewindow.Parse(in)
+			assert.EqualError(t, err, msg)
+		})
+	}
+}
+
+func TestWindowContains(t *testing.T) {
+	t.Parallel()
+
+	for expr, cases := range map[string]map[time.Time]bool{
+		"* 2-4 * * sat,sun": {
+			at(1, 2, 0):   true,
+			at(2, 4, 59):  true,
+			at(1, 5, 0):   false,
+			at(3, 3, 0):   false, // Monday
+			at(8, 1, 59):  false,
+			at(15, 3, 30): true,
+		},
+		"0-30/10 * * * *": {
+			at(3, 12, 0):  true,
+			at(3, 12, 20): true,
+			at(3, 12, 25): false,
+			at(3, 12, 40): false,
+		},
+		"5/20 * * * *": {
+			at(3, 12, 5):  true,
+			at(3, 12, 45): true,
+			at(3, 12, 0):  false,
+		},
+		"* * * * 7": {
+			at(2, 12, 0): true, // Sunday
+			at(3, 12, 0): false,
+		},
+		// either the day of the month or the day of the week
+		"* * 3 * fri": {
+			at(3, 12, 0): true,
+			at(7, 12, 0): true,
+			at(4, 12, 0): false,
+		},
+		"* * * nov *": {
+			at(31, 23, 59): false,
+		},
+	} {
+		w, err := changewindow.Parse(expr)
+		require.NoError(t, err)
+
+		for when, expected := range cases {
+			assert.Equal(t, expected, w.Contains(when), "%q at %s", expr, when)
+		}
+	}
+}
+
+func TestWindowNext(t *testing.T) {
+	t.Parallel()
+
+	w, err := changewindow.Parse("30 2 * * sun")
+	require.NoError(t, err)
+
+	next, ok := w.Next(at(3, 12, 15))
+	assert.True(t, ok)
+	assert.Equal(t, at(9, 2, 30), next)
+
+	next, ok = w.Next(at(9, 2, 30).Add(20 * time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, at(9, 2, 30), next)
+
+	never, err := changewindow.Parse("0 0 31 feb *")
+	require.NoError(t, err)
+	_, ok = never.Next(at(3, 12, 15))
+	assert.False(t, ok)
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	named := map[string]string{
+		"weekends": "* * * * sat,sun",
+		"broken":   "* *",
+	}
+
+	w, err := changewindow.Resolve("weekends", named)
+	require.NoError(t, err)
+	assert.Equal(t, "weekends (* * * * sat,sun)", w.String())
+
+	w, err = changewindow.Resolve("* 2 * * *", named)
+	require.NoError(t, err)
+	assert.Equal(t, "", w.Name)
+
+	_, err = changewindow.Resolve("maintenance", named)
+	assert.EqualError(t, err, `unknown change window "maintenance"`)
+
+	_, err = changewindow.Resolve("broken", named)
+	assert.EqualError(t, err, `change window "broken": window "* *" must have 5 fields (minute hour day-of-month month day-of-week), got 2`)
+}
+
+func TestNamed(t *testing.T) {
+	t.Parallel()
+
+	named := map[string]string{"weekends": "* * * * sat,sun"}
+	ctx := changewindow.WithNamed(context.Background(), named)
+
+	assert.Equal(t, named, changewindow.Named(ctx))
+	assert.Nil(t, changewindow.Named(context.Background()))
+}
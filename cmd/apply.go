@@ -18,8 +18,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/errcode"
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/helpers/logging"
@@ -78,9 +80,14 @@ real happens.`,
 			stream, err := client.Apply(
 				ctx,
 				&pb.LoadRequest{
-					Location:   fname,
-					Parameters: rpcParams,
-					Verify:     verifyModules,
+					Location:         fname,
+					Parameters:       rpcParams,
+					Verify:           verifyModules,
+					AllowDestructive: getAllowDestructive(),
+					Parallelism:      getParallelism(),
+					StrictRender:     getStrictRender(),
+					StrictRefs:       getStrictRefs(),
+					Heartbeat:        getHeartbeat(),
 				},
 			)
 			if err != nil {
@@ -105,6 +112,12 @@ real happens.`,
 
 			// get vertices
 			var applyError bool
+			warnings := new(warningCollector)
+			destructive := new(destructiveCollector)
+			explain := newExplainCollector(getExplain())
+			tracker := newRunTracker(edges)
+			watchCtx, stopWatching := context.WithCancel(ctx)
+			dumpStatusOnSignal(watchCtx, tracker, timer.Bypass())
 			err = iterateOverStream(
 				stream,
 				func(resp *pb.StatusResponse) {
@@ -116,10 +129,21 @@ real happens.`,
 					switch resp.Run {
 					case pb.StatusResponse_STARTED:
 						timer.AddTimer(resp.Meta.Id + ": " + resp.Stage.String())
+						tracker.Start(resp.Meta.Id, resp.Stage.String())
 						slog.Info("got status")
 
+					case pb.StatusResponse_RUNNING:
+						heartbeat := resp.GetHeartbeat()
+						tracker.Heartbeat(resp.Meta.Id, heartbeat.GetOutput())
+						slog = slog.WithField("elapsed", roundSeconds(time.Duration(heartbeat.GetElapsed())))
+						if heartbeat.GetOutput() != "" {
+							slog = slog.WithField("output", heartbeat.GetOutput())
+						}
+						slog.Info("still running")
+
 					case pb.StatusResponse_FINISHED:
 						timer.RemoveTimer(resp.Meta.Id + ": " + resp.Stage.String())
+						tracker.Finish(resp.Meta.Id)
 						slog.Debug("got status")
 
 						details := resp.GetDetails()
@@ -129,6 +153,9 @@ real happens.`,
 								applyError = true
 							}
 							g.Add(node.New(resp.Id, printable))
+							warnings.Add(resp.Id, printable)
+							destructive.Add(resp.Id, printable)
+							explain.Add(resp.Id, printable)
 						}
 
 					default:
@@ -137,11 +164,20 @@ real happens.`,
 				},
 			)
 
+			stopWatching()
 			timer.Stop()
 			flog.Logger.Out = oldOut
 
 			if err != nil {
-				flog.WithError(err).Fatal("could not get responses")
+				if ctx.Err() == nil {
+					flog.WithError(err).Fatal("could not get responses")
+				}
+
+				flog.Warn("interrupted, waiting for running tasks to stop")
+				waitForSelfHostedRPC()
+
+				tracker.Interrupt(g, errcode.ApplyInterrupted)
+				applyError = true
 			}
 
 			// validate resulting graph
@@ -155,11 +191,37 @@ real happens.`,
 				flog.WithError(err).Fatal("failed to print results")
 			}
 
+			if destructive.Len() > 0 {
+				fmt.Print("\n")
+				fmt.Print(destructive)
+			}
+
 			fmt.Print("\n")
 			fmt.Print(out)
+
+			if explain.Enabled() {
+				fmt.Print("\n")
+				fmt.Print(explain)
+			}
+
+			if id := getExplainDeps(); id != "" {
+				fmt.Print("\n")
+				fmt.Print(explainDeps(id, edges))
+			}
+
+			warnings.CheckParams(g, rpcParams)
+			if warnings.Len() > 0 {
+				fmt.Print("\n")
+				fmt.Print(warnings)
+			}
+
 			if applyError {
 				os.Exit(1)
 			}
+			if getStrict() && warnings.Len() > 0 {
+				flog.Error("warnings are treated as errors in strict mode")
+				os.Exit(1)
+			}
 		}
 	},
 }
@@ -172,6 +234,15 @@ func init() {
 	registerLocalRPCFlags(applyCmd.Flags())
 	registerSSLFlags(applyCmd.Flags())
 	registerParamsFlags(applyCmd.Flags())
+	registerStatusDumpFlags(applyCmd.Flags())
+	registerWarningFlags(applyCmd.Flags())
+	registerDestructiveFlags(applyCmd.Flags())
+	registerParallelismFlags(applyCmd.Flags())
+	registerStrictRenderFlags(applyCmd.Flags())
+	registerStrictRefsFlags(applyCmd.Flags())
+	registerExplainFlags(applyCmd.Flags())
+	registerExplainDepsFlags(applyCmd.Flags())
+	registerHeartbeatFlags(applyCmd.Flags())
 
 	RootCmd.AddCommand(applyCmd)
 }
@@ -0,0 +1,97 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/asteris-llc/converge/baseline"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+)
+
+// baselineCmd represents the baseline command
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "record and compare the observed state of a module",
+	Long: `baselines record the state of a module's resources as checking them
+observes it. A baseline exported from one host can be compared against others
+to audit them for consistency without applying anything:
+
+		converge baseline export --local --out=web.baseline myFile.hcl
+		converge baseline compare --local --baseline=web.baseline myFile.hcl`,
+}
+
+// observeBaseline plans the module at location and records what checking each
+// node observed
+func observeBaseline(ctx context.Context, client pb.ExecutorClient, location string, params map[string]string) (*baseline.Baseline, error) {
+	logger := logging.GetLogger(ctx).WithField("file", location)
+
+	stream, err := client.Plan(
+		ctx,
+		&pb.LoadRequest{
+			Location:     location,
+			Parameters:   params,
+			Verify:       viper.GetBool("verify-modules"),
+			Parallelism:  getParallelism(),
+			StrictRender: getStrictRender(),
+			StrictRefs:   getStrictRefs(),
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting RPC stream")
+	}
+
+	if _, err := getMeta(stream); err != nil {
+		return nil, errors.Wrap(err, "error getting RPC metadata")
+	}
+
+	observed := baseline.New(location)
+	err = iterateOverStream(
+		stream,
+		func(resp *pb.StatusResponse) {
+			if resp.Run != pb.StatusResponse_FINISHED {
+				return
+			}
+			logger.WithField("id", resp.Id).Debug("observed")
+
+			if details := resp.GetDetails(); details != nil {
+				observed.Add(resp.Id, details.ToPrintable())
+			}
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get responses")
+	}
+
+	return observed, nil
+}
+
+func registerBaselineFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("verify-modules", false, "verify module signatures")
+	registerRPCFlags(cmd.Flags())
+	registerLocalRPCFlags(cmd.Flags())
+	registerSSLFlags(cmd.Flags())
+	registerParamsFlags(cmd.Flags())
+	registerParallelismFlags(cmd.Flags())
+	registerStrictRenderFlags(cmd.Flags())
+	registerStrictRefsFlags(cmd.Flags())
+}
+
+func init() {
+	RootCmd.AddCommand(baselineCmd)
+}
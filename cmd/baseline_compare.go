@@ -0,0 +1,91 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/baseline"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+)
+
+// baselineCompareCmd represents the baseline compare command
+var baselineCompareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "compare the observed state of a module against a baseline",
+	Long: `compare checks every resource in a module, like plan, and reports where
+what it observed differs from a baseline written by export. Nothing is
+applied. The exit status is 1 when there are differences.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Need exactly one module filename as argument")
+		}
+		if viper.GetString("baseline") == "" {
+			return errors.New("Need a baseline to compare against with --baseline")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		// set up execution context
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		GracefulExit(cancel)
+
+		// logging
+		clog := log.WithField("component", "client")
+		ctx = logging.WithLogger(ctx, clog)
+
+		want, err := baseline.ReadFile(viper.GetString("baseline"))
+		if err != nil {
+			clog.WithError(err).Fatal("could not read baseline")
+		}
+
+		maybeSetToken()
+
+		if err := maybeStartSelfHostedRPC(ctx); err != nil {
+			clog.WithError(err).Fatal("could not start RPC")
+		}
+
+		client, err := getRPCExecutorClient(ctx, getSecurityConfig())
+		if err != nil {
+			clog.WithError(err).Fatal("could not get client")
+		}
+
+		observed, err := observeBaseline(ctx, client, args[0], getParamsRPC(cmd))
+		if err != nil {
+			clog.WithError(err).Fatal("could not observe module")
+		}
+
+		diffs := baseline.Compare(want, observed)
+		fmt.Print(baseline.Report(diffs))
+
+		if len(diffs) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	baselineCompareCmd.Flags().String("baseline", "", "the baseline to compare against")
+	registerBaselineFlags(baselineCompareCmd)
+
+	baselineCmd.AddCommand(baselineCompareCmd)
+}
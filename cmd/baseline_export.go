@@ -0,0 +1,88 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+)
+
+// baselineExportCmd represents the baseline export command
+var baselineExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "record the observed state of a module in a baseline",
+	Long: `export checks every resource in a module, like plan, and writes what it
+observed to a baseline document. Nothing is applied.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Need exactly one module filename as argument")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		// set up execution context
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		GracefulExit(cancel)
+
+		// logging
+		clog := log.WithField("component", "client")
+		ctx = logging.WithLogger(ctx, clog)
+
+		maybeSetToken()
+
+		if err := maybeStartSelfHostedRPC(ctx); err != nil {
+			clog.WithError(err).Fatal("could not start RPC")
+		}
+
+		client, err := getRPCExecutorClient(ctx, getSecurityConfig())
+		if err != nil {
+			clog.WithError(err).Fatal("could not get client")
+		}
+
+		observed, err := observeBaseline(ctx, client, args[0], getParamsRPC(cmd))
+		if err != nil {
+			clog.WithError(err).Fatal("could not observe module")
+		}
+
+		var out io.Writer = os.Stdout
+		if fname := viper.GetString("out"); fname != "" && fname != "-" {
+			file, err := os.OpenFile(fname, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+			if err != nil {
+				clog.WithError(err).Fatal("could not create baseline")
+			}
+			defer file.Close()
+			out = file
+		}
+
+		if err := observed.Write(out); err != nil {
+			clog.WithError(err).Fatal("could not write baseline")
+		}
+	},
+}
+
+func init() {
+	baselineExportCmd.Flags().String("out", "-", "write the baseline to this file")
+	registerBaselineFlags(baselineExportCmd)
+
+	baselineCmd.AddCommand(baselineExportCmd)
+}
@@ -0,0 +1,245 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/plan"
+	"github.com/asteris-llc/converge/render"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+)
+
+const consoleHelp = `Enter a template expression to evaluate it, for example:
+
+    param "message"
+    {{lookup "task.query.name.status"}}
+    platform.OS
+
+Expressions are evaluated from the current node, which starts at "root".
+
+Commands:
+    :node [id]  show or change the current node
+    :nodes      list all nodes in the graph
+    :funcs      list the functions available in templates
+    :help       show this help
+    :quit       leave the console
+`
+
+// consoleCmd represents the console command
+var consoleCmd = &cobra.Command{
+	Use:   "console",
+	Short: "evaluate template expressions against a module",
+	Long: `console loads and renders a module with the given parameters, then
+provides an interactive prompt for evaluating template expressions against the
+result. It's the quickest way to find out why a value isn't what you expect:
+
+		converge console -p message=hello myFile.hcl
+
+Lookups need the status of the node they refer to. Use --plan to run the
+module's checks before starting the prompt so that lookups can be resolved.`,
+
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("Need one module filename as argument, got %d", len(args))
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		fname := args[0]
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		GracefulExit(cancel)
+
+		flog := log.WithField("file", fname)
+
+		loaded, err := (&pb.LoadRequest{
+			Location:   fname,
+			Parameters: getParamsRPC(cmd),
+			Verify:     viper.GetBool("verify-modules"),
+		}).Load(ctx)
+		if err != nil {
+			flog.WithError(err).Fatal("could not load module")
+		}
+
+		planned := viper.GetBool("plan")
+		if planned {
+			loaded, err = plan.Plan(ctx, loaded)
+			if err != nil && err != plan.ErrTreeContainsErrors {
+				flog.WithError(err).Fatal("could not plan module")
+			}
+		}
+
+		c, err := newConsole(ctx, loaded, planned)
+		if err != nil {
+			flog.WithError(err).Fatal("could not start console")
+		}
+
+		if err := c.run(os.Stdin, os.Stdout); err != nil {
+			flog.WithError(err).Fatal("console failed")
+		}
+	},
+}
+
+// console evaluates template expressions against a graph
+type console struct {
+	graph   *graph.Graph
+	factory *render.Factory
+	planned bool
+
+	id string
+}
+
+// consoleID is the placeholder node expressions are evaluated from when the
+// current node is the root. Lookups and params are resolved relative to a
+// node's siblings, so the root itself can't be used.
+var consoleID = graph.ID("root", "console")
+
+func newConsole(ctx context.Context, g *graph.Graph, planned bool) (*console, error) {
+	withConsole := g.Copy()
+	withConsole.Add(node.New(consoleID, nil))
+	withConsole.ConnectParent("root", consoleID)
+
+	factory, err := render.NewFactory(ctx, withConsole)
+	if err != nil {
+		return nil, err
+	}
+
+	return &console{
+		graph:   g,
+		factory: factory,
+		planned: planned,
+		id:      "root",
+	}, nil
+}
+
+// eval renders a single expression from the current node. Bare expressions
+// are wrapped in template delimiters.
+func (c *console) eval(expr string) (string, error) {
+	if !strings.Contains(expr, "{{") {
+		expr = "{{" + expr + "}}"
+	}
+
+	id := c.id
+	if graph.IsRoot(id) {
+		id = consoleID
+	}
+
+	renderer, err := c.factory.GetRenderer(id)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := renderer.Render("console", expr)
+	if _, unresolvable := err.(render.ErrUnresolvable); unresolvable && !c.planned {
+		return out, fmt.Errorf("%s (lookups need a status, try starting the console with --plan)", err)
+	}
+	return out, err
+}
+
+// setNode changes the node expressions are evaluated from. IDs may be given
+// relative to the root.
+func (c *console) setNode(id string) error {
+	for _, candidate := range []string{id, graph.ID("root", id)} {
+		if c.graph.Contains(candidate) {
+			c.id = candidate
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s is not in the graph", id)
+}
+
+// run reads expressions from in until it is exhausted or the user quits
+func (c *console) run(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+
+	for {
+		fmt.Fprintf(out, "%s> ", c.id)
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		fields := strings.Fields(line)
+
+		switch {
+		case line == "":
+			continue
+
+		case line == ":quit" || line == ":exit":
+			return nil
+
+		case line == ":help":
+			fmt.Fprint(out, consoleHelp)
+
+		case line == ":nodes":
+			vertices := c.graph.Vertices()
+			sort.Strings(vertices)
+			for _, vertex := range vertices {
+				fmt.Fprintln(out, vertex)
+			}
+
+		case line == ":funcs":
+			var funcs []string
+			for name := range c.factory.Language.Funcs {
+				funcs = append(funcs, name)
+			}
+			sort.Strings(funcs)
+			fmt.Fprintln(out, strings.Join(funcs, " "))
+
+		case fields[0] == ":node":
+			if len(fields) > 1 {
+				if err := c.setNode(fields[1]); err != nil {
+					fmt.Fprintf(out, "error: %s\n", err)
+					continue
+				}
+			}
+			fmt.Fprintln(out, c.id)
+
+		case strings.HasPrefix(line, ":"):
+			fmt.Fprintf(out, "error: unknown command %s, try :help\n", fields[0])
+
+		default:
+			result, err := c.eval(line)
+			if err != nil {
+				fmt.Fprintf(out, "error: %s\n", err)
+				continue
+			}
+			fmt.Fprintf(out, "%q\n", result)
+		}
+	}
+}
+
+func init() {
+	consoleCmd.Flags().Bool("plan", false, "plan the module before starting, so lookups can be resolved")
+	consoleCmd.Flags().Bool("verify-modules", false, "verify module signatures")
+	registerParamsFlags(consoleCmd.Flags())
+
+	RootCmd.AddCommand(consoleCmd)
+}
@@ -0,0 +1,105 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/helpers/testing/hclutils"
+	"github.com/asteris-llc/converge/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func newTestConsole(t *testing.T) *console {
+	loaded, err := hclutils.LoadAndParseFromString("console", `
+param "message" {
+  default = "hello"
+}
+
+task "greet" {
+  check = "true"
+  apply = "true"
+}
+`)
+	require.NoError(t, err)
+
+	rendered, err := render.Render(context.Background(), loaded, render.Values{"message": "hi"})
+	require.NoError(t, err)
+
+	c, err := newConsole(context.Background(), rendered, false)
+	require.NoError(t, err)
+
+	return c
+}
+
+func TestConsoleEval(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	c := newTestConsole(t)
+
+	t.Run("bare expression", func(t *testing.T) {
+		out, err := c.eval(`param "message"`)
+		assert.NoError(t, err)
+		assert.Equal(t, "hi", out)
+	})
+
+	t.Run("template", func(t *testing.T) {
+		out, err := c.eval(`say {{param "message"}}`)
+		assert.NoError(t, err)
+		assert.Equal(t, "say hi", out)
+	})
+
+	t.Run("unresolvable lookup", func(t *testing.T) {
+		_, err := c.eval(`lookup "task.greet.status"`)
+		assert.Error(t, err)
+	})
+}
+
+func TestConsoleSetNode(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	c := newTestConsole(t)
+
+	assert.NoError(t, c.setNode("task.greet"))
+	assert.Equal(t, "root/task.greet", c.id)
+
+	assert.NoError(t, c.setNode("root"))
+	assert.Equal(t, "root", c.id)
+
+	assert.Error(t, c.setNode("task.missing"))
+	assert.Equal(t, "root", c.id)
+}
+
+func TestConsoleRun(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	c := newTestConsole(t)
+
+	var out bytes.Buffer
+	in := strings.NewReader(":node task.greet\nparam \"message\"\n:wat\n:quit\nparam \"message\"\n")
+
+	require.NoError(t, c.run(in, &out))
+
+	assert.Equal(
+		t,
+		"root> root/task.greet\nroot/task.greet> \"hi\"\nroot/task.greet> error: unknown command :wat, try :help\nroot/task.greet> ",
+		out.String(),
+	)
+}
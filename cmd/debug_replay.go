@@ -0,0 +1,144 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/snapshot"
+	"github.com/asteris-llc/converge/load"
+	"github.com/asteris-llc/converge/render"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+)
+
+// replayCmd represents the replay command
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "re-run a single load phase from a graph snapshot",
+	Long: `replay takes a snapshot written with --dump-graph-dir and re-runs the
+phase that follows it, printing a snapshot of the result. This makes it
+possible to debug the resolver and renderer without loading the whole module
+again:
+
+		converge apply --local --dump-graph-dir=snapshots myFile.hcl
+		converge debug replay snapshots/02-resolve.json
+
+Phases run in the order nodes, resolve, resources, render.`,
+
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("Need one snapshot filename as argument, got %d", len(args))
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		fname := args[0]
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		GracefulExit(cancel)
+
+		flog := log.WithField("file", fname)
+
+		snap, err := snapshot.Read(fname)
+		if err != nil {
+			flog.WithError(err).Fatal("could not read snapshot")
+		}
+
+		phase := viper.GetString("phase")
+		if phase == "" {
+			next, ok := snapshot.Next(snap.Phase)
+			if !ok {
+				flog.WithField("phase", snap.Phase).Fatal("no phase follows this snapshot")
+			}
+			phase = next
+		}
+
+		out, err := replayPhase(ctx, snap, phase)
+		if err != nil {
+			flog.WithError(err).WithField("phase", phase).Fatal("replay failed")
+		}
+
+		result, err := snapshot.New(phase, out)
+		if err != nil {
+			flog.WithError(err).Fatal("could not snapshot result")
+		}
+		result.Location = snap.Location
+		result.Parameters = snap.Parameters
+		result.InheritSources(snap)
+
+		if dest := viper.GetString("out"); dest != "" {
+			if err := result.Write(dest); err != nil {
+				flog.WithError(err).Fatal("could not write snapshot")
+			}
+			return
+		}
+
+		content, err := result.Marshal()
+		if err != nil {
+			flog.WithError(err).Fatal("could not serialize snapshot")
+		}
+		fmt.Print(string(content))
+	},
+}
+
+// replayPhase runs a single phase on the graph stored in the snapshot. The
+// snapshot must have been taken directly before that phase.
+func replayPhase(ctx context.Context, snap *snapshot.Snapshot, phase string) (*graph.Graph, error) {
+	if next, ok := snapshot.Next(snap.Phase); !ok || next != phase {
+		return nil, fmt.Errorf("cannot replay %q from a %q snapshot", phase, snap.Phase)
+	}
+
+	g, err := snap.Graph()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not rebuild graph")
+	}
+
+	switch phase {
+	case snapshot.PhaseResolve:
+		return load.ResolveDependencies(ctx, g)
+
+	case snapshot.PhaseResources:
+		return load.SetResources(ctx, g)
+
+	case snapshot.PhaseRender:
+		// resources can't be serialized, so set them again from source
+		resourced, err := load.SetResources(ctx, g)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not restore resources")
+		}
+
+		values := render.Values{}
+		for k, v := range snap.Parameters {
+			values[k] = v
+		}
+		return render.Render(ctx, resourced, values)
+
+	default:
+		return nil, fmt.Errorf("%q cannot be replayed", phase)
+	}
+}
+
+func init() {
+	replayCmd.Flags().String("phase", "", "phase to replay (default is the phase following the snapshot)")
+	replayCmd.Flags().String("out", "", "write the resulting snapshot to this file instead of stdout")
+
+	debugCmd.AddCommand(replayCmd)
+}
@@ -0,0 +1,332 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+)
+
+// depsContains is the kind of the edges from a module to the nodes in it,
+// which the module waits for
+const depsContains = "contains"
+
+// depsCmd represents the deps command
+var depsCmd = &cobra.Command{
+	Use:   "deps module.hcl [node...]",
+	Short: "report what each node in a module depends on, and why",
+	Long: `deps lists the direct and transitive dependencies of every node in a
+module, along with where each direct dependency came from: a node listed in
+depends, a param, a lookup, a group, and so on. Transitive dependencies show
+the nodes they are reached through.
+
+Give node IDs after the module to report on just those nodes. IDs may leave
+out the root, like "file.content.config". Use --format json to get one JSON
+object per node, which is easy to diff when reviewing changes to ordering.`,
+
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return fmt.Errorf("Need a module filename as argument, got %d arguments", len(args))
+		}
+		if format := viper.GetString("format"); format != "tree" && format != "json" {
+			return fmt.Errorf("format must be one of tree or json, got %q", format)
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		fname := args[0]
+
+		// set up execution context
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		GracefulExit(cancel)
+
+		// logging
+		flog := log.WithField("file", fname).WithField("component", "client")
+
+		maybeSetToken()
+
+		if err := maybeStartSelfHostedRPC(ctx); err != nil {
+			flog.WithError(err).Fatal("could not start RPC")
+		}
+
+		client, err := getRPCGrapherClient(ctx, getSecurityConfig())
+		if err != nil {
+			flog.WithError(err).Fatal("could not get client")
+		}
+
+		// load the graph
+		g, err := client.Graph(
+			ctx,
+			&pb.LoadRequest{
+				Location:     fname,
+				Parameters:   getParamsRPC(cmd),
+				StrictRender: getStrictRender(),
+				StrictRefs:   getStrictRefs(),
+			},
+		)
+		if err != nil {
+			flog.WithError(err).Fatal("could not get graph")
+		}
+
+		ids := g.Vertices()
+		if len(args) > 1 {
+			ids = nil
+			for _, id := range args[1:] {
+				id = qualifyID(id)
+				if !g.Contains(id) {
+					flog.WithField("id", id).Fatal("node was not found")
+				}
+				ids = append(ids, id)
+			}
+		}
+
+		report := newDepsReport(ids, g.Edges())
+		if viper.GetString("format") == "json" {
+			out, err := report.JSON()
+			if err != nil {
+				flog.WithError(err).Fatal("could not generate report")
+			}
+			fmt.Print(out)
+		} else {
+			fmt.Print(report.Tree())
+		}
+	},
+}
+
+func init() {
+	depsCmd.Flags().String("format", "tree", "output format, one of tree or json")
+	registerParamsFlags(depsCmd.Flags())
+	registerStrictRenderFlags(depsCmd.Flags())
+	registerStrictRefsFlags(depsCmd.Flags())
+	registerSSLFlags(depsCmd.Flags())
+	registerRPCFlags(depsCmd.Flags())
+	registerLocalRPCFlags(depsCmd.Flags())
+
+	RootCmd.AddCommand(depsCmd)
+}
+
+// depsEdge is a direct dependency, with where it came from
+type depsEdge struct {
+	ID      string   `json:"id"`
+	Kinds   []string `json:"kinds"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// depsTransitive is a dependency reached through other nodes
+type depsTransitive struct {
+	ID string `json:"id"`
+
+	// Via is the nodes between the reported node and this one, starting
+	// with a direct dependency
+	Via []string `json:"via"`
+}
+
+// depsNode is the dependencies of a single node
+type depsNode struct {
+	ID         string           `json:"id"`
+	Direct     []depsEdge       `json:"direct"`
+	Transitive []depsTransitive `json:"transitive"`
+}
+
+// depsReport is the dependencies of the nodes in a module
+type depsReport struct {
+	Nodes []depsNode
+
+	// edges are the direct dependencies of every node, sorted by ID
+	edges map[string][]depsEdge
+}
+
+// newDepsReport reports on the nodes with the given IDs. The root is left
+// out, since it waits for everything.
+func newDepsReport(ids []string, edges []graph.Edge) *depsReport {
+	report := &depsReport{edges: make(map[string][]depsEdge)}
+
+	for _, edge := range edges {
+		dep := depsEdge{ID: edge.Dest, Reasons: reasonTexts(edge.Reasons)}
+		if isParentEdge(&edge) {
+			dep.Kinds = []string{depsContains}
+		} else {
+			dep.Kinds = reasonKinds(edge.Reasons)
+		}
+		report.edges[edge.Source] = append(report.edges[edge.Source], dep)
+	}
+	for _, deps := range report.edges {
+		sort.Sort(depsEdgesByID(deps))
+	}
+
+	sorted := append([]string{}, ids...)
+	sort.Strings(sorted)
+	for _, id := range sorted {
+		if graph.IsRoot(id) {
+			continue
+		}
+		report.Nodes = append(report.Nodes, depsNode{
+			ID:         id,
+			Direct:     append([]depsEdge{}, report.edges[id]...),
+			Transitive: report.transitive(id),
+		})
+	}
+
+	return report
+}
+
+// transitive finds the dependencies of id that aren't direct, each through the
+// shortest chain of nodes that reaches it
+func (r *depsReport) transitive(id string) []depsTransitive {
+	seen := map[string]struct{}{id: {}}
+	for _, dep := range r.edges[id] {
+		seen[dep.ID] = struct{}{}
+	}
+
+	type step struct {
+		id  string
+		via []string
+	}
+	var queue []step
+	for _, dep := range r.edges[id] {
+		queue = append(queue, step{dep.ID, []string{dep.ID}})
+	}
+
+	var out []depsTransitive
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, dep := range r.edges[current.id] {
+			if _, ok := seen[dep.ID]; ok {
+				continue
+			}
+			seen[dep.ID] = struct{}{}
+
+			via := append(append([]string{}, current.via...), dep.ID)
+			out = append(out, depsTransitive{ID: dep.ID, Via: via[:len(via)-1]})
+			queue = append(queue, step{dep.ID, via})
+		}
+	}
+
+	sort.Sort(depsTransitiveByID(out))
+	return out
+}
+
+// Tree shows the dependencies of each node as a tree, with the reasons for
+// every edge. Nodes that have been shown once for a node aren't expanded
+// again.
+func (r *depsReport) Tree() string {
+	var buf bytes.Buffer
+	for idx, n := range r.Nodes {
+		if idx > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(n.ID + "\n")
+
+		if len(n.Direct) == 0 {
+			buf.WriteString("  no dependencies\n")
+			continue
+		}
+
+		r.writeTree(&buf, n.ID, 1, map[string]struct{}{n.ID: {}})
+	}
+	return buf.String()
+}
+
+func (r *depsReport) writeTree(buf *bytes.Buffer, id string, depth int, shown map[string]struct{}) {
+	indent := strings.Repeat("  ", depth)
+	for _, dep := range r.edges[id] {
+		fmt.Fprintf(buf, "%s%s [%s]", indent, dep.ID, strings.Join(dep.Kinds, ", "))
+		if len(dep.Reasons) > 0 {
+			fmt.Fprintf(buf, ": %s", strings.Join(dep.Reasons, "; "))
+		}
+
+		if _, ok := shown[dep.ID]; ok {
+			if len(r.edges[dep.ID]) > 0 {
+				buf.WriteString(" (see above)")
+			}
+			buf.WriteString("\n")
+			continue
+		}
+		buf.WriteString("\n")
+
+		shown[dep.ID] = struct{}{}
+		r.writeTree(buf, dep.ID, depth+1, shown)
+	}
+}
+
+// JSON is the report as one JSON object per node
+func (r *depsReport) JSON() (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, n := range r.Nodes {
+		if n.Direct == nil {
+			n.Direct = []depsEdge{}
+		}
+		if n.Transitive == nil {
+			n.Transitive = []depsTransitive{}
+		}
+		if err := enc.Encode(n); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+// reasonKinds are the distinct kinds of the reasons for an edge
+func reasonKinds(reasons []graph.Reason) []string {
+	if len(reasons) == 0 {
+		return []string{string(graph.ReasonUnknown)}
+	}
+
+	var kinds []string
+	seen := make(map[graph.ReasonKind]struct{})
+	for _, reason := range reasons {
+		if _, ok := seen[reason.Kind]; ok {
+			continue
+		}
+		seen[reason.Kind] = struct{}{}
+		kinds = append(kinds, string(reason.Kind))
+	}
+	return kinds
+}
+
+// reasonTexts are the descriptions of the reasons for an edge
+func reasonTexts(reasons []graph.Reason) []string {
+	var texts []string
+	for _, reason := range reasons {
+		texts = append(texts, reason.Text)
+	}
+	return texts
+}
+
+type depsEdgesByID []depsEdge
+
+func (d depsEdgesByID) Len() int           { return len(d) }
+func (d depsEdgesByID) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d depsEdgesByID) Less(i, j int) bool { return d[i].ID < d[j].ID }
+
+type depsTransitiveByID []depsTransitive
+
+func (d depsTransitiveByID) Len() int           { return len(d) }
+func (d depsTransitiveByID) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d depsTransitiveByID) Less(i, j int) bool { return d[i].ID < d[j].ID }
@@ -0,0 +1,109 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDepsReport(t *testing.T) {
+	t.Parallel()
+
+	edges := []graph.Edge{
+		{Source: "root", Dest: "root/module.m", Attributes: []string{"parent"}},
+		{Source: "root", Dest: "root/task.c", Attributes: []string{"parent"}},
+		{Source: "root/module.m", Dest: "root/module.m/task.a", Attributes: []string{"parent"}},
+		{Source: "root/module.m/task.a", Dest: "root/param.x", Reasons: []graph.Reason{{Kind: graph.ReasonParam, Text: `uses param "x"`}}},
+		{Source: "root/task.c", Dest: "root/module.m", Reasons: []graph.Reason{{Kind: graph.ReasonDepends, Text: `listed in depends as "module.m"`}, {Kind: graph.ReasonLookup, Text: `looks up "module.m.status"`}}},
+		{Source: "root/task.c", Dest: "root/param.x", Reasons: []graph.Reason{{Kind: graph.ReasonParam, Text: `uses param "x"`}}},
+		{Source: "root/task.d", Dest: "root/task.c"},
+	}
+	ids := []string{"root", "root/task.d", "root/task.c", "root/module.m", "root/module.m/task.a", "root/param.x"}
+
+	report := newDepsReport(ids, edges)
+
+	t.Run("nodes", func(t *testing.T) {
+		require.Len(t, report.Nodes, 5)
+		assert.Equal(t, "root/module.m", report.Nodes[0].ID)
+
+		c := report.Nodes[3]
+		assert.Equal(t, "root/task.c", c.ID)
+		assert.Equal(
+			t,
+			[]depsEdge{
+				{ID: "root/module.m", Kinds: []string{"depends", "lookup"}, Reasons: []string{`listed in depends as "module.m"`, `looks up "module.m.status"`}},
+				{ID: "root/param.x", Kinds: []string{"param"}, Reasons: []string{`uses param "x"`}},
+			},
+			c.Direct,
+		)
+		assert.Equal(t, []depsTransitive{{ID: "root/module.m/task.a", Via: []string{"root/module.m"}}}, c.Transitive)
+
+		d := report.Nodes[4]
+		assert.Equal(t, []depsEdge{{ID: "root/task.c", Kinds: []string{"unknown"}}}, d.Direct)
+		assert.Equal(
+			t,
+			[]depsTransitive{
+				{ID: "root/module.m", Via: []string{"root/task.c"}},
+				{ID: "root/module.m/task.a", Via: []string{"root/task.c", "root/module.m"}},
+				{ID: "root/param.x", Via: []string{"root/task.c"}},
+			},
+			d.Transitive,
+		)
+	})
+
+	t.Run("tree", func(t *testing.T) {
+		assert.Equal(
+			t,
+			"root/module.m\n"+
+				"  root/module.m/task.a [contains]\n"+
+				"    root/param.x [param]: uses param \"x\"\n"+
+				"\n"+
+				"root/module.m/task.a\n"+
+				"  root/param.x [param]: uses param \"x\"\n"+
+				"\n"+
+				"root/param.x\n"+
+				"  no dependencies\n"+
+				"\n"+
+				"root/task.c\n"+
+				"  root/module.m [depends, lookup]: listed in depends as \"module.m\"; looks up \"module.m.status\"\n"+
+				"    root/module.m/task.a [contains]\n"+
+				"      root/param.x [param]: uses param \"x\"\n"+
+				"  root/param.x [param]: uses param \"x\"\n"+
+				"\n"+
+				"root/task.d\n"+
+				"  root/task.c [unknown]\n"+
+				"    root/module.m [depends, lookup]: listed in depends as \"module.m\"; looks up \"module.m.status\"\n"+
+				"      root/module.m/task.a [contains]\n"+
+				"        root/param.x [param]: uses param \"x\"\n"+
+				"    root/param.x [param]: uses param \"x\"\n",
+			report.Tree(),
+		)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		out, err := newDepsReport([]string{"root/param.x", "root/module.m/task.a"}, edges).JSON()
+		require.NoError(t, err)
+		assert.Equal(
+			t,
+			`{"id":"root/module.m/task.a","direct":[{"id":"root/param.x","kinds":["param"],"reasons":["uses param \"x\""]}],"transitive":[]}`+"\n"+
+				`{"id":"root/param.x","direct":[],"transitive":[]}`+"\n",
+			out,
+		)
+	})
+}
@@ -0,0 +1,88 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/asteris-llc/converge/prettyprinters/human"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const allowDestructiveFlagName = "allow-destructive"
+
+func registerDestructiveFlags(flags *pflag.FlagSet) {
+	flags.Bool(allowDestructiveFlagName, false, "apply destructive changes on nodes without allow_destructive = true")
+}
+
+func getAllowDestructive() bool { return viper.GetBool(allowDestructiveFlagName) }
+
+// nodeDestructive is the reason a node's changes are destructive
+type nodeDestructive struct {
+	ID     string
+	Reason string
+}
+
+// destructiveCollector gathers the nodes with destructive changes so they can
+// be listed ahead of the rest of the results
+type destructiveCollector struct {
+	nodes []nodeDestructive
+}
+
+// Add collects the destructive changes from a node's result
+func (c *destructiveCollector) Add(id string, printable human.Printable) {
+	reporter, ok := printable.(resource.DestructiveReporter)
+	if !ok {
+		return
+	}
+
+	for _, reason := range reporter.Destructive() {
+		c.nodes = append(c.nodes, nodeDestructive{ID: id, Reason: reason})
+	}
+}
+
+// Len returns the number of collected destructive changes
+func (c *destructiveCollector) Len() int {
+	return len(c.nodes)
+}
+
+// String formats the destructive changes ordered by node
+func (c *destructiveCollector) String() string {
+	if len(c.nodes) == 0 {
+		return ""
+	}
+
+	nodes := make([]nodeDestructive, len(c.nodes))
+	copy(nodes, c.nodes)
+	sort.Stable(byDestructiveID(nodes))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Destructive changes: %d\n", len(nodes))
+	for _, node := range nodes {
+		fmt.Fprintf(&buf, "  %s: %s\n", node.ID, node.Reason)
+	}
+
+	return buf.String()
+}
+
+type byDestructiveID []nodeDestructive
+
+func (b byDestructiveID) Len() int           { return len(b) }
+func (b byDestructiveID) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byDestructiveID) Less(i, j int) bool { return b[i].ID < b[j].ID }
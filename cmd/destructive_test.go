@@ -0,0 +1,52 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDestructiveCollector(t *testing.T) {
+	t.Parallel()
+
+	c := new(destructiveCollector)
+	c.Add("root/user.b", (&pb.StatusResponse_Details{
+		Destructive: []string{"user b will be deleted"},
+	}).ToPrintable())
+	c.Add("root/fs.a", (&pb.StatusResponse_Details{
+		Destructive: []string{"/dev/sdb1 will be formatted as ext4"},
+	}).ToPrintable())
+	c.Add("root/task.c", (&pb.StatusResponse_Details{}).ToPrintable())
+
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(
+		t,
+		"Destructive changes: 2\n"+
+			"  root/fs.a: /dev/sdb1 will be formatted as ext4\n"+
+			"  root/user.b: user b will be deleted\n",
+		c.String(),
+	)
+}
+
+func TestDestructiveCollectorEmpty(t *testing.T) {
+	t.Parallel()
+
+	c := new(destructiveCollector)
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, "", c.String())
+}
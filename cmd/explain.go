@@ -0,0 +1,109 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/prettyprinters/human"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const explainFlagName = "explain"
+
+func registerExplainFlags(flags *pflag.FlagSet) {
+	flags.String(explainFlagName, "", "show where the values rendered into a node's fields came from")
+}
+
+func getExplain() string { return viper.GetString(explainFlagName) }
+
+// explainCollector keeps where the values of one node's rendered fields came
+// from, so they can be explained after the results
+type explainCollector struct {
+	ID      string
+	found   bool
+	origins []resource.Origin
+}
+
+// newExplainCollector explains the node with the given ID. IDs may leave out
+// the root, like "file.content.config".
+func newExplainCollector(id string) *explainCollector {
+	return &explainCollector{ID: qualifyID(id)}
+}
+
+// qualifyID adds the root to IDs given without it on the command line
+func qualifyID(id string) string {
+	if id != "" && !graph.IsRoot(id) && !strings.HasPrefix(id, "root/") {
+		id = graph.ID("root", id)
+	}
+	return id
+}
+
+// Add keeps the provenance from the explained node's result
+func (c *explainCollector) Add(id string, printable human.Printable) {
+	if c.ID == "" || id != c.ID {
+		return
+	}
+	c.found = true
+
+	if reporter, ok := printable.(resource.ProvenanceReporter); ok {
+		c.origins = reporter.Provenance()
+	}
+}
+
+// Enabled is true if a node is being explained
+func (c *explainCollector) Enabled() bool {
+	return c.ID != ""
+}
+
+// String formats the provenance by field
+func (c *explainCollector) String() string {
+	if !c.Enabled() {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Explaining %s:\n", c.ID)
+
+	switch {
+	case !c.found:
+		buf.WriteString("  node was not found\n")
+	case len(c.origins) == 0:
+		buf.WriteString("  no fields were rendered from params, lookups, or facts\n")
+	}
+
+	var fields []string
+	byField := map[string][]resource.Origin{}
+	for _, origin := range c.origins {
+		if _, seen := byField[origin.Field]; !seen {
+			fields = append(fields, origin.Field)
+		}
+		byField[origin.Field] = append(byField[origin.Field], origin)
+	}
+
+	for _, field := range fields {
+		fmt.Fprintf(&buf, "  %s:\n", field)
+		for _, origin := range byField[field] {
+			fmt.Fprintf(&buf, "    %s\n", origin)
+		}
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,68 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainCollector(t *testing.T) {
+	t.Parallel()
+
+	c := newExplainCollector("file.content.config")
+	c.Add("root/file.content.other", (&pb.StatusResponse_Details{
+		Provenance: []*pb.ProvenanceResponse{{Field: "content", Kind: "env", Name: "HOME", Value: "/root"}},
+	}).ToPrintable())
+	c.Add("root/file.content.config", (&pb.StatusResponse_Details{
+		Provenance: []*pb.ProvenanceResponse{
+			{Field: "destination", Kind: "param", Name: "dir", Node: "root/param.dir", Value: "/etc/app"},
+			{Field: "content", Kind: "lookup", Name: "task.query.port.status", Node: "root/task.query.port", Value: "8080"},
+			{Field: "destination", Kind: "platform", Name: "platform.OS", Value: "linux"},
+		},
+	}).ToPrintable())
+
+	assert.Equal(
+		t,
+		"Explaining root/file.content.config:\n"+
+			"  destination:\n"+
+			"    param dir = \"/etc/app\" (from root/param.dir)\n"+
+			"    platform platform.OS = \"linux\"\n"+
+			"  content:\n"+
+			"    lookup task.query.port.status = \"8080\" (from root/task.query.port)\n",
+		c.String(),
+	)
+}
+
+func TestExplainCollectorNotFound(t *testing.T) {
+	t.Parallel()
+
+	c := newExplainCollector("root/file.content.missing")
+	c.Add("root/file.content.other", (&pb.StatusResponse_Details{}).ToPrintable())
+
+	assert.Equal(t, "Explaining root/file.content.missing:\n  node was not found\n", c.String())
+}
+
+func TestExplainCollectorDisabled(t *testing.T) {
+	t.Parallel()
+
+	c := newExplainCollector("")
+	c.Add("root/file.content.other", (&pb.StatusResponse_Details{}).ToPrintable())
+
+	assert.False(t, c.Enabled())
+	assert.Equal(t, "", c.String())
+}
@@ -0,0 +1,106 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const explainDepsFlagName = "explain-deps"
+
+func registerExplainDepsFlags(flags *pflag.FlagSet) {
+	flags.String(explainDepsFlagName, "", "show every edge of a node and why it was created")
+}
+
+func getExplainDeps() string { return viper.GetString(explainDepsFlagName) }
+
+// explainDeps describes every edge to and from the node with the given ID, and
+// why it was created. IDs may leave out the root, like "file.content.config".
+func explainDeps(id string, edges []*graph.Edge) string {
+	id = qualifyID(id)
+
+	var partOf, contains, dependsOn, neededBy []string
+	for _, edge := range edges {
+		if edge.Source != id && edge.Dest != id {
+			continue
+		}
+
+		if isParentEdge(edge) {
+			if edge.Dest == id {
+				partOf = append(partOf, edge.Source)
+			} else {
+				contains = append(contains, edge.Dest)
+			}
+			continue
+		}
+
+		reasons := "no reason was recorded"
+		if len(edge.Reasons) > 0 {
+			reasons = strings.Join(reasonTexts(edge.Reasons), "; ")
+		}
+
+		if edge.Source == id {
+			dependsOn = append(dependsOn, edge.Dest+": "+reasons)
+		} else {
+			neededBy = append(neededBy, edge.Source+": "+reasons)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Dependencies of %s:\n", id)
+
+	if len(partOf)+len(contains)+len(dependsOn)+len(neededBy) == 0 {
+		buf.WriteString("  node was not found\n")
+		return buf.String()
+	}
+
+	for _, section := range []struct {
+		title string
+		lines []string
+	}{
+		{"depends on", dependsOn},
+		{"needed by", neededBy},
+		{"part of", partOf},
+		{"contains", contains},
+	} {
+		if len(section.lines) == 0 {
+			continue
+		}
+
+		sort.Strings(section.lines)
+		fmt.Fprintf(&buf, "  %s:\n", section.title)
+		for _, line := range section.lines {
+			fmt.Fprintf(&buf, "    %s\n", line)
+		}
+	}
+
+	return buf.String()
+}
+
+func isParentEdge(edge *graph.Edge) bool {
+	for _, attr := range edge.Attributes {
+		if attr == "parent" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainDeps(t *testing.T) {
+	t.Parallel()
+
+	edges := []*graph.Edge{
+		{Source: "root", Dest: "root/task.b", Attributes: []string{"parent"}},
+		{Source: "root", Dest: "root/param.x", Attributes: []string{"parent"}},
+		{Source: "root/task.b", Dest: "root/param.x", Reasons: []graph.Reason{{Kind: graph.ReasonParam, Text: `uses param "x"`}}},
+		{Source: "root/task.b", Dest: "root/task.a", Reasons: []graph.Reason{{Kind: graph.ReasonDepends, Text: `listed in depends as "task.a"`}, {Kind: graph.ReasonLookup, Text: `looks up "task.a.status"`}}},
+		{Source: "root/task.c", Dest: "root/task.b"},
+	}
+
+	assert.Equal(
+		t,
+		"Dependencies of root/task.b:\n"+
+			"  depends on:\n"+
+			"    root/param.x: uses param \"x\"\n"+
+			"    root/task.a: listed in depends as \"task.a\"; looks up \"task.a.status\"\n"+
+			"  needed by:\n"+
+			"    root/task.c: no reason was recorded\n"+
+			"  part of:\n"+
+			"    root\n",
+		explainDeps("task.b", edges),
+	)
+
+	assert.Equal(t, "Dependencies of root/task.x:\n  node was not found\n", explainDeps("task.x", edges))
+}
@@ -17,12 +17,13 @@ package cmd
 import (
 	"os"
 	"os/signal"
+	"syscall"
 
 	log "github.com/Sirupsen/logrus"
 	"golang.org/x/net/context"
 )
 
-// GracefulExit traps interrupt signals for a graceful exit
+// GracefulExit traps interrupt and termination signals for a graceful exit
 func GracefulExit(cancel context.CancelFunc) {
 	go GracefulExitBlocking(cancel)
 }
@@ -30,7 +31,7 @@ func GracefulExit(cancel context.CancelFunc) {
 // GracefulExitBlocking handles graceful exits, and blocks until exit
 func GracefulExitBlocking(cancel context.CancelFunc) {
 	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
 	interruptCount := 0
 
@@ -18,9 +18,12 @@ import (
 	"fmt"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/prettyprinters"
 	"github.com/asteris-llc/converge/prettyprinters/graphviz"
 	"github.com/asteris-llc/converge/prettyprinters/graphviz/providers"
+	"github.com/asteris-llc/converge/prettyprinters/jsonl"
 	"github.com/asteris-llc/converge/rpc/pb"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -36,12 +39,19 @@ dependencies are structured.
 
 You can pipe the output directly to the 'dot' command, for example:
 
-		converge graph myFile.hcl | dot -Tpng -o myFile.png`,
+		converge graph myFile.hcl | dot -Tpng -o myFile.png
+
+Modules are drawn as clusters. For large graphs, --collapse-modules draws each
+module as a single vertex instead. Use --format json to get the graph as JSON
+lines, with the parent of each vertex included.`,
 
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) != 1 {
 			return fmt.Errorf("Need one module filename as argument, got %d", len(args))
 		}
+		if format := viper.GetString("format"); format != "dot" && format != "json" {
+			return fmt.Errorf("format must be one of dot or json, got %q", format)
+		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
@@ -67,41 +77,65 @@ You can pipe the output directly to the 'dot' command, for example:
 		}
 
 		// load the graph
-		graph, err := client.Graph(
+		g, err := client.Graph(
 			ctx,
 			&pb.LoadRequest{
-				Location:   fname,
-				Parameters: getParamsRPC(cmd),
+				Location:     fname,
+				Parameters:   getParamsRPC(cmd),
+				StrictRender: getStrictRender(),
+				StrictRefs:   getStrictRefs(),
 			},
 		)
 		if err != nil {
 			flog.WithError(err).Fatal("could not get graph")
 		}
 
-		printer := prettyprinters.New(
-			graphviz.New(
-				graphviz.DefaultOptions(),
-				providers.RPCProvider{
-					ShowParams: viper.GetBool("show-params"),
-				},
-			),
-		)
+		collapse := viper.GetBool("collapse-modules")
+		if collapse {
+			g = graph.Collapse(g, isModuleVertex)
+		}
 
-		dotCode, err := printer.Show(ctx, graph)
+		var printer prettyprinters.Printer
+		if viper.GetString("format") == "json" {
+			printer = prettyprinters.New(new(jsonl.Printer))
+		} else {
+			printer = prettyprinters.New(
+				graphviz.New(
+					graphviz.DefaultOptions(),
+					providers.RPCProvider{
+						ShowParams:      viper.GetBool("show-params"),
+						CollapseModules: collapse,
+					},
+				),
+			)
+		}
+
+		out, err := printer.Show(ctx, g)
 		if err != nil {
-			flog.WithError(err).Fatal("could not generate dot output")
+			flog.WithError(err).Fatal("could not generate graph output")
 		}
 
-		fmt.Println(dotCode)
+		fmt.Println(out)
 	},
 }
 
 func init() {
 	graphCmd.Flags().Bool("show-params", false, "also graph param dependencies")
+	graphCmd.Flags().Bool("collapse-modules", false, "draw each module as a single vertex")
+	graphCmd.Flags().String("format", "dot", "output format, one of dot or json")
 	registerParamsFlags(graphCmd.Flags())
+	registerStrictRenderFlags(graphCmd.Flags())
+	registerStrictRefsFlags(graphCmd.Flags())
 	registerSSLFlags(graphCmd.Flags())
 	registerRPCFlags(graphCmd.Flags())
 	registerLocalRPCFlags(graphCmd.Flags())
 
 	RootCmd.AddCommand(graphCmd)
 }
+
+// isModuleVertex picks the module vertices in a graph loaded over RPC, leaving
+// out the root module
+func isModuleVertex(meta *node.Node) bool {
+	vertex, ok := meta.Value().(*pb.GraphComponent_Vertex)
+	return ok && vertex.Kind == "module" && !graph.IsRoot(meta.ID)
+}
@@ -78,9 +78,12 @@ not display healthy checks.`,
 			stream, err := client.HealthCheck(
 				ctx,
 				&pb.LoadRequest{
-					Location:   fname,
-					Parameters: rpcParams,
-					Verify:     verifyModules,
+					Location:     fname,
+					Parameters:   rpcParams,
+					Verify:       verifyModules,
+					Parallelism:  getParallelism(),
+					StrictRender: getStrictRender(),
+					StrictRefs:   getStrictRefs(),
 				},
 			)
 			if err != nil {
@@ -149,6 +152,9 @@ func init() {
 	registerLocalRPCFlags(healthcheckCmd.Flags())
 	registerSSLFlags(healthcheckCmd.Flags())
 	registerParamsFlags(healthcheckCmd.Flags())
+	registerParallelismFlags(healthcheckCmd.Flags())
+	registerStrictRenderFlags(healthcheckCmd.Flags())
+	registerStrictRefsFlags(healthcheckCmd.Flags())
 
 	RootCmd.AddCommand(healthcheckCmd)
 }
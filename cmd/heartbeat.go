@@ -0,0 +1,39 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const heartbeatFlagName = "heartbeat"
+
+func registerHeartbeatFlags(flags *pflag.FlagSet) {
+	flags.Duration(heartbeatFlagName, 30*time.Second, "report nodes which are still running after this long, and again each time it passes (0 to disable)")
+}
+
+func getHeartbeat() int32 { return heartbeatSeconds(viper.GetDuration(heartbeatFlagName)) }
+
+// heartbeatSeconds returns a heartbeat interval in whole seconds, rounding up
+// so that short intervals don't disable heartbeats
+func heartbeatSeconds(interval time.Duration) int32 {
+	if interval <= 0 {
+		return 0
+	}
+	return int32((interval + time.Second - 1) / time.Second)
+}
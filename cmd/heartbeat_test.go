@@ -0,0 +1,39 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeartbeatSeconds(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		interval time.Duration
+		seconds  int32
+	}{
+		{0, 0},
+		{-time.Second, 0},
+		{500 * time.Millisecond, 1},
+		{30 * time.Second, 30},
+		{90500 * time.Millisecond, 91},
+	} {
+		assert.Equal(t, tc.seconds, heartbeatSeconds(tc.interval), tc.interval.String())
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/journal"
+	"github.com/spf13/cobra"
+)
+
+// journalShowCmd represents the journal show command
+var journalShowCmd = &cobra.Command{
+	Use:   "show [journal]",
+	Short: "reconstruct what an apply did from its journal",
+	Long: `show reads a journal written with --journal-dir and reports which nodes
+finished, what they changed, and which were still running when the journal
+ends. After a crash or reboot this tells you what was and wasn't done:
+
+		converge apply --local --journal-dir=/var/lib/converge/journal myFile.hcl
+		converge journal show --journal-dir=/var/lib/converge/journal
+
+Without an argument, the most recent journal in --journal-dir is shown.`,
+
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 1 {
+			return fmt.Errorf("Need at most one journal filename as argument, got %d", len(args))
+		}
+		if len(args) == 0 && getJournalDir() == "" {
+			return fmt.Errorf("Need a journal filename or --%s", journalDirFlagName)
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		var fname string
+		if len(args) == 1 {
+			fname = args[0]
+		} else {
+			latest, err := journal.Latest(getJournalDir())
+			if err != nil {
+				log.WithError(err).Fatal("could not find journal")
+			}
+			fname = latest
+		}
+
+		flog := log.WithField("file", fname)
+
+		entries, err := journal.ReadFile(fname)
+		if err != nil {
+			flog.WithError(err).Fatal("could not read journal")
+		}
+
+		fmt.Print(journal.Summarize(entries).String())
+	},
+}
+
+func init() {
+	journalCmd.AddCommand(journalShowCmd)
+	registerJournalFlags(journalShowCmd.Flags())
+}
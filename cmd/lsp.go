@@ -0,0 +1,58 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/changewindow"
+	"github.com/asteris-llc/converge/lsp"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+// lspCmd represents the lsp command
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "run a language server for editing modules",
+	Long: `run a Language Server Protocol server on stdin and stdout, for editors
+to report problems in modules, document resources and fields on hover, and
+jump to included modules and the targets of params and lookups.
+
+Logs are written to stderr so they don't interfere with the protocol.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// set up execution context
+		ctx, cancel := context.WithCancel(context.Background())
+		GracefulExit(cancel)
+
+		ctx = changewindow.WithNamed(ctx, getChangeWindows())
+
+		llog := log.WithField("component", "lsp")
+
+		server, err := lsp.New(os.Stdin, os.Stdout)
+		if err != nil {
+			llog.WithError(err).Fatal("could not start language server")
+		}
+
+		if err := server.Serve(ctx); err != nil {
+			llog.WithError(err).Fatal("language server stopped")
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(lspCmd)
+}
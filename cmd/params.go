@@ -17,9 +17,11 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/inventory"
 	"github.com/asteris-llc/converge/render"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -28,10 +30,20 @@ import (
 // Once the command line options are parsed, these will hold real values
 var paramsJSON string
 var params []string
+var inventoryFile string
+var inventoryHost string
 
 func registerParamsFlags(flags *pflag.FlagSet) {
+	registerFleetParamsFlags(flags)
+	flags.StringVar(&inventoryHost, "host", "", "host to take inventory parameters for (default this machine's hostname)")
+}
+
+// registerFleetParamsFlags registers the params flags for commands that apply
+// to several hosts, which take inventory parameters for each host in turn
+func registerFleetParamsFlags(flags *pflag.FlagSet) {
 	flags.StringVar(&paramsJSON, "paramsJSON", "{}", "parameters for the top-level module, in JSON format")
 	flags.StringSliceVarP(&params, "params", "p", []string{}, "parameters for the top-level module in key=value format")
+	flags.StringVar(&inventoryFile, "inventory", "", "inventory file with host and group parameters for the top-level module")
 }
 
 // parseKVPair parses an input of the form "key=value" into its
@@ -72,6 +84,23 @@ func parseKVPairs(pairs []string) (values render.Values, errors []error) {
 }
 
 func getParamsFromFlags(flags *pflag.FlagSet) (vals render.Values, errors []error) {
+	vals, errors = getCommandLineParams()
+
+	// parameters from the inventory are overridden by the ones above
+	if inventoryFile != "" {
+		invParams, err := getInventoryParams(inventoryFile, inventoryHost)
+		if err != nil {
+			errors = append(errors, err)
+		}
+		vals = withInventoryParams(vals, invParams)
+	}
+
+	return vals, errors
+}
+
+// getCommandLineParams gets the parameters passed to --params and
+// --paramsJSON
+func getCommandLineParams() (vals render.Values, errors []error) {
 	// get parameters passed to the --params flag
 	vals, errors = parseKVPairs(params)
 
@@ -95,6 +124,39 @@ func getParamsFromFlags(flags *pflag.FlagSet) (vals render.Values, errors []erro
 	return vals, errors
 }
 
+// withInventoryParams returns a copy of vals with the inventory parameters
+// added. Values already in vals take precedence.
+func withInventoryParams(vals render.Values, invParams map[string]interface{}) render.Values {
+	out := make(render.Values, len(vals)+len(invParams))
+	for key, value := range invParams {
+		out[key] = value
+	}
+	for key, value := range vals {
+		out[key] = value
+	}
+	return out
+}
+
+// getInventoryParams loads the params for a host from an inventory file. An
+// empty host means this machine.
+func getInventoryParams(filename, host string) (map[string]interface{}, error) {
+	inv, err := inventory.Load(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if host == "" {
+		host, err = os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("could not determine hostname for inventory: %s", err)
+		}
+	}
+
+	log.WithField("host", host).WithField("inventory", filename).Debug("using inventory parameters")
+
+	return inv.Params(host), nil
+}
+
 // getParams wraps getParamsFromFlags, logging and exiting upon error
 func getParams(cmd *cobra.Command) render.Values {
 	params, errors := getParamsFromFlags(cmd.Flags())
@@ -110,8 +172,11 @@ func getParams(cmd *cobra.Command) render.Values {
 }
 
 func getParamsRPC(cmd *cobra.Command) map[string]string {
-	params := getParams(cmd)
+	return stringifyParams(getParams(cmd))
+}
 
+// stringifyParams converts parameters to the string values sent over RPC
+func stringifyParams(params render.Values) map[string]string {
 	clientParams := map[string]string{}
 	for k, v := range params {
 		clientParams[k] = fmt.Sprintf("%v", v)
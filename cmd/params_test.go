@@ -15,11 +15,15 @@
 package cmd
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/asteris-llc/converge/render"
 	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // set up a FlagSet for testing
@@ -116,3 +120,36 @@ func TestMultipleArgs(t *testing.T) {
 	assert.Len(t, values, 2)
 	assert.Len(t, errors, 0)
 }
+
+// test that inventory parameters apply to the given host and are overridden by
+// --params
+func TestInventoryParameters(t *testing.T) {
+	dir, err := ioutil.TempDir("", "converge-params")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "inventory.hcl")
+	require.NoError(t, ioutil.WriteFile(filename, []byte(`
+vars { key1 = "all" }
+group "web" {
+  hosts = ["web*"]
+  vars { key2 = "web" }
+}
+host "web1" {
+  vars { key3 = "web1" }
+}
+`), 0644))
+
+	flagSet := pflag.NewFlagSet("", pflag.PanicOnError)
+	registerParamsFlags(flagSet)
+	require.NoError(t, flagSet.Parse([]string{"--inventory", filename, "--host", "web1", "-p", "key1=flag"}))
+	values, errors := getParamsFromFlags(flagSet)
+	assert.Empty(t, errors)
+	assert.EqualValues(t, render.Values{"key1": "flag", "key2": "web", "key3": "web1"}, values)
+
+	flagSet = pflag.NewFlagSet("", pflag.PanicOnError)
+	registerParamsFlags(flagSet)
+	require.NoError(t, flagSet.Parse([]string{"--inventory", filepath.Join(dir, "missing.hcl")}))
+	_, errors = getParamsFromFlags(flagSet)
+	assert.Len(t, errors, 1)
+}
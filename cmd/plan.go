@@ -20,6 +20,7 @@ import (
 	"os"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/errcode"
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/helpers/logging"
@@ -78,9 +79,12 @@ can be done separately to see what needs to be changed before execution.`,
 			stream, err := client.Plan(
 				ctx,
 				&pb.LoadRequest{
-					Location:   fname,
-					Parameters: rpcParams,
-					Verify:     verifyModules,
+					Location:     fname,
+					Parameters:   rpcParams,
+					Verify:       verifyModules,
+					Parallelism:  getParallelism(),
+					StrictRender: getStrictRender(),
+					StrictRefs:   getStrictRefs(),
 				},
 			)
 			if err != nil {
@@ -105,6 +109,12 @@ can be done separately to see what needs to be changed before execution.`,
 
 			// get vertices
 			var planError bool
+			warnings := new(warningCollector)
+			destructive := new(destructiveCollector)
+			explain := newExplainCollector(getExplain())
+			tracker := newRunTracker(edges)
+			watchCtx, stopWatching := context.WithCancel(ctx)
+			dumpStatusOnSignal(watchCtx, tracker, timer.Bypass())
 			err = iterateOverStream(
 				stream,
 				func(resp *pb.StatusResponse) {
@@ -116,10 +126,12 @@ can be done separately to see what needs to be changed before execution.`,
 					switch resp.Run {
 					case pb.StatusResponse_STARTED:
 						timer.AddTimer(resp.Meta.Id + ": " + resp.Stage.String())
+						tracker.Start(resp.Meta.Id, resp.Stage.String())
 						slog.Info("got status")
 
 					case pb.StatusResponse_FINISHED:
 						timer.RemoveTimer(resp.Meta.Id + ": " + resp.Stage.String())
+						tracker.Finish(resp.Meta.Id)
 						slog.Debug("got status")
 
 						details := resp.GetDetails()
@@ -129,6 +141,9 @@ can be done separately to see what needs to be changed before execution.`,
 								planError = true
 							}
 							g.Add(node.New(resp.Id, printable))
+							warnings.Add(resp.Id, printable)
+							destructive.Add(resp.Id, printable)
+							explain.Add(resp.Id, printable)
 						}
 
 					default:
@@ -137,11 +152,20 @@ can be done separately to see what needs to be changed before execution.`,
 				},
 			)
 
+			stopWatching()
 			timer.Stop()
 			flog.Logger.Out = oldOut
 
 			if err != nil {
-				flog.WithError(err).Fatal("could not get responses")
+				if ctx.Err() == nil {
+					flog.WithError(err).Fatal("could not get responses")
+				}
+
+				flog.Warn("interrupted, waiting for running tasks to stop")
+				waitForSelfHostedRPC()
+
+				tracker.Interrupt(g, errcode.PlanInterrupted)
+				planError = true
 			}
 
 			// validate resulting graph
@@ -155,11 +179,37 @@ can be done separately to see what needs to be changed before execution.`,
 				flog.WithError(err).Fatal("failed to print results")
 			}
 
+			if destructive.Len() > 0 {
+				fmt.Print("\n")
+				fmt.Print(destructive)
+			}
+
 			fmt.Print("\n")
 			fmt.Print(out)
+
+			if explain.Enabled() {
+				fmt.Print("\n")
+				fmt.Print(explain)
+			}
+
+			if id := getExplainDeps(); id != "" {
+				fmt.Print("\n")
+				fmt.Print(explainDeps(id, edges))
+			}
+
+			warnings.CheckParams(g, rpcParams)
+			if warnings.Len() > 0 {
+				fmt.Print("\n")
+				fmt.Print(warnings)
+			}
+
 			if planError {
 				os.Exit(1)
 			}
+			if getStrict() && warnings.Len() > 0 {
+				flog.Error("warnings are treated as errors in strict mode")
+				os.Exit(1)
+			}
 		}
 	},
 }
@@ -172,6 +222,13 @@ func init() {
 	registerLocalRPCFlags(planCmd.Flags())
 	registerSSLFlags(planCmd.Flags())
 	registerParamsFlags(planCmd.Flags())
+	registerStatusDumpFlags(planCmd.Flags())
+	registerWarningFlags(planCmd.Flags())
+	registerParallelismFlags(planCmd.Flags())
+	registerStrictRenderFlags(planCmd.Flags())
+	registerStrictRefsFlags(planCmd.Flags())
+	registerExplainFlags(planCmd.Flags())
+	registerExplainDepsFlags(planCmd.Flags())
 
 	RootCmd.AddCommand(planCmd)
 }
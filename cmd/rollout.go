@@ -0,0 +1,322 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/fleet"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/inventory"
+	"github.com/asteris-llc/converge/quarantine"
+	"github.com/asteris-llc/converge/render"
+	"github.com/asteris-llc/converge/rpc"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+)
+
+// rolloutCmd represents the rollout command
+var rolloutCmd = &cobra.Command{
+	Use:   "rollout",
+	Short: "apply a module to several hosts, canaries first",
+	Long: `rollout applies a module to the RPC servers of several hosts. It
+applies to a canary share of the hosts first, and only continues to the rest
+when enough of the canaries succeed. A host succeeds when the apply has no
+errors and, with --healthcheck, its health check passes afterwards.
+
+Hosts are given with --hosts as host or host:port. Without --hosts, the hosts
+named in the inventory are used. Inventory parameters are taken for each host
+by name.
+
+With --quarantine-file, quarantined hosts are left out and reported as such,
+and quarantined resources are skipped on the hosts they apply to.`,
+	Example: `converge rollout --hosts web1,web2,web3,web4 --canary 25% module.hcl`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("Need at least one module filename as argument, got 0")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		// set up execution context
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		GracefulExit(cancel)
+
+		// logging
+		clog := log.WithField("component", "client")
+		ctx = logging.WithLogger(ctx, clog)
+
+		maybeSetToken()
+
+		rollout, err := getRollout()
+		if err != nil {
+			clog.WithError(err).Fatal("invalid rollout")
+		}
+
+		var inv *inventory.Inventory
+		if inventoryFile != "" {
+			inv, err = inventory.Load(inventoryFile)
+			if err != nil {
+				clog.WithError(err).Fatal("could not load inventory")
+			}
+		}
+
+		hosts, err := cmd.Flags().GetStringSlice("hosts")
+		if err != nil {
+			clog.WithError(err).Fatal("could not get hosts")
+		}
+
+		targets, err := getRolloutTargets(hosts, inv)
+		if err != nil {
+			clog.WithError(err).Fatal("invalid hosts")
+		}
+
+		quarantined := new(quarantine.List)
+		if file := getQuarantineFile(); file != "" {
+			quarantined, err = quarantine.Load(file)
+			if err != nil {
+				clog.WithError(err).Fatal("could not load quarantine")
+			}
+		}
+		rollout.Quarantine = func(target fleet.Target) (string, bool) {
+			entry, ok := quarantined.Host(targetHost(target))
+			if !ok {
+				return "", false
+			}
+			return entry.Reason, true
+		}
+
+		params, errs := getCommandLineParams()
+		for _, err := range errs {
+			clog.WithError(err).Fatal("error while parsing parameters")
+		}
+
+		verifyModules := viper.GetBool("verify-modules")
+		if !verifyModules {
+			clog.Warn("skipping module verification")
+		}
+
+		apply := &rolloutApplier{
+			files:       args,
+			params:      params,
+			inventory:   inv,
+			quarantine:  quarantined,
+			verify:      verifyModules,
+			healthcheck: viper.GetBool("healthcheck"),
+			security:    getSecurityConfig(),
+		}
+
+		report := rollout.Run(ctx, targets, apply.Apply)
+
+		fmt.Print("\n")
+		fmt.Print(report)
+
+		if !report.OK() {
+			os.Exit(1)
+		}
+	},
+}
+
+// getRollout builds a rollout from the command line flags
+func getRollout() (*fleet.Rollout, error) {
+	canary, err := fleet.ParseAmount(viper.GetString("canary"))
+	if err != nil {
+		return nil, fmt.Errorf("canary: %s", err)
+	}
+
+	threshold, err := fleet.ParseAmount(viper.GetString("threshold"))
+	if err != nil {
+		return nil, fmt.Errorf("threshold: %s", err)
+	}
+
+	return &fleet.Rollout{
+		Canary:      canary,
+		Threshold:   threshold,
+		Parallelism: viper.GetInt("host-parallelism"),
+	}, nil
+}
+
+// getRolloutTargets turns host or host:port entries into targets named as
+// given, using the default RPC port when none is given. Without any hosts, the hosts named in
+// the inventory are used in sorted order.
+func getRolloutTargets(hosts []string, inv *inventory.Inventory) ([]fleet.Target, error) {
+	if len(hosts) == 0 && inv != nil {
+		for name := range inv.Hosts {
+			hosts = append(hosts, name)
+		}
+		sort.Strings(hosts)
+	}
+	if len(hosts) == 0 {
+		return nil, errors.New("no hosts given with --hosts or in the inventory")
+	}
+
+	_, defaultPort, err := net.SplitHostPort(addrServer)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	targets := make([]fleet.Target, 0, len(hosts))
+	for _, host := range hosts {
+		name, port, err := net.SplitHostPort(host)
+		if err != nil {
+			name, port = host, defaultPort
+		}
+		if name == "" {
+			return nil, fmt.Errorf("host %q has no name", host)
+		}
+
+		addr := net.JoinHostPort(name, port)
+		if seen[addr] {
+			return nil, fmt.Errorf("duplicate host %q", host)
+		}
+		seen[addr] = true
+
+		targets = append(targets, fleet.Target{Name: host, Addr: addr})
+	}
+
+	return targets, nil
+}
+
+// rolloutApplier applies modules to a single host over RPC
+type rolloutApplier struct {
+	files       []string
+	params      render.Values
+	inventory   *inventory.Inventory
+	quarantine  *quarantine.List
+	verify      bool
+	healthcheck bool
+	security    *rpc.Security
+}
+
+// Apply applies the modules to a target, and then health checks them if
+// asked to
+func (a *rolloutApplier) Apply(ctx context.Context, target fleet.Target) error {
+	logger := logging.GetLogger(ctx).WithField("host", target.Name)
+
+	client, err := rpc.NewExecutorClient(ctx, target.Addr, a.security)
+	if err != nil {
+		return fmt.Errorf("could not connect: %s", err)
+	}
+
+	host := targetHost(target)
+
+	params := a.params
+	if a.inventory != nil {
+		params = withInventoryParams(params, a.inventory.Params(host))
+	}
+
+	var quarantined map[string]string
+	if a.quarantine != nil {
+		quarantined = a.quarantine.Resources(host)
+	}
+
+	for _, fname := range a.files {
+		flog := logger.WithField("file", fname)
+		req := &pb.LoadRequest{
+			Location:         fname,
+			Parameters:       stringifyParams(params),
+			Verify:           a.verify,
+			AllowDestructive: getAllowDestructive(),
+			Parallelism:      getParallelism(),
+			StrictRender:     getStrictRender(),
+			StrictRefs:       getStrictRefs(),
+			Quarantine:       quarantined,
+		}
+
+		flog.Info("applying")
+		stream, err := client.Apply(ctx, req)
+		if err != nil {
+			return fmt.Errorf("%s: could not apply: %s", fname, err)
+		}
+		if failed, err := countFailedNodes(stream); err != nil {
+			return fmt.Errorf("%s: %s", fname, err)
+		} else if failed > 0 {
+			return fmt.Errorf("%s: %d nodes failed to apply", fname, failed)
+		}
+
+		if !a.healthcheck {
+			continue
+		}
+
+		flog.Info("checking health")
+		health, err := client.HealthCheck(ctx, req)
+		if err != nil {
+			return fmt.Errorf("%s: could not check health: %s", fname, err)
+		}
+		if failed, err := countFailedNodes(health); err != nil {
+			return fmt.Errorf("%s: %s", fname, err)
+		} else if failed > 0 {
+			return fmt.Errorf("%s: %d health checks failed", fname, failed)
+		}
+	}
+
+	return nil
+}
+
+// targetHost is the host name of a target, without any port
+func targetHost(target fleet.Target) string {
+	host, _, err := net.SplitHostPort(target.Name)
+	if err != nil {
+		return target.Name
+	}
+	return host
+}
+
+// countFailedNodes reads a status stream to the end and counts the nodes that
+// finished with an error
+func countFailedNodes(stream recver) (int, error) {
+	failed := map[string]bool{}
+	err := iterateOverStream(
+		stream,
+		func(resp *pb.StatusResponse) {
+			if resp.Run != pb.StatusResponse_FINISHED {
+				return
+			}
+			if details := resp.GetDetails(); details != nil && details.Error != "" {
+				failed[resp.Id] = true
+			}
+		},
+	)
+	return len(failed), err
+}
+
+func init() {
+	rolloutCmd.Flags().StringSlice("hosts", []string{}, "hosts to apply to, as host or host:port")
+	rolloutCmd.Flags().String("canary", "10%", "hosts to apply to first, as a count or a percentage (0 for no canaries)")
+	rolloutCmd.Flags().String("threshold", "100%", "canaries that must succeed before continuing, as a count or a percentage of the canaries")
+	rolloutCmd.Flags().Int("host-parallelism", 1, "apply to at most this many hosts at once (0 for no limit)")
+	rolloutCmd.Flags().Bool("healthcheck", false, "run a health check after applying, and count unhealthy hosts as failed")
+	rolloutCmd.Flags().Bool("verify-modules", false, "verify module signatures")
+	rolloutCmd.Flags().String(rpcTokenFlagName, "", "token for RPC")
+	rolloutCmd.Flags().Bool(rpcNoTokenFlagName, false, "don't use or generate an RPC token")
+	registerClientSSLFlags(rolloutCmd.Flags())
+	registerFleetParamsFlags(rolloutCmd.Flags())
+	registerDestructiveFlags(rolloutCmd.Flags())
+	registerParallelismFlags(rolloutCmd.Flags())
+	registerStrictRenderFlags(rolloutCmd.Flags())
+	registerStrictRefsFlags(rolloutCmd.Flags())
+	registerQuarantineFlags(rolloutCmd.Flags())
+
+	RootCmd.AddCommand(rolloutCmd)
+}
@@ -0,0 +1,108 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io"
+	"testing"
+
+	"github.com/asteris-llc/converge/fleet"
+	"github.com/asteris-llc/converge/inventory"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRolloutTargets(t *testing.T) {
+	t.Parallel()
+
+	t.Run("hosts", func(t *testing.T) {
+		targets, err := getRolloutTargets([]string{"web1", "web2:4800"}, nil)
+
+		require.NoError(t, err)
+		assert.Equal(
+			t,
+			[]fleet.Target{
+				{Name: "web1", Addr: "web1:4774"},
+				{Name: "web2:4800", Addr: "web2:4800"},
+			},
+			targets,
+		)
+	})
+
+	t.Run("from inventory", func(t *testing.T) {
+		inv, err := inventory.Parse([]byte(`host "web2" {}` + "\n" + `host "web1" {}`))
+		require.NoError(t, err)
+
+		targets, err := getRolloutTargets(nil, inv)
+
+		require.NoError(t, err)
+		require.Len(t, targets, 2)
+		assert.Equal(t, "web1", targets[0].Name)
+		assert.Equal(t, "web2", targets[1].Name)
+	})
+
+	t.Run("no hosts", func(t *testing.T) {
+		_, err := getRolloutTargets(nil, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		_, err := getRolloutTargets([]string{"web1", "web1:4774"}, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("no name", func(t *testing.T) {
+		_, err := getRolloutTargets([]string{":4774"}, nil)
+		assert.Error(t, err)
+	})
+}
+
+// fakeStream replays status responses
+type fakeStream struct {
+	responses []*pb.StatusResponse
+}
+
+func (f *fakeStream) Recv() (*pb.StatusResponse, error) {
+	if len(f.responses) == 0 {
+		return nil, io.EOF
+	}
+	resp := f.responses[0]
+	f.responses = f.responses[1:]
+	return resp, nil
+}
+
+func TestCountFailedNodes(t *testing.T) {
+	t.Parallel()
+
+	finished := func(id, err string) *pb.StatusResponse {
+		return &pb.StatusResponse{
+			Id:      id,
+			Run:     pb.StatusResponse_FINISHED,
+			Details: &pb.StatusResponse_Details{Error: err},
+		}
+	}
+
+	failed, err := countFailedNodes(&fakeStream{responses: []*pb.StatusResponse{
+		{Id: "root/task.a", Run: pb.StatusResponse_STARTED},
+		finished("root/task.a", "failed"),
+		finished("root/task.a", "failed again"),
+		finished("root/task.b", ""),
+		finished("root", "error in dependency"),
+	}})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, failed)
+}
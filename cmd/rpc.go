@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net"
 	"net/url"
@@ -26,6 +27,7 @@ import (
 
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/publish"
 	"github.com/asteris-llc/converge/rpc"
 	"github.com/asteris-llc/converge/rpc/pb"
 	"github.com/pkg/errors"
@@ -38,6 +40,24 @@ const (
 	rpcAddrFlagName    = "rpc-addr"
 	rpcLocalAddrName   = "local-addr"
 	rpcEnableLocalName = "local"
+
+	dumpGraphDirFlagName = "dump-graph-dir"
+
+	journalDirFlagName = "journal-dir"
+
+	quarantineFileFlagName = "quarantine-file"
+
+	publishURLFlagName = "publish-url"
+
+	artifactCacheFlagName      = "artifact-cache"
+	artifactCacheTTLFlagName   = "artifact-cache-ttl"
+	artifactCacheHostsFlagName = "artifact-cache-hosts"
+	artifactProxyFlagName      = "artifact-proxy"
+	artifactProxyTokenFlagName = "artifact-proxy-token"
+
+	// changeWindowsConfigName is the config key of the named change windows.
+	// It can only be set in the config file.
+	changeWindowsConfigName = "change-windows"
 )
 
 func registerRPCFlags(flags *pflag.FlagSet) {
@@ -50,11 +70,89 @@ func registerRPCFlags(flags *pflag.FlagSet) {
 func registerLocalRPCFlags(flags *pflag.FlagSet) {
 	flags.String(rpcLocalAddrName, addrServerLocal, "address for local RPC connection")
 	flags.Bool(rpcEnableLocalName, false, "self host RPC")
+	registerDumpGraphFlags(flags)
+	registerJournalFlags(flags)
+	registerQuarantineFlags(flags)
+	registerPublishFlags(flags)
+	registerArtifactProxyFlags(flags)
+}
+
+func registerArtifactCacheFlags(flags *pflag.FlagSet) {
+	flags.String(artifactCacheFlagName, "", "cache artifacts downloaded for agents in this directory")
+	flags.Duration(artifactCacheTTLFlagName, 0, "download cached artifacts again after this long (0 keeps them until removed)")
+	flags.StringSlice(artifactCacheHostsFlagName, nil, "upstream hosts the artifact cache fetches from (\"*.example.com\" matches subdomains)")
+}
+
+func registerArtifactProxyFlags(flags *pflag.FlagSet) {
+	flags.String(artifactProxyFlagName, "", "download artifacts through the artifact cache of the server at this URL")
+	flags.String(artifactProxyTokenFlagName, "", "RPC token of the server with the artifact cache")
+}
+
+// validateArtifactCache checks that an artifact cache knows which hosts it
+// may fetch from, so it can't be used as an open proxy
+func validateArtifactCache() error {
+	if getArtifactCacheDir() != "" && len(getArtifactCacheHosts()) == 0 {
+		return fmt.Errorf("--%s requires --%s", artifactCacheFlagName, artifactCacheHostsFlagName)
+	}
+	return nil
+}
+
+// validateArtifactProxy checks the artifact proxy URL, so a bad one is
+// reported before anything is applied
+func validateArtifactProxy() error {
+	if getArtifactProxy() == "" {
+		return nil
+	}
+	u, err := url.Parse(getArtifactProxy())
+	if err != nil {
+		return errors.Wrap(err, "invalid artifact proxy")
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid artifact proxy %q: must be an http or https URL", getArtifactProxy())
+	}
+	return nil
+}
+
+func registerPublishFlags(flags *pflag.FlagSet) {
+	flags.String(publishURLFlagName, "", "publish a summary of each apply to this consul:// or etcd:// URL")
+}
+
+// validatePublishURL checks the publish URL, so a bad one is reported before
+// anything is applied
+func validatePublishURL() error {
+	if getPublishURL() == "" {
+		return nil
+	}
+	_, err := publish.New(getPublishURL())
+	return err
+}
+
+func registerQuarantineFlags(flags *pflag.FlagSet) {
+	flags.String(quarantineFileFlagName, "", "skip the hosts and resources quarantined in this file")
+}
+
+func registerJournalFlags(flags *pflag.FlagSet) {
+	flags.String(journalDirFlagName, "", "write a crash-safe journal of each apply to this directory")
 }
 
+func registerDumpGraphFlags(flags *pflag.FlagSet) {
+	flags.String(dumpGraphDirFlagName, "", "write a snapshot of the graph after each load phase to this directory")
+}
+
+// selfHosted is the self-hosted RPC server, if one was started
+var selfHosted *rpc.Server
+
 func maybeStartSelfHostedRPC(ctx context.Context) error {
 	if getLocal() {
-		go startRPC(ctx)
+		if err := validatePublishURL(); err != nil {
+			return err
+		}
+		if err := validateArtifactProxy(); err != nil {
+			return err
+		}
+
+		selfHosted = newRPCServer()
+		go startRPC(ctx, selfHosted)
 
 		var err error
 		for i := 0; i < 5; i++ {
@@ -71,7 +169,35 @@ func maybeStartSelfHostedRPC(ctx context.Context) error {
 	return nil
 }
 
-func startRPC(ctx context.Context) error {
+// waitForSelfHostedRPC blocks until the self-hosted RPC server, if any, has
+// finished its in-flight executions. The server stops them when the context
+// it was started with is cancelled, so call this after cancelling and before
+// exiting.
+func waitForSelfHostedRPC() {
+	if selfHosted != nil {
+		selfHosted.Wait()
+	}
+}
+
+func newRPCServer() *rpc.Server {
+	return &rpc.Server{
+		Security:             getSecurityConfig(),
+		ResourceRoot:         viper.GetString("root"),
+		EnableBinaryDownload: viper.GetBool("self-serve"),
+		DumpGraphDir:         getDumpGraphDir(),
+		JournalDir:           getJournalDir(),
+		QuarantineFile:       getQuarantineFile(),
+		ChangeWindows:        getChangeWindows(),
+		PublishURL:           getPublishURL(),
+		ArtifactCacheDir:     getArtifactCacheDir(),
+		ArtifactCacheTTL:     getArtifactCacheTTL(),
+		ArtifactCacheHosts:   getArtifactCacheHosts(),
+		ArtifactProxy:        getArtifactProxy(),
+		ArtifactProxyToken:   getArtifactProxyToken(),
+	}
+}
+
+func startRPC(ctx context.Context, server *rpc.Server) error {
 	// set context for logging
 	logger := logging.GetLogger(ctx).WithField("component", "rpc")
 	ctx = logging.WithLogger(ctx, logger)
@@ -83,14 +209,12 @@ func startRPC(ctx context.Context) error {
 		logger.Warning("no SSL config in use, server will accept unencrypted connections")
 	}
 
-	// create server
-	server := &rpc.Server{
-		Security:             getSecurityConfig(),
-		ResourceRoot:         viper.GetString("root"),
-		EnableBinaryDownload: viper.GetBool("self-serve"),
-	}
+	err := server.Listen(ctx, loc)
 
-	return server.Listen(ctx, loc)
+	// let in-flight executions stop their tasks before returning
+	server.Wait()
+
+	return err
 }
 
 func getRPCExecutorClient(ctx context.Context, security *rpc.Security) (pb.ExecutorClient, error) {
@@ -158,6 +282,34 @@ func getLocal() bool       { return viper.GetBool(rpcEnableLocalName) }
 func getLocalAddr() string { return viper.GetString(rpcLocalAddrName) }
 func getRPCAddr() string   { return viper.GetString(rpcAddrFlagName) }
 
+func getDumpGraphDir() string { return viper.GetString(dumpGraphDirFlagName) }
+func getJournalDir() string   { return viper.GetString(journalDirFlagName) }
+
+func getQuarantineFile() string { return viper.GetString(quarantineFileFlagName) }
+
+func getPublishURL() string { return viper.GetString(publishURLFlagName) }
+
+func getArtifactCacheDir() string        { return viper.GetString(artifactCacheFlagName) }
+func getArtifactCacheTTL() time.Duration { return viper.GetDuration(artifactCacheTTLFlagName) }
+func getArtifactProxy() string           { return viper.GetString(artifactProxyFlagName) }
+func getArtifactProxyToken() string      { return viper.GetString(artifactProxyTokenFlagName) }
+
+// getArtifactCacheHosts gets the upstream hosts of the artifact cache. Viper
+// only sees the flag as its string form, "[a,b]", so that is split up here.
+func getArtifactCacheHosts() []string {
+	var hosts []string
+	for _, value := range viper.GetStringSlice(artifactCacheHostsFlagName) {
+		for _, host := range strings.Split(strings.Trim(value, "[]"), ",") {
+			if host = strings.TrimSpace(host); host != "" {
+				hosts = append(hosts, host)
+			}
+		}
+	}
+	return hosts
+}
+
+func getChangeWindows() map[string]string { return viper.GetStringMapString(changeWindowsConfigName) }
+
 func getServerURL() *url.URL {
 	out := new(url.URL)
 
@@ -0,0 +1,165 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/asteris-llc/converge/errcode"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/rpc/pb"
+)
+
+// runTracker keeps track of which nodes have started and finished in a run, so
+// that the state of the run can be reported while it's in progress or after it
+// was interrupted
+type runTracker struct {
+	ids      []string
+	begun    time.Time
+	started  map[string]trackedNode
+	finished map[string]struct{}
+
+	lock sync.RWMutex
+}
+
+// trackedNode is a node that has started running
+type trackedNode struct {
+	Stage  string
+	Start  time.Time
+	Output string
+}
+
+// newRunTracker tracks a run over the nodes named in the edges
+func newRunTracker(edges []*graph.Edge) *runTracker {
+	seen := map[string]struct{}{}
+	rt := &runTracker{
+		begun:    time.Now(),
+		started:  map[string]trackedNode{},
+		finished: map[string]struct{}{},
+	}
+
+	for _, edge := range edges {
+		for _, id := range []string{edge.Source, edge.Dest} {
+			if _, ok := seen[id]; !ok {
+				seen[id] = struct{}{}
+				rt.ids = append(rt.ids, id)
+			}
+		}
+	}
+	sort.Strings(rt.ids)
+
+	return rt
+}
+
+// Start records that a node has started the given stage
+func (rt *runTracker) Start(id, stage string) {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	rt.started[id] = trackedNode{Stage: stage, Start: time.Now()}
+}
+
+// Heartbeat records the last output of a node that is still running
+func (rt *runTracker) Heartbeat(id, output string) {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	if tracked, ok := rt.started[id]; ok && output != "" {
+		tracked.Output = output
+		rt.started[id] = tracked
+	}
+}
+
+// Finish records that a node has finished
+func (rt *runTracker) Finish(id string) {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	rt.finished[id] = struct{}{}
+}
+
+// Status describes the nodes which are running, how long they have been
+// running, and which nodes are still queued
+func (rt *runTracker) Status(now time.Time) string {
+	rt.lock.RLock()
+	defer rt.lock.RUnlock()
+
+	var running, queued []string
+	for _, id := range rt.ids {
+		if _, ok := rt.finished[id]; ok {
+			continue
+		}
+
+		if tracked, ok := rt.started[id]; ok {
+			line := fmt.Sprintf("%s: %s (running %s)", id, tracked.Stage, roundSeconds(now.Sub(tracked.Start)))
+			if tracked.Output != "" {
+				line += fmt.Sprintf(", last output: %q", tracked.Output)
+			}
+			running = append(running, line)
+		} else {
+			queued = append(queued, id)
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "Status at %s (running %s)\n", now.Format(time.RFC3339), roundSeconds(now.Sub(rt.begun)))
+
+	fmt.Fprintf(&out, "Running (%d):\n", len(running))
+	for _, line := range running {
+		fmt.Fprintf(&out, "  %s\n", line)
+	}
+
+	fmt.Fprintf(&out, "Queued (%d):\n", len(queued))
+	for _, id := range queued {
+		fmt.Fprintf(&out, "  %s\n", id)
+	}
+
+	fmt.Fprintf(&out, "Finished: %d of %d\n", len(rt.finished), len(rt.ids))
+
+	return out.String()
+}
+
+// Interrupt adds a result for each tracked node which did not report one
+// before the run was interrupted. Nodes which started but did not finish are
+// reported as interrupted, and the rest as not run.
+func (rt *runTracker) Interrupt(g *graph.Graph, code errcode.Code) {
+	rt.lock.RLock()
+	defer rt.lock.RUnlock()
+
+	for _, id := range rt.ids {
+		if _, ok := g.Get(id); ok {
+			continue
+		}
+
+		details := &pb.StatusResponse_Details{
+			Error:     "not run because the run was interrupted",
+			ErrorCode: string(code),
+		}
+		if _, ok := rt.started[id]; ok {
+			details.Error = "interrupted while running"
+		}
+
+		g.Add(node.New(id, details.ToPrintable()))
+	}
+}
+
+func roundSeconds(d time.Duration) time.Duration {
+	return d - (d % time.Second)
+}
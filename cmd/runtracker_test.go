@@ -0,0 +1,104 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/errcode"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/prettyprinters/human"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var trackerEdges = []*graph.Edge{
+	{Source: "root", Dest: "root/a"},
+	{Source: "root", Dest: "root/b"},
+	{Source: "root", Dest: "root/c"},
+}
+
+func TestRunTrackerStatus(t *testing.T) {
+	t.Parallel()
+
+	tracker := newRunTracker(trackerEdges)
+	tracker.Start("root/a", "APPLY")
+	tracker.Finish("root/a")
+	tracker.Start("root/b", "APPLY")
+
+	now := time.Now().Add(90 * time.Second)
+	assert.Equal(
+		t,
+		"Status at "+now.Format(time.RFC3339)+" (running 1m30s)\n"+
+			"Running (1):\n"+
+			"  root/b: APPLY (running 1m30s)\n"+
+			"Queued (2):\n"+
+			"  root\n"+
+			"  root/c\n"+
+			"Finished: 1 of 4\n",
+		tracker.Status(now),
+	)
+}
+
+func TestRunTrackerHeartbeat(t *testing.T) {
+	t.Parallel()
+
+	tracker := newRunTracker(trackerEdges)
+	tracker.Start("root/b", "APPLY")
+	tracker.Heartbeat("root/b", "downloading")
+	tracker.Heartbeat("root/b", "")
+	tracker.Heartbeat("root/c", "not started")
+
+	assert.Contains(t, tracker.Status(time.Now()), `  root/b: APPLY (running 0s), last output: "downloading"`+"\n")
+	assert.NotContains(t, tracker.Status(time.Now()), "not started")
+}
+
+func TestRunTrackerInterrupt(t *testing.T) {
+	t.Parallel()
+
+	finished := (&pb.StatusResponse_Details{}).ToPrintable()
+
+	g := graph.New()
+	g.Add(node.New("root/a", finished))
+
+	tracker := newRunTracker(trackerEdges)
+	tracker.Start("root/a", "APPLY")
+	tracker.Finish("root/a")
+	tracker.Start("root/b", "APPLY")
+
+	tracker.Interrupt(g, errcode.ApplyInterrupted)
+
+	expected := map[string]string{
+		"root":   "not run because the run was interrupted",
+		"root/b": "interrupted while running",
+		"root/c": "not run because the run was interrupted",
+	}
+	for id, msg := range expected {
+		meta, ok := g.Get(id)
+		require.True(t, ok, "%q was not added", id)
+
+		err := meta.Value().(human.Printable).Error()
+		assert.EqualError(t, err, msg)
+
+		code, _ := errcode.Of(err)
+		assert.Equal(t, errcode.ApplyInterrupted, code)
+	}
+
+	meta, _ := g.Get("root/a")
+	assert.Equal(t, finished, meta.Value())
+}
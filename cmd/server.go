@@ -45,7 +45,14 @@ var serverCmd = &cobra.Command{
 			return errors.New("root should be a directory")
 		}
 
-		return nil
+		if err := validateArtifactCache(); err != nil {
+			return err
+		}
+		if err := validateArtifactProxy(); err != nil {
+			return err
+		}
+
+		return validatePublishURL()
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx, cancel := context.WithCancel(context.Background())
@@ -57,7 +64,7 @@ var serverCmd = &cobra.Command{
 		setLocal(false) // unset local so we get the right flag addresses
 
 		// start RPC server
-		if err := startRPC(ctx); err != nil {
+		if err := startRPC(ctx, newRPCServer()); err != nil {
 			log.WithError(err).Fatal("serving failed")
 		}
 	},
@@ -74,6 +81,16 @@ func init() {
 	serverCmd.Flags().String("root", ".", "location of modules to serve")
 	serverCmd.Flags().Bool("self-serve", false, "serve own binary for bootstrapping")
 
+	// debugging
+	registerDumpGraphFlags(serverCmd.Flags())
+	registerJournalFlags(serverCmd.Flags())
+	registerQuarantineFlags(serverCmd.Flags())
+	registerPublishFlags(serverCmd.Flags())
+
+	// artifacts
+	registerArtifactCacheFlags(serverCmd.Flags())
+	registerArtifactProxyFlags(serverCmd.Flags())
+
 	// set RPC logging to use logrus
 	grpclog.SetLogger(log.WithField("component", "grpc"))
 }
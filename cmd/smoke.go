@@ -0,0 +1,70 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/load"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+// smokeCmd represents the smoke command
+var smokeCmd = &cobra.Command{
+	Use:    "smoke [example files...]",
+	Hidden: true,
+	Short:  "prepare every resource to check that none of them panic",
+	Long: `smoke prepares every registered resource with zero values, and with each
+resource in the example files given as arguments. Resources are expected to
+return errors for bad input; any resource which panics instead is reported and
+the command exits non-zero.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		examples := map[string][]byte{}
+		for _, fname := range args {
+			content, err := ioutil.ReadFile(fname)
+			if err != nil {
+				log.WithError(err).WithField("file", fname).Fatal("could not read example")
+			}
+			examples[fname] = content
+		}
+
+		results, err := load.SmokePrepare(context.Background(), examples)
+		if err != nil {
+			log.WithError(err).Fatal("could not prepare resources")
+		}
+
+		var panicked int
+		for _, result := range results {
+			rlog := log.WithField("kind", result.Kind).WithField("source", result.Source)
+			if result.Panicked() {
+				panicked++
+				rlog.WithError(result.Err).Error("resource panicked")
+				continue
+			}
+			rlog.WithField("error", result.Err).Debug("prepared")
+		}
+
+		if panicked > 0 {
+			log.WithField("panicked", panicked).Fatal("resources panicked during prepare")
+		}
+		log.WithField("prepared", len(results)).Info("no resources panicked")
+	},
+}
+
+func init() {
+	genCmd.AddCommand(smokeCmd)
+}
@@ -0,0 +1,75 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+)
+
+const statusFileFlagName = "status-file"
+
+func registerStatusDumpFlags(flags *pflag.FlagSet) {
+	flags.String(statusFileFlagName, "", "write status dumps requested with SIGUSR1 to this file instead of stderr")
+}
+
+func getStatusFile() string { return viper.GetString(statusFileFlagName) }
+
+// dumpStatusOnSignal reports the status of a run each time a status signal is
+// received (SIGUSR1, where supported) until ctx is done. This lets a stuck run
+// be diagnosed without stopping it. The status is written to the file set with
+// --status-file, or to out if there is none.
+func dumpStatusOnSignal(ctx context.Context, tracker *runTracker, out io.Writer) {
+	if len(statusSignals) == 0 {
+		return
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, statusSignals...)
+
+	go func() {
+		defer signal.Stop(c)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-c:
+				if err := writeStatus(tracker.Status(time.Now()), out); err != nil {
+					log.WithError(err).Warn("could not write status")
+				}
+			}
+		}
+	}()
+}
+
+func writeStatus(status string, out io.Writer) error {
+	if fname := getStatusFile(); fname != "" {
+		return ioutil.WriteFile(fname, []byte(status), 0644)
+	}
+
+	_, err := fmt.Fprint(out, "\n"+status)
+	return err
+}
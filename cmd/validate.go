@@ -18,6 +18,9 @@ import (
 	"errors"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/changewindow"
+	"github.com/asteris-llc/converge/errcode"
+	"github.com/asteris-llc/converge/graph/snapshot"
 	"github.com/asteris-llc/converge/load"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -39,6 +42,9 @@ var validateCmd = &cobra.Command{
 		ctx, cancel := context.WithCancel(context.Background())
 		GracefulExit(cancel)
 
+		ctx = changewindow.WithNamed(ctx, getChangeWindows())
+		ctx = load.WithStrictRefs(ctx, getStrictRefs())
+
 		verifyModules := viper.GetBool("verify-modules")
 		if !verifyModules {
 			log.WithField("component", "client").Warn("skipping module verification")
@@ -47,9 +53,14 @@ var validateCmd = &cobra.Command{
 		for _, fname := range args {
 			flog := log.WithField("file", fname)
 
-			_, err := load.Load(ctx, fname, verifyModules)
+			fctx := ctx
+			if dir := getDumpGraphDir(); dir != "" {
+				fctx = snapshot.WithDumper(ctx, snapshot.NewDumper(dir, fname, nil))
+			}
+
+			_, err := load.Load(fctx, fname, verifyModules)
 			if err != nil {
-				flog.WithError(err).Fatal("could not parse file")
+				flog.WithError(errcode.WithMessage(err)).Fatal("could not parse file")
 			}
 
 			flog.Info("module valid")
@@ -59,5 +70,7 @@ var validateCmd = &cobra.Command{
 
 func init() {
 	validateCmd.Flags().Bool("verify-modules", false, "verify module signatures")
+	registerDumpGraphFlags(validateCmd.Flags())
+	registerStrictRefsFlags(validateCmd.Flags())
 	RootCmd.AddCommand(validateCmd)
 }
@@ -0,0 +1,72 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/errcode"
+	"github.com/asteris-llc/converge/load"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+)
+
+// vendorCmd represents the vendor command
+var vendorCmd = &cobra.Command{
+	Use:   "vendor",
+	Short: "download the remote modules of a module into its vendor directory",
+	Long: `vendor downloads the remote modules a module refers to, and the modules
+those refer to in turn, into a vendor directory next to the module. Vendored
+modules are used instead of their remote locations when the module is loaded,
+so it can be planned and applied without network access. Run vendor again to
+update them.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("Need at least one module filename as argument, got 0")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		// set up execution context
+		ctx, cancel := context.WithCancel(context.Background())
+		GracefulExit(cancel)
+
+		verifyModules := viper.GetBool("verify-modules")
+		if !verifyModules {
+			log.WithField("component", "client").Warn("skipping module verification")
+		}
+
+		for _, fname := range args {
+			flog := log.WithField("file", fname)
+
+			vendored, err := load.Vendor(ctx, fname, verifyModules)
+			if err != nil {
+				flog.WithError(errcode.WithMessage(err)).Fatal("could not vendor modules")
+			}
+
+			for _, url := range vendored {
+				flog.WithField("module", url).Info("vendored")
+			}
+			flog.WithField("modules", len(vendored)).Info("vendoring complete")
+		}
+	},
+}
+
+func init() {
+	vendorCmd.Flags().Bool("verify-modules", false, "verify module signatures")
+	RootCmd.AddCommand(vendorCmd)
+}
@@ -0,0 +1,118 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/prettyprinters/human"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const strictFlagName = "strict"
+
+func registerWarningFlags(flags *pflag.FlagSet) {
+	flags.Bool(strictFlagName, false, "treat warnings as errors")
+}
+
+func getStrict() bool { return viper.GetBool(strictFlagName) }
+
+// nodeWarning is a warning along with the node that raised it
+type nodeWarning struct {
+	ID string
+	resource.Warning
+}
+
+// warningCollector aggregates the warnings from a run so they can be shown
+// together at the end
+type warningCollector struct {
+	warnings []nodeWarning
+}
+
+// Add collects the warnings from a node's result
+func (c *warningCollector) Add(id string, printable human.Printable) {
+	var warnings []resource.Warning
+	if reporter, ok := printable.(resource.WarningReporter); ok {
+		warnings = reporter.Warnings()
+	} else if warning := printable.Warning(); warning != "" {
+		warnings = []resource.Warning{{Kind: resource.WarningResource, Message: warning}}
+	}
+
+	for _, warning := range warnings {
+		c.warnings = append(c.warnings, nodeWarning{ID: id, Warning: warning})
+	}
+}
+
+// CheckParams adds a warning for every top-level parameter that doesn't have
+// a matching param in the graph
+func (c *warningCollector) CheckParams(g *graph.Graph, params map[string]string) {
+	var names []string
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !g.Contains(graph.ID("root", "param."+name)) {
+			c.warnings = append(c.warnings, nodeWarning{
+				ID: "root",
+				Warning: resource.Warning{
+					Kind:    resource.WarningUnusedParam,
+					Message: fmt.Sprintf("param %q was set but is not used by the module", name),
+				},
+			})
+		}
+	}
+}
+
+// Len returns the number of collected warnings
+func (c *warningCollector) Len() int {
+	return len(c.warnings)
+}
+
+// String formats the warnings grouped by kind
+func (c *warningCollector) String() string {
+	if len(c.warnings) == 0 {
+		return ""
+	}
+
+	warnings := make([]nodeWarning, len(c.warnings))
+	copy(warnings, c.warnings)
+	sort.Stable(byKindAndID(warnings))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Warnings: %d\n", len(warnings))
+	for _, warning := range warnings {
+		fmt.Fprintf(&buf, "  [%s] %s: %s\n", warning.Kind, warning.ID, warning.Message)
+	}
+
+	return buf.String()
+}
+
+type byKindAndID []nodeWarning
+
+func (b byKindAndID) Len() int      { return len(b) }
+func (b byKindAndID) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byKindAndID) Less(i, j int) bool {
+	if b[i].Kind == b[j].Kind {
+		return b[i].ID < b[j].ID
+	}
+	return b[i].Kind < b[j].Kind
+}
@@ -0,0 +1,58 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarningCollector(t *testing.T) {
+	t.Parallel()
+
+	c := new(warningCollector)
+	c.Add("root/task.b", (&pb.StatusResponse_Details{
+		Warnings: []*pb.WarningResponse{{Kind: "skipped", Message: "not applied"}},
+	}).ToPrintable())
+	c.Add("root/task.a", (&pb.StatusResponse_Details{Warning: "careful"}).ToPrintable())
+	c.Add("root/task.c", (&pb.StatusResponse_Details{}).ToPrintable())
+
+	g := graph.New()
+	g.Add(node.New("root", nil))
+	g.Add(node.New("root/param.used", nil))
+	c.CheckParams(g, map[string]string{"used": "1", "unused": "2"})
+
+	assert.Equal(t, 3, c.Len())
+	assert.Equal(
+		t,
+		"Warnings: 3\n"+
+			"  [resource] root/task.a: careful\n"+
+			"  [skipped] root/task.b: not applied\n"+
+			"  [unused-param] root: param \"unused\" was set but is not used by the module\n",
+		c.String(),
+	)
+}
+
+func TestWarningCollectorEmpty(t *testing.T) {
+	t.Parallel()
+
+	c := new(warningCollector)
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, "", c.String())
+}
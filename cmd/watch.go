@@ -0,0 +1,317 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/errcode"
+	"github.com/asteris-llc/converge/fetch"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/graph/snapshot"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/load"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/net/context"
+)
+
+// watchQuiet is how long the module has to go without changes before it is
+// loaded again, so that saving several files at once leads to a single run
+const watchQuiet = 250 * time.Millisecond
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "apply a module again whenever its source changes",
+	Long: `watch applies a module, then watches the directory it is in for changes
+to module files. After a change the module is loaded again and compared to the
+last time it was loaded, and only the nodes that changed, the nodes that depend
+on them, and what those need to run are planned and applied. This makes for a
+fast loop when developing a module against a test machine:
+
+		converge watch --local myModule.hcl
+
+Nodes that are removed from the module are not undone. Use --plan to only plan
+the changed nodes.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("Need exactly one module filename as argument, got %d", len(args))
+		}
+		if _, err := fetch.LocalPath(args[0]); err != nil {
+			return errors.New("only modules on the local filesystem can be watched")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		// set up execution context
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		GracefulExit(cancel)
+
+		// logging
+		fname := args[0]
+		clog := log.WithField("component", "client").WithField("file", fname)
+		ctx = logging.WithLogger(ctx, clog)
+
+		maybeSetToken()
+
+		if err := maybeStartSelfHostedRPC(ctx); err != nil {
+			clog.WithError(err).Fatal("could not start RPC")
+		}
+
+		client, err := getRPCExecutorClient(ctx, getSecurityConfig())
+		if err != nil {
+			clog.WithError(err).Fatal("could not get client")
+		}
+
+		rpcParams := getParamsRPC(cmd)
+
+		verifyModules := viper.GetBool("verify-modules")
+		if !verifyModules {
+			clog.Warn("skipping module verification")
+		}
+
+		watcher, err := watchTree(filepath.Dir(fname))
+		if err != nil {
+			clog.WithError(err).Fatal("could not watch module")
+		}
+		defer watcher.Close()
+
+		last, err := snapshotModule(ctx, fname, verifyModules)
+		if err != nil {
+			clog.WithError(errcode.WithMessage(err)).Fatal("could not load module")
+		}
+
+		request := func(only []string) *pb.LoadRequest {
+			return &pb.LoadRequest{
+				Location:         fname,
+				Parameters:       rpcParams,
+				Verify:           verifyModules,
+				AllowDestructive: getAllowDestructive(),
+				Parallelism:      getParallelism(),
+				StrictRender:     getStrictRender(),
+				StrictRefs:       getStrictRefs(),
+				Only:             only,
+			}
+		}
+
+		if err := runWatched(ctx, client, request(nil)); err != nil {
+			clog.WithError(err).Error("run failed")
+		}
+		clog.Info("watching for changes")
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case err := <-watcher.Errors:
+				clog.WithError(err).Warn("error watching module")
+
+			case event := <-watcher.Events:
+				events := collectEvents(watcher.Events, event, watchQuiet)
+				for _, dir := range createdDirs(events) {
+					if err := watcher.Add(dir); err != nil {
+						clog.WithError(err).WithField("dir", dir).Warn("could not watch new directory")
+					}
+				}
+				if !moduleChanged(events) {
+					continue
+				}
+
+				next, err := snapshotModule(ctx, fname, verifyModules)
+				if err != nil {
+					clog.WithError(errcode.WithMessage(err)).Error("could not load module, waiting for the next change")
+					continue
+				}
+
+				affected := next.Affected(last)
+				last = next
+				if len(affected) == 0 {
+					clog.Info("no nodes changed")
+					continue
+				}
+
+				clog.WithField("nodes", len(affected)).Info("running changed nodes")
+				if err := runWatched(ctx, client, request(affected)); err != nil {
+					clog.WithError(err).Error("run failed")
+				}
+				clog.Info("watching for changes")
+			}
+		}
+	},
+}
+
+// watchStream is what plan and apply stream back
+type watchStream interface {
+	recver
+	headerer
+}
+
+// runWatched plans or applies a module, depending on the plan flag, and
+// prints the results
+func runWatched(ctx context.Context, client pb.ExecutorClient, req *pb.LoadRequest) error {
+	var (
+		stream watchStream
+		err    error
+	)
+	if viper.GetBool("plan") {
+		stream, err = client.Plan(ctx, req)
+	} else {
+		stream, err = client.Apply(ctx, req)
+	}
+	if err != nil {
+		return errcode.WithMessage(err)
+	}
+
+	edges, err := getMeta(stream)
+	if err != nil {
+		return err
+	}
+
+	g := graph.New()
+	for _, edge := range edges {
+		g.Connect(edge.Source, edge.Dest)
+	}
+
+	err = iterateOverStream(stream, func(resp *pb.StatusResponse) {
+		if resp.Run != pb.StatusResponse_FINISHED {
+			return
+		}
+		if details := resp.GetDetails(); details != nil {
+			g.Add(node.New(resp.Id, details.ToPrintable()))
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	out, err := getPrinter().Show(ctx, g)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print("\n")
+	fmt.Print(out)
+	return nil
+}
+
+// snapshotModule loads a module far enough to know its nodes and how they
+// depend on each other, which is what changes are compared by
+func snapshotModule(ctx context.Context, location string, verify bool) (*snapshot.Snapshot, error) {
+	nodes, err := load.Nodes(ctx, location, verify)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := load.ResolveDependencies(ctx, nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot.New(snapshot.PhaseResolve, resolved)
+}
+
+// watchTree watches a directory and the directories below it, except hidden
+// ones like .git
+func watchTree(root string) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return err
+		}
+		if path != root && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	return watcher, nil
+}
+
+// collectEvents gathers the events that follow the first one until none
+// arrive for the quiet period
+func collectEvents(events <-chan fsnotify.Event, first fsnotify.Event, quiet time.Duration) []fsnotify.Event {
+	out := []fsnotify.Event{first}
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return out
+			}
+			out = append(out, event)
+		case <-time.After(quiet):
+			return out
+		}
+	}
+}
+
+// moduleChanged is true when any of the events are for a module file
+func moduleChanged(events []fsnotify.Event) bool {
+	for _, event := range events {
+		if filepath.Ext(event.Name) == ".hcl" && event.Op != fsnotify.Chmod {
+			return true
+		}
+	}
+	return false
+}
+
+// createdDirs returns the directories that were created, so they can be
+// watched too
+func createdDirs(events []fsnotify.Event) (dirs []string) {
+	for _, event := range events {
+		if event.Op&fsnotify.Create == 0 || strings.HasPrefix(filepath.Base(event.Name), ".") {
+			continue
+		}
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			dirs = append(dirs, event.Name)
+		}
+	}
+	return dirs
+}
+
+func init() {
+	watchCmd.Flags().Bool("plan", false, "only plan the changed nodes")
+	watchCmd.Flags().Bool("show-meta", false, "show metadata (params and modules)")
+	watchCmd.Flags().Bool("only-show-changes", false, "only show changes")
+	watchCmd.Flags().Bool("verify-modules", false, "verify module signatures")
+	registerRPCFlags(watchCmd.Flags())
+	registerLocalRPCFlags(watchCmd.Flags())
+	registerSSLFlags(watchCmd.Flags())
+	registerParamsFlags(watchCmd.Flags())
+	registerDestructiveFlags(watchCmd.Flags())
+	registerParallelismFlags(watchCmd.Flags())
+	registerStrictRenderFlags(watchCmd.Flags())
+	registerStrictRefsFlags(watchCmd.Flags())
+	RootCmd.AddCommand(watchCmd)
+}
@@ -0,0 +1,89 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectEvents(t *testing.T) {
+	t.Parallel()
+
+	events := make(chan fsnotify.Event, 2)
+	events <- fsnotify.Event{Name: "b.hcl", Op: fsnotify.Write}
+	events <- fsnotify.Event{Name: "c.hcl", Op: fsnotify.Write}
+
+	collected := collectEvents(events, fsnotify.Event{Name: "a.hcl", Op: fsnotify.Write}, 10*time.Millisecond)
+
+	var names []string
+	for _, event := range collected {
+		names = append(names, event.Name)
+	}
+	assert.Equal(t, []string{"a.hcl", "b.hcl", "c.hcl"}, names)
+}
+
+func TestModuleChanged(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, moduleChanged([]fsnotify.Event{
+		{Name: ".main.hcl.swp", Op: fsnotify.Write},
+		{Name: "main.hcl", Op: fsnotify.Write},
+	}))
+	assert.False(t, moduleChanged([]fsnotify.Event{{Name: "main.hcl", Op: fsnotify.Chmod}}))
+	assert.False(t, moduleChanged([]fsnotify.Event{{Name: "notes.txt", Op: fsnotify.Write}}))
+}
+
+func TestWatchTree(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "converge-watch")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tmp, "modules"), 0755))
+
+	watcher, err := watchTree(tmp)
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	module := filepath.Join(tmp, "modules", "inner.hcl")
+	require.NoError(t, ioutil.WriteFile(module, []byte(`task "x" {}`), 0644))
+
+	select {
+	case event := <-watcher.Events:
+		assert.Equal(t, module, event.Name)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event for a change in a subdirectory")
+	}
+
+	t.Run("created directories", func(t *testing.T) {
+		created := filepath.Join(tmp, "new")
+		require.NoError(t, os.Mkdir(created, 0755))
+
+		assert.Equal(t, []string{created}, createdDirs([]fsnotify.Event{
+			{Name: created, Op: fsnotify.Create},
+			{Name: module, Op: fsnotify.Create},
+			{Name: filepath.Join(tmp, "modules"), Op: fsnotify.Write},
+		}))
+	})
+}
@@ -0,0 +1,197 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package errcode assigns stable codes to failures so that they can be
+// referenced without matching on error messages.
+package errcode
+
+import (
+	"fmt"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+)
+
+// Code identifies a class of failure. Codes are stable across releases and
+// documented in docs/content/errors.md; never renumber an existing code.
+type Code string
+
+// loading
+const (
+	// LoadFetch indicates a module could not be fetched
+	LoadFetch Code = "CONVERGE-LOAD-001"
+
+	// LoadSignature indicates a module signature could not be verified
+	LoadSignature Code = "CONVERGE-LOAD-002"
+
+	// LoadParse indicates a module could not be parsed
+	LoadParse Code = "CONVERGE-LOAD-003"
+
+	// LoadUnknownResource indicates a module used a resource type that does
+	// not exist
+	LoadUnknownResource Code = "CONVERGE-LOAD-004"
+
+	// LoadInvalidResource indicates a resource could not be decoded
+	LoadInvalidResource Code = "CONVERGE-LOAD-005"
+
+	// LoadInvalidGraph indicates the loaded graph is malformed, such as a
+	// misused conditional
+	LoadInvalidGraph Code = "CONVERGE-LOAD-006"
+)
+
+// resolving dependencies
+const (
+	// ResolveFailed indicates dependencies between nodes could not be resolved
+	ResolveFailed Code = "CONVERGE-RESOLVE-001"
+
+	// ResolveAmbiguous indicates a reference could resolve to more than one
+	// node, with strict references
+	ResolveAmbiguous Code = "CONVERGE-RESOLVE-002"
+)
+
+// rendering
+const (
+	// RenderBadTemplate indicates a template could not be executed
+	RenderBadTemplate Code = "CONVERGE-RENDER-001"
+
+	// RenderUnresolvable indicates a template referred to a value that can't
+	// be resolved
+	RenderUnresolvable Code = "CONVERGE-RENDER-002"
+
+	// RenderFailed indicates any other failure to render a node
+	RenderFailed Code = "CONVERGE-RENDER-003"
+
+	// RenderStrict indicates a template referred to a missing value while
+	// rendering strictly
+	RenderStrict Code = "CONVERGE-RENDER-004"
+)
+
+// planning
+const (
+	// PlanCheckFailed indicates a node's check failed
+	PlanCheckFailed Code = "CONVERGE-PLAN-001"
+
+	// PlanDependency indicates a node was not checked because a dependency
+	// failed
+	PlanDependency Code = "CONVERGE-PLAN-002"
+
+	// PlanInterrupted indicates a node's check was interrupted or never started
+	// because the run was cancelled
+	PlanInterrupted Code = "CONVERGE-PLAN-003"
+)
+
+// applying
+const (
+	// ApplyFailed indicates a node's apply failed
+	ApplyFailed Code = "CONVERGE-APPLY-001"
+
+	// ApplyDependency indicates a node was not applied because a dependency
+	// failed
+	ApplyDependency Code = "CONVERGE-APPLY-002"
+
+	// ApplyStillChanges indicates a node still had changes after apply
+	ApplyStillChanges Code = "CONVERGE-APPLY-003"
+
+	// ApplyInterrupted indicates a node's apply was interrupted or never
+	// started because the run was cancelled
+	ApplyInterrupted Code = "CONVERGE-APPLY-004"
+
+	// ApplyDestructive indicates a node was not applied because its changes are
+	// destructive and were not allowed
+	ApplyDestructive Code = "CONVERGE-APPLY-005"
+)
+
+// Error is an error with a code attached. The message is that of the wrapped
+// error, so attaching a code doesn't change existing output.
+type Error struct {
+	Code Code
+	Err  error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+// Cause returns the wrapped error, for compatibility with errors.Cause
+func (e *Error) Cause() error { return e.Err }
+
+// Wrap attaches a code to an error. It returns nil if err is nil, and leaves
+// errors that already carry a code alone.
+func Wrap(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := Of(err); ok {
+		return err
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// Errorf formats a new error with a code
+func Errorf(code Code, format string, args ...interface{}) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// Of returns the outermost code attached to err or any error it wraps. For
+// multiple errors, the code of the first one with a code is returned.
+func Of(err error) (Code, bool) {
+	type causer interface {
+		Cause() error
+	}
+
+	for err != nil {
+		switch typed := err.(type) {
+		case *Error:
+			return typed.Code, true
+
+		case *multierror.Error:
+			for _, inner := range typed.Errors {
+				if code, ok := Of(inner); ok {
+					return code, true
+				}
+			}
+			return "", false
+
+		case causer:
+			err = typed.Cause()
+
+		default:
+			return "", false
+		}
+	}
+
+	return "", false
+}
+
+// Format prefixes the message of err with its code, if it has one
+func Format(err error) string {
+	if err == nil {
+		return ""
+	}
+	if code, ok := Of(err); ok {
+		return fmt.Sprintf("[%s] %s", code, err)
+	}
+	return err.Error()
+}
+
+// WithMessage returns an error whose message includes its code. Use it where
+// errors are flattened to strings, such as when crossing the RPC boundary.
+func WithMessage(err error) error {
+	if err == nil {
+		return nil
+	}
+	code, ok := Of(err)
+	if !ok {
+		return err
+	}
+	return &Error{Code: code, Err: errors.New(Format(err))}
+}
@@ -0,0 +1,104 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errcode_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/asteris-llc/converge/errcode"
+	multierror "github.com/hashicorp/go-multierror"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrap(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil", func(t *testing.T) {
+		assert.Nil(t, errcode.Wrap(errcode.LoadParse, nil))
+	})
+
+	t.Run("message", func(t *testing.T) {
+		err := errcode.Wrap(errcode.LoadParse, errors.New("x"))
+		assert.EqualError(t, err, "x")
+	})
+
+	t.Run("keeps existing", func(t *testing.T) {
+		inner := errcode.Errorf(errcode.LoadFetch, "x")
+		err := errcode.Wrap(errcode.LoadParse, pkgerrors.Wrap(inner, "y"))
+
+		code, ok := errcode.Of(err)
+		require.True(t, ok)
+		assert.Equal(t, errcode.LoadFetch, code)
+	})
+}
+
+func TestOf(t *testing.T) {
+	t.Parallel()
+
+	t.Run("plain", func(t *testing.T) {
+		_, ok := errcode.Of(errors.New("x"))
+		assert.False(t, ok)
+	})
+
+	t.Run("wrapped", func(t *testing.T) {
+		err := pkgerrors.Wrap(errcode.Errorf(errcode.ApplyFailed, "x"), "y")
+
+		code, ok := errcode.Of(err)
+		require.True(t, ok)
+		assert.Equal(t, errcode.ApplyFailed, code)
+	})
+
+	t.Run("multierror", func(t *testing.T) {
+		err := multierror.Append(
+			errors.New("x"),
+			pkgerrors.Wrap(errcode.Errorf(errcode.RenderFailed, "y"), "z"),
+		)
+
+		code, ok := errcode.Of(err)
+		require.True(t, ok)
+		assert.Equal(t, errcode.RenderFailed, code)
+	})
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "", errcode.Format(nil))
+	assert.Equal(t, "x", errcode.Format(errors.New("x")))
+	assert.Equal(
+		t,
+		"[CONVERGE-PLAN-001] y: x",
+		errcode.Format(pkgerrors.Wrap(errcode.Errorf(errcode.PlanCheckFailed, "x"), "y")),
+	)
+}
+
+func TestWithMessage(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, errcode.WithMessage(nil))
+
+	plain := errors.New("x")
+	assert.Equal(t, plain, errcode.WithMessage(plain))
+
+	err := errcode.WithMessage(errcode.Errorf(errcode.LoadUnknownResource, "x"))
+	assert.EqualError(t, err, "[CONVERGE-LOAD-004] x")
+
+	code, ok := errcode.Of(err)
+	require.True(t, ok)
+	assert.Equal(t, errcode.LoadUnknownResource, code)
+}
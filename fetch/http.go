@@ -22,31 +22,60 @@ import (
 	"golang.org/x/net/context"
 )
 
-// HTTP fetches content over HTTP
+// maxResumes is how many times an interrupted download is resumed
+const maxResumes = 3
+
+// HTTP fetches content over HTTP. Compressed responses are decompressed
+// transparently, and a download which is cut off is resumed with a range
+// request when the server supports it.
 func HTTP(ctx context.Context, loc string) ([]byte, error) {
-	var client http.Client
-	req, err := http.NewRequest("GET", loc, nil)
-	if err != nil {
-		return nil, err
-	}
+	var (
+		client  http.Client
+		content []byte
+		etag    string
+	)
 
-	req.Header.Add("Accept", "text/plain")
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest("GET", loc, nil)
+		if err != nil {
+			return nil, err
+		}
 
-	req = req.WithContext(ctx)
+		req.Header.Add("Accept", "text/plain")
+		if len(content) > 0 {
+			// If-Range makes the server send everything again if the content
+			// changed since the first attempt
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", len(content)))
+			req.Header.Set("If-Range", etag)
+		}
 
-	response, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
+		req = req.WithContext(ctx)
 
-	content, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return nil, err
-	}
+		response, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
 
-	if response.StatusCode >= 300 {
-		return nil, fmt.Errorf("Fetching %s failed: %s", loc, response.Status)
-	}
+		body, err := ioutil.ReadAll(response.Body)
+		response.Body.Close()
 
-	return content, err
+		if response.StatusCode >= 300 {
+			return nil, fmt.Errorf("Fetching %s failed: %s", loc, response.Status)
+		}
+
+		if response.StatusCode != http.StatusPartialContent {
+			content = content[:0]
+		}
+		content = append(content, body...)
+
+		if err == nil {
+			return content, nil
+		}
+
+		etag = response.Header.Get("ETag")
+		resumable := etag != "" && response.Header.Get("Accept-Ranges") == "bytes"
+		if !resumable || attempt >= maxResumes || ctx.Err() != nil {
+			return nil, err
+		}
+	}
 }
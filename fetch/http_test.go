@@ -15,14 +15,17 @@
 package fetch_test
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"path"
-	"testing"
-
 	"strings"
+	"testing"
+	"time"
 
 	"github.com/asteris-llc/converge/fetch"
 	"github.com/asteris-llc/converge/helpers/logging"
-	"github.com/asteris-llc/converge/helpers/testing/http"
+	testhttp "github.com/asteris-llc/converge/helpers/testing/http"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/context"
@@ -32,7 +35,7 @@ func TestHTTP(t *testing.T) {
 	// HTTP should load successfully
 	defer logging.HideLogs(t)()
 
-	addr, cancel, err := http.ServeFile(path.Join("..", "samples", "basic.hcl"))
+	addr, cancel, err := testhttp.ServeFile(path.Join("..", "samples", "basic.hcl"))
 	defer cancel()
 	require.NoError(t, err)
 
@@ -44,7 +47,7 @@ func TestHTTPNotFound(t *testing.T) {
 	// HTTP should not succeed for a bad file
 	defer logging.HideLogs(t)()
 
-	addr, cancel, err := http.ServeFile(path.Join("..", "samples", "basic.hcl"))
+	addr, cancel, err := testhttp.ServeFile(path.Join("..", "samples", "basic.hcl"))
 	defer cancel()
 	require.NoError(t, err)
 
@@ -55,3 +58,38 @@ func TestHTTPNotFound(t *testing.T) {
 		assert.EqualError(t, err, "Fetching "+addr+" failed: 404 Not Found")
 	}
 }
+
+func TestHTTPResume(t *testing.T) {
+	// HTTP should resume a download that was cut off
+	defer logging.HideLogs(t)()
+
+	content := strings.Repeat("x", 1000) + strings.Repeat("y", 1000)
+	var ranges []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"content"`)
+
+		if r.Header.Get("Range") != "" {
+			ranges = append(ranges, r.Header.Get("Range"))
+			http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+			return
+		}
+
+		// promise everything, then hang up halfway through
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(content[:1000]))
+		w.(http.Flusher).Flush()
+
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		conn.Close()
+	}))
+	defer server.Close()
+
+	fetched, err := fetch.HTTP(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(fetched))
+	assert.Equal(t, []string{"bytes=1000-"}, ranges)
+}
@@ -15,6 +15,7 @@
 package fetch
 
 import (
+	"fmt"
 	"path"
 	"strings"
 
@@ -42,6 +43,32 @@ func ResolveInContext(loc, ctx string) (string, error) {
 	return locScheme + "://" + locPath, nil
 }
 
+// Dir returns the location of the directory containing loc, keeping its
+// scheme
+func Dir(loc string) string {
+	if loc == "" {
+		return ""
+	}
+
+	scheme, locPath := parse(loc)
+	if scheme == "" {
+		scheme = "file"
+	}
+
+	return scheme + "://" + path.Dir(locPath)
+}
+
+// LocalPath returns the filesystem path of a location, which must use the file
+// scheme or no scheme at all
+func LocalPath(loc string) (string, error) {
+	scheme, locPath := parse(loc)
+	if scheme != "" && scheme != "file" {
+		return "", fmt.Errorf("%s is not a local path", loc)
+	}
+
+	return locPath, nil
+}
+
 func parse(loc string) (scheme, path string) {
 	if strings.Contains(loc, "://") {
 		parts := strings.SplitN(loc, "://", 2)
@@ -77,3 +77,33 @@ func TestResolveInContextNoSchemesAtAll(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "file://../samples/basic.hcl", resolved)
 }
+
+func TestDir(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "file:///a/b", fetch.Dir("file:///a/b/c.hcl"))
+	assert.Equal(t, "http://a.com/b", fetch.Dir("http://a.com/b/c.hcl"))
+	assert.Equal(t, "file://samples", fetch.Dir("samples/basic.hcl"))
+	assert.Equal(t, "", fetch.Dir(""))
+}
+
+func TestLocalPath(t *testing.T) {
+	t.Parallel()
+
+	t.Run("file", func(t *testing.T) {
+		local, err := fetch.LocalPath("file:///a/b")
+		assert.NoError(t, err)
+		assert.Equal(t, "/a/b", local)
+	})
+
+	t.Run("no scheme", func(t *testing.T) {
+		local, err := fetch.LocalPath("a/b")
+		assert.NoError(t, err)
+		assert.Equal(t, "a/b", local)
+	})
+
+	t.Run("http", func(t *testing.T) {
+		_, err := fetch.LocalPath("http://a.com/b")
+		assert.EqualError(t, err, "http://a.com/b is not a local path")
+	})
+}
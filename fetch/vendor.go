@@ -0,0 +1,99 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fetch
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// VendorDir is the directory, next to a root module, that the remote modules
+// it refers to are vendored in
+const VendorDir = "vendor"
+
+// FilesDir is the directory, next to a module, that holds the files its
+// resources copy, like the payloads of file.content
+const FilesDir = "files"
+
+// FilePath returns the location of a file in the files directory, relative to
+// the module. Names that would leave the files directory are an error.
+func FilePath(name string) (string, error) {
+	clean := path.Clean(filepath.ToSlash(name))
+	if name == "" || path.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("%q is not a path inside the %s directory", name, FilesDir)
+	}
+
+	return path.Join(FilesDir, clean), nil
+}
+
+// IsRemote is true for locations that are fetched over the network
+func IsRemote(loc string) bool {
+	scheme, _ := parse(loc)
+	return scheme == "http" || scheme == "https"
+}
+
+// VendorPath returns where a remote location is kept in a vendor directory,
+// under the host and path of its URL
+func VendorPath(dir, loc string) (string, error) {
+	if !IsRemote(loc) {
+		return "", fmt.Errorf("%s is not a remote location", loc)
+	}
+
+	u, err := url.Parse(loc)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, u.Host, filepath.FromSlash(path.Clean("/"+u.Path))), nil
+}
+
+// RootVendorDir returns the vendor directory of a root module, which must be
+// on the local filesystem
+func RootVendorDir(root string) (string, error) {
+	rootPath, err := LocalPath(root)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(rootPath), VendorDir), nil
+}
+
+// Vendored returns the location of a remote module in the vendor directory of
+// a root module, when it has been vendored there. Otherwise the location is
+// returned as is.
+func Vendored(root, loc string) string {
+	if !IsRemote(loc) {
+		return loc
+	}
+
+	dir, err := RootVendorDir(root)
+	if err != nil {
+		return loc
+	}
+
+	dest, err := VendorPath(dir, loc)
+	if err != nil {
+		return loc
+	}
+
+	if _, err := os.Stat(dest); err != nil {
+		return loc
+	}
+	return "file://" + dest
+}
@@ -0,0 +1,82 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fetch_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/fetch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVendorPath(t *testing.T) {
+	t.Parallel()
+
+	dest, err := fetch.VendorPath("vendor", "https://example.com:8080/modules/../base.hcl")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("vendor", "example.com:8080", "base.hcl"), dest)
+
+	_, err = fetch.VendorPath("vendor", "file:///modules/base.hcl")
+	assert.Error(t, err)
+}
+
+func TestVendored(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "converge-vendored")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	root := "file://" + filepath.Join(tmp, "main.hcl")
+	vendored := filepath.Join(tmp, fetch.VendorDir, "example.com", "modules", "base.hcl")
+	require.NoError(t, os.MkdirAll(filepath.Dir(vendored), 0755))
+	require.NoError(t, ioutil.WriteFile(vendored, []byte{}, 0644))
+
+	t.Run("vendored", func(t *testing.T) {
+		assert.Equal(t, "file://"+vendored, fetch.Vendored(root, "https://example.com/modules/base.hcl"))
+	})
+
+	t.Run("not vendored", func(t *testing.T) {
+		loc := "https://example.com/modules/other.hcl"
+		assert.Equal(t, loc, fetch.Vendored(root, loc))
+	})
+
+	t.Run("local", func(t *testing.T) {
+		loc := "file://" + filepath.Join(tmp, "other.hcl")
+		assert.Equal(t, loc, fetch.Vendored(root, loc))
+	})
+
+	t.Run("remote root", func(t *testing.T) {
+		loc := "https://example.com/modules/base.hcl"
+		assert.Equal(t, loc, fetch.Vendored("https://example.com/main.hcl", loc))
+	})
+}
+
+func TestFilePath(t *testing.T) {
+	t.Parallel()
+
+	loc, err := fetch.FilePath("img/../logo.png")
+	require.NoError(t, err)
+	assert.Equal(t, "files/logo.png", loc)
+
+	for _, name := range []string{"", ".", "..", "../main.hcl", "img/../../main.hcl", "/etc/passwd"} {
+		_, err := fetch.FilePath(name)
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,296 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fleet rolls a module out across several hosts. It applies to a
+// canary share of the hosts first, and only continues to the rest when enough
+// of the canaries succeed.
+package fleet
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// Target is a host to apply to
+type Target struct {
+	// Name identifies the host in reports and inventories
+	Name string
+
+	// Addr is the address of the host's RPC server
+	Addr string
+}
+
+// ApplyFunc applies a module to a target, returning an error if the apply
+// failed or left the host unhealthy
+type ApplyFunc func(ctx context.Context, target Target) error
+
+// Amount is a number of hosts, given either as a count or as a percentage of
+// some total
+type Amount struct {
+	N       int
+	Percent bool
+}
+
+// ParseAmount parses a count like "3" or a percentage like "10%"
+func ParseAmount(s string) (Amount, error) {
+	trimmed := strings.TrimSuffix(s, "%")
+	n, err := strconv.Atoi(trimmed)
+	if err != nil || n < 0 {
+		return Amount{}, fmt.Errorf("invalid amount %q: expected a count or a percentage", s)
+	}
+
+	amount := Amount{N: n, Percent: trimmed != s}
+	if amount.Percent && n > 100 {
+		return Amount{}, fmt.Errorf("invalid amount %q: percentage is over 100%%", s)
+	}
+	return amount, nil
+}
+
+// Of resolves the amount against a total. Percentages round up, so any
+// non-zero percentage of a non-empty total is at least one host. The result
+// is never more than the total.
+func (a Amount) Of(total int) int {
+	n := a.N
+	if a.Percent {
+		n = (a.N*total + 99) / 100
+	}
+	if n > total {
+		return total
+	}
+	return n
+}
+
+// String formats the amount the way ParseAmount reads it
+func (a Amount) String() string {
+	if a.Percent {
+		return fmt.Sprintf("%d%%", a.N)
+	}
+	return strconv.Itoa(a.N)
+}
+
+// Rollout applies to hosts in two waves: canaries, then everyone else
+type Rollout struct {
+	// Canary is how many hosts are applied to first. Zero applies to every
+	// host at once.
+	Canary Amount
+
+	// Threshold is how many of the canaries must succeed before the rest of
+	// the hosts are applied to. Percentages are of the canaries.
+	Threshold Amount
+
+	// Parallelism is how many hosts are applied to at once. Zero or less
+	// applies to every host in a wave at once.
+	Parallelism int
+
+	// Quarantine returns the reason a target is quarantined, if it is.
+	// Quarantined targets are left out of the rollout. When nil, no target is
+	// quarantined.
+	Quarantine func(Target) (reason string, quarantined bool)
+}
+
+// Quarantined is a target left out of a rollout
+type Quarantined struct {
+	Target Target
+	Reason string
+}
+
+// Result is the outcome of applying to a target
+type Result struct {
+	Target Target
+	Canary bool
+
+	// Err is set when the target failed
+	Err error
+}
+
+// Report is the outcome of a rollout
+type Report struct {
+	Results []*Result
+
+	// Halted is set when too few canaries succeeded, in which case Skipped
+	// holds the hosts that were not applied to
+	Halted   bool
+	Skipped  []Target
+	Required int
+
+	// Quarantined holds the hosts that were left out on purpose. They don't
+	// count against the rollout.
+	Quarantined []*Quarantined
+}
+
+// Failed returns the results of targets that failed
+func (r *Report) Failed() []*Result {
+	var failed []*Result
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// OK is true when the rollout reached every host that wasn't quarantined and
+// none failed
+func (r *Report) OK() bool {
+	return !r.Halted && len(r.Skipped) == 0 && len(r.Failed()) == 0
+}
+
+// String summarizes the report, one line per host
+func (r *Report) String() string {
+	var out bytes.Buffer
+
+	for _, result := range r.Results {
+		wave := ""
+		if result.Canary {
+			wave = " (canary)"
+		}
+		if result.Err != nil {
+			fmt.Fprintf(&out, "%s%s: failed: %s\n", result.Target.Name, wave, result.Err)
+		} else {
+			fmt.Fprintf(&out, "%s%s: ok\n", result.Target.Name, wave)
+		}
+	}
+	for _, target := range r.Skipped {
+		fmt.Fprintf(&out, "%s: skipped\n", target.Name)
+	}
+	for _, quarantined := range r.Quarantined {
+		if quarantined.Reason != "" {
+			fmt.Fprintf(&out, "%s: quarantined: %s\n", quarantined.Target.Name, quarantined.Reason)
+		} else {
+			fmt.Fprintf(&out, "%s: quarantined\n", quarantined.Target.Name)
+		}
+	}
+
+	if r.Halted {
+		var canaries, succeeded int
+		for _, result := range r.Results {
+			if result.Canary {
+				canaries++
+				if result.Err == nil {
+					succeeded++
+				}
+			}
+		}
+		fmt.Fprintf(&out, "\nHalted: %d of %d canaries succeeded, %d required\n", succeeded, canaries, r.Required)
+	}
+
+	fmt.Fprintf(
+		&out,
+		"\nSummary: %d hosts, %d failed, %d skipped, %d quarantined\n",
+		len(r.Results)+len(r.Skipped)+len(r.Quarantined),
+		len(r.Failed()),
+		len(r.Skipped),
+		len(r.Quarantined),
+	)
+
+	return out.String()
+}
+
+// Run applies to the targets. Quarantined targets are left out first, and the
+// canaries are the first of the remaining targets. Hosts that were not started
+// when the context is cancelled are skipped.
+func (r *Rollout) Run(ctx context.Context, targets []Target, apply ApplyFunc) *Report {
+	report := new(Report)
+
+	if r.Quarantine != nil {
+		var remaining []Target
+		for _, target := range targets {
+			if reason, ok := r.Quarantine(target); ok {
+				report.Quarantined = append(report.Quarantined, &Quarantined{Target: target, Reason: reason})
+			} else {
+				remaining = append(remaining, target)
+			}
+		}
+		targets = remaining
+	}
+
+	canaries := r.Canary.Of(len(targets))
+	if canaries == 0 {
+		canaries = len(targets)
+	} else if canaries < len(targets) {
+		report.Required = r.Threshold.Of(canaries)
+
+		results, skipped := r.wave(ctx, targets[:canaries], apply)
+		succeeded := 0
+		for _, result := range results {
+			result.Canary = true
+			if result.Err == nil {
+				succeeded++
+			}
+		}
+		report.Results = append(report.Results, results...)
+		report.Skipped = append(report.Skipped, skipped...)
+
+		if succeeded < report.Required {
+			report.Halted = true
+			report.Skipped = append(report.Skipped, targets[canaries:]...)
+			return report
+		}
+
+		targets = targets[canaries:]
+	}
+
+	results, skipped := r.wave(ctx, targets, apply)
+	report.Results = append(report.Results, results...)
+	report.Skipped = append(report.Skipped, skipped...)
+
+	return report
+}
+
+// wave applies to targets at the configured parallelism, returning results
+// in the order of targets
+func (r *Rollout) wave(ctx context.Context, targets []Target, apply ApplyFunc) (results []*Result, skipped []Target) {
+	parallelism := r.Parallelism
+	if parallelism <= 0 || parallelism > len(targets) {
+		parallelism = len(targets)
+	}
+
+	slots := make(chan struct{}, parallelism)
+	all := make([]*Result, len(targets))
+	var wg sync.WaitGroup
+
+	for i, target := range targets {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, target Target) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			all[i] = &Result{Target: target, Err: apply(ctx, target)}
+		}(i, target)
+	}
+
+	wg.Wait()
+
+	for i, result := range all {
+		if result == nil {
+			skipped = append(skipped, targets[i])
+		} else {
+			results = append(results, result)
+		}
+	}
+	return results, skipped
+}
@@ -0,0 +1,198 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fleet_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/asteris-llc/converge/fleet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestParseAmount(t *testing.T) {
+	t.Parallel()
+
+	for in, expected := range map[string]fleet.Amount{
+		"3":    {N: 3},
+		"0":    {N: 0},
+		"10%":  {N: 10, Percent: true},
+		"100%": {N: 100, Percent: true},
+	} {
+		t.Run(in, func(t *testing.T) {
+			amount, err := fleet.ParseAmount(in)
+			require.NoError(t, err)
+			assert.Equal(t, expected, amount)
+			assert.Equal(t, in, amount.String())
+		})
+	}
+
+	for _, in := range []string{"", "x", "-1", "101%", "%"} {
+		t.Run(fmt.Sprintf("invalid %q", in), func(t *testing.T) {
+			_, err := fleet.ParseAmount(in)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAmountOf(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 1, fleet.Amount{N: 10, Percent: true}.Of(3))
+	assert.Equal(t, 2, fleet.Amount{N: 10, Percent: true}.Of(11))
+	assert.Equal(t, 0, fleet.Amount{N: 0, Percent: true}.Of(10))
+	assert.Equal(t, 10, fleet.Amount{N: 100, Percent: true}.Of(10))
+	assert.Equal(t, 4, fleet.Amount{N: 5}.Of(4))
+}
+
+// recorder is an ApplyFunc that records the targets it was called for, and
+// fails for the targets in fail
+type recorder struct {
+	fail map[string]bool
+
+	lock    sync.Mutex
+	applied []string
+}
+
+func (r *recorder) apply(ctx context.Context, target fleet.Target) error {
+	r.lock.Lock()
+	r.applied = append(r.applied, target.Name)
+	r.lock.Unlock()
+
+	if r.fail[target.Name] {
+		return errors.New("apply failed")
+	}
+	return nil
+}
+
+func targets(names ...string) []fleet.Target {
+	var out []fleet.Target
+	for _, name := range names {
+		out = append(out, fleet.Target{Name: name, Addr: name + ":4774"})
+	}
+	return out
+}
+
+func TestRolloutRun(t *testing.T) {
+	t.Parallel()
+
+	hosts := targets("a", "b", "c", "d", "e")
+
+	t.Run("canaries succeed", func(t *testing.T) {
+		rec := &recorder{}
+		rollout := &fleet.Rollout{
+			Canary:      fleet.Amount{N: 40, Percent: true},
+			Threshold:   fleet.Amount{N: 100, Percent: true},
+			Parallelism: 1,
+		}
+
+		report := rollout.Run(context.Background(), hosts, rec.apply)
+
+		assert.True(t, report.OK())
+		assert.Equal(t, []string{"a", "b", "c", "d", "e"}, rec.applied)
+		require.Len(t, report.Results, 5)
+		assert.True(t, report.Results[1].Canary)
+		assert.False(t, report.Results[2].Canary)
+	})
+
+	t.Run("halts below threshold", func(t *testing.T) {
+		rec := &recorder{fail: map[string]bool{"b": true}}
+		rollout := &fleet.Rollout{
+			Canary:      fleet.Amount{N: 2},
+			Threshold:   fleet.Amount{N: 100, Percent: true},
+			Parallelism: 1,
+		}
+
+		report := rollout.Run(context.Background(), hosts, rec.apply)
+
+		assert.True(t, report.Halted)
+		assert.False(t, report.OK())
+		assert.Equal(t, []string{"a", "b"}, rec.applied)
+		assert.Equal(t, hosts[2:], report.Skipped)
+		require.Len(t, report.Failed(), 1)
+		assert.Equal(t, "b", report.Failed()[0].Target.Name)
+		assert.Contains(t, report.String(), "Halted: 1 of 2 canaries succeeded, 2 required")
+	})
+
+	t.Run("continues at threshold", func(t *testing.T) {
+		rec := &recorder{fail: map[string]bool{"b": true}}
+		rollout := &fleet.Rollout{
+			Canary:    fleet.Amount{N: 2},
+			Threshold: fleet.Amount{N: 50, Percent: true},
+		}
+
+		report := rollout.Run(context.Background(), hosts, rec.apply)
+
+		assert.False(t, report.Halted)
+		assert.False(t, report.OK())
+		assert.Len(t, rec.applied, 5)
+		assert.Empty(t, report.Skipped)
+	})
+
+	t.Run("no canary", func(t *testing.T) {
+		rec := &recorder{fail: map[string]bool{"a": true}}
+		rollout := &fleet.Rollout{Threshold: fleet.Amount{N: 100, Percent: true}}
+
+		report := rollout.Run(context.Background(), hosts, rec.apply)
+
+		assert.False(t, report.Halted)
+		assert.Len(t, rec.applied, 5)
+		for _, result := range report.Results {
+			assert.False(t, result.Canary)
+		}
+	})
+
+	t.Run("quarantined", func(t *testing.T) {
+		rec := &recorder{}
+		rollout := &fleet.Rollout{
+			Canary:      fleet.Amount{N: 1},
+			Threshold:   fleet.Amount{N: 100, Percent: true},
+			Parallelism: 1,
+			Quarantine: func(target fleet.Target) (string, bool) {
+				return "INC-1", target.Name == "a" || target.Name == "c"
+			},
+		}
+
+		report := rollout.Run(context.Background(), hosts, rec.apply)
+
+		assert.True(t, report.OK())
+		assert.Equal(t, []string{"b", "d", "e"}, rec.applied)
+		require.Len(t, report.Results, 3)
+		assert.Equal(t, "b", report.Results[0].Target.Name)
+		assert.True(t, report.Results[0].Canary)
+		require.Len(t, report.Quarantined, 2)
+		assert.Equal(t, "a", report.Quarantined[0].Target.Name)
+		assert.Contains(t, report.String(), "a: quarantined: INC-1")
+		assert.Contains(t, report.String(), "Summary: 5 hosts, 0 failed, 0 skipped, 2 quarantined")
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		rec := &recorder{}
+		rollout := &fleet.Rollout{Canary: fleet.Amount{N: 1}, Parallelism: 1}
+
+		report := rollout.Run(ctx, hosts, rec.apply)
+
+		assert.Empty(t, rec.applied)
+		assert.Equal(t, hosts, report.Skipped)
+		assert.False(t, report.OK())
+	})
+}
@@ -0,0 +1,66 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import "github.com/asteris-llc/converge/graph/node"
+
+// CollapseFunc picks the nodes that stand in for all of their descendants
+type CollapseFunc func(*node.Node) bool
+
+// Collapse returns a copy of the graph with the descendants of every node
+// picked by collapse removed. Edges to or from a removed node are moved to the
+// outermost collapsed node containing it, and edges that would end up pointing
+// a node at itself are dropped.
+func Collapse(g *Graph, collapse CollapseFunc) *Graph {
+	into := map[string]string{}
+	for _, meta := range g.Nodes() {
+		for id := ParentID(meta.ID); id != "." && id != "/"; id = ParentID(id) {
+			if parent, ok := g.Get(id); ok && collapse(parent) {
+				into[meta.ID] = id
+			}
+		}
+	}
+
+	target := func(id string) string {
+		if collapsed, ok := into[id]; ok {
+			return collapsed
+		}
+		return id
+	}
+
+	out := New()
+	for _, meta := range g.Nodes() {
+		if _, ok := into[meta.ID]; !ok {
+			out.Add(meta)
+		}
+	}
+
+	for _, edge := range g.inner.Edges() {
+		src, dst := edge.Source().(string), edge.Target().(string)
+
+		if _, ok := edge.(*ParentEdge); ok {
+			if _, collapsed := into[dst]; !collapsed {
+				out.ConnectParent(src, dst)
+			}
+			continue
+		}
+
+		if src, dst = target(src), target(dst); src != dst {
+			out.Connect(src, dst)
+		}
+	}
+
+	return out
+}
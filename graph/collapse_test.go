@@ -0,0 +1,80 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph_test
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollapse(t *testing.T) {
+	t.Parallel()
+
+	g := graph.New()
+	g.Add(node.New("root", nil))
+	g.Add(node.New("root/module.a", "module"))
+	g.Add(node.New("root/module.a/task.x", "task"))
+	g.Add(node.New("root/module.a/module.b", "module"))
+	g.Add(node.New("root/module.a/module.b/task.y", "task"))
+	g.Add(node.New("root/task.z", "task"))
+
+	g.ConnectParent("root", "root/module.a")
+	g.ConnectParent("root", "root/task.z")
+	g.ConnectParent("root/module.a", "root/module.a/task.x")
+	g.ConnectParent("root/module.a", "root/module.a/module.b")
+	g.ConnectParent("root/module.a/module.b", "root/module.a/module.b/task.y")
+
+	g.Connect("root/module.a/module.b/task.y", "root/module.a/task.x")
+	g.Connect("root/task.z", "root/module.a/module.b/task.y")
+
+	collapsed := graph.Collapse(g, func(meta *node.Node) bool {
+		return meta.Value() == "module"
+	})
+
+	assert.Equal(t, []string{"root", "root/module.a", "root/task.z"}, sortedVertices(collapsed))
+	assert.Equal(
+		t,
+		[]string{
+			"root -> root/module.a [parent]",
+			"root -> root/task.z [parent]",
+			"root/task.z -> root/module.a []",
+		},
+		sortedEdges(collapsed),
+	)
+
+	// the original graph is left alone
+	_, ok := g.Get("root/module.a/task.x")
+	assert.True(t, ok)
+}
+
+func sortedVertices(g *graph.Graph) []string {
+	vertices := g.Vertices()
+	sort.Strings(vertices)
+	return vertices
+}
+
+func sortedEdges(g *graph.Graph) []string {
+	var edges []string
+	for _, edge := range g.Edges() {
+		edges = append(edges, fmt.Sprintf("%s -> %s %v", edge.Source, edge.Dest, edge.Attributes))
+	}
+	sort.Strings(edges)
+	return edges
+}
@@ -26,6 +26,56 @@ func NewParentEdge(parent, child string) *ParentEdge {
 	return &ParentEdge{Edge: dag.BasicEdge(parent, child)}
 }
 
+// ReasonKind is the kind of reference a dependency reason describes, like a
+// param or a lookup
+type ReasonKind string
+
+const (
+	// ReasonDepends is a node listed in `depends`
+	ReasonDepends ReasonKind = "depends"
+
+	// ReasonParam is a param used in a field
+	ReasonParam ReasonKind = "param"
+
+	// ReasonLookup is a value looked up from another node
+	ReasonLookup ReasonKind = "lookup"
+
+	// ReasonGroup is a group that runs one node at a time
+	ReasonGroup ReasonKind = "group"
+
+	// ReasonOwner is a user or group that owns what a node manages
+	ReasonOwner ReasonKind = "owner"
+
+	// ReasonBranch is a switch branch checked before another one
+	ReasonBranch ReasonKind = "branch"
+
+	// ReasonUnknown is a reason without a kind, or an edge without a reason
+	ReasonUnknown ReasonKind = "unknown"
+)
+
+// Reason is why one vertex depends on another
+type Reason struct {
+	// Kind is the kind of reference
+	Kind ReasonKind `json:"kind"`
+
+	// Text describes the reference, like "uses param \"name\""
+	Text string `json:"text"`
+}
+
+// DependencyEdge marks an edge as a dependency, recording why it was created
+type DependencyEdge struct {
+	dag.Edge
+
+	// Reasons describe why the source depends on the target
+	Reasons []Reason
+}
+
+// NewDependencyEdge constructs a new DependencyEdge between the given
+// vertices
+func NewDependencyEdge(from, to string, reasons ...Reason) *DependencyEdge {
+	return &DependencyEdge{Edge: dag.BasicEdge(from, to), Reasons: reasons}
+}
+
 // Sources gets the sources from slice of edges
 func Sources(edges []dag.Edge) (sources []string) {
 	for _, edge := range edges {
@@ -16,6 +16,7 @@ package graph
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 
@@ -41,6 +42,9 @@ type Edge struct {
 	Source     string   `json:"source"`
 	Dest       string   `json:"dest"`
 	Attributes []string `json:"attributes"`
+
+	// Reasons describe why the edge was created, if they were recorded
+	Reasons []Reason `json:"reasons,omitempty"`
 }
 
 // Graph is a generic graph structure that uses IDs to connect the graph
@@ -148,7 +152,8 @@ func (g *Graph) ConnectParent(from, to string) {
 	g.inner.Connect(NewParentEdge(from, to))
 }
 
-// Children returns a list of ids whose parent id is set to the specified node
+// Children returns a list of ids whose parent id is set to the specified node,
+// sorted so that searches through them always happen in the same order
 func (g *Graph) Children(id string) (out []string) {
 	downEdges := g.DownEdges(id)
 	g.innerLock.RLock()
@@ -158,6 +163,7 @@ func (g *Graph) Children(id string) (out []string) {
 			out = append(out, edge.Target().(string))
 		}
 	}
+	sort.Strings(out)
 	return
 }
 
@@ -183,6 +189,95 @@ func (g *Graph) SafeConnect(from, to string) error {
 	return nil
 }
 
+// ConnectBecause connects two vertices together by ID, recording why. If they
+// are already connected, the reasons are added to those of the existing edge.
+func (g *Graph) ConnectBecause(from, to string, reasons ...Reason) {
+	g.innerLock.Lock()
+	defer g.innerLock.Unlock()
+
+	g.connectBecause(from, to, reasons)
+}
+
+// SafeConnectBecause is ConnectBecause, but only connects the vertices if the
+// graph is still valid afterwards
+func (g *Graph) SafeConnectBecause(from, to string, reasons ...Reason) error {
+	g.innerLock.Lock()
+	defer g.innerLock.Unlock()
+
+	old, edge := g.connectBecause(from, to, reasons)
+
+	if err := g.Validate(); err != nil {
+		if edge != nil {
+			g.inner.RemoveEdge(edge)
+		}
+		if old != nil {
+			g.inner.Connect(old)
+		}
+		return err
+	}
+	return nil
+}
+
+// connectBecause replaces any edge between two vertices with one recording
+// the given reasons, returning the old and new edges. Parent edges are left
+// alone, in which case the new edge is nil.
+func (g *Graph) connectBecause(from, to string, reasons []Reason) (old, edge dag.Edge) {
+	old = g.edge(from, to)
+
+	var existing []Reason
+	switch typed := old.(type) {
+	case *ParentEdge:
+		return old, nil
+	case *DependencyEdge:
+		existing = typed.Reasons
+	}
+
+	merged := append([]Reason{}, existing...)
+	for _, reason := range reasons {
+		if !containsReason(merged, reason) {
+			merged = append(merged, reason)
+		}
+	}
+
+	if old != nil {
+		g.inner.RemoveEdge(old)
+	}
+	edge = NewDependencyEdge(from, to, merged...)
+	g.inner.Connect(edge)
+	return old, edge
+}
+
+// EdgeReasons returns why two vertices are connected, if that was recorded
+func (g *Graph) EdgeReasons(from, to string) []Reason {
+	g.innerLock.RLock()
+	defer g.innerLock.RUnlock()
+
+	if edge, ok := g.edge(from, to).(*DependencyEdge); ok {
+		return append([]Reason{}, edge.Reasons...)
+	}
+	return nil
+}
+
+// edge finds the edge between two vertices, if there is one. The caller must
+// hold the lock.
+func (g *Graph) edge(from, to string) dag.Edge {
+	for _, edge := range g.inner.Edges() {
+		if edge.Source().(string) == from && edge.Target().(string) == to {
+			return edge
+		}
+	}
+	return nil
+}
+
+func containsReason(list []Reason, r Reason) bool {
+	for _, item := range list {
+		if item == r {
+			return true
+		}
+	}
+	return false
+}
+
 // Disconnect two vertices by IDs
 func (g *Graph) Disconnect(from, to string) {
 	g.innerLock.Lock()
@@ -353,6 +448,9 @@ func dependencyWalk(rctx context.Context, g *Graph, cb WalkFunc) error {
 
 	wait := new(sync.WaitGroup)
 
+	// bound how many nodes execute at once, if requested
+	limiter := getLimiter(rctx)
+
 	// keep track of what we've scheduled so we don't schedule the same work
 	// twice
 	var worker func(id string)
@@ -394,7 +492,7 @@ func dependencyWalk(rctx context.Context, g *Graph, cb WalkFunc) error {
 			logger.WithField("id", id).Debug("waiting for id")
 			select {
 			case <-ctx.Done():
-				return nil
+				return ctx.Err()
 
 			case <-depChan:
 				if err := getErr(id); err != nil {
@@ -459,6 +557,23 @@ func dependencyWalk(rctx context.Context, g *Graph, cb WalkFunc) error {
 			return
 		}
 
+		// once cancelled, nodes which are already executing are left to wrap up
+		// but no new ones are started
+		if ctx.Err() != nil {
+			logger.WithField("id", id).Debug("cancelled before executing")
+			setErr(id, errDepFailed)
+			return
+		}
+
+		if limiter != nil {
+			if err := limiter.acquire(ctx, moduleGroup(id)); err != nil {
+				logger.WithField("id", id).Debug("cancelled while waiting for a slot")
+				setErr(id, errDepFailed)
+				return
+			}
+			defer limiter.release()
+		}
+
 		logger.WithField("id", id).Debug("executing")
 		val, _ := g.Get(id)
 		if err := cb(val); err != nil {
@@ -479,9 +594,11 @@ func dependencyWalk(rctx context.Context, g *Graph, cb WalkFunc) error {
 			}
 			err = multierror.Append(err, errors.Wrap(v, k))
 		}
-		return err
+		if err != nil {
+			return err
+		}
 	}
-	return nil
+	return rctx.Err()
 }
 
 // RootFirstWalk walks the graph root-to-leaf, checking sibling dependencies
@@ -672,6 +789,10 @@ func (g *Graph) Edges() []Edge {
 			edge.Attributes = append(edge.Attributes, "parent")
 		}
 
+		if dep, ok := srcEdge.(*DependencyEdge); ok {
+			edge.Reasons = append(edge.Reasons, dep.Reasons...)
+		}
+
 		edges[idx] = edge
 	}
 	return edges
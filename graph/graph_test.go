@@ -22,6 +22,7 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
@@ -222,6 +223,45 @@ func TestSafeConnect(t *testing.T) {
 	})
 }
 
+func TestSafeConnectBecause(t *testing.T) {
+	t.Parallel()
+
+	t.Run("records reasons", func(t *testing.T) {
+		g := graph.New()
+		g.Add(node.New("a", nil))
+		g.Add(node.New("b", nil))
+		g.Connect("a", "b")
+
+		param := graph.Reason{Kind: graph.ReasonParam, Text: "uses param \"b\""}
+		depends := graph.Reason{Kind: graph.ReasonDepends, Text: "listed in depends"}
+
+		require.NoError(t, g.SafeConnectBecause("a", "b", param))
+		require.NoError(t, g.SafeConnectBecause("a", "b", depends, param))
+
+		assert.Equal(t, []graph.Reason{param, depends}, g.EdgeReasons("a", "b"))
+		assert.Equal(t, []graph.Edge{{Source: "a", Dest: "b", Reasons: []graph.Reason{param, depends}}}, g.Edges())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		g := invalidGraph()
+		g.Add(node.New("a", nil))
+		g.Add(node.New("b", nil))
+
+		assert.Error(t, g.SafeConnectBecause("a", "b", graph.Reason{Kind: graph.ReasonUnknown, Text: "reason"}))
+		assert.Nil(t, g.EdgeReasons("a", "b"))
+	})
+
+	t.Run("parent", func(t *testing.T) {
+		g := graph.New()
+		g.Add(node.New("a", nil))
+		g.Add(node.New("b", nil))
+		g.ConnectParent("a", "b")
+
+		require.NoError(t, g.SafeConnectBecause("a", "b", graph.Reason{Kind: graph.ReasonUnknown, Text: "reason"}))
+		assert.Equal(t, []string{"b"}, g.Children("a"))
+	})
+}
+
 func TestDisconnect(t *testing.T) {
 	t.Parallel()
 
@@ -421,6 +461,38 @@ func TestWalkOrderParentDependency(t *testing.T) {
 	)
 }
 
+func TestWalkCancelled(t *testing.T) {
+	// once the context is cancelled, no new nodes should be started
+	defer logging.HideLogs(t)()
+
+	g := graph.New()
+	g.Add(node.New("root", nil))
+	g.Add(node.New("child1", nil))
+	g.Add(node.New("child2", nil))
+
+	g.ConnectParent("root", "child1")
+	g.ConnectParent("root", "child2")
+	g.Connect("child1", "child2")
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var (
+		lock sync.Mutex
+		out  []string
+	)
+	err := g.Walk(ctx, func(meta *node.Node) error {
+		lock.Lock()
+		defer lock.Unlock()
+
+		out = append(out, meta.ID)
+		cancel()
+		return nil
+	})
+
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, []string{"child2"}, out)
+}
+
 func TestWalkError(t *testing.T) {
 	g := graph.New()
 
@@ -690,3 +762,46 @@ func newGroupNode(id, group string, val interface{}) *node.Node {
 	n.Group = group
 	return n
 }
+
+func TestWalkParallelism(t *testing.T) {
+	// with bounded parallelism, no more than the given number of nodes should
+	// execute at once
+	defer logging.HideLogs(t)()
+
+	g := graph.New()
+	g.Add(node.New("root", nil))
+	for i := 0; i < 10; i++ {
+		id := graph.ID("root", "child"+strconv.Itoa(i))
+		g.Add(node.New(id, nil))
+		g.ConnectParent("root", id)
+	}
+
+	var (
+		lock          sync.Mutex
+		running, most int
+		executedRoot  bool
+	)
+	ctx := graph.WithParallelism(context.Background(), 2)
+	err := g.Walk(ctx, func(meta *node.Node) error {
+		lock.Lock()
+		running++
+		if running > most {
+			most = running
+		}
+		if meta.ID == "root" {
+			executedRoot = true
+		}
+		lock.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		lock.Lock()
+		running--
+		lock.Unlock()
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, executedRoot)
+	assert.Equal(t, 2, most)
+}
@@ -67,8 +67,9 @@ func MergeDuplicates(ctx context.Context, g *Graph, skip SkipMergeFunc) (*Graph,
 		// Point all inbound links to value to target instead
 		for _, src := range Sources(g.UpEdges(meta.ID)) {
 			logger.WithField("src", src).WithField("duplicate", meta.ID).WithField("target", target).Debug("re-pointing dependency")
+			reasons := out.EdgeReasons(src, meta.ID)
 			out.Disconnect(src, meta.ID)
-			out.Connect(src, target)
+			out.ConnectBecause(src, target, reasons...)
 		}
 
 		// Remove children and their edges
@@ -29,6 +29,34 @@ type Groupable interface {
 // metadata field.
 var ErrMetadataNotUnique = errors.New("metadata field is non-unique")
 
+// MetaSource is the metadata key under which the loader records the URL that
+// the root or a module node was loaded from
+const MetaSource = "source"
+
+// MetaSourceHash is the metadata key under which the loader records the hex
+// SHA256 of the content the root or a module node was loaded from
+const MetaSourceHash = "source-sha256"
+
+// MetaAllowDestructive is the metadata key which marks a node whose
+// destructive changes are applied without being allowed for the whole run
+const MetaAllowDestructive = "allow-destructive"
+
+// MetaChangeWindow is the metadata key under which the loader records the
+// *changewindow.Window a node's changes are limited to
+const MetaChangeWindow = "change-window"
+
+// MetaTransaction is the metadata key under which the loader records the name
+// of the transaction a node is a member of
+const MetaTransaction = "transaction"
+
+// MetaWarnings is the metadata key under which rendering records the
+// []resource.Warning raised while preparing a node, like deprecated fields
+const MetaWarnings = "warnings"
+
+// MetaProvenance is the metadata key under which rendering records the
+// []resource.Origin of the values rendered into a node's fields
+const MetaProvenance = "provenance"
+
 // Node tracks the metadata associated with a node in the graph
 type Node struct {
 	ID    string `json:"id"`
@@ -94,6 +122,15 @@ func (n *Node) LookupMetadata(key string) (interface{}, bool) {
 	return result, ok
 }
 
+// Metadata returns a copy of all metadata fields set on the node
+func (n *Node) Metadata() map[string]interface{} {
+	out := make(map[string]interface{}, len(n.metadata))
+	for k, v := range n.metadata {
+		out[k] = v
+	}
+	return out
+}
+
 // ShowMetadata will print out the existing metadata.  Used for debugging
 func (n *Node) ShowMetadata() string {
 	if n == nil {
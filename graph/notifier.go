@@ -14,11 +14,19 @@
 
 package graph
 
-import "github.com/asteris-llc/converge/graph/node"
+import (
+	"time"
+
+	"github.com/asteris-llc/converge/graph/node"
+)
 
 // NotifyFunc will be called before execution
 type NotifyFunc func(*node.Node) error
 
+// HeartbeatFunc is called while a node is still running, with how long it has
+// been running and the last line of its output, if any
+type HeartbeatFunc func(meta *node.Node, elapsed time.Duration, output string) error
+
 // NotifyPre will call a function before walking a node
 func NotifyPre(pre NotifyFunc, inner TransformFunc) TransformFunc {
 	return func(meta *node.Node, g *Graph) error {
@@ -47,6 +55,12 @@ func NotifyPost(post NotifyFunc, inner TransformFunc) TransformFunc {
 type Notifier struct {
 	Pre  NotifyFunc
 	Post NotifyFunc
+
+	// Heartbeat is called every HeartbeatInterval for nodes that are still
+	// running, starting once they have run for HeartbeatInterval. Heartbeats
+	// are only sent by executions that call StartHeartbeat.
+	Heartbeat         HeartbeatFunc
+	HeartbeatInterval time.Duration
 }
 
 // Transform wraps a TransformFunc with this notifier
@@ -65,3 +79,38 @@ func (n *Notifier) Transform(inner TransformFunc) TransformFunc {
 
 	return inner
 }
+
+// StartHeartbeat calls Heartbeat for a node until the returned function is
+// called, which waits for any heartbeat in progress so none are sent after
+// it returns. Errors from Heartbeat are ignored, since the node is still
+// running; a broken stream will show up when the node finishes.
+func (n *Notifier) StartHeartbeat(meta *node.Node, output func() string) (stop func()) {
+	if n == nil || n.Heartbeat == nil || n.HeartbeatInterval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		started := time.Now()
+		ticker := time.NewTicker(n.HeartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = n.Heartbeat(meta, time.Since(started), output())
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
@@ -17,6 +17,7 @@ package graph_test
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
@@ -120,3 +121,33 @@ func TestNotifyTransform(t *testing.T) {
 		)
 	})
 }
+
+func TestNotifierStartHeartbeat(t *testing.T) {
+	meta := node.New("root", 1)
+
+	t.Run("nil", func(t *testing.T) {
+		var notifier *graph.Notifier
+		notifier.StartHeartbeat(meta, func() string { return "" })()
+	})
+
+	t.Run("beats until stopped", func(t *testing.T) {
+		beats := make(chan string, 100)
+		notifier := &graph.Notifier{
+			Heartbeat: func(beat *node.Node, elapsed time.Duration, output string) error {
+				assert.Equal(t, "root", beat.ID)
+				assert.True(t, elapsed >= time.Millisecond)
+				beats <- output
+				return nil
+			},
+			HeartbeatInterval: time.Millisecond,
+		}
+
+		stop := notifier.StartHeartbeat(meta, func() string { return "working" })
+		assert.Equal(t, "working", <-beats)
+		stop()
+
+		count := len(beats)
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(t, count, len(beats), "heartbeat sent after stopping")
+	})
+}
@@ -0,0 +1,167 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+type parallelismKey struct{}
+
+// WithParallelism returns a context in which walks execute at most n nodes at
+// once. When nodes are waiting for a slot, slots are handed out round-robin
+// across the top-level modules of the graph, so a module with many ready nodes
+// can't starve the others. A value of zero or less means no limit.
+func WithParallelism(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, parallelismKey{}, n)
+}
+
+// getLimiter returns a limiter for the parallelism in ctx, or nil if there is
+// no limit
+func getLimiter(ctx context.Context) *fairLimiter {
+	n, _ := ctx.Value(parallelismKey{}).(int)
+	if n <= 0 {
+		return nil
+	}
+	return newFairLimiter(n)
+}
+
+// moduleGroup is the ID of the top-level module or resource containing id.
+// Nodes in the same group share turns when waiting for a slot.
+func moduleGroup(id string) string {
+	parts := strings.SplitN(id, "/", 3)
+	if len(parts) < 2 {
+		return id
+	}
+	return ID(parts[0], parts[1])
+}
+
+// fairLimiter limits how many callers hold a slot at once. Waiters are queued
+// by group and the groups are served in turn.
+type fairLimiter struct {
+	lock  sync.Mutex
+	slots int
+
+	queues map[string][]chan struct{}
+	order  []string
+	next   int
+}
+
+func newFairLimiter(n int) *fairLimiter {
+	return &fairLimiter{
+		slots:  n,
+		queues: map[string][]chan struct{}{},
+	}
+}
+
+// acquire waits for a slot on behalf of group. Callers must call release once
+// they're done with a slot.
+func (l *fairLimiter) acquire(ctx context.Context, group string) error {
+	l.lock.Lock()
+	if l.slots > 0 && len(l.order) == 0 {
+		l.slots--
+		l.lock.Unlock()
+		return nil
+	}
+
+	ready := make(chan struct{})
+	if _, ok := l.queues[group]; !ok {
+		l.order = append(l.order, group)
+	}
+	l.queues[group] = append(l.queues[group], ready)
+	l.lock.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+
+	case <-ctx.Done():
+		l.lock.Lock()
+		removed := l.remove(group, ready)
+		l.lock.Unlock()
+
+		// the slot may have been handed over while we were cancelled, in
+		// which case it needs to go to the next waiter
+		if !removed {
+			l.release()
+		}
+		return ctx.Err()
+	}
+}
+
+// release gives up a slot, handing it to the next group in turn if anyone is
+// waiting
+func (l *fairLimiter) release() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if len(l.order) == 0 {
+		l.slots++
+		return
+	}
+
+	if l.next >= len(l.order) {
+		l.next = 0
+	}
+	group := l.order[l.next]
+
+	queue := l.queues[group]
+	close(queue[0])
+
+	if len(queue) == 1 {
+		l.dropGroup(l.next)
+	} else {
+		l.queues[group] = queue[1:]
+		l.next++
+	}
+}
+
+// remove takes a waiter out of its group's queue, reporting whether it was
+// still waiting
+func (l *fairLimiter) remove(group string, ready chan struct{}) bool {
+	queue := l.queues[group]
+	for i, waiter := range queue {
+		if waiter != ready {
+			continue
+		}
+
+		if len(queue) > 1 {
+			l.queues[group] = append(queue[:i], queue[i+1:]...)
+			return true
+		}
+
+		for j, name := range l.order {
+			if name == group {
+				l.dropGroup(j)
+				break
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// dropGroup removes the group at index i from the rotation. The group after it
+// takes its place, so it's up next.
+func (l *fairLimiter) dropGroup(i int) {
+	delete(l.queues, l.order[i])
+	l.order = append(l.order[:i], l.order[i+1:]...)
+	if l.next > i {
+		l.next--
+	}
+}
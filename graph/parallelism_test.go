@@ -0,0 +1,111 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestModuleGroup(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "root", moduleGroup("root"))
+	assert.Equal(t, "root/module.a", moduleGroup("root/module.a"))
+	assert.Equal(t, "root/module.a", moduleGroup("root/module.a/module.b/task.x"))
+	assert.Equal(t, "root/task.x", moduleGroup("root/task.x"))
+}
+
+func TestFairLimiter(t *testing.T) {
+	t.Parallel()
+
+	// waiting reports how many callers are queued
+	waiting := func(l *fairLimiter) int {
+		l.lock.Lock()
+		defer l.lock.Unlock()
+
+		var n int
+		for _, queue := range l.queues {
+			n += len(queue)
+		}
+		return n
+	}
+
+	// enqueue starts a waiter and blocks until it's queued, so waiters are
+	// queued in a known order
+	enqueue := func(t *testing.T, l *fairLimiter, ctx context.Context, group, name string, out chan string) {
+		before := waiting(l)
+		go func() {
+			if err := l.acquire(ctx, group); err != nil {
+				out <- fmt.Sprintf("%s: %s", name, err)
+				return
+			}
+			out <- name
+		}()
+		for i := 0; waiting(l) == before; i++ {
+			require.True(t, i < 1000, "%s was never queued", name)
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	t.Run("round-robin", func(t *testing.T) {
+		l := newFairLimiter(1)
+		out := make(chan string)
+		ctx := context.Background()
+
+		require.NoError(t, l.acquire(ctx, "root/module.big"))
+
+		enqueue(t, l, ctx, "root/module.big", "big1", out)
+		enqueue(t, l, ctx, "root/module.big", "big2", out)
+		enqueue(t, l, ctx, "root/module.big", "big3", out)
+		enqueue(t, l, ctx, "root/module.monitor", "monitor", out)
+
+		var order []string
+		for i := 0; i < 4; i++ {
+			l.release()
+			order = append(order, <-out)
+		}
+		l.release()
+
+		assert.Equal(t, []string{"big1", "monitor", "big2", "big3"}, order)
+		assert.Equal(t, 1, l.slots)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		l := newFairLimiter(1)
+		out := make(chan string)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		require.NoError(t, l.acquire(context.Background(), "root/module.a"))
+
+		enqueue(t, l, ctx, "root/module.a", "a1", out)
+		enqueue(t, l, context.Background(), "root/module.b", "b1", out)
+
+		cancel()
+		assert.Equal(t, "a1: context canceled", <-out)
+
+		l.release()
+		assert.Equal(t, "b1", <-out)
+
+		l.release()
+		assert.Equal(t, 1, l.slots)
+		assert.Empty(t, l.order)
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+var dumperKey = struct{ name string }{"snapshot-dumper"}
+
+// Dumper writes a snapshot to a directory after each phase
+type Dumper struct {
+	Dir        string
+	Location   string
+	Parameters map[string]string
+
+	lock sync.Mutex
+	seq  int
+	last *Snapshot
+}
+
+// NewDumper creates a Dumper writing into the given directory
+func NewDumper(dir, location string, params map[string]string) *Dumper {
+	return &Dumper{
+		Dir:        dir,
+		Location:   location,
+		Parameters: params,
+	}
+}
+
+// Dump writes a snapshot of the graph after the given phase. Sources seen in
+// earlier phases are carried forward so later snapshots can be replayed once
+// node values are no longer parsed HCL.
+func (d *Dumper) Dump(phase string, g *graph.Graph) (string, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	snap, err := New(phase, g)
+	if err != nil {
+		return "", err
+	}
+	snap.Location = d.Location
+	snap.Parameters = d.Parameters
+
+	snap.InheritSources(d.last)
+	d.last = snap
+
+	if err := os.MkdirAll(d.Dir, 0755); err != nil {
+		return "", errors.Wrap(err, "could not create snapshot directory")
+	}
+
+	d.seq++
+	path := filepath.Join(d.Dir, fmt.Sprintf("%02d-%s.json", d.seq, phase))
+
+	return path, snap.Write(path)
+}
+
+// WithDumper sets a Dumper on a context
+func WithDumper(ctx context.Context, d *Dumper) context.Context {
+	return context.WithValue(ctx, dumperKey, d)
+}
+
+// Dump writes a snapshot of the graph if the context carries a Dumper. Failing
+// to write a snapshot is logged but does not interrupt loading.
+func Dump(ctx context.Context, phase string, g *graph.Graph) {
+	d, ok := ctx.Value(dumperKey).(*Dumper)
+	if !ok || d == nil {
+		return
+	}
+
+	logger := logging.GetLogger(ctx).WithField("phase", phase)
+
+	path, err := d.Dump(phase, g)
+	if err != nil {
+		logger.WithError(err).Warn("could not write graph snapshot")
+		return
+	}
+
+	logger.WithField("path", path).Debug("wrote graph snapshot")
+}
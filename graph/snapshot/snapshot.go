@@ -0,0 +1,322 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package snapshot serializes graphs between loading phases so that a single
+// phase can be replayed later for debugging.
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/parse"
+	"github.com/hashicorp/hcl/hcl/printer"
+	"github.com/pkg/errors"
+)
+
+// Phases that are captured in snapshots, in the order they are run
+const (
+	PhaseNodes     = "nodes"
+	PhaseResolve   = "resolve"
+	PhaseResources = "resources"
+	PhaseRender    = "render"
+)
+
+// Phases lists all phases in the order they are run
+var Phases = []string{PhaseNodes, PhaseResolve, PhaseResources, PhaseRender}
+
+// Next returns the phase that runs after the given one
+func Next(phase string) (string, bool) {
+	for i, candidate := range Phases {
+		if candidate == phase && i+1 < len(Phases) {
+			return Phases[i+1], true
+		}
+	}
+	return "", false
+}
+
+// Snapshot is the serialized form of a graph after a given phase
+type Snapshot struct {
+	Phase      string            `json:"phase"`
+	Location   string            `json:"location"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+	Vertices   []*Vertex         `json:"vertices"`
+	Edges      []graph.Edge      `json:"edges"`
+}
+
+// Vertex is the serialized form of a single node in the graph
+type Vertex struct {
+	ID       string                 `json:"id"`
+	Group    string                 `json:"group,omitempty"`
+	Type     string                 `json:"type,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// Source is the HCL the node was parsed from. It is used to rebuild the
+	// graph when replaying.
+	Source string `json:"source,omitempty"`
+
+	// Value is a human-readable form of the node value, for inspection only
+	Value string `json:"value,omitempty"`
+}
+
+// New creates a snapshot of the given graph
+func New(phase string, g *graph.Graph) (*Snapshot, error) {
+	snap := &Snapshot{
+		Phase: phase,
+		Edges: g.Edges(),
+	}
+
+	for _, meta := range g.Nodes() {
+		vertex := &Vertex{
+			ID:       meta.ID,
+			Group:    meta.Group,
+			Metadata: meta.Metadata(),
+		}
+
+		switch value := meta.Value().(type) {
+		case nil:
+		case *parse.Node:
+			vertex.Type = fmt.Sprintf("%T", value)
+
+			source, err := Source(value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not print %s", meta.ID)
+			}
+			vertex.Source = source
+		default:
+			vertex.Type = fmt.Sprintf("%T", value)
+			vertex.Value = fmt.Sprintf("%+v", value)
+		}
+
+		snap.Vertices = append(snap.Vertices, vertex)
+	}
+
+	sort.Sort(verticesByID(snap.Vertices))
+	sort.Sort(edgesByEndpoints(snap.Edges))
+
+	return snap, nil
+}
+
+// Source prints the HCL for a parsed node
+func Source(n *parse.Node) (string, error) {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, n.ObjectItem); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Read loads a snapshot from disk
+func Read(path string) (*Snapshot, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := new(Snapshot)
+	if err := json.Unmarshal(content, snap); err != nil {
+		return nil, errors.Wrapf(err, "could not parse snapshot %s", path)
+	}
+
+	return snap, nil
+}
+
+// Marshal serializes the snapshot as indented JSON
+func (s *Snapshot) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+
+	// HCL is full of characters that would otherwise be escaped, which makes
+	// the source hard to read
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Write saves the snapshot to the given path
+func (s *Snapshot) Write(path string) error {
+	content, err := s.Marshal()
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, content, 0644)
+}
+
+// InheritSources copies sources from an earlier snapshot onto vertices that
+// don't have one, such as after resources have been set on the graph.
+func (s *Snapshot) InheritSources(prev *Snapshot) {
+	if prev == nil {
+		return
+	}
+
+	sources := make(map[string]string, len(prev.Vertices))
+	for _, vertex := range prev.Vertices {
+		sources[vertex.ID] = vertex.Source
+	}
+
+	for _, vertex := range s.Vertices {
+		if vertex.Source == "" {
+			vertex.Source = sources[vertex.ID]
+		}
+	}
+}
+
+// Affected compares the snapshot to an earlier one of the same module and
+// returns the IDs of the nodes that need to run again: nodes that are new or
+// whose source changed, the nodes inside of them, and the nodes that depend
+// on any of those. Nodes that were removed are not included.
+func (s *Snapshot) Affected(prev *Snapshot) []string {
+	sources := make(map[string]string, len(prev.Vertices))
+	for _, vertex := range prev.Vertices {
+		sources[vertex.ID] = vertex.Source
+	}
+
+	dependents := map[string][]string{}
+	for _, edge := range s.Edges {
+		if !isParentEdge(edge) {
+			dependents[edge.Dest] = append(dependents[edge.Dest], edge.Source)
+		}
+	}
+
+	affected := map[string]bool{}
+	var visit func(id string)
+	visit = func(id string) {
+		if affected[id] {
+			return
+		}
+		affected[id] = true
+
+		for _, vertex := range s.Vertices {
+			if strings.HasPrefix(vertex.ID, id+"/") {
+				visit(vertex.ID)
+			}
+		}
+		for _, dependent := range dependents[id] {
+			visit(dependent)
+		}
+	}
+
+	for _, vertex := range s.Vertices {
+		if source, ok := sources[vertex.ID]; !ok || source != vertex.Source {
+			visit(vertex.ID)
+		}
+	}
+
+	var out []string
+	for id := range affected {
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Graph rebuilds a graph of parsed nodes from the snapshot. Every vertex
+// except the root needs to have its source set.
+func (s *Snapshot) Graph() (*graph.Graph, error) {
+	out := graph.New()
+
+	for _, vertex := range s.Vertices {
+		var value interface{}
+
+		if !graph.IsRoot(vertex.ID) {
+			if vertex.Source == "" {
+				return nil, fmt.Errorf("%s: no source in snapshot", vertex.ID)
+			}
+
+			nodes, err := parse.Parse([]byte(vertex.Source))
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s: could not parse source", vertex.ID)
+			}
+			if len(nodes) != 1 {
+				return nil, fmt.Errorf("%s: expected a single node in source, got %d", vertex.ID, len(nodes))
+			}
+			value = nodes[0]
+		}
+
+		meta := node.New(vertex.ID, value)
+		for key, val := range vertex.Metadata {
+			if err := meta.AddMetadata(key, restoreMetadata(val)); err != nil {
+				return nil, errors.Wrapf(err, "%s: could not restore metadata %q", vertex.ID, key)
+			}
+		}
+
+		out.Add(meta)
+	}
+
+	for _, edge := range s.Edges {
+		if isParentEdge(edge) {
+			out.ConnectParent(edge.Source, edge.Dest)
+		} else {
+			out.Connect(edge.Source, edge.Dest)
+		}
+	}
+
+	return out, out.Validate()
+}
+
+func isParentEdge(edge graph.Edge) bool {
+	for _, attr := range edge.Attributes {
+		if attr == "parent" {
+			return true
+		}
+	}
+	return false
+}
+
+// restoreMetadata converts lists of strings back to []string after a round
+// trip through JSON, since that's what the conditional metadata uses.
+func restoreMetadata(val interface{}) interface{} {
+	list, ok := val.([]interface{})
+	if !ok {
+		return val
+	}
+
+	out := make([]string, len(list))
+	for i, item := range list {
+		str, ok := item.(string)
+		if !ok {
+			return val
+		}
+		out[i] = str
+	}
+	return out
+}
+
+type verticesByID []*Vertex
+
+func (v verticesByID) Len() int           { return len(v) }
+func (v verticesByID) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
+func (v verticesByID) Less(i, j int) bool { return v[i].ID < v[j].ID }
+
+type edgesByEndpoints []graph.Edge
+
+func (e edgesByEndpoints) Len() int      { return len(e) }
+func (e edgesByEndpoints) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
+func (e edgesByEndpoints) Less(i, j int) bool {
+	if e[i].Source == e[j].Source {
+		return e[i].Dest < e[j].Dest
+	}
+	return e[i].Source < e[j].Source
+}
@@ -0,0 +1,217 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/graph/node/conditional"
+	"github.com/asteris-llc/converge/graph/snapshot"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/helpers/testing/hclutils"
+	"github.com/asteris-llc/converge/load"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+const sample = `
+param "message" {
+  default = "hello"
+}
+
+task "echo" {
+  check = "test -f out.txt"
+  apply = "echo hello > out.txt"
+}
+
+switch "test" {
+  case "true" "a" {
+    file.content "a" {
+      destination = "a.txt"
+    }
+  }
+
+  default {
+    file.content "b" {
+      destination = "b.txt"
+    }
+  }
+}
+`
+
+func TestNext(t *testing.T) {
+	t.Parallel()
+
+	next, ok := snapshot.Next(snapshot.PhaseNodes)
+	assert.True(t, ok)
+	assert.Equal(t, snapshot.PhaseResolve, next)
+
+	_, ok = snapshot.Next(snapshot.PhaseRender)
+	assert.False(t, ok)
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	nodes, err := hclutils.LoadFromString("snapshot", sample)
+	require.NoError(t, err)
+
+	snap, err := snapshot.New(snapshot.PhaseNodes, nodes)
+	require.NoError(t, err)
+
+	tmpdir, err := ioutil.TempDir("", "converge-snapshot")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	path := filepath.Join(tmpdir, "nodes.json")
+	require.NoError(t, snap.Write(path))
+
+	read, err := snapshot.Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot.PhaseNodes, read.Phase)
+
+	rebuilt, err := read.Graph()
+	require.NoError(t, err)
+
+	expected, actual := nodes.Vertices(), rebuilt.Vertices()
+	sort.Strings(expected)
+	sort.Strings(actual)
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, len(nodes.Edges()), len(rebuilt.Edges()))
+
+	t.Run("metadata", func(t *testing.T) {
+		meta, ok := rebuilt.Get("root/macro.switch.test/macro.case.a/file.content.a")
+		require.True(t, ok)
+
+		peers, ok := meta.LookupMetadata(conditional.MetaPeers)
+		require.True(t, ok)
+		assert.Equal(t, []string{"macro.case.a", "macro.case.default"}, peers)
+	})
+
+	t.Run("resolve", func(t *testing.T) {
+		expected, err := load.ResolveDependencies(context.Background(), nodes)
+		require.NoError(t, err)
+
+		actual, err := load.ResolveDependencies(context.Background(), rebuilt)
+		require.NoError(t, err)
+
+		assert.Equal(t, expected.String(), actual.String())
+	})
+}
+
+func TestDumper(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	tmpdir, err := ioutil.TempDir("", "converge-snapshot")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	src := filepath.Join(tmpdir, "sample.hcl")
+	require.NoError(t, ioutil.WriteFile(src, []byte(sample), 0600))
+
+	dir := filepath.Join(tmpdir, "snapshots")
+	ctx := snapshot.WithDumper(context.Background(), snapshot.NewDumper(dir, src, nil))
+
+	_, err = load.Load(ctx, src, false)
+	require.NoError(t, err)
+
+	for _, name := range []string{"01-nodes.json", "02-resolve.json", "03-resources.json"} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.NoError(t, err, name)
+	}
+
+	t.Run("sources are carried forward", func(t *testing.T) {
+		snap, err := snapshot.Read(filepath.Join(dir, "03-resources.json"))
+		require.NoError(t, err)
+
+		for _, vertex := range snap.Vertices {
+			if vertex.ID == "root" {
+				continue
+			}
+			assert.NotEmpty(t, vertex.Source, vertex.ID)
+			assert.NotEmpty(t, vertex.Value, vertex.ID)
+		}
+
+		_, err = snap.Graph()
+		assert.NoError(t, err)
+	})
+}
+
+func TestSnapshotAffected(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	snap := func(content string) *snapshot.Snapshot {
+		nodes, err := hclutils.LoadFromString("affected", content)
+		require.NoError(t, err)
+
+		resolved, err := load.ResolveDependencies(context.Background(), nodes)
+		require.NoError(t, err)
+
+		out, err := snapshot.New(snapshot.PhaseResolve, resolved)
+		require.NoError(t, err)
+		return out
+	}
+
+	const before = `
+param "message" {
+  default = "hello"
+}
+
+task "echo" {
+  check = "false"
+  apply = "echo {{param ` + "`message`" + `}}"
+}
+
+task "other" {
+  check = "true"
+  apply = "true"
+}
+`
+
+	prev := snap(before)
+
+	t.Run("unchanged", func(t *testing.T) {
+		assert.Empty(t, snap(before).Affected(prev))
+	})
+
+	t.Run("dependency changed", func(t *testing.T) {
+		changed := strings.Replace(before, `"hello"`, `"goodbye"`, 1)
+
+		assert.Equal(t, []string{"root/param.message", "root/task.echo"}, snap(changed).Affected(prev))
+	})
+
+	t.Run("node changed", func(t *testing.T) {
+		changed := strings.Replace(before, `check = "true"`, `check = "false"`, 1)
+
+		assert.Equal(t, []string{"root/task.other"}, snap(changed).Affected(prev))
+	})
+
+	t.Run("node added", func(t *testing.T) {
+		changed := before + `
+task "new" {
+  check = "true"
+  apply = "true"
+}
+`
+
+		assert.Equal(t, []string{"root/task.new"}, snap(changed).Affected(prev))
+	})
+}
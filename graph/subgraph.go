@@ -0,0 +1,47 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+// Subgraph returns a copy of the graph with only what is needed to run the
+// nodes with the given IDs: the nodes, everything they depend on, and the
+// nodes containing any of those. IDs that aren't in the graph are ignored, since
+// nodes can be merged or skipped while loading.
+func Subgraph(g *Graph, ids []string) *Graph {
+	keep := map[string]bool{}
+	for _, id := range ids {
+		if !g.Contains(id) {
+			continue
+		}
+
+		keep[id] = true
+		for _, dep := range g.Dependencies(id) {
+			keep[dep] = true
+		}
+	}
+
+	for id := range keep {
+		for parent := ParentID(id); g.Contains(parent); parent = ParentID(parent) {
+			keep[parent] = true
+		}
+	}
+
+	out := g.Copy()
+	for _, id := range g.Vertices() {
+		if !keep[id] {
+			out.Remove(id)
+		}
+	}
+	return out
+}
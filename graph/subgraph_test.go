@@ -0,0 +1,80 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubgraph(t *testing.T) {
+	t.Parallel()
+
+	g := graph.New()
+	g.Add(node.New("root", nil))
+	g.Add(node.New("root/param.x", "param"))
+	g.Add(node.New("root/module.a", "module"))
+	g.Add(node.New("root/module.a/task.x", "task"))
+	g.Add(node.New("root/module.a/task.y", "task"))
+	g.Add(node.New("root/task.z", "task"))
+
+	g.ConnectParent("root", "root/param.x")
+	g.ConnectParent("root", "root/module.a")
+	g.ConnectParent("root", "root/task.z")
+	g.ConnectParent("root/module.a", "root/module.a/task.x")
+	g.ConnectParent("root/module.a", "root/module.a/task.y")
+
+	g.Connect("root/module.a/task.x", "root/param.x")
+	g.Connect("root/task.z", "root/module.a/task.y")
+
+	t.Run("node", func(t *testing.T) {
+		sub := graph.Subgraph(g, []string{"root/module.a/task.x"})
+
+		assert.Equal(t, []string{"root", "root/module.a", "root/module.a/task.x", "root/param.x"}, sortedVertices(sub))
+		assert.Equal(
+			t,
+			[]string{
+				"root -> root/module.a [parent]",
+				"root -> root/param.x [parent]",
+				"root/module.a -> root/module.a/task.x [parent]",
+				"root/module.a/task.x -> root/param.x []",
+			},
+			sortedEdges(sub),
+		)
+		require.NoError(t, sub.Validate())
+	})
+
+	t.Run("module", func(t *testing.T) {
+		sub := graph.Subgraph(g, []string{"root/module.a"})
+
+		assert.Equal(t, []string{"root", "root/module.a", "root/module.a/task.x", "root/module.a/task.y", "root/param.x"}, sortedVertices(sub))
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		sub := graph.Subgraph(g, []string{"root/task.missing", "root/task.z"})
+
+		assert.Equal(t, []string{"root", "root/module.a", "root/module.a/task.y", "root/task.z"}, sortedVertices(sub))
+	})
+
+	t.Run("original unchanged", func(t *testing.T) {
+		graph.Subgraph(g, []string{"root/task.z"})
+
+		assert.Len(t, g.Vertices(), 6)
+	})
+}
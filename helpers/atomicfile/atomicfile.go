@@ -0,0 +1,59 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package atomicfile writes files through a temporary file which is renamed
+// into place, so readers never see them partially written
+package atomicfile
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// WriteFile writes content to path with the given mode. The temporary file is
+// created next to path, so the rename stays on one filesystem, and is removed
+// if the write fails.
+func WriteFile(path string, content io.Reader, mode os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return errors.Wrapf(err, "cannot write %s", path)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "cannot write %s", path)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "cannot write %s", path)
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return errors.Wrapf(err, "cannot set mode of %s", path)
+	}
+	return errors.Wrapf(os.Rename(tmp.Name(), path), "cannot write %s", path)
+}
+
+// WriteLines writes lines to path, each ending in a newline
+func WriteLines(path string, lines []string, mode os.FileMode) error {
+	content := ""
+	if len(lines) > 0 {
+		content = strings.Join(lines, "\n") + "\n"
+	}
+	return WriteFile(path, strings.NewReader(content), mode)
+}
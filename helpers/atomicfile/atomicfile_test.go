@@ -0,0 +1,69 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package atomicfile_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/atomicfile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteLines(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		name    string
+		lines   []string
+		content string
+	}{
+		{"lines", []string{"a", "b"}, "a\nb\n"},
+		{"empty", nil, ""},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "atomicfile")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "file")
+			require.NoError(t, ioutil.WriteFile(path, []byte("old\n"), 0644))
+			require.NoError(t, atomicfile.WriteLines(path, test.lines, 0600))
+
+			content, err := ioutil.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, test.content, string(content))
+
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+			entries, err := ioutil.ReadDir(dir)
+			require.NoError(t, err)
+			assert.Len(t, entries, 1, "temporary file left behind")
+		})
+	}
+}
+
+func TestWriteFileMissingDir(t *testing.T) {
+	t.Parallel()
+
+	err := atomicfile.WriteLines(filepath.Join(os.TempDir(), "atomicfile-missing", "file"), nil, 0644)
+	assert.Error(t, err)
+}
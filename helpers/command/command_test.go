@@ -0,0 +1,49 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/command"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError(t *testing.T) {
+	t.Parallel()
+
+	t.Run("succeeded", func(t *testing.T) {
+		assert.NoError(t, command.Error("chattr", []byte("ignored"), nil))
+	})
+
+	t.Run("with output", func(t *testing.T) {
+		err := command.Error("chattr", []byte("Operation not permitted\n"), errors.New("exit status 1"))
+		assert.EqualError(t, err, "chattr: exit status 1: Operation not permitted")
+	})
+
+	t.Run("without output", func(t *testing.T) {
+		err := command.Error("chattr", nil, errors.New("exit status 1"))
+		assert.EqualError(t, err, "chattr: exit status 1")
+	})
+}
+
+func TestExecRunner(t *testing.T) {
+	t.Parallel()
+
+	out, err := command.ExecRunner{}.Run("echo", "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello\n", string(out))
+}
@@ -14,13 +14,19 @@
 
 package fakerenderer
 
-import "github.com/asteris-llc/converge/resource"
+import (
+	"github.com/asteris-llc/converge/fetch"
+	"github.com/asteris-llc/converge/resource"
+)
 
 // FakeRenderer is a pass-through renderer for testing resources
 type FakeRenderer struct {
 	ID           string
 	DotValue     resource.Value
 	ValuePresent bool
+
+	// SourceURL is the URL of the module the node was loaded from
+	SourceURL string
 }
 
 // GetID returns the ID of this renderer
@@ -38,6 +44,30 @@ func (fr *FakeRenderer) Render(name, content string) (string, error) {
 	return content, nil
 }
 
+// Source returns the module URL
+func (fr *FakeRenderer) Source() string {
+	return fr.SourceURL
+}
+
+// SourceDir returns the URL of the module's directory
+func (fr *FakeRenderer) SourceDir() string {
+	return fetch.Dir(fr.SourceURL)
+}
+
+// ResolvePath resolves a location relative to the module
+func (fr *FakeRenderer) ResolvePath(loc string) (string, error) {
+	return fetch.ResolveInContext(loc, fr.SourceURL)
+}
+
+// LocalPath resolves a location relative to the module to a filesystem path
+func (fr *FakeRenderer) LocalPath(loc string) (string, error) {
+	url, err := fr.ResolvePath(loc)
+	if err != nil {
+		return "", err
+	}
+	return fetch.LocalPath(url)
+}
+
 // New gets a default FakeRenderer
 func New() *FakeRenderer {
 	return new(FakeRenderer)
@@ -52,6 +82,14 @@ func NewWithValue(val string) *FakeRenderer {
 	return fr
 }
 
+// NewWithSource gets a FakeRenderer loaded from the specified module URL
+func NewWithSource(source string) *FakeRenderer {
+	fr := New()
+	fr.SourceURL = source
+
+	return fr
+}
+
 // NewWithID gets a FakeRenderer with the specified ID
 func NewWithID(id string) *FakeRenderer {
 	fr := New()
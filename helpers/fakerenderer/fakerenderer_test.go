@@ -27,3 +27,14 @@ func TestFakeRendererInterface(t *testing.T) {
 
 	assert.Implements(t, (*resource.Renderer)(nil), new(fakerenderer.FakeRenderer))
 }
+
+func TestFakeRendererLocalPath(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.NewWithSource("file:///modules/app/main.hcl")
+	assert.Equal(t, "file:///modules/app", fr.SourceDir())
+
+	local, err := fr.LocalPath("files/app.conf")
+	assert.NoError(t, err)
+	assert.Equal(t, "/modules/app/files/app.conf", local)
+}
@@ -16,6 +16,8 @@ package faketask
 
 import (
 	"errors"
+	"sync"
+	"time"
 
 	"github.com/asteris-llc/converge/resource"
 	"golang.org/x/net/context"
@@ -121,3 +123,145 @@ func Swapper() *FakeSwapper {
 		Error:      nil,
 	}
 }
+
+// PanicTask panics on Check/Apply calls
+type PanicTask struct {
+	Value interface{}
+}
+
+// Check always panics
+func (pt *PanicTask) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	panic(pt.Value)
+}
+
+// Apply always panics
+func (pt *PanicTask) Apply(context.Context) (resource.TaskStatus, error) {
+	panic(pt.Value)
+}
+
+// Panic returns a PanicTask that will panic while checking or applying
+func Panic() *PanicTask {
+	return &PanicTask{Value: "task panicked"}
+}
+
+// ClaimingTask records how many tasks sharing its counter are running at once
+type ClaimingTask struct {
+	Claim resource.Claim
+
+	counter *ConcurrencyCounter
+	applied bool
+}
+
+// Claims returns the configured claim
+func (ct *ClaimingTask) Claims() []resource.Claim {
+	return []resource.Claim{ct.Claim}
+}
+
+// Check runs briefly and reports that changes are needed until applied
+func (ct *ClaimingTask) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	ct.counter.run()
+	if ct.applied {
+		return &resource.Status{Level: resource.StatusNoChange}, nil
+	}
+	return &resource.Status{Level: resource.StatusWillChange}, nil
+}
+
+// Apply runs briefly
+func (ct *ClaimingTask) Apply(context.Context) (resource.TaskStatus, error) {
+	ct.counter.run()
+	ct.applied = true
+	return &resource.Status{}, nil
+}
+
+// Claiming returns a ClaimingTask which makes the given claim
+func Claiming(claim resource.Claim, counter *ConcurrencyCounter) *ClaimingTask {
+	return &ClaimingTask{Claim: claim, counter: counter}
+}
+
+// CleaningTask wraps a task and records whether it was cleaned up
+type CleaningTask struct {
+	resource.Task
+
+	CleanedUp bool
+}
+
+// Cleanup records that the task was cleaned up
+func (ct *CleaningTask) Cleanup(context.Context) error {
+	ct.CleanedUp = true
+	return nil
+}
+
+// Cleaning returns a CleaningTask wrapping the given task
+func Cleaning(task resource.Task) *CleaningTask {
+	return &CleaningTask{Task: task}
+}
+
+// RollbackTask wraps a task and records when it is rolled back
+type RollbackTask struct {
+	resource.Task
+
+	name  string
+	order *[]string
+}
+
+// Rollback records that the task was rolled back
+func (rt *RollbackTask) Rollback(context.Context) error {
+	*rt.order = append(*rt.order, rt.name)
+	return nil
+}
+
+// RollingBack returns a RollbackTask wrapping the given task, which appends
+// name to order when it is rolled back
+func RollingBack(task resource.Task, name string, order *[]string) *RollbackTask {
+	return &RollbackTask{Task: task, name: name, order: order}
+}
+
+// ReapplyTask wraps a task and records when it is reapplied
+type ReapplyTask struct {
+	resource.Task
+
+	name  string
+	order *[]string
+}
+
+// Reapply records that the task was reapplied
+func (rt *ReapplyTask) Reapply(context.Context) error {
+	*rt.order = append(*rt.order, rt.name)
+	return nil
+}
+
+// Reapplying returns a ReapplyTask wrapping the given task, which appends
+// name to order when it is reapplied
+func Reapplying(task resource.Task, name string, order *[]string) *ReapplyTask {
+	return &ReapplyTask{Task: task, name: name, order: order}
+}
+
+// ConcurrencyCounter tracks the greatest number of tasks running at once
+type ConcurrencyCounter struct {
+	lock    sync.Mutex
+	running int
+	max     int
+}
+
+// Max returns the greatest number of tasks that were running at once
+func (cc *ConcurrencyCounter) Max() int {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+
+	return cc.max
+}
+
+func (cc *ConcurrencyCounter) run() {
+	cc.lock.Lock()
+	cc.running++
+	if cc.running > cc.max {
+		cc.max = cc.running
+	}
+	cc.lock.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	cc.lock.Lock()
+	cc.running--
+	cc.lock.Unlock()
+}
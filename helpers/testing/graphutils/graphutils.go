@@ -2,8 +2,11 @@ package graphutils
 
 import (
 	"errors"
+	"testing"
 
+	"github.com/asteris-llc/converge/apply"
 	"github.com/asteris-llc/converge/graph"
+	"github.com/stretchr/testify/require"
 )
 
 // DependsOn returns true if target and source both exist in the graph, and
@@ -40,3 +43,15 @@ func GetMetadata(g *graph.Graph, id, key string) (interface{}, error) {
 	}
 	return value, nil
 }
+
+// GetApplyResult returns the apply result stored at key, failing the test if
+// the node doesn't exist or holds something else.
+func GetApplyResult(t *testing.T, g *graph.Graph, key string) *apply.Result {
+	meta, ok := g.Get(key)
+	require.True(t, ok, "%q was not present in the graph", key)
+
+	result, ok := meta.Value().(*apply.Result)
+	require.True(t, ok, "needed a %T for %q, got a %T", result, key, meta.Value())
+
+	return result
+}
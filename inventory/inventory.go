@@ -0,0 +1,242 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inventory reads params scoped to hosts and groups of hosts, so one
+// module can be applied across hosts that need different values.
+//
+// An inventory file is HCL:
+//
+//	vars {
+//	  env = "prod"
+//	}
+//
+//	group "web" {
+//	  hosts = ["web*"]
+//	  vars {
+//	    port = 8080
+//	  }
+//	}
+//
+//	host "web1" {
+//	  vars {
+//	    port = 9090
+//	  }
+//	}
+//
+// Params for a host are merged from the top-level vars, then each group the
+// host belongs to in the order the groups are declared, then the host's own
+// vars. Later values replace earlier ones.
+package inventory
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/parser"
+	"github.com/pkg/errors"
+)
+
+// Inventory holds params for hosts and groups of hosts
+type Inventory struct {
+	// Vars apply to every host
+	Vars map[string]interface{}
+
+	// Groups are in the order they were declared
+	Groups []*Group
+
+	// Hosts are keyed by name
+	Hosts map[string]*Host
+}
+
+// Group is a named set of hosts sharing params
+type Group struct {
+	Name string
+
+	// Hosts are host names or shell patterns matching them, like "web*"
+	Hosts []string `hcl:"hosts"`
+
+	Vars map[string]interface{} `hcl:"vars"`
+}
+
+// Host holds params for a single host
+type Host struct {
+	Name string
+
+	// Groups the host belongs to, in addition to groups listing it
+	Groups []string `hcl:"groups"`
+
+	Vars map[string]interface{} `hcl:"vars"`
+}
+
+// Load reads an inventory file
+func Load(filename string) (*Inventory, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read inventory")
+	}
+
+	return ParseFile(content, filename)
+}
+
+// Parse parses inventory content
+func Parse(content []byte) (*Inventory, error) {
+	return ParseFile(content, "")
+}
+
+// ParseFile parses inventory content, reporting errors at file:line:column
+func ParseFile(content []byte, filename string) (*Inventory, error) {
+	file, err := hcl.ParseBytes(content)
+	if err != nil {
+		if posErr, ok := err.(*parser.PosError); ok && filename != "" {
+			posErr.Pos.Filename = filename
+		}
+		return nil, err
+	}
+
+	list, ok := file.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, errors.New("inventory must be a list of blocks")
+	}
+
+	inv := &Inventory{
+		Vars:  map[string]interface{}{},
+		Hosts: map[string]*Host{},
+	}
+
+	for _, item := range list.Items {
+		pos := item.Pos()
+		pos.Filename = filename
+
+		keys := make([]string, len(item.Keys))
+		for i, key := range item.Keys {
+			keys[i] = key.Token.Value().(string)
+		}
+
+		switch {
+		case len(keys) == 1 && keys[0] == "vars":
+			var vars map[string]interface{}
+			if err := hcl.DecodeObject(&vars, item.Val); err != nil {
+				return nil, errors.Wrapf(err, "%s: vars", pos)
+			}
+			for k, v := range vars {
+				inv.Vars[k] = v
+			}
+
+		case len(keys) == 2 && keys[0] == "group":
+			if inv.Group(keys[1]) != nil {
+				return nil, fmt.Errorf("%s: duplicate group %q", pos, keys[1])
+			}
+			group := &Group{Name: keys[1]}
+			if err := hcl.DecodeObject(group, item.Val); err != nil {
+				return nil, errors.Wrapf(err, "%s: group %q", pos, keys[1])
+			}
+			for _, pattern := range group.Hosts {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return nil, fmt.Errorf("%s: group %q: bad host pattern %q", pos, keys[1], pattern)
+				}
+			}
+			inv.Groups = append(inv.Groups, group)
+
+		case len(keys) == 2 && keys[0] == "host":
+			if _, ok := inv.Hosts[keys[1]]; ok {
+				return nil, fmt.Errorf("%s: duplicate host %q", pos, keys[1])
+			}
+			host := &Host{Name: keys[1]}
+			if err := hcl.DecodeObject(host, item.Val); err != nil {
+				return nil, errors.Wrapf(err, "%s: host %q", pos, keys[1])
+			}
+			inv.Hosts[host.Name] = host
+
+		default:
+			return nil, fmt.Errorf("%s: expected vars, group or host block, got %q", pos, keys)
+		}
+	}
+
+	for _, host := range inv.Hosts {
+		for _, name := range host.Groups {
+			if inv.Group(name) == nil {
+				return nil, fmt.Errorf("host %q is in unknown group %q", host.Name, name)
+			}
+		}
+	}
+
+	return inv, nil
+}
+
+// Group returns the group with the given name, or nil if there is none
+func (i *Inventory) Group(name string) *Group {
+	for _, group := range i.Groups {
+		if group.Name == name {
+			return group
+		}
+	}
+	return nil
+}
+
+// GroupsFor returns the groups a host belongs to, in declaration order
+func (i *Inventory) GroupsFor(hostname string) []*Group {
+	var listed []string
+	if host, ok := i.Hosts[hostname]; ok {
+		listed = host.Groups
+	}
+
+	var groups []*Group
+	for _, group := range i.Groups {
+		if group.matches(hostname) || contains(listed, group.Name) {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// Params returns the merged params for a host
+func (i *Inventory) Params(hostname string) map[string]interface{} {
+	params := map[string]interface{}{}
+	merge := func(vars map[string]interface{}) {
+		for k, v := range vars {
+			params[k] = v
+		}
+	}
+
+	merge(i.Vars)
+	for _, group := range i.GroupsFor(hostname) {
+		merge(group.Vars)
+	}
+	if host, ok := i.Hosts[hostname]; ok {
+		merge(host.Vars)
+	}
+
+	return params
+}
+
+func (g *Group) matches(hostname string) bool {
+	for _, pattern := range g.Hosts {
+		if ok, _ := path.Match(pattern, hostname); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/inventory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sample = `
+vars {
+  env  = "prod"
+  port = 80
+}
+
+group "web" {
+  hosts = ["web*"]
+  vars {
+    port = 8080
+    role = "web"
+  }
+}
+
+group "canary" {
+  vars {
+    env = "canary"
+  }
+}
+
+host "web1" {
+  groups = ["canary"]
+  vars {
+    port = 9090
+  }
+}
+`
+
+func TestParams(t *testing.T) {
+	t.Parallel()
+
+	inv, err := inventory.Parse([]byte(sample))
+	require.NoError(t, err)
+
+	t.Run("host", func(t *testing.T) {
+		assert.Equal(
+			t,
+			map[string]interface{}{"env": "canary", "port": 9090, "role": "web"},
+			inv.Params("web1"),
+		)
+	})
+
+	t.Run("group pattern", func(t *testing.T) {
+		assert.Equal(
+			t,
+			map[string]interface{}{"env": "prod", "port": 8080, "role": "web"},
+			inv.Params("web2"),
+		)
+	})
+
+	t.Run("unknown host", func(t *testing.T) {
+		assert.Equal(
+			t,
+			map[string]interface{}{"env": "prod", "port": 80},
+			inv.Params("db1"),
+		)
+	})
+
+	t.Run("groups in declaration order", func(t *testing.T) {
+		groups := inv.GroupsFor("web1")
+		require.Len(t, groups, 2)
+		assert.Equal(t, "web", groups[0].Name)
+		assert.Equal(t, "canary", groups[1].Name)
+	})
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	for name, content := range map[string]string{
+		"duplicate group": `group "a" {}` + "\n" + `group "a" {}`,
+		"duplicate host":  `host "a" {}` + "\n" + `host "a" {}`,
+		"unknown block":   `hosts "a" {}`,
+		"unknown group":   `host "a" { groups = ["b"] }`,
+		"bad pattern":     `group "a" { hosts = ["["] }`,
+		"syntax":          `group "a" {`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := inventory.Parse([]byte(content))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-inventory")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "inventory.hcl")
+	require.NoError(t, ioutil.WriteFile(filename, []byte(`host "a" {`+"\n"+`x`), 0644))
+
+	_, err = inventory.Load(filename)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), filename)
+
+	_, err = inventory.Load(filepath.Join(dir, "missing.hcl"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,228 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package journal records what happens during an apply in an append-only file,
+// so that a run that was cut short by a crash or reboot can be reconstructed
+// afterwards.
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/asteris-llc/converge/errcode"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/prettyprinters/human"
+	"github.com/pkg/errors"
+)
+
+// Extension is the file extension of journal files
+const Extension = ".journal"
+
+// Event is the kind of a journal entry
+type Event string
+
+const (
+	// EventRunStart is written when a run starts
+	EventRunStart Event = "run-start"
+
+	// EventNodeStart is written when a node starts
+	EventNodeStart Event = "node-start"
+
+	// EventNodeFinish is written when a node finishes, along with its result
+	EventNodeFinish Event = "node-finish"
+
+	// EventRunFinish is written when a run finishes
+	EventRunFinish Event = "run-finish"
+)
+
+// Change is a single changed field of a node
+type Change struct {
+	Field    string `json:"field"`
+	Original string `json:"original"`
+	Current  string `json:"current"`
+}
+
+// Entry is a single line in a journal
+type Entry struct {
+	Time  time.Time `json:"time"`
+	Event Event     `json:"event"`
+
+	// run-start
+	Location string `json:"location,omitempty"`
+	PID      int    `json:"pid,omitempty"`
+
+	// node-start and node-finish
+	ID string `json:"id,omitempty"`
+
+	// node-finish
+	HasChanges bool     `json:"hasChanges,omitempty"`
+	Changes    []Change `json:"changes,omitempty"`
+
+	// node-finish and run-finish
+	Error     string `json:"error,omitempty"`
+	ErrorCode string `json:"errorCode,omitempty"`
+}
+
+// Journal is an append-only record of a run. Every entry is synced to disk
+// before the write returns, so the journal is accurate up to the moment of a
+// crash.
+type Journal struct {
+	Path string
+
+	file *os.File
+	lock sync.Mutex
+}
+
+// Create starts a new journal for a run of the module at location, in the
+// given directory
+func Create(dir, location string) (*Journal, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "could not create journal directory")
+	}
+
+	now := time.Now().UTC()
+	path := filepath.Join(dir, fmt.Sprintf("%s-%d%s", now.Format("20060102T150405.000000000Z"), os.Getpid(), Extension))
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create journal")
+	}
+
+	j := &Journal{Path: path, file: file}
+	if err := j.Write(Entry{Time: now, Event: EventRunStart, Location: location, PID: os.Getpid()}); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// Write appends an entry to the journal and syncs it to disk
+func (j *Journal) Write(entry Entry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+
+	blob, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "could not serialize journal entry")
+	}
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if _, err := j.file.Write(append(blob, '\n')); err != nil {
+		return errors.Wrap(err, "could not write journal entry")
+	}
+
+	return errors.Wrap(j.file.Sync(), "could not sync journal")
+}
+
+// NodeStarted records that a node has started
+func (j *Journal) NodeStarted(meta *node.Node) error {
+	return j.Write(Entry{Event: EventNodeStart, ID: meta.ID})
+}
+
+// NodeFinished records that a node has finished, along with its result
+func (j *Journal) NodeFinished(meta *node.Node) error {
+	entry := Entry{Event: EventNodeFinish, ID: meta.ID}
+
+	if printable, ok := meta.Value().(human.Printable); ok {
+		entry.HasChanges = printable.HasChanges()
+		entry.Error, entry.ErrorCode = describeError(printable.Error())
+
+		for field, diff := range printable.Changes() {
+			if diff.Changes() {
+				entry.Changes = append(entry.Changes, Change{
+					Field:    field,
+					Original: diff.Original(),
+					Current:  diff.Current(),
+				})
+			}
+		}
+		sort.Sort(changesByField(entry.Changes))
+	}
+
+	return j.Write(entry)
+}
+
+// Finish records the end of the run and closes the journal
+func (j *Journal) Finish(runErr error) error {
+	entry := Entry{Event: EventRunFinish}
+	entry.Error, entry.ErrorCode = describeError(runErr)
+
+	err := j.Write(entry)
+	if closeErr := j.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Notifier records node starts and finishes in the journal, then calls the
+// corresponding functions of inner, if any
+func (j *Journal) Notifier(inner *graph.Notifier) *graph.Notifier {
+	notifier := &graph.Notifier{
+		Pre:  j.NodeStarted,
+		Post: j.NodeFinished,
+	}
+
+	if inner == nil {
+		return notifier
+	}
+
+	notifier.Heartbeat = inner.Heartbeat
+	notifier.HeartbeatInterval = inner.HeartbeatInterval
+
+	if inner.Pre != nil {
+		notifier.Pre = func(meta *node.Node) error {
+			if err := j.NodeStarted(meta); err != nil {
+				return err
+			}
+			return inner.Pre(meta)
+		}
+	}
+
+	if inner.Post != nil {
+		notifier.Post = func(meta *node.Node) error {
+			if err := j.NodeFinished(meta); err != nil {
+				return err
+			}
+			return inner.Post(meta)
+		}
+	}
+
+	return notifier
+}
+
+func describeError(err error) (string, string) {
+	if err == nil {
+		return "", ""
+	}
+
+	code, _ := errcode.Of(err)
+	return err.Error(), string(code)
+}
+
+type changesByField []Change
+
+func (c changesByField) Len() int           { return len(c) }
+func (c changesByField) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c changesByField) Less(i, j int) bool { return c[i].Field < c[j].Field }
@@ -0,0 +1,141 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package journal_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/errcode"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/journal"
+	"github.com/asteris-llc/converge/plan"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournalRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-journal")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	j, err := journal.Create(dir, "test.hcl")
+	require.NoError(t, err)
+
+	changed := resource.NewStatus()
+	changed.AddDifference("mode", "0644", "0600", "")
+
+	var pre, post []string
+	notify := j.Notifier(&graph.Notifier{
+		Pre:  func(meta *node.Node) error { pre = append(pre, meta.ID); return nil },
+		Post: func(meta *node.Node) error { post = append(post, meta.ID); return nil },
+	})
+
+	a := node.New("root/file.content.a", &plan.Result{Status: changed})
+	b := node.New("root/task.b", &plan.Result{
+		Status: resource.NewStatus(),
+		Err:    errcode.Wrap(errcode.ApplyFailed, errors.New("exit status 1")),
+	})
+	c := node.New("root/task.c", &plan.Result{Status: resource.NewStatus()})
+
+	require.NoError(t, notify.Pre(a))
+	require.NoError(t, notify.Post(a))
+	require.NoError(t, notify.Pre(b))
+	require.NoError(t, notify.Post(b))
+	require.NoError(t, notify.Pre(c))
+	require.NoError(t, j.Finish(nil))
+
+	// the inner notifier is still called
+	assert.Equal(t, []string{a.ID, b.ID, c.ID}, pre)
+	assert.Equal(t, []string{a.ID, b.ID}, post)
+
+	latest, err := journal.Latest(dir)
+	require.NoError(t, err)
+	assert.Equal(t, j.Path, latest)
+
+	entries, err := journal.ReadFile(latest)
+	require.NoError(t, err)
+	require.Len(t, entries, 7)
+
+	report := journal.Summarize(entries)
+	assert.Equal(t, "test.hcl", report.Location)
+	assert.Equal(t, os.Getpid(), report.PID)
+	assert.True(t, report.Completed)
+	require.Len(t, report.Nodes, 3)
+
+	assert.True(t, report.Nodes[0].Done)
+	assert.True(t, report.Nodes[0].HasChanges)
+	assert.Equal(t, []journal.Change{{Field: "mode", Original: "0644", Current: "0600"}}, report.Nodes[0].Changes)
+
+	assert.True(t, report.Nodes[1].Done)
+	assert.Equal(t, "exit status 1", report.Nodes[1].Error)
+	assert.Equal(t, string(errcode.ApplyFailed), report.Nodes[1].ErrorCode)
+
+	assert.False(t, report.Nodes[2].Done)
+	assert.Equal(t, []*journal.NodeReport{report.Nodes[2]}, report.InProgress())
+}
+
+func TestReadTruncated(t *testing.T) {
+	t.Parallel()
+
+	in := `{"time":"2016-10-01T00:00:00Z","event":"run-start","location":"test.hcl","pid":10}
+{"time":"2016-10-01T00:00:01Z","event":"node-start","id":"root/task.a"}
+{"time":"2016-10-01T00:00:02Z","event":"node-finish","id":"root/task.a","hasChanges":true}
+{"time":"2016-10-01T00:00:02Z","event":"node-start","id":"root/task.b"}
+{"time":"2016-10-01T00:00:03Z","event":"node-fin`
+
+	entries, err := journal.Read(strings.NewReader(in))
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+
+	report := journal.Summarize(entries)
+	assert.False(t, report.Completed)
+	require.Len(t, report.InProgress(), 1)
+	assert.Equal(t, "root/task.b", report.InProgress()[0].ID)
+
+	out := report.String()
+	assert.Contains(t, out, "Finished: never")
+	assert.Contains(t, out, "root/task.a: changed")
+	assert.Contains(t, out, "root/task.b: started at 2016-10-01T00:00:02Z, never finished")
+	assert.Contains(t, out, "1 node(s) may have been partially applied")
+}
+
+func TestReadInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := journal.Read(strings.NewReader("{\"event\":\"run-start\"}\nnot json\n"))
+	assert.EqualError(t, err, "invalid journal entry on line 2: invalid character 'o' in literal null (expecting 'u')")
+}
+
+func TestLatestEmpty(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-journal")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0600))
+
+	_, err = journal.Latest(dir)
+	assert.Error(t, err)
+}
@@ -0,0 +1,225 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Read reads the entries of a journal. A journal cut off by a crash may end
+// with a partial line, which is ignored.
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		raw, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// a line without a trailing newline was never completely written
+			return entries, nil
+		} else if err != nil {
+			return entries, errors.Wrap(err, "could not read journal")
+		}
+
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return entries, errors.Wrapf(err, "invalid journal entry on line %d", line)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// ReadFile reads the entries of the journal at path
+func ReadFile(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open journal")
+	}
+	defer file.Close()
+
+	return Read(file)
+}
+
+// Latest returns the path of the most recent journal in dir
+func Latest(dir string) (string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", errors.Wrap(err, "could not read journal directory")
+	}
+
+	var names []string
+	for _, info := range infos {
+		if !info.IsDir() && strings.HasSuffix(info.Name(), Extension) {
+			names = append(names, info.Name())
+		}
+	}
+
+	if len(names) == 0 {
+		return "", fmt.Errorf("no journals in %s", dir)
+	}
+
+	// names start with the time the run started, so they sort chronologically
+	sort.Strings(names)
+	return filepath.Join(dir, names[len(names)-1]), nil
+}
+
+// NodeReport is what the journal says about a single node
+type NodeReport struct {
+	ID         string
+	Started    time.Time
+	Finished   time.Time
+	Done       bool
+	HasChanges bool
+	Changes    []Change
+	Error      string
+	ErrorCode  string
+}
+
+// Report reconstructs a run from its journal
+type Report struct {
+	Location  string
+	PID       int
+	Started   time.Time
+	Finished  time.Time
+	Completed bool
+	Error     string
+	ErrorCode string
+
+	// Nodes in the order they started
+	Nodes []*NodeReport
+}
+
+// Summarize builds a report from journal entries
+func Summarize(entries []Entry) *Report {
+	report := new(Report)
+	nodes := map[string]*NodeReport{}
+
+	getNode := func(entry Entry) *NodeReport {
+		if node, ok := nodes[entry.ID]; ok {
+			return node
+		}
+		node := &NodeReport{ID: entry.ID, Started: entry.Time}
+		nodes[entry.ID] = node
+		report.Nodes = append(report.Nodes, node)
+		return node
+	}
+
+	for _, entry := range entries {
+		switch entry.Event {
+		case EventRunStart:
+			report.Location = entry.Location
+			report.PID = entry.PID
+			report.Started = entry.Time
+
+		case EventNodeStart:
+			getNode(entry)
+
+		case EventNodeFinish:
+			node := getNode(entry)
+			node.Finished = entry.Time
+			node.Done = true
+			node.HasChanges = entry.HasChanges
+			node.Changes = entry.Changes
+			node.Error = entry.Error
+			node.ErrorCode = entry.ErrorCode
+
+		case EventRunFinish:
+			report.Finished = entry.Time
+			report.Completed = true
+			report.Error = entry.Error
+			report.ErrorCode = entry.ErrorCode
+		}
+	}
+
+	return report
+}
+
+// InProgress returns the nodes which started but never finished
+func (r *Report) InProgress() []*NodeReport {
+	var out []*NodeReport
+	for _, node := range r.Nodes {
+		if !node.Done {
+			out = append(out, node)
+		}
+	}
+	return out
+}
+
+// String describes what was and wasn't done in the run
+func (r *Report) String() string {
+	var out bytes.Buffer
+
+	fmt.Fprintf(&out, "Run of %s (pid %d)\n", r.Location, r.PID)
+	fmt.Fprintf(&out, "Started:  %s\n", r.Started.Format(time.RFC3339))
+	if r.Completed {
+		fmt.Fprintf(&out, "Finished: %s\n", r.Finished.Format(time.RFC3339))
+	} else {
+		fmt.Fprintln(&out, "Finished: never (the run was cut short)")
+	}
+	if r.Error != "" {
+		fmt.Fprintf(&out, "Error:    %s\n", withCode(r.ErrorCode, r.Error))
+	}
+
+	fmt.Fprintln(&out, "\nNodes:")
+	for _, node := range r.Nodes {
+		switch {
+		case !node.Done:
+			fmt.Fprintf(&out, "  %s: started at %s, never finished\n", node.ID, node.Started.Format(time.RFC3339))
+		case node.Error != "":
+			fmt.Fprintf(&out, "  %s: failed: %s\n", node.ID, withCode(node.ErrorCode, node.Error))
+		case node.HasChanges:
+			fmt.Fprintf(&out, "  %s: changed\n", node.ID)
+		default:
+			fmt.Fprintf(&out, "  %s: no changes\n", node.ID)
+		}
+
+		for _, change := range node.Changes {
+			fmt.Fprintf(&out, "    %s: %q => %q\n", change.Field, change.Original, change.Current)
+		}
+	}
+
+	if inProgress := r.InProgress(); len(inProgress) > 0 {
+		fmt.Fprintf(&out, "\n%d node(s) may have been partially applied:\n", len(inProgress))
+		for _, node := range inProgress {
+			fmt.Fprintf(&out, "  %s\n", node.ID)
+		}
+	}
+
+	return out.String()
+}
+
+func withCode(code, msg string) string {
+	if code == "" {
+		return msg
+	}
+	return "[" + code + "] " + msg
+}
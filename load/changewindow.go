@@ -0,0 +1,56 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"fmt"
+
+	"github.com/asteris-llc/converge/changewindow"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/parse"
+	"golang.org/x/net/context"
+)
+
+// changeWindowFor returns the window a node's changes are limited to. A node
+// without a change_window of its own takes the one of the closest module call
+// it's part of.
+func changeWindowFor(ctx context.Context, g *graph.Graph, id string) (*changewindow.Window, error) {
+	for ; !graph.IsRoot(id) && id != "."; id = graph.ParentID(id) {
+		meta, ok := g.Get(id)
+		if !ok {
+			continue
+		}
+
+		raw, ok := meta.Value().(*parse.Node)
+		if !ok {
+			continue
+		}
+
+		spec, err := raw.ChangeWindow()
+		if err != nil {
+			return nil, err
+		}
+		if spec == "" {
+			continue
+		}
+
+		window, err := changewindow.Resolve(spec, changewindow.Named(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", raw.FieldPosition("change_window"), err)
+		}
+		return window, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,144 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/parse"
+	"github.com/pkg/errors"
+)
+
+// applyDefaults merges the defaults blocks of each module into the resources
+// in that module. A defaults block applies to resources of the type it names,
+// or to every type under it, so `defaults "file"` applies to `file.content`
+// and `file.directory`. Only fields the resource has are set, and the most
+// specific block wins when more than one sets a field.
+func applyDefaults(g *graph.Graph, defaults map[string][]*parse.Node) error {
+	if len(defaults) == 0 {
+		return nil
+	}
+
+	for _, meta := range g.Nodes() {
+		raw, ok := meta.Value().(*parse.Node)
+		if !ok || raw.IsModule() {
+			continue
+		}
+
+		blocks := defaultsFor(raw.Kind(), defaults[owningModule(g, meta.ID)])
+		if len(blocks) == 0 {
+			continue
+		}
+
+		fields, exclusive, ok := fieldsFor(raw.Kind())
+		if !ok {
+			continue
+		}
+
+		accept := func(key string) bool {
+			if !fields[key] {
+				return false
+			}
+
+			// a default is left out when the resource sets a field it can't be
+			// used together with
+			for _, other := range exclusive[key] {
+				if _, err := raw.Get(other); other != key && err == nil {
+					return false
+				}
+			}
+			return true
+		}
+
+		for _, block := range blocks {
+			if err := raw.MergeDefaults(block, accept); err != nil {
+				return errors.Wrap(err, meta.ID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// owningModule finds the module a node was loaded from, which is the nearest
+// ancestor with a recorded source
+func owningModule(g *graph.Graph, id string) string {
+	for id != "" && id != "." {
+		id = graph.ParentID(id)
+		if meta, ok := g.Get(id); ok {
+			if _, ok := meta.LookupMetadata(node.MetaSource); ok {
+				return id
+			}
+		}
+	}
+	return "root"
+}
+
+// defaultsFor picks the defaults blocks that apply to a resource type, most
+// specific first
+func defaultsFor(kind string, blocks []*parse.Node) []*parse.Node {
+	var out []*parse.Node
+	for _, block := range blocks {
+		if block.Name() == kind || strings.HasPrefix(kind, block.Name()+".") {
+			out = append(out, block)
+		}
+	}
+	sort.Sort(bySpecificity(out))
+	return out
+}
+
+type bySpecificity []*parse.Node
+
+func (b bySpecificity) Len() int           { return len(b) }
+func (b bySpecificity) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b bySpecificity) Less(i, j int) bool { return len(b[i].Name()) > len(b[j].Name()) }
+
+// fieldsFor returns the HCL names of the fields of a resource type, along with
+// the fields each of them is mutually exclusive with
+func fieldsFor(kind string) (fields map[string]bool, exclusive map[string][]string, ok bool) {
+	dest, ok := registry.NewByName(kind)
+	if !ok {
+		return nil, nil, false
+	}
+
+	typ := reflect.TypeOf(dest)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, nil, false
+	}
+
+	fields = make(map[string]bool, typ.NumField())
+	exclusive = make(map[string][]string)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if name, ok := field.Tag.Lookup("hcl"); ok {
+			fields[name] = true
+		}
+		if raw, ok := field.Tag.Lookup("mutually_exclusive"); ok {
+			group := strings.Split(raw, ",")
+			for _, name := range group {
+				exclusive[name] = append(exclusive[name], group...)
+			}
+		}
+	}
+	return fields, exclusive, true
+}
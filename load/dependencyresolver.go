@@ -18,10 +18,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"sort"
+	"strings"
 	"sync"
 	"text/template"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/errcode"
+	"github.com/asteris-llc/converge/fetch"
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/helpers/logging"
@@ -32,7 +35,13 @@ import (
 	"golang.org/x/net/context"
 )
 
-type dependencyGenerator func(g *graph.Graph, id string, node *parse.Node) ([]string, error)
+type dependencyGenerator func(ctx context.Context, g *graph.Graph, id string, node *parse.Node) ([]dependency, error)
+
+// dependency is a node that another node depends on, and why
+type dependency struct {
+	ID     string
+	Reason graph.Reason
+}
 
 // ResolveDependencies examines the strings and depdendencies at each vertex of
 // the graph and creates edges to fit them
@@ -52,17 +61,17 @@ func ResolveDependencies(ctx context.Context, g *graph.Graph) (*graph.Graph, err
 			return fmt.Errorf("ResolveDependencies can only be used on Graphs of *parse.Node. I got %T", meta.Value())
 		}
 
-		depGenerators := []dependencyGenerator{getDepends, getParams, getXrefs}
+		depGenerators := []dependencyGenerator{getDepends, getParams, getXrefs, getOwners}
 
 		// we have dependencies from various sources, but they're always IDs, so we
 		// can connect them pretty easily
 		for _, source := range depGenerators {
-			deps, err := source(g, meta.ID, node)
+			deps, err := source(ctx, g, meta.ID, node)
 			if err != nil {
 				return err
 			}
 			for _, dep := range deps {
-				if err := out.SafeConnect(meta.ID, dep); err != nil {
+				if err := out.SafeConnectBecause(meta.ID, dep.ID, dep.Reason); err != nil {
 					logger.Error(err)
 					return err
 				}
@@ -87,43 +96,33 @@ func ResolveDependencies(ctx context.Context, g *graph.Graph) (*graph.Graph, err
 	return g, err
 }
 
-func getDepends(g *graph.Graph, id string, node *parse.Node) ([]string, error) {
+func getDepends(ctx context.Context, g *graph.Graph, id string, node *parse.Node) ([]dependency, error) {
 	deps, err := node.GetStringSlice("depends")
 	switch err {
 	case parse.ErrNotFound:
-		return []string{}, nil
+		return []dependency{}, nil
 	case nil:
+		out := make([]dependency, len(deps))
 		for idx, dep := range deps {
 			if ancestor, ok := getNearestAncestor(g, id, dep); ok {
-				deps[idx] = ancestor
+				out[idx] = dependency{ancestor, dependsReason(dep)}
 			} else {
-				return nil, fmt.Errorf("nonexistent vertices in edges: %s", dep)
+				return nil, fmt.Errorf("%s: nonexistent vertices in edges: %s", node.FieldPosition("depends"), dep)
 			}
 		}
-		return deps, nil
+		return out, nil
 	default:
 		return nil, err
 	}
 }
 
-func getParams(g *graph.Graph, id string, node *parse.Node) (out []string, err error) {
-	var nodeStrings []string
-	nodeStrings, err = node.GetStrings()
+func getParams(ctx context.Context, g *graph.Graph, id string, node *parse.Node) (deps []dependency, err error) {
+	var out []string
+	nodeStrings, err := dependencyStrings(ctx, g, id, node)
 	if err != nil {
 		return nil, err
 	}
 
-	meta, found := g.Get(id)
-	if !found {
-		return nil, errors.New("error: node is not in the provided graph")
-	}
-
-	if metaIface, ok := meta.LookupMetadata("conditional-predicate-raw"); ok {
-		if str, ok := metaIface.(string); ok {
-			nodeStrings = append(nodeStrings, str)
-		}
-	}
-
 	type stub struct{}
 	language := extensions.MinimalLanguage()
 	language.On("param", extensions.RememberCalls(&out, ""))
@@ -134,65 +133,214 @@ func getParams(g *graph.Graph, id string, node *parse.Node) (out []string, err e
 		useless := stub{}
 		tmpl, tmplErr := template.New("DependencyTemplate").Funcs(language.Funcs).Parse(s)
 		if tmplErr != nil {
-			return out, tmplErr
+			return nil, errors.Wrap(tmplErr, node.Locate(s).String())
 		}
 		tmpl.Execute(ioutil.Discard, &useless)
 	}
-	for idx, val := range out {
+	for _, val := range out {
 		ancestor, found := getNearestAncestor(g, id, "param."+val)
 		if !found {
-			return out, fmt.Errorf("unknown parameter: param.%s", val)
+			return nil, fmt.Errorf("%s: unknown parameter: param.%s", node.Locate("`"+val+"`"), val)
 		}
-		out[idx] = ancestor
+		deps = append(deps, dependency{ancestor, paramReason(val)})
 	}
-	return out, err
+	return deps, err
 }
 
-func getXrefs(g *graph.Graph, id string, node *parse.Node) (out []string, err error) {
-	var nodeStrings []string
+func getXrefs(ctx context.Context, g *graph.Graph, id string, node *parse.Node) (out []dependency, err error) {
 	var calls []string
 	nodeRefs := make(map[string]struct{})
-	nodeStrings, err = node.GetStrings()
+	nodeStrings, err := dependencyStrings(ctx, g, id, node)
 	if err != nil {
 		return nil, err
 	}
 
-	meta, found := g.Get(id)
-	if !found {
-		return nil, errors.New("error: node is not in the provided graph")
-	}
-
-	if metaIface, ok := meta.LookupMetadata("conditional-predicate-raw"); ok {
-		if str, ok := metaIface.(string); ok {
-			nodeStrings = append(nodeStrings, str)
-		}
-	}
-
 	language := extensions.MinimalLanguage()
 	language.On(extensions.RefFuncName, extensions.RememberCalls(&calls, 0))
 	for _, s := range nodeStrings {
 		tmpl, tmplErr := template.New("DependencyTemplate").Funcs(language.Funcs).Parse(s)
 		if tmplErr != nil {
-			return out, tmplErr
+			return out, errors.Wrap(tmplErr, node.Locate(s).String())
 		}
 		tmpl.Execute(ioutil.Discard, &struct{}{})
 	}
 	for _, call := range calls {
 		vertex, _, found := preprocessor.VertexSplitTraverse(g, call, id, preprocessor.TraverseUntilModule, make(map[string]struct{}))
 		if !found {
-			return []string{}, fmt.Errorf("dependency generator: unresolvable call to %s", call)
+			return []dependency{}, fmt.Errorf("%s: dependency generator: unresolvable call to %s", node.Locate("`"+call+"`"), call)
+		}
+		if err := checkAmbiguous(ctx, g, id, node, call, vertex); err != nil {
+			return nil, err
 		}
 		if _, ok := nodeRefs[vertex]; !ok {
 			nodeRefs[vertex] = struct{}{}
-			out = append(out, vertex)
-			if peerVertex, ok := getPeerVertex(g, id, vertex); ok {
-				out = append(out, peerVertex)
+			out = append(out, dependency{vertex, lookupReason(call)})
+			if peerVertex, ok := getPeerVertex(g, id, vertex); ok && peerVertex != vertex {
+				out = append(out, dependency{peerVertex, peerReason(lookupReason(call), vertex)})
 			}
 		}
 	}
 	return out, err
 }
 
+// templateFiles names the fields of each resource that point at a file which
+// is rendered as a template when the resource is prepared. The contents of
+// these files are scanned for dependencies along with the node's own strings.
+var templateFiles = map[string][]string{
+	"file.content":     {"source"},
+	"task":             {"check_script", "apply_script", "rollback_script"},
+	"healthcheck.task": {"check_script"},
+}
+
+// dependencyStrings collects the strings of a node that may call template
+// functions: its fields, its raw conditional predicate, and the contents of
+// any template files it renders
+func dependencyStrings(ctx context.Context, g *graph.Graph, id string, node *parse.Node) ([]string, error) {
+	nodeStrings, err := node.GetStrings()
+	if err != nil {
+		return nil, err
+	}
+
+	meta, found := g.Get(id)
+	if !found {
+		return nil, errors.New("error: node is not in the provided graph")
+	}
+
+	if metaIface, ok := meta.LookupMetadata("conditional-predicate-raw"); ok {
+		if str, ok := metaIface.(string); ok {
+			nodeStrings = append(nodeStrings, str)
+		}
+	}
+
+	for _, field := range templateFiles[node.Kind()] {
+		loc, err := node.GetString(field)
+		if err == parse.ErrNotFound || loc == "" {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if content, ok := loadTemplateFile(ctx, g, id, loc); ok {
+			nodeStrings = append(nodeStrings, content)
+		}
+	}
+
+	return nodeStrings, nil
+}
+
+// loadTemplateFile reads a template file relative to the module a node was
+// loaded from, the same way the renderer will. Locations which are templates
+// themselves can't be read before rendering, and files which can't be read are
+// skipped here so that preparing the resource reports the error.
+func loadTemplateFile(ctx context.Context, g *graph.Graph, id, loc string) (string, bool) {
+	if strings.Contains(loc, "{{") {
+		return "", false
+	}
+
+	var source string
+	if meta, ok := g.Get(owningModule(g, id)); ok {
+		if src, ok := meta.LookupMetadata(node.MetaSource); ok {
+			source, _ = src.(string)
+		}
+	}
+
+	url, err := fetch.ResolveInContext(loc, source)
+	if err != nil {
+		return "", false
+	}
+	if root, ok := g.Get("root"); ok {
+		if src, ok := root.LookupMetadata(node.MetaSource); ok {
+			if rootURL, ok := src.(string); ok {
+				url = fetch.Vendored(rootURL, url)
+			}
+		}
+	}
+
+	content, err := fetch.Any(ctx, url)
+	if err != nil {
+		logging.GetLogger(ctx).WithError(err).WithField("location", url).Debug("could not read template file for dependencies")
+		return "", false
+	}
+	return string(content), true
+}
+
+// checkAmbiguous makes sure a reference resolves to a single node. When it
+// could resolve to more than one, it's an error with strict references, and a
+// warning otherwise.
+func checkAmbiguous(ctx context.Context, g *graph.Graph, id string, node *parse.Node, call, vertex string) error {
+	candidates := preprocessor.VertexCandidates(g, call, id, preprocessor.TraverseUntilModule)
+	if len(candidates) < 2 {
+		return nil
+	}
+
+	if IsStrictRefs(ctx) {
+		return errcode.Errorf(
+			errcode.ResolveAmbiguous,
+			"%s: ambiguous reference to %s, which could refer to any of %s",
+			node.Locate("`"+call+"`"), call, strings.Join(candidates, ", "),
+		)
+	}
+
+	logging.GetLogger(ctx).WithFields(logrus.Fields{
+		"id":         id,
+		"reference":  call,
+		"candidates": candidates,
+		"using":      vertex,
+	}).Warn("ambiguous reference, pass --strict-refs to make this an error")
+	return nil
+}
+
+// ownerKinds maps the fields which name a user or group to the kind and field
+// of the resources which manage them
+var ownerKinds = map[string]struct{ kind, field string }{
+	"user":  {"user.user", "username"},
+	"group": {"user.group", "name"},
+}
+
+// ownedKinds are the resources which name the user or group that owns what
+// they manage, and the fields they name them in
+var ownedKinds = map[string][]string{
+	"file.owner":          {"user", "group"},
+	"unarchive":           {"user", "group"},
+	"user.authorized_key": {"user"},
+	"user.subid":          {"user"},
+}
+
+// getOwners makes resources depend on the user and group resources that
+// manage the user and group they name, so the ids can be looked up when they
+// are applied. Names are compared before rendering, so a name set with a
+// template gets no edge here; the params and lookups it uses are still
+// dependencies.
+func getOwners(ctx context.Context, g *graph.Graph, id string, node *parse.Node) (out []dependency, err error) {
+	for _, field := range ownedKinds[node.Kind()] {
+		owner := ownerKinds[field]
+		name, err := node.GetString(field)
+		if err == parse.ErrNotFound || name == "" || strings.Contains(name, "{{") {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, meta := range g.Nodes() {
+			other, ok := meta.Value().(*parse.Node)
+			if !ok || meta.ID == id || other.Kind() != owner.kind {
+				continue
+			}
+
+			if otherName, err := other.GetString(owner.field); err != nil || otherName != name {
+				continue
+			}
+
+			reason := ownerReason(field, name)
+			out = append(out, dependency{meta.ID, reason})
+			if peerVertex, ok := getPeerVertex(g, id, meta.ID); ok && peerVertex != meta.ID {
+				out = append(out, dependency{peerVertex, peerReason(reason, meta.ID)})
+			}
+		}
+	}
+	return out, nil
+}
+
 func getPeerVertex(g *graph.Graph, src, dst string) (string, bool) {
 	if dst == "." || graph.IsRoot(dst) {
 		return "", false
@@ -333,7 +481,7 @@ func alignEdgesInGroup(ctx context.Context, g *graph.Graph, id, group string) (*
 			}
 
 			if !willCycle(g, upEdge, dest) {
-				if err := g.SafeConnect(upEdge, dest); err != nil {
+				if err := g.SafeConnectBecause(upEdge, dest, groupReason(group)); err != nil {
 					return g, err
 				}
 			}
@@ -392,7 +540,7 @@ func connectIsolatedGroupNodes(ctx context.Context, g *graph.Graph, nodes []*nod
 				"from": from,
 				"to":   to,
 			}).Debug("connecting isolated group nodes")
-			if err := g.SafeConnect(from, to); err != nil {
+			if err := g.SafeConnectBecause(from, to, groupReason(group)); err != nil {
 				return g, err
 			}
 		}
@@ -418,7 +566,7 @@ func connectIsolatedGroupBranches(ctx context.Context, g *graph.Graph, nodes []*
 
 			if moduleEdge(g, from) == moduleEdge(g, dest) {
 				if !willCycle(g, from, dest) {
-					if err := g.SafeConnect(from, dest); err != nil {
+					if err := g.SafeConnectBecause(from, dest, groupReason(group)); err != nil {
 						return g, err
 					}
 				}
@@ -69,7 +69,19 @@ func TestDependencyResolverBadDependency(t *testing.T) {
 
 	_, err = load.ResolveDependencies(context.Background(), nodes)
 	if assert.Error(t, err) {
-		assert.EqualError(t, err, "1 error(s) occurred:\n\n* root/task.bad_requirement: nonexistent vertices in edges: task.nonexistent")
+		assert.EqualError(t, err, "1 error(s) occurred:\n\n* root/task.bad_requirement: ../samples/errors/bad_requirement.hcl:2:13: nonexistent vertices in edges: task.nonexistent")
+	}
+}
+
+func TestDependencyResolverBadParam(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	nodes, err := load.Nodes(context.Background(), "../samples/errors/bad_param_call.hcl", false)
+	require.NoError(t, err)
+
+	_, err = load.ResolveDependencies(context.Background(), nodes)
+	if assert.Error(t, err) {
+		assert.EqualError(t, err, "1 error(s) occurred:\n\n* root/task.bad: ../samples/errors/bad_param_call.hcl:3:20: unknown parameter: param.nonexistent")
 	}
 }
 
@@ -100,6 +112,20 @@ func TestDependencyResolverResolvesParam(t *testing.T) {
 	)
 }
 
+// TestDependencyResolverResolvesTemplateFile tests that params used in a
+// template file get dependencies
+func TestDependencyResolverResolvesTemplateFile(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	nodes, err := load.Nodes(context.Background(), "../samples/fileContentSource.hcl", false)
+	require.NoError(t, err)
+
+	resolved, err := load.ResolveDependencies(context.Background(), nodes)
+	require.NoError(t, err)
+
+	assert.True(t, graphutils.DependsOn(resolved, "root/file.content.motd", "root/param.hostname"))
+}
+
 // TestDependencyResolverHandlesConditionalMetadata ensures that we generate
 // dependencies for predicates
 func TestDependencyResolverHandlesConditionalMetadata(t *testing.T) {
@@ -229,3 +255,124 @@ func TestDependencyResolverResolvesGroupDependencies(t *testing.T) {
 		}
 	})
 }
+
+// TestDependencyResolverResolvesOwners tests that file ownership depends on
+// the users and groups it names
+func TestDependencyResolverResolvesOwners(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	src := `
+user.user "app" {
+	username = "app"
+}
+
+user.group "app" {
+	name = "app"
+}
+
+user.group "other" {
+	name = "other"
+}
+
+file.owner "config" {
+	destination = "/etc/app.conf"
+	user = "app"
+	group = "app"
+}
+
+user.authorized_key "deploy" {
+	user = "app"
+	key = "ssh-ed25519 AAAA deploy"
+}
+
+param "owner" {
+	default = "app"
+}
+
+file.owner "templated" {
+	destination = "/etc/other.conf"
+	user = "{{param ` + "`owner`" + `}}"
+}
+`
+	gr, err := hclutils.LoadFromString("ResolverResolvesOwners", src)
+	require.NoError(t, err)
+
+	g, err := load.ResolveDependencies(context.Background(), gr)
+	require.NoError(t, err)
+
+	assert.True(t, graphutils.DependsOn(g, "root/file.owner.config", "root/user.user.app"))
+	assert.True(t, graphutils.DependsOn(g, "root/file.owner.config", "root/user.group.app"))
+	assert.False(t, graphutils.DependsOn(g, "root/file.owner.config", "root/user.group.other"))
+	assert.True(t, graphutils.DependsOn(g, "root/user.authorized_key.deploy", "root/user.user.app"))
+
+	// templated names aren't rendered yet, so only the param is a dependency
+	assert.True(t, graphutils.DependsOn(g, "root/file.owner.templated", "root/param.owner"))
+	assert.False(t, graphutils.DependsOn(g, "root/file.owner.templated", "root/user.user.app"))
+}
+
+func TestDependencyResolverRecordsReasons(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	nodes, err := load.Nodes(context.Background(), "../samples/basicDependencies.hcl", false)
+	require.NoError(t, err)
+
+	resolved, err := load.ResolveDependencies(context.Background(), nodes)
+	require.NoError(t, err)
+
+	assert.Equal(
+		t,
+		[]graph.Reason{{Kind: graph.ReasonParam, Text: `uses param "filename"`}},
+		resolved.EdgeReasons("root/task.directory", "root/param.filename"),
+	)
+	assert.Contains(
+		t,
+		resolved.EdgeReasons("root/task.render", "root/task.directory"),
+		graph.Reason{Kind: graph.ReasonDepends, Text: `listed in depends as "task.directory"`},
+	)
+}
+
+func TestDependencyResolverAmbiguousReference(t *testing.T) {
+	src := `
+switch "env" {
+	case "true" "a" {
+		task.query "version" {
+			query = "echo a"
+		}
+	}
+
+	case "false" "b" {
+		task.query "version" {
+			query = "echo b"
+		}
+	}
+}
+
+task.query "report" {
+	query = "echo {{lookup ` + "`task.query.version.status.stdout`" + `}}"
+}
+`
+
+	t.Run("warns", func(t *testing.T) {
+		defer logging.HideLogs(t)()
+
+		gr, err := hclutils.LoadFromString("ResolverAmbiguousReference", src)
+		require.NoError(t, err)
+
+		g, err := load.ResolveDependencies(context.Background(), gr)
+		require.NoError(t, err)
+
+		// the first candidate by ID is used
+		assert.True(t, graphutils.DependsOn(g, "root/task.query.report", "root/macro.switch.env/macro.case.a/task.query.version"))
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		defer logging.HideLogs(t)()
+
+		gr, err := hclutils.LoadFromString("ResolverAmbiguousReference", src)
+		require.NoError(t, err)
+
+		_, err = load.ResolveDependencies(load.WithStrictRefs(context.Background(), true), gr)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ambiguous reference to task.query.version.status.stdout, which could refer to any of root/macro.switch.env/macro.case.a/task.query.version, root/macro.switch.env/macro.case.b/task.query.version")
+	})
+}
@@ -15,7 +15,9 @@
 package load
 
 import (
+	"github.com/asteris-llc/converge/errcode"
 	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/snapshot"
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 )
@@ -26,17 +28,21 @@ func Load(ctx context.Context, root string, verify bool) (*graph.Graph, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "loading failed")
 	}
+	snapshot.Dump(ctx, snapshot.PhaseNodes, base)
 
 	resolved, err := ResolveDependencies(ctx, base)
 
 	if err != nil {
-		return nil, errors.Wrap(err, "could not resolve dependencies")
+		return nil, errcode.Wrap(errcode.ResolveFailed, errors.Wrap(err, "could not resolve dependencies"))
 	}
+	snapshot.Dump(ctx, snapshot.PhaseResolve, resolved)
 
 	resourced, err := SetResources(ctx, resolved)
 
 	if err != nil {
-		return nil, errors.Wrap(err, "could not resolve resources")
+		return nil, errcode.Wrap(errcode.LoadInvalidResource, errors.Wrap(err, "could not resolve resources"))
 	}
+	snapshot.Dump(ctx, snapshot.PhaseResources, resourced)
+
 	return resourced, nil
 }
@@ -16,8 +16,11 @@ package load
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 
+	"github.com/asteris-llc/converge/errcode"
 	"github.com/asteris-llc/converge/fetch"
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
@@ -49,6 +52,11 @@ func Nodes(ctx context.Context, root string, verify bool) (*graph.Graph, error)
 	out := graph.New()
 	out.Add(node.New("root", nil))
 
+	// defaults blocks by the ID of the module they are in
+	defaults := map[string][]*parse.Node{}
+	// override blocks by the ID of the module they are in
+	overrides := map[string][]*parse.Node{}
+
 	for len(toLoad) > 0 {
 		select {
 		case <-ctx.Done():
@@ -61,40 +69,46 @@ func Nodes(ctx context.Context, root string, verify bool) (*graph.Graph, error)
 
 		url, err := fetch.ResolveInContext(current.Source, current.ParentSource)
 		if err != nil {
-			return nil, err
+			return nil, errcode.Wrap(errcode.LoadFetch, err)
 		}
 
-		logger.WithField("url", url).Debug("fetching")
-		content, err := fetch.Any(ctx, url)
-		if err != nil {
-			return nil, errors.Wrap(err, url)
+		if meta, ok := out.Get(current.Parent); ok {
+			meta.AddMetadata(node.MetaSource, url)
 		}
 
-		if verify {
-			signatureURL := url + ".asc"
+		location := fetch.Vendored(root, url)
+		if location != url {
+			logger.WithField("url", url).WithField("vendored", location).Debug("using vendored module")
+		}
 
-			logger.WithField("signatureUrl", signatureURL).Debug("fetching")
-			signature, sigErr := fetch.Any(ctx, signatureURL)
-			if sigErr != nil {
-				return nil, errors.Wrap(sigErr, signatureURL)
-			}
+		content, _, err := fetchModule(ctx, location, verify)
+		if err != nil {
+			return nil, err
+		}
 
-			err = keystore.Default().CheckSignature(bytes.NewBuffer(content), bytes.NewBuffer(signature))
-			if err != nil {
-				return nil, errors.Wrap(err, signatureURL)
-			}
+		if meta, ok := out.Get(current.Parent); ok {
+			sum := sha256.Sum256(content)
+			meta.AddMetadata(node.MetaSourceHash, hex.EncodeToString(sum[:]))
 		}
 
-		resources, err := parse.Parse(content)
+		resources, err := parse.ParseFile(content, displayName(url))
 		if err != nil {
-			return nil, errors.Wrap(err, url)
+			return nil, errcode.Wrap(errcode.LoadParse, errors.Wrap(err, url))
 		}
 
 		for _, resource := range resources {
+			if resource.IsDefaults() {
+				defaults[current.Parent] = append(defaults[current.Parent], resource)
+				continue
+			}
+			if resource.IsOverride() {
+				overrides[current.Parent] = append(overrides[current.Parent], resource)
+				continue
+			}
 			if control.IsSwitchNode(resource) {
 				out, err = expandSwitchMacro(content, current, resource, out)
 				if err != nil {
-					return out, errors.Wrap(err, "unable to load resource")
+					return out, errcode.Wrap(errcode.LoadInvalidGraph, errors.Wrap(err, "unable to load resource"))
 				}
 				continue
 			}
@@ -114,7 +128,43 @@ func Nodes(ctx context.Context, root string, verify bool) (*graph.Graph, error)
 			}
 		}
 	}
-	return out, out.Validate()
+	if err := applyDefaults(out, defaults); err != nil {
+		return nil, errcode.Wrap(errcode.LoadInvalidResource, errors.Wrap(err, "could not apply defaults"))
+	}
+	if err := applyOverrides(out, overrides); err != nil {
+		return nil, errcode.Wrap(errcode.LoadInvalidResource, errors.Wrap(err, "could not apply overrides"))
+	}
+
+	return out, errcode.Wrap(errcode.LoadInvalidGraph, out.Validate())
+}
+
+// fetchModule fetches the content of a module. When verify is set, the
+// signature of the module is fetched and checked too, and returned with it.
+func fetchModule(ctx context.Context, url string, verify bool) (content, signature []byte, err error) {
+	logger := logging.GetLogger(ctx).WithField("function", "fetchModule")
+
+	logger.WithField("url", url).Debug("fetching")
+	content, err = fetch.Any(ctx, url)
+	if err != nil {
+		return nil, nil, errcode.Wrap(errcode.LoadFetch, errors.Wrap(err, url))
+	}
+
+	if verify {
+		signatureURL := url + ".asc"
+
+		logger.WithField("signatureUrl", signatureURL).Debug("fetching")
+		signature, err = fetch.Any(ctx, signatureURL)
+		if err != nil {
+			return nil, nil, errcode.Wrap(errcode.LoadFetch, errors.Wrap(err, signatureURL))
+		}
+
+		err = keystore.Default().CheckSignature(bytes.NewBuffer(content), bytes.NewBuffer(signature))
+		if err != nil {
+			return nil, nil, errcode.Wrap(errcode.LoadSignature, errors.Wrap(err, signatureURL))
+		}
+	}
+
+	return content, signature, nil
 }
 
 // expandSwitchMacro is responsible for adding the generated switch nodes into
@@ -183,7 +233,7 @@ func expandSwitchMacro(data []byte, current *source, n *parse.Node, g *graph.Gra
 		if idx > 0 {
 			parent, _ := switchObj.Branches[idx-1].GenerateNode()
 
-			g.Connect(branchID, graph.ID(switchID, parent.ID()))
+			g.ConnectBecause(branchID, graph.ID(switchID, parent.ID()), branchReason)
 		}
 	}
 	return g, nil
@@ -199,6 +249,19 @@ func validateInnerNode(node *parse.Node) error {
 		return errors.New("nested conditionals are not supported")
 	case "case":
 		return errors.New("nested branches are not supported")
+	case "defaults":
+		return errors.New("defaults not supported in conditionals")
+	case "override":
+		return errors.New("overrides not supported in conditionals")
 	}
 	return nil
 }
+
+// displayName is how a module is named in the positions of its nodes: the
+// path for modules on the local filesystem, and the URL for anything else
+func displayName(url string) string {
+	if path, err := fetch.LocalPath(url); err == nil {
+		return path
+	}
+	return url
+}
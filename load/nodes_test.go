@@ -16,7 +16,12 @@ package load_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sort"
 	"testing"
 
@@ -25,6 +30,7 @@ import (
 	"github.com/asteris-llc/converge/helpers/logging"
 	"github.com/asteris-llc/converge/helpers/testing/graphutils"
 	"github.com/asteris-llc/converge/load"
+	"github.com/asteris-llc/converge/parse"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -38,6 +44,106 @@ func TestNodesBasic(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// TestNodesDefaults tests loading defaults.hcl
+func TestNodesDefaults(t *testing.T) {
+	t.Parallel()
+	defer logging.HideLogs(t)()
+
+	g, err := load.Nodes(context.Background(), "../samples/defaults.hcl", false)
+	require.NoError(t, err)
+
+	get := func(id, key string) string {
+		meta, ok := g.Get(id)
+		require.True(t, ok, "%q was missing from the graph", id)
+
+		val, err := meta.Value().(*parse.Node).GetString(key)
+		require.NoError(t, err)
+		return val
+	}
+
+	assert.Equal(t, "60s", get("root/task.create", "timeout"))
+	assert.Equal(t, "/bin/bash", get("root/task.create", "interpreter"))
+	assert.Equal(t, "5m", get("root/task.slow", "timeout"))
+	assert.Equal(t, "/tmp/converge-defaults", get("root/file.mode.mode", "destination"))
+
+	for _, id := range g.Vertices() {
+		assert.NotContains(t, id, "defaults.")
+	}
+}
+
+// TestNodesOverride tests loading override.hcl
+func TestNodesOverride(t *testing.T) {
+	t.Parallel()
+	defer logging.HideLogs(t)()
+
+	g, err := load.Nodes(context.Background(), "../samples/override.hcl", false)
+	require.NoError(t, err)
+
+	meta, ok := g.Get("root/module.basic/task.render")
+	require.True(t, ok)
+	raw := meta.Value().(*parse.Node)
+
+	apply, err := raw.GetString("apply")
+	require.NoError(t, err)
+	assert.Equal(t, "echo '{{param `message`}}' | tee {{param `filename`}}", apply)
+
+	interpreter, err := raw.GetString("interpreter")
+	require.NoError(t, err)
+	assert.Equal(t, "/bin/bash", interpreter)
+
+	// fields that aren't overridden are kept
+	_, err = raw.GetString("check")
+	assert.NoError(t, err)
+
+	for _, id := range g.Vertices() {
+		assert.NotContains(t, id, "override.")
+	}
+}
+
+// TestNodesOverrideInvalid tests overrides that can't be applied
+func TestNodesOverrideInvalid(t *testing.T) {
+	t.Parallel()
+	defer logging.HideLogs(t)()
+
+	tmp, err := ioutil.TempDir("", "converge-override")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	require.NoError(t, ioutil.WriteFile(
+		filepath.Join(tmp, "base.hcl"),
+		[]byte(`task "x" { check = "true" apply = "true" }`),
+		0644,
+	))
+
+	loadWith := func(name, override string) error {
+		path := filepath.Join(tmp, name+".hcl")
+		content := `module "base.hcl" "base" {}` + "\n" + override
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+
+		_, err := load.Nodes(context.Background(), path, false)
+		return err
+	}
+
+	t.Run("missing resource", func(t *testing.T) {
+		err := loadWith("missing", `override "module.base/task.y" { check = "false" }`)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "root/module.base/task.y")
+		assert.Contains(t, err.Error(), "no resource to override")
+	})
+
+	t.Run("module", func(t *testing.T) {
+		err := loadWith("module", `override "module.base" { check = "false" }`)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "only resources can be overridden")
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		err := loadWith("field", `override "module.base/task.x" { bogus = "false" }`)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `task has no field "bogus"`)
+	})
+}
+
 // TestNodesSourceFile tests loading from a source file
 func TestNodesSourceFile(t *testing.T) {
 	t.Parallel()
@@ -69,6 +175,27 @@ func TestNodesSourceFile(t *testing.T) {
 		},
 		basicDeps,
 	)
+
+	assertSource := func(id, expected string) {
+		meta, ok := g.Get(id)
+		require.True(t, ok, "%q was missing from the graph", id)
+		source, _ := meta.LookupMetadata(node.MetaSource)
+		assert.Equal(t, expected, source)
+	}
+
+	assertSource("root", "file://../samples/sourceFile.hcl")
+	assertSource("root/module.basic", "file://../samples/basic.hcl")
+
+	t.Run("hash", func(t *testing.T) {
+		content, err := ioutil.ReadFile("../samples/basic.hcl")
+		require.NoError(t, err)
+		sum := sha256.Sum256(content)
+
+		meta, ok := g.Get("root/module.basic")
+		require.True(t, ok)
+		hash, _ := meta.LookupMetadata(node.MetaSourceHash)
+		assert.Equal(t, hex.EncodeToString(sum[:]), hash)
+	})
 }
 
 // TestNodeWithConditionals tests loading when switch statements are present
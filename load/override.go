@@ -0,0 +1,109 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/parse"
+	"github.com/pkg/errors"
+)
+
+// applyOverrides replaces fields of resources in child modules with the values
+// set in override blocks. An override block is named by the path to the
+// resource from the module it is in, like `module.base/file.content.config`.
+// Overrides in outer modules are applied last, so they win over the ones in
+// the modules they call.
+func applyOverrides(g *graph.Graph, overrides map[string][]*parse.Node) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	var modules []string
+	for module := range overrides {
+		modules = append(modules, module)
+	}
+	sort.Sort(byDepth(modules))
+
+	for _, module := range modules {
+		for _, block := range overrides[module] {
+			id := graph.ID(module, block.Name())
+			if err := applyOverride(g, id, block); err != nil {
+				return errors.Wrap(err, id)
+			}
+		}
+	}
+
+	return nil
+}
+
+// applyOverride replaces fields of a single resource
+func applyOverride(g *graph.Graph, id string, block *parse.Node) error {
+	meta, ok := g.Get(id)
+	if !ok {
+		return fmt.Errorf("%s: no resource to override", block.Pos())
+	}
+
+	raw, ok := meta.Value().(*parse.Node)
+	if !ok || raw.IsModule() {
+		return fmt.Errorf("%s: only resources can be overridden", block.Pos())
+	}
+
+	fields, exclusive, ok := fieldsFor(raw.Kind())
+	if !ok {
+		return fmt.Errorf("%s: only resources can be overridden", block.Pos())
+	}
+
+	positions := block.FieldPositions()
+	var keys []string
+	for key := range positions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !fields[key] {
+			return fmt.Errorf("%s: %s has no field %q", positions[key], raw.Kind(), key)
+		}
+	}
+
+	// setting a field removes the fields it can't be used together with
+	conflicts := func(key string) []string {
+		var out []string
+		for _, other := range exclusive[key] {
+			if other != key {
+				out = append(out, other)
+			}
+		}
+		return out
+	}
+
+	return raw.MergeOverride(block, conflicts)
+}
+
+// byDepth sorts module IDs so the most deeply nested come first
+type byDepth []string
+
+func (b byDepth) Len() int      { return len(b) }
+func (b byDepth) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byDepth) Less(i, j int) bool {
+	di, dj := strings.Count(b[i], "/"), strings.Count(b[j], "/")
+	if di != dj {
+		return di > dj
+	}
+	return b[i] < b[j]
+}
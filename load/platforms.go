@@ -0,0 +1,124 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/parse"
+	"github.com/asteris-llc/converge/render/extensions/platform"
+	"github.com/asteris-llc/converge/resource"
+	"golang.org/x/net/context"
+)
+
+// hostPlatform is the platform of the host, as "os/arch"
+func hostPlatform() string {
+	// the OS and architecture are set even if the version can't be detected
+	p, _ := platform.DefaultPlatform()
+	return p.OS + "/" + p.Arch
+}
+
+// matchesPlatform checks a platform from the platforms field against the host.
+// A platform without an architecture matches every architecture of that OS.
+func matchesPlatform(want, host string) bool {
+	if strings.Contains(want, "/") {
+		return want == host
+	}
+	return strings.SplitN(host, "/", 2)[0] == want
+}
+
+// validatePlatforms checks that each platform is "os" or "os/arch"
+func validatePlatforms(raw *parse.Node, platforms []string) error {
+	for _, p := range platforms {
+		parts := strings.Split(p, "/")
+		if len(parts) > 2 || parts[0] == "" || (len(parts) == 2 && parts[1] == "") {
+			return fmt.Errorf("%s: invalid platform %q, expected \"os\" or \"os/arch\"", raw.FieldPosition("platforms"), p)
+		}
+	}
+	return nil
+}
+
+// excludedBy returns the platforms which keep a node from running on the
+// host. A node is excluded when it, or the module call it's part of, lists
+// platforms and none of them match.
+func excludedBy(g *graph.Graph, id, host string) ([]string, error) {
+	for ; !graph.IsRoot(id) && id != "."; id = graph.ParentID(id) {
+		meta, ok := g.Get(id)
+		if !ok {
+			continue
+		}
+
+		raw, ok := meta.Value().(*parse.Node)
+		if !ok {
+			continue
+		}
+
+		platforms, err := raw.Platforms()
+		if err != nil {
+			return nil, err
+		}
+		if len(platforms) == 0 {
+			continue
+		}
+
+		if err := validatePlatforms(raw, platforms); err != nil {
+			return nil, err
+		}
+
+		matched := false
+		for _, p := range platforms {
+			if matchesPlatform(p, host) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return platforms, nil
+		}
+	}
+	return nil, nil
+}
+
+// platformSkipped takes the place of a node which doesn't run on the host
+type platformSkipped struct {
+	Platforms []string
+	Host      string
+}
+
+// Prepare returns the skipped node as its own task
+func (p *platformSkipped) Prepare(context.Context, resource.Renderer) (resource.Task, error) {
+	return p, nil
+}
+
+// Check reports that the node was skipped
+func (p *platformSkipped) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	return p.skip("checked"), nil
+}
+
+// Apply reports that the node was skipped
+func (p *platformSkipped) Apply(context.Context) (resource.TaskStatus, error) {
+	return p.skip("applied"), nil
+}
+
+func (p *platformSkipped) skip(verb string) *resource.Status {
+	status := resource.NewStatus()
+	status.AddWarning(
+		resource.WarningSkipped,
+		fmt.Sprintf("not %s because it only runs on %s, and this host is %s", verb, strings.Join(p.Platforms, ", "), p.Host),
+	)
+	return status
+}
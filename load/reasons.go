@@ -0,0 +1,52 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"fmt"
+
+	"github.com/asteris-llc/converge/graph"
+)
+
+// branchReason is why a switch branch depends on the branch before it
+var branchReason = graph.Reason{Kind: graph.ReasonBranch, Text: "checked after the branch before it"}
+
+func dependsReason(dep string) graph.Reason {
+	return graph.Reason{Kind: graph.ReasonDepends, Text: fmt.Sprintf("listed in depends as %q", dep)}
+}
+
+func paramReason(name string) graph.Reason {
+	return graph.Reason{Kind: graph.ReasonParam, Text: fmt.Sprintf("uses param %q", name)}
+}
+
+func lookupReason(call string) graph.Reason {
+	return graph.Reason{Kind: graph.ReasonLookup, Text: fmt.Sprintf("looks up %q", call)}
+}
+
+// groupReason is why nodes in a group are connected
+func groupReason(group string) graph.Reason {
+	return graph.Reason{Kind: graph.ReasonGroup, Text: fmt.Sprintf("in group %q, which runs one node at a time", group)}
+}
+
+func ownerReason(field, name string) graph.Reason {
+	return graph.Reason{Kind: graph.ReasonOwner, Text: fmt.Sprintf("owned by the %s %q it manages", field, name)}
+}
+
+// peerReason is the reason for depending on the peer of the vertex that a
+// reason first pointed at
+func peerReason(reason graph.Reason, vertex string) graph.Reason {
+	reason.Text += ", in " + vertex
+	return reason
+}
@@ -17,19 +17,22 @@ package registry
 import (
 	"fmt"
 	"reflect"
+	"sort"
 )
 
 // Registry for importable types
 type Registry struct {
 	forward map[string]reflect.Type
 	reverse map[reflect.Type]string
+	created map[string][]reflect.Type
 }
 
 // New creates a new Registry
 func New() *Registry {
 	return &Registry{
-		map[string]reflect.Type{},
-		map[reflect.Type]string{},
+		forward: map[string]reflect.Type{},
+		reverse: map[reflect.Type]string{},
+		created: map[string][]reflect.Type{},
 	}
 }
 
@@ -40,6 +43,9 @@ func (r *Registry) Register(name string, i interface{}, reverse ...interface{})
 	}
 
 	r.forward[name] = reflect.TypeOf(i)
+	for _, rev := range reverse {
+		r.created[name] = append(r.created[name], reflect.TypeOf(rev))
+	}
 
 	var err error
 	for _, rev := range append(reverse, i) {
@@ -93,6 +99,22 @@ func (r *Registry) NameForType(i interface{}) (string, bool) {
 	return name, present
 }
 
+// Names lists the names types were registered under, sorted
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.forward))
+	for name := range r.forward {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CreatedTypes lists the types given in reverse when a name was registered,
+// which are the types its registered value creates
+func (r *Registry) CreatedTypes(name string) []reflect.Type {
+	return r.created[name]
+}
+
 // package-global API
 var registry *Registry
 
@@ -122,6 +144,18 @@ func NameForType(i interface{}) (string, bool) {
 	return registry.NameForType(i)
 }
 
+// Names lists the names types were registered under in the global registry,
+// sorted
+func Names() []string {
+	return registry.Names()
+}
+
+// CreatedTypes lists the types given in reverse when a name was registered in
+// the global registry
+func CreatedTypes(name string) []reflect.Type {
+	return registry.CreatedTypes(name)
+}
+
 func init() {
 	registry = New()
 }
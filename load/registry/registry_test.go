@@ -16,6 +16,7 @@ package registry_test
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 
 	"github.com/asteris-llc/converge/load/registry"
@@ -27,6 +28,8 @@ type TestType struct {
 	X string `json:"x"`
 }
 
+type TestType2 struct{}
+
 func TestRegistryRegister(t *testing.T) {
 	t.Parallel()
 
@@ -65,6 +68,26 @@ func TestRegistryRegisterReverse(t *testing.T) {
 	})
 }
 
+func TestRegistryNames(t *testing.T) {
+	t.Parallel()
+
+	r := registry.New()
+	require.NoError(t, r.Register("b", new(TestType)))
+	require.NoError(t, r.Register("a", new(TestType)))
+
+	assert.Equal(t, []string{"a", "b"}, r.Names())
+}
+
+func TestRegistryCreatedTypes(t *testing.T) {
+	t.Parallel()
+
+	r := registry.New()
+	require.NoError(t, r.Register("test", new(TestType), new(TestType2)))
+
+	assert.Equal(t, []reflect.Type{reflect.TypeOf(new(TestType2))}, r.CreatedTypes("test"))
+	assert.Empty(t, r.CreatedTypes("missing"))
+}
+
 func TestRegistryNewByName(t *testing.T) {
 	t.Parallel()
 
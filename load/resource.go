@@ -17,6 +17,7 @@ package load
 import (
 	"fmt"
 
+	"github.com/asteris-llc/converge/errcode"
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/helpers/logging"
@@ -24,31 +25,49 @@ import (
 	"github.com/asteris-llc/converge/parse"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/hashicorp/hcl"
+	"github.com/pkg/errors"
 
 	// import empty to register types for SetResources
+	_ "github.com/asteris-llc/converge/resource/authorizedkey"
 	_ "github.com/asteris-llc/converge/resource/docker/container"
 	_ "github.com/asteris-llc/converge/resource/docker/image"
 	_ "github.com/asteris-llc/converge/resource/docker/network"
 	_ "github.com/asteris-llc/converge/resource/docker/volume"
+	_ "github.com/asteris-llc/converge/resource/file/acl"
+	_ "github.com/asteris-llc/converge/resource/file/attributes"
+	_ "github.com/asteris-llc/converge/resource/file/block"
+	_ "github.com/asteris-llc/converge/resource/file/cleanup"
 	_ "github.com/asteris-llc/converge/resource/file/content"
 	_ "github.com/asteris-llc/converge/resource/file/directory"
+	_ "github.com/asteris-llc/converge/resource/file/document"
 	_ "github.com/asteris-llc/converge/resource/file/fetch"
+	_ "github.com/asteris-llc/converge/resource/file/ini"
+	_ "github.com/asteris-llc/converge/resource/file/link"
 	_ "github.com/asteris-llc/converge/resource/file/mode"
 	_ "github.com/asteris-llc/converge/resource/file/owner"
+	_ "github.com/asteris-llc/converge/resource/file/selinux"
+	_ "github.com/asteris-llc/converge/resource/file/tempdir"
+	_ "github.com/asteris-llc/converge/resource/file/touch"
+	_ "github.com/asteris-llc/converge/resource/file/verify"
 	_ "github.com/asteris-llc/converge/resource/group"
 	_ "github.com/asteris-llc/converge/resource/lvm/fs"
 	_ "github.com/asteris-llc/converge/resource/lvm/lv"
 	_ "github.com/asteris-llc/converge/resource/lvm/vg"
 	_ "github.com/asteris-llc/converge/resource/module"
+	_ "github.com/asteris-llc/converge/resource/mount"
 	_ "github.com/asteris-llc/converge/resource/package/apt"
 	_ "github.com/asteris-llc/converge/resource/package/rpm"
 	_ "github.com/asteris-llc/converge/resource/param"
+	_ "github.com/asteris-llc/converge/resource/service"
 	_ "github.com/asteris-llc/converge/resource/shell"
 	_ "github.com/asteris-llc/converge/resource/shell/query"
+	_ "github.com/asteris-llc/converge/resource/subid"
 	_ "github.com/asteris-llc/converge/resource/systemd/unit"
 	_ "github.com/asteris-llc/converge/resource/unarchive"
 	_ "github.com/asteris-llc/converge/resource/user"
+	_ "github.com/asteris-llc/converge/resource/user/query"
 	_ "github.com/asteris-llc/converge/resource/wait"
+	_ "github.com/asteris-llc/converge/resource/wait/consul"
 	_ "github.com/asteris-llc/converge/resource/wait/port"
 	"golang.org/x/net/context"
 )
@@ -58,6 +77,8 @@ func SetResources(ctx context.Context, g *graph.Graph) (*graph.Graph, error) {
 	logger := logging.GetLogger(ctx).WithField("function", "SetResources")
 	logger.Debug("loading resources")
 
+	host := hostPlatform()
+
 	return g.Transform(ctx, func(meta *node.Node, out *graph.Graph) error {
 		if graph.IsRoot(meta.ID) {
 			return nil
@@ -70,22 +91,63 @@ func SetResources(ctx context.Context, g *graph.Graph) (*graph.Graph, error) {
 
 		dest, ok := registry.NewByName(raw.Kind())
 		if !ok {
-			return fmt.Errorf("%q is not a valid resource type in %q", raw.Kind(), raw)
+			return errcode.Errorf(errcode.LoadUnknownResource, "%s: %q is not a valid resource type in %q", raw.Pos(), raw.Kind(), raw)
 		}
 
 		res, ok := dest.(resource.Resource)
 		if !ok {
-			return fmt.Errorf("%q is not a valid resource, got %T", raw.Kind(), dest)
+			return errcode.Errorf(errcode.LoadInvalidResource, "%q is not a valid resource, got %T", raw.Kind(), dest)
+		}
+
+		// the type is checked even for nodes excluded on this platform, so typos
+		// are caught everywhere
+		platforms, err := excludedBy(g, meta.ID, host)
+		if err != nil {
+			return errcode.Wrap(errcode.LoadInvalidResource, err)
+		}
+		if platforms != nil {
+			logger.WithField("id", meta.ID).WithField("platforms", platforms).Debug("excluded on this platform")
+			out.Add(meta.WithValue(&platformSkipped{Platforms: platforms, Host: host}))
+			return nil
 		}
 
 		preparer := resource.NewPreparer(res)
+		preparer.Position = raw.Pos().String()
+		preparer.FieldPositions = map[string]string{}
+		for field, pos := range raw.FieldPositions() {
+			preparer.FieldPositions[field] = pos.String()
+		}
 
-		err := hcl.DecodeObject(&preparer.Source, raw.ObjectItem.Val)
+		err = hcl.DecodeObject(&preparer.Source, raw.ObjectItem.Val)
 		if err != nil {
-			return err
+			return errcode.Wrap(errcode.LoadInvalidResource, errors.Wrap(err, raw.Pos().String()))
+		}
+
+		updated := meta.WithValue(preparer)
+		if raw.AllowDestructive() {
+			updated.AddMetadata(node.MetaAllowDestructive, true)
+		}
+
+		transaction, err := raw.Transaction()
+		if err != nil {
+			return errcode.Wrap(errcode.LoadInvalidResource, fmt.Errorf("%s: %s", raw.FieldPosition("transaction"), err))
+		}
+		if transaction != "" {
+			if group, _ := raw.GetString("group"); group != "" && group != transaction {
+				return errcode.Errorf(errcode.LoadInvalidResource, "%s: can't be in group %q and transaction %q, members of a transaction are already grouped by its name", raw.FieldPosition("group"), group, transaction)
+			}
+			updated.AddMetadata(node.MetaTransaction, transaction)
+		}
+
+		window, err := changeWindowFor(ctx, g, meta.ID)
+		if err != nil {
+			return errcode.Wrap(errcode.LoadInvalidResource, err)
+		}
+		if window != nil {
+			updated.AddMetadata(node.MetaChangeWindow, window)
 		}
 
-		out.Add(meta.WithValue(preparer))
+		out.Add(updated)
 		return nil
 	})
 }
@@ -16,8 +16,10 @@ package load_test
 
 import (
 	"fmt"
+	"runtime"
 	"testing"
 
+	"github.com/asteris-llc/converge/changewindow"
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/helpers/logging"
@@ -57,10 +59,207 @@ func TestSetResourcesBad(t *testing.T) {
 
 	_, err := getResourcesGraph(t, []byte("x x {}"))
 	if assert.Error(t, err) {
-		assert.EqualError(t, err, "1 error(s) occurred:\n\n* root/x.x: \"x\" is not a valid resource type in \"x.x\"")
+		assert.EqualError(t, err, "1 error(s) occurred:\n\n* root/x.x: 1:1: \"x\" is not a valid resource type in \"x.x\"")
 	}
 }
 
+func TestSetResourcesPlatforms(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	loadTask := func(t *testing.T, platforms string) (resource.Resource, error) {
+		resourced, err := getResourcesGraph(t, []byte(fmt.Sprintf(`
+task x {
+  check     = "check"
+  apply     = "apply"
+  platforms = %s
+}`, platforms)))
+		if err != nil {
+			return nil, err
+		}
+
+		meta, ok := resourced.Get("root/task.x")
+		require.True(t, ok, `"root/task.x" was not present in the graph`)
+
+		res, ok := meta.Value().(resource.Resource)
+		require.True(t, ok, fmt.Sprintf("value was %T, not a resource", meta.Value()))
+		return res, nil
+	}
+
+	t.Run("matching os", func(t *testing.T) {
+		res, err := loadTask(t, fmt.Sprintf(`["plan9", %q]`, runtime.GOOS))
+		require.NoError(t, err)
+
+		_, ok := res.(*resource.Preparer)
+		assert.True(t, ok)
+	})
+
+	t.Run("matching os and arch", func(t *testing.T) {
+		res, err := loadTask(t, fmt.Sprintf(`[%q]`, runtime.GOOS+"/"+runtime.GOARCH))
+		require.NoError(t, err)
+
+		_, ok := res.(*resource.Preparer)
+		assert.True(t, ok)
+	})
+
+	t.Run("other arch", func(t *testing.T) {
+		res, err := loadTask(t, fmt.Sprintf(`[%q]`, runtime.GOOS+"/other"))
+		require.NoError(t, err)
+
+		_, ok := res.(*resource.Preparer)
+		assert.False(t, ok)
+	})
+
+	t.Run("not matching", func(t *testing.T) {
+		res, err := loadTask(t, `["plan9"]`)
+		require.NoError(t, err)
+
+		_, ok := res.(*resource.Preparer)
+		require.False(t, ok)
+
+		task, err := res.Prepare(context.Background(), nil)
+		require.NoError(t, err)
+
+		status, err := task.Check(context.Background(), nil)
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Equal(
+			t,
+			[]resource.Warning{{
+				Kind:    resource.WarningSkipped,
+				Message: fmt.Sprintf("not checked because it only runs on plan9, and this host is %s/%s", runtime.GOOS, runtime.GOARCH),
+			}},
+			resource.StatusWarnings(status),
+		)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := loadTask(t, `["linux/"]`)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), `invalid platform "linux/", expected "os" or "os/arch"`)
+		}
+	})
+
+	t.Run("module call", func(t *testing.T) {
+		resources, err := parse.Parse([]byte(`
+module "source.hcl" "x" {
+  platforms = ["plan9"]
+}
+
+task y {
+  check = "check"
+  apply = "apply"
+}`))
+		require.NoError(t, err)
+
+		g := graph.New()
+		g.Add(node.New("root", nil))
+		g.Add(node.New("root/module.x", resources[0]))
+		g.Add(node.New("root/module.x/task.y", resources[1]))
+		g.Connect("root", "root/module.x")
+		g.Connect("root/module.x", "root/module.x/task.y")
+		require.NoError(t, g.Validate())
+
+		resourced, err := load.SetResources(context.Background(), g)
+		require.NoError(t, err)
+
+		// nodes in the module are excluded along with the module call
+		meta, ok := resourced.Get("root/module.x/task.y")
+		require.True(t, ok)
+		_, ok = meta.Value().(*resource.Preparer)
+		assert.False(t, ok)
+	})
+}
+
+func TestSetResourcesChangeWindow(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	resources, err := parse.Parse([]byte(`
+module "source.hcl" "x" {
+  change_window = "weekends"
+}
+
+task y {
+  check = "check"
+  apply = "apply"
+}
+
+task z {
+  check         = "check"
+  apply         = "apply"
+  change_window = "* 2-4 * * *"
+}`))
+	require.NoError(t, err)
+
+	g := graph.New()
+	g.Add(node.New("root", nil))
+	g.Add(node.New("root/module.x", resources[0]))
+	g.Add(node.New("root/module.x/task.y", resources[1]))
+	g.Add(node.New("root/task.z", resources[2]))
+	g.Connect("root", "root/module.x")
+	g.Connect("root", "root/task.z")
+	g.Connect("root/module.x", "root/module.x/task.y")
+	require.NoError(t, g.Validate())
+
+	windowOf := func(t *testing.T, g *graph.Graph, id string) string {
+		meta, ok := g.Get(id)
+		require.True(t, ok)
+		raw, ok := meta.LookupMetadata(node.MetaChangeWindow)
+		require.True(t, ok, "%s has no change window", id)
+		return fmt.Sprint(raw)
+	}
+
+	t.Run("named", func(t *testing.T) {
+		ctx := changewindow.WithNamed(context.Background(), map[string]string{"weekends": "* * * * sat,sun"})
+		resourced, err := load.SetResources(ctx, g)
+		require.NoError(t, err)
+
+		// nodes in the module take the window of the module call
+		assert.Equal(t, "weekends (* * * * sat,sun)", windowOf(t, resourced, "root/module.x/task.y"))
+		assert.Equal(t, "* 2-4 * * *", windowOf(t, resourced, "root/task.z"))
+	})
+
+	t.Run("unknown name", func(t *testing.T) {
+		_, err := load.SetResources(context.Background(), g)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), `unknown change window "weekends"`)
+		}
+	})
+}
+
+func TestSetResourcesTransaction(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	t.Run("member", func(t *testing.T) {
+		g, err := getResourcesGraph(t, []byte(`
+task x {
+  check       = "check"
+  apply       = "apply"
+  transaction = "service"
+}`))
+		require.NoError(t, err)
+
+		meta, ok := g.Get("root/task.x")
+		require.True(t, ok)
+		transaction, ok := meta.LookupMetadata(node.MetaTransaction)
+		assert.True(t, ok)
+		assert.Equal(t, "service", transaction)
+		assert.Equal(t, "service", meta.Group)
+	})
+
+	t.Run("other group", func(t *testing.T) {
+		_, err := getResourcesGraph(t, []byte(`
+task x {
+  check       = "check"
+  apply       = "apply"
+  group       = "apt"
+  transaction = "service"
+}`))
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), `can't be in group "apt" and transaction "service"`)
+		}
+	})
+}
+
 func getResourcesGraph(t *testing.T, content []byte) (*graph.Graph, error) {
 	resources, err := parse.Parse(content)
 	require.NoError(t, err)
@@ -0,0 +1,107 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"path/filepath"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/parse"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/hashicorp/hcl"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// SmokeResult is the outcome of preparing a registered resource outside of a
+// graph
+type SmokeResult struct {
+	Kind string
+
+	// Source is "zero value", "empty", or the position of an example
+	Source string
+
+	Err error
+}
+
+// Panicked is true if the resource panicked instead of returning an error
+func (s *SmokeResult) Panicked() bool {
+	_, ok := errors.Cause(s.Err).(*resource.PanicError)
+	return ok
+}
+
+// SmokePrepare prepares every registered resource to check that none of them
+// panic. Each resource is prepared three ways: as its zero value, through a
+// wrapping preparer with no fields set, and with every node of its kind in the
+// example files. Templates in the examples are not rendered, so most
+// resources return errors; only panics indicate a broken resource.
+func SmokePrepare(ctx context.Context, examples map[string][]byte) ([]*SmokeResult, error) {
+	var results []*SmokeResult
+
+	for _, kind := range registry.Names() {
+		res, ok := newResource(kind)
+		if !ok {
+			continue
+		}
+
+		_, err := resource.SafePrepare(ctx, res, fakerenderer.New())
+		results = append(results, &SmokeResult{Kind: kind, Source: "zero value", Err: err})
+
+		res, _ = newResource(kind)
+		_, err = resource.SafePrepare(ctx, resource.NewPreparer(res), fakerenderer.New())
+		results = append(results, &SmokeResult{Kind: kind, Source: "empty", Err: err})
+	}
+
+	for filename, content := range examples {
+		nodes, err := parse.ParseFile(content, filename)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not parse %s", filename)
+		}
+
+		renderer := fakerenderer.New()
+		if abs, err := filepath.Abs(filename); err == nil {
+			renderer.SourceURL = "file://" + abs
+		}
+
+		for _, node := range nodes {
+			res, ok := newResource(node.Kind())
+			if !ok {
+				continue
+			}
+
+			preparer := resource.NewPreparer(res)
+			preparer.Position = node.Pos().String()
+			if err := hcl.DecodeObject(&preparer.Source, node.ObjectItem.Val); err != nil {
+				return nil, errors.Wrap(err, node.Pos().String())
+			}
+
+			_, err := resource.SafePrepare(ctx, preparer, renderer)
+			results = append(results, &SmokeResult{Kind: node.Kind(), Source: preparer.Position, Err: err})
+		}
+	}
+
+	return results, nil
+}
+
+// newResource creates a new resource by the kind it was registered under
+func newResource(kind string) (resource.Resource, bool) {
+	val, ok := registry.NewByName(kind)
+	if !ok {
+		return nil, false
+	}
+	res, ok := val.(resource.Resource)
+	return res, ok
+}
@@ -0,0 +1,68 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/load"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// TestSmokePrepare prepares every registered resource with zero values and
+// with the samples, so a resource which panics on unexpected input is caught
+// before users load it
+func TestSmokePrepare(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	files, err := filepath.Glob(filepath.Join("..", "samples", "*.hcl"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	examples := map[string][]byte{}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		require.NoError(t, err)
+		examples[file] = content
+	}
+
+	results, err := load.SmokePrepare(context.Background(), examples)
+	require.NoError(t, err)
+
+	kinds := map[string]bool{}
+	for _, result := range results {
+		kinds[result.Kind] = true
+		assert.False(t, result.Panicked(), "%s (%s): %s", result.Kind, result.Source, result.Err)
+	}
+
+	for _, name := range registry.Names() {
+		assert.True(t, kinds[name], "%s was not prepared", name)
+	}
+}
+
+func TestSmokeResultPanicked(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, (&load.SmokeResult{Err: &resource.PanicError{Value: "boom"}}).Panicked())
+	assert.False(t, (&load.SmokeResult{Err: assert.AnError}).Panicked())
+	assert.False(t, (&load.SmokeResult{}).Panicked())
+}
@@ -0,0 +1,32 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import "golang.org/x/net/context"
+
+type strictRefsKey struct{}
+
+// WithStrictRefs returns a context in which references that could resolve to
+// more than one node are errors. Otherwise they resolve to the first
+// candidate, in order of their IDs, with a warning.
+func WithStrictRefs(ctx context.Context, strict bool) context.Context {
+	return context.WithValue(ctx, strictRefsKey{}, strict)
+}
+
+// IsStrictRefs is true if ambiguous references are errors in this context
+func IsStrictRefs(ctx context.Context) bool {
+	strict, _ := ctx.Value(strictRefsKey{}).(bool)
+	return strict
+}
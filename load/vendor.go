@@ -0,0 +1,173 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/asteris-llc/converge/errcode"
+	"github.com/asteris-llc/converge/fetch"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/parse"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// Vendor downloads the remote modules a root module refers to, and the
+// modules those refer to in turn, into the vendor directory next to the root
+// module, along with the remote files their file.content resources load.
+// Loading and rendering prefer the vendored copies, so the root module can be
+// planned and applied without network access afterwards. Modules are always
+// downloaded again, even if they have been vendored before. The signatures of
+// the modules are checked and vendored with them when verify is set. The
+// remote locations of the vendored modules and files are returned.
+func Vendor(ctx context.Context, root string, verify bool) ([]string, error) {
+	logger := logging.GetLogger(ctx).WithField("function", "Vendor")
+
+	dir, err := fetch.RootVendorDir(root)
+	if err != nil {
+		return nil, errors.Wrap(err, "modules can only be vendored for a root module on the local filesystem")
+	}
+
+	toLoad := []*source{{"root", root, root}}
+	seen := map[string]bool{}
+	var vendored []string
+
+	for len(toLoad) > 0 {
+		select {
+		case <-ctx.Done():
+			return vendored, errors.New("interrupted")
+		default:
+		}
+
+		current := toLoad[0]
+		toLoad = toLoad[1:]
+
+		url, err := fetch.ResolveInContext(current.Source, current.ParentSource)
+		if err != nil {
+			return vendored, errcode.Wrap(errcode.LoadFetch, err)
+		}
+		if seen[url] {
+			continue
+		}
+		seen[url] = true
+
+		content, signature, err := fetchModule(ctx, url, verify)
+		if err != nil {
+			return vendored, err
+		}
+
+		resources, err := parse.ParseFile(content, displayName(url))
+		if err != nil {
+			return vendored, errcode.Wrap(errcode.LoadParse, errors.Wrap(err, url))
+		}
+
+		if fetch.IsRemote(url) {
+			dest, err := fetch.VendorPath(dir, url)
+			if err != nil {
+				return vendored, err
+			}
+
+			logger.WithField("url", url).WithField("path", dest).Debug("vendoring")
+			if err := writeVendored(dest, content, signature); err != nil {
+				return vendored, errors.Wrapf(err, "could not vendor %s", url)
+			}
+			vendored = append(vendored, url)
+		}
+
+		for _, loc := range payloads(resources) {
+			payloadURL, err := fetch.ResolveInContext(loc, url)
+			if err != nil {
+				return vendored, errcode.Wrap(errcode.LoadFetch, err)
+			}
+			if !fetch.IsRemote(payloadURL) || seen[payloadURL] {
+				continue
+			}
+			seen[payloadURL] = true
+
+			dest, err := fetch.VendorPath(dir, payloadURL)
+			if err != nil {
+				return vendored, err
+			}
+
+			logger.WithField("url", payloadURL).WithField("path", dest).Debug("vendoring")
+			payload, err := fetch.Any(ctx, payloadURL)
+			if err != nil {
+				return vendored, errcode.Wrap(errcode.LoadFetch, errors.Wrap(err, payloadURL))
+			}
+			if err := writeVendored(dest, payload, nil); err != nil {
+				return vendored, errors.Wrapf(err, "could not vendor %s", payloadURL)
+			}
+			vendored = append(vendored, payloadURL)
+		}
+
+		for _, resource := range resources {
+			if resource.IsModule() {
+				toLoad = append(toLoad, &source{Parent: current.Parent, ParentSource: url, Source: resource.Source()})
+			}
+		}
+	}
+
+	return vendored, nil
+}
+
+// payloads finds the files that the resources of a module load from next to
+// it: the templates of file.content's `source` and the files named by its
+// `file`. Locations which are templates themselves can't be known before
+// rendering, and are left out.
+func payloads(resources []*parse.Node) []string {
+	var locs []string
+	for _, resource := range resources {
+		if resource.Kind() != "file.content" {
+			continue
+		}
+
+		if source, err := resource.GetString("source"); err == nil && isStatic(source) {
+			locs = append(locs, source)
+		}
+
+		if name, err := resource.GetString("file"); err == nil && isStatic(name) {
+			if loc, err := fetch.FilePath(name); err == nil {
+				locs = append(locs, loc)
+			}
+		}
+	}
+	return locs
+}
+
+// isStatic is true for values that are not templates
+func isStatic(val string) bool {
+	return val != "" && !strings.Contains(val, "{{")
+}
+
+// writeVendored writes a module to its path in the vendor directory, along
+// with its signature if it was checked
+func writeVendored(dest string, content, signature []byte) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(dest, content, 0644); err != nil {
+		return err
+	}
+
+	if signature == nil {
+		return nil
+	}
+	return ioutil.WriteFile(dest+".asc", signature, 0644)
+}
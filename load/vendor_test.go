@@ -0,0 +1,104 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/fetch"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/load"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// TestVendor tests vendoring remote modules and loading the vendored copies
+func TestVendor(t *testing.T) {
+	t.Parallel()
+	defer logging.HideLogs(t)()
+
+	tmp, err := ioutil.TempDir("", "converge-vendor")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	remote := filepath.Join(tmp, "remote")
+	require.NoError(t, os.MkdirAll(filepath.Join(remote, "modules"), 0755))
+	require.NoError(t, ioutil.WriteFile(
+		filepath.Join(remote, "modules", "outer.hcl"),
+		[]byte(`module "inner.hcl" "inner" {}`),
+		0644,
+	))
+	require.NoError(t, ioutil.WriteFile(
+		filepath.Join(remote, "modules", "inner.hcl"),
+		[]byte(`task "hello" { check = "true" apply = "true" }
+file.content "logo" {
+  destination = "/tmp/logo.png"
+  file        = "logo.png"
+}`),
+		0644,
+	))
+	require.NoError(t, os.MkdirAll(filepath.Join(remote, "modules", "files"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(remote, "modules", "files", "logo.png"), []byte("\x89PNG\x00"), 0644))
+
+	server := httptest.NewServer(http.FileServer(http.Dir(remote)))
+	outer := server.URL + "/modules/outer.hcl"
+
+	local := filepath.Join(tmp, "local")
+	require.NoError(t, os.MkdirAll(local, 0755))
+	root := filepath.Join(local, "main.hcl")
+	require.NoError(t, ioutil.WriteFile(root, []byte(fmt.Sprintf(
+		"module %q \"outer\" {}\nmodule %q \"again\" {}\n", outer, outer,
+	)), 0644))
+
+	vendored, err := load.Vendor(context.Background(), root, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{outer, server.URL + "/modules/inner.hcl", server.URL + "/modules/files/logo.png"}, vendored)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	_, err = os.Stat(filepath.Join(local, fetch.VendorDir, host, "modules", "inner.hcl"))
+	assert.NoError(t, err)
+
+	logo, err := ioutil.ReadFile(filepath.Join(local, fetch.VendorDir, host, "modules", "files", "logo.png"))
+	require.NoError(t, err)
+	assert.Equal(t, "\x89PNG\x00", string(logo))
+
+	// the vendored copies are loaded once the remote modules are gone
+	server.Close()
+
+	g, err := load.Nodes(context.Background(), root, false)
+	require.NoError(t, err)
+
+	for _, id := range []string{"root/module.outer/module.inner/task.hello", "root/module.again/module.inner/task.hello"} {
+		_, ok := g.Get(id)
+		assert.True(t, ok, "%q was missing from the graph", id)
+	}
+}
+
+// TestVendorRemoteRoot tests that a remote root module can't be vendored
+func TestVendorRemoteRoot(t *testing.T) {
+	t.Parallel()
+	defer logging.HideLogs(t)()
+
+	_, err := load.Vendor(context.Background(), "https://example.com/main.hcl", false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,288 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/arbovm/levenshtein"
+	"github.com/asteris-llc/converge/errcode"
+	"github.com/asteris-llc/converge/fetch"
+	"github.com/asteris-llc/converge/load"
+	"github.com/asteris-llc/converge/parse"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/hashicorp/hcl/hcl/parser"
+	"golang.org/x/net/context"
+)
+
+// diagnose checks a document for problems. Checks that don't need the module
+// loaded run on every change; loading the module from disk, which also
+// resolves dependencies, only runs when withLoad is set and nothing else was
+// found.
+func (s *Server) diagnose(ctx context.Context, doc *document, withLoad bool) []Diagnostic {
+	diags := []Diagnostic{}
+
+	if doc.parseErr != nil {
+		return append(diags, doc.parseDiagnostic())
+	}
+
+	var (
+		blocks = doc.blocks()
+		nodes  = map[*block]*parse.Node{}
+		ids    = map[string]struct{}{}
+		params = map[string]struct{}{}
+	)
+
+	for _, b := range blocks {
+		node, err := b.node()
+		if err != nil {
+			diags = append(diags, doc.errorAt(b.item.Keys[0], err.Error()))
+			continue
+		}
+		nodes[b] = node
+
+		if _, ok := ids[node.ID()]; ok && !b.nested {
+			diags = append(diags, doc.errorAt(b.item.Keys[0], fmt.Sprintf("duplicate resource %s", node.ID())))
+		}
+		ids[node.ID()] = struct{}{}
+
+		if node.Kind() == "param" {
+			params[node.Name()] = struct{}{}
+		}
+	}
+
+	for _, b := range blocks {
+		node, ok := nodes[b]
+		if !ok {
+			continue
+		}
+
+		diags = append(diags, s.checkFields(doc, b, node)...)
+		if node.IsModule() {
+			diags = append(diags, checkModuleParams(doc, b, node)...)
+		}
+		if !node.IsOverride() {
+			// overrides are rendered in the module of the resource they
+			// override, so their params and lookups aren't from this one
+			diags = append(diags, checkReferences(doc, b, ids, params)...)
+		}
+	}
+
+	if withLoad && len(diags) == 0 && doc.onDisk() {
+		if _, err := load.Load(ctx, doc.uri, false); err != nil {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Source:   source,
+				Message:  errcode.WithMessage(err).Error(),
+			})
+		}
+	}
+
+	return diags
+}
+
+// checkFields finds fields that the kind of a block doesn't accept
+func (s *Server) checkFields(doc *document, b *block, node *parse.Node) []Diagnostic {
+	kind := node.Kind()
+	switch kind {
+	case "switch":
+		return nil
+	case "override":
+		// the kind of an overridden resource is only known once its module
+		// is loaded
+		return nil
+	case "defaults":
+		kind = node.Name()
+	}
+
+	schema, ok := s.schemas[kind]
+	if !ok {
+		return []Diagnostic{doc.errorAt(b.item.Keys[0], fmt.Sprintf("%q is not a valid resource type", kind))}
+	}
+
+	fields := map[string]struct{}{}
+	for _, field := range resource.SpecialFields {
+		fields[field] = struct{}{}
+	}
+	for _, field := range schema.Fields {
+		fields[field.Name] = struct{}{}
+	}
+
+	var diags []Diagnostic
+	for _, child := range children(b.item) {
+		key := keyValue(child.Keys[0])
+		if _, ok := fields[key]; ok {
+			continue
+		}
+
+		var candidates []string
+		for candidate := range fields {
+			if levenshtein.Distance(key, candidate) <= 5 {
+				candidates = append(candidates, candidate)
+			}
+		}
+		sort.Strings(candidates)
+
+		msg := fmt.Sprintf("%s has no field named %q", kind, key)
+		if len(candidates) > 0 {
+			msg += ". Maybe you meant: " + strings.Join(candidates, ", ")
+		}
+		diags = append(diags, doc.errorAt(child.Keys[0], msg))
+	}
+
+	return diags
+}
+
+// checkModuleParams finds params without defaults that a module call doesn't
+// set. Only modules on the local filesystem are checked.
+func checkModuleParams(doc *document, b *block, node *parse.Node) []Diagnostic {
+	url, err := fetch.ResolveInContext(node.Source(), doc.uri)
+	if err != nil {
+		return []Diagnostic{doc.errorAt(b.item.Keys[1], err.Error())}
+	}
+
+	path, err := fetch.LocalPath(url)
+	if err != nil {
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return []Diagnostic{doc.errorAt(b.item.Keys[1], fmt.Sprintf("could not read module: %s", err))}
+	}
+
+	module := newDocument(url, content)
+	if module.parseErr != nil {
+		return []Diagnostic{doc.errorAt(b.item.Keys[1], fmt.Sprintf("could not parse module: %s", module.parseErr))}
+	}
+
+	passed := map[string]struct{}{}
+	if raw, err := node.Get("params"); err == nil {
+		for _, name := range mapKeys(raw) {
+			passed[name] = struct{}{}
+		}
+	}
+
+	var diags []Diagnostic
+	for _, inner := range module.blocks() {
+		param, err := inner.node()
+		if err != nil || param.Kind() != "param" {
+			continue
+		}
+
+		if _, err := param.Get("default"); err != parse.ErrNotFound {
+			continue
+		}
+
+		if _, ok := passed[param.Name()]; !ok {
+			diags = append(diags, doc.errorAt(b.item.Keys[0], fmt.Sprintf("module %q is missing param %q", node.Name(), param.Name())))
+		}
+	}
+
+	return diags
+}
+
+// checkReferences finds param and lookup calls that don't refer to anything
+// in the module
+func checkReferences(doc *document, b *block, ids, params map[string]struct{}) []Diagnostic {
+	var diags []Diagnostic
+
+	for _, ref := range b.references() {
+		var msg string
+		switch ref.function {
+		case "param":
+			if _, ok := params[ref.name]; !ok {
+				msg = fmt.Sprintf("unknown param %q", ref.name)
+			}
+		case "lookup":
+			if _, ok := resolveLookup(ids, ref.name); !ok {
+				msg = fmt.Sprintf("lookup %q does not resolve to a node in this module", ref.name)
+			}
+		}
+
+		if msg != "" {
+			diags = append(diags, Diagnostic{
+				Range:    doc.span(ref.start, ref.end),
+				Severity: SeverityError,
+				Source:   source,
+				Message:  msg,
+			})
+		}
+	}
+
+	return diags
+}
+
+// resolveLookup finds the node ID a lookup refers to. Lookups name a node
+// followed by the path to a field, so the longest matching ID wins.
+func resolveLookup(ids map[string]struct{}, name string) (string, bool) {
+	var found string
+	for id := range ids {
+		if (name == id || strings.HasPrefix(name, id+".")) && len(id) > len(found) {
+			found = id
+		}
+	}
+	return found, found != ""
+}
+
+// mapKeys returns the keys of a decoded HCL object, which may be a map or a
+// list of maps
+func mapKeys(raw interface{}) []string {
+	var keys []string
+	switch val := raw.(type) {
+	case map[string]interface{}:
+		for key := range val {
+			keys = append(keys, key)
+		}
+	case []map[string]interface{}:
+		for _, m := range val {
+			keys = append(keys, mapKeys(m)...)
+		}
+	}
+	return keys
+}
+
+// onDisk tests whether the document has been saved to the local filesystem,
+// which loading requires
+func (d *document) onDisk() bool {
+	path, err := fetch.LocalPath(d.uri)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// parseDiagnostic reports the error from parsing the document, at its position
+// if known
+func (d *document) parseDiagnostic() Diagnostic {
+	diag := Diagnostic{
+		Severity: SeverityError,
+		Source:   source,
+		Message:  d.parseErr.Error(),
+	}
+
+	if posErr, ok := d.parseErr.(*parser.PosError); ok {
+		diag.Message = posErr.Err.Error()
+		pos := Position{Line: posErr.Pos.Line - 1, Character: posErr.Pos.Column - 1}
+		diag.Range = Range{Start: pos, End: pos}
+	}
+
+	return diag
+}
@@ -0,0 +1,214 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/asteris-llc/converge/parse"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/token"
+)
+
+// source identifies this server as the origin of diagnostics
+const source = "converge"
+
+// referencePattern matches param and lookup calls in templates, capturing the
+// function and its argument
+var referencePattern = regexp.MustCompile("\\b(param|lookup)\\s+`([^`]*)`")
+
+// document is an open module file
+type document struct {
+	uri  string
+	text []byte
+
+	file     *ast.File
+	parseErr error
+}
+
+// block is a node declared in a document, either at the top level or in a
+// branch of a switch
+type block struct {
+	item   *ast.ObjectItem
+	nested bool
+}
+
+// reference is a param or lookup call found in a template
+type reference struct {
+	function string
+	name     string
+	start    int
+	end      int
+}
+
+func newDocument(uri string, text []byte) *document {
+	doc := &document{uri: uri, text: text}
+	doc.file, doc.parseErr = hcl.ParseBytes(text)
+	return doc
+}
+
+// blocks lists the nodes declared in the document. Switches are included along
+// with the nodes in their branches.
+func (d *document) blocks() []*block {
+	if d.file == nil {
+		return nil
+	}
+
+	list, ok := d.file.Node.(*ast.ObjectList)
+	if !ok {
+		return nil
+	}
+
+	var out []*block
+	for _, item := range list.Items {
+		out = append(out, &block{item: item})
+
+		if len(item.Keys) == 0 || keyValue(item.Keys[0]) != "switch" {
+			continue
+		}
+		for _, branch := range children(item) {
+			for _, inner := range children(branch) {
+				out = append(out, &block{item: inner, nested: true})
+			}
+		}
+	}
+
+	return out
+}
+
+// node returns the block as a parse.Node, or an error if it isn't well formed
+func (b *block) node() (*parse.Node, error) {
+	node := parse.NewNode(b.item)
+	if err := node.Validate(); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// references finds the param and lookup calls in the string values of a block
+func (b *block) references() []*reference {
+	var refs []*reference
+
+	ast.Walk(b.item.Val, func(n ast.Node) (ast.Node, bool) {
+		lit, ok := n.(*ast.LiteralType)
+		if !ok || (lit.Token.Type != token.STRING && lit.Token.Type != token.HEREDOC) {
+			return n, true
+		}
+
+		text := lit.Token.Text
+		for _, match := range referencePattern.FindAllStringSubmatchIndex(text, -1) {
+			refs = append(refs, &reference{
+				function: text[match[2]:match[3]],
+				name:     text[match[4]:match[5]],
+				start:    lit.Token.Pos.Offset + match[0],
+				end:      lit.Token.Pos.Offset + match[1],
+			})
+		}
+		return n, true
+	})
+
+	return refs
+}
+
+// children lists the items in the body of an item
+func children(item *ast.ObjectItem) []*ast.ObjectItem {
+	obj, ok := item.Val.(*ast.ObjectType)
+	if !ok || obj.List == nil {
+		return nil
+	}
+	return obj.List.Items
+}
+
+// keyValue returns the unquoted text of a key
+func keyValue(key *ast.ObjectKey) string {
+	if val, ok := key.Token.Value().(string); ok {
+		return val
+	}
+	return key.Token.Text
+}
+
+// position converts a byte offset to a position in the document
+func (d *document) position(offset int) Position {
+	var pos Position
+	if offset > len(d.text) {
+		offset = len(d.text)
+	}
+
+	for i := 0; i < offset; {
+		r, size := utf8.DecodeRune(d.text[i:])
+		if r == '\n' {
+			pos.Line++
+			pos.Character = 0
+		} else {
+			pos.Character++
+		}
+		i += size
+	}
+
+	return pos
+}
+
+// offset converts a position in the document to a byte offset
+func (d *document) offset(pos Position) int {
+	var line, char int
+	for i := 0; i < len(d.text); {
+		if line == pos.Line && char == pos.Character {
+			return i
+		}
+
+		r, size := utf8.DecodeRune(d.text[i:])
+		if r == '\n' {
+			if line == pos.Line {
+				return i
+			}
+			line++
+			char = 0
+		} else {
+			char++
+		}
+		i += size
+	}
+
+	return len(d.text)
+}
+
+// errorAt creates an error diagnostic covering a key
+func (d *document) errorAt(key *ast.ObjectKey, msg string) Diagnostic {
+	return Diagnostic{
+		Range:    d.keyRange(key),
+		Severity: SeverityError,
+		Source:   source,
+		Message:  msg,
+	}
+}
+
+// span returns the range between two byte offsets
+func (d *document) span(start, end int) Range {
+	return Range{Start: d.position(start), End: d.position(end)}
+}
+
+// keyRange returns the range covered by a key
+func (d *document) keyRange(key *ast.ObjectKey) Range {
+	start := key.Token.Pos.Offset
+	return d.span(start, start+len(key.Token.Text))
+}
+
+// containsKey tests whether a byte offset falls within a key
+func containsKey(key *ast.ObjectKey, offset int) bool {
+	start := key.Token.Pos.Offset
+	return offset >= start && offset <= start+len(key.Token.Text)
+}
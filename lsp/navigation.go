@@ -0,0 +1,183 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/fetch"
+	"github.com/asteris-llc/converge/rpc/pb"
+)
+
+// hover describes the resource type or field under the cursor, using the
+// schemas from the registry
+func (s *Server) hover(doc *document, pos Position) *Hover {
+	offset := doc.offset(pos)
+
+	for _, b := range doc.blocks() {
+		kind := keyValue(b.item.Keys[0])
+		if kind == "defaults" && len(b.item.Keys) > 1 {
+			kind = keyValue(b.item.Keys[1])
+		}
+
+		schema, ok := s.schemas[kind]
+		if !ok {
+			continue
+		}
+
+		if containsKey(b.item.Keys[0], offset) {
+			rng := doc.keyRange(b.item.Keys[0])
+			return &Hover{Contents: markdown(describeKind(schema)), Range: &rng}
+		}
+
+		for _, child := range children(b.item) {
+			if !containsKey(child.Keys[0], offset) {
+				continue
+			}
+
+			name := keyValue(child.Keys[0])
+			for _, field := range schema.Fields {
+				if field.Name == name {
+					rng := doc.keyRange(child.Keys[0])
+					return &Hover{Contents: markdown(describeField(kind, field)), Range: &rng}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// definition finds where the module source or param or lookup reference under
+// the cursor is declared
+func (s *Server) definition(doc *document, pos Position) *Location {
+	offset := doc.offset(pos)
+	blocks := doc.blocks()
+
+	ids := map[string]struct{}{}
+	byID := map[string]*block{}
+	for _, b := range blocks {
+		node, err := b.node()
+		if err != nil {
+			continue
+		}
+		ids[node.ID()] = struct{}{}
+		if _, ok := byID[node.ID()]; !ok {
+			byID[node.ID()] = b
+		}
+
+		if node.IsModule() && containsKey(b.item.Keys[1], offset) {
+			url, err := fetch.ResolveInContext(node.Source(), doc.uri)
+			if err != nil {
+				return nil
+			}
+			if _, err := fetch.LocalPath(url); err != nil {
+				return nil
+			}
+			return &Location{URI: url}
+		}
+	}
+
+	for _, b := range blocks {
+		for _, ref := range b.references() {
+			if offset < ref.start || offset > ref.end {
+				continue
+			}
+
+			id := "param." + ref.name
+			if ref.function == "lookup" {
+				var ok bool
+				if id, ok = resolveLookup(ids, ref.name); !ok {
+					return nil
+				}
+			}
+
+			target, ok := byID[id]
+			if !ok {
+				return nil
+			}
+			return &Location{URI: doc.uri, Range: doc.keyRange(target.item.Keys[0])}
+		}
+	}
+
+	return nil
+}
+
+// describeKind documents a resource type and its fields
+func describeKind(schema *pb.ResourceSchema) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "**%s**\n", schema.Kind)
+
+	if len(schema.Fields) > 0 {
+		buf.WriteString("\nFields:\n\n")
+		for _, field := range schema.Fields {
+			fmt.Fprintf(&buf, "- `%s` *%s*", field.Name, field.Type)
+			if attrs := fieldAttributes(field); attrs != "" {
+				fmt.Fprintf(&buf, ": %s", attrs)
+			}
+			buf.WriteString("\n")
+		}
+	}
+
+	if len(schema.Exports) > 0 {
+		buf.WriteString("\nExports:\n\n")
+		for _, field := range schema.Exports {
+			fmt.Fprintf(&buf, "- `%s` *%s*\n", field.Name, field.Type)
+		}
+	}
+
+	return buf.String()
+}
+
+// describeField documents a single field of a resource type
+func describeField(kind string, field *pb.ResourceSchema_Field) string {
+	desc := fmt.Sprintf("`%s` *%s* on **%s**\n", field.Name, field.Type, kind)
+	if attrs := fieldAttributes(field); attrs != "" {
+		desc += "\n" + attrs + "\n"
+	}
+	return desc
+}
+
+// fieldAttributes summarizes the validations on a field
+func fieldAttributes(field *pb.ResourceSchema_Field) string {
+	var attrs []string
+	if field.Required {
+		attrs = append(attrs, "required")
+	}
+	if field.Nonempty {
+		attrs = append(attrs, "nonempty")
+	}
+	if len(field.ValidValues) > 0 {
+		attrs = append(attrs, "one of "+strings.Join(field.ValidValues, ", "))
+	}
+
+	var exclusive []string
+	for _, other := range field.MutuallyExclusive {
+		if other != field.Name {
+			exclusive = append(exclusive, other)
+		}
+	}
+	if len(exclusive) > 0 {
+		attrs = append(attrs, "mutually exclusive with "+strings.Join(exclusive, ", "))
+	}
+
+	return strings.Join(attrs, "; ")
+}
+
+func markdown(value string) MarkupContent {
+	return MarkupContent{Kind: "markdown", Value: value}
+}
@@ -0,0 +1,254 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lsp implements a Language Server Protocol server for authoring
+// modules. It reports diagnostics found by parsing and loading modules, and
+// offers hover documentation and go-to-definition using the resource registry.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/rpc"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// Server answers LSP requests from a single client
+type Server struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	schemas map[string]*pb.ResourceSchema
+	docs    map[string]*document
+
+	shutdown bool
+}
+
+// New creates a Server that reads requests from in and writes responses to out
+func New(in io.Reader, out io.Writer) (*Server, error) {
+	resp, err := rpc.ResourceSchemas()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get resource schemas")
+	}
+
+	schemas := map[string]*pb.ResourceSchema{}
+	for _, schema := range resp.Resources {
+		schemas[schema.Kind] = schema
+	}
+
+	return &Server{
+		in:      bufio.NewReader(in),
+		out:     out,
+		schemas: schemas,
+		docs:    map[string]*document{},
+	}, nil
+}
+
+// Serve handles requests until the client exits, the input is closed, or the
+// context is canceled
+func (s *Server) Serve(ctx context.Context) error {
+	logger := logging.GetLogger(ctx).WithField("component", "lsp")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		body, err := readMessage(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "could not read message")
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			logger.WithError(err).Warn("could not decode message")
+			if err := s.reply(nil, nil, &responseError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit requested before shutdown")
+			}
+			return nil
+		}
+
+		logger.WithField("method", req.Method).Debug("handling message")
+		result, err := s.handle(ctx, &req)
+		if req.isNotification() {
+			if err != nil {
+				logger.WithError(err).WithField("method", req.Method).Warn("could not handle notification")
+			}
+			continue
+		}
+
+		var rerr *responseError
+		if err != nil {
+			var ok bool
+			if rerr, ok = err.(*responseError); !ok {
+				rerr = &responseError{Code: codeInternalError, Message: err.Error()}
+			}
+		}
+		if err := s.reply(req.ID, result, rerr); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches a single request or notification
+func (s *Server) handle(ctx context.Context, req *request) (interface{}, error) {
+	switch req.Method {
+	case "initialize":
+		return &InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:   textDocumentSyncFull,
+				HoverProvider:      true,
+				DefinitionProvider: true,
+			},
+		}, nil
+
+	case "initialized":
+		return nil, nil
+
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		doc := newDocument(params.TextDocument.URI, []byte(params.TextDocument.Text))
+		s.docs[doc.uri] = doc
+		return nil, s.publish(ctx, doc, true)
+
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+		doc := newDocument(params.TextDocument.URI, []byte(text))
+		s.docs[doc.uri] = doc
+		return nil, s.publish(ctx, doc, false)
+
+	case "textDocument/didSave":
+		var params DidSaveTextDocumentParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		return nil, s.publish(ctx, doc, true)
+
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return nil, s.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		if hover := s.hover(doc, params.Position); hover != nil {
+			return hover, nil
+		}
+		return nil, nil
+
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		if loc := s.definition(doc, params.Position); loc != nil {
+			return loc, nil
+		}
+		return nil, nil
+	}
+
+	if req.isNotification() {
+		return nil, nil
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+}
+
+// publish sends the diagnostics for a document to the client
+func (s *Server) publish(ctx context.Context, doc *document, withLoad bool) error {
+	return s.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+		URI:         doc.uri,
+		Diagnostics: s.diagnose(ctx, doc, withLoad),
+	})
+}
+
+func (s *Server) reply(id *json.RawMessage, result interface{}, rerr *responseError) error {
+	resp := &response{JSONRPC: "2.0", ID: id, Error: rerr}
+
+	if rerr == nil {
+		raw, err := json.Marshal(result)
+		if err != nil {
+			return errors.Wrap(err, "could not encode result")
+		}
+		msg := json.RawMessage(raw)
+		resp.Result = &msg
+	}
+
+	return writeMessage(s.out, resp)
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return writeMessage(s.out, &notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func decodeParams(req *request, dest interface{}) error {
+	if req.Params == nil {
+		return &responseError{Code: codeInvalidParams, Message: "missing params"}
+	}
+	if err := json.Unmarshal(*req.Params, dest); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,357 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// TestServer tests a full session with the server
+func TestServer(t *testing.T) {
+	t.Run("initialize", func(t *testing.T) {
+		out, err := session(t, call(1, "initialize", map[string]interface{}{}))
+		require.NoError(t, err)
+		require.Len(t, out, 2)
+
+		var result InitializeResult
+		require.NoError(t, json.Unmarshal(out[0].Result, &result))
+		assert.True(t, result.Capabilities.HoverProvider)
+		assert.True(t, result.Capabilities.DefinitionProvider)
+		assert.Equal(t, textDocumentSyncFull, result.Capabilities.TextDocumentSync)
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		out, err := session(t, call(1, "textDocument/rename", map[string]interface{}{}))
+		require.NoError(t, err)
+		require.NotNil(t, out[0].Error)
+		assert.Equal(t, codeMethodNotFound, out[0].Error.Code)
+	})
+
+	t.Run("exit without shutdown", func(t *testing.T) {
+		_, err := runSession(t, notify("exit", nil))
+		assert.Error(t, err)
+	})
+}
+
+// TestServerDiagnostics tests diagnostics published for open documents
+func TestServerDiagnostics(t *testing.T) {
+	t.Run("fields and references", func(t *testing.T) {
+		diags := openDiagnostics(t, "file:///nonexistent/main.hcl", `param "message" {}
+
+task "hello" {
+  check = "echo {{param `+"`messages`"+`}}"
+  aply  = "echo {{lookup `+"`task.missing.status`"+`}}"
+}
+`)
+
+		require.Len(t, diags, 3)
+		assert.Contains(t, messages(diags), `task has no field named "aply". Maybe you meant: apply, check, dir, env, group, impact, stdin, umask`)
+		assert.Contains(t, messages(diags), `unknown param "messages"`)
+		assert.Contains(t, messages(diags), `lookup "task.missing.status" does not resolve to a node in this module`)
+
+		for _, diag := range diags {
+			if diag.Message == `unknown param "messages"` {
+				assert.Equal(t, Range{Start: Position{3, 18}, End: Position{3, 34}}, diag.Range)
+			}
+		}
+	})
+
+	t.Run("unknown resource type", func(t *testing.T) {
+		diags := openDiagnostics(t, "file:///nonexistent/main.hcl", `tsak "hello" {}`)
+
+		require.Len(t, diags, 1)
+		assert.Equal(t, `"tsak" is not a valid resource type`, diags[0].Message)
+		assert.Equal(t, Range{Start: Position{0, 0}, End: Position{0, 4}}, diags[0].Range)
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		diags := openDiagnostics(t, "file:///nonexistent/main.hcl", "task \"hello\" {\n  check = \n}\n")
+
+		require.Len(t, diags, 1)
+		assert.Equal(t, "object expected closing RBRACE got: EOF", diags[0].Message)
+		assert.Equal(t, Position{3, 0}, diags[0].Range.Start)
+	})
+
+	t.Run("lookups into switch branches", func(t *testing.T) {
+		diags := openDiagnostics(t, "file:///nonexistent/main.hcl", `switch "test" {
+  case "true" "a" {
+    task.query "inner" {
+      query = "echo hi"
+    }
+  }
+}
+
+task.query "outer" {
+  query = "echo {{lookup `+"`task.query.inner.status.stdout`"+`}}"
+}
+`)
+
+		assert.Empty(t, diags)
+	})
+
+	t.Run("missing module params", func(t *testing.T) {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		writeFile(t, dir, "mod.hcl", "param \"required\" {}\n\nparam \"optional\" {\n  default = \"x\"\n}\n")
+
+		diags := openDiagnostics(t, "file://"+filepath.Join(dir, "main.hcl"), `module "mod.hcl" "mod" {}`)
+
+		require.Len(t, diags, 1)
+		assert.Equal(t, `module "mod" is missing param "required"`, diags[0].Message)
+	})
+
+	t.Run("load errors", func(t *testing.T) {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		content := `task "a" {
+  check   = "true"
+  apply   = "true"
+  depends = ["task.b"]
+}
+
+task "b" {
+  check   = "true"
+  apply   = "true"
+  depends = ["task.a"]
+}
+`
+		writeFile(t, dir, "main.hcl", content)
+
+		diags := openDiagnostics(t, "file://"+filepath.Join(dir, "main.hcl"), content)
+
+		require.Len(t, diags, 1)
+		assert.Contains(t, diags[0].Message, "Cycle")
+	})
+
+	t.Run("valid module", func(t *testing.T) {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		content := "task \"a\" {\n  check = \"true\"\n  apply = \"true\"\n}\n"
+		writeFile(t, dir, "main.hcl", content)
+
+		diags := openDiagnostics(t, "file://"+filepath.Join(dir, "main.hcl"), content)
+
+		assert.Empty(t, diags)
+	})
+}
+
+// TestServerHover tests hover documentation
+func TestServerHover(t *testing.T) {
+	content := "task \"hello\" {\n  check = \"true\"\n}\n"
+
+	t.Run("kind", func(t *testing.T) {
+		hover := hoverAt(t, content, Position{0, 1})
+		require.NotNil(t, hover)
+		assert.Contains(t, hover.Contents.Value, "**task**")
+		assert.Contains(t, hover.Contents.Value, "- `check` *string*: nonempty; mutually exclusive with check_script")
+		assert.Equal(t, &Range{Start: Position{0, 0}, End: Position{0, 4}}, hover.Range)
+	})
+
+	t.Run("field", func(t *testing.T) {
+		hover := hoverAt(t, content, Position{1, 3})
+		require.NotNil(t, hover)
+		assert.Equal(t, "`check` *string* on **task**\n\nnonempty; mutually exclusive with check_script\n", hover.Contents.Value)
+	})
+
+	t.Run("nothing", func(t *testing.T) {
+		assert.Nil(t, hoverAt(t, content, Position{1, 12}))
+	})
+}
+
+// TestServerDefinition tests go-to-definition
+func TestServerDefinition(t *testing.T) {
+	uri := "file:///modules/main.hcl"
+	content := `param "message" {}
+
+module "other.hcl" "other" {}
+
+task.query "a" {
+  query = "echo {{param ` + "`message`" + `}}"
+}
+
+task.query "b" {
+  query = "echo {{lookup ` + "`task.query.a.status.stdout`" + `}}"
+}
+`
+
+	t.Run("param", func(t *testing.T) {
+		loc := definitionAt(t, uri, content, Position{5, 20})
+		require.NotNil(t, loc)
+		assert.Equal(t, &Location{URI: uri, Range: Range{Start: Position{0, 0}, End: Position{0, 5}}}, loc)
+	})
+
+	t.Run("lookup", func(t *testing.T) {
+		loc := definitionAt(t, uri, content, Position{9, 20})
+		require.NotNil(t, loc)
+		assert.Equal(t, &Location{URI: uri, Range: Range{Start: Position{4, 0}, End: Position{4, 10}}}, loc)
+	})
+
+	t.Run("module", func(t *testing.T) {
+		loc := definitionAt(t, uri, content, Position{2, 10})
+		require.NotNil(t, loc)
+		assert.Equal(t, &Location{URI: "file:///modules/other.hcl"}, loc)
+	})
+
+	t.Run("nothing", func(t *testing.T) {
+		assert.Nil(t, definitionAt(t, uri, content, Position{0, 0}))
+	})
+}
+
+// message is a decoded message from the server
+type message struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+func call(id int, method string, params interface{}) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params}
+}
+
+func notify(method string, params interface{}) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+}
+
+// TestReadMessage tests reading messages framed with a Content-Length header
+func TestReadMessage(t *testing.T) {
+	read := func(framed string) ([]byte, error) {
+		return readMessage(bufio.NewReader(bytes.NewBufferString(framed)))
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		body, err := read("Content-Length: 2\r\n\r\n{}")
+		require.NoError(t, err)
+		assert.Equal(t, "{}", string(body))
+	})
+
+	t.Run("missing length", func(t *testing.T) {
+		_, err := read("Content-Type: application/json\r\n\r\n{}")
+		assert.EqualError(t, err, "missing Content-Length header")
+	})
+
+	t.Run("negative length", func(t *testing.T) {
+		_, err := read("Content-Length: -1\r\n\r\n{}")
+		assert.EqualError(t, err, fmt.Sprintf("invalid Content-Length -1, must be at most %d", maxMessageLength))
+	})
+
+	t.Run("length too large", func(t *testing.T) {
+		_, err := read(fmt.Sprintf("Content-Length: %d\r\n\r\n{}", maxMessageLength+1))
+		assert.EqualError(t, err, fmt.Sprintf("invalid Content-Length %d, must be at most %d", maxMessageLength+1, maxMessageLength))
+	})
+}
+
+// session runs the given messages followed by a clean shutdown
+func session(t *testing.T, msgs ...map[string]interface{}) ([]*message, error) {
+	msgs = append(msgs, call(1000, "shutdown", nil), notify("exit", nil))
+	return runSession(t, msgs...)
+}
+
+func runSession(t *testing.T, msgs ...map[string]interface{}) ([]*message, error) {
+	var in, out bytes.Buffer
+	for _, msg := range msgs {
+		require.NoError(t, writeMessage(&in, msg))
+	}
+
+	server, err := New(&in, &out)
+	require.NoError(t, err)
+
+	serveErr := server.Serve(context.Background())
+
+	var replies []*message
+	reader := bufio.NewReader(&out)
+	for reader.Buffered() > 0 || out.Len() > 0 {
+		body, err := readMessage(reader)
+		require.NoError(t, err)
+
+		var reply message
+		require.NoError(t, json.Unmarshal(body, &reply))
+		replies = append(replies, &reply)
+	}
+
+	return replies, serveErr
+}
+
+func open(uri, content string) map[string]interface{} {
+	return notify("textDocument/didOpen", &DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "hcl", Version: 1, Text: content},
+	})
+}
+
+func openDiagnostics(t *testing.T, uri, content string) []Diagnostic {
+	out, err := session(t, open(uri, content))
+	require.NoError(t, err)
+	require.Equal(t, "textDocument/publishDiagnostics", out[0].Method)
+
+	var params PublishDiagnosticsParams
+	require.NoError(t, json.Unmarshal(out[0].Params, &params))
+	assert.Equal(t, uri, params.URI)
+	return params.Diagnostics
+}
+
+func hoverAt(t *testing.T, content string, pos Position) *Hover {
+	uri := "file:///nonexistent/main.hcl"
+	out, err := session(t, open(uri, content), call(1, "textDocument/hover", &TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     pos,
+	}))
+	require.NoError(t, err)
+
+	var hover *Hover
+	require.NoError(t, json.Unmarshal(out[1].Result, &hover))
+	return hover
+}
+
+func definitionAt(t *testing.T, uri, content string, pos Position) *Location {
+	out, err := session(t, open(uri, content), call(1, "textDocument/definition", &TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     pos,
+	}))
+	require.NoError(t, err)
+
+	var loc *Location
+	require.NoError(t, json.Unmarshal(out[1].Result, &loc))
+	return loc
+}
+
+func messages(diags []Diagnostic) []string {
+	out := make([]string, len(diags))
+	for i, diag := range diags {
+		out[i] = diag.Message
+	}
+	return out
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "converge-lsp")
+	require.NoError(t, err)
+	return dir
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644), fmt.Sprintf("writing %s", name))
+}
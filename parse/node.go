@@ -18,12 +18,15 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"sync"
 	"unicode"
+	"unicode/utf8"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/token"
 )
 
 // ErrNotFound is returned from Get and friends when the key does not exist
@@ -82,7 +85,14 @@ func (n *Node) Validate() error {
 
 		return fmt.Errorf("%s: too many keys", n.Pos())
 	}
-	if err := validateName(n.Name()); err != nil {
+	if n.IsOverride() {
+		// overrides are named by the path to a resource in a child module
+		for _, part := range strings.Split(n.Name(), "/") {
+			if err := validateName(part); err != nil {
+				return fmt.Errorf("%s: %s", n.Pos(), err)
+			}
+		}
+	} else if err := validateName(n.Name()); err != nil {
 		return fmt.Errorf("%s: %s", n.Pos(), err)
 	}
 	return n.setValues()
@@ -139,6 +149,102 @@ func (n *Node) IsDefault() bool {
 	return n.Kind() == "default"
 }
 
+// IsDefaults tests whether this node sets default values for the resources in
+// its module
+func (n *Node) IsDefaults() bool {
+	return n.Kind() == "defaults"
+}
+
+// MergeDefaults adds the values of a defaults block which aren't already set on
+// this node. Keys that accept returns false for are skipped.
+func (n *Node) MergeDefaults(defaults *Node, accept func(key string) bool) error {
+	obj, ok := n.Val.(*ast.ObjectType)
+	if !ok {
+		return fmt.Errorf("%s: cannot set defaults on %s", n.Pos(), n)
+	}
+
+	defaultsObj, ok := defaults.Val.(*ast.ObjectType)
+	if !ok {
+		return fmt.Errorf("%s: defaults must be a block", defaults.Pos())
+	}
+
+	set := map[string]bool{}
+	for _, item := range obj.List.Items {
+		set[itemKey(item)] = true
+	}
+
+	var merged bool
+	for _, item := range defaultsObj.List.Items {
+		key := itemKey(item)
+		if set[key] || !accept(key) {
+			continue
+		}
+
+		obj.List.Add(item)
+		set[key] = true
+		merged = true
+	}
+
+	if !merged {
+		return nil
+	}
+
+	// decode the values again to pick up the merged items
+	n.once = sync.Once{}
+	return n.setValues()
+}
+
+// IsOverride tests whether this node replaces field values of a resource in a
+// child module
+func (n *Node) IsOverride() bool {
+	return n.Kind() == "override"
+}
+
+// MergeOverride replaces the values on this node with the ones set in an
+// override block. Before a field is set, the fields that conflicts returns for
+// it are removed from the node.
+func (n *Node) MergeOverride(override *Node, conflicts func(key string) []string) error {
+	obj, ok := n.Val.(*ast.ObjectType)
+	if !ok {
+		return fmt.Errorf("%s: cannot override %s", n.Pos(), n)
+	}
+
+	overrideObj, ok := override.Val.(*ast.ObjectType)
+	if !ok {
+		return fmt.Errorf("%s: override must be a block", override.Pos())
+	}
+
+	for _, item := range overrideObj.List.Items {
+		remove := map[string]bool{itemKey(item): true}
+		for _, key := range conflicts(itemKey(item)) {
+			remove[key] = true
+		}
+
+		kept := obj.List.Items[:0]
+		for _, existing := range obj.List.Items {
+			if !remove[itemKey(existing)] {
+				kept = append(kept, existing)
+			}
+		}
+		obj.List.Items = kept
+
+		obj.List.Add(item)
+	}
+
+	// decode the values again to pick up the replaced items
+	n.once = sync.Once{}
+	return n.setValues()
+}
+
+// itemKey returns the name of the field an item sets
+func itemKey(item *ast.ObjectItem) string {
+	if len(item.Keys) == 0 {
+		return ""
+	}
+	key, _ := item.Keys[0].Token.Value().(string)
+	return key
+}
+
 // Source returns where a module call is to be loaded from
 func (n *Node) Source() string {
 	if n.IsModule() {
@@ -147,15 +253,132 @@ func (n *Node) Source() string {
 	return ""
 }
 
-// Group returns the group that the node is a member of
+// FieldPosition returns the position of the value of a field, or the position
+// of the node if the field isn't set
+func (n *Node) FieldPosition(key string) token.Pos {
+	if obj, ok := n.Val.(*ast.ObjectType); ok {
+		for _, item := range obj.List.Items {
+			if itemKey(item) == key {
+				return item.Val.Pos()
+			}
+		}
+	}
+	return n.Pos()
+}
+
+// FieldPositions returns the positions of the values of every field set on the
+// node, keyed by field name
+func (n *Node) FieldPositions() map[string]token.Pos {
+	positions := map[string]token.Pos{}
+	if obj, ok := n.Val.(*ast.ObjectType); ok {
+		for _, item := range obj.List.Items {
+			if key := itemKey(item); key != "" {
+				if _, ok := positions[key]; !ok {
+					positions[key] = item.Val.Pos()
+				}
+			}
+		}
+	}
+	return positions
+}
+
+// Locate returns the position of the first occurrence of text in the string
+// values of the node, such as a call in a template, or the position of the node
+// if the text doesn't occur
+func (n *Node) Locate(text string) token.Pos {
+	found := n.Pos()
+	done := false
+
+	ast.Walk(n.Val, func(node ast.Node) (ast.Node, bool) {
+		if done {
+			return node, false
+		}
+
+		lit, ok := node.(*ast.LiteralType)
+		if !ok || (lit.Token.Type != token.STRING && lit.Token.Type != token.HEREDOC) {
+			return node, true
+		}
+
+		idx := strings.Index(lit.Token.Text, text)
+		if idx < 0 {
+			return node, true
+		}
+
+		found = advance(lit.Token.Pos, lit.Token.Text[:idx])
+		done = true
+		return node, false
+	})
+
+	return found
+}
+
+// advance moves a position past some text
+func advance(pos token.Pos, text string) token.Pos {
+	for _, r := range text {
+		pos.Offset += utf8.RuneLen(r)
+		if r == '\n' {
+			pos.Line++
+			pos.Column = 1
+		} else {
+			pos.Column++
+		}
+	}
+	return pos
+}
+
+// Group returns the group that the node is a member of. Members of a
+// transaction are grouped by the transaction's name.
 func (n *Node) Group() string {
 	group, err := n.GetString("group")
 	if err != nil {
-		return ""
+		transaction, _ := n.Transaction()
+		return transaction
 	}
 	return group
 }
 
+// Transaction returns the name of the transaction the node is a member of, or
+// an empty string if it isn't in one
+func (n *Node) Transaction() (string, error) {
+	transaction, err := n.GetString("transaction")
+	if err == ErrNotFound {
+		return "", nil
+	}
+	return transaction, err
+}
+
+// AllowDestructive returns whether the node's destructive changes should be
+// applied without being explicitly allowed for the whole run
+func (n *Node) AllowDestructive() bool {
+	raw, err := n.Get("allow_destructive")
+	if err != nil {
+		return false
+	}
+	allow, ok := raw.(bool)
+	return ok && allow
+}
+
+// ChangeWindow returns the window the node's changes are limited to, as an
+// expression or the name of a configured window. An empty result means the
+// node may be applied at any time.
+func (n *Node) ChangeWindow() (string, error) {
+	window, err := n.GetString("change_window")
+	if err == ErrNotFound {
+		return "", nil
+	}
+	return window, err
+}
+
+// Platforms returns the platforms the node is limited to, as "os" or
+// "os/arch". An empty result means the node runs everywhere.
+func (n *Node) Platforms() ([]string, error) {
+	platforms, err := n.GetStringSlice("platforms")
+	if err == ErrNotFound {
+		return nil, nil
+	}
+	return platforms, err
+}
+
 func (n *Node) setValues() (err error) {
 	n.once.Do(func() {
 		n.values = map[string]interface{}{}
@@ -220,6 +220,111 @@ func TestNodeDefault(t *testing.T) {
 	})
 }
 
+// TestNodeMergeDefaults tests merging defaults blocks into nodes
+func TestNodeMergeDefaults(t *testing.T) {
+	t.Parallel()
+
+	all := func(string) bool { return true }
+
+	t.Run("keeps values set on the node", func(t *testing.T) {
+		node, err := fromString(`task "x" { timeout = "5m" }`)
+		require.NoError(t, err)
+		defaults, err := fromString(`defaults "task" { timeout = "60s" interpreter = "/bin/bash" }`)
+		require.NoError(t, err)
+
+		require.NoError(t, node.MergeDefaults(defaults, all))
+
+		timeout, err := node.GetString("timeout")
+		require.NoError(t, err)
+		assert.Equal(t, "5m", timeout)
+
+		interpreter, err := node.GetString("interpreter")
+		require.NoError(t, err)
+		assert.Equal(t, "/bin/bash", interpreter)
+	})
+
+	t.Run("skips keys that are not accepted", func(t *testing.T) {
+		node, err := fromString(`task "x" {}`)
+		require.NoError(t, err)
+		defaults, err := fromString(`defaults "task" { timeout = "60s" bogus = "x" }`)
+		require.NoError(t, err)
+
+		require.NoError(t, node.MergeDefaults(defaults, func(key string) bool { return key != "bogus" }))
+
+		_, err = node.Get("timeout")
+		assert.NoError(t, err)
+		_, err = node.Get("bogus")
+		assert.Error(t, err)
+	})
+}
+
+// TestNodeOverride tests validating override blocks
+func TestNodeOverride(t *testing.T) {
+	t.Parallel()
+
+	t.Run("named by path", func(t *testing.T) {
+		node, err := fromString(`override "module.base/task.x" {}`)
+		require.NoError(t, err)
+		assert.NoError(t, node.Validate())
+		assert.True(t, node.IsOverride())
+		assert.Equal(t, "module.base/task.x", node.Name())
+	})
+
+	t.Run("invalid part", func(t *testing.T) {
+		validateTable(t, `override "module.base/task x" {}`, "1:1: resource name may not contain spaces")
+	})
+}
+
+// TestNodeMergeOverride tests merging override blocks into nodes
+func TestNodeMergeOverride(t *testing.T) {
+	t.Parallel()
+
+	none := func(string) []string { return nil }
+
+	t.Run("replaces values set on the node", func(t *testing.T) {
+		node, err := fromString(`task "x" { timeout = "5m" check = "true" }`)
+		require.NoError(t, err)
+		override, err := fromString(`override "module.a/task.x" { timeout = "60s" interpreter = "/bin/bash" }`)
+		require.NoError(t, err)
+
+		require.NoError(t, node.MergeOverride(override, none))
+
+		timeout, err := node.GetString("timeout")
+		require.NoError(t, err)
+		assert.Equal(t, "60s", timeout)
+
+		interpreter, err := node.GetString("interpreter")
+		require.NoError(t, err)
+		assert.Equal(t, "/bin/bash", interpreter)
+
+		check, err := node.GetString("check")
+		require.NoError(t, err)
+		assert.Equal(t, "true", check)
+	})
+
+	t.Run("removes conflicting values", func(t *testing.T) {
+		node, err := fromString(`file.content "x" { source = "a.txt" }`)
+		require.NoError(t, err)
+		override, err := fromString(`override "module.a/file.content.x" { content = "a" }`)
+		require.NoError(t, err)
+
+		conflicts := func(key string) []string {
+			if key == "content" {
+				return []string{"source"}
+			}
+			return nil
+		}
+		require.NoError(t, node.MergeOverride(override, conflicts))
+
+		_, err = node.Get("source")
+		assert.Equal(t, parse.ErrNotFound, err)
+
+		content, err := node.GetString("content")
+		require.NoError(t, err)
+		assert.Equal(t, "a", content)
+	})
+}
+
 func TestNodeKind(t *testing.T) {
 	t.Parallel()
 
@@ -269,6 +374,70 @@ func TestNodeGroup(t *testing.T) {
 	assert.Equal(t, "somegroup", node.Group())
 }
 
+// TestNodeTransaction verifies that a transaction can be parsed, and that its
+// members are grouped by its name
+func TestNodeTransaction(t *testing.T) {
+	t.Parallel()
+
+	node, err := fromString(`task "x" { transaction = "service" }`)
+	require.NoError(t, err)
+	transaction, err := node.Transaction()
+	assert.NoError(t, err)
+	assert.Equal(t, "service", transaction)
+	assert.Equal(t, "service", node.Group())
+
+	node, err = fromString(`task "x" {}`)
+	require.NoError(t, err)
+	transaction, err = node.Transaction()
+	assert.NoError(t, err)
+	assert.Equal(t, "", transaction)
+
+	node, err = fromString(`task "x" { transaction = true }`)
+	require.NoError(t, err)
+	_, err = node.Transaction()
+	assert.Error(t, err)
+}
+
+// TestNodeAllowDestructive verifies that allow_destructive can be parsed
+func TestNodeAllowDestructive(t *testing.T) {
+	t.Parallel()
+
+	node, err := fromString(`task "x" { allow_destructive = true }`)
+	assert.NoError(t, err)
+	assert.True(t, node.AllowDestructive())
+
+	node, err = fromString(`task "x" {}`)
+	assert.NoError(t, err)
+	assert.False(t, node.AllowDestructive())
+
+	// resources like docker.container have their own force field
+	node, err = fromString(`docker.container "x" { force = true }`)
+	assert.NoError(t, err)
+	assert.False(t, node.AllowDestructive())
+}
+
+// TestNodeChangeWindow verifies that change_window can be parsed
+func TestNodeChangeWindow(t *testing.T) {
+	t.Parallel()
+
+	node, err := fromString(`task "x" { change_window = "maintenance" }`)
+	require.NoError(t, err)
+	window, err := node.ChangeWindow()
+	assert.NoError(t, err)
+	assert.Equal(t, "maintenance", window)
+
+	node, err = fromString(`task "x" {}`)
+	require.NoError(t, err)
+	window, err = node.ChangeWindow()
+	assert.NoError(t, err)
+	assert.Equal(t, "", window)
+
+	node, err = fromString(`task "x" { change_window = 1 }`)
+	require.NoError(t, err)
+	_, err = node.ChangeWindow()
+	assert.Error(t, err)
+}
+
 func TestNodeGet(t *testing.T) {
 	t.Parallel()
 
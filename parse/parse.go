@@ -20,15 +20,30 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/parser"
 )
 
 // Parse content into a bunch of nodes
 func Parse(content []byte) (resources []*Node, err error) {
+	return ParseFile(content, "")
+}
+
+// ParseFile parses content into a bunch of nodes, like Parse. The filename is
+// recorded in the position of every node and value, so that errors about them
+// can be reported as file:line:column.
+func ParseFile(content []byte, filename string) (resources []*Node, err error) {
 	obj, err := hcl.ParseBytes(content)
 	if err != nil {
+		if posErr, ok := err.(*parser.PosError); ok && filename != "" {
+			posErr.Pos.Filename = filename
+		}
 		return resources, err
 	}
 
+	if filename != "" {
+		setFilename(obj, filename)
+	}
+
 	ast.Walk(obj.Node, func(n ast.Node) (ast.Node, bool) {
 		baseItem, ok := n.(*ast.ObjectItem)
 		if !ok {
@@ -44,7 +59,7 @@ func Parse(content []byte) (resources []*Node, err error) {
 
 		for _, v := range resources {
 			if v.ID() == item.ID() {
-				err = multierror.Append(fmt.Errorf("duplicate resource %s", item.ID()))
+				err = multierror.Append(err, fmt.Errorf("%s: duplicate resource %s", item.Pos(), item.ID()))
 				return n, false
 			}
 		}
@@ -55,3 +70,24 @@ func Parse(content []byte) (resources []*Node, err error) {
 
 	return resources, err
 }
+
+// setFilename records the filename in the positions throughout an AST
+func setFilename(root ast.Node, filename string) {
+	ast.Walk(root, func(n ast.Node) (ast.Node, bool) {
+		switch node := n.(type) {
+		case *ast.ObjectItem:
+			node.Assign.Filename = filename
+		case *ast.ObjectKey:
+			node.Token.Pos.Filename = filename
+		case *ast.LiteralType:
+			node.Token.Pos.Filename = filename
+		case *ast.ListType:
+			node.Lbrack.Filename = filename
+			node.Rbrack.Filename = filename
+		case *ast.ObjectType:
+			node.Lbrace.Filename = filename
+			node.Rbrace.Filename = filename
+		}
+		return n, true
+	})
+}
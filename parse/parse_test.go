@@ -19,6 +19,7 @@ import (
 
 	"github.com/asteris-llc/converge/parse"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParse(t *testing.T) {
@@ -48,3 +49,48 @@ func TestParseInvalid(t *testing.T) {
 		assert.EqualError(t, err, "1 error(s) occurred:\n\n* 1:1: missing name")
 	}
 }
+
+func TestParseFile(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := parse.ParseFile([]byte("task {}"), "web.hcl")
+
+		if assert.Error(t, err) {
+			assert.EqualError(t, err, "1 error(s) occurred:\n\n* web.hcl:1:1: missing name")
+		}
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		_, err := parse.ParseFile([]byte("task x {\n  check = \"a\"\n  }}"), "web.hcl")
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "web.hcl:3:")
+		}
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		_, err := parse.ParseFile([]byte("task x {}\ntask x {}"), "web.hcl")
+
+		if assert.Error(t, err) {
+			assert.EqualError(t, err, "1 error(s) occurred:\n\n* web.hcl:2:1: duplicate resource task.x")
+		}
+	})
+
+	t.Run("positions", func(t *testing.T) {
+		resources, err := parse.ParseFile([]byte("task x {\n  check = \"true\"\n  apply = \"echo {{param `message`}}\"\n}"), "web.hcl")
+		require.NoError(t, err)
+		require.Len(t, resources, 1)
+
+		node := resources[0]
+		assert.Equal(t, "web.hcl:1:1", node.Pos().String())
+		assert.Equal(t, "web.hcl:2:11", node.FieldPosition("check").String())
+		assert.Equal(t, "web.hcl:1:1", node.FieldPosition("missing").String())
+		assert.Equal(t, "web.hcl:3:19", node.Locate("param").String())
+		assert.Equal(t, "web.hcl:1:1", node.Locate("lookup").String())
+
+		positions := node.FieldPositions()
+		assert.Len(t, positions, 2)
+		assert.Equal(t, "web.hcl:3:11", positions["apply"].String())
+	})
+}
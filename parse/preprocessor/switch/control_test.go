@@ -49,6 +49,26 @@ func (m *MockRenderer) Render(name, content string) (string, error) {
 	return args.String(0), args.Error(1)
 }
 
+// Source is a mock source
+func (m *MockRenderer) Source() string {
+	return ""
+}
+
+// SourceDir is a mock source dir
+func (m *MockRenderer) SourceDir() string {
+	return ""
+}
+
+// ResolvePath is a mock path resolver
+func (m *MockRenderer) ResolvePath(loc string) (string, error) {
+	return loc, nil
+}
+
+// LocalPath is a mock local path resolver
+func (m *MockRenderer) LocalPath(loc string) (string, error) {
+	return loc, nil
+}
+
 // defaultMockRenderer takes (any,any) and returns ("",nil)
 func defaultMockRenderer() *MockRenderer {
 	m := &MockRenderer{}
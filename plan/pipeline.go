@@ -20,12 +20,14 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/asteris-llc/converge/errcode"
 	"github.com/asteris-llc/converge/executor"
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node/conditional"
 	"github.com/asteris-llc/converge/parse/preprocessor/switch"
 	"github.com/asteris-llc/converge/render"
 	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/module"
 	"golang.org/x/net/context"
 )
 
@@ -37,6 +39,9 @@ type pipelineGen struct {
 
 type taskWrapper struct {
 	Task resource.Task
+
+	// Deferred are the fields that depend on values known only after apply
+	Deferred []string
 }
 
 // Pipeline generates a pipeline to evaluate a single graph node
@@ -53,6 +58,13 @@ func Pipeline(ctx context.Context, g *graph.Graph, id string, factory *render.Fa
 func (g *pipelineGen) GetTask(ctx context.Context, idi interface{}) (interface{}, error) {
 	if thunk, ok := idi.(*render.PrepareThunk); ok {
 		thunked, err := thunk.Thunk(g.RenderingPlant)
+
+		// the task is prepared with placeholders, which it may not accept, so
+		// the thunk is kept to prepare again at apply time
+		if deferred := g.RenderingPlant.Deferred(g.ID); len(deferred) > 0 {
+			return taskWrapper{Task: thunk, Deferred: deferred}, nil
+		}
+
 		if err != nil {
 			return nil, err
 		}
@@ -130,10 +142,17 @@ func (g *pipelineGen) DependencyCheck(ctx context.Context, taskI interface{}) (i
 			return nil, fmt.Errorf("expected executor.Status but got %T", meta.Value())
 		}
 		if err := dep.Error(); err != nil {
+			status := &resource.Status{Level: resource.StatusWillChange}
+			// modules depend on all of their children, so reporting them as
+			// skipped would only repeat the failure
+			if !module.IsModule(task.Task) {
+				status.AddWarning(resource.WarningSkipped, fmt.Sprintf("not checked because dependency %q failed", depID))
+			}
+
 			errResult := &Result{
-				Status: &resource.Status{Level: resource.StatusWillChange},
+				Status: status,
 				Task:   task.Task,
-				Err:    fmt.Errorf("error in dependency %q", depID),
+				Err:    errcode.Errorf(errcode.PlanDependency, "error in dependency %q", depID),
 			}
 			return errResult, nil
 		}
@@ -155,11 +174,23 @@ func (g *pipelineGen) PlanNode(ctx context.Context, taski interface{}) (interfac
 		return nil, fmt.Errorf("expected type *Result or taskWrapper but got %T", taski)
 	}
 
+	if len(twrapper.Deferred) > 0 {
+		return deferredResult(twrapper), nil
+	}
+
 	renderer, err := g.Renderer(g.ID)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get renderer for %s", g.ID)
 	}
-	status, err := twrapper.Task.Check(ctx, renderer)
+	// wait for tasks claiming the same system objects to finish first
+	var status resource.TaskStatus
+	release, err := resource.AcquireClaims(ctx, twrapper.Task)
+	if err == nil {
+		status, err = resource.RunHooks(ctx, g.operation(twrapper.Task), func(ctx context.Context) (resource.TaskStatus, error) {
+			return resource.SafeCheck(ctx, twrapper.Task, renderer)
+		})
+		release()
+	}
 
 	// create empty Status structure, if it not created in .Check()
 	if status == nil {
@@ -182,13 +213,45 @@ func (g *pipelineGen) PlanNode(ctx context.Context, taski interface{}) (interfac
 		inner.SetError(err)
 	}
 
+	code := errcode.PlanCheckFailed
+	if ctx.Err() != nil {
+		code = errcode.PlanInterrupted
+	}
+
 	return &Result{
 		Status: status,
 		Task:   twrapper.Task,
-		Err:    status.Error(),
+		Err:    errcode.Wrap(code, status.Error()),
 	}, nil
 }
 
+// deferredResult is the result for a node which depends on values known only
+// after apply. It isn't checked, and reports that its deferred fields will
+// change.
+func deferredResult(twrapper taskWrapper) *Result {
+	status := resource.NewStatus()
+	for _, field := range twrapper.Deferred {
+		status.AddDifference(field, "", render.KnownAfterApply, "")
+	}
+	status.AddMessage("not checked because it depends on values known after apply")
+	status.RaiseLevelForDiffs()
+
+	return &Result{
+		Status:   status,
+		Task:     twrapper.Task,
+		Deferred: twrapper.Deferred,
+	}
+}
+
+// operation describes checking the node to hooks
+func (g *pipelineGen) operation(task resource.Task) *resource.Operation {
+	op := &resource.Operation{Kind: resource.OperationCheck, ID: g.ID, Task: task}
+	if meta, ok := g.Graph.Get(g.ID); ok {
+		op.Metadata = meta.Metadata()
+	}
+	return op
+}
+
 func (g *pipelineGen) Renderer(id string) (*render.Renderer, error) {
 	return g.RenderingPlant.GetRenderer(id)
 }
@@ -18,9 +18,12 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/asteris-llc/converge/errcode"
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/render"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/module"
 	"golang.org/x/net/context"
 )
 
@@ -36,6 +39,8 @@ func Plan(ctx context.Context, in *graph.Graph) (*graph.Graph, error) {
 func WithNotify(ctx context.Context, in *graph.Graph, notify *graph.Notifier) (*graph.Graph, error) {
 	var hasErrors error
 
+	ctx = resource.WithClaimLocker(ctx, resource.NewClaimLocker())
+
 	out, err := in.Transform(ctx,
 		notify.Transform(func(meta *node.Node, out *graph.Graph) error {
 			renderingPlant, err := render.NewFactory(ctx, in)
@@ -43,6 +48,7 @@ func WithNotify(ctx context.Context, in *graph.Graph, notify *graph.Notifier) (*
 				return err
 			}
 			renderingPlant.Graph = out
+			renderingPlant.DeferLookups = true
 
 			pipeline := Pipeline(ctx, out, meta.ID, renderingPlant)
 
@@ -60,13 +66,55 @@ func WithNotify(ctx context.Context, in *graph.Graph, notify *graph.Notifier) (*
 				hasErrors = ErrTreeContainsErrors
 			}
 
-			out.Add(meta.WithValue(asResult))
+			// nodes prepared from thunks were rendered here
+			updated := meta.WithValue(asResult)
+			render.RecordProvenance(updated, renderingPlant.Provenance(meta.ID))
+			out.Add(updated)
 
 			return nil
 		}),
 	)
+	if ctx.Err() != nil {
+		if interruptErr := interrupt(out, notify); interruptErr != nil {
+			return out, interruptErr
+		}
+		hasErrors = ErrTreeContainsErrors
+		if err == ctx.Err() {
+			err = nil
+		}
+	}
 	if err != nil {
 		return out, err
 	}
 	return out, hasErrors
 }
+
+// interrupt marks every node that was not checked before the run was cancelled
+// so that partial results can be reported
+func interrupt(g *graph.Graph, notify *graph.Notifier) error {
+	for _, meta := range g.Nodes() {
+		if _, ok := meta.Value().(*Result); ok {
+			continue
+		}
+
+		task, _ := meta.Value().(resource.Task)
+		status := &resource.Status{Level: resource.StatusWillChange}
+		if !module.IsModule(task) {
+			status.AddWarning(resource.WarningSkipped, "not checked because the run was interrupted")
+		}
+
+		updated := meta.WithValue(&Result{
+			Task:   task,
+			Status: status,
+			Err:    errcode.Errorf(errcode.PlanInterrupted, "interrupted before checking"),
+		})
+		g.Add(updated)
+
+		if notify != nil && notify.Post != nil {
+			if err := notify.Post(updated); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
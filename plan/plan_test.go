@@ -17,11 +17,14 @@ package plan_test
 import (
 	"testing"
 
+	"github.com/asteris-llc/converge/errcode"
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/helpers/faketask"
 	"github.com/asteris-llc/converge/helpers/logging"
 	"github.com/asteris-llc/converge/plan"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/context"
@@ -62,6 +65,59 @@ func TestPlanNilAndError(t *testing.T) {
 	assert.Equal(t, task, result.Task)
 }
 
+// TestPlanPanic tests that a panic in Check fails only the panicking node
+func TestPlanPanic(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	g := graph.New()
+	g.Add(node.New("root", faketask.NoOp()))
+	g.Add(node.New("root/panic", faketask.Panic()))
+	g.ConnectParent("root", "root/panic")
+
+	require.NoError(t, g.Validate())
+
+	planned, err := plan.Plan(context.Background(), g)
+	assert.Equal(t, plan.ErrTreeContainsErrors, err)
+
+	result := getResult(t, planned, "root/panic")
+	if assert.Error(t, result.Error()) {
+		cause, ok := errors.Cause(result.Error()).(*resource.PanicError)
+		require.True(t, ok, "expected *resource.PanicError, got %T", errors.Cause(result.Error()))
+		assert.Equal(t, "task panicked", cause.Value)
+		assert.NotEmpty(t, cause.Stack)
+	}
+
+	root := getResult(t, planned, "root")
+	assert.EqualError(t, root.Error(), `error in dependency "root/panic"`)
+}
+
+// TestPlanInterrupted tests that nodes are reported as interrupted when the
+// context is cancelled before they are checked
+func TestPlanInterrupted(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	g := graph.New()
+	g.Add(node.New("root", faketask.NoOp()))
+	g.Add(node.New("root/a", faketask.NoOp()))
+	g.ConnectParent("root", "root/a")
+
+	require.NoError(t, g.Validate())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	planned, err := plan.Plan(ctx, g)
+	assert.Equal(t, plan.ErrTreeContainsErrors, err)
+
+	for _, id := range []string{"root", "root/a"} {
+		result := getResult(t, planned, id)
+
+		code, ok := errcode.Of(result.Error())
+		assert.True(t, ok)
+		assert.Equal(t, errcode.PlanInterrupted, code)
+	}
+}
+
 func TestPlanErrorsBelow(t *testing.T) {
 	defer logging.HideLogs(t)()
 
@@ -90,6 +146,11 @@ func TestPlanErrorsBelow(t *testing.T) {
 	rootNode, ok := rootMeta.Value().(*plan.Result)
 	require.True(t, ok)
 	assert.EqualError(t, rootNode.Error(), `error in dependency "root/err"`)
+	assert.Equal(
+		t,
+		[]resource.Warning{{Kind: resource.WarningSkipped, Message: `not checked because dependency "root/err" failed`}},
+		rootNode.Warnings(),
+	)
 }
 
 func getResult(t *testing.T, src *graph.Graph, key string) *plan.Result {
@@ -21,6 +21,11 @@ type Result struct {
 	Task   resource.Task
 	Status resource.TaskStatus
 	Err    error
+
+	// Deferred are the fields that depend on values known only after apply.
+	// The task of a deferred result is the unprepared node, and is prepared
+	// and checked again at apply time.
+	Deferred []string
 }
 
 // Messages returns any message values supplied by the task
@@ -38,8 +43,21 @@ func (r *Result) Error() error { return r.Err }
 // Warning returns the warning assigned to this Result, if any
 func (r *Result) Warning() string { return r.Status.Warning() }
 
+// Warnings returns all categorized warnings assigned to this Result
+func (r *Result) Warnings() []resource.Warning { return resource.StatusWarnings(r.Status) }
+
+// Destructive returns why the planned changes are destructive, if they are
+func (r *Result) Destructive() []string { return resource.StatusDestructive(r.Status) }
+
+// Impact returns how disruptive the planned changes are
+func (r *Result) Impact() resource.Impact { return resource.StatusImpact(r.Status) }
+
 // GetStatus returns the current task status
 func (r *Result) GetStatus() resource.TaskStatus { return r.Status }
 
 // GetTask returns the embedded task
 func (r *Result) GetTask() (resource.Task, bool) { return r.Task, true }
+
+// DeferredFields returns the fields that depend on values known only after
+// apply
+func (r *Result) DeferredFields() []string { return r.Deferred }
@@ -92,6 +92,13 @@ type PrintProvider interface {
 	SubgraphMarker(GraphEntity) SubgraphMarkerKey
 }
 
+// SubgraphLabeler can be implemented by a PrintProvider to label subgraphs.
+// Given the graph entity that starts a subgraph, it returns the label drawn on
+// the subgraph cluster.
+type SubgraphLabeler interface {
+	SubgraphGetLabel(GraphEntity) (pp.VisibleRenderable, error)
+}
+
 // Options specifies global graph options that can be configured for output.
 // Arbitrary graphviz options are not supported.
 type Options struct {
@@ -224,7 +231,7 @@ func (p *Printer) DrawEdge(g *graph.Graph, id1, id2 string) (pp.Renderable, erro
 
 	srcVert, sok := srcVal.(*pb.GraphComponent_Vertex)
 	destVert, dok := destVal.(*pb.GraphComponent_Vertex)
-	if sok && dok && srcVert.Kind == "module" && destVert.Kind != "module" {
+	if sok && dok && srcVert.Kind == "module" && destVert.Kind != "module" && strings.HasPrefix(id2, id1+"/") {
 		return pp.HiddenString(), nil
 	}
 
@@ -241,6 +248,25 @@ func (p *Printer) DrawEdge(g *graph.Graph, id1, id2 string) (pp.Renderable, erro
 // StartSubgraph returns a string with the beginning of the subgraph cluster
 func (p *Printer) StartSubgraph(g *graph.Graph, startNode string, subgraphID pp.SubgraphID) (pp.Renderable, error) {
 	clusterStart := fmt.Sprintf("subgraph cluster_%d {\n", subgraphID.(int))
+
+	labeler, ok := p.printProvider.(SubgraphLabeler)
+	if !ok {
+		return pp.VisibleString(clusterStart), nil
+	}
+
+	var val interface{}
+	if meta, ok := g.Get(startNode); ok {
+		val = meta.Value()
+	}
+
+	label, err := labeler.SubgraphGetLabel(GraphEntity{startNode, val})
+	if err != nil {
+		return pp.HiddenString(), err
+	}
+	if label.Visible() {
+		clusterStart += fmt.Sprintf("label = \"%s\";\n", escapeNewline(label))
+	}
+
 	return pp.VisibleString(clusterStart), nil
 }
 
@@ -261,6 +261,25 @@ func Test_FinishSubgraph_ReturnsClosingBrace(t *testing.T) {
 	assert.Equal(t, expected, actual.String())
 }
 
+func Test_StartSubgraph_ReturnsClusterStart(t *testing.T) {
+	provider := defaultMockProvider()
+	printer := graphviz.New(graphviz.DefaultOptions(), provider)
+	expected := "subgraph cluster_1 {\n"
+	actual, err := printer.StartSubgraph(emptyGraph, "root/module.x", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual.String())
+}
+
+func Test_StartSubgraph_SetsLabel_WhenProviderLabelsSubgraphs(t *testing.T) {
+	provider := &MockLabelingPrintProvider{*defaultMockProvider()}
+	provider.On("SubgraphGetLabel", mock.Anything).Return(pp.VisibleString("module.x"), nil)
+	printer := graphviz.New(graphviz.DefaultOptions(), provider)
+	expected := "subgraph cluster_1 {\nlabel = \"module.x\";\n"
+	actual, err := printer.StartSubgraph(emptyGraph, "root/module.x", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual.String())
+}
+
 type MockPrintProvider struct {
 	mock.Mock
 }
@@ -295,6 +314,15 @@ func (m *MockPrintProvider) EdgeGetProperties(i, j graphviz.GraphEntity) graphvi
 	return args.Get(0).(graphviz.PropertySet)
 }
 
+type MockLabelingPrintProvider struct {
+	MockPrintProvider
+}
+
+func (m *MockLabelingPrintProvider) SubgraphGetLabel(i graphviz.GraphEntity) (pp.VisibleRenderable, error) {
+	args := m.Called(i)
+	return args.Get(0).(pp.VisibleRenderable), args.Error(1)
+}
+
 func defaultMockProvider() *MockPrintProvider {
 	m := new(MockPrintProvider)
 	m.On("VertexGetID", mock.Anything).Return(pp.VisibleString("id1"), nil)
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/asteris-llc/converge/graph"
 	pp "github.com/asteris-llc/converge/prettyprinters"
 	"github.com/asteris-llc/converge/prettyprinters/graphviz"
 	"github.com/asteris-llc/converge/resource/file/content"
@@ -33,6 +34,11 @@ import (
 type RPCProvider struct {
 	graphviz.GraphIDProvider
 	ShowParams bool
+
+	// CollapseModules should be set when the graph has had the contents of its
+	// modules removed, so modules are drawn as single vertices instead of
+	// clusters
+	CollapseModules bool
 }
 
 // VertexGetID returns the graph ID as the VertexID, possibly maksing it
@@ -167,13 +173,27 @@ func (p RPCProvider) SubgraphMarker(e graphviz.GraphEntity) graphviz.SubgraphMar
 		return graphviz.SubgraphMarkerNOP
 	}
 
-	if val.Kind == "module" {
+	if val.Kind == "module" && !p.CollapseModules {
 		return graphviz.SubgraphMarkerStart
 	}
 
 	return graphviz.SubgraphMarkerNOP
 }
 
+// SubgraphGetLabel labels module clusters with the name of the module
+func (p RPCProvider) SubgraphGetLabel(e graphviz.GraphEntity) (pp.VisibleRenderable, error) {
+	val, ok := e.Value.(*pb.GraphComponent_Vertex)
+	if !ok || val.Kind != "module" {
+		return pp.HiddenString(), nil
+	}
+
+	if e.Name == rootNodeID {
+		return pp.VisibleString("/"), nil
+	}
+
+	return pp.VisibleString(graph.BaseID(e.Name)), nil
+}
+
 // NewRPCProvider is a utility function to return a new RPCProvider
 func NewRPCProvider() graphviz.PrintProvider {
 	return RPCProvider{}
@@ -17,13 +17,16 @@ package human
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"text/tabwriter"
 	"text/template"
 
+	"github.com/asteris-llc/converge/errcode"
 	"github.com/asteris-llc/converge/graph"
 	pp "github.com/asteris-llc/converge/prettyprinters"
+	"github.com/asteris-llc/converge/resource"
 	"github.com/pkg/errors"
 )
 
@@ -63,6 +66,25 @@ func (p *Printer) InitColors() {
 	p.funcsMapWrite("white", p.styled(func(in string) string { return "\x1b[37m" + in + reset }))
 }
 
+// impactGroup collects the IDs of changed nodes with the same impact
+type impactGroup struct {
+	Impact resource.Impact
+	IDs    []string
+}
+
+// rolledBack describes how a transaction was rolled back when the node with
+// ID failed
+type rolledBack struct {
+	ID       string
+	Messages []string
+}
+
+type rolledBackByID []rolledBack
+
+func (r rolledBackByID) Len() int           { return len(r) }
+func (r rolledBackByID) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r rolledBackByID) Less(i, j int) bool { return r[i].ID < r[j].ID }
+
 // StartPP does nothing, but is required to satisfy the GraphPrinter interface
 func (p *Printer) StartPP(g *graph.Graph) (pp.Renderable, error) {
 	return pp.HiddenString(), nil
@@ -78,11 +100,21 @@ func (p *Printer) FinishPP(g *graph.Graph) (pp.Renderable, error) {
 {{range .DependencyErrors}} * {{.}}
 {{end}}
 {{end}}
+{{- if .Impacts}}Changes by impact:
+{{range .Impacts}} * {{.Impact}}: {{range $i, $id := .IDs}}{{if $i}}, {{end}}{{$id}}{{end}}
+{{end}}
+{{end}}
+{{- range .Rollbacks}}Rolled back after {{.ID}} failed:
+{{range .Messages}} * {{.}}
+{{end}}
+{{end}}
 {{- if gt (len .Errors) 0}}{{red "Summary"}}
 {{- else}}{{green "Summary"}}
 {{- end}}: {{len .Errors}} errors, {{.ChangesCount}} changes
 {{- if .DependencyErrors}}, {{len .DependencyErrors}} dependency errors
 {{- end}}
+{{- if .Rollbacks}}, {{len .Rollbacks}} transactions rolled back
+{{- end}}
 `)
 	if err != nil {
 		return pp.HiddenString(), err
@@ -92,8 +124,12 @@ func (p *Printer) FinishPP(g *graph.Graph) (pp.Renderable, error) {
 		ChangesCount     int
 		Errors           []error
 		DependencyErrors []error
+		Impacts          []impactGroup
+		Rollbacks        []rolledBack
 	}{}
 
+	byImpact := map[resource.Impact][]string{}
+
 	for _, id := range g.Vertices() {
 		meta, ok := g.Get(id)
 		if !ok {
@@ -105,31 +141,62 @@ func (p *Printer) FinishPP(g *graph.Graph) (pp.Renderable, error) {
 			continue
 		}
 
+		if reporter, ok := printable.(resource.RollbackReporter); ok {
+			if messages := reporter.Rollbacks(); len(messages) > 0 {
+				counts.Rollbacks = append(counts.Rollbacks, rolledBack{ID: id, Messages: messages})
+			}
+		}
+
 		if err = printable.Error(); err != nil {
 			if id != "root" {
-				if strings.Contains(err.Error(), "error in dependency") {
+				if isDependencyError(err) {
 					counts.DependencyErrors = append(
 						counts.DependencyErrors,
-						errors.Wrap(err, id),
+						errors.New(errcode.Format(errors.Wrap(err, id))),
 					)
 				} else {
 					counts.Errors = append(
 						counts.Errors,
-						errors.Wrap(err, id),
+						errors.New(errcode.Format(errors.Wrap(err, id))),
 					)
 				}
 			}
 		} else if printable.HasChanges() && id != "root" {
 			counts.ChangesCount++
+
+			if reporter, ok := printable.(resource.ImpactReporter); ok {
+				if impact := reporter.Impact(); impact != resource.ImpactNone {
+					byImpact[impact] = append(byImpact[impact], id)
+				}
+			}
+		}
+	}
+
+	// most disruptive first, so reviewers see the dangerous changes at the top
+	for impact := resource.ImpactDestructive; impact > resource.ImpactNone; impact-- {
+		if ids, ok := byImpact[impact]; ok {
+			sort.Strings(ids)
+			counts.Impacts = append(counts.Impacts, impactGroup{Impact: impact, IDs: ids})
 		}
 	}
 
+	sort.Sort(rolledBackByID(counts.Rollbacks))
+
 	var buf bytes.Buffer
 	err = tmpl.Execute(&buf, counts)
 
 	return &buf, err
 }
 
+// isDependencyError checks if the error was caused by a failing dependency.
+// Errors without a code are checked by message.
+func isDependencyError(err error) bool {
+	if code, ok := errcode.Of(err); ok {
+		return code == errcode.PlanDependency || code == errcode.ApplyDependency
+	}
+	return strings.Contains(err.Error(), "error in dependency")
+}
+
 // DrawNode containing a result
 func (p *Printer) DrawNode(g *graph.Graph, id string) (pp.Renderable, error) {
 	meta, ok := g.Get(id)
@@ -148,7 +215,7 @@ func (p *Printer) DrawNode(g *graph.Graph, id string) (pp.Renderable, error) {
 
 	tmpl, err := p.template(`{{if .Error}}{{red .ID}}{{else if .HasChanges}}{{yellow .ID}}{{else}}{{.ID}}{{end}}:
 	{{- if .Error}}
-	{{red "Error"}}: {{.Error}}
+	{{red "Error"}}: {{if .ErrorCode}}[{{.ErrorCode}}] {{end}}{{.Error}}
 	{{- end}}
 	{{- if .Warning}}
 	{{yellow "Warning"}}: {{.Warning}}
@@ -160,8 +227,23 @@ func (p *Printer) DrawNode(g *graph.Graph, id string) (pp.Renderable, error) {
 	Has Changes: {{if .HasChanges}}{{yellow "yes"}}{{else}}no{{end}}
 	Changes:
 		{{- range $key, $values := .Changes}}
-		{{cyan $key}}:	{{diff ($values.Original) ($values.Current)}}
+		{{cyan $key}}:	{{change $values}}
 		{{- else}} No changes {{- end}}
+	{{- if .Outcome}}
+	Outcome: {{if .Remaining}}{{red .Outcome}}{{else}}{{.Outcome}}{{end}}
+	{{- end}}
+	{{- if .Remaining}}
+	Remaining Changes:
+		{{- range $key, $values := .Remaining}}
+		{{cyan $key}}:	{{change $values}}
+		{{- end}}
+	{{- end}}
+	{{- if .Rollbacks}}
+	Rolled Back:
+	{{- range $msg := .Rollbacks}}
+	{{indent $msg}}
+	{{- end}}
+	{{- end}}
 
 `)
 	if err != nil {
@@ -169,7 +251,19 @@ func (p *Printer) DrawNode(g *graph.Graph, id string) (pp.Renderable, error) {
 	}
 
 	var intermediate, out bytes.Buffer
-	err = tmpl.Execute(&intermediate, &printerNode{ID: id, Printable: printable})
+	node := &printerNode{ID: id, Printable: printable}
+	if code, ok := errcode.Of(printable.Error()); ok {
+		node.ErrorCode = string(code)
+	}
+	if reporter, ok := printable.(resource.OutcomeReporter); ok {
+		node.Outcome = string(reporter.Outcome())
+		node.Remaining = reporter.RemainingChanges()
+	}
+	if reporter, ok := printable.(resource.RollbackReporter); ok {
+		node.Rollbacks = reporter.Rollbacks()
+	}
+
+	err = tmpl.Execute(&intermediate, node)
 	if err != nil {
 		return pp.HiddenString(), err
 	}
@@ -194,6 +288,7 @@ func (p *Printer) funcsMapWrite(key string, value interface{}) {
 
 func (p *Printer) template(source string) (*template.Template, error) {
 	p.funcsMapWrite("diff", p.diff)
+	p.funcsMapWrite("change", p.change)
 	p.funcsMapWrite("indent", p.indent)
 	p.funcsMapWrite("empty", p.empty)
 
@@ -233,6 +328,31 @@ after:
 	return "\n" + p.indent(p.indent(buf.String())), err
 }
 
+// change renders a diff as a unified diff when one is available, and falls back
+// to showing the values before and after
+func (p *Printer) change(d resource.Diff) (string, error) {
+	unified, ok := d.(resource.UnifiedDiffer)
+	if !ok || unified.Unified() == "" {
+		return p.diff(d.Original(), d.Current())
+	}
+
+	lines := strings.Split(unified.Unified(), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			lines[i] = p.getFunc("bold")(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = p.getFunc("green")(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = p.getFunc("red")(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = p.getFunc("cyan")(line)
+		}
+	}
+
+	return "\n" + p.indent(p.indent(strings.Join(lines, "\n"))), nil
+}
+
 func (p *Printer) indent(in string) string {
 	return "\t" + strings.Replace(in, "\n", "\n\t", -1)
 }
@@ -120,6 +120,53 @@ func TestFinishPPError(t *testing.T) {
 	})
 }
 
+func TestFinishPPImpact(t *testing.T) {
+	t.Parallel()
+
+	t.Run("grouped by impact", func(t *testing.T) {
+		testFinishPPMultiNode(
+			t,
+			[]string{"root/a", "root/b", "root/c", "root/d"},
+			[]Printable{
+				{"a": "b", "impact": "low"},
+				{"a": "b", "impact": "reboot"},
+				{"a": "b", "impact": "low"},
+				{"a": "b"},
+			},
+			"Changes by impact:\n * reboot: root/b\n * low: root/a, root/c\n\nSummary: 0 errors, 4 changes\n",
+		)
+	})
+
+	t.Run("no impact", func(t *testing.T) {
+		testFinishPP(
+			t,
+			Printable{"impact": "none"},
+			"Summary: 0 errors, 1 changes\n",
+		)
+	})
+}
+
+func TestFinishPPRollbacks(t *testing.T) {
+	t.Parallel()
+
+	g := graph.New()
+	g.Add(node.New("root/verify", rollbackPrintable{
+		Printable: Printable{"error": "unhealthy"},
+		rollbacks: []string{"rolled back root/config", "reapplied root/restart"},
+	}))
+
+	printer := human.New()
+	printer.InitColors()
+	str, err := printer.FinishPP(g)
+
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		"Errors:\n * root/verify: unhealthy\n\nRolled back after root/verify failed:\n * rolled back root/config\n * reapplied root/restart\n\nSummary: 1 errors, 0 changes, 1 transactions rolled back\n",
+		str.String(),
+	)
+}
+
 func testDrawNodes(t *testing.T, in Printable, out string) {
 	printer := human.New()
 	printer.InitColors()
@@ -142,6 +189,19 @@ func testDrawNodesCustomPrinter(t *testing.T, h *human.Printer, id string, in Pr
 	assert.Equal(t, out, str.String())
 }
 
+func testDrawNodeValue(t *testing.T, in human.Printable, out string) {
+	printer := human.New()
+	printer.InitColors()
+
+	g := graph.New()
+	g.Add(node.New("root", in))
+
+	str, err := printer.DrawNode(g, "root")
+
+	require.Nil(t, err)
+	assert.Equal(t, out, str.String())
+}
+
 func benchmarkDrawNodes(in Printable) {
 	benchmarkDrawNodesCustomPrinter(
 		defaultPrinter,
@@ -239,6 +299,27 @@ func BenchmarkDrawNodeChanges(b *testing.B) {
 	}
 }
 
+// TestDrawNodeUnifiedChanges tests that content diffs are shown as unified
+// diffs
+func TestDrawNodeUnifiedChanges(t *testing.T) {
+	t.Parallel()
+
+	printer := human.New()
+	printer.InitColors()
+
+	g := graph.New()
+	g.Add(node.New("root", unifiedPrintable{Printable{"a": "b"}}))
+
+	str, err := printer.DrawNode(g, "root")
+
+	require.Nil(t, err)
+	assert.Equal(
+		t,
+		"root:\n Messages:\n Has Changes: yes\n Changes:\n  a: \n  --- a\n  +++ a\n  @@ -1,2 +1,2 @@\n   x\n  -y\n  +z\n\n",
+		str.String(),
+	)
+}
+
 func TestDrawNodeError(t *testing.T) {
 	t.Parallel()
 
@@ -260,6 +341,47 @@ func TestDrawNodeWarning(t *testing.T) {
 	)
 }
 
+// TestDrawNodeOutcome tests that the outcome of applying is shown, along with
+// any changes left afterwards
+func TestDrawNodeOutcome(t *testing.T) {
+	t.Parallel()
+
+	t.Run("changed", func(t *testing.T) {
+		testDrawNodeValue(
+			t,
+			outcomePrintable{Printable: Printable{"a": "b"}, outcome: resource.OutcomeChanged},
+			"root:\n Messages:\n Has Changes: yes\n Changes:\n  a: \"\" => \"b\"\n Outcome: changed\n\n",
+		)
+	})
+
+	t.Run("drifted", func(t *testing.T) {
+		testDrawNodeValue(
+			t,
+			outcomePrintable{
+				Printable: Printable{"a": "b"},
+				outcome:   resource.OutcomeDrifted,
+				remaining: map[string]resource.Diff{"a": resource.TextDiff{Values: [2]string{"c", "b"}}},
+			},
+			"root:\n Messages:\n Has Changes: yes\n Changes:\n  a: \"\" => \"b\"\n Outcome: drifted\n Remaining Changes:\n  a: \"c\" => \"b\"\n\n",
+		)
+	})
+}
+
+// TestDrawNodeRollbacks tests that how a transaction was rolled back is shown
+// on the node that failed
+func TestDrawNodeRollbacks(t *testing.T) {
+	t.Parallel()
+
+	testDrawNodeValue(
+		t,
+		rollbackPrintable{
+			Printable: Printable{"error": "x"},
+			rollbacks: []string{"rolled back root/config", "reapplied root/restart"},
+		},
+		"root:\n Error: x\n Messages:\n Has Changes: yes\n Changes:\n  error: \"\" => \"x\"\n Rolled Back:\n  rolled back root/config\n  reapplied root/restart\n\n",
+	)
+}
+
 func BenchmarkDrawNodeError(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		benchmarkDrawNodes(
@@ -306,3 +428,41 @@ func (p Printable) Error() error {
 func (p Printable) Warning() string {
 	return p["warning"]
 }
+
+func (p Printable) Impact() resource.Impact {
+	impact, _ := resource.ParseImpact(p["impact"])
+	return impact
+}
+
+// outcomePrintable reports how applying turned out
+type outcomePrintable struct {
+	Printable
+	outcome   resource.Outcome
+	remaining map[string]resource.Diff
+}
+
+func (p outcomePrintable) Outcome() resource.Outcome                  { return p.outcome }
+func (p outcomePrintable) RemainingChanges() map[string]resource.Diff { return p.remaining }
+
+// rollbackPrintable reports how its transaction was rolled back
+type rollbackPrintable struct {
+	Printable
+	rollbacks []string
+}
+
+func (p rollbackPrintable) Rollbacks() []string { return p.rollbacks }
+
+// unifiedPrintable returns content diffs for each key
+type unifiedPrintable struct {
+	Printable
+}
+
+func (p unifiedPrintable) Changes() map[string]resource.Diff {
+	out := map[string]resource.Diff{}
+
+	for key := range p.Printable {
+		out[key] = resource.ContentDiff{Name: key, Values: [2]string{"x\ny\n", "x\nz\n"}}
+	}
+
+	return out
+}
@@ -17,7 +17,11 @@ package human
 import "github.com/asteris-llc/converge/resource"
 
 type printerNode struct {
-	ID string
+	ID        string
+	ErrorCode string
+	Outcome   string
+	Remaining map[string]resource.Diff
+	Rollbacks []string
 
 	Printable
 }
@@ -24,10 +24,11 @@ import (
 
 // Node is the serializable type for graph nodes
 type Node struct {
-	Kind  string      `json:"kind"`
-	ID    string      `json:"id"` // TODO: preserved for compat, remove in 0.4.0
-	Meta  *node.Node  `json:"meta"`
-	Value interface{} `json:"value"`
+	Kind   string      `json:"kind"`
+	ID     string      `json:"id"` // TODO: preserved for compat, remove in 0.4.0
+	Parent string      `json:"parent,omitempty"`
+	Meta   *node.Node  `json:"meta"`
+	Value  interface{} `json:"value"`
 }
 
 // Edge is the serializable type for graph edges
@@ -47,11 +48,14 @@ func (j *Printer) DrawNode(graph *graph.Graph, nodeID string) (pp.Renderable, er
 		return pp.HiddenString(), nil
 	}
 
+	parent, _ := graph.GetParentID(nodeID)
+
 	out, err := json.Marshal(&Node{
-		Kind:  "node",
-		ID:    meta.ID, // TODO: preserved for compat, remove in 0.4.0
-		Meta:  meta,
-		Value: meta.Value(),
+		Kind:   "node",
+		ID:     meta.ID, // TODO: preserved for compat, remove in 0.4.0
+		Parent: parent,
+		Meta:   meta,
+		Value:  meta.Value(),
 	})
 	return pp.VisibleString(string(out) + "\n"), err
 }
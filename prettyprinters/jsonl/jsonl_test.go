@@ -44,6 +44,19 @@ func TestDrawNode(t *testing.T) {
 	assert.Equal(t, `{"kind":"node","id":"x","meta":{"id":"x","group":""},"value":1}`+"\n", fmt.Sprint(out))
 }
 
+func TestDrawNodeWithParent(t *testing.T) {
+	g := graph.New()
+	g.Add(node.New("x", 1))
+	g.Add(node.New("x/y", 2))
+	g.ConnectParent("x", "x/y")
+
+	printer := new(jsonl.Printer)
+	out, err := printer.DrawNode(g, "x/y")
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"kind":"node","id":"x/y","parent":"x","meta":{"id":"x/y","group":""},"value":2}`+"\n", fmt.Sprint(out))
+}
+
 func TestDrawEdge(t *testing.T) {
 	g := graph.New()
 
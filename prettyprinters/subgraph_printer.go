@@ -188,7 +188,6 @@ func addNodeToSubgraph(subgraphs SubgraphMap, subgraphID SubgraphID, vertexID st
 		oldGraph.StartNode = &vertexID
 		oldGraph.ID = subgraphID
 	}
-	oldGraph.StartNode = &vertexID
 	oldGraph.Nodes = append(oldGraph.Nodes, vertexID)
 	subgraphs[subgraphID] = oldGraph
 }
@@ -0,0 +1,256 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package publish sends a summary of each apply to a key-value store, so the
+// convergence status of a fleet can be queried in one place. Summaries are
+// written to Consul or etcd under <prefix>/<host>/<module>, replacing the
+// summary of the previous run of the module on the host.
+package publish
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/resource/module"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// Summary is what is published about a run
+type Summary struct {
+	Host     string `json:"host"`
+	Location string `json:"location"`
+
+	// ModuleHash identifies the content of the module and every module it
+	// includes, so hosts running the same version of a module have the same
+	// hash
+	ModuleHash string `json:"moduleHash"`
+
+	Nodes   int `json:"nodes"`
+	Changed int `json:"changed"`
+	Failed  int `json:"failed"`
+
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+
+	// Error is set when the run as a whole failed
+	Error string `json:"error,omitempty"`
+}
+
+// result is the part of an applied node the summary is made of
+type result interface {
+	HasChanges() bool
+	Error() error
+}
+
+// Summarize summarizes an applied graph. Module nodes only repeat what
+// happened to their children, so they aren't counted.
+func Summarize(host, location string, g *graph.Graph, started time.Time, runErr error) *Summary {
+	summary := &Summary{
+		Host:       host,
+		Location:   location,
+		ModuleHash: ModuleHash(g),
+		Started:    started.UTC(),
+		Finished:   time.Now().UTC(),
+	}
+	if runErr != nil {
+		summary.Error = runErr.Error()
+	}
+
+	if g == nil {
+		return summary
+	}
+
+	for _, meta := range g.Nodes() {
+		if graph.IsRoot(meta.ID) {
+			continue
+		}
+		res, ok := meta.Value().(result)
+		if !ok || module.IsModule(meta.Value()) {
+			continue
+		}
+
+		summary.Nodes++
+		if res.Error() != nil {
+			summary.Failed++
+		} else if res.HasChanges() {
+			summary.Changed++
+		}
+	}
+
+	return summary
+}
+
+// ModuleHash combines the hashes of the content of every module in the graph,
+// as recorded by the loader. It's empty if no hashes were recorded.
+func ModuleHash(g *graph.Graph) string {
+	if g == nil {
+		return ""
+	}
+
+	var hashes []string
+	for _, meta := range g.Nodes() {
+		if hash, ok := meta.LookupMetadata(node.MetaSourceHash); ok {
+			hashes = append(hashes, fmt.Sprintf("%s=%s", meta.ID, hash))
+		}
+	}
+	if len(hashes) == 0 {
+		return ""
+	}
+	sort.Strings(hashes)
+
+	sum := sha256.Sum256([]byte(strings.Join(hashes, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// unsafeKeyChars are replaced in the parts of a key
+var unsafeKeyChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Key is where a summary is published under prefix
+func Key(prefix string, summary *Summary) string {
+	module := strings.Trim(unsafeKeyChars.ReplaceAllString(summary.Location, "_"), "_")
+	host := strings.Trim(unsafeKeyChars.ReplaceAllString(summary.Host, "_"), "_")
+	return strings.TrimPrefix(path.Join(prefix, host, module), "/")
+}
+
+// Publisher publishes summaries
+type Publisher interface {
+	Publish(context.Context, *Summary) error
+}
+
+// New returns a publisher for a URL like consul://host:8500/prefix or
+// etcd://host:2379/prefix. Use consul+https or etcd+https to connect with
+// TLS. The Consul ACL token is read from CONSUL_HTTP_TOKEN.
+func New(raw string) (Publisher, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid publish URL")
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("publish URL %q has no host", raw)
+	}
+
+	store, scheme := u.Scheme, "http"
+	if strings.HasSuffix(store, "+https") {
+		store, scheme = strings.TrimSuffix(store, "+https"), "https"
+	}
+
+	base := &url.URL{Scheme: scheme, Host: u.Host}
+	prefix := strings.Trim(u.Path, "/")
+
+	switch store {
+	case "consul":
+		return &Consul{Base: base, Prefix: prefix, Token: os.Getenv("CONSUL_HTTP_TOKEN"), Client: http.DefaultClient}, nil
+	case "etcd":
+		return &Etcd{Base: base, Prefix: prefix, Client: http.DefaultClient}, nil
+	default:
+		return nil, fmt.Errorf("publish URL %q: unsupported store %q, expected consul or etcd", raw, u.Scheme)
+	}
+}
+
+// Consul publishes summaries to the Consul KV store
+type Consul struct {
+	Base   *url.URL
+	Prefix string
+	Token  string
+	Client *http.Client
+}
+
+// Publish writes the summary as JSON
+func (c *Consul) Publish(ctx context.Context, summary *Summary) error {
+	blob, err := json.Marshal(summary)
+	if err != nil {
+		return errors.Wrap(err, "could not serialize summary")
+	}
+
+	target := *c.Base
+	target.Path = "/v1/kv/" + Key(c.Prefix, summary)
+
+	req, err := http.NewRequest("PUT", target.String(), bytes.NewReader(blob))
+	if err != nil {
+		return err
+	}
+	if c.Token != "" {
+		req.Header.Set("X-Consul-Token", c.Token)
+	}
+
+	return send(ctx, c.Client, req)
+}
+
+// Etcd publishes summaries to etcd through its v3 JSON gateway
+type Etcd struct {
+	Base   *url.URL
+	Prefix string
+	Client *http.Client
+}
+
+// Publish writes the summary as JSON
+func (e *Etcd) Publish(ctx context.Context, summary *Summary) error {
+	blob, err := json.Marshal(summary)
+	if err != nil {
+		return errors.Wrap(err, "could not serialize summary")
+	}
+
+	// the gateway takes keys and values base64-encoded
+	body, err := json.Marshal(map[string]string{
+		"key":   base64.StdEncoding.EncodeToString([]byte(Key(e.Prefix, summary))),
+		"value": base64.StdEncoding.EncodeToString(blob),
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not serialize summary")
+	}
+
+	target := *e.Base
+	target.Path = "/v3/kv/put"
+
+	req, err := http.NewRequest("POST", target.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return send(ctx, e.Client, req)
+}
+
+// send sends a request, turning responses other than 200 into errors
+func send(ctx context.Context, client *http.Client, req *http.Request) error {
+	resp, err := ctxhttp.Do(ctx, client, req)
+	if err != nil {
+		return errors.Wrap(err, "could not publish summary")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("could not publish summary: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package publish_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/publish"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/module"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+type fakeResult struct {
+	changed bool
+	err     error
+	task    resource.Task
+}
+
+func (f *fakeResult) HasChanges() bool { return f.changed }
+func (f *fakeResult) Error() error     { return f.err }
+func (f *fakeResult) GetTask() (resource.Task, bool) {
+	return f.task, f.task != nil
+}
+func (f *fakeResult) GetStatus() resource.TaskStatus { return nil }
+
+func appliedGraph() *graph.Graph {
+	g := graph.New()
+
+	root := node.New("root", nil)
+	root.AddMetadata(node.MetaSourceHash, "aaaa")
+	g.Add(root)
+
+	mod := node.New("root/module.sub", &fakeResult{changed: true, task: &module.Module{}})
+	mod.AddMetadata(node.MetaSourceHash, "bbbb")
+	g.Add(mod)
+
+	g.Add(node.New("root/module.sub/file.content.a", &fakeResult{changed: true}))
+	g.Add(node.New("root/file.content.b", &fakeResult{}))
+	g.Add(node.New("root/file.content.c", &fakeResult{changed: true, err: errors.New("failed")}))
+
+	return g
+}
+
+// TestSummarize tests summarizing an applied graph
+func TestSummarize(t *testing.T) {
+	t.Parallel()
+
+	started := time.Now().Add(-time.Minute)
+	summary := publish.Summarize("web1", "site.hcl", appliedGraph(), started, nil)
+
+	assert.Equal(t, "web1", summary.Host)
+	assert.Equal(t, "site.hcl", summary.Location)
+	assert.Equal(t, 3, summary.Nodes)
+	assert.Equal(t, 1, summary.Changed)
+	assert.Equal(t, 1, summary.Failed)
+	assert.Equal(t, "", summary.Error)
+	assert.Equal(t, started.UTC(), summary.Started)
+	assert.False(t, summary.Finished.Before(summary.Started))
+	assert.Len(t, summary.ModuleHash, 64)
+
+	t.Run("failed run", func(t *testing.T) {
+		summary := publish.Summarize("web1", "site.hcl", nil, started, errors.New("could not load"))
+		assert.Equal(t, "could not load", summary.Error)
+		assert.Equal(t, 0, summary.Nodes)
+		assert.Equal(t, "", summary.ModuleHash)
+	})
+}
+
+// TestModuleHash tests that the module hash follows module content
+func TestModuleHash(t *testing.T) {
+	t.Parallel()
+
+	g := appliedGraph()
+	assert.Equal(t, publish.ModuleHash(g), publish.ModuleHash(appliedGraph()))
+
+	changed := graph.New()
+	root := node.New("root", nil)
+	root.AddMetadata(node.MetaSourceHash, "cccc")
+	changed.Add(root)
+	assert.NotEqual(t, publish.ModuleHash(g), publish.ModuleHash(changed))
+
+	assert.Equal(t, "", publish.ModuleHash(graph.New()))
+}
+
+// TestKey tests where summaries are published
+func TestKey(t *testing.T) {
+	t.Parallel()
+
+	summary := &publish.Summary{Host: "web1.example.com", Location: "/etc/converge/site.hcl"}
+	assert.Equal(t, "converge/web1.example.com/etc_converge_site.hcl", publish.Key("converge", summary))
+	assert.Equal(t, "web1.example.com/etc_converge_site.hcl", publish.Key("", summary))
+
+	summary.Location = "https://example.com/modules/site.hcl"
+	assert.Equal(t, "converge/web1.example.com/https_example.com_modules_site.hcl", publish.Key("converge", summary))
+}
+
+// TestNew tests parsing publish URLs
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	t.Run("consul", func(t *testing.T) {
+		p, err := publish.New("consul://127.0.0.1:8500/converge/runs")
+		require.NoError(t, err)
+		consul, ok := p.(*publish.Consul)
+		require.True(t, ok)
+		assert.Equal(t, "http://127.0.0.1:8500", consul.Base.String())
+		assert.Equal(t, "converge/runs", consul.Prefix)
+	})
+
+	t.Run("etcd over https", func(t *testing.T) {
+		p, err := publish.New("etcd+https://etcd.example.com:2379/converge")
+		require.NoError(t, err)
+		etcd, ok := p.(*publish.Etcd)
+		require.True(t, ok)
+		assert.Equal(t, "https://etcd.example.com:2379", etcd.Base.String())
+		assert.Equal(t, "converge", etcd.Prefix)
+	})
+
+	t.Run("unsupported store", func(t *testing.T) {
+		_, err := publish.New("redis://127.0.0.1:6379")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unsupported store "redis"`)
+	})
+
+	t.Run("no host", func(t *testing.T) {
+		_, err := publish.New("consul:///converge")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "has no host")
+	})
+}
+
+// TestPublish tests publishing to the stores
+func TestPublish(t *testing.T) {
+	t.Parallel()
+
+	summary := &publish.Summary{Host: "web1", Location: "site.hcl", Nodes: 3, Changed: 1}
+
+	t.Run("consul", func(t *testing.T) {
+		var method, path, token string
+		var got publish.Summary
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method, path, token = r.Method, r.URL.Path, r.Header.Get("X-Consul-Token")
+			json.NewDecoder(r.Body).Decode(&got)
+			w.Write([]byte("true"))
+		}))
+		defer srv.Close()
+
+		p, err := publish.New(strings.Replace(srv.URL, "http", "consul", 1) + "/converge")
+		require.NoError(t, err)
+		p.(*publish.Consul).Token = "secret"
+
+		require.NoError(t, p.Publish(context.Background(), summary))
+		assert.Equal(t, "PUT", method)
+		assert.Equal(t, "/v1/kv/converge/web1/site.hcl", path)
+		assert.Equal(t, "secret", token)
+		assert.Equal(t, *summary, got)
+	})
+
+	t.Run("etcd", func(t *testing.T) {
+		var path string
+		var body map[string]string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			json.NewDecoder(r.Body).Decode(&body)
+			w.Write([]byte("{}"))
+		}))
+		defer srv.Close()
+
+		p, err := publish.New(strings.Replace(srv.URL, "http", "etcd", 1) + "/converge")
+		require.NoError(t, err)
+
+		require.NoError(t, p.Publish(context.Background(), summary))
+		assert.Equal(t, "/v3/kv/put", path)
+
+		key, err := base64.StdEncoding.DecodeString(body["key"])
+		require.NoError(t, err)
+		assert.Equal(t, "converge/web1/site.hcl", string(key))
+
+		value, err := base64.StdEncoding.DecodeString(body["value"])
+		require.NoError(t, err)
+		var got publish.Summary
+		require.NoError(t, json.Unmarshal(value, &got))
+		assert.Equal(t, *summary, got)
+	})
+
+	t.Run("error response", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Permission denied", http.StatusForbidden)
+		}))
+		defer srv.Close()
+
+		p, err := publish.New(strings.Replace(srv.URL, "http", "consul", 1))
+		require.NoError(t, err)
+
+		err = p.Publish(context.Background(), summary)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "403 Forbidden: Permission denied")
+	})
+}
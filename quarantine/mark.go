@@ -0,0 +1,88 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quarantine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/resource"
+	"golang.org/x/net/context"
+)
+
+// Mark replaces the quarantined nodes of a loaded graph with tasks that report
+// they were skipped. Params, modules and switch macros are kept, since other
+// nodes are rendered with them.
+func Mark(ctx context.Context, g *graph.Graph, patterns map[string]string) (*graph.Graph, error) {
+	logger := logging.GetLogger(ctx).WithField("function", "quarantine.Mark")
+
+	return g.Transform(ctx, func(meta *node.Node, out *graph.Graph) error {
+		if graph.IsRoot(meta.ID) || keep(meta) {
+			return nil
+		}
+
+		pattern, ok := Match(patterns, meta.ID)
+		if !ok {
+			return nil
+		}
+
+		logger.WithField("id", meta.ID).WithField("pattern", pattern).Info("quarantined")
+		out.Add(meta.WithValue(&Skipped{Pattern: pattern, Reason: patterns[pattern]}))
+		return nil
+	})
+}
+
+// keep is true for nodes that are needed to render other nodes
+func keep(meta *node.Node) bool {
+	base := graph.BaseID(meta.ID)
+	return strings.HasPrefix(base, "module.") ||
+		strings.HasPrefix(base, "param.") ||
+		strings.HasPrefix(base, "macro.")
+}
+
+// Skipped takes the place of a quarantined node
+type Skipped struct {
+	Pattern string
+	Reason  string
+}
+
+// Prepare returns the skipped node as its own task
+func (s *Skipped) Prepare(context.Context, resource.Renderer) (resource.Task, error) {
+	return s, nil
+}
+
+// Check reports that the node was skipped
+func (s *Skipped) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	return s.skip("checked"), nil
+}
+
+// Apply reports that the node was skipped
+func (s *Skipped) Apply(context.Context) (resource.TaskStatus, error) {
+	return s.skip("applied"), nil
+}
+
+func (s *Skipped) skip(verb string) *resource.Status {
+	message := fmt.Sprintf("not %s because it is quarantined by %q", verb, s.Pattern)
+	if s.Reason != "" {
+		message += ": " + s.Reason
+	}
+
+	status := resource.NewStatus()
+	status.AddWarning(resource.WarningQuarantined, message)
+	return status
+}
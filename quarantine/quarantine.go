@@ -0,0 +1,181 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package quarantine keeps automation away from hosts and resources during
+// incidents. A quarantine file lists entries like:
+//
+//	quarantine {
+//	  reason    = "INC-1234: disk pressure"
+//	  hosts     = ["web3"]
+//	  resources = ["root/task.deploy", "root/module.app"]
+//	}
+//
+// An entry with only hosts quarantines those hosts entirely. An entry with only
+// resources quarantines those resources on every host, and an entry with both
+// quarantines the resources on those hosts. Hosts and resources are shell
+// patterns, and a resource pattern matching a module also covers everything
+// in it.
+package quarantine
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/parser"
+	"github.com/pkg/errors"
+)
+
+// Entry quarantines hosts, resources, or resources on some hosts
+type Entry struct {
+	Reason    string   `hcl:"reason"`
+	Hosts     []string `hcl:"hosts"`
+	Resources []string `hcl:"resources"`
+}
+
+// List is a set of quarantine entries
+type List struct {
+	Entries []*Entry
+}
+
+// Load reads a quarantine file. A missing file is an empty list, so hosts can
+// be pointed at a file that only exists during an incident.
+func Load(filename string) (*List, error) {
+	content, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return new(List), nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not read quarantine")
+	}
+
+	return ParseFile(content, filename)
+}
+
+// Parse parses the content of a quarantine file
+func Parse(content []byte) (*List, error) {
+	return ParseFile(content, "")
+}
+
+// ParseFile parses the content of a quarantine file, reporting errors at
+// file:line:column
+func ParseFile(content []byte, filename string) (*List, error) {
+	file, err := hcl.ParseBytes(content)
+	if err != nil {
+		if posErr, ok := err.(*parser.PosError); ok && filename != "" {
+			posErr.Pos.Filename = filename
+		}
+		return nil, err
+	}
+
+	items, ok := file.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, errors.New("quarantine must be a list of blocks")
+	}
+
+	list := new(List)
+	for _, item := range items.Items {
+		pos := item.Pos()
+		pos.Filename = filename
+
+		if len(item.Keys) != 1 || item.Keys[0].Token.Value() != "quarantine" {
+			return nil, fmt.Errorf("%s: expected quarantine block", pos)
+		}
+
+		entry := new(Entry)
+		if err := hcl.DecodeObject(entry, item.Val); err != nil {
+			return nil, errors.Wrapf(err, "%s: quarantine", pos)
+		}
+		if len(entry.Hosts) == 0 && len(entry.Resources) == 0 {
+			return nil, fmt.Errorf("%s: quarantine needs hosts or resources", pos)
+		}
+		for _, pattern := range append(entry.Hosts, entry.Resources...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("%s: quarantine has bad pattern %q", pos, pattern)
+			}
+		}
+
+		list.Entries = append(list.Entries, entry)
+	}
+
+	return list, nil
+}
+
+// Host returns the entry quarantining a host entirely, if there is one
+func (l *List) Host(host string) (*Entry, bool) {
+	for _, entry := range l.Entries {
+		if len(entry.Resources) == 0 && matchAny(entry.Hosts, host) {
+			return entry, true
+		}
+	}
+	return nil, false
+}
+
+// Resources returns the resource patterns quarantined on a host, with the
+// reason for each. A quarantined host has every resource quarantined.
+func (l *List) Resources(host string) map[string]string {
+	patterns := map[string]string{}
+
+	if entry, ok := l.Host(host); ok {
+		patterns[Everything] = entry.Reason
+	}
+
+	for _, entry := range l.Entries {
+		if len(entry.Hosts) > 0 && !matchAny(entry.Hosts, host) {
+			continue
+		}
+		for _, pattern := range entry.Resources {
+			if _, ok := patterns[pattern]; !ok {
+				patterns[pattern] = entry.Reason
+			}
+		}
+	}
+
+	return patterns
+}
+
+// Everything is the pattern that quarantines every resource
+const Everything = "root"
+
+// Match returns the pattern that quarantines the node with the given ID, if
+// any. A pattern matching one of the node's parents matches the node too.
+func Match(patterns map[string]string, id string) (string, bool) {
+	sorted := make([]string, 0, len(patterns))
+	for pattern := range patterns {
+		sorted = append(sorted, pattern)
+	}
+	sort.Strings(sorted)
+
+	for ; id != "." && id != "/"; id = graph.ParentID(id) {
+		for _, pattern := range sorted {
+			if ok, _ := path.Match(pattern, id); ok {
+				return pattern, true
+			}
+		}
+	}
+	return "", false
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, s); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quarantine_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/quarantine"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+const sample = `
+quarantine {
+  reason = "INC-1: disk pressure"
+  hosts  = ["web3"]
+}
+
+quarantine {
+  reason    = "INC-2: bad deploy"
+  resources = ["root/module.app"]
+}
+
+quarantine {
+  reason    = "INC-3: flapping"
+  hosts     = ["db*"]
+  resources = ["root/task.restart"]
+}
+`
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		list, err := quarantine.Parse([]byte(sample))
+		require.NoError(t, err)
+		require.Len(t, list.Entries, 3)
+		assert.Equal(t, "INC-1: disk pressure", list.Entries[0].Reason)
+		assert.Equal(t, []string{"db*"}, list.Entries[2].Hosts)
+	})
+
+	t.Run("empty entry", func(t *testing.T) {
+		_, err := quarantine.Parse([]byte(`quarantine { reason = "x" }`))
+		assert.EqualError(t, err, "1:1: quarantine needs hosts or resources")
+	})
+
+	t.Run("other block", func(t *testing.T) {
+		_, err := quarantine.Parse([]byte(`hosts = ["web1"]`))
+		assert.EqualError(t, err, "1:1: expected quarantine block")
+	})
+
+	t.Run("bad pattern", func(t *testing.T) {
+		_, err := quarantine.Parse([]byte(`quarantine { hosts = ["[web"] }`))
+		assert.EqualError(t, err, `1:1: quarantine has bad pattern "[web"`)
+	})
+}
+
+func TestLoadMissing(t *testing.T) {
+	t.Parallel()
+
+	list, err := quarantine.Load("/this/file/does/not/exist.hcl")
+	require.NoError(t, err)
+	assert.Empty(t, list.Entries)
+}
+
+func TestListHost(t *testing.T) {
+	t.Parallel()
+
+	list, err := quarantine.Parse([]byte(sample))
+	require.NoError(t, err)
+
+	entry, ok := list.Host("web3")
+	require.True(t, ok)
+	assert.Equal(t, "INC-1: disk pressure", entry.Reason)
+
+	// db hosts only have resources quarantined
+	_, ok = list.Host("db1")
+	assert.False(t, ok)
+}
+
+func TestListResources(t *testing.T) {
+	t.Parallel()
+
+	list, err := quarantine.Parse([]byte(sample))
+	require.NoError(t, err)
+
+	assert.Equal(
+		t,
+		map[string]string{"root/module.app": "INC-2: bad deploy"},
+		list.Resources("web1"),
+	)
+	assert.Equal(
+		t,
+		map[string]string{
+			"root/module.app":   "INC-2: bad deploy",
+			"root/task.restart": "INC-3: flapping",
+		},
+		list.Resources("db1"),
+	)
+	assert.Equal(
+		t,
+		map[string]string{
+			quarantine.Everything: "INC-1: disk pressure",
+			"root/module.app":     "INC-2: bad deploy",
+		},
+		list.Resources("web3"),
+	)
+}
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	patterns := map[string]string{
+		"root/module.app": "",
+		"root/task.*":     "",
+	}
+
+	pattern, ok := quarantine.Match(patterns, "root/module.app/file.content.x")
+	assert.True(t, ok)
+	assert.Equal(t, "root/module.app", pattern)
+
+	pattern, ok = quarantine.Match(patterns, "root/task.restart")
+	assert.True(t, ok)
+	assert.Equal(t, "root/task.*", pattern)
+
+	_, ok = quarantine.Match(patterns, "root/module.other/task.x")
+	assert.False(t, ok)
+}
+
+func TestMark(t *testing.T) {
+	t.Parallel()
+
+	g := graph.New()
+	g.Add(node.New("root", nil))
+	g.Add(node.New("root/module.app", "module"))
+	g.Add(node.New("root/module.app/param.x", "param"))
+	g.Add(node.New("root/module.app/task.x", "task"))
+	g.Add(node.New("root/task.y", "task"))
+
+	g.ConnectParent("root", "root/module.app")
+	g.ConnectParent("root", "root/task.y")
+	g.ConnectParent("root/module.app", "root/module.app/param.x")
+	g.ConnectParent("root/module.app", "root/module.app/task.x")
+
+	marked, err := quarantine.Mark(
+		context.Background(),
+		g,
+		map[string]string{"root/module.app": "INC-2"},
+	)
+	require.NoError(t, err)
+
+	for id, value := range map[string]interface{}{
+		"root/module.app":         "module",
+		"root/module.app/param.x": "param",
+		"root/task.y":             "task",
+	} {
+		meta, ok := marked.Get(id)
+		require.True(t, ok, id)
+		assert.Equal(t, value, meta.Value(), id)
+	}
+
+	meta, ok := marked.Get("root/module.app/task.x")
+	require.True(t, ok)
+	skipped, ok := meta.Value().(*quarantine.Skipped)
+	require.True(t, ok)
+
+	status, err := skipped.Check(context.Background(), nil)
+	require.NoError(t, err)
+	assert.False(t, status.HasChanges())
+	assert.Equal(
+		t,
+		[]resource.Warning{{
+			Kind:    resource.WarningQuarantined,
+			Message: `not checked because it is quarantined by "root/module.app": INC-2`,
+		}},
+		status.(*resource.Status).Warnings(),
+	)
+}
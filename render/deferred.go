@@ -0,0 +1,87 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/asteris-llc/converge/resource"
+)
+
+// KnownAfterApply is rendered in place of lookups that are deferred until the
+// node they refer to has been applied
+const KnownAfterApply = "(known after apply)"
+
+// Deferrer is implemented by planned nodes whose fields were deferred, so
+// lookups of them are deferred too
+type Deferrer interface {
+	DeferredFields() []string
+}
+
+// deferrals records which fields of which nodes rendered deferred lookups
+type deferrals struct {
+	lock   sync.Mutex
+	fields map[string][]string
+}
+
+func newDeferrals() *deferrals {
+	return &deferrals{fields: map[string][]string{}}
+}
+
+func (d *deferrals) add(id, field string) {
+	if d == nil {
+		return
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for _, existing := range d.fields[id] {
+		if existing == field {
+			return
+		}
+	}
+	d.fields[id] = append(d.fields[id], field)
+}
+
+func (d *deferrals) get(id string) []string {
+	if d == nil {
+		return nil
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return append([]string(nil), d.fields[id]...)
+}
+
+// shouldDefer is true if a lookup of an exported value in a node can only be
+// known after the node is applied: the node is deferred itself, or it will
+// change and its task computes the value when it is applied
+func shouldDefer(node interface{}, task resource.Task, status resource.TaskStatus, term string) bool {
+	if deferrer, ok := node.(Deferrer); ok && len(deferrer.DeferredFields()) > 0 {
+		return true
+	}
+
+	computed, ok := task.(resource.ComputedReporter)
+	if !ok || !status.HasChanges() {
+		return false
+	}
+	for _, field := range computed.ComputedFields() {
+		if term == field || strings.HasPrefix(term, field+".") {
+			return true
+		}
+	}
+	return false
+}
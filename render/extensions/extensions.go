@@ -172,10 +172,16 @@ func (l *LanguageExtension) Validate() (missingKeywords []string, extraKeywords
 // output into the provided io.Writer.  If any error is returned at any point it
 // is passed on to the user.
 func (l *LanguageExtension) Render(dotValues interface{}, name, toRender string) (bytes.Buffer, error) {
+	return l.RenderWithOptions(dotValues, name, toRender)
+}
+
+// RenderWithOptions is Render, with text/template options like
+// "missingkey=error" set on the template
+func (l *LanguageExtension) RenderWithOptions(dotValues interface{}, name, toRender string, options ...string) (bytes.Buffer, error) {
 	l.innerLock.Lock()
 	defer l.innerLock.Unlock()
 	var output bytes.Buffer
-	tmpl, err := template.New(name).Funcs(l.Funcs).Parse(toRender)
+	tmpl, err := template.New(name).Option(options...).Funcs(l.Funcs).Parse(toRender)
 	if err != nil {
 		return output, err
 	}
@@ -0,0 +1,59 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package platform
+
+import (
+	"os"
+	"runtime"
+)
+
+// Init systems that DetectInitSystem recognizes
+const (
+	InitSystemd  = "systemd"
+	InitLaunchd  = "launchd"
+	InitOpenRC   = "openrc"
+	InitSysVInit = "sysvinit"
+)
+
+// DetectInitSystem finds the init system managing services on this host. It
+// returns an empty string if the init system isn't recognized.
+func DetectInitSystem() string {
+	return detectInitSystem(runtime.GOOS, exists)
+}
+
+func detectInitSystem(goos string, exists func(string) bool) string {
+	switch goos {
+	case "darwin":
+		if exists("/bin/launchctl") {
+			return InitLaunchd
+		}
+	case "linux":
+		switch {
+		// systemd creates this directory when it boots the host
+		case exists("/run/systemd/system"):
+			return InitSystemd
+		case exists("/run/openrc") || exists("/sbin/openrc-run"):
+			return InitOpenRC
+		case exists("/etc/init.d"):
+			return InitSysVInit
+		}
+	}
+	return ""
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
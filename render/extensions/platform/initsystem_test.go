@@ -0,0 +1,45 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package platform
+
+import "testing"
+
+func TestDetectInitSystem(t *testing.T) {
+	cases := []struct {
+		goos     string
+		paths    []string
+		expected string
+	}{
+		{"linux", []string{"/run/systemd/system", "/etc/init.d"}, InitSystemd},
+		{"linux", []string{"/run/openrc", "/etc/init.d"}, InitOpenRC},
+		{"linux", []string{"/sbin/openrc-run"}, InitOpenRC},
+		{"linux", []string{"/etc/init.d"}, InitSysVInit},
+		{"linux", nil, ""},
+		{"darwin", []string{"/bin/launchctl"}, InitLaunchd},
+		{"freebsd", []string{"/etc/init.d"}, ""},
+	}
+
+	for _, c := range cases {
+		present := map[string]bool{}
+		for _, path := range c.paths {
+			present[path] = true
+		}
+
+		actual := detectInitSystem(c.goos, func(path string) bool { return present[path] })
+		if actual != c.expected {
+			t.Errorf("detectInitSystem(%q, %v): wanted %q, got %q", c.goos, c.paths, c.expected, actual)
+		}
+	}
+}
@@ -20,7 +20,9 @@ import "runtime"
 // Platform is a struct containing version information for the
 // underlying operating system
 type Platform struct {
+	Arch              string
 	Build             string
+	InitSystem        string
 	OS                string
 	LinuxDistribution string
 	LinuxLSBLike      []string
@@ -35,6 +37,8 @@ func DefaultPlatform() (*Platform, error) {
 	var platform Platform
 	var err error
 	platform.OS = runtime.GOOS
+	platform.Arch = runtime.GOARCH
+	platform.InitSystem = DetectInitSystem()
 	switch platform.OS {
 	case "darwin":
 		err = platform.OSXVers()
@@ -20,6 +20,7 @@ import (
 	"strings"
 
 	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/render/extensions"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/module"
@@ -31,6 +32,27 @@ type Factory struct {
 	Graph     *graph.Graph
 	DotValues map[string]*LazyValue
 	Language  *extensions.LanguageExtension
+
+	// Strict is passed on to Renderers
+	Strict bool
+
+	// DeferLookups renders lookups of values that will only exist once the
+	// node they refer to is applied as KnownAfterApply, recording the fields
+	// they were in. It is set when planning without applying.
+	DeferLookups bool
+
+	deferrals  *deferrals
+	provenance *provenance
+}
+
+// Deferred returns the fields of a node that rendered deferred lookups
+func (f *Factory) Deferred(id string) []string {
+	return f.deferrals.get(id)
+}
+
+// Provenance returns where the values rendered into a node's fields came from
+func (f *Factory) Provenance(id string) []resource.Origin {
+	return f.provenance.get(id)
 }
 
 // ValueThunk lazily evaluates a param
@@ -62,7 +84,17 @@ func (v *LazyValue) Value() (resource.Value, bool, error) {
 
 // GetRenderer returns a Factory for the specific graph node
 func (f *Factory) GetRenderer(id string) (*Renderer, error) {
-	r := &Renderer{Language: f.Language, Graph: func() *graph.Graph { return f.Graph }, ID: id}
+	r := &Renderer{
+		Language:     f.Language,
+		Graph:        func() *graph.Graph { return f.Graph },
+		ID:           id,
+		Strict:       f.Strict,
+		deferLookups: f.DeferLookups,
+		deferrals:    f.deferrals,
+		provenance:   f.provenance,
+	}
+	r.source = moduleSource(f.Graph, id)
+	r.root = moduleSource(f.Graph, "root")
 	if dotVal, found := f.DotValues[id]; found {
 		if valResult, valFound, err := dotVal.Value(); err != nil {
 			return nil, err
@@ -74,12 +106,34 @@ func (f *Factory) GetRenderer(id string) (*Renderer, error) {
 	return r, nil
 }
 
+// moduleSource finds the URL of the module a node was loaded from by walking
+// up to the nearest ancestor with a recorded source
+func moduleSource(g *graph.Graph, id string) string {
+	if g == nil {
+		return ""
+	}
+	if meta, ok := g.Get(id); ok {
+		if src, ok := meta.LookupMetadata(node.MetaSource); ok {
+			if url, ok := src.(string); ok {
+				return url
+			}
+		}
+	}
+	if graph.IsRoot(id) || graph.ParentID(id) == "." {
+		return ""
+	}
+	return moduleSource(g, graph.ParentID(id))
+}
+
 // NewFactory generates a new Render factory
 func NewFactory(ctx context.Context, g *graph.Graph) (*Factory, error) {
 	f := &Factory{
-		Graph:     g,
-		Language:  extensions.DefaultLanguage(),
-		DotValues: make(map[string]*LazyValue),
+		Graph:      g,
+		Language:   extensions.DefaultLanguage(),
+		DotValues:  make(map[string]*LazyValue),
+		Strict:     IsStrict(ctx),
+		deferrals:  newDeferrals(),
+		provenance: newProvenance(),
 	}
 
 	for _, vertex := range g.Vertices() {
@@ -15,6 +15,7 @@
 package preprocessor
 
 import (
+	"sort"
 	"strings"
 
 	"github.com/asteris-llc/converge/parse"
@@ -69,6 +70,46 @@ func VertexSplitTraverse(g *graph.Graph, toFind string, startingNode string, sto
 	return VertexSplitTraverse(g, toFind, parentID, stop, history)
 }
 
+// VertexCandidates returns every vertex that toFind could refer to from
+// startingNode, in the places VertexSplitTraverse looks. Each level from
+// startingNode up to the first stop is searched in turn: a match directly
+// under the level wins, otherwise the nearest match under each of its
+// children counts. VertexSplitTraverse picks one of them, so more than one
+// candidate means the reference is ambiguous.
+func VertexCandidates(g *graph.Graph, toFind string, startingNode string, stop func(*graph.Graph, string) bool) []string {
+	searched := map[string]struct{}{}
+
+	for level := startingNode; ; level = graph.ParentID(level) {
+		candidates := levelCandidates(g, toFind, level, stop, searched)
+		if len(candidates) > 0 {
+			sort.Strings(candidates)
+			return candidates
+		}
+
+		if stop(g, level) || graph.ParentID(level) == level {
+			return nil
+		}
+	}
+}
+
+// levelCandidates finds the matches for toFind under id, skipping subtrees
+// that have already been searched
+func levelCandidates(g *graph.Graph, toFind, id string, stop func(*graph.Graph, string) bool, searched map[string]struct{}) []string {
+	if vertex, _, found := VertexSplit(g, graph.ID(id, toFind)); found {
+		return []string{vertex}
+	}
+	searched[id] = struct{}{}
+
+	var candidates []string
+	for _, child := range g.Children(id) {
+		if _, ok := searched[child]; ok || stop(g, child) {
+			continue
+		}
+		candidates = append(candidates, levelCandidates(g, toFind, child, stop, searched)...)
+	}
+	return candidates
+}
+
 // TraverseUntilModule is a function intended to be used with
 // VertexSplitTraverse and will cause vertex splitting to propogate upwards
 // until it encounters a module
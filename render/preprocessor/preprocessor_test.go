@@ -66,3 +66,60 @@ func TestVertexSplit(t *testing.T) {
 		assert.False(t, found)
 	})
 }
+
+// TestVertexCandidates ensures that every vertex a reference could resolve to
+// is found
+func TestVertexCandidates(t *testing.T) {
+	t.Parallel()
+
+	g := graph.New()
+	for _, id := range []string{
+		"root",
+		"root/task.q",
+		"root/task.z",
+		"root/macro.switch.s",
+		"root/macro.switch.s/macro.case.a",
+		"root/macro.switch.s/macro.case.a/task.q",
+		"root/macro.switch.s/macro.case.a/task.y",
+		"root/macro.switch.s/macro.case.b",
+		"root/macro.switch.s/macro.case.b/task.y",
+	} {
+		g.Add(node.New(id, id))
+		if !graph.IsRoot(id) {
+			g.ConnectParent(graph.ParentID(id), id)
+		}
+	}
+
+	t.Run("ambiguous", func(t *testing.T) {
+		assert.Equal(
+			t,
+			[]string{"root/macro.switch.s/macro.case.a/task.y", "root/macro.switch.s/macro.case.b/task.y"},
+			preprocessor.VertexCandidates(g, "task.y.status", "root/task.z", preprocessor.TraverseUntilModule),
+		)
+	})
+
+	t.Run("nearest wins", func(t *testing.T) {
+		assert.Equal(
+			t,
+			[]string{"root/task.q"},
+			preprocessor.VertexCandidates(g, "task.q", "root/task.z", preprocessor.TraverseUntilModule),
+		)
+		assert.Equal(
+			t,
+			[]string{"root/macro.switch.s/macro.case.a/task.q"},
+			preprocessor.VertexCandidates(g, "task.q", "root/macro.switch.s/macro.case.a/task.y", preprocessor.TraverseUntilModule),
+		)
+	})
+
+	t.Run("path", func(t *testing.T) {
+		assert.Equal(
+			t,
+			[]string{"root/macro.switch.s/macro.case.b/task.y"},
+			preprocessor.VertexCandidates(g, "macro.switch.s/macro.case.b/task.y.status", "root/task.z", preprocessor.TraverseUntilModule),
+		)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		assert.Nil(t, preprocessor.VertexCandidates(g, "task.x", "root/task.z", preprocessor.TraverseUntilModule))
+	})
+}
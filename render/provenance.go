@@ -0,0 +1,145 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync"
+
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/render/extensions"
+	"github.com/asteris-llc/converge/render/extensions/platform"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// provenance records where the values rendered into the fields of each node
+// came from
+type provenance struct {
+	lock    sync.Mutex
+	origins map[string][]resource.Origin
+}
+
+func newProvenance() *provenance {
+	return &provenance{origins: map[string][]resource.Origin{}}
+}
+
+// set replaces the origins of a field, since a field is rendered again when a
+// thunk is evaluated or a deferred node is planned again
+func (p *provenance) set(id, field string, origins []resource.Origin) {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var kept []resource.Origin
+	for _, origin := range p.origins[id] {
+		if origin.Field != field {
+			kept = append(kept, origin)
+		}
+	}
+	p.origins[id] = append(kept, origins...)
+}
+
+func (p *provenance) get(id string) []resource.Origin {
+	if p == nil {
+		return nil
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return append([]resource.Origin(nil), p.origins[id]...)
+}
+
+// RecordProvenance adds where the values of a node's fields came from to its
+// metadata, unless it was recorded already. The values of the task's
+// sensitive fields are redacted.
+func RecordProvenance(meta *node.Node, origins []resource.Origin) {
+	if len(origins) == 0 {
+		return
+	}
+	if _, found := meta.LookupMetadata(node.MetaProvenance); found {
+		return
+	}
+	if task, ok := resource.ResolveTask(meta.Value()); ok {
+		if reporter, ok := task.(resource.SensitiveReporter); ok {
+			origins = resource.Redact(origins, reporter.SensitiveFields())
+		}
+	}
+	meta.AddMetadata(node.MetaProvenance, origins)
+}
+
+// Provenance gets where the values of a node's fields came from, from its
+// metadata
+func Provenance(meta *node.Node) []resource.Origin {
+	recorded, ok := meta.LookupMetadata(node.MetaProvenance)
+	if !ok {
+		return nil
+	}
+	origins, _ := recorded.([]resource.Origin)
+	return origins
+}
+
+// record adds an origin of the field being rendered
+func (r *Renderer) record(kind resource.OriginKind, name, node, value string) {
+	origin := resource.Origin{Field: r.field, Kind: kind, Name: name, Node: node, Value: value}
+	for _, existing := range r.origins {
+		if existing == origin {
+			return
+		}
+	}
+	r.origins = append(r.origins, origin)
+}
+
+func (r *Renderer) env(name string) string {
+	val := extensions.DefaultEnv(name)
+	r.record(resource.OriginEnv, name, "", val)
+	return val
+}
+
+func (r *Renderer) platform() (*platform.Platform, error) {
+	facts, err := platform.DefaultPlatform()
+	r.facts = facts
+	return facts, err
+}
+
+// platformField finds the fields of the platform used in a template
+var platformField = regexp.MustCompile(`platform\.([A-Za-z]+)`)
+
+// recordPlatform records the platform facts used in a template. Templates get
+// the whole platform, so the facts used are found by looking for its fields
+// in the source.
+func (r *Renderer) recordPlatform(src string) {
+	if r.facts == nil {
+		return
+	}
+
+	facts := reflect.ValueOf(*r.facts)
+	found := false
+	for _, match := range platformField.FindAllStringSubmatch(src, -1) {
+		field := facts.FieldByName(match[1])
+		if !field.IsValid() {
+			continue
+		}
+		r.record(resource.OriginPlatform, "platform."+match[1], "", fmt.Sprintf("%v", field.Interface()))
+		found = true
+	}
+
+	if !found {
+		r.record(resource.OriginPlatform, "platform", "", r.facts.OS+"/"+r.facts.Arch)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"crypto/rand"
 	"fmt"
 
+	"github.com/asteris-llc/converge/errcode"
 	"github.com/asteris-llc/converge/executor"
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
@@ -42,14 +43,37 @@ func Render(ctx context.Context, g *graph.Graph, top Values) (*graph.Graph, erro
 		pipeline := Pipeline(out, meta.ID, renderingPlant, top)
 		value, err := pipeline.Exec(ctx, meta.Value())
 		if err != nil {
-			return err
+			return errcode.Wrap(codeFor(err), err)
 		}
-		out.Add(meta.WithValue(value))
+		rendered := meta.WithValue(value)
+		if _, isThunk := value.(*PrepareThunk); !isThunk {
+			RecordProvenance(rendered, renderingPlant.Provenance(meta.ID))
+		}
+		if reporter, ok := meta.Value().(resource.WarningReporter); ok {
+			if _, found := rendered.LookupMetadata(node.MetaWarnings); !found && len(reporter.Warnings()) > 0 {
+				rendered.AddMetadata(node.MetaWarnings, reporter.Warnings())
+			}
+		}
+		out.Add(rendered)
 		renderingPlant.Graph = out
 		return nil
 	})
 }
 
+// codeFor classifies a rendering error
+func codeFor(err error) errcode.Code {
+	switch errors.Cause(err).(type) {
+	case ErrBadTemplate:
+		return errcode.RenderBadTemplate
+	case ErrUnresolvable:
+		return errcode.RenderUnresolvable
+	case ErrStrict:
+		return errcode.RenderStrict
+	default:
+		return errcode.RenderFailed
+	}
+}
+
 type pipelineGen struct {
 	Graph          *graph.Graph
 	RenderingPlant *Factory
@@ -16,11 +16,14 @@ package render_test
 
 import (
 	"fmt"
+	"os"
+	"runtime"
 	"testing"
 
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/graph/node/conditional"
+	"github.com/asteris-llc/converge/helpers/faketask"
 	"github.com/asteris-llc/converge/helpers/logging"
 	"github.com/asteris-llc/converge/helpers/testing/graphutils"
 	"github.com/asteris-llc/converge/helpers/testing/hclutils"
@@ -219,3 +222,201 @@ task.query "b" {
 		assert.Equal(t, "true", strValue)
 	})
 }
+
+// renamed is a resource whose "destination" had an older name
+type renamed struct {
+	Destination string `hcl:"destination"`
+}
+
+func (r *renamed) Prepare(context.Context, resource.Renderer) (resource.Task, error) {
+	return faketask.NoOp(), nil
+}
+
+func (r *renamed) Migrations() []resource.Migration {
+	return []resource.Migration{{Field: "path", RenamedTo: "destination"}}
+}
+
+func TestRenderMigrationWarnings(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	g := graph.New()
+	g.Add(node.New(
+		"root/file.content.x",
+		resource.NewPreparerWithSource(
+			&renamed{},
+			map[string]interface{}{"path": "x"},
+		),
+	))
+
+	rendered, err := render.Render(context.Background(), g, render.Values{})
+	require.NoError(t, err)
+
+	meta, ok := rendered.Get("root/file.content.x")
+	require.True(t, ok)
+
+	warnings, ok := meta.LookupMetadata(node.MetaWarnings)
+	require.True(t, ok)
+	assert.Equal(
+		t,
+		[]resource.Warning{{Kind: resource.WarningDeprecation, Message: `"path" is deprecated, use "destination" instead`}},
+		warnings,
+	)
+}
+
+func TestRenderStrict(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	strictGraph := func(src string) *graph.Graph {
+		g := graph.New()
+		g.Add(node.New("root", nil))
+		g.Add(node.New(
+			"root/file.content.x",
+			resource.NewPreparerWithSource(
+				new(content.Preparer),
+				map[string]interface{}{"destination": "x", "content": src},
+			),
+		))
+		g.Add(node.New(
+			"root/param.m",
+			resource.NewPreparerWithSource(
+				new(param.Preparer),
+				map[string]interface{}{"default": map[string]interface{}{"a": "1"}},
+			),
+		))
+
+		g.ConnectParent("root", "root/file.content.x")
+		g.ConnectParent("root", "root/param.m")
+		g.Connect("root/file.content.x", "root/param.m")
+		return g
+	}
+
+	t.Run("lenient", func(t *testing.T) {
+		rendered, err := render.Render(context.Background(), strictGraph("{{(paramMap `m`).b}}"), render.Values{})
+		require.NoError(t, err)
+
+		meta, ok := rendered.Get("root/file.content.x")
+		require.True(t, ok)
+		assert.Equal(t, "<no value>", meta.Value().(*resource.TaskWrapper).Task.(*content.Content).Content)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		ctx := render.WithStrict(context.Background(), true)
+		_, err := render.Render(ctx, strictGraph("{{(paramMap `m`).b}}"), render.Values{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "root/file.content.x: \"{{(paramMap `m`).b}}\"")
+		assert.Contains(t, err.Error(), `map has no entry for key "b"`)
+	})
+
+	t.Run("no value", func(t *testing.T) {
+		ctx := render.WithStrict(context.Background(), true)
+		_, err := render.Render(ctx, strictGraph("{{index (paramMap `m`) `b`}}"), render.Values{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "renders a missing value as <no value>")
+	})
+
+	t.Run("present", func(t *testing.T) {
+		ctx := render.WithStrict(context.Background(), true)
+		_, err := render.Render(ctx, strictGraph("{{(paramMap `m`).a}}"), render.Values{})
+		assert.NoError(t, err)
+	})
+}
+
+func TestRenderProvenance(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	g := graph.New()
+	g.Add(node.New("root", nil))
+	g.Add(node.New(
+		"root/file.content.x",
+		resource.NewPreparerWithSource(
+			new(content.Preparer),
+			map[string]interface{}{
+				"destination": "{{param `dir`}}/{{platform.OS}}.conf",
+				"content":     "{{env `CONVERGE_PROVENANCE_TEST`}}",
+			},
+		),
+	))
+	g.Add(node.New(
+		"root/param.dir",
+		resource.NewPreparerWithSource(
+			new(param.Preparer),
+			map[string]interface{}{"default": "/etc/app"},
+		),
+	))
+
+	g.ConnectParent("root", "root/file.content.x")
+	g.ConnectParent("root", "root/param.dir")
+	g.Connect("root/file.content.x", "root/param.dir")
+
+	require.NoError(t, os.Setenv("CONVERGE_PROVENANCE_TEST", "from env"))
+	defer os.Unsetenv("CONVERGE_PROVENANCE_TEST")
+
+	rendered, err := render.Render(context.Background(), g, render.Values{})
+	require.NoError(t, err)
+
+	meta, ok := rendered.Get("root/file.content.x")
+	require.True(t, ok)
+
+	assert.Equal(
+		t,
+		[]resource.Origin{
+			{Field: "content", Kind: resource.OriginEnv, Name: "CONVERGE_PROVENANCE_TEST", Value: "from env"},
+			{Field: "destination", Kind: resource.OriginParam, Name: "dir", Node: "root/param.dir", Value: "/etc/app"},
+			{Field: "destination", Kind: resource.OriginPlatform, Name: "platform.OS", Value: runtime.GOOS},
+		},
+		render.Provenance(meta),
+	)
+}
+
+func TestRenderProvenanceSensitive(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	g := graph.New()
+	g.Add(node.New("root", nil))
+	g.Add(node.New(
+		"root/file.content.x",
+		resource.NewPreparerWithSource(
+			new(content.Preparer),
+			map[string]interface{}{
+				"destination": "{{param `dir`}}/secret",
+				"content":     "{{param `secret`}}",
+				"sensitive":   true,
+			},
+		),
+	))
+	g.Add(node.New(
+		"root/param.dir",
+		resource.NewPreparerWithSource(
+			new(param.Preparer),
+			map[string]interface{}{"default": "/etc/app"},
+		),
+	))
+	g.Add(node.New(
+		"root/param.secret",
+		resource.NewPreparerWithSource(
+			new(param.Preparer),
+			map[string]interface{}{"default": "hunter2"},
+		),
+	))
+
+	g.ConnectParent("root", "root/file.content.x")
+	g.ConnectParent("root", "root/param.dir")
+	g.ConnectParent("root", "root/param.secret")
+	g.Connect("root/file.content.x", "root/param.dir")
+	g.Connect("root/file.content.x", "root/param.secret")
+
+	rendered, err := render.Render(context.Background(), g, render.Values{})
+	require.NoError(t, err)
+
+	meta, ok := rendered.Get("root/file.content.x")
+	require.True(t, ok)
+
+	assert.Equal(
+		t,
+		[]resource.Origin{
+			{Field: "content", Kind: resource.OriginParam, Name: "secret", Node: "root/param.secret", Value: resource.RedactedValue},
+			{Field: "destination", Kind: resource.OriginParam, Name: "dir", Node: "root/param.dir", Value: "/etc/app"},
+		},
+		render.Provenance(meta),
+	)
+}
@@ -17,11 +17,14 @@ package render
 import (
 	"fmt"
 	"reflect"
+	"strings"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/fetch"
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/parse"
 	"github.com/asteris-llc/converge/render/extensions"
+	"github.com/asteris-llc/converge/render/extensions/platform"
 	"github.com/asteris-llc/converge/render/preprocessor"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/param"
@@ -43,6 +46,21 @@ func (e ErrBadTemplate) Error() string {
 	return fmt.Sprintf("%s: cannot execute template", e.Err)
 }
 
+// ErrStrict is returned by Render in strict mode if the template refers to a
+// value that isn't defined, instead of rendering "<no value>"
+type ErrStrict struct {
+	ID       string
+	Template string
+	Err      error
+}
+
+func (e ErrStrict) Error() string {
+	return fmt.Sprintf("%s: %q: %s", e.ID, e.Template, e.Err)
+}
+
+// noValue is what text/template renders for missing values
+const noValue = "<no value>"
+
 // Renderer to be passed to preparers, which will render strings
 type Renderer struct {
 	Graph           func() *graph.Graph
@@ -51,6 +69,21 @@ type Renderer struct {
 	DotValuePresent bool
 	resolverErr     bool
 	Language        *extensions.LanguageExtension
+
+	// Strict makes references to missing values errors
+	Strict bool
+
+	deferLookups bool
+	deferrals    *deferrals
+	deferred     bool
+
+	provenance *provenance
+	field      string
+	origins    []resource.Origin
+	facts      *platform.Platform
+
+	source string
+	root   string
 }
 
 // GetID returns the ID of this renderer
@@ -63,25 +96,95 @@ func (r *Renderer) Value() (value resource.Value, present bool) {
 	return r.DotValue, r.DotValuePresent
 }
 
+// Source returns the URL of the module this node was loaded from, or an empty
+// string if it is not known
+func (r *Renderer) Source() string {
+	return r.source
+}
+
+// SourceDir returns the URL of the directory containing the module this node
+// was loaded from, or an empty string if it is not known
+func (r *Renderer) SourceDir() string {
+	return fetch.Dir(r.source)
+}
+
+// ResolvePath resolves a location relative to the module this node was loaded
+// from, returning a URL. Remote locations which have been vendored next to the
+// root module resolve to the vendored copy.
+func (r *Renderer) ResolvePath(loc string) (string, error) {
+	url, err := fetch.ResolveInContext(loc, r.source)
+	if err != nil || r.root == "" {
+		return url, err
+	}
+	return fetch.Vendored(r.root, url), nil
+}
+
+// LocalPath resolves a location relative to the module this node was loaded
+// from, returning a path on the local filesystem. Modules loaded over HTTP
+// have no local paths.
+func (r *Renderer) LocalPath(loc string) (string, error) {
+	url, err := r.ResolvePath(loc)
+	if err != nil {
+		return "", err
+	}
+	return fetch.LocalPath(url)
+}
+
 // Render a string with text/template
 func (r *Renderer) Render(name, src string) (string, error) {
 	r.resolverErr = false
+	r.deferred = false
+	r.field = name
+	r.origins = nil
+	r.facts = nil
 
+	r.Language = r.Language.On("env", r.env)
+	r.Language = r.Language.On("platform", r.platform)
 	r.Language = r.Language.On("param", r.param)
 	r.Language = r.Language.On("paramList", r.paramList)
 	r.Language = r.Language.On("paramMap", r.paramMap)
 
 	r.Language = r.Language.On(extensions.RefFuncName, r.lookup)
-	out, err := r.Language.Render(r.DotValue, name, src)
+
+	var options []string
+	if r.Strict {
+		options = append(options, "missingkey=error")
+	}
+
+	out, err := r.Language.RenderWithOptions(r.DotValue, name, src, options...)
 	if err != nil {
 		if r.resolverErr {
 			return "", ErrUnresolvable{}
 		}
+		if r.Strict && isMissingKey(err) {
+			return "", ErrStrict{ID: r.ID, Template: src, Err: err}
+		}
 		return "", ErrBadTemplate{Err: err}
 	}
+
+	// missing map keys fetched with index, and a missing dot value, still
+	// render as "<no value>"
+	if r.Strict && strings.Contains(out.String(), noValue) && !strings.Contains(src, noValue) {
+		return "", ErrStrict{ID: r.ID, Template: src, Err: errors.New("renders a missing value as " + noValue)}
+	}
+
+	if r.deferred {
+		r.deferrals.add(r.ID, name)
+	}
+
+	r.recordPlatform(src)
+	r.provenance.set(r.ID, name, r.origins)
+
 	return out.String(), err
 }
 
+// isMissingKey is true for the errors text/template returns for missing keys
+// with missingkey=error
+func isMissingKey(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "map has no entry for key") || strings.Contains(msg, "nil data; no entry for key")
+}
+
 func getNearestAncestor(g *graph.Graph, id, node string) (string, bool) {
 	if graph.IsRoot(node) || node == "" {
 		return "", false
@@ -149,7 +252,6 @@ func (r *Renderer) paramMap(name string) (map[string]interface{}, error) {
 }
 
 func (r *Renderer) paramRawValue(name string) (interface{}, error) {
-
 	ancestor, found := getNearestAncestor(r.Graph(), r.ID, "param."+name)
 	if !found {
 		return "", errors.New("param not found (no such ancestor)")
@@ -167,6 +269,7 @@ func (r *Renderer) paramRawValue(name string) (interface{}, error) {
 		return nil, fmt.Errorf("task it not a param, but a %T", task)
 	}
 
+	r.record(resource.OriginParam, name, ancestor, fmt.Sprintf("%v", param.Val))
 	return param.Val, nil
 }
 
@@ -232,18 +335,25 @@ func (r *Renderer) lookup(name string) (string, error) {
 
 	result, ok := status.ExportedFields()[terms]
 
+	task, _ := resource.ResolveTask(asTasker)
+	if r.deferLookups && shouldDefer(meta.Value(), task, status, terms) {
+		r.deferred = true
+		r.record(resource.OriginLookup, name, vertexName, KnownAfterApply)
+		return KnownAfterApply, nil
+	}
+
 	if !ok {
 		var keys []string
 		for key := range status.ExportedFields() {
 			keys = append(keys, key)
 		}
-		innerTask, _ := asTasker.GetTask()
-		innerTask, _ = resource.ResolveTask(innerTask)
-		log.WithField("current-node", r.ID).Warn(fmt.Sprintf("%s is not one of the exported fields for type %T: %v at %s", terms, innerTask, keys, vertexName))
+		log.WithField("current-node", r.ID).Warn(fmt.Sprintf("%s is not one of the exported fields for type %T: %v at %s", terms, task, keys, vertexName))
 		return "", ErrUnresolvable{}
 	}
 
-	return fmt.Sprintf("%v", result), nil
+	value := fmt.Sprintf("%v", result)
+	r.record(resource.OriginLookup, name, vertexName, value)
+	return value, nil
 }
 
 // validateLookup ensures that the lookup is valid and resolvable over cases of
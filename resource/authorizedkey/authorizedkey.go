@@ -0,0 +1,302 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authorizedkey
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+
+	"github.com/asteris-llc/converge/helpers/atomicfile"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// State type for AuthorizedKey
+type State string
+
+const (
+	// StatePresent indicates the key should be authorized
+	StatePresent State = "present"
+
+	// StateAbsent indicates the key should not be authorized
+	StateAbsent State = "absent"
+)
+
+const (
+	// dirMode is the mode of ~/.ssh. sshd refuses keys in directories which
+	// are writable by others.
+	dirMode os.FileMode = 0700
+
+	// fileMode is the mode of ~/.ssh/authorized_keys
+	fileMode os.FileMode = 0600
+)
+
+// AuthorizedKey manages a public key in a user's authorized_keys file
+type AuthorizedKey struct {
+	// the user whose keys are managed
+	Username string `export:"user"`
+
+	// the configured key line
+	Key string `export:"key"`
+
+	// whether the key should be present
+	State State `export:"state"`
+
+	// the path of the authorized_keys file, once the user has been looked up
+	Path string `export:"path"`
+
+	key    *authorizedKey
+	lookup func(string) (*user.User, error)
+}
+
+// keyFile is the state of an authorized_keys file and the directory it's in
+type keyFile struct {
+	usr  *user.User
+	dir  string
+	path string
+
+	dirInfo  os.FileInfo
+	fileInfo os.FileInfo
+	lines    []string
+}
+
+// Claims returns the user's authorized_keys file, if the user exists
+func (a *AuthorizedKey) Claims() []resource.Claim {
+	usr, err := a.lookup(a.Username)
+	if err != nil {
+		return nil
+	}
+	return []resource.Claim{resource.ClaimPath(keyPath(usr))}
+}
+
+// Check whether the key is authorized
+func (a *AuthorizedKey) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	usr, err := a.lookup(a.Username)
+	if err != nil {
+		if _, ok := err.(user.UnknownUserError); ok && a.State == StatePresent {
+			// the user may be added by another resource in the same run
+			status.AddMessage(fmt.Sprintf("user %s does not exist yet", a.Username))
+			status.AddDifference(a.diffName(), string(StateAbsent), string(StatePresent), "")
+			status.RaiseLevelForDiffs()
+			return status, nil
+		}
+		if _, ok := err.(user.UnknownUserError); ok {
+			status.AddMessage(fmt.Sprintf("user %s does not exist", a.Username))
+			return status, nil
+		}
+		status.RaiseLevel(resource.StatusFatal)
+		return status, errors.Wrapf(err, "cannot look up user %s", a.Username)
+	}
+
+	kf, err := a.read(usr)
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+
+	a.diff(status, kf)
+	status.RaiseLevelForDiffs()
+
+	return status, nil
+}
+
+// Apply adds or removes the key
+func (a *AuthorizedKey) Apply(context.Context) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	usr, err := a.lookup(a.Username)
+	if err != nil {
+		if _, ok := err.(user.UnknownUserError); ok && a.State == StateAbsent {
+			status.AddMessage(fmt.Sprintf("user %s does not exist", a.Username))
+			return status, nil
+		}
+		status.RaiseLevel(resource.StatusFatal)
+		return status, errors.Wrapf(err, "cannot look up user %s", a.Username)
+	}
+
+	kf, err := a.read(usr)
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+
+	lines, changed := a.update(kf)
+	a.diff(status, kf)
+
+	switch a.State {
+	case StatePresent:
+		if err := a.writeFile(kf, lines, changed); err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			return status, err
+		}
+		if changed {
+			status.AddMessage(fmt.Sprintf("authorized key %s for %s", a.key.Fingerprint(), a.Username))
+		}
+
+	case StateAbsent:
+		if changed {
+			if err := a.writeFile(kf, lines, changed); err != nil {
+				status.RaiseLevel(resource.StatusFatal)
+				return status, err
+			}
+			status.AddMessage(fmt.Sprintf("removed key %s for %s", a.key.Fingerprint(), a.Username))
+		}
+	}
+
+	return status, nil
+}
+
+// keyPath is the path of a user's authorized_keys file
+func keyPath(usr *user.User) string {
+	return filepath.Join(usr.HomeDir, ".ssh", "authorized_keys")
+}
+
+// read reads the user's authorized_keys file. A missing file or directory is
+// not an error.
+func (a *AuthorizedKey) read(usr *user.User) (*keyFile, error) {
+	kf := &keyFile{usr: usr, path: keyPath(usr)}
+	kf.dir = filepath.Dir(kf.path)
+	a.Path = kf.path
+
+	info, err := os.Stat(kf.dir)
+	switch {
+	case os.IsNotExist(err):
+		return kf, nil
+	case err != nil:
+		return nil, errors.Wrapf(err, "cannot read %s", kf.dir)
+	case !info.IsDir():
+		return nil, fmt.Errorf("%s is not a directory", kf.dir)
+	}
+	kf.dirInfo = info
+
+	info, err = os.Stat(kf.path)
+	switch {
+	case os.IsNotExist(err):
+		return kf, nil
+	case err != nil:
+		return nil, errors.Wrapf(err, "cannot read %s", kf.path)
+	}
+	kf.fileInfo = info
+
+	content, err := ioutil.ReadFile(kf.path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", kf.path)
+	}
+	kf.lines = splitLines(string(content))
+
+	return kf, nil
+}
+
+// update returns the lines of the authorized_keys file after adding or
+// removing the key, and whether they changed
+func (a *AuthorizedKey) update(kf *keyFile) ([]string, bool) {
+	switch a.State {
+	case StateAbsent:
+		lines, removed := remove(kf.lines, a.key)
+		return lines, removed > 0
+
+	default:
+		lines, _ := merge(kf.lines, a.key)
+		return lines, joinLines(lines) != joinLines(kf.lines)
+	}
+}
+
+// diff adds the differences between the authorized_keys file and the desired
+// state to status
+func (a *AuthorizedKey) diff(status *resource.Status, kf *keyFile) {
+	if a.State == StateAbsent {
+		if _, removed := remove(kf.lines, a.key); removed > 0 {
+			status.AddDifference(a.diffName(), string(StatePresent), string(StateAbsent), "")
+		}
+		return
+	}
+
+	if kf.dirInfo == nil {
+		status.AddDifference(kf.dir, "<absent>", fmt.Sprintf("directory %04o", dirMode), "")
+	} else if perm := kf.dirInfo.Mode().Perm(); perm != dirMode {
+		status.AddDifference(kf.dir+" mode", fmt.Sprintf("%04o", perm), fmt.Sprintf("%04o", dirMode), "")
+	}
+
+	if kf.fileInfo != nil {
+		if perm := kf.fileInfo.Mode().Perm(); perm != fileMode {
+			status.AddDifference(kf.path+" mode", fmt.Sprintf("%04o", perm), fmt.Sprintf("%04o", fileMode), "")
+		}
+	}
+
+	lines, existing := merge(kf.lines, a.key)
+	switch {
+	case existing == nil:
+		status.AddDifference(a.diffName(), string(StateAbsent), string(StatePresent), "")
+	case joinLines(lines) != joinLines(kf.lines):
+		status.AddDifference(a.diffName(), existing.String(), a.merged(existing).String(), "")
+	}
+}
+
+// merged is the existing key with the configured options and comment
+func (a *AuthorizedKey) merged(existing *authorizedKey) *authorizedKey {
+	lines, _ := merge([]string{existing.String()}, a.key)
+	merged, _ := parseKey(lines[0])
+	return merged
+}
+
+// diffName names the key in differences
+func (a *AuthorizedKey) diffName() string {
+	return "key " + a.key.Fingerprint()
+}
+
+// writeFile writes the authorized_keys file, creating the directory if
+// needed, and sets their modes and ownership
+func (a *AuthorizedKey) writeFile(kf *keyFile, lines []string, changed bool) error {
+	uid, err := strconv.Atoi(kf.usr.Uid)
+	if err != nil {
+		return fmt.Errorf("invalid uid %q for user %s", kf.usr.Uid, a.Username)
+	}
+	gid, err := strconv.Atoi(kf.usr.Gid)
+	if err != nil {
+		return fmt.Errorf("invalid gid %q for user %s", kf.usr.Gid, a.Username)
+	}
+
+	if kf.dirInfo == nil {
+		if err := os.Mkdir(kf.dir, dirMode); err != nil {
+			return errors.Wrapf(err, "cannot create %s", kf.dir)
+		}
+	}
+	if err := os.Chmod(kf.dir, dirMode); err != nil {
+		return errors.Wrapf(err, "cannot set mode of %s", kf.dir)
+	}
+	if err := os.Chown(kf.dir, uid, gid); err != nil {
+		return errors.Wrapf(err, "cannot set owner of %s", kf.dir)
+	}
+
+	if changed || kf.fileInfo == nil {
+		// write through a temporary file, so sshd never sees a partially
+		// written file
+		if err := atomicfile.WriteLines(kf.path, lines, fileMode); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Chmod(kf.path, fileMode); err != nil {
+		return errors.Wrapf(err, "cannot set mode of %s", kf.path)
+	}
+	return errors.Wrapf(os.Chown(kf.path, uid, gid), "cannot set owner of %s", kf.path)
+}
@@ -0,0 +1,180 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authorizedkey
+
+import (
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// newTestKey creates an AuthorizedKey for a user with a temporary home
+// directory, owned by the current user so ownership can be set
+func newTestKey(t *testing.T, line string, state State) (*AuthorizedKey, string) {
+	home, err := ioutil.TempDir("", "converge-authorized-key")
+	require.NoError(t, err)
+
+	key, err := parseKey(line)
+	require.NoError(t, err)
+
+	usr := &user.User{
+		Username: "test",
+		Uid:      strconv.Itoa(os.Getuid()),
+		Gid:      strconv.Itoa(os.Getgid()),
+		HomeDir:  home,
+	}
+
+	return &AuthorizedKey{
+		Username: usr.Username,
+		Key:      line,
+		State:    state,
+		key:      key,
+		lookup: func(name string) (*user.User, error) {
+			if name != usr.Username {
+				return nil, user.UnknownUserError(name)
+			}
+			return usr, nil
+		},
+	}, home
+}
+
+func TestAuthorizedKeyInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(AuthorizedKey))
+	assert.Implements(t, (*resource.Claimer)(nil), new(AuthorizedKey))
+}
+
+func TestAuthorizedKey(t *testing.T) {
+	t.Parallel()
+
+	line := "ssh-ed25519 " + testBlob + " alice"
+
+	t.Run("add", func(t *testing.T) {
+		a, home := newTestKey(t, line, StatePresent)
+		defer os.RemoveAll(home)
+		dir := filepath.Join(home, ".ssh")
+		path := filepath.Join(dir, "authorized_keys")
+
+		status, err := a.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		assert.Equal(t, "absent", status.Diffs()[a.diffName()].Original())
+		assert.Equal(t, "directory 0700", status.Diffs()[dir].Current())
+
+		_, err = a.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, path, a.Path)
+
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, line+"\n", string(content))
+
+		info, err := os.Stat(dir)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+		info, err = os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		status, err = a.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		a, home := newTestKey(t, `command="uptime" ssh-ed25519 `+testBlob, StatePresent)
+		defer os.RemoveAll(home)
+		dir := filepath.Join(home, ".ssh")
+		path := filepath.Join(dir, "authorized_keys")
+
+		require.NoError(t, os.Mkdir(dir, 0755))
+		original := "# keys\n" + `from="10.0.0.0/8" ssh-ed25519 ` + testBlob + " alice\nssh-ed25519 " + otherBlob + " bob\n"
+		require.NoError(t, ioutil.WriteFile(path, []byte(original), 0644))
+
+		status, err := a.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, `from="10.0.0.0/8" ssh-ed25519 `+testBlob+" alice", status.Diffs()[a.diffName()].Original())
+		assert.Equal(t, `command="uptime" ssh-ed25519 `+testBlob+" alice", status.Diffs()[a.diffName()].Current())
+		assert.Equal(t, "0755", status.Diffs()[dir+" mode"].Original())
+		assert.Equal(t, "0644", status.Diffs()[path+" mode"].Original())
+
+		_, err = a.Apply(context.Background())
+		require.NoError(t, err)
+
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "# keys\n"+`command="uptime" ssh-ed25519 `+testBlob+" alice\nssh-ed25519 "+otherBlob+" bob\n", string(content))
+
+		info, err := os.Stat(dir)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		a, home := newTestKey(t, "ssh-ed25519 "+testBlob, StateAbsent)
+		defer os.RemoveAll(home)
+		dir := filepath.Join(home, ".ssh")
+		path := filepath.Join(dir, "authorized_keys")
+
+		require.NoError(t, os.Mkdir(dir, 0700))
+		require.NoError(t, ioutil.WriteFile(path, []byte(line+"\nssh-ed25519 "+otherBlob+" bob\n"), 0600))
+
+		status, err := a.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		assert.Equal(t, "absent", status.Diffs()[a.diffName()].Current())
+
+		_, err = a.Apply(context.Background())
+		require.NoError(t, err)
+
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "ssh-ed25519 "+otherBlob+" bob\n", string(content))
+	})
+
+	t.Run("remove without file", func(t *testing.T) {
+		a, home := newTestKey(t, line, StateAbsent)
+		defer os.RemoveAll(home)
+
+		status, err := a.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		a, home := newTestKey(t, line, StatePresent)
+		defer os.RemoveAll(home)
+		a.Username = "missing"
+
+		status, err := a.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		assert.Equal(t, []string{"user missing does not exist yet"}, status.Messages())
+		assert.Nil(t, a.Claims())
+
+		_, err = a.Apply(context.Background())
+		assert.EqualError(t, err, "cannot look up user missing: user: unknown user missing")
+	})
+}
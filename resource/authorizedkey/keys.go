@@ -0,0 +1,185 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authorizedkey
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// keyTypes are the key types accepted by sshd in authorized_keys
+var keyTypes = map[string]bool{
+	"ssh-rsa":                            true,
+	"ssh-dss":                            true,
+	"ssh-ed25519":                        true,
+	"ecdsa-sha2-nistp256":                true,
+	"ecdsa-sha2-nistp384":                true,
+	"ecdsa-sha2-nistp521":                true,
+	"sk-ssh-ed25519@openssh.com":         true,
+	"sk-ecdsa-sha2-nistp256@openssh.com": true,
+}
+
+// authorizedKey is a single line of an authorized_keys file
+type authorizedKey struct {
+	// Options restrict what the key can be used for, like `command="..."` or
+	// `from="..."`
+	Options string
+	Type    string
+	Blob    string
+	Comment string
+}
+
+// parseKey parses a line of an authorized_keys file, in the form
+// `[options] type blob [comment]`
+func parseKey(line string) (*authorizedKey, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, fmt.Errorf("not a key")
+	}
+
+	key := new(authorizedKey)
+
+	tok, rest := nextToken(line)
+	if !keyTypes[tok] {
+		key.Options = tok
+		tok, rest = nextToken(rest)
+		if !keyTypes[tok] {
+			return nil, fmt.Errorf("unknown key type %q", tok)
+		}
+	}
+	key.Type = tok
+
+	key.Blob, rest = nextToken(rest)
+	if key.Blob == "" {
+		return nil, fmt.Errorf("missing key data")
+	}
+	if _, err := base64.StdEncoding.DecodeString(key.Blob); err != nil {
+		return nil, fmt.Errorf("invalid key data: %s", err)
+	}
+
+	key.Comment = rest
+
+	return key, nil
+}
+
+// nextToken splits off the first whitespace-separated token of s. Whitespace
+// inside double quotes, as in `command="a b"`, does not end a token.
+func nextToken(s string) (token, rest string) {
+	s = strings.TrimSpace(s)
+
+	quoted := false
+	for i, r := range s {
+		switch {
+		case r == '"' && (i == 0 || s[i-1] != '\\'):
+			quoted = !quoted
+		case !quoted && (r == ' ' || r == '\t'):
+			return s[:i], strings.TrimSpace(s[i:])
+		}
+	}
+
+	return s, ""
+}
+
+// String formats the key as a line of an authorized_keys file
+func (k *authorizedKey) String() string {
+	var parts []string
+	for _, part := range []string{k.Options, k.Type, k.Blob, k.Comment} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Same reports whether two keys are the same public key, regardless of their
+// options and comments
+func (k *authorizedKey) Same(other *authorizedKey) bool {
+	return k.Type == other.Type && k.Blob == other.Blob
+}
+
+// Fingerprint is the SHA256 fingerprint of the key, as shown by ssh-keygen -l
+func (k *authorizedKey) Fingerprint() string {
+	raw, _ := base64.StdEncoding.DecodeString(k.Blob)
+	sum := sha256.Sum256(raw)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// merge returns the lines of an authorized_keys file with key added, or
+// updated if it's already present. The options and comment of a key already in
+// the file are kept unless key sets its own. The returned key is the one that
+// was in the file, if any.
+func merge(lines []string, key *authorizedKey) ([]string, *authorizedKey) {
+	out := make([]string, len(lines))
+	copy(out, lines)
+
+	for i, line := range out {
+		existing, err := parseKey(line)
+		if err != nil || !existing.Same(key) {
+			continue
+		}
+
+		merged := *existing
+		if key.Options != "" {
+			merged.Options = key.Options
+		}
+		if key.Comment != "" {
+			merged.Comment = key.Comment
+		}
+		if merged != *existing {
+			out[i] = merged.String()
+		}
+
+		return out, existing
+	}
+
+	return append(out, key.String()), nil
+}
+
+// remove returns the lines of an authorized_keys file without any lines for
+// key, and how many were removed
+func remove(lines []string, key *authorizedKey) ([]string, int) {
+	var (
+		out     []string
+		removed int
+	)
+
+	for _, line := range lines {
+		if existing, err := parseKey(line); err == nil && existing.Same(key) {
+			removed++
+			continue
+		}
+		out = append(out, line)
+	}
+
+	return out, removed
+}
+
+// splitLines splits the content of an authorized_keys file into lines
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// joinLines joins lines into the content of an authorized_keys file
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,110 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authorizedkey
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testBlob  = "AAAAC3NzaC1lZDI1NTE5AAAAIHb0Kq0qfMmMUSC6ZwwqHdkEmJuQCrfMJyHwjsZlXmzM"
+	otherBlob = "AAAAC3NzaC1lZDI1NTE5AAAAIBHgvFXiK0fqJ8HQ3LdvbnIHsmgRvvRmSbM6xaTd1tGg"
+)
+
+func TestParseKey(t *testing.T) {
+	t.Parallel()
+
+	t.Run("plain", func(t *testing.T) {
+		key, err := parseKey("ssh-ed25519 " + testBlob + " alice@laptop")
+		require.NoError(t, err)
+		assert.Equal(t, &authorizedKey{Type: "ssh-ed25519", Blob: testBlob, Comment: "alice@laptop"}, key)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		line := `command="echo hi there",from="10.0.0.0/8" ssh-ed25519 ` + testBlob + " deploy key"
+		key, err := parseKey(line)
+		require.NoError(t, err)
+		assert.Equal(t, `command="echo hi there",from="10.0.0.0/8"`, key.Options)
+		assert.Equal(t, "ssh-ed25519", key.Type)
+		assert.Equal(t, "deploy key", key.Comment)
+		assert.Equal(t, line, key.String())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for line, msg := range map[string]string{
+			"":                           "not a key",
+			"# comment":                  "not a key",
+			"no-pty ssh-foo " + testBlob: `unknown key type "ssh-foo"`,
+			"ssh-ed25519":                "missing key data",
+			"ssh-ed25519 not!base64":     "invalid key data: illegal base64 data at input byte 3",
+		} {
+			_, err := parseKey(line)
+			assert.EqualError(t, err, msg, line)
+		}
+	})
+
+	t.Run("fingerprint", func(t *testing.T) {
+		key, err := parseKey("ssh-ed25519 " + testBlob)
+		require.NoError(t, err)
+		assert.Regexp(t, `^SHA256:[A-Za-z0-9+/]{43}$`, key.Fingerprint())
+	})
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	existing := []string{
+		"# managed by hand",
+		`from="10.0.0.0/8" ssh-ed25519 ` + testBlob + " alice",
+		"ssh-ed25519 " + otherBlob + " bob",
+	}
+
+	t.Run("keeps options", func(t *testing.T) {
+		key, _ := parseKey("ssh-ed25519 " + testBlob)
+		lines, found := merge(existing, key)
+
+		assert.Equal(t, existing, lines)
+		require.NotNil(t, found)
+		assert.Equal(t, `from="10.0.0.0/8"`, found.Options)
+	})
+
+	t.Run("replaces options", func(t *testing.T) {
+		key, _ := parseKey(`command="uptime" ssh-ed25519 ` + testBlob)
+		lines, _ := merge(existing, key)
+
+		assert.Equal(t, `command="uptime" ssh-ed25519 `+testBlob+" alice", lines[1])
+		assert.Equal(t, existing[0], lines[0])
+		assert.Equal(t, existing[2], lines[2])
+	})
+
+	t.Run("appends", func(t *testing.T) {
+		key, _ := parseKey("ssh-ed25519 " + testBlob + " alice")
+		lines, found := merge(existing[:1], key)
+
+		assert.Nil(t, found)
+		assert.Equal(t, []string{existing[0], "ssh-ed25519 " + testBlob + " alice"}, lines)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		key, _ := parseKey("ssh-ed25519 " + testBlob)
+		lines, removed := remove(append(existing, existing[1]), key)
+
+		assert.Equal(t, 2, removed)
+		assert.Equal(t, []string{existing[0], existing[2]}, lines)
+	})
+}
@@ -0,0 +1,69 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authorizedkey
+
+import (
+	"fmt"
+	"os/user"
+
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"golang.org/x/net/context"
+)
+
+// Preparer for AuthorizedKey
+//
+// AuthorizedKey manages a public key in a user's `~/.ssh/authorized_keys`
+// file. When the key is added, `~/.ssh` is created if it's missing, and the
+// directory and file are given mode 0700 and 0600 and are owned by the user.
+// Other lines in the file are left as they are.
+type Preparer struct {
+	// User is the name of the user whose authorized keys are managed
+	User string `hcl:"user" required:"true" nonempty:"true"`
+
+	// Key is the public key, as a line of an authorized_keys file: optional
+	// options, the key type, the base64-encoded key, and an optional comment.
+	// If the key is already in the file, its options (like `command="..."` or
+	// `from="..."`) and comment are kept unless Key sets its own.
+	Key string `hcl:"key" required:"true" nonempty:"true"`
+
+	// State is whether the key should be present. Every line with the key is
+	// removed when it is absent.
+	State State `hcl:"state" valid_values:"present,absent"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	key, err := parseKey(p.Key)
+	if err != nil {
+		return nil, fmt.Errorf("user.authorized_key \"key\" parameter: %s", err)
+	}
+
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	return &AuthorizedKey{
+		Username: p.User,
+		Key:      p.Key,
+		State:    p.State,
+		key:      key,
+		lookup:   user.Lookup,
+	}, nil
+}
+
+func init() {
+	registry.Register("user.authorized_key", (*Preparer)(nil), (*AuthorizedKey)(nil))
+}
@@ -0,0 +1,56 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authorizedkey_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/authorizedkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestPreparerInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Resource)(nil), new(authorizedkey.Preparer))
+}
+
+func TestPreparerPrepare(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		p := authorizedkey.Preparer{
+			User: "alice",
+			Key:  "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHb0Kq0qfMmMUSC6ZwwqHdkEmJuQCrfMJyHwjsZlXmzM alice",
+		}
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		key := task.(*authorizedkey.AuthorizedKey)
+		assert.Equal(t, "alice", key.Username)
+		assert.Equal(t, authorizedkey.StatePresent, key.State)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		p := authorizedkey.Preparer{User: "alice", Key: "ssh-ed25519"}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+
+		assert.EqualError(t, err, "user.authorized_key \"key\" parameter: missing key data")
+	})
+}
@@ -0,0 +1,173 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// Claim names a system object that a task reads or modifies while checking or
+// applying, such as a file path or a system database. Tasks with overlapping
+// claims are never checked or applied at the same time, even when there is no
+// dependency between them.
+type Claim string
+
+const (
+	// ClaimPackageDB claims the system package database
+	ClaimPackageDB Claim = "db:package"
+
+	// ClaimUserDB claims the system user and group databases
+	ClaimUserDB Claim = "db:user"
+)
+
+const claimPathPrefix = "path:"
+
+// ClaimPath claims a path on the file system, along with everything under it
+func ClaimPath(path string) Claim {
+	return Claim(claimPathPrefix + filepath.Clean(path))
+}
+
+// Overlaps reports whether two claims refer to the same system object. Path
+// claims overlap when either path contains the other.
+func (c Claim) Overlaps(other Claim) bool {
+	if c == other {
+		return true
+	}
+
+	if !strings.HasPrefix(string(c), claimPathPrefix) || !strings.HasPrefix(string(other), claimPathPrefix) {
+		return false
+	}
+
+	fst := strings.TrimPrefix(string(c), claimPathPrefix)
+	snd := strings.TrimPrefix(string(other), claimPathPrefix)
+
+	return pathContains(fst, snd) || pathContains(snd, fst)
+}
+
+func pathContains(parent, child string) bool {
+	return strings.HasPrefix(child, strings.TrimSuffix(parent, "/")+"/")
+}
+
+// Claimer is implemented by tasks which claim system objects
+type Claimer interface {
+	Claims() []Claim
+}
+
+// ClaimsOf returns the claims of a task, if it makes any
+func ClaimsOf(task Task) []Claim {
+	resolved, ok := ResolveTask(task)
+	if !ok {
+		return nil
+	}
+
+	if claimer, ok := resolved.(Claimer); ok {
+		return claimer.Claims()
+	}
+
+	return nil
+}
+
+// ClaimLocker serializes tasks with overlapping claims
+type ClaimLocker struct {
+	lock     sync.Mutex
+	held     map[int][]Claim
+	nextHold int
+
+	// closed and replaced whenever claims are released
+	released chan struct{}
+}
+
+// NewClaimLocker creates a new ClaimLocker with no claims held
+func NewClaimLocker() *ClaimLocker {
+	return &ClaimLocker{
+		held:     map[int][]Claim{},
+		released: make(chan struct{}),
+	}
+}
+
+// Acquire waits until none of the given claims overlap with claims that are
+// held, and then holds them until the returned release function is called. It
+// returns an error if ctx is cancelled while waiting.
+func (l *ClaimLocker) Acquire(ctx context.Context, claims []Claim) (func(), error) {
+	if len(claims) == 0 {
+		return func() {}, nil
+	}
+
+	for {
+		l.lock.Lock()
+		if !l.conflicts(claims) {
+			hold := l.nextHold
+			l.nextHold++
+			l.held[hold] = claims
+			l.lock.Unlock()
+
+			return func() { l.release(hold) }, nil
+		}
+		released := l.released
+		l.lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-released:
+		}
+	}
+}
+
+func (l *ClaimLocker) conflicts(claims []Claim) bool {
+	for _, held := range l.held {
+		for _, fst := range held {
+			for _, snd := range claims {
+				if fst.Overlaps(snd) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (l *ClaimLocker) release(hold int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	delete(l.held, hold)
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+type claimLockerKey struct{}
+
+// WithClaimLocker returns a context carrying a ClaimLocker, to be shared by
+// all tasks in a run
+func WithClaimLocker(ctx context.Context, locker *ClaimLocker) context.Context {
+	return context.WithValue(ctx, claimLockerKey{}, locker)
+}
+
+// AcquireClaims acquires the claims of a task from the ClaimLocker in ctx. If
+// there is no ClaimLocker in ctx or the task doesn't make claims, it returns
+// immediately.
+func AcquireClaims(ctx context.Context, task Task) (func(), error) {
+	locker, ok := ctx.Value(claimLockerKey{}).(*ClaimLocker)
+	if !ok {
+		return func() {}, nil
+	}
+
+	return locker.Acquire(ctx, ClaimsOf(task))
+}
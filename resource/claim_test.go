@@ -0,0 +1,116 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestClaimOverlaps(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		name     string
+		fst, snd resource.Claim
+		overlaps bool
+	}{
+		{"same path", resource.ClaimPath("/etc/hosts"), resource.ClaimPath("/etc/hosts"), true},
+		{"unclean path", resource.ClaimPath("/etc/hosts"), resource.ClaimPath("/etc//hosts"), true},
+		{"parent", resource.ClaimPath("/etc"), resource.ClaimPath("/etc/hosts"), true},
+		{"child", resource.ClaimPath("/etc/hosts"), resource.ClaimPath("/etc"), true},
+		{"root", resource.ClaimPath("/"), resource.ClaimPath("/etc"), true},
+		{"sibling", resource.ClaimPath("/etc/hosts"), resource.ClaimPath("/etc/hostname"), false},
+		{"prefix", resource.ClaimPath("/etc/host"), resource.ClaimPath("/etc/hosts"), false},
+		{"same database", resource.ClaimPackageDB, resource.ClaimPackageDB, true},
+		{"different databases", resource.ClaimPackageDB, resource.ClaimUserDB, false},
+		{"database and path", resource.ClaimUserDB, resource.ClaimPath("/"), false},
+	} {
+		assert.Equal(t, test.overlaps, test.fst.Overlaps(test.snd), test.name)
+	}
+}
+
+func TestClaimLocker(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no claims", func(t *testing.T) {
+		locker := resource.NewClaimLocker()
+
+		release, err := locker.Acquire(context.Background(), nil)
+		require.NoError(t, err)
+		release()
+	})
+
+	t.Run("disjoint", func(t *testing.T) {
+		locker := resource.NewClaimLocker()
+
+		releaseFst, err := locker.Acquire(context.Background(), []resource.Claim{resource.ClaimPath("/a")})
+		require.NoError(t, err)
+		defer releaseFst()
+
+		releaseSnd, err := locker.Acquire(context.Background(), []resource.Claim{resource.ClaimPath("/b")})
+		require.NoError(t, err)
+		releaseSnd()
+	})
+
+	t.Run("overlapping", func(t *testing.T) {
+		locker := resource.NewClaimLocker()
+
+		release, err := locker.Acquire(context.Background(), []resource.Claim{resource.ClaimPath("/a")})
+		require.NoError(t, err)
+
+		acquired := make(chan struct{})
+		go func() {
+			releaseSnd, err := locker.Acquire(context.Background(), []resource.Claim{resource.ClaimPath("/a/b")})
+			if assert.NoError(t, err) {
+				releaseSnd()
+			}
+			close(acquired)
+		}()
+
+		select {
+		case <-acquired:
+			t.Fatal("overlapping claim was acquired while held")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		release()
+
+		select {
+		case <-acquired:
+		case <-time.After(time.Second):
+			t.Fatal("overlapping claim was not acquired after release")
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		locker := resource.NewClaimLocker()
+
+		release, err := locker.Acquire(context.Background(), []resource.Claim{resource.ClaimUserDB})
+		require.NoError(t, err)
+		defer release()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err = locker.Acquire(ctx, []resource.Claim{resource.ClaimUserDB})
+		assert.Equal(t, context.Canceled, err)
+	})
+}
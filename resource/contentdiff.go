@@ -0,0 +1,155 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const (
+	// ContentDiffLines is the most lines of a unified diff that are shown
+	ContentDiffLines = 200
+
+	// ContentDiffSize is the largest content, in bytes, that is diffed line by
+	// line
+	ContentDiffSize = 1 << 20
+)
+
+// UnifiedDiffer is implemented by Diffs which can show their change as a
+// unified diff
+type UnifiedDiffer interface {
+	Unified() string
+}
+
+// ContentDiff is a Diff of multi-line content, like the contents of a file. It
+// can show the change as a unified diff.
+type ContentDiff struct {
+	// Name is shown in the headers of the unified diff
+	Name string
+
+	// Values are the original and current content
+	Values [2]string
+
+	// Missing is true when there is no original content. The original value is
+	// then a placeholder, like "<file-missing>".
+	Missing bool
+
+	// Sensitive content is never shown, only its size
+	Sensitive bool
+}
+
+// Original returns the original content. Binary content is summarized by its
+// size.
+func (d ContentDiff) Original() string {
+	if d.Missing {
+		return d.Values[0]
+	}
+	if d.Sensitive {
+		return sensitiveSummary(d.Values[0])
+	}
+	if isBinary(d.Values[0]) {
+		return binarySummary(d.Values[0])
+	}
+	return d.Values[0]
+}
+
+// Current returns the current content. Binary content is summarized by its
+// size.
+func (d ContentDiff) Current() string {
+	if d.Sensitive {
+		return sensitiveSummary(d.Values[1])
+	}
+	if isBinary(d.Values[1]) {
+		return binarySummary(d.Values[1])
+	}
+	return d.Values[1]
+}
+
+// Changes is true if the content differs
+func (d ContentDiff) Changes() bool {
+	return d.Missing || d.Values[0] != d.Values[1]
+}
+
+// Unified returns the change as a unified diff, limited to ContentDiffLines
+// lines. It is empty when there are no changes or the content is sensitive.
+func (d ContentDiff) Unified() string {
+	if !d.Changes() || d.Sensitive {
+		return ""
+	}
+
+	before, from := d.Values[0], d.Name
+	if d.Missing {
+		before, from = "", "/dev/null"
+	}
+	after := d.Values[1]
+
+	if len(before) > ContentDiffSize || len(after) > ContentDiffSize {
+		return fmt.Sprintf("content too large to diff (%d bytes => %d bytes)", len(before), len(after))
+	}
+
+	if isBinary(before) || isBinary(after) {
+		return fmt.Sprintf("binary content differs (%d bytes => %d bytes)", len(before), len(after))
+	}
+
+	unified, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(before),
+		B:        splitLines(after),
+		FromFile: from,
+		ToFile:   d.Name,
+		Context:  3,
+	})
+	if err != nil {
+		return ""
+	}
+
+	lines := strings.Split(strings.TrimRight(unified, "\n"), "\n")
+	if len(lines) > ContentDiffLines {
+		more := len(lines) - ContentDiffLines
+		lines = append(lines[:ContentDiffLines], fmt.Sprintf("... %d more lines", more))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// splitLines splits content into lines which keep their line endings, as
+// difflib expects
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+func sensitiveSummary(content string) string {
+	return fmt.Sprintf("<sensitive: %d bytes>", len(content))
+}
+
+func binarySummary(content string) string {
+	return fmt.Sprintf("<binary: %d bytes>", len(content))
+}
+
+func isBinary(content string) bool {
+	return strings.IndexByte(content, 0) >= 0
+}
@@ -0,0 +1,94 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestContentDiff tests unified diffs of content
+func TestContentDiff(t *testing.T) {
+	t.Parallel()
+
+	t.Run("implements", func(t *testing.T) {
+		assert.Implements(t, (*resource.Diff)(nil), resource.ContentDiff{})
+		assert.Implements(t, (*resource.UnifiedDiffer)(nil), resource.ContentDiff{})
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		diff := resource.ContentDiff{Name: "x", Values: [2]string{"a\n", "a\n"}}
+		assert.False(t, diff.Changes())
+		assert.Equal(t, "", diff.Unified())
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		diff := resource.ContentDiff{Name: "x", Values: [2]string{"a\nb\nc\n", "a\nB\nc\n"}}
+		assert.True(t, diff.Changes())
+		assert.Equal(
+			t,
+			"--- x\n+++ x\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c",
+			diff.Unified(),
+		)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		diff := resource.ContentDiff{
+			Name:    "x",
+			Values:  [2]string{"<file-missing>", "a\n"},
+			Missing: true,
+		}
+		assert.True(t, diff.Changes())
+		assert.Equal(t, "<file-missing>", diff.Original())
+		assert.Equal(t, "--- /dev/null\n+++ x\n@@ -0,0 +1 @@\n+a", diff.Unified())
+	})
+
+	t.Run("sensitive", func(t *testing.T) {
+		diff := resource.ContentDiff{
+			Name:      "x",
+			Values:    [2]string{"secret", "new secret"},
+			Sensitive: true,
+		}
+		assert.True(t, diff.Changes())
+		assert.Equal(t, "<sensitive: 6 bytes>", diff.Original())
+		assert.Equal(t, "<sensitive: 10 bytes>", diff.Current())
+		assert.Equal(t, "", diff.Unified())
+	})
+
+	t.Run("line limit", func(t *testing.T) {
+		after := strings.Repeat("x\n", resource.ContentDiffLines*2)
+		diff := resource.ContentDiff{Name: "x", Values: [2]string{"", after}}
+
+		lines := strings.Split(diff.Unified(), "\n")
+		assert.Len(t, lines, resource.ContentDiffLines+1)
+		assert.Equal(t, "... 203 more lines", lines[len(lines)-1])
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		after := strings.Repeat("x", resource.ContentDiffSize+1)
+		diff := resource.ContentDiff{Name: "x", Values: [2]string{"", after}}
+		assert.Equal(t, "content too large to diff (0 bytes => 1048577 bytes)", diff.Unified())
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		diff := resource.ContentDiff{Name: "x", Values: [2]string{"a\x00", "b\x00"}}
+		assert.Equal(t, "binary content differs (2 bytes => 2 bytes)", diff.Unified())
+		assert.Equal(t, "<binary: 2 bytes>", diff.Original())
+		assert.Equal(t, "<binary: 2 bytes>", diff.Current())
+	})
+}
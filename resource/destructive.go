@@ -0,0 +1,36 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+// DestructiveReporter is implemented by statuses whose planned changes can't
+// be undone, like deleting a user, formatting a partition or removing a
+// container. Apply refuses these changes unless they are explicitly allowed.
+type DestructiveReporter interface {
+	Destructive() []string
+}
+
+// StatusDestructive gets the reasons a status's planned changes are
+// destructive. An empty result means the changes are safe to apply.
+func StatusDestructive(status TaskStatus) []string {
+	if status == nil {
+		return nil
+	}
+
+	if reporter, ok := status.(DestructiveReporter); ok {
+		return reporter.Destructive()
+	}
+
+	return nil
+}
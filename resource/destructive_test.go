@@ -0,0 +1,49 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusDestructive(t *testing.T) {
+	t.Parallel()
+
+	t.Run("none", func(t *testing.T) {
+		status := resource.NewStatus()
+
+		assert.Empty(t, status.Destructive())
+		assert.Empty(t, resource.StatusDestructive(status))
+	})
+
+	t.Run("marked", func(t *testing.T) {
+		status := resource.NewStatus()
+		status.MarkDestructive("user will be deleted")
+		status.MarkDestructive("home directory will be removed")
+
+		assert.Equal(
+			t,
+			[]string{"user will be deleted", "home directory will be removed"},
+			resource.StatusDestructive(status),
+		)
+	})
+
+	t.Run("nil status", func(t *testing.T) {
+		assert.Nil(t, resource.StatusDestructive(nil))
+	})
+}
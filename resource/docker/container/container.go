@@ -114,6 +114,9 @@ func (c *Container) Check(context.Context, resource.Renderer) (resource.TaskStat
 			if diffErr := c.diffContainer(container, status); diffErr != nil {
 				return nil, diffErr
 			}
+			if resource.AnyChanges(status.Differences) {
+				status.MarkDestructive(fmt.Sprintf("container %s will be removed and recreated", c.Name))
+			}
 		}
 	} else {
 		status.AddDifference("name", "", c.Name, "<container-missing>")
@@ -21,9 +21,15 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/asteris-llc/converge/apply"
+	"github.com/asteris-llc/converge/errcode"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/helpers/comparison"
 	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/helpers/testing/graphutils"
 	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/plan"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/docker/container"
 	dc "github.com/fsouza/go-dockerclient"
@@ -701,6 +707,65 @@ func TestContainerApply(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// TestContainerApplyDestructive tests that recreating a container with `force`
+// set is refused unless the node allows destructive changes
+func TestContainerApplyDestructive(t *testing.T) {
+	t.Parallel()
+	defer logging.HideLogs(t)()
+
+	recreate := func(allow bool) *graph.Graph {
+		created := false
+		c := &fakeAPIClient{
+			FindContainerFunc: func(name string) (*dc.Container, error) {
+				state := "exited"
+				if created {
+					state = "running"
+				}
+				return &dc.Container{Name: name, State: dc.State{Status: state}, Config: &dc.Config{}}, nil
+			},
+			FindImageFunc: func(string) (*dc.Image, error) {
+				return &dc.Image{Config: &dc.Config{}}, nil
+			},
+			CreateContainerFunc: func(dc.CreateContainerOptions) (*dc.Container, error) {
+				created = true
+				return &dc.Container{}, nil
+			},
+			StartContainerFunc: func(string, string) error { return nil },
+		}
+		container := &container.Container{Force: true, Name: "nginx"}
+		container.SetClient(c)
+
+		status, err := container.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		require.NotEmpty(t, resource.StatusDestructive(status))
+
+		g := graph.New()
+		meta := node.New("root", &plan.Result{Status: status, Task: container})
+		if allow {
+			require.NoError(t, meta.AddMetadata(node.MetaAllowDestructive, true))
+		}
+		g.Add(meta)
+		require.NoError(t, g.Validate())
+		return g
+	}
+
+	t.Run("refused", func(t *testing.T) {
+		applied, err := apply.Apply(context.Background(), recreate(false))
+		assert.Equal(t, apply.ErrTreeContainsErrors, err)
+
+		result := graphutils.GetApplyResult(t, applied, "root")
+		assert.False(t, result.Ran)
+		code, _ := errcode.Of(result.Error())
+		assert.Equal(t, errcode.ApplyDestructive, code)
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		applied, err := apply.Apply(context.Background(), recreate(true))
+		assert.NoError(t, err)
+		assert.True(t, graphutils.GetApplyResult(t, applied, "root").Ran)
+	})
+}
+
 type fakeAPIClient struct {
 	FindImageFunc       func(repoTag string) (*dc.Image, error)
 	PullImageFunc       func(name, tag string) error
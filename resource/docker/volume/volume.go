@@ -84,6 +84,9 @@ func (v *Volume) Check(context.Context, resource.Renderer) (resource.TaskStatus,
 	}
 
 	status.RaiseLevelForDiffs()
+	if vol != nil && status.HasChanges() {
+		status.MarkDestructive(fmt.Sprintf("volume %s will be removed", v.Name))
+	}
 
 	return status, nil
 }
@@ -0,0 +1,245 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package acl
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/command"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// ACL manages the POSIX access control list of a file or directory
+type ACL struct {
+	// the file or directory whose ACL is managed
+	Destination string `export:"destination"`
+
+	// the managed entries, in the form setfacl uses
+	Entries []string `export:"entries"`
+
+	// if true, named and default entries which are not managed are removed
+	Purge bool `export:"purge"`
+
+	entries []Entry
+	runner  command.Runner
+}
+
+// New creates an ACL task for the given entries
+func New(destination string, entries []Entry, purge bool, runner command.Runner) *ACL {
+	acl := &ACL{
+		Destination: destination,
+		Purge:       purge,
+		entries:     entries,
+		runner:      runner,
+	}
+	for _, entry := range entries {
+		acl.Entries = append(acl.Entries, entry.String())
+	}
+	return acl
+}
+
+// changes are the setfacl operations needed to converge the ACL
+type changes struct {
+	modify         []Entry
+	remove         []Entry
+	removeDefaults bool
+}
+
+// Claims returns the file or directory
+func (a *ACL) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimPath(a.Destination)}
+}
+
+// Check compares each managed entry with the current ACL
+func (a *ACL) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	info, err := os.Stat(a.Destination)
+	switch {
+	case os.IsNotExist(err):
+		status.AddMessage(fmt.Sprintf("%s: does not exist; will re-attempt during apply", a.Destination))
+		status.RaiseLevel(resource.StatusMayChange)
+		return status, nil
+	case err != nil:
+		return nil, errors.Wrapf(err, "could not stat %q", a.Destination)
+	case !info.IsDir() && a.hasDefaults():
+		status.AddMessage(fmt.Sprintf("%s: default entries can only be set on directories", a.Destination))
+		status.RaiseLevel(resource.StatusCantChange)
+		return status, nil
+	}
+
+	_, err = a.diff(status)
+	if err != nil {
+		return nil, err
+	}
+
+	status.RaiseLevelForDiffs()
+	return status, nil
+}
+
+// Apply removes unmanaged entries, when purging, and then sets the managed
+// entries which differ
+func (a *ACL) Apply(context.Context) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	c, err := a.diff(status)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.removeDefaults {
+		if err := a.setfacl("-k"); err != nil {
+			return nil, err
+		}
+	}
+	if len(c.remove) > 0 {
+		if err := a.setfacl("-x", keys(c.remove)); err != nil {
+			return nil, err
+		}
+	}
+	if len(c.modify) > 0 {
+		if err := a.setfacl("-m", specs(c.modify)); err != nil {
+			return nil, err
+		}
+	}
+
+	status.RaiseLevelForDiffs()
+	return status, nil
+}
+
+// diff adds a difference to the status for each entry which will change, and
+// returns the changes to make
+func (a *ACL) diff(status *resource.Status) (*changes, error) {
+	current, err := a.current()
+	if err != nil {
+		return nil, err
+	}
+
+	c := new(changes)
+	managed := map[string]bool{}
+	for _, entry := range a.entries {
+		managed[entry.Key()] = true
+
+		existing, ok := current[entry.Key()]
+		switch {
+		case !ok:
+			status.AddDifference(entry.Key(), "<absent>", entry.Perms, "")
+		case existing.Perms != entry.Perms:
+			status.AddDifference(entry.Key(), existing.Perms, entry.Perms, "")
+		default:
+			continue
+		}
+		c.modify = append(c.modify, entry)
+	}
+
+	if !a.Purge {
+		return c, nil
+	}
+
+	// without any managed default entries, the whole default ACL goes
+	c.removeDefaults = !a.hasDefaults() && anyDefault(current)
+
+	for _, key := range sortedKeys(current) {
+		existing := current[key]
+		switch {
+		case managed[key]:
+		case existing.Default && c.removeDefaults:
+			status.AddDifference(key, existing.Perms, "<absent>", "")
+		case !existing.Base():
+			status.AddDifference(key, existing.Perms, "<absent>", "")
+			c.remove = append(c.remove, existing)
+		}
+	}
+
+	return c, nil
+}
+
+// current reads the ACL with getfacl, keyed by entry
+func (a *ACL) current() (map[string]Entry, error) {
+	out, err := a.runner.Run("getfacl", "--omit-header", "--absolute-names", "--", a.Destination)
+	if err != nil {
+		return nil, command.Error("getfacl", out, err)
+	}
+
+	entries, err := ParseACL(string(out))
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse the ACL of %q", a.Destination)
+	}
+
+	current := map[string]Entry{}
+	for _, entry := range entries {
+		current[entry.Key()] = entry
+	}
+	return current, nil
+}
+
+func (a *ACL) setfacl(args ...string) error {
+	args = append(args, "--", a.Destination)
+	out, err := a.runner.Run("setfacl", args...)
+	if err != nil {
+		return command.Error("setfacl", out, err)
+	}
+	return nil
+}
+
+func (a *ACL) hasDefaults() bool {
+	for _, entry := range a.entries {
+		if entry.Default {
+			return true
+		}
+	}
+	return false
+}
+
+func anyDefault(entries map[string]Entry) bool {
+	for _, entry := range entries {
+		if entry.Default {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(entries map[string]Entry) []string {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// keys joins entries in the form setfacl removes them
+func keys(entries []Entry) string {
+	out := make([]string, len(entries))
+	for i, entry := range entries {
+		out[i] = entry.Key()
+	}
+	return strings.Join(out, ",")
+}
+
+// specs joins entries in the form setfacl sets them
+func specs(entries []Entry) string {
+	out := make([]string, len(entries))
+	for i, entry := range entries {
+		out[i] = entry.String()
+	}
+	return strings.Join(out, ",")
+}
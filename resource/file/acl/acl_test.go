@@ -0,0 +1,166 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package acl_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/acl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// fakeRunner answers getfacl with a fixed ACL and records setfacl calls
+type fakeRunner struct {
+	acl string
+	ran []string
+}
+
+func (f *fakeRunner) Run(name string, args ...string) ([]byte, error) {
+	if name == "getfacl" {
+		return []byte(f.acl), nil
+	}
+	f.ran = append(f.ran, strings.Join(append([]string{name}, args[:len(args)-2]...), " "))
+	return nil, nil
+}
+
+const currentACL = `user::rwx
+user:alice:r--
+user:bob:rwx
+group::r-x
+mask::rwx
+other::---
+default:user::rwx
+default:user:bob:rwx
+default:group::r-x
+default:other::---
+`
+
+func entries(t *testing.T, raw ...string) []acl.Entry {
+	var out []acl.Entry
+	for _, r := range raw {
+		entry, err := acl.ParseEntry(r)
+		require.NoError(t, err)
+		out = append(out, entry)
+	}
+	return out
+}
+
+func TestACLCheck(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-acl")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("no changes", func(t *testing.T) {
+		a := acl.New(dir, entries(t, "user:alice:r", "default:user:bob:rwx"), false, &fakeRunner{acl: currentACL})
+		status, err := a.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("per entry", func(t *testing.T) {
+		a := acl.New(dir, entries(t, "user:alice:rw", "group:dev:rx"), false, &fakeRunner{acl: currentACL})
+		status, err := a.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+
+		diffs := status.Diffs()
+		assert.Equal(t, 2, len(diffs))
+		assert.Equal(t, "r--", diffs["user:alice"].Original())
+		assert.Equal(t, "rw-", diffs["user:alice"].Current())
+		assert.Equal(t, "<absent>", diffs["group:dev"].Original())
+		assert.Equal(t, "r-x", diffs["group:dev"].Current())
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		a := acl.New(dir, entries(t, "user:alice:r"), true, &fakeRunner{acl: currentACL})
+		status, err := a.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		diffs := status.Diffs()
+		assert.Equal(t, 5, len(diffs))
+		assert.Equal(t, "<absent>", diffs["user:bob"].Current())
+		assert.Equal(t, "<absent>", diffs["default:user:bob"].Current())
+		assert.Equal(t, "<absent>", diffs["default:user:"].Current())
+		assert.NotContains(t, diffs, "mask:")
+		assert.NotContains(t, diffs, "user:")
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		a := acl.New(filepath.Join(dir, "missing"), entries(t, "user:alice:r"), false, &fakeRunner{})
+		status, err := a.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusMayChange, status.StatusCode())
+	})
+
+	t.Run("defaults on a file", func(t *testing.T) {
+		file := filepath.Join(dir, "file")
+		require.NoError(t, ioutil.WriteFile(file, nil, 0644))
+
+		a := acl.New(file, entries(t, "default:user:alice:r"), false, &fakeRunner{})
+		status, err := a.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+	})
+}
+
+func TestACLApply(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-acl")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("modify", func(t *testing.T) {
+		runner := &fakeRunner{acl: currentACL}
+		a := acl.New(dir, entries(t, "user:alice:rw", "user:bob:rwx", "group:dev:rx"), false, runner)
+		_, err := a.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"setfacl -m user:alice:rw-,group:dev:r-x"}, runner.ran)
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		runner := &fakeRunner{acl: currentACL}
+		a := acl.New(dir, entries(t, "user:alice:rw"), true, runner)
+		_, err := a.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Equal(
+			t,
+			[]string{"setfacl -k", "setfacl -x user:bob", "setfacl -m user:alice:rw-"},
+			runner.ran,
+		)
+	})
+
+	t.Run("purge keeping defaults", func(t *testing.T) {
+		runner := &fakeRunner{acl: currentACL}
+		a := acl.New(dir, entries(t, "user:alice:r", "user:bob:rwx", "default:user:alice:r"), true, runner)
+		_, err := a.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Equal(
+			t,
+			[]string{"setfacl -x default:user:bob", "setfacl -m default:user:alice:r--"},
+			runner.ran,
+		)
+	})
+}
@@ -0,0 +1,147 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package acl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Entry is a single POSIX ACL entry, like "user:alice:rw-" or
+// "default:group:dev:r-x"
+type Entry struct {
+	// Default is true for entries of a directory's default ACL, which new
+	// files in it inherit
+	Default bool
+
+	// Tag is "user", "group", "mask", or "other"
+	Tag string
+
+	// Qualifier is the user or group the entry applies to. It is empty for
+	// the owning user and group, the mask, and other.
+	Qualifier string
+
+	// Perms are the permissions, always as "rwx" with "-" for those not set
+	Perms string
+}
+
+var tags = map[string]string{
+	"u": "user", "user": "user",
+	"g": "group", "group": "group",
+	"m": "mask", "mask": "mask",
+	"o": "other", "other": "other",
+}
+
+// ParseEntry parses an entry in the form setfacl and getfacl use, accepting
+// short tags like "u:alice:rw" and "d:g:dev:rx"
+func ParseEntry(s string) (Entry, error) {
+	var entry Entry
+
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) > 0 && (parts[0] == "default" || parts[0] == "d") {
+		entry.Default = true
+		parts = parts[1:]
+	}
+
+	switch len(parts) {
+	case 3:
+		entry.Qualifier = parts[1]
+	case 2:
+		// "other::r--" and "mask::rwx" may be written without the qualifier
+	default:
+		return entry, fmt.Errorf("invalid ACL entry %q: expected [default:]tag:qualifier:perms", s)
+	}
+
+	tag, ok := tags[parts[0]]
+	if !ok {
+		return entry, fmt.Errorf("invalid ACL entry %q: unknown tag %q", s, parts[0])
+	}
+	entry.Tag = tag
+
+	if entry.Qualifier != "" && (tag == "mask" || tag == "other") {
+		return entry, fmt.Errorf("invalid ACL entry %q: %s entries can't have a qualifier", s, tag)
+	}
+
+	perms, err := parsePerms(parts[len(parts)-1])
+	if err != nil {
+		return entry, fmt.Errorf("invalid ACL entry %q: %s", s, err)
+	}
+	entry.Perms = perms
+
+	return entry, nil
+}
+
+// parsePerms normalizes permissions like "rx" or "r-x" to "r-x"
+func parsePerms(s string) (string, error) {
+	perms := []byte("---")
+	for _, c := range s {
+		switch c {
+		case 'r':
+			perms[0] = 'r'
+		case 'w':
+			perms[1] = 'w'
+		case 'x':
+			perms[2] = 'x'
+		case '-':
+		default:
+			return "", fmt.Errorf("invalid permission %q", c)
+		}
+	}
+	return string(perms), nil
+}
+
+// Key identifies the entry regardless of its permissions. It is also the form
+// setfacl takes to remove an entry.
+func (e Entry) Key() string {
+	key := e.Tag + ":" + e.Qualifier
+	if e.Default {
+		key = "default:" + key
+	}
+	return key
+}
+
+// String formats the entry for setfacl
+func (e Entry) String() string {
+	return e.Key() + ":" + e.Perms
+}
+
+// Base is true for the entries every ACL has, which mirror the file mode and
+// can't be removed: the owning user and group, and other. The mask is
+// recalculated by setfacl, so it counts too.
+func (e Entry) Base() bool {
+	return e.Qualifier == "" || e.Tag == "mask"
+}
+
+// ParseACL parses the output of getfacl, ignoring comments and the effective
+// permissions it notes after entries
+func ParseACL(out string) ([]Entry, error) {
+	var entries []Entry
+	for _, line := range strings.Split(out, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		entry, err := ParseEntry(line)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
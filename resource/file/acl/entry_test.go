@@ -0,0 +1,83 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package acl_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/resource/file/acl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEntry(t *testing.T) {
+	t.Parallel()
+
+	for raw, expected := range map[string]acl.Entry{
+		"user:alice:rw-":     {Tag: "user", Qualifier: "alice", Perms: "rw-"},
+		"u:alice:wr":         {Tag: "user", Qualifier: "alice", Perms: "rw-"},
+		"group::r-x":         {Tag: "group", Perms: "r-x"},
+		"mask::rwx":          {Tag: "mask", Perms: "rwx"},
+		"other:r":            {Tag: "other", Perms: "r--"},
+		"default:g:dev:rx":   {Default: true, Tag: "group", Qualifier: "dev", Perms: "r-x"},
+		"d:user:1001:---":    {Default: true, Tag: "user", Qualifier: "1001", Perms: "---"},
+		" user:bob:x ":       {Tag: "user", Qualifier: "bob", Perms: "--x"},
+		"default:other::r-x": {Default: true, Tag: "other", Perms: "r-x"},
+	} {
+		entry, err := acl.ParseEntry(raw)
+		require.NoError(t, err, raw)
+		assert.Equal(t, expected, entry, raw)
+	}
+
+	for raw, msg := range map[string]string{
+		"alice:rw":        `invalid ACL entry "alice:rw": unknown tag "alice"`,
+		"user:alice:rwz":  `invalid ACL entry "user:alice:rwz": invalid permission 'z'`,
+		"mask:alice:rwx":  `invalid ACL entry "mask:alice:rwx": mask entries can't have a qualifier`,
+		"user:a:b:c:rwx":  `invalid ACL entry "user:a:b:c:rwx": expected [default:]tag:qualifier:perms`,
+		"user":            `invalid ACL entry "user": expected [default:]tag:qualifier:perms`,
+		"default:u:x:y:z": `invalid ACL entry "default:u:x:y:z": expected [default:]tag:qualifier:perms`,
+	} {
+		_, err := acl.ParseEntry(raw)
+		assert.EqualError(t, err, msg, raw)
+	}
+}
+
+func TestEntryFormat(t *testing.T) {
+	t.Parallel()
+
+	entry := acl.Entry{Default: true, Tag: "user", Qualifier: "alice", Perms: "r-x"}
+	assert.Equal(t, "default:user:alice", entry.Key())
+	assert.Equal(t, "default:user:alice:r-x", entry.String())
+	assert.False(t, entry.Base())
+
+	assert.True(t, acl.Entry{Tag: "user", Perms: "rwx"}.Base())
+	assert.True(t, acl.Entry{Tag: "mask", Perms: "rwx"}.Base())
+}
+
+func TestParseACL(t *testing.T) {
+	t.Parallel()
+
+	entries, err := acl.ParseACL(`user::rwx
+user:alice:rwx	#effective:r-x
+group::r-x
+mask::r-x
+other::---
+default:user::rwx
+`)
+	require.NoError(t, err)
+	require.Equal(t, 6, len(entries))
+	assert.Equal(t, acl.Entry{Tag: "user", Qualifier: "alice", Perms: "rwx"}, entries[1])
+	assert.Equal(t, acl.Entry{Default: true, Tag: "user", Perms: "rwx"}, entries[5])
+}
@@ -0,0 +1,74 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package acl
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/asteris-llc/converge/helpers/command"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"golang.org/x/net/context"
+)
+
+// Preparer for ACL
+//
+// ACL manages the POSIX access control list of a file or directory with
+// getfacl and setfacl, which must be installed. Each entry is diffed
+// separately, and entries which aren't listed are left alone unless `purge` is
+// set.
+type Preparer struct {
+	// the file or directory whose ACL is managed
+	Destination string `hcl:"destination" required:"true" nonempty:"true"`
+
+	// the entries to set, in the form setfacl uses: "user:alice:rw-",
+	// "group:dev:r-x", "mask::rwx", or "default:user:alice:rwx" for the default
+	// ACL of a directory. Short tags like "u:alice:rw" work too. The owning
+	// user and group and other can be set with "user::", "group::", and
+	// "other::", which changes the file mode.
+	Entries []string `hcl:"entries"`
+
+	// whether to remove named user and group entries which aren't listed. If
+	// no default entries are listed, the default ACL is removed too.
+	Purge bool `hcl:"purge"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	if len(p.Entries) == 0 && !p.Purge {
+		return nil, errors.New("acl needs entries, purge, or both")
+	}
+
+	var entries []Entry
+	seen := map[string]bool{}
+	for _, raw := range p.Entries {
+		entry, err := ParseEntry(raw)
+		if err != nil {
+			return nil, err
+		}
+		if seen[entry.Key()] {
+			return nil, fmt.Errorf("duplicate ACL entry for %s", entry.Key())
+		}
+		seen[entry.Key()] = true
+		entries = append(entries, entry)
+	}
+
+	return New(p.Destination, entries, p.Purge, command.ExecRunner{}), nil
+}
+
+func init() {
+	registry.Register("file.acl", (*Preparer)(nil), (*ACL)(nil))
+}
@@ -0,0 +1,67 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package acl_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/acl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestPreparerInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Resource)(nil), new(acl.Preparer))
+}
+
+func TestPreparerPrepare(t *testing.T) {
+	t.Parallel()
+
+	t.Run("entries", func(t *testing.T) {
+		p := &acl.Preparer{Destination: "/srv/app", Entries: []string{"u:alice:rw", "d:g:dev:rx"}}
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"user:alice:rw-", "default:group:dev:r-x"}, task.(*acl.ACL).Entries)
+	})
+
+	t.Run("purge only", func(t *testing.T) {
+		p := &acl.Preparer{Destination: "/srv/app", Purge: true}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.NoError(t, err)
+	})
+
+	t.Run("nothing to manage", func(t *testing.T) {
+		p := &acl.Preparer{Destination: "/srv/app"}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "acl needs entries, purge, or both")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		p := &acl.Preparer{Destination: "/srv/app", Entries: []string{"user:alice"}}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, `invalid ACL entry "user:alice": invalid permission 'a'`)
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		p := &acl.Preparer{Destination: "/srv/app", Entries: []string{"user:alice:r", "u:alice:rw"}}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "duplicate ACL entry for user:alice")
+	})
+}
@@ -0,0 +1,175 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attributes
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/command"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// attribute is a file attribute managed by chattr
+type attribute struct {
+	name string
+	flag byte
+}
+
+var (
+	immutable  = attribute{"immutable", 'i'}
+	appendOnly = attribute{"append_only", 'a'}
+)
+
+// Attributes manages the immutable and append-only attributes of a file or
+// directory
+type Attributes struct {
+	// the file or directory whose attributes are managed
+	Destination string `export:"destination"`
+
+	// whether the file can't be changed, if it is managed
+	Immutable bool `export:"immutable"`
+
+	// whether the file can only be appended to, if it is managed
+	AppendOnly bool `export:"append_only"`
+
+	managed map[attribute]bool
+	runner  command.Runner
+}
+
+// New creates an Attributes task. A nil attribute is left alone.
+func New(destination string, immutableAttr, appendOnlyAttr *bool, runner command.Runner) *Attributes {
+	a := &Attributes{
+		Destination: destination,
+		managed:     map[attribute]bool{},
+		runner:      runner,
+	}
+	if immutableAttr != nil {
+		a.Immutable = *immutableAttr
+		a.managed[immutable] = a.Immutable
+	}
+	if appendOnlyAttr != nil {
+		a.AppendOnly = *appendOnlyAttr
+		a.managed[appendOnly] = a.AppendOnly
+	}
+	return a
+}
+
+// Claims returns the file or directory
+func (a *Attributes) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimPath(a.Destination)}
+}
+
+// Check compares each managed attribute with the current ones
+func (a *Attributes) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	_, err := os.Lstat(a.Destination)
+	switch {
+	case os.IsNotExist(err):
+		status.AddMessage(fmt.Sprintf("%s: does not exist; will re-attempt during apply", a.Destination))
+		status.RaiseLevel(resource.StatusMayChange)
+		return status, nil
+	case err != nil:
+		return nil, errors.Wrapf(err, "could not stat %q", a.Destination)
+	}
+
+	if _, err := a.diff(status); err != nil {
+		return nil, err
+	}
+
+	status.RaiseLevelForDiffs()
+	return status, nil
+}
+
+// Apply sets and clears the attributes which differ with a single chattr
+func (a *Attributes) Apply(context.Context) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	modes, err := a.diff(status)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(modes) > 0 {
+		if err := chattr(a.runner, a.Destination, modes...); err != nil {
+			return nil, err
+		}
+	}
+
+	status.RaiseLevelForDiffs()
+	return status, nil
+}
+
+// diff adds a difference to the status for each attribute which will change,
+// and returns the chattr modes to change them
+func (a *Attributes) diff(status *resource.Status) ([]string, error) {
+	current, err := lsattr(a.runner, a.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	var modes []string
+	for _, attr := range []attribute{immutable, appendOnly} {
+		want, ok := a.managed[attr]
+		if !ok {
+			continue
+		}
+
+		have := strings.IndexByte(current, attr.flag) >= 0
+		if have == want {
+			continue
+		}
+
+		status.AddDifference(attr.name, strconv.FormatBool(have), strconv.FormatBool(want), "")
+		if want {
+			modes = append(modes, "+"+string(attr.flag))
+		} else {
+			modes = append(modes, "-"+string(attr.flag))
+		}
+	}
+	return modes, nil
+}
+
+// lsattr returns the attribute flags of a file, like "----i---------e-------"
+func lsattr(runner command.Runner, path string) (string, error) {
+	out, err := runner.Run("lsattr", "-d", "--", path)
+	if err != nil {
+		return "", command.Error("lsattr", out, err)
+	}
+
+	fields := strings.Fields(string(out))
+	if len(fields) < 2 {
+		return "", fmt.Errorf("unexpected output from lsattr: %q", string(out))
+	}
+	return fields[0], nil
+}
+
+// chattr changes attributes. chattr reads arguments starting with "-" as
+// modes and doesn't accept "--", so relative paths are made unambiguous.
+func chattr(runner command.Runner, path string, modes ...string) error {
+	if strings.HasPrefix(path, "-") {
+		path = "./" + path
+	}
+	out, err := runner.Run("chattr", append(modes, path)...)
+	if err != nil {
+		return command.Error("chattr", out, err)
+	}
+	return nil
+}
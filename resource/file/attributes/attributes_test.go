@@ -0,0 +1,126 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attributes_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/attributes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// fakeRunner answers lsattr with fixed flags and records chattr calls
+type fakeRunner struct {
+	flags string
+	err   error
+	ran   []string
+}
+
+func (f *fakeRunner) Run(name string, args ...string) ([]byte, error) {
+	if name == "lsattr" {
+		if f.err != nil {
+			return []byte("lsattr: Operation not supported"), f.err
+		}
+		return []byte(f.flags + " " + args[len(args)-1] + "\n"), nil
+	}
+	f.ran = append(f.ran, strings.Join(append([]string{name}, args[:len(args)-1]...), " "))
+	return nil, nil
+}
+
+func yes() *bool { b := true; return &b }
+func no() *bool  { b := false; return &b }
+
+func TestAttributesCheck(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-attributes")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("no changes", func(t *testing.T) {
+		a := attributes.New(dir, yes(), nil, &fakeRunner{flags: "----i---------e-------"})
+		status, err := a.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		a := attributes.New(dir, yes(), no(), &fakeRunner{flags: "-----a--------e-------"})
+		status, err := a.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+
+		diffs := status.Diffs()
+		assert.Equal(t, 2, len(diffs))
+		assert.Equal(t, "false", diffs["immutable"].Original())
+		assert.Equal(t, "true", diffs["immutable"].Current())
+		assert.Equal(t, "true", diffs["append_only"].Original())
+		assert.Equal(t, "false", diffs["append_only"].Current())
+	})
+
+	t.Run("unmanaged", func(t *testing.T) {
+		a := attributes.New(dir, nil, yes(), &fakeRunner{flags: "----ia--------e-------"})
+		status, err := a.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		a := attributes.New(filepath.Join(dir, "missing"), yes(), nil, &fakeRunner{})
+		status, err := a.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusMayChange, status.StatusCode())
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		a := attributes.New(dir, yes(), nil, &fakeRunner{err: errors.New("exit status 1")})
+		_, err := a.Check(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "lsattr: exit status 1: lsattr: Operation not supported")
+	})
+}
+
+func TestAttributesApply(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-attributes")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("changes", func(t *testing.T) {
+		runner := &fakeRunner{flags: "-----a--------e-------"}
+		a := attributes.New(dir, yes(), no(), runner)
+		status, err := a.Apply(context.Background())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, []string{"chattr +i -a"}, runner.ran)
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		runner := &fakeRunner{flags: "----i---------e-------"}
+		a := attributes.New(dir, yes(), nil, runner)
+		_, err := a.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, runner.ran)
+	})
+}
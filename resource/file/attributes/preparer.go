@@ -0,0 +1,56 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attributes
+
+import (
+	"errors"
+
+	"github.com/asteris-llc/converge/helpers/command"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"golang.org/x/net/context"
+)
+
+// Preparer for Attributes
+//
+// Attributes sets or clears the immutable and append-only attributes of a file
+// or directory with lsattr and chattr, which must be installed. Attributes
+// which aren't specified are left alone. `file.content` and `file.mode` lift
+// these attributes while they change a file, and set them again afterwards, so
+// a protected file can still be managed in the same run.
+type Preparer struct {
+	// the file or directory whose attributes are managed
+	Destination string `hcl:"destination" required:"true" nonempty:"true"`
+
+	// whether the file can't be changed, deleted, renamed, or linked to, even
+	// by root (`chattr +i`)
+	Immutable *bool `hcl:"immutable"`
+
+	// whether the file can only be opened for appending (`chattr +a`)
+	AppendOnly *bool `hcl:"append_only"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	if p.Immutable == nil && p.AppendOnly == nil {
+		return nil, errors.New("attributes needs immutable, append_only, or both")
+	}
+
+	return New(p.Destination, p.Immutable, p.AppendOnly, command.ExecRunner{}), nil
+}
+
+func init() {
+	registry.Register("file.attributes", (*Preparer)(nil), (*Attributes)(nil))
+}
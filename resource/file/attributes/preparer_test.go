@@ -0,0 +1,52 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attributes_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/attributes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestPreparerInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Resource)(nil), new(attributes.Preparer))
+}
+
+func TestPreparerPrepare(t *testing.T) {
+	t.Parallel()
+
+	t.Run("immutable", func(t *testing.T) {
+		p := &attributes.Preparer{Destination: "/etc/resolv.conf", Immutable: yes()}
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		a := task.(*attributes.Attributes)
+		assert.True(t, a.Immutable)
+		assert.False(t, a.AppendOnly)
+	})
+
+	t.Run("nothing to manage", func(t *testing.T) {
+		p := &attributes.Preparer{Destination: "/etc/resolv.conf"}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "attributes needs immutable, append_only, or both")
+	})
+}
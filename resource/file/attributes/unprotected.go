@@ -0,0 +1,55 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attributes
+
+import (
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/command"
+)
+
+// Unprotected runs fn with the immutable and append-only attributes of path
+// cleared, setting them again afterwards, so resources can rewrite files that
+// are protected by `file.attributes` in the same run. Resources call it after
+// a change fails with a permission error, so lsattr isn't run for every
+// change. If the attributes can't be read, because path doesn't exist or the
+// filesystem doesn't support them, fn is just run again.
+func Unprotected(runner command.Runner, path string, fn func() error) error {
+	current, err := lsattr(runner, path)
+	if err != nil {
+		return fn()
+	}
+
+	var set, cleared []string
+	for _, attr := range []attribute{immutable, appendOnly} {
+		if strings.IndexByte(current, attr.flag) >= 0 {
+			set = append(set, "+"+string(attr.flag))
+			cleared = append(cleared, "-"+string(attr.flag))
+		}
+	}
+	if len(set) == 0 {
+		return fn()
+	}
+
+	if err := chattr(runner, path, cleared...); err != nil {
+		return err
+	}
+
+	fnErr := fn()
+	if err := chattr(runner, path, set...); err != nil && fnErr == nil {
+		return err
+	}
+	return fnErr
+}
@@ -0,0 +1,69 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attributes_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/asteris-llc/converge/resource/file/attributes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnprotected(t *testing.T) {
+	t.Parallel()
+
+	t.Run("protected", func(t *testing.T) {
+		runner := &fakeRunner{flags: "----ia--------e-------"}
+		err := attributes.Unprotected(runner, "/etc/resolv.conf", func() error {
+			runner.ran = append(runner.ran, "fn")
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"chattr -i -a", "fn", "chattr +i +a"}, runner.ran)
+	})
+
+	t.Run("restored on error", func(t *testing.T) {
+		runner := &fakeRunner{flags: "----i---------e-------"}
+		err := attributes.Unprotected(runner, "/etc/resolv.conf", func() error {
+			return errors.New("failed")
+		})
+		assert.EqualError(t, err, "failed")
+		assert.Equal(t, []string{"chattr -i", "chattr +i"}, runner.ran)
+	})
+
+	t.Run("unprotected", func(t *testing.T) {
+		runner := &fakeRunner{flags: "--------------e-------"}
+		called := false
+		err := attributes.Unprotected(runner, "/etc/resolv.conf", func() error {
+			called = true
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, called)
+		assert.Empty(t, runner.ran)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		runner := &fakeRunner{err: errors.New("exit status 1")}
+		called := false
+		err := attributes.Unprotected(runner, "/etc/resolv.conf", func() error {
+			called = true
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.True(t, called)
+	})
+}
@@ -0,0 +1,121 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package backup copies files before resources change them
+package backup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TimeFormat is the format of the timestamp in backup names
+const TimeFormat = "20060102T150405Z"
+
+// Copy copies the file at path to a backup named "<name>.<timestamp>.bak".
+// The backup is made next to the file, or when dir is set, at the file's
+// absolute path below dir, so files with the same name in different
+// directories don't collide. The backup keeps the mode, owner, and
+// modification time of the file. Only regular files are backed up: if path
+// does not exist or is not a regular file, no backup is made and the returned
+// path is empty.
+func Copy(path, dir string, at time.Time) (string, error) {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", errors.Wrapf(err, "could not back up %s", path)
+	}
+	if !info.Mode().IsRegular() {
+		return "", nil
+	}
+
+	name, err := Name(path, dir, at)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+		return "", errors.Wrapf(err, "could not create backup directory for %s", path)
+	}
+
+	dest, err := create(name, info.Mode().Perm())
+	if err != nil {
+		return "", errors.Wrapf(err, "could not back up %s", path)
+	}
+
+	if err := copyFile(path, dest); err != nil {
+		os.Remove(dest.Name())
+		return "", errors.Wrapf(err, "could not back up %s", path)
+	}
+
+	preserve(dest.Name(), info)
+	return dest.Name(), nil
+}
+
+// Name returns the name of a backup of path made at the given time, without
+// any suffix added to avoid an existing backup
+func Name(path, dir string, at time.Time) (string, error) {
+	name := fmt.Sprintf("%s.%s.bak", path, at.UTC().Format(TimeFormat))
+	if dir == "" {
+		return name, nil
+	}
+
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not back up %s", path)
+	}
+	return filepath.Join(dir, abs), nil
+}
+
+// create creates a new backup file, adding a numeric suffix if a backup made
+// in the same second already exists
+func create(name string, perm os.FileMode) (*os.File, error) {
+	candidate := name
+	for i := 1; ; i++ {
+		f, err := os.OpenFile(candidate, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+		if !os.IsExist(err) {
+			return f, err
+		}
+		candidate = fmt.Sprintf("%s.%d", name, i)
+	}
+}
+
+func copyFile(path string, dest *os.File) error {
+	src, err := os.Open(path)
+	if err != nil {
+		dest.Close()
+		return err
+	}
+	defer src.Close()
+
+	if _, err := io.Copy(dest, src); err != nil {
+		dest.Close()
+		return err
+	}
+	return dest.Close()
+}
+
+// preserve copies the mode, owner, and modification time of the original to
+// the backup. Failing to, like when changing the owner as a regular user, is
+// not fatal: the content is what matters.
+func preserve(name string, info os.FileInfo) {
+	os.Chmod(name, info.Mode().Perm())
+	chown(name, info)
+	os.Chtimes(name, info.ModTime(), info.ModTime())
+}
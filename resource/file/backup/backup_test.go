@@ -0,0 +1,87 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/resource/file/backup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopy(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2016, 9, 1, 12, 30, 0, 0, time.UTC)
+
+	dir, err := ioutil.TempDir("", "converge-backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.conf")
+	require.NoError(t, ioutil.WriteFile(path, []byte("original"), 0640))
+
+	t.Run("next to file", func(t *testing.T) {
+		name, err := backup.Copy(path, "", at)
+		require.NoError(t, err)
+		assert.Equal(t, path+".20160901T123000Z.bak", name)
+
+		content, err := ioutil.ReadFile(name)
+		require.NoError(t, err)
+		assert.Equal(t, "original", string(content))
+
+		info, err := os.Stat(name)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	})
+
+	t.Run("same second", func(t *testing.T) {
+		name, err := backup.Copy(path, "", at.Add(time.Minute))
+		require.NoError(t, err)
+
+		again, err := backup.Copy(path, "", at.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, name+".1", again)
+	})
+
+	t.Run("backup dir", func(t *testing.T) {
+		backups := filepath.Join(dir, "backups")
+
+		name, err := backup.Copy(path, backups, at)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(backups, path+".20160901T123000Z.bak"), name)
+
+		content, err := ioutil.ReadFile(name)
+		require.NoError(t, err)
+		assert.Equal(t, "original", string(content))
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		name, err := backup.Copy(filepath.Join(dir, "missing"), "", at)
+		assert.NoError(t, err)
+		assert.Equal(t, "", name)
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		name, err := backup.Copy(dir, "", at)
+		assert.NoError(t, err)
+		assert.Equal(t, "", name)
+	})
+}
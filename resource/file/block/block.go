@@ -0,0 +1,194 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package block
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/command"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/attributes"
+	"golang.org/x/net/context"
+)
+
+// State type for Block
+type State string
+
+const (
+	// StatePresent indicates the block should be in the file
+	StatePresent State = "present"
+
+	// StateAbsent indicates the block and its markers should be removed
+	StateAbsent State = "absent"
+)
+
+// MarkPlaceholder is replaced with "BEGIN" and "END" in markers
+const MarkPlaceholder = "{mark}"
+
+// DefaultMarker is the marker used when none is set
+const DefaultMarker = "# " + MarkPlaceholder + " CONVERGE MANAGED BLOCK"
+
+// Block manages a block of lines between markers in a file
+type Block struct {
+	// the file the block is in
+	Destination string `export:"destination"`
+
+	// the lines between the markers
+	Block string `export:"block"`
+
+	// the marker lines, with MarkPlaceholder for "BEGIN" or "END"
+	Marker string `export:"marker"`
+
+	// whether the block should be in the file
+	State State `export:"state"`
+
+	// whether the file is created if it doesn't exist
+	Create bool `export:"create"`
+}
+
+// Claims returns the file the block is in
+func (b *Block) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimPath(b.Destination)}
+}
+
+// Begin is the line that starts the block
+func (b *Block) Begin() string {
+	return strings.Replace(b.Marker, MarkPlaceholder, "BEGIN", -1)
+}
+
+// End is the line that ends the block
+func (b *Block) End() string {
+	return strings.Replace(b.Marker, MarkPlaceholder, "END", -1)
+}
+
+// Check whether the block needs to be changed
+func (b *Block) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	original, missing, err := b.read()
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+
+	if missing && b.State == StatePresent && !b.Create {
+		// the file may be created by another resource in the same run
+		status.RaiseLevel(resource.StatusMayChange)
+		status.SetWarning(fmt.Sprintf("%s does not exist", b.Destination))
+		return status, nil
+	}
+
+	updated, err := b.update(original, missing)
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+
+	b.diff(status, original, updated, missing)
+	status.RaiseLevelForDiffs()
+	return status, nil
+}
+
+// Apply writes the block to the file
+func (b *Block) Apply(context.Context) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	original, missing, err := b.read()
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+
+	if missing && b.State == StatePresent && !b.Create {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, fmt.Errorf("cannot add block to %s: it does not exist", b.Destination)
+	}
+
+	updated, err := b.update(original, missing)
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+
+	b.diff(status, original, updated, missing)
+	if !status.HasChanges() {
+		return status, nil
+	}
+
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(b.Destination); err == nil {
+		perm = info.Mode()
+	}
+
+	write := func() error { return ioutil.WriteFile(b.Destination, []byte(updated), perm) }
+	err = write()
+	if os.IsPermission(err) {
+		// the file may be immutable or append-only
+		err = attributes.Unprotected(command.ExecRunner{}, b.Destination, write)
+	}
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+
+	status.RaiseLevelForDiffs()
+	return status, nil
+}
+
+// read reads the file, which may be missing
+func (b *Block) read() (content string, missing bool, err error) {
+	data, err := ioutil.ReadFile(b.Destination)
+	if os.IsNotExist(err) {
+		return "", true, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	return string(data), false, nil
+}
+
+// update returns the content of the file with the block set or removed
+func (b *Block) update(original string, missing bool) (string, error) {
+	if missing && b.State == StateAbsent {
+		return "", nil
+	}
+
+	updated, err := splice(original, b.Begin(), b.End(), b.Block, b.State == StateAbsent)
+	if err != nil {
+		return "", fmt.Errorf("cannot update block in %s: %s", b.Destination, err)
+	}
+	return updated, nil
+}
+
+// diff adds the change to the file to a status
+func (b *Block) diff(status *resource.Status, original, updated string, missing bool) {
+	if missing && b.State == StateAbsent {
+		return
+	}
+
+	diff := resource.ContentDiff{
+		Name:    b.Destination,
+		Values:  [2]string{original, updated},
+		Missing: missing,
+	}
+	if missing {
+		diff.Values[0] = "<file-missing>"
+	}
+	if diff.Changes() {
+		status.Differences[b.Destination] = diff
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package block_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/block"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestBlockInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(block.Block))
+}
+
+func TestBlockApply(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-block")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	marker := "# {mark} test"
+
+	apply := func(t *testing.T, original string, b *block.Block) string {
+		require.NoError(t, ioutil.WriteFile(b.Destination, []byte(original), 0600))
+
+		status, err := b.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		changes := status.HasChanges()
+
+		status, err = b.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, changes, status.HasChanges())
+
+		info, err := os.Stat(b.Destination)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode())
+
+		out, err := ioutil.ReadFile(b.Destination)
+		require.NoError(t, err)
+
+		status, err = b.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+
+		return string(out)
+	}
+
+	t.Run("adds markers", func(t *testing.T) {
+		b := &block.Block{Destination: filepath.Join(dir, "add"), Block: "a\nb\n", Marker: marker, State: block.StatePresent}
+
+		assert.Equal(
+			t,
+			"127.0.0.1 localhost\n# BEGIN test\na\nb\n# END test\n",
+			apply(t, "127.0.0.1 localhost", b),
+		)
+	})
+
+	t.Run("replaces between markers", func(t *testing.T) {
+		b := &block.Block{Destination: filepath.Join(dir, "replace"), Block: "new", Marker: marker, State: block.StatePresent}
+
+		assert.Equal(
+			t,
+			"before\n# BEGIN test\nnew\n# END test\nafter\n",
+			apply(t, "before\n# BEGIN test\nold\nolder\n# END test\nafter\n", b),
+		)
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		b := &block.Block{Destination: filepath.Join(dir, "unchanged"), Block: "same", Marker: marker, State: block.StatePresent}
+
+		original := "# BEGIN test\nsame\n# END test\n"
+		assert.Equal(t, original, apply(t, original, b))
+	})
+
+	t.Run("removes", func(t *testing.T) {
+		b := &block.Block{Destination: filepath.Join(dir, "remove"), Marker: marker, State: block.StateAbsent}
+
+		assert.Equal(
+			t,
+			"before\nafter\n",
+			apply(t, "before\n# BEGIN test\nold\n# END test\nafter\n", b),
+		)
+	})
+
+	t.Run("unterminated", func(t *testing.T) {
+		b := &block.Block{Destination: filepath.Join(dir, "unterminated"), Block: "new", Marker: marker, State: block.StatePresent}
+		require.NoError(t, ioutil.WriteFile(b.Destination, []byte("# BEGIN test\nold\n"), 0600))
+
+		_, err := b.Check(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, `cannot update block in `+b.Destination+`: found "# BEGIN test" without "# END test"`)
+	})
+}
+
+func TestBlockMissingFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-block")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("without create", func(t *testing.T) {
+		b := &block.Block{Destination: filepath.Join(dir, "missing"), Block: "a", Marker: block.DefaultMarker, State: block.StatePresent}
+
+		status, err := b.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusMayChange, status.StatusCode())
+
+		_, err = b.Apply(context.Background())
+		assert.Error(t, err)
+	})
+
+	t.Run("with create", func(t *testing.T) {
+		b := &block.Block{Destination: filepath.Join(dir, "created"), Block: "a", Marker: block.DefaultMarker, State: block.StatePresent, Create: true}
+
+		status, err := b.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+
+		_, err = b.Apply(context.Background())
+		require.NoError(t, err)
+
+		out, err := ioutil.ReadFile(b.Destination)
+		require.NoError(t, err)
+		assert.Equal(t, "# BEGIN CONVERGE MANAGED BLOCK\na\n# END CONVERGE MANAGED BLOCK\n", string(out))
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		b := &block.Block{Destination: filepath.Join(dir, "absent"), Marker: block.DefaultMarker, State: block.StateAbsent}
+
+		status, err := b.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+}
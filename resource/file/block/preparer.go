@@ -0,0 +1,91 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package block
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"golang.org/x/net/context"
+)
+
+// Preparer for Block
+//
+// Block manages a block of lines in a file, between a line that begins it and
+// a line that ends it, leaving the rest of the file alone. If the markers are
+// not in the file, they are added at its end along with the block. This is
+// useful for adding entries to files like `/etc/hosts` or `sshd_config` that
+// other tools also change.
+type Preparer struct {
+	// Destination is the file the block is in
+	Destination string `hcl:"destination" required:"true" nonempty:"true"`
+
+	// Block is the lines between the markers
+	Block string `hcl:"block"`
+
+	// Marker is the line that begins and ends the block, with "{mark}"
+	// replaced by "BEGIN" and "END". Files with more than one block need a
+	// different marker for each one. The default is
+	// "# {mark} CONVERGE MANAGED BLOCK".
+	Marker string `hcl:"marker" nonempty:"true"`
+
+	// State is whether the block should be in the file. When it is absent the
+	// markers are removed with it.
+	State State `hcl:"state" valid_values:"present,absent"`
+
+	// Create creates the file if it doesn't exist. Otherwise a missing file is
+	// an error when the block is applied.
+	Create bool `hcl:"create"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	if p.Marker == "" {
+		p.Marker = DefaultMarker
+	}
+	if !strings.Contains(p.Marker, MarkPlaceholder) {
+		return nil, fmt.Errorf("marker needs %s, to tell the beginning of the block from its end", MarkPlaceholder)
+	}
+	if strings.Contains(p.Marker, "\n") {
+		return nil, errors.New("marker must be a single line")
+	}
+
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	b := &Block{
+		Destination: p.Destination,
+		Block:       p.Block,
+		Marker:      p.Marker,
+		State:       p.State,
+		Create:      p.Create,
+	}
+
+	for _, line := range strings.Split(p.Block, "\n") {
+		if line == b.Begin() || line == b.End() {
+			return nil, fmt.Errorf("block can't contain its marker %q", line)
+		}
+	}
+
+	return b, nil
+}
+
+func init() {
+	registry.Register("file.block", (*Preparer)(nil), (*Block)(nil))
+}
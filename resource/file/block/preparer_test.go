@@ -0,0 +1,63 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package block_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/block"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestPreparerInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Resource)(nil), new(block.Preparer))
+}
+
+func TestPreparerPrepare(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		p := &block.Preparer{Destination: "/etc/hosts", Block: "10.0.0.1 db"}
+
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		b, ok := task.(*block.Block)
+		require.True(t, ok)
+		assert.Equal(t, block.StatePresent, b.State)
+		assert.Equal(t, "# BEGIN CONVERGE MANAGED BLOCK", b.Begin())
+		assert.Equal(t, "# END CONVERGE MANAGED BLOCK", b.End())
+	})
+
+	t.Run("marker without placeholder", func(t *testing.T) {
+		p := &block.Preparer{Destination: "/etc/hosts", Marker: "# converge"}
+
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "marker needs {mark}, to tell the beginning of the block from its end")
+	})
+
+	t.Run("block containing marker", func(t *testing.T) {
+		p := &block.Preparer{Destination: "/etc/hosts", Block: "a\n# END CONVERGE MANAGED BLOCK\nb"}
+
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, `block can't contain its marker "# END CONVERGE MANAGED BLOCK"`)
+	})
+}
@@ -0,0 +1,73 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package block
+
+import (
+	"fmt"
+	"strings"
+)
+
+// splice sets the lines between the begin and end markers in content to
+// block, adding the markers and block at the end of the content if they
+// aren't found. When remove is set, the markers and the lines between them
+// are removed instead.
+func splice(content, begin, end, block string, remove bool) (string, error) {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	start, stop := -1, -1
+	for i, line := range lines {
+		trimmed := strings.TrimRight(line, "\r\n")
+		if start < 0 && trimmed == begin {
+			start = i
+		} else if start >= 0 && trimmed == end {
+			stop = i
+			break
+		}
+	}
+	if start >= 0 && stop < 0 {
+		return "", fmt.Errorf("found %q without %q", begin, end)
+	}
+
+	var replacement []string
+	if !remove {
+		replacement = append(replacement, begin+"\n")
+		if block != "" {
+			for _, line := range strings.SplitAfter(strings.TrimSuffix(block, "\n")+"\n", "\n") {
+				if line != "" {
+					replacement = append(replacement, line)
+				}
+			}
+		}
+		replacement = append(replacement, end+"\n")
+	}
+
+	var out []string
+	if start >= 0 {
+		out = append(out, lines[:start]...)
+		out = append(out, replacement...)
+		out = append(out, lines[stop+1:]...)
+	} else {
+		out = append(out, lines...)
+		if len(out) > 0 && len(replacement) > 0 && !strings.HasSuffix(out[len(out)-1], "\n") {
+			out[len(out)-1] += "\n"
+		}
+		out = append(out, replacement...)
+	}
+
+	return strings.Join(out, ""), nil
+}
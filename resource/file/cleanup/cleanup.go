@@ -0,0 +1,172 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cleanup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/filter"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// Cleanup removes files matching globs from a directory
+type Cleanup struct {
+	resource.TaskStatus
+
+	// the directory cleaned up
+	Directory string `export:"directory"`
+
+	// globs of the files to remove
+	Include []string `export:"include"`
+
+	// globs of the files to keep
+	Exclude []string `export:"exclude"`
+
+	// if true, files below subdirectories are removed too
+	Recursive bool `export:"recursive"`
+
+	// only files older than this are removed, if set
+	OlderThan time.Duration `export:"older_than"`
+
+	// only files larger than this many bytes are removed, if set
+	LargerThan int64 `export:"larger_than"`
+
+	// only files smaller than this many bytes are removed, if set
+	SmallerThan int64 `export:"smaller_than"`
+
+	// the files that were removed when applying
+	Removed []string `export:"removed"`
+}
+
+// Claims returns the directory
+func (c *Cleanup) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimPath(c.Directory)}
+}
+
+// Check lists the files that will be removed
+func (c *Cleanup) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+	c.TaskStatus = status
+
+	info, err := os.Stat(c.Directory)
+	switch {
+	case os.IsNotExist(err):
+		status.AddMessage(fmt.Sprintf("%q does not exist, there is nothing to clean up", c.Directory))
+		return status, nil
+	case err != nil:
+		return nil, errors.Wrapf(err, "could not stat %q", c.Directory)
+	case !info.IsDir():
+		status.RaiseLevel(resource.StatusCantChange)
+		status.AddMessage(fmt.Sprintf("%q is not a directory", c.Directory))
+		return status, nil
+	}
+
+	matched, err := c.matching(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matched) == 0 {
+		status.AddMessage("no files to remove")
+		return status, nil
+	}
+
+	status.RaiseLevel(resource.StatusWillChange)
+	for _, path := range matched {
+		status.AddDifference(path, "<present>", "<absent>", "")
+	}
+	status.AddMessage(fmt.Sprintf("%d files will be removed", len(matched)))
+	status.MarkDestructive(fmt.Sprintf("%d files in %s will be removed", len(matched), c.Directory))
+	return status, nil
+}
+
+// Apply removes the matching files. They are found again, so files that
+// appeared or changed since planning are judged as they are now.
+func (c *Cleanup) Apply(context.Context) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+	c.Removed = nil
+
+	if _, err := os.Stat(c.Directory); os.IsNotExist(err) {
+		status.AddMessage(fmt.Sprintf("%q does not exist, there is nothing to clean up", c.Directory))
+		return status, nil
+	}
+
+	matched, err := c.matching(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range matched {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			status.RaiseLevel(resource.StatusFatal)
+			status.AddMessage(fmt.Sprintf("removed %d files", len(c.Removed)))
+			return status, errors.Wrapf(err, "could not remove %q", path)
+		}
+		c.Removed = append(c.Removed, path)
+		status.AddDifference(path, "<present>", "<absent>", "")
+	}
+
+	status.RaiseLevel(resource.StatusWillChange)
+	status.AddMessage(fmt.Sprintf("removed %d files", len(c.Removed)))
+	return status, nil
+}
+
+// matching finds the files to remove, judging their age from now
+func (c *Cleanup) matching(now time.Time) ([]string, error) {
+	var matched []string
+
+	f := &filter.Filter{Include: c.Include, Exclude: c.Exclude}
+	walk := f.Wrap(c.Directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		if c.OlderThan > 0 && now.Sub(info.ModTime()) <= c.OlderThan {
+			return nil
+		}
+		if c.LargerThan > 0 && info.Size() <= c.LargerThan {
+			return nil
+		}
+		if c.SmallerThan > 0 && info.Size() >= c.SmallerThan {
+			return nil
+		}
+
+		matched = append(matched, path)
+		return nil
+	})
+
+	err := filepath.Walk(c.Directory, func(path string, info os.FileInfo, err error) error {
+		// the filter walks directories that aren't included, so subdirectories
+		// are skipped before it sees them
+		if err == nil && info.IsDir() && path != c.Directory && !c.Recursive {
+			return filepath.SkipDir
+		}
+		return walk(path, info, err)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not list the files in %q", c.Directory)
+	}
+
+	return matched, nil
+}
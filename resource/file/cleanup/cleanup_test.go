@@ -0,0 +1,178 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cleanup_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/cleanup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestCleanupInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(cleanup.Cleanup))
+}
+
+// setup creates a directory of log files of different ages and sizes
+func setup(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "converge-cleanup")
+	require.NoError(t, err)
+
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	for name, file := range map[string]struct {
+		size int
+		old  bool
+	}{
+		"app.log":          {10, false},
+		"app.1.log":        {10, true},
+		"big.log":          {4096, true},
+		"keep.conf":        {10, true},
+		"archive/app.log":  {10, true},
+		"archive/keep.txt": {10, true},
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(strings.Repeat("x", file.size)), 0644))
+		if file.old {
+			require.NoError(t, os.Chtimes(path, old, old))
+		}
+	}
+
+	return dir
+}
+
+func planned(t *testing.T, c *cleanup.Cleanup) []string {
+	status, err := c.Check(context.Background(), fakerenderer.New())
+	require.NoError(t, err)
+
+	var paths []string
+	for path := range status.Diffs() {
+		rel, err := filepath.Rel(c.Directory, path)
+		require.NoError(t, err)
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	return paths
+}
+
+func TestCleanupCheck(t *testing.T) {
+	t.Parallel()
+
+	dir := setup(t)
+	defer os.RemoveAll(dir)
+
+	t.Run("globs", func(t *testing.T) {
+		c := &cleanup.Cleanup{Directory: dir, Include: []string{"*.log"}}
+		assert.Equal(t, []string{"app.1.log", "app.log", "big.log"}, sorted(planned(t, c)))
+	})
+
+	t.Run("exclude", func(t *testing.T) {
+		c := &cleanup.Cleanup{Directory: dir, Include: []string{"*.log"}, Exclude: []string{"app.*"}}
+		assert.Equal(t, []string{"big.log"}, planned(t, c))
+	})
+
+	t.Run("recursive", func(t *testing.T) {
+		c := &cleanup.Cleanup{Directory: dir, Include: []string{"*.log"}, Recursive: true}
+		assert.Equal(t, []string{"app.1.log", "app.log", "archive/app.log", "big.log"}, sorted(planned(t, c)))
+	})
+
+	t.Run("older than", func(t *testing.T) {
+		c := &cleanup.Cleanup{Directory: dir, Include: []string{"*.log"}, OlderThan: 7 * 24 * time.Hour}
+		assert.Equal(t, []string{"app.1.log", "big.log"}, sorted(planned(t, c)))
+	})
+
+	t.Run("sizes", func(t *testing.T) {
+		larger := &cleanup.Cleanup{Directory: dir, Include: []string{"*.log"}, LargerThan: 1024}
+		assert.Equal(t, []string{"big.log"}, planned(t, larger))
+
+		smaller := &cleanup.Cleanup{Directory: dir, Include: []string{"*.log"}, SmallerThan: 1024}
+		assert.Equal(t, []string{"app.1.log", "app.log"}, sorted(planned(t, smaller)))
+	})
+
+	t.Run("destructive", func(t *testing.T) {
+		c := &cleanup.Cleanup{Directory: dir, Include: []string{"big.log"}}
+		status, err := c.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		assert.Equal(t, []string{"1 files in " + dir + " will be removed"}, resource.StatusDestructive(status))
+	})
+
+	t.Run("nothing to remove", func(t *testing.T) {
+		c := &cleanup.Cleanup{Directory: dir, Include: []string{"*.tmp"}}
+		status, err := c.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		c := &cleanup.Cleanup{Directory: filepath.Join(dir, "missing"), Include: []string{"*"}}
+		status, err := c.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("not a directory", func(t *testing.T) {
+		c := &cleanup.Cleanup{Directory: filepath.Join(dir, "app.log"), Include: []string{"*"}}
+		status, err := c.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+	})
+}
+
+func TestCleanupApply(t *testing.T) {
+	t.Parallel()
+
+	dir := setup(t)
+	defer os.RemoveAll(dir)
+
+	c := &cleanup.Cleanup{Directory: dir, Include: []string{"*.log"}, OlderThan: 7 * 24 * time.Hour, Recursive: true}
+	_, err := c.Check(context.Background(), fakerenderer.New())
+	require.NoError(t, err)
+
+	status, err := c.Apply(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, status.Diffs(), 3)
+	assert.Len(t, c.Removed, 3)
+
+	for _, name := range []string{"app.1.log", "big.log", "archive/app.log"} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.True(t, os.IsNotExist(err), "%s should be removed", name)
+	}
+	for _, name := range []string{"app.log", "keep.conf", "archive/keep.txt"} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.NoError(t, err, "%s should be kept", name)
+	}
+
+	status, err = c.Check(context.Background(), fakerenderer.New())
+	require.NoError(t, err)
+	assert.False(t, status.HasChanges())
+}
+
+func sorted(paths []string) []string {
+	sort.Strings(paths)
+	return paths
+}
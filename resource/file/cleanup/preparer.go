@@ -0,0 +1,131 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cleanup
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/filter"
+	"golang.org/x/net/context"
+)
+
+// Preparer for file Cleanup
+//
+// Cleanup removes the files in a directory that match glob patterns, like old
+// logs or config fragments no module manages. Only files and symlinks are
+// removed, never directories. The plan lists every file that will be removed;
+// removing files is a destructive change, so set `allow_destructive = true` on
+// the node for cleanups that should run unattended.
+type Preparer struct {
+	// the directory to clean up. Nothing is removed if it doesn't exist.
+	Directory string `hcl:"directory" required:"true" nonempty:"true"`
+
+	// files matching any of these globs are removed. A glob without a slash
+	// matches names anywhere in the tree, like "*.log", and a glob with a slash
+	// matches paths relative to `directory`, like "conf.d/*.conf".
+	Include []string `hcl:"include" required:"true"`
+
+	// files matching any of these globs are kept. Nothing below an excluded
+	// directory is removed.
+	Exclude []string `hcl:"exclude"`
+
+	// whether to look for files below subdirectories of `directory`, instead
+	// of only directly in it
+	Recursive bool `hcl:"recursive"`
+
+	// only remove files last modified longer ago than this, like "168h"
+	OlderThan *time.Duration `hcl:"older_than"`
+
+	// only remove files larger than this size, in bytes or with a K, M, G, or T
+	// suffix for powers of 1024, like "100M"
+	LargerThan string `hcl:"larger_than" nonempty:"true"`
+
+	// only remove files smaller than this size, in the same form as
+	// `larger_than`
+	SmallerThan string `hcl:"smaller_than" nonempty:"true"`
+}
+
+// Prepare the new cleanup
+func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	if len(p.Include) == 0 {
+		return nil, errors.New("include needs at least one glob")
+	}
+	if _, err := filter.New(p.Include, p.Exclude); err != nil {
+		return nil, err
+	}
+
+	cleanup := &Cleanup{
+		Directory: p.Directory,
+		Include:   p.Include,
+		Exclude:   p.Exclude,
+		Recursive: p.Recursive,
+	}
+
+	if p.OlderThan != nil {
+		if *p.OlderThan <= 0 {
+			return nil, fmt.Errorf("older_than must be positive, was %s", *p.OlderThan)
+		}
+		cleanup.OlderThan = *p.OlderThan
+	}
+
+	var err error
+	if p.LargerThan != "" {
+		if cleanup.LargerThan, err = parseSize(p.LargerThan); err != nil {
+			return nil, fmt.Errorf("invalid larger_than: %s", err)
+		}
+	}
+	if p.SmallerThan != "" {
+		if cleanup.SmallerThan, err = parseSize(p.SmallerThan); err != nil {
+			return nil, fmt.Errorf("invalid smaller_than: %s", err)
+		}
+	}
+
+	return cleanup, nil
+}
+
+var sizeRE = regexp.MustCompile(`^(?i)(\d+)\s*([kmgt]?)b?$`)
+
+// parseSize parses a size in bytes, with an optional suffix for powers of 1024
+func parseSize(size string) (int64, error) {
+	m := sizeRE.FindStringSubmatch(strings.TrimSpace(size))
+	if m == nil {
+		return 0, fmt.Errorf("%q is not a size, like 512, 10K, or 100M", size)
+	}
+
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	var shift uint
+	if m[2] != "" {
+		shift = uint(strings.Index("kmgt", strings.ToLower(m[2]))+1) * 10
+	}
+	if n > (1<<63-1)>>shift {
+		return 0, fmt.Errorf("%q is too large", size)
+	}
+	return n << shift, nil
+}
+
+func init() {
+	registry.Register("file.cleanup", (*Preparer)(nil), (*Cleanup)(nil))
+}
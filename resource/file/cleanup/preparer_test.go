@@ -0,0 +1,99 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cleanup_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/cleanup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestPreparerInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Resource)(nil), new(cleanup.Preparer))
+}
+
+func TestPreparerPrepare(t *testing.T) {
+	t.Parallel()
+
+	week := 168 * time.Hour
+
+	t.Run("valid", func(t *testing.T) {
+		p := &cleanup.Preparer{
+			Directory:   "/var/log/app",
+			Include:     []string{"*.log"},
+			OlderThan:   &week,
+			LargerThan:  "10K",
+			SmallerThan: "2g",
+		}
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		c := task.(*cleanup.Cleanup)
+		assert.Equal(t, "/var/log/app", c.Directory)
+		assert.Equal(t, week, c.OlderThan)
+		assert.Equal(t, int64(10*1024), c.LargerThan)
+		assert.Equal(t, int64(2*1024*1024*1024), c.SmallerThan)
+	})
+
+	t.Run("sizes in bytes", func(t *testing.T) {
+		p := &cleanup.Preparer{Directory: "/tmp", Include: []string{"*"}, LargerThan: "512"}
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, int64(512), task.(*cleanup.Cleanup).LargerThan)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		negative := -time.Hour
+
+		for name, test := range map[string]struct {
+			preparer *cleanup.Preparer
+			err      string
+		}{
+			"no include": {
+				&cleanup.Preparer{Directory: "/tmp", Include: []string{}},
+				"include needs at least one glob",
+			},
+			"pattern": {
+				&cleanup.Preparer{Directory: "/tmp", Include: []string{"[*.log"}},
+				`invalid pattern "[*.log": syntax error in pattern`,
+			},
+			"older_than": {
+				&cleanup.Preparer{Directory: "/tmp", Include: []string{"*"}, OlderThan: &negative},
+				"older_than must be positive, was -1h0m0s",
+			},
+			"size": {
+				&cleanup.Preparer{Directory: "/tmp", Include: []string{"*"}, LargerThan: "big"},
+				`invalid larger_than: "big" is not a size, like 512, 10K, or 100M`,
+			},
+			"overflow": {
+				&cleanup.Preparer{Directory: "/tmp", Include: []string{"*"}, SmallerThan: "9000000000T"},
+				`invalid smaller_than: "9000000000T" is too large`,
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := test.preparer.Prepare(context.Background(), fakerenderer.New())
+				assert.EqualError(t, err, test.err)
+			})
+		}
+	})
+}
@@ -16,10 +16,18 @@ package content
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/asteris-llc/converge/helpers/command"
 	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/attributes"
+	"github.com/asteris-llc/converge/resource/file/backup"
+	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 )
 
@@ -28,18 +36,62 @@ type Content struct {
 	// configured content of the file
 	Content string `export:"content"`
 
+	// template file the content was rendered from, if any
+	Source string `export:"source"`
+
+	// file in the module's files directory the content was copied from, if any
+	File string `export:"file"`
+
 	// configured destination of the file
 	Destination string `export:"destination"`
+
+	// whether the content is hidden from plan and apply output
+	Sensitive bool `export:"sensitive"`
+
+	// command that checks the content before it is written
+	Validate string `export:"validate"`
+
+	// whether the file is backed up before it is changed
+	Backup bool `export:"backup"`
+
+	// directory backups are made in, if not next to the file
+	BackupDir string `export:"backup_dir"`
+
+	// large file the content is copied from as it is read, instead of being
+	// held in Content
+	stream *stream
+
+	// what the file was before it was written, kept to roll it back in a
+	// transaction. Large files are kept in a temporary file instead of
+	// previous, and files that were backed up are restored from the backup.
+	applied      bool
+	created      bool
+	previous     []byte
+	previousPath string
+	backupPath   string
+	perm         os.FileMode
+}
+
+// Claims returns the destination file
+func (t *Content) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimPath(t.Destination)}
+}
+
+// SensitiveFields returns the content when it is sensitive, so it isn't shown
+// in provenance
+func (t *Content) SensitiveFields() []string {
+	if t.Sensitive || isPrivateKey(t.Content) {
+		return []string{"content"}
+	}
+	return nil
 }
 
 // Check if the content needs to be rendered
 func (t *Content) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
 	diffs := make(map[string]resource.Diff)
-	contentDiff := resource.TextDiff{Values: [2]string{"", t.Content}}
 	stat, err := os.Stat(t.Destination)
 	if os.IsNotExist(err) {
-		contentDiff.Values[0] = "<file-missing>"
-		diffs[t.Destination] = contentDiff
+		diffs[t.Destination] = t.missingDiff()
 		return &resource.Status{
 			Level:       resource.StatusWillChange,
 			Differences: diffs,
@@ -57,16 +109,16 @@ func (t *Content) Check(context.Context, resource.Renderer) (resource.TaskStatus
 		}, fmt.Errorf("cannot update contents of %q, it is a directory", t.Destination)
 	}
 
-	actual, err := ioutil.ReadFile(t.Destination)
+	diff, err := t.compare(stat.Size())
 	if err != nil {
 		return &resource.Status{}, err
 	}
 
 	statusMessage := "OK"
 
-	if string(actual) != t.Content {
+	if diff.Changes() {
 		statusMessage = "contents differ"
-		diffs[t.Destination] = resource.TextDiff{Values: [2]string{string(actual), t.Content}}
+		diffs[t.Destination] = diff
 	}
 
 	return &resource.Status{
@@ -76,9 +128,8 @@ func (t *Content) Check(context.Context, resource.Renderer) (resource.TaskStatus
 }
 
 // Apply writes the content to disk
-func (t *Content) Apply(context.Context) (resource.TaskStatus, error) {
+func (t *Content) Apply(ctx context.Context) (resource.TaskStatus, error) {
 	var perm os.FileMode
-	var preChange string
 	diffs := make(map[string]resource.Diff)
 
 	stat, err := os.Stat(t.Destination)
@@ -94,15 +145,58 @@ func (t *Content) Apply(context.Context) (resource.TaskStatus, error) {
 		perm = stat.Mode()
 	}
 
-	if rawData, readErr := ioutil.ReadFile(t.Destination); readErr != nil {
-		preChange = "<file-missing>"
-	} else {
-		preChange = string(rawData)
+	diff := t.missingDiff()
+	missing := true
+	if stat != nil {
+		if compared, compareErr := t.compare(stat.Size()); compareErr == nil {
+			diff, missing = compared, false
+		}
 	}
+	diffs[t.Destination] = diff
 
-	diffs[t.Destination] = resource.TextDiff{Values: [2]string{preChange, t.Content}}
+	if t.Validate != "" {
+		if out, err := t.validate(perm); err != nil {
+			status := &resource.Status{
+				Level:       resource.StatusFatal,
+				Differences: diffs,
+			}
+			status.AddMessage(err.Error())
+			if output := strings.TrimSpace(string(out)); output != "" {
+				status.AddMessage(strings.Split(output, "\n")...)
+			}
+			return status, err
+		}
+	}
+
+	var backedUp string
+	if t.Backup && !missing {
+		if backedUp, err = backup.Copy(t.Destination, t.BackupDir, time.Now()); err != nil {
+			return &resource.Status{
+				Output:      []string{err.Error()},
+				Level:       resource.StatusFatal,
+				Differences: diffs,
+			}, err
+		}
+	}
 
-	if err = ioutil.WriteFile(t.Destination, []byte(t.Content), perm); err != nil {
+	t.applied, t.created, t.previous, t.previousPath, t.backupPath = false, stat == nil, nil, "", backedUp
+	if stat != nil && backedUp == "" && resource.TransactionFrom(ctx) != "" {
+		if err := t.keepPrevious(stat.Size()); err != nil {
+			return &resource.Status{
+				Output:      []string{err.Error()},
+				Level:       resource.StatusFatal,
+				Differences: diffs,
+			}, err
+		}
+	}
+
+	write := func() error { return t.write(t.Destination, perm) }
+	err = write()
+	if os.IsPermission(err) {
+		// the file may be immutable or append-only
+		err = attributes.Unprotected(command.ExecRunner{}, t.Destination, write)
+	}
+	if err != nil {
 		return &resource.Status{
 			Output:      []string{err.Error()},
 			Level:       resource.StatusFatal,
@@ -110,5 +204,110 @@ func (t *Content) Apply(context.Context) (resource.TaskStatus, error) {
 		}, err
 	}
 
-	return &resource.Status{Differences: diffs}, nil
+	t.applied, t.perm = true, perm
+
+	status := &resource.Status{Differences: diffs}
+	status.AddUsage(&resource.Usage{BytesWritten: t.size()})
+	if backedUp != "" {
+		status.AddMessage(fmt.Sprintf("backed up %s to %s", t.Destination, backedUp))
+	}
+
+	return status, nil
+}
+
+// Rollback puts back what the file was before Apply wrote it in a
+// transaction, from its backup if one was made, removing it if it didn't
+// exist
+func (t *Content) Rollback(context.Context) error {
+	if !t.applied {
+		return nil
+	}
+
+	if t.created {
+		if err := os.Remove(t.Destination); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	var write func() error
+	switch {
+	case t.backupPath != "":
+		write = func() error { return copyFile(t.Destination, t.backupPath, t.perm) }
+	case t.previousPath != "":
+		write = func() error { return copyFile(t.Destination, t.previousPath, t.perm) }
+	case t.previous != nil:
+		write = func() error { return ioutil.WriteFile(t.Destination, t.previous, t.perm) }
+	default:
+		return errors.New("the previous content was not kept")
+	}
+
+	err := write()
+	if os.IsPermission(err) {
+		err = attributes.Unprotected(command.ExecRunner{}, t.Destination, write)
+	}
+	return err
+}
+
+// Cleanup removes the copy of a large file kept to roll it back
+func (t *Content) Cleanup(context.Context) error {
+	if t.previousPath == "" {
+		return nil
+	}
+	err := os.Remove(t.previousPath)
+	t.previousPath = ""
+	return err
+}
+
+// keepPrevious keeps the content of the file at Destination, which has the
+// given size, to roll it back
+func (t *Content) keepPrevious(size int64) (err error) {
+	if size <= resource.ContentDiffSize {
+		t.previous, err = ioutil.ReadFile(t.Destination)
+		if t.previous == nil && err == nil {
+			t.previous = []byte{}
+		}
+		return err
+	}
+
+	kept, err := ioutil.TempFile(filepath.Dir(t.Destination), "."+filepath.Base(t.Destination)+".converge-rollback-")
+	if err != nil {
+		return err
+	}
+	defer kept.Close()
+
+	current, err := os.Open(t.Destination)
+	if err != nil {
+		os.Remove(kept.Name())
+		return err
+	}
+	defer current.Close()
+
+	if _, err := io.Copy(kept, current); err != nil {
+		os.Remove(kept.Name())
+		return err
+	}
+
+	t.previousPath = kept.Name()
+	return nil
+}
+
+// diff builds the content diff from the original content. Content that looks
+// like a private key is always treated as sensitive.
+func (t *Content) diff(original string, missing bool) resource.ContentDiff {
+	sensitive := t.Sensitive || isPrivateKey(t.Content)
+	if !missing {
+		sensitive = sensitive || isPrivateKey(original)
+	}
+
+	return resource.ContentDiff{
+		Name:      t.Destination,
+		Values:    [2]string{original, t.Content},
+		Missing:   missing,
+		Sensitive: sensitive,
+	}
+}
+
+func isPrivateKey(content string) bool {
+	return strings.Contains(content, "-----BEGIN") && strings.Contains(content, "PRIVATE KEY-----")
 }
@@ -0,0 +1,207 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/asteris-llc/converge/resource"
+)
+
+// stream is a large file which is copied to the destination as it is read,
+// so it is never held in memory
+type stream struct {
+	path string
+	size int64
+	sum  string
+}
+
+// newStream reads the file at path once to find its size and SHA256
+func newStream(path string) (*stream, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stream{path: path, size: size, sum: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// size is the size of the content in bytes
+func (t *Content) size() int64 {
+	if t.stream != nil {
+		return t.stream.size
+	}
+	return int64(len(t.Content))
+}
+
+// sum is the hex SHA256 of the content
+func (t *Content) sum() string {
+	if t.stream != nil {
+		return t.stream.sum
+	}
+	hash := sha256.New()
+	io.Copy(hash, chunked{strings.NewReader(t.Content)})
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// open returns a reader of the content
+func (t *Content) open() (io.ReadCloser, error) {
+	if t.stream != nil {
+		return os.Open(t.stream.path)
+	}
+	return ioutil.NopCloser(chunked{strings.NewReader(t.Content)}), nil
+}
+
+// chunked hides the WriterTo of a strings.Reader, which converts the whole
+// string to bytes at once, so io.Copy reads it through a small buffer instead
+type chunked struct{ io.Reader }
+
+// write writes the content to path, creating it with perm if it doesn't
+// exist. The content is copied as it is read rather than converted to bytes
+// first.
+func (t *Content) write(path string, perm os.FileMode) error {
+	content, err := t.open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	return writeFile(path, content, perm)
+}
+
+// copyFile replaces the content of dest with the content of src
+func copyFile(dest, src string, perm os.FileMode) error {
+	content, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	return writeFile(dest, content, perm)
+}
+
+func writeFile(path string, content io.Reader, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// missingDiff is the diff for a file that doesn't exist yet
+func (t *Content) missingDiff() resource.Diff {
+	if t.stream == nil {
+		return t.diff("<file-missing>", true)
+	}
+
+	return &largeDiff{
+		sizes:     [2]int64{0, t.stream.size},
+		sums:      [2]string{"", t.stream.sum},
+		missing:   true,
+		sensitive: t.Sensitive,
+	}
+}
+
+// compare compares the file at Destination, which has the given size, with
+// the content. Files larger than resource.ContentDiffSize are compared by
+// their size and a SHA256 computed as they are read, so they are never read
+// into memory.
+func (t *Content) compare(size int64) (resource.Diff, error) {
+	if t.stream == nil && size <= resource.ContentDiffSize && len(t.Content) <= resource.ContentDiffSize {
+		actual, err := ioutil.ReadFile(t.Destination)
+		if err != nil {
+			return nil, err
+		}
+		return t.diff(string(actual), false), nil
+	}
+
+	diff := &largeDiff{
+		sizes:     [2]int64{size, t.size()},
+		sensitive: t.Sensitive || isPrivateKey(t.Content),
+	}
+	if diff.sizes[0] != diff.sizes[1] {
+		return diff, nil
+	}
+
+	f, err := os.Open(t.Destination)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return nil, err
+	}
+
+	diff.sums = [2]string{hex.EncodeToString(hash.Sum(nil)), t.sum()}
+	return diff, nil
+}
+
+// largeDiff is the diff of content too large to diff line by line. It shows
+// the sizes of the content, and their SHA256 when the sizes are the same.
+type largeDiff struct {
+	sizes     [2]int64
+	sums      [2]string
+	missing   bool
+	sensitive bool
+}
+
+// Original summarizes the original content
+func (d *largeDiff) Original() string { return d.summary(0) }
+
+// Current summarizes the current content
+func (d *largeDiff) Current() string { return d.summary(1) }
+
+// Changes is true if the sizes or sums of the content differ
+func (d *largeDiff) Changes() bool {
+	return d.missing || d.sizes[0] != d.sizes[1] || d.sums[0] != d.sums[1]
+}
+
+// Unified explains why there is no unified diff
+func (d *largeDiff) Unified() string {
+	if !d.Changes() {
+		return ""
+	}
+	return fmt.Sprintf("content too large to diff (%d bytes => %d bytes)", d.sizes[0], d.sizes[1])
+}
+
+func (d *largeDiff) summary(i int) string {
+	if i == 0 && d.missing {
+		return "<file-missing>"
+	}
+	if d.sums[i] == "" || d.sensitive {
+		return fmt.Sprintf("<%d bytes>", d.sizes[i])
+	}
+	return fmt.Sprintf("<%d bytes, sha256 %s>", d.sizes[i], d.sums[i])
+}
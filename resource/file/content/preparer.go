@@ -15,30 +15,168 @@
 package content
 
 import (
+	"encoding/base64"
+	"os"
+	"strings"
+
+	"github.com/asteris-llc/converge/fetch"
 	"github.com/asteris-llc/converge/load/registry"
 	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 )
 
 // Preparer for Content
 //
-// Content renders content to disk
+// Content renders content to disk. Content or files larger than 1 MiB are
+// compared by size and SHA256 instead of being diffed, so large files are
+// never read into memory to check them. A large `file` from a local module is
+// also copied to `destination` as it is read rather than being loaded first.
 type Preparer struct {
 	// Content is the file content. This will be rendered as a template.
-	Content string `hcl:"content"`
+	Content string `hcl:"content" mutually_exclusive:"content,source,file"`
+
+	// Source is a template file to render instead of `content`, to keep large
+	// templates out of HCL strings. The path is resolved relative to the module
+	// and the contents are rendered as a template. Params and lookups in the
+	// file become dependencies, unless `source` is itself a template.
+	Source string `hcl:"source" mutually_exclusive:"content,source,file" nonempty:"true"`
+
+	// File is the name of a file in the `files` directory next to the module,
+	// which is copied to `destination` as is. It is not rendered as a template,
+	// so it can be a binary file. The files of remote modules are fetched from
+	// next to them, and vendored with them by `converge vendor`. Local files
+	// larger than 1 MiB are copied as they are read, so their content can't be
+	// looked up.
+	File string `hcl:"file" mutually_exclusive:"content,source,file" nonempty:"true"`
+
+	// Encoding is the encoding of `content`. Set it to "base64" to give binary
+	// content inline. The content is decoded after it is rendered.
+	Encoding string `hcl:"encoding" valid_values:"base64"`
 
 	// Destination is the location on disk where the content will be rendered.
 	Destination string `hcl:"destination" required:"true" nonempty:"true"`
+
+	// Sensitive hides the content from plan and apply output. Only the size
+	// of the content is shown. Content that looks like a private key is always
+	// hidden.
+	Sensitive bool `hcl:"sensitive"`
+
+	// Validate is a command that checks the new content before it replaces
+	// the file, like "visudo -cf %s" or "nginx -t -c %s". It is run with
+	// /bin/sh against a temporary file next to `destination` holding the
+	// content, whose path is quoted for the shell and replaces "%s". If the
+	// command fails, the file is left untouched and the output of the command
+	// is shown.
+	Validate string `hcl:"validate" nonempty:"true"`
+
+	// Backup copies the existing file before changing it, to
+	// `<destination>.<timestamp>.bak`. The path of the backup is shown in the
+	// apply output.
+	Backup bool `hcl:"backup"`
+
+	// BackupDir puts backups below this directory instead of next to the file,
+	// at the file's absolute path. Requires `backup`.
+	BackupDir string `hcl:"backup_dir" nonempty:"true"`
 }
 
 // Prepare a new task
 func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	if p.BackupDir != "" && !p.Backup {
+		return nil, errors.New("backup_dir requires backup")
+	}
+
+	if p.Validate != "" && !strings.Contains(p.Validate, "%s") {
+		return nil, errors.New("validate needs %s for the path of the file to check")
+	}
+
+	if p.Encoding != "" && p.Content == "" {
+		return nil, errors.New("encoding requires content")
+	}
+
+	content := p.Content
+	var (
+		large *stream
+		err   error
+	)
+	switch {
+	case p.Source != "":
+		if content, err = loadSource(ctx, render, p.Source); err != nil {
+			return nil, err
+		}
+	case p.File != "":
+		if content, large, err = loadFile(ctx, render, p.File); err != nil {
+			return nil, err
+		}
+	case p.Encoding == "base64":
+		decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(content), ""))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decode \"content\" as base64")
+		}
+		content = string(decoded)
+	}
+
 	return &Content{
 		Destination: p.Destination,
-		Content:     p.Content,
+		Content:     content,
+		Source:      p.Source,
+		File:        p.File,
+		Sensitive:   p.Sensitive,
+		Validate:    p.Validate,
+		Backup:      p.Backup,
+		BackupDir:   p.BackupDir,
+		stream:      large,
 	}, nil
 }
 
+// loadSource fetches a template file, resolved relative to the module being
+// rendered, and renders its contents
+func loadSource(ctx context.Context, render resource.Renderer, loc string) (string, error) {
+	url, err := render.ResolvePath(loc)
+	if err != nil {
+		return "", errors.Wrap(err, "could not resolve \"source\"")
+	}
+
+	content, err := fetch.Any(ctx, url)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not load \"source\" from %s", url)
+	}
+
+	return render.Render("source", string(content))
+}
+
+// loadFile fetches a file from the files directory next to the module being
+// rendered, without rendering it. Large local files are streamed instead of
+// being loaded.
+func loadFile(ctx context.Context, render resource.Renderer, name string) (string, *stream, error) {
+	loc, err := fetch.FilePath(name)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "invalid \"file\"")
+	}
+
+	url, err := render.ResolvePath(loc)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "could not resolve \"file\"")
+	}
+
+	if path, err := fetch.LocalPath(url); err == nil {
+		if stat, err := os.Stat(path); err == nil && stat.Size() > resource.ContentDiffSize {
+			large, err := newStream(path)
+			if err != nil {
+				return "", nil, errors.Wrapf(err, "could not load \"file\" from %s", url)
+			}
+			return "", large, nil
+		}
+	}
+
+	content, err := fetch.Any(ctx, url)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "could not load \"file\" from %s", url)
+	}
+
+	return string(content), nil, nil
+}
+
 func init() {
 	registry.Register("file.content", (*Preparer)(nil), (*Content)(nil))
 }
@@ -15,11 +15,18 @@
 package content_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/file/content"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
 )
 
 func TestPreparerInterface(t *testing.T) {
@@ -27,3 +34,148 @@ func TestPreparerInterface(t *testing.T) {
 
 	assert.Implements(t, (*resource.Resource)(nil), new(content.Preparer))
 }
+
+func TestPreparerSource(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-content-source")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "motd.tmpl"), []byte("welcome\n"), 0644))
+
+	t.Run("relative to module", func(t *testing.T) {
+		p := &content.Preparer{Destination: "/etc/motd", Source: "motd.tmpl"}
+
+		task, err := p.Prepare(context.Background(), fakerenderer.NewWithSource("file://"+filepath.Join(dir, "main.hcl")))
+		require.NoError(t, err)
+
+		c, ok := task.(*content.Content)
+		require.True(t, ok)
+		assert.Equal(t, "welcome\n", c.Content)
+		assert.Equal(t, "motd.tmpl", c.Source)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		p := &content.Preparer{Destination: "/etc/motd", Source: "/nonexistent/converge/motd.tmpl"}
+
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `could not load "source"`)
+	})
+}
+
+func TestPreparerBackupDir(t *testing.T) {
+	t.Parallel()
+
+	prep := content.Preparer{Destination: "path/to/file", BackupDir: "/var/backups"}
+	_, err := prep.Prepare(context.Background(), fakerenderer.New())
+	assert.EqualError(t, err, "backup_dir requires backup")
+}
+
+func TestPreparerFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-content-file")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	payload := []byte("\x89PNG\r\n\x1a\n\x00{{not a template}}")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "files", "img"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "files", "img", "logo.png"), payload, 0644))
+
+	renderer := fakerenderer.NewWithSource("file://" + filepath.Join(dir, "main.hcl"))
+
+	t.Run("copied as is", func(t *testing.T) {
+		p := &content.Preparer{Destination: "/srv/logo.png", File: "img/logo.png"}
+
+		task, err := p.Prepare(context.Background(), renderer)
+		require.NoError(t, err)
+
+		c, ok := task.(*content.Content)
+		require.True(t, ok)
+		assert.Equal(t, string(payload), c.Content)
+		assert.Equal(t, "img/logo.png", c.File)
+	})
+
+	t.Run("large", func(t *testing.T) {
+		large := strings.Repeat("\x00data", resource.ContentDiffSize/5+1)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "files", "disk.img"), []byte(large), 0644))
+		dest := filepath.Join(dir, "disk.img")
+
+		p := &content.Preparer{Destination: dest, File: "disk.img"}
+		task, err := p.Prepare(context.Background(), renderer)
+		require.NoError(t, err)
+
+		// the file is streamed rather than loaded
+		c, ok := task.(*content.Content)
+		require.True(t, ok)
+		assert.Equal(t, "", c.Content)
+
+		status, err := task.Check(context.Background(), renderer)
+		require.NoError(t, err)
+		require.True(t, status.HasChanges())
+		assert.Equal(t, "<file-missing>", status.Diffs()[dest].Original())
+
+		_, err = task.Apply(context.Background())
+		require.NoError(t, err)
+
+		written, err := ioutil.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, large, string(written))
+
+		status, err = task.Check(context.Background(), renderer)
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("outside files", func(t *testing.T) {
+		p := &content.Preparer{Destination: "/srv/main.hcl", File: "../main.hcl"}
+
+		_, err := p.Prepare(context.Background(), renderer)
+		assert.EqualError(t, err, `invalid "file": "../main.hcl" is not a path inside the files directory`)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		p := &content.Preparer{Destination: "/srv/missing", File: "missing"}
+
+		_, err := p.Prepare(context.Background(), renderer)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `could not load "file"`)
+	})
+}
+
+func TestPreparerEncoding(t *testing.T) {
+	t.Parallel()
+
+	t.Run("base64", func(t *testing.T) {
+		p := &content.Preparer{Destination: "/srv/bin", Content: "AAEC\n/w==\n", Encoding: "base64"}
+
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, "\x00\x01\x02\xff", task.(*content.Content).Content)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		p := &content.Preparer{Destination: "/srv/bin", Content: "not base64!", Encoding: "base64"}
+
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `could not decode "content" as base64`)
+	})
+
+	t.Run("without content", func(t *testing.T) {
+		p := &content.Preparer{Destination: "/srv/bin", Encoding: "base64"}
+
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "encoding requires content")
+	})
+}
+
+func TestPreparerValidate(t *testing.T) {
+	t.Parallel()
+
+	prep := content.Preparer{Destination: "/etc/sudoers", Content: "x", Validate: "visudo -c"}
+	_, err := prep.Prepare(context.Background(), fakerenderer.New())
+	assert.EqualError(t, err, "validate needs %s for the path of the file to check")
+}
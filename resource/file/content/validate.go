@@ -0,0 +1,75 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// validate checks the content with the validate command before it is written
+func (t *Content) validate(perm os.FileMode) ([]byte, error) {
+	content, err := t.open()
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	return validateContent(t.Validate, t.Destination, content, perm)
+}
+
+// validateContent writes content to a temporary file next to the destination
+// and runs the validate command against it, with "%s" replaced by the path of
+// the temporary file. The output of the command is returned.
+func validateContent(command, dest string, content io.Reader, perm os.FileMode) ([]byte, error) {
+	dir := filepath.Dir(dest)
+	if _, err := os.Stat(dir); err != nil {
+		dir = ""
+	}
+
+	candidate, err := ioutil.TempFile(dir, "."+filepath.Base(dest)+".converge-validate-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(candidate.Name())
+
+	if _, err := io.Copy(candidate, content); err != nil {
+		candidate.Close()
+		return nil, err
+	}
+	if err := candidate.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(candidate.Name(), perm); err != nil {
+		return nil, err
+	}
+
+	script := strings.Replace(command, "%s", shellQuote(candidate.Name()), -1)
+	out, err := exec.Command("sh", "-c", script).CombinedOutput()
+	if err != nil {
+		return out, fmt.Errorf("validation with %q failed: %s", command, err)
+	}
+	return out, nil
+}
+
+// shellQuote quotes a path for sh
+func shellQuote(path string) string {
+	return "'" + strings.Replace(path, "'", `'\''`, -1) + "'"
+}
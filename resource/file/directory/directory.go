@@ -35,6 +35,11 @@ type Directory struct {
 	CreateAll bool `export:"createall"`
 }
 
+// Claims returns the directory
+func (d *Directory) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimPath(d.Destination)}
+}
+
 // Check if the directory exists
 func (d *Directory) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
 	status := resource.NewStatus()
@@ -0,0 +1,319 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Format of a document
+type Format string
+
+const (
+	// FormatJSON is a JSON document
+	FormatJSON Format = "json"
+
+	// FormatYAML is a YAML document
+	FormatYAML Format = "yaml"
+)
+
+// FormatForPath guesses the format of a file from its extension
+func FormatForPath(path string) (Format, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, true
+	case ".yaml", ".yml":
+		return FormatYAML, true
+	}
+	return "", false
+}
+
+// decode reads a document in the given format. Empty documents are empty
+// objects.
+func decode(format Format, content string) (interface{}, error) {
+	if strings.TrimSpace(content) == "" {
+		return newObject(), nil
+	}
+
+	if format == FormatYAML {
+		return decodeYAML([]byte(content))
+	}
+	return decodeJSON([]byte(content))
+}
+
+// encode writes a document in the given format, following the layout of the
+// original content where it can
+func encode(format Format, value interface{}, original string) (string, error) {
+	if format == FormatYAML {
+		out, err := yaml.Marshal(toYAML(value))
+		return string(out), err
+	}
+
+	encoded, err := encodeJSON(value)
+	if err != nil {
+		return "", err
+	}
+
+	indent, pretty := detectIndent(original)
+	if !pretty {
+		if strings.HasSuffix(original, "\n") {
+			encoded = append(encoded, '\n')
+		}
+		return string(encoded), nil
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, encoded, "", indent); err != nil {
+		return "", err
+	}
+	if original == "" || strings.HasSuffix(original, "\n") {
+		out.WriteString("\n")
+	}
+	return out.String(), nil
+}
+
+// detectIndent finds the indentation used in a JSON document, and whether
+// it's spread over more than one line. New documents use two spaces.
+func detectIndent(content string) (indent string, pretty bool) {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return "  ", true
+	}
+	if !strings.Contains(trimmed, "\n") {
+		return "", false
+	}
+
+	for _, line := range strings.Split(trimmed, "\n")[1:] {
+		if ws := line[:len(line)-len(strings.TrimLeft(line, " \t"))]; ws != "" && strings.TrimSpace(line) != "" {
+			return ws, true
+		}
+	}
+	return "  ", true
+}
+
+// decodeJSON reads a JSON document, keeping the order of keys in objects
+func decodeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	value, err := decodeJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected content after the end of the document")
+	}
+	return value, nil
+}
+
+func decodeJSONValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		obj := newObject()
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(key.(string), value)
+		}
+		_, err = dec.Token()
+		return obj, err
+
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err = dec.Token()
+		return list, err
+	}
+
+	return tok, nil
+}
+
+// encodeJSON writes a value as compact JSON, without escaping HTML
+// characters, so strings come out the way they went in
+func encodeJSON(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeJSON(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case *object:
+		buf.WriteString("{")
+		for idx, key := range v.keys {
+			if idx > 0 {
+				buf.WriteString(",")
+			}
+			if err := writeJSON(buf, key); err != nil {
+				return err
+			}
+			buf.WriteString(":")
+			if err := writeJSON(buf, v.values[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("}")
+
+	case []interface{}:
+		buf.WriteString("[")
+		for idx, elem := range v {
+			if idx > 0 {
+				buf.WriteString(",")
+			}
+			if err := writeJSON(buf, elem); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+
+	default:
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		buf.Truncate(buf.Len() - 1) // Encode adds a newline
+	}
+
+	return nil
+}
+
+// compact is a value as compact JSON, for differences
+func compact(value interface{}) string {
+	out, err := encodeJSON(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(out)
+}
+
+// decodeYAML reads a YAML document, keeping the order of keys in mappings.
+// Comments are not kept.
+func decodeYAML(data []byte) (interface{}, error) {
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	if _, ok := value.(map[interface{}]interface{}); ok {
+		var mapping yaml.MapSlice
+		if err := yaml.Unmarshal(data, &mapping); err != nil {
+			return nil, err
+		}
+		return fromYAML(mapping), nil
+	}
+
+	// documents that aren't mappings, like lists, lose the order of the keys
+	// in the mappings inside them
+	return fromYAML(value), nil
+}
+
+func fromYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		obj := newObject()
+		for _, item := range v {
+			key := fmt.Sprint(item.Key)
+			obj.set(key, fromYAML(item.Value))
+			obj.raw[key] = item.Key
+		}
+		return obj
+
+	case map[interface{}]interface{}:
+		keys := make([]string, 0, len(v))
+		raw := make(map[string]interface{}, len(v))
+		for key := range v {
+			keys = append(keys, fmt.Sprint(key))
+			raw[fmt.Sprint(key)] = key
+		}
+		sort.Strings(keys)
+
+		obj := newObject()
+		for _, key := range keys {
+			obj.set(key, fromYAML(v[raw[key]]))
+			obj.raw[key] = raw[key]
+		}
+		return obj
+
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for idx, elem := range v {
+			list[idx] = fromYAML(elem)
+		}
+		return list
+	}
+
+	return value
+}
+
+func toYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *object:
+		mapping := make(yaml.MapSlice, len(v.keys))
+		for idx, key := range v.keys {
+			raw, ok := v.raw[key]
+			if !ok {
+				raw = key
+			}
+			mapping[idx] = yaml.MapItem{Key: raw, Value: toYAML(v.values[key])}
+		}
+		return mapping
+
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for idx, elem := range v {
+			list[idx] = toYAML(elem)
+		}
+		return list
+
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return string(v)
+	}
+
+	return value
+}
@@ -0,0 +1,196 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package document
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/asteris-llc/converge/helpers/command"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/attributes"
+	"golang.org/x/net/context"
+)
+
+// Document patches keys in a JSON or YAML file
+type Document struct {
+	// the file to patch
+	Destination string `export:"destination"`
+
+	// the format of the file
+	Format Format `export:"format"`
+
+	// a JSON merge patch applied to the document
+	MergePatch string `export:"merge_patch"`
+
+	// the values to set, by key path
+	Set map[string]string `export:"set"`
+
+	// the key paths to delete
+	Delete []string `export:"delete"`
+
+	// whether the file is created if it doesn't exist
+	Create bool `export:"create"`
+}
+
+// Claims returns the file to patch
+func (d *Document) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimPath(d.Destination)}
+}
+
+// Check whether the document needs to be patched
+func (d *Document) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	original, missing, err := d.read()
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+
+	if missing && d.adds() && !d.Create {
+		// the file may be created by another resource in the same run
+		status.RaiseLevel(resource.StatusMayChange)
+		status.SetWarning(fmt.Sprintf("%s does not exist", d.Destination))
+		return status, nil
+	}
+
+	if _, err := d.update(status, original); err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+
+	status.RaiseLevelForDiffs()
+	return status, nil
+}
+
+// Apply writes the patched document
+func (d *Document) Apply(context.Context) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	original, missing, err := d.read()
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+
+	if missing && d.adds() && !d.Create {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, fmt.Errorf("cannot patch %s: it does not exist", d.Destination)
+	}
+
+	updated, err := d.update(status, original)
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+	if !status.HasChanges() {
+		return status, nil
+	}
+
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(d.Destination); err == nil {
+		perm = info.Mode()
+	}
+
+	write := func() error { return ioutil.WriteFile(d.Destination, []byte(updated), perm) }
+	err = write()
+	if os.IsPermission(err) {
+		// the file may be immutable or append-only
+		err = attributes.Unprotected(command.ExecRunner{}, d.Destination, write)
+	}
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+
+	status.RaiseLevelForDiffs()
+	return status, nil
+}
+
+// adds is whether the patch adds anything to the document, as opposed to
+// only deleting keys
+func (d *Document) adds() bool {
+	return d.MergePatch != "" || len(d.Set) > 0
+}
+
+// read reads the file, which may be missing
+func (d *Document) read() (content string, missing bool, err error) {
+	data, err := ioutil.ReadFile(d.Destination)
+	if os.IsNotExist(err) {
+		return "", true, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	return string(data), false, nil
+}
+
+// update returns the content of the patched document, adding a difference to
+// status for each key path that changes
+func (d *Document) update(status *resource.Status, original string) (string, error) {
+	before, err := decode(d.Format, original)
+	if err != nil {
+		return "", fmt.Errorf("cannot read %s as %s: %s", d.Destination, d.Format, err)
+	}
+	after, _ := decode(d.Format, original)
+
+	if d.MergePatch != "" {
+		patch, err := decodeJSON([]byte(d.MergePatch))
+		if err != nil {
+			return "", fmt.Errorf("cannot read merge patch: %s", err)
+		}
+		after = mergePatch(after, patch)
+	}
+
+	paths := make([]string, 0, len(d.Set))
+	for path := range d.Set {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		keys, err := SplitPath(path)
+		if err != nil {
+			return "", err
+		}
+		after, err = setIn(after, keys, parseValue(d.Set[path]))
+		if err != nil {
+			return "", fmt.Errorf("cannot set %q in %s: %s", path, d.Destination, err)
+		}
+	}
+
+	for _, path := range d.Delete {
+		keys, err := SplitPath(path)
+		if err != nil {
+			return "", err
+		}
+		if err := deleteIn(after, keys); err != nil {
+			return "", fmt.Errorf("cannot delete %q in %s: %s", path, d.Destination, err)
+		}
+	}
+
+	changed := false
+	diff(before, after, nil, func(path, from, to string) {
+		changed = true
+		status.AddDifference(path, from, to, "")
+	})
+	if !changed {
+		return original, nil
+	}
+
+	return encode(d.Format, after, original)
+}
@@ -0,0 +1,230 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package document_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/document"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestDocumentInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(document.Document))
+}
+
+func TestDocumentApply(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-document")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	apply := func(t *testing.T, name, original string, d *document.Document) (string, map[string]resource.Diff) {
+		d.Destination = filepath.Join(dir, name)
+		if d.Format == "" {
+			d.Format, _ = document.FormatForPath(name)
+		}
+		require.NoError(t, ioutil.WriteFile(d.Destination, []byte(original), 0600))
+
+		status, err := d.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		diffs := status.Diffs()
+
+		applied, err := d.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, status.HasChanges(), applied.HasChanges())
+
+		info, err := os.Stat(d.Destination)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode())
+
+		out, err := ioutil.ReadFile(d.Destination)
+		require.NoError(t, err)
+
+		status, err = d.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+
+		return string(out), diffs
+	}
+
+	t.Run("json set keeps order and indentation", func(t *testing.T) {
+		out, diffs := apply(
+			t, "set.json",
+			"{\n    \"storage-driver\": \"overlay2\",\n    \"log-opts\": {\"max-size\": \"10m\"},\n    \"debug\": false\n}\n",
+			&document.Document{Set: map[string]string{
+				"log-opts.max-size": "100m",
+				"log-opts.max-file": "3",
+				"labels":            `["a&b"]`,
+			}},
+		)
+
+		assert.Equal(
+			t,
+			"{\n"+
+				"    \"storage-driver\": \"overlay2\",\n"+
+				"    \"log-opts\": {\n"+
+				"        \"max-size\": \"100m\",\n"+
+				"        \"max-file\": 3\n"+
+				"    },\n"+
+				"    \"debug\": false,\n"+
+				"    \"labels\": [\n"+
+				"        \"a&b\"\n"+
+				"    ]\n"+
+				"}\n",
+			out,
+		)
+
+		require.Contains(t, diffs, "log-opts.max-size")
+		assert.Equal(t, `"10m"`, diffs["log-opts.max-size"].Original())
+		assert.Equal(t, `"100m"`, diffs["log-opts.max-size"].Current())
+		assert.Equal(t, "<absent>", diffs["log-opts.max-file"].Original())
+		assert.Equal(t, "3", diffs["log-opts.max-file"].Current())
+		assert.Len(t, diffs, 3)
+	})
+
+	t.Run("json compact stays compact", func(t *testing.T) {
+		out, _ := apply(
+			t, "compact.json",
+			`{"b":1,"a":2}`,
+			&document.Document{Set: map[string]string{"b": `"one"`}},
+		)
+
+		assert.Equal(t, `{"b":"one","a":2}`, out)
+	})
+
+	t.Run("merge patch", func(t *testing.T) {
+		out, diffs := apply(
+			t, "merge.json",
+			"{\n  \"a\": {\"b\": 1, \"c\": 2},\n  \"d\": [1, 2]\n}",
+			&document.Document{MergePatch: `{"a": {"c": null, "e": true}, "d": [3]}`},
+		)
+
+		assert.Equal(t, "{\n  \"a\": {\n    \"b\": 1,\n    \"e\": true\n  },\n  \"d\": [\n    3\n  ]\n}", out)
+		assert.Equal(t, "<absent>", diffs["a.c"].Current())
+		assert.Equal(t, "[1,2]", diffs["d"].Original())
+		assert.Equal(t, "[3]", diffs["d"].Current())
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		out, diffs := apply(
+			t, "delete.json",
+			"{\n  \"hosts\": [{\"name\": \"a\", \"tls\": true}],\n  \"keep\": 1\n}\n",
+			&document.Document{Delete: []string{"hosts.0.tls", "missing.key", "keep.inner"}},
+		)
+
+		assert.Equal(t, "{\n  \"hosts\": [\n    {\n      \"name\": \"a\"\n    }\n  ],\n  \"keep\": 1\n}\n", out)
+		assert.Len(t, diffs, 1)
+	})
+
+	t.Run("unchanged keeps file as is", func(t *testing.T) {
+		original := "{ \"a\" :   1 }\n"
+		out, diffs := apply(
+			t, "unchanged.json", original,
+			&document.Document{Set: map[string]string{"a": "1"}, Delete: []string{"b"}},
+		)
+
+		assert.Equal(t, original, out)
+		assert.Empty(t, diffs)
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		out, diffs := apply(
+			t, "config.yaml",
+			"server:\n  port: 8080\n  host: localhost\nfeatures:\n- a\n1: one\n",
+			&document.Document{
+				Set:        map[string]string{"server.port": "9090", "server.tls.enabled": "true"},
+				MergePatch: `{"features": null}`,
+			},
+		)
+
+		assert.Equal(t, "server:\n  port: 9090\n  host: localhost\n  tls:\n    enabled: true\n1: one\n", out)
+		assert.Equal(t, "8080", diffs["server.port"].Original())
+		assert.Equal(t, "9090", diffs["server.port"].Current())
+		assert.Equal(t, `["a"]`, diffs["features"].Original())
+	})
+}
+
+func TestDocumentErrors(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-document")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("through a scalar", func(t *testing.T) {
+		d := &document.Document{Destination: filepath.Join(dir, "scalar.json"), Format: document.FormatJSON, Set: map[string]string{"a.b": "1"}}
+		require.NoError(t, ioutil.WriteFile(d.Destination, []byte(`{"a": "x"}`), 0600))
+
+		_, err := d.Check(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, `cannot set "a.b" in `+d.Destination+`: a is a string, not an object or list`)
+	})
+
+	t.Run("list index out of range", func(t *testing.T) {
+		d := &document.Document{Destination: filepath.Join(dir, "index.json"), Format: document.FormatJSON, Set: map[string]string{"a.2": "1"}}
+		require.NoError(t, ioutil.WriteFile(d.Destination, []byte(`{"a": [1]}`), 0600))
+
+		_, err := d.Check(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, `cannot set "a.2" in `+d.Destination+`: a: no index 2 in a list of 1`)
+	})
+
+	t.Run("delete from list", func(t *testing.T) {
+		d := &document.Document{Destination: filepath.Join(dir, "list.json"), Format: document.FormatJSON, Delete: []string{"a.0"}}
+		require.NoError(t, ioutil.WriteFile(d.Destination, []byte(`{"a": [1]}`), 0600))
+
+		_, err := d.Check(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, `cannot delete "a.0" in `+d.Destination+`: items can't be deleted from lists, set the whole list instead`)
+	})
+
+	t.Run("invalid document", func(t *testing.T) {
+		d := &document.Document{Destination: filepath.Join(dir, "invalid.json"), Format: document.FormatJSON, Set: map[string]string{"a": "1"}}
+		require.NoError(t, ioutil.WriteFile(d.Destination, []byte(`{"a": `), 0600))
+
+		_, err := d.Check(context.Background(), fakerenderer.New())
+		assert.Error(t, err)
+	})
+
+	t.Run("missing without create", func(t *testing.T) {
+		d := &document.Document{Destination: filepath.Join(dir, "missing.json"), Format: document.FormatJSON, Set: map[string]string{"a": "1"}}
+
+		status, err := d.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusMayChange, status.StatusCode())
+
+		_, err = d.Apply(context.Background())
+		assert.Error(t, err)
+	})
+
+	t.Run("missing with create", func(t *testing.T) {
+		d := &document.Document{Destination: filepath.Join(dir, "created.json"), Format: document.FormatJSON, Set: map[string]string{"a": "1"}, Create: true}
+
+		_, err := d.Apply(context.Background())
+		require.NoError(t, err)
+
+		out, err := ioutil.ReadFile(d.Destination)
+		require.NoError(t, err)
+		assert.Equal(t, "{\n  \"a\": 1\n}\n", string(out))
+	})
+}
@@ -0,0 +1,112 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package document
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"golang.org/x/net/context"
+)
+
+// Preparer for Document
+//
+// Document patches keys in a JSON or YAML file, leaving the rest of it alone,
+// so configuration files like `/etc/docker/daemon.json` can be partly managed.
+// The patch can be a JSON merge patch, keys to set, keys to delete, or any of
+// them together, applied in that order. The order of keys in the file is kept,
+// along with the indentation of JSON files. YAML files are written out again
+// when they change, so their comments are not kept. Plans show a difference for
+// each key that changes.
+type Preparer struct {
+	// Destination is the file to patch
+	Destination string `hcl:"destination" required:"true" nonempty:"true"`
+
+	// Format is the format of the file. The default is picked from its
+	// extension: ".json" for JSON, or ".yaml" and ".yml" for YAML.
+	Format Format `hcl:"format" valid_values:"json,yaml"`
+
+	// MergePatch is a JSON merge patch, as described in RFC 7386, applied to
+	// the document. Keys set to null in the patch are removed. It works for
+	// YAML files too.
+	MergePatch string `hcl:"merge_patch"`
+
+	// Set is the values to set, by key path, like "log-opts.max-size". Use
+	// "\." for a dot in a key and a number for an index in a list. Values are
+	// read as JSON when they can be, so "3" is a number and "true" is a
+	// boolean; use "\"3\"" to set a string that looks like something else.
+	// Objects are added for keys in the path that aren't there.
+	Set map[string]string `hcl:"set"`
+
+	// Delete is the key paths to delete. Paths that aren't in the document are
+	// left alone. Items can't be deleted from lists, since the item at an index
+	// changes each time one is deleted; set the whole list instead.
+	Delete []string `hcl:"delete"`
+
+	// Create creates the file if it doesn't exist. Otherwise a missing file is
+	// an error when the patch adds anything.
+	Create bool `hcl:"create"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	if p.MergePatch == "" && len(p.Set) == 0 && len(p.Delete) == 0 {
+		return nil, errors.New("merge_patch, set or delete must be given")
+	}
+
+	if p.Format == "" {
+		format, ok := FormatForPath(p.Destination)
+		if !ok {
+			return nil, fmt.Errorf("cannot tell the format of %s from its extension, set format", p.Destination)
+		}
+		p.Format = format
+	}
+
+	if p.MergePatch != "" {
+		if _, err := decodeJSON([]byte(p.MergePatch)); err != nil {
+			return nil, fmt.Errorf("merge_patch is not valid JSON: %s", err)
+		}
+	}
+
+	for path := range p.Set {
+		if _, err := SplitPath(path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, path := range p.Delete {
+		if _, err := SplitPath(path); err != nil {
+			return nil, err
+		}
+		if _, ok := p.Set[path]; ok {
+			return nil, fmt.Errorf("%q can't be both set and deleted", path)
+		}
+	}
+
+	return &Document{
+		Destination: p.Destination,
+		Format:      p.Format,
+		MergePatch:  p.MergePatch,
+		Set:         p.Set,
+		Delete:      p.Delete,
+		Create:      p.Create,
+	}, nil
+}
+
+func init() {
+	registry.Register("file.document", (*Preparer)(nil), (*Document)(nil))
+}
@@ -0,0 +1,82 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package document_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/document"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestPreparerInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Resource)(nil), new(document.Preparer))
+}
+
+func TestPreparerPrepare(t *testing.T) {
+	t.Parallel()
+
+	t.Run("format from extension", func(t *testing.T) {
+		p := &document.Preparer{Destination: "/etc/app/config.yml", Set: map[string]string{"a": "1"}}
+
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		d, ok := task.(*document.Document)
+		require.True(t, ok)
+		assert.Equal(t, document.FormatYAML, d.Format)
+	})
+
+	t.Run("unknown extension", func(t *testing.T) {
+		p := &document.Preparer{Destination: "/etc/app/config", Set: map[string]string{"a": "1"}}
+
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "cannot tell the format of /etc/app/config from its extension, set format")
+	})
+
+	t.Run("nothing to do", func(t *testing.T) {
+		p := &document.Preparer{Destination: "/etc/docker/daemon.json"}
+
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "merge_patch, set or delete must be given")
+	})
+
+	t.Run("invalid merge patch", func(t *testing.T) {
+		p := &document.Preparer{Destination: "/etc/docker/daemon.json", MergePatch: "{"}
+
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.Error(t, err)
+	})
+
+	t.Run("set and deleted", func(t *testing.T) {
+		p := &document.Preparer{Destination: "/etc/docker/daemon.json", Set: map[string]string{"a.b": "1"}, Delete: []string{"a.b"}}
+
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, `"a.b" can't be both set and deleted`)
+	})
+
+	t.Run("empty key", func(t *testing.T) {
+		p := &document.Preparer{Destination: "/etc/docker/daemon.json", Delete: []string{"a."}}
+
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, `"a." has an empty key`)
+	})
+}
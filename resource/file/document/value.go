@@ -0,0 +1,281 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package document
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// object is a JSON object or YAML mapping that keeps the order of its keys
+type object struct {
+	keys   []string
+	values map[string]interface{}
+
+	// raw are keys as they were decoded, for YAML keys that aren't strings
+	raw map[string]interface{}
+}
+
+func newObject() *object {
+	return &object{values: make(map[string]interface{}), raw: make(map[string]interface{})}
+}
+
+func (o *object) get(key string) (interface{}, bool) {
+	value, ok := o.values[key]
+	return value, ok
+}
+
+// set sets the value of a key, adding it after the other keys if it's new
+func (o *object) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *object) remove(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+	delete(o.values, key)
+	delete(o.raw, key)
+	for idx, existing := range o.keys {
+		if existing == key {
+			o.keys = append(o.keys[:idx], o.keys[idx+1:]...)
+			break
+		}
+	}
+}
+
+// SplitPath splits a key path like "log-opts.max-size" into its keys. A "\."
+// is a dot in a key, and a number is an index when the value it's used on is a
+// list.
+func SplitPath(path string) ([]string, error) {
+	var (
+		keys    []string
+		current bytes.Buffer
+		escaped bool
+	)
+	for _, r := range path {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '.':
+			keys = append(keys, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("%q ends in an escape", path)
+	}
+	keys = append(keys, current.String())
+
+	for _, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("%q has an empty key", path)
+		}
+	}
+	return keys, nil
+}
+
+// joinPath is the inverse of SplitPath
+func joinPath(keys []string) string {
+	escaped := make([]string, len(keys))
+	for idx, key := range keys {
+		key = strings.Replace(key, `\`, `\\`, -1)
+		escaped[idx] = strings.Replace(key, ".", `\.`, -1)
+	}
+	return strings.Join(escaped, ".")
+}
+
+// setIn sets the value at path in current, adding objects for keys that
+// aren't there, and returns the updated value
+func setIn(current interface{}, path []string, value interface{}) (interface{}, error) {
+	return setInFrom(current, nil, path, value)
+}
+
+func setInFrom(current interface{}, walked, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	key, rest := path[0], path[1:]
+	walked = append(walked, key)
+
+	switch c := current.(type) {
+	case *object:
+		child, _ := c.get(key)
+		updated, err := setInFrom(child, walked, rest, value)
+		if err != nil {
+			return nil, err
+		}
+		c.set(key, updated)
+		return c, nil
+
+	case []interface{}:
+		idx, err := listIndex(c, key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", joinPath(walked[:len(walked)-1]), err)
+		}
+		c[idx], err = setInFrom(c[idx], walked, rest, value)
+		return c, err
+
+	case nil:
+		return setInFrom(newObject(), walked[:len(walked)-1], path, value)
+
+	default:
+		return nil, fmt.Errorf("%s is %s, not an object or list", joinPath(walked[:len(walked)-1]), describe(c))
+	}
+}
+
+// deleteIn removes the value at path in current, if it's there. Items can't
+// be deleted from lists, since the item at an index changes each time one is
+// deleted.
+func deleteIn(current interface{}, path []string) error {
+	key, rest := path[0], path[1:]
+
+	switch c := current.(type) {
+	case *object:
+		child, ok := c.get(key)
+		if !ok {
+			return nil
+		}
+		if len(rest) == 0 {
+			c.remove(key)
+			return nil
+		}
+		return deleteIn(child, rest)
+
+	case []interface{}:
+		if len(rest) == 0 {
+			return errors.New("items can't be deleted from lists, set the whole list instead")
+		}
+		idx, err := listIndex(c, key)
+		if err != nil {
+			return nil
+		}
+		return deleteIn(c[idx], rest)
+	}
+
+	return nil
+}
+
+func listIndex(list []interface{}, key string) (int, error) {
+	idx, err := strconv.Atoi(key)
+	if err != nil || idx < 0 || idx >= len(list) {
+		return 0, fmt.Errorf("no index %s in a list of %d", key, len(list))
+	}
+	return idx, nil
+}
+
+// mergePatch applies a JSON merge patch, as described in RFC 7386, to target
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(*object)
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(*object)
+	if !ok {
+		t = newObject()
+	}
+
+	for _, key := range p.keys {
+		value := p.values[key]
+		if value == nil {
+			t.remove(key)
+			continue
+		}
+
+		current, _ := t.get(key)
+		t.set(key, mergePatch(current, value))
+	}
+
+	return t
+}
+
+// diff calls changed for each key path whose value differs between before
+// and after, with the values as compact JSON. Lists are compared as a whole.
+func diff(before, after interface{}, path []string, changed func(path, before, after string)) {
+	b, bok := before.(*object)
+	a, aok := after.(*object)
+	if !bok || !aok {
+		if encoded, current := compact(before), compact(after); encoded != current {
+			changed(pathName(path), encoded, current)
+		}
+		return
+	}
+
+	keys := append([]string{}, b.keys...)
+	for _, key := range a.keys {
+		if _, ok := b.get(key); !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range keys {
+		child := append(append([]string{}, path...), key)
+		bv, inBefore := b.get(key)
+		av, inAfter := a.get(key)
+
+		switch {
+		case !inBefore:
+			changed(pathName(child), "<absent>", compact(av))
+		case !inAfter:
+			changed(pathName(child), compact(bv), "<absent>")
+		default:
+			diff(bv, av, child, changed)
+		}
+	}
+}
+
+func pathName(path []string) string {
+	if len(path) == 0 {
+		return "<document>"
+	}
+	return joinPath(path)
+}
+
+// parseValue reads a value to set as JSON, so numbers, booleans, lists and
+// objects can be set, falling back to the value as a string
+func parseValue(value string) interface{} {
+	if strings.TrimSpace(value) == "" {
+		return value
+	}
+	if parsed, err := decodeJSON([]byte(value)); err == nil {
+		return parsed
+	}
+	return value
+}
+
+// describe a value for an error message
+func describe(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	default:
+		return "a number"
+	}
+}
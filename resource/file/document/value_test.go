@@ -0,0 +1,71 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package document
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitPath(t *testing.T) {
+	t.Parallel()
+
+	keys, err := SplitPath(`log-opts.max-size`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"log-opts", "max-size"}, keys)
+
+	keys, err = SplitPath(`labels.com\.example\.team`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"labels", "com.example.team"}, keys)
+	assert.Equal(t, `labels.com\.example\.team`, joinPath(keys))
+
+	_, err = SplitPath("a..b")
+	assert.EqualError(t, err, `"a..b" has an empty key`)
+
+	_, err = SplitPath(`a\`)
+	assert.EqualError(t, err, `"a\\" ends in an escape`)
+}
+
+// TestMergePatch runs the examples from RFC 7386
+func TestMergePatch(t *testing.T) {
+	t.Parallel()
+
+	for _, example := range []struct{ target, patch, result string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	} {
+		target, err := decodeJSON([]byte(example.target))
+		require.NoError(t, err)
+		patch, err := decodeJSON([]byte(example.patch))
+		require.NoError(t, err)
+
+		assert.Equal(t, example.result, compact(mergePatch(target, patch)), example.patch)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fetch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// validators identify the version of a file on an HTTP server, so it is only
+// downloaded again when it changes
+type validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// defaultCacheDir is where validators are kept across runs
+func defaultCacheDir() (string, error) {
+	usr, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(usr.HomeDir, ".converge/cache/fetch"), nil
+}
+
+// validatorsPath is where the validators for a destination are kept in dir,
+// named by a hash of the destination's absolute path so nothing is left next
+// to the destination itself
+func validatorsPath(dir, dst string) string {
+	if abs, err := filepath.Abs(dst); err == nil {
+		dst = abs
+	}
+	sum := sha256.Sum256([]byte(dst))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// readValidators returns the validators saved for a destination. They are
+// empty if the destination or its validators are missing, so the request is
+// not conditional.
+func readValidators(dir, dst string) validators {
+	var v validators
+	if dir == "" {
+		return v
+	}
+	if _, err := os.Stat(dst); err != nil {
+		return v
+	}
+	blob, err := ioutil.ReadFile(validatorsPath(dir, dst))
+	if err != nil {
+		return v
+	}
+	json.Unmarshal(blob, &v)
+	return v
+}
+
+func (v validators) save(dir, dst string) error {
+	if dir == "" {
+		return nil
+	}
+	if v.ETag == "" && v.LastModified == "" {
+		os.Remove(validatorsPath(dir, dst))
+		return nil
+	}
+	blob, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(validatorsPath(dir, dst), blob, 0600)
+}
+
+// apply makes a request conditional
+func (v validators) apply(req *http.Request) {
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+}
+
+// isHTTP is true for sources that can be fetched conditionally
+func isHTTP(u *url.URL) bool {
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// modified asks the server whether the file changed since it was fetched into
+// dst, without downloading it
+func modified(client *http.Client, source, dir, dst string) (bool, error) {
+	req, err := http.NewRequest("HEAD", source, nil)
+	if err != nil {
+		return false, err
+	}
+	readValidators(dir, dst).apply(req)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return false, nil
+	case http.StatusOK:
+		return true, nil
+	default:
+		return false, fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+}
+
+// conditionalGetter downloads files over HTTP only when they changed on the
+// server since they were last fetched
+type conditionalGetter struct {
+	client *http.Client
+
+	// dir is where the validators are kept
+	dir string
+}
+
+// Get is not supported, only files are fetched conditionally
+func (g *conditionalGetter) Get(dst string, u *url.URL) error {
+	return errors.New("only files can be fetched conditionally")
+}
+
+// GetFile downloads the file at u to dst, unless it is not modified
+func (g *conditionalGetter) GetFile(dst string, u *url.URL) error {
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	readValidators(g.dir, dst).apply(req)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil
+	case http.StatusOK:
+	default:
+		return fmt.Errorf("bad response code: %d", resp.StatusCode)
+	}
+
+	if err := writeFile(dst, resp.Body); err != nil {
+		return err
+	}
+
+	return validators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}.save(g.dir, dst)
+}
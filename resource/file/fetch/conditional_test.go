@@ -0,0 +1,110 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fetch_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource/file/fetch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// TestConditional tests fetching files only when they changed on the server
+func TestConditional(t *testing.T) {
+	t.Parallel()
+
+	var (
+		lock     sync.Mutex
+		content  = "v1"
+		etag     = `"v1"`
+		getCount int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		if r.Method == "GET" {
+			getCount++
+			w.Write([]byte(content))
+		}
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "fetch_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dest := filepath.Join(dir, "dest", "config.json")
+	cache := filepath.Join(dir, "cache")
+	newTask := func() *fetch.Fetch {
+		return &fetch.Fetch{Source: srv.URL + "/config.json", Destination: dest, Conditional: true, CacheDir: cache}
+	}
+
+	apply := func() {
+		_, err := newTask().Apply(context.Background())
+		require.NoError(t, err)
+	}
+
+	check := func() bool {
+		status, err := newTask().Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		return status.HasChanges()
+	}
+
+	assert.True(t, check(), "a missing file is fetched")
+	apply()
+
+	actual, err := ioutil.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(actual))
+
+	saved, err := ioutil.ReadDir(cache)
+	require.NoError(t, err)
+	assert.Len(t, saved, 1, "the validators should be saved")
+
+	beside, err := ioutil.ReadDir(filepath.Dir(dest))
+	require.NoError(t, err)
+	assert.Len(t, beside, 1, "nothing should be left next to the destination")
+
+	assert.False(t, check(), "an unmodified file is not fetched")
+	apply()
+	assert.Equal(t, 1, getCount)
+
+	lock.Lock()
+	content, etag = "v2", `"v2"`
+	lock.Unlock()
+
+	assert.True(t, check(), "a modified file is fetched")
+	apply()
+
+	actual, err = ioutil.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", string(actual))
+	assert.Equal(t, 2, getCount)
+}
@@ -23,9 +23,13 @@ import (
 	"fmt"
 	"hash"
 	"io"
+	"net/http"
 	"net/url"
 	"os"
+	"time"
 
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/artifact"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/hashicorp/go-getter"
 	"github.com/pkg/errors"
@@ -67,12 +71,34 @@ type Fetch struct {
 	// whether the file will be fetched if it already exists
 	Force bool `export:"force"`
 
+	// whether an existing file is fetched again when it changed on the server
+	Conditional bool `export:"conditional"`
+
+	// CacheDir is where the ETag and Last-Modified headers of conditional
+	// fetches are kept. It defaults to ~/.converge/cache/fetch.
+	CacheDir string
+
+	// how many times a failed fetch is retried
+	Retries int `export:"retries"`
+
+	// how long to wait before the first retry, doubling for each retry after
+	RetryDelay time.Duration `export:"retry_delay"`
+
 	// whether the fetched file will be unarchived
 	Unarchive bool
 
+	// proxy is the artifact cache http and https sources are fetched through
+	proxy string
+
+	// proxyToken signs tokens for the artifact cache, if it requires them
+	proxyToken func() (string, error)
+
 	hasApplied bool
 }
 
+// maxRetryDelay caps the backoff between retries
+const maxRetryDelay = time.Minute
+
 // response struct
 // contains response (resource.TaskStatus and error) from Check and Apply
 type response struct {
@@ -80,6 +106,11 @@ type response struct {
 	err    error
 }
 
+// Claims returns the destination file
+func (f *Fetch) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimPath(f.Destination)}
+}
+
 // Check if changes are needed for Fetch
 func (f *Fetch) Check(ctx context.Context, r resource.Renderer) (resource.TaskStatus, error) {
 	ch := make(chan response, 1)
@@ -115,13 +146,16 @@ func (f *Fetch) Apply(ctx context.Context) (resource.TaskStatus, error) {
 }
 
 // checkWithContext implements Check for Fetch
-func (f *Fetch) checkWithContext(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+func (f *Fetch) checkWithContext(ctx context.Context, r resource.Renderer) (resource.TaskStatus, error) {
 	var (
 		hsh    hash.Hash
 		err    error
 		status = resource.NewStatus()
 	)
 
+	f.proxy = artifact.ProxyFrom(ctx)
+	f.proxyToken = artifact.ProxyTokenFrom(ctx)
+
 	if f.hasApplied {
 		return status, nil
 	}
@@ -140,15 +174,17 @@ func (f *Fetch) checkWithContext(context.Context, resource.Renderer) (resource.T
 }
 
 // applyWithContext implements Apply for Fetch
-func (f *Fetch) applyWithContext(context.Context) (resource.TaskStatus, error) {
+func (f *Fetch) applyWithContext(ctx context.Context) (resource.TaskStatus, error) {
 	var (
-		hsh      hash.Hash
-		err      error
-		status   = resource.NewStatus()
-		checksum = ""
-		mode     = getter.ClientModeFile
+		hsh    hash.Hash
+		err    error
+		status = resource.NewStatus()
+		mode   = getter.ClientModeFile
 	)
 
+	f.proxy = artifact.ProxyFrom(ctx)
+	f.proxyToken = artifact.ProxyTokenFrom(ctx)
+
 	if f.Hash != "" {
 		hsh, err = f.getHash()
 		if err != nil {
@@ -164,22 +200,14 @@ func (f *Fetch) applyWithContext(context.Context) (resource.TaskStatus, error) {
 		return status, nil
 	}
 
-	u, err := url.Parse(f.Source)
+	source, err := f.clientSource()
 	if err != nil {
 		status.RaiseLevel(resource.StatusFatal)
 		return status, errors.Wrap(err, "could not parse source")
 	}
-
-	values := u.Query()
-	if f.Unarchive == false {
-		values.Set("archive", "false")
-	} else {
+	if f.Unarchive {
 		mode = getter.ClientModeAny
 	}
-	if f.Hash != "" {
-		checksum = fmt.Sprintf("checksum=%s:%s", f.HashType, f.Hash)
-	}
-	source := u.String() + "?" + values.Encode() + checksum
 
 	pwd, err := os.Getwd()
 	if err != nil {
@@ -188,12 +216,21 @@ func (f *Fetch) applyWithContext(context.Context) (resource.TaskStatus, error) {
 	}
 
 	client := &getter.Client{
-		Src:  source,
-		Dst:  f.Destination,
-		Pwd:  pwd,
-		Mode: mode,
+		Src:     source,
+		Dst:     f.Destination,
+		Pwd:     pwd,
+		Mode:    mode,
+		Getters: f.getters(),
+	}
+	get := func() error {
+		// a token for the artifact cache is only valid for a short time, so
+		// every attempt gets a new one
+		if client.Src, err = f.clientSource(); err != nil {
+			return err
+		}
+		return client.Get()
 	}
-	if err := client.Get(); err != nil {
+	if err := f.retry(ctx, status, get); err != nil {
 		status.RaiseLevel(resource.StatusFatal)
 		return status, errors.Wrap(err, "failed to fetch")
 	}
@@ -203,6 +240,89 @@ func (f *Fetch) applyWithContext(context.Context) (resource.TaskStatus, error) {
 	return status, nil
 }
 
+// fetchSource is where the file is fetched from. Through an artifact cache,
+// http and https sources are fetched from the cache instead.
+func (f *Fetch) fetchSource() (string, error) {
+	if f.proxy == "" {
+		return f.Source, nil
+	}
+
+	var token string
+	if f.proxyToken != nil {
+		var err error
+		if token, err = f.proxyToken(); err != nil {
+			return "", errors.Wrap(err, "could not sign token for the artifact cache")
+		}
+	}
+	return artifact.ProxyURL(f.proxy, token, f.Source)
+}
+
+// clientSource is the source with the options for the getter: whether to
+// unarchive it and the checksum it must have
+func (f *Fetch) clientSource() (string, error) {
+	source, err := f.fetchSource()
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	values := u.Query()
+	if f.Unarchive == false {
+		values.Set("archive", "false")
+	}
+	if f.Hash != "" {
+		values.Set("checksum", fmt.Sprintf("%s:%s", f.HashType, f.Hash))
+	}
+	u.RawQuery = values.Encode()
+	return u.String(), nil
+}
+
+// getters are the protocols files can be fetched with
+func (f *Fetch) getters() map[string]getter.Getter {
+	getters := map[string]getter.Getter{"ftp": new(ftpGetter)}
+	for scheme, g := range getter.Getters {
+		getters[scheme] = g
+	}
+
+	if f.Conditional {
+		conditional := &conditionalGetter{client: http.DefaultClient, dir: f.cacheDir()}
+		getters["http"] = conditional
+		getters["https"] = conditional
+	}
+
+	return getters
+}
+
+// retry runs fetch until it succeeds or runs out of retries, backing off
+// between attempts
+func (f *Fetch) retry(ctx context.Context, status *resource.Status, fetch func() error) error {
+	delay := f.RetryDelay
+	for attempt := 0; ; attempt++ {
+		err := fetch()
+		if err == nil || attempt >= f.Retries {
+			return err
+		}
+
+		log.WithField("module", "file.fetch").WithError(err).WithField("delay", delay).Debug("fetch failed, retrying")
+		status.AddMessage(fmt.Sprintf("attempt %d failed: %s", attempt+1, err))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
+
 // DiffFile evaluates the differences of the file to be fetched and the current
 // state of the system
 func (f *Fetch) DiffFile(status *resource.Status, hsh hash.Hash) (*resource.Status, error) {
@@ -253,6 +373,8 @@ func (f *Fetch) DiffFile(status *resource.Status, hsh hash.Hash) (*resource.Stat
 		if f.Force {
 			status.AddDifference("destination", "<force fetch>", f.Destination, "")
 			status.AddMessage("file exists, will fetch due to \"force\"")
+		} else if f.Conditional {
+			f.diffConditional(status)
 		} else {
 			status.AddMessage("file exists")
 		}
@@ -263,6 +385,42 @@ func (f *Fetch) DiffFile(status *resource.Status, hsh hash.Hash) (*resource.Stat
 	return status, nil
 }
 
+// diffConditional asks the server whether an existing file changed
+func (f *Fetch) diffConditional(status *resource.Status) {
+	changed, err := f.modified()
+	switch {
+	case err != nil:
+		status.AddDifference("destination", "<unchecked>", f.Destination, "")
+		status.AddMessage(fmt.Sprintf("could not check for changes, will fetch: %s", err))
+	case changed:
+		status.AddDifference("destination", "<modified>", f.Destination, "")
+		status.AddMessage("file changed on the server")
+	default:
+		status.AddMessage("file exists, not modified on the server")
+	}
+}
+
+// modified asks wherever the file is fetched from whether it changed, so the
+// answer matches what will be fetched
+func (f *Fetch) modified() (bool, error) {
+	source, err := f.fetchSource()
+	if err != nil {
+		return false, err
+	}
+	return modified(http.DefaultClient, source, f.cacheDir(), f.Destination)
+}
+
+// cacheDir is where the validators of conditional fetches are kept. It is
+// empty if there is no home directory to keep them in, and then every fetch is
+// unconditional.
+func (f *Fetch) cacheDir() string {
+	if f.CacheDir != "" {
+		return f.CacheDir
+	}
+	dir, _ := defaultCacheDir()
+	return dir
+}
+
 // getHash returns a new hash based on the f.HashType
 func (f *Fetch) getHash() (hash.Hash, error) {
 	switch f.HashType {
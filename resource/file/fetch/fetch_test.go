@@ -24,9 +24,15 @@ import (
 	"hash"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/asteris-llc/converge/artifact"
 	"github.com/asteris-llc/converge/helpers/fakerenderer"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/file/fetch"
@@ -198,6 +204,10 @@ func TestApply(t *testing.T) {
 			require.NoError(t, err)
 			defer os.Remove(src.Name())
 
+			_, err = src.Write([]byte("new content"))
+			require.NoError(t, err)
+			require.NoError(t, src.Sync())
+
 			dest, err := ioutil.TempFile("", "fetch_test.txt")
 			require.NoError(t, err)
 			defer os.Remove(dest.Name())
@@ -205,12 +215,15 @@ func TestApply(t *testing.T) {
 			hash, err := getHash(src.Name(), string(fetch.HashMD5))
 			require.NoError(t, err)
 
+			destHash, err := getHash(dest.Name(), string(fetch.HashMD5))
+			require.NoError(t, err)
+
 			m := &MockDiff{}
 			task := fetch.Fetch{
 				Source:      src.Name(),
 				Destination: dest.Name(),
 				HashType:    string(fetch.HashMD5),
-				Hash:        "notarealhashbutstringnonetheless",
+				Hash:        hex.EncodeToString(hash.Sum(nil)),
 				Force:       true,
 			}
 			defer os.Remove(task.Destination)
@@ -222,7 +235,7 @@ func TestApply(t *testing.T) {
 
 			assert.NoError(t, err)
 			assert.Contains(t, status.Messages(), "fetched successfully")
-			assert.Equal(t, hex.EncodeToString(hash.Sum(nil)), status.Diffs()["checksum"].Original())
+			assert.Equal(t, hex.EncodeToString(destHash.Sum(nil)), status.Diffs()["checksum"].Original())
 			assert.Equal(t, task.Hash, status.Diffs()["checksum"].Current())
 			assert.True(t, status.HasChanges())
 		})
@@ -467,6 +480,126 @@ func TestApply(t *testing.T) {
 	})
 }
 
+// TestApplyVerifiesChecksum tests that a fetched file must match the checksum
+func TestApplyVerifiesChecksum(t *testing.T) {
+	t.Parallel()
+
+	src, err := ioutil.TempFile("", "fetch_test.txt")
+	require.NoError(t, err)
+	defer os.Remove(src.Name())
+
+	_, err = src.Write([]byte("tampered"))
+	require.NoError(t, err)
+	require.NoError(t, src.Close())
+
+	dir, err := ioutil.TempDir("", "fetch_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	task := fetch.Fetch{
+		Source:      src.Name(),
+		Destination: filepath.Join(dir, "fetched.txt"),
+		HashType:    string(fetch.HashSHA256),
+		Hash:        hex.EncodeToString(sha256.New().Sum(nil)),
+	}
+
+	_, err = task.Apply(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Checksums did not match")
+}
+
+// TestApplyRetries tests retrying failed fetches
+func TestApplyRetries(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= 2 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("finally"))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "fetch_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("gives up", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		task := fetch.Fetch{
+			Source:      srv.URL + "/file.txt",
+			Destination: filepath.Join(dir, "gives-up.txt"),
+			Retries:     1,
+			RetryDelay:  time.Millisecond,
+		}
+
+		_, err := task.Apply(context.Background())
+		assert.Error(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("succeeds", func(t *testing.T) {
+		atomic.StoreInt32(&requests, 0)
+		task := fetch.Fetch{
+			Source:      srv.URL + "/file.txt",
+			Destination: filepath.Join(dir, "succeeds.txt"),
+			Retries:     2,
+			RetryDelay:  time.Millisecond,
+		}
+
+		status, err := task.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Contains(t, status.Messages(), "fetched successfully")
+		assert.Len(t, status.Messages(), 3)
+
+		content, err := ioutil.ReadFile(task.Destination)
+		require.NoError(t, err)
+		assert.Equal(t, "finally", string(content))
+	})
+}
+
+// TestApplyThroughArtifactProxy tests fetching through an artifact cache
+func TestApplyThroughArtifactProxy(t *testing.T) {
+	t.Parallel()
+
+	var upstream int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&upstream, 1)
+		w.Write([]byte("cached"))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "fetch_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cache, err := artifact.New(filepath.Join(dir, "cache"), 0, []string{"127.0.0.1"})
+	require.NoError(t, err)
+	proxy := httptest.NewServer(cache.Handler(http.NotFoundHandler()))
+	defer proxy.Close()
+
+	ctx := artifact.WithProxy(context.Background(), proxy.URL)
+	for _, name := range []string{"first.txt", "second.txt"} {
+		task := fetch.Fetch{
+			Source:      srv.URL + "/file.txt",
+			Destination: filepath.Join(dir, name),
+			HashType:    string(fetch.HashSHA256),
+			Hash:        "3673014e72b67383be302485694555a57ad393afdebaed6ded110a775bd0556d",
+		}
+
+		_, err := task.Apply(ctx)
+		require.NoError(t, err)
+
+		content, err := ioutil.ReadFile(task.Destination)
+		require.NoError(t, err)
+		assert.Equal(t, "cached", string(content))
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&upstream))
+}
+
 // TestDiffFile tests DiffFile
 func TestDiffFile(t *testing.T) {
 	t.Parallel()
@@ -0,0 +1,223 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fetch
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/atomicfile"
+	"github.com/pkg/errors"
+)
+
+// ftpTimeout bounds connecting to an FTP server, and how long it can go
+// without answering once connected
+const ftpTimeout = 30 * time.Second
+
+// ftpGetter downloads single files over FTP in passive mode. Credentials are
+// taken from the URL, and the login is anonymous without them.
+type ftpGetter struct{}
+
+// Get is not supported, only files can be fetched over FTP
+func (g *ftpGetter) Get(dst string, u *url.URL) error {
+	return errors.New("only files can be fetched over ftp")
+}
+
+// GetFile downloads the file at u to dst
+func (g *ftpGetter) GetFile(dst string, u *url.URL) error {
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "21")
+	}
+
+	conn, err := net.DialTimeout("tcp", host, ftpTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctrl := textproto.NewConn(&timeoutConn{Conn: conn, timeout: ftpTimeout})
+	if _, _, err := ctrl.ReadResponse(220); err != nil {
+		return errors.Wrap(err, "ftp greeting")
+	}
+
+	if err := ftpLogin(ctrl, u.User); err != nil {
+		return err
+	}
+
+	if _, err := ftpCmd(ctrl, 200, "TYPE I"); err != nil {
+		return err
+	}
+
+	msg, err := ftpCmd(ctrl, 227, "PASV")
+	if err != nil {
+		return err
+	}
+	port, err := parsePASV(msg)
+	if err != nil {
+		return err
+	}
+
+	// connect to the data port on the host we already reached, since the
+	// address in the reply is often wrong behind NAT
+	dataConn, err := net.DialTimeout("tcp", net.JoinHostPort(u.Hostname(), strconv.Itoa(port)), ftpTimeout)
+	if err != nil {
+		return errors.Wrap(err, "ftp data connection")
+	}
+	defer dataConn.Close()
+	data := &timeoutConn{Conn: dataConn, timeout: ftpTimeout}
+
+	if _, err := ftpCmd(ctrl, 1, "RETR %s", ftpPath(u)); err != nil {
+		return err
+	}
+
+	if err := writeFile(dst, data); err != nil {
+		return err
+	}
+	data.Close()
+
+	if _, _, err := ctrl.ReadResponse(2); err != nil {
+		return errors.Wrap(err, "ftp transfer")
+	}
+
+	ftpCmd(ctrl, 221, "QUIT")
+	return nil
+}
+
+// ftpPath is the path of the file in a URL, relative to the directory the
+// login starts in. An absolute path is written with an escaped slash, like
+// ftp://host/%2Fetc/motd, as RFC 1738 describes.
+func ftpPath(u *url.URL) string {
+	return strings.TrimPrefix(u.Path, "/")
+}
+
+// timeoutConn extends the deadline of a connection before each read and
+// write, so a server which stops answering fails the fetch instead of hanging
+// it
+type timeoutConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *timeoutConn) Read(b []byte) (int, error) {
+	c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	return c.Conn.Read(b)
+}
+
+func (c *timeoutConn) Write(b []byte) (int, error) {
+	c.Conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	return c.Conn.Write(b)
+}
+
+// ftpLogin logs in with the URL's credentials, or anonymously
+func ftpLogin(ctrl *textproto.Conn, user *url.Userinfo) error {
+	name, pass := "anonymous", "anonymous@"
+	if user != nil {
+		name = user.Username()
+		if p, ok := user.Password(); ok {
+			pass = p
+		}
+	}
+
+	code, msg, err := ftpSend(ctrl, "USER %s", name)
+	if err != nil {
+		return err
+	}
+	switch code {
+	case 230:
+		return nil
+	case 331:
+		_, err := ftpCmd(ctrl, 230, "PASS %s", pass)
+		return err
+	default:
+		return fmt.Errorf("ftp login: %d %s", code, msg)
+	}
+}
+
+// ftpCmd sends a command and checks the reply code, which may be a prefix like
+// 2 for any 2xx reply
+func ftpCmd(ctrl *textproto.Conn, expect int, format string, args ...interface{}) (string, error) {
+	id, err := ctrl.Cmd(format, args...)
+	if err != nil {
+		return "", err
+	}
+	ctrl.StartResponse(id)
+	defer ctrl.EndResponse(id)
+
+	_, msg, err := ctrl.ReadResponse(expect)
+	if err != nil {
+		return "", errors.Wrapf(err, "ftp %s", commandName(format))
+	}
+	return msg, nil
+}
+
+// ftpSend sends a command and returns whatever the server replies
+func ftpSend(ctrl *textproto.Conn, format string, args ...interface{}) (int, string, error) {
+	id, err := ctrl.Cmd(format, args...)
+	if err != nil {
+		return 0, "", err
+	}
+	ctrl.StartResponse(id)
+	defer ctrl.EndResponse(id)
+
+	code, msg, err := ctrl.ReadResponse(0)
+	if _, ok := err.(*textproto.Error); ok {
+		err = nil
+	}
+	return code, msg, err
+}
+
+// commandName is the FTP command in a format, for errors that shouldn't
+// include arguments like passwords
+func commandName(format string) string {
+	for i, r := range format {
+		if r == ' ' {
+			return format[:i]
+		}
+	}
+	return format
+}
+
+var pasvReply = regexp.MustCompile(`(\d+),(\d+),(\d+),(\d+),(\d+),(\d+)`)
+
+// parsePASV finds the data port in a reply like
+// "Entering Passive Mode (192,168,1,2,195,80)"
+func parsePASV(msg string) (int, error) {
+	parts := pasvReply.FindStringSubmatch(msg)
+	if parts == nil {
+		return 0, fmt.Errorf("ftp PASV: unexpected reply %q", msg)
+	}
+	high, _ := strconv.Atoi(parts[5])
+	low, _ := strconv.Atoi(parts[6])
+	return high*256 + low, nil
+}
+
+// writeFile writes to a temporary file next to dst and renames it into place,
+// so a failed download doesn't leave a partial file behind
+func writeFile(dst string, src io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(dst, src, 0644)
+}
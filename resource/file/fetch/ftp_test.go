@@ -0,0 +1,183 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fetch_test
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/resource/file/fetch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// serveFTP serves files over a minimal passive-mode FTP server, accepting one
+// login as user/pass
+func serveFTP(t *testing.T, files map[string]string) (string, func()) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go handleFTP(conn, files)
+		}
+	}()
+
+	return ln.Addr().String(), func() { ln.Close() }
+}
+
+func handleFTP(conn net.Conn, files map[string]string) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(format string, args ...interface{}) {
+		fmt.Fprintf(conn, format+"\r\n", args...)
+	}
+
+	var data net.Listener
+	reply("220 ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.SplitN(strings.TrimSpace(line), " ", 2)
+		arg := ""
+		if len(fields) == 2 {
+			arg = fields[1]
+		}
+
+		switch fields[0] {
+		case "USER":
+			reply("331 password please")
+		case "PASS":
+			if arg != "pass" {
+				reply("530 login incorrect")
+				continue
+			}
+			reply("230 logged in")
+		case "TYPE":
+			reply("200 binary")
+		case "PASV":
+			data, err = net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				reply("425 no data connection")
+				continue
+			}
+			port := data.Addr().(*net.TCPAddr).Port
+			reply("227 Entering Passive Mode (10,0,0,1,%d,%d)", port/256, port%256)
+		case "RETR":
+			content, ok := files[arg]
+			if !ok {
+				reply("550 no such file")
+				continue
+			}
+			reply("150 sending")
+			dconn, err := data.Accept()
+			if err != nil {
+				return
+			}
+			dconn.Write([]byte(content))
+			dconn.Close()
+			data.Close()
+			reply("226 done")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+// TestFetchFTP tests fetching files over FTP
+func TestFetchFTP(t *testing.T) {
+	t.Parallel()
+
+	addr, stop := serveFTP(t, map[string]string{
+		"pub/motd":  "hello over ftp\n",
+		"/etc/motd": "absolute\n",
+	})
+	defer stop()
+
+	dir, err := ioutil.TempDir("", "fetch_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("fetches", func(t *testing.T) {
+		task := fetch.Fetch{
+			Source:      "ftp://user:pass@" + addr + "/pub/motd",
+			Destination: filepath.Join(dir, "motd"),
+		}
+
+		status, err := task.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Contains(t, status.Messages(), "fetched successfully")
+
+		content, err := ioutil.ReadFile(task.Destination)
+		require.NoError(t, err)
+		assert.Equal(t, "hello over ftp\n", string(content))
+	})
+
+	t.Run("absolute path", func(t *testing.T) {
+		task := fetch.Fetch{
+			Source:      "ftp://user:pass@" + addr + "/%2Fetc/motd",
+			Destination: filepath.Join(dir, "absolute"),
+		}
+
+		_, err := task.Apply(context.Background())
+		require.NoError(t, err)
+
+		content, err := ioutil.ReadFile(task.Destination)
+		require.NoError(t, err)
+		assert.Equal(t, "absolute\n", string(content))
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		task := fetch.Fetch{
+			Source:      "ftp://user:pass@" + addr + "/pub/missing",
+			Destination: filepath.Join(dir, "missing"),
+		}
+
+		_, err := task.Apply(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ftp RETR: 550")
+
+		_, err = os.Stat(task.Destination)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("bad login", func(t *testing.T) {
+		task := fetch.Fetch{
+			Source:      "ftp://user:wrong@" + addr + "/pub/motd",
+			Destination: filepath.Join(dir, "denied"),
+		}
+
+		_, err := task.Apply(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ftp PASS: 530")
+	})
+}
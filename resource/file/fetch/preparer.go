@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/asteris-llc/converge/load/registry"
 	"github.com/asteris-llc/converge/resource"
@@ -47,6 +48,22 @@ type Preparer struct {
 	// 1. no checksum is provided
 	// 2. the checksum of the existing file differs from the checksum provided
 	Force bool `hcl:"force"`
+
+	// Conditional fetches an existing file again when it changed on the server,
+	// using the ETag and Last-Modified headers from the last fetch. Only http
+	// and https sources can be fetched conditionally. It can't be used with a
+	// hash: a file with the right checksum is never fetched again, and one that
+	// changed on the server would fail the checksum anyway. The headers are
+	// kept in `~/.converge/cache/fetch` of the user running converge.
+	Conditional bool `hcl:"conditional"`
+
+	// Retries is how many times a failed fetch is retried
+	Retries int `hcl:"retries"`
+
+	// RetryDelay is how long to wait before the first retry. The delay doubles
+	// for each retry after that, up to a minute. If no delay is specified, it
+	// will default to 1 second.
+	RetryDelay *time.Duration `hcl:"retry_delay"`
 }
 
 // Prepare a new fetch task
@@ -54,7 +71,7 @@ func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resou
 	if strings.TrimSpace(p.Source) == "" {
 		return nil, errors.New("\"source\" must contain a value")
 	}
-	_, err := url.Parse(p.Source)
+	u, err := url.Parse(p.Source)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse \"source\"")
 	}
@@ -85,10 +102,31 @@ func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resou
 		}
 	}
 
+	if p.Conditional {
+		if !isHTTP(u) {
+			return nil, errors.New("\"conditional\" requires an http or https \"source\"")
+		}
+		if p.Hash != nil {
+			return nil, errors.New("\"conditional\" cannot be used with \"hash\", which already decides whether to fetch")
+		}
+	}
+
+	if p.Retries < 0 {
+		return nil, errors.New("\"retries\" cannot be negative")
+	}
+
+	retryDelay := time.Second
+	if p.RetryDelay != nil {
+		retryDelay = *p.RetryDelay
+	}
+
 	fetch := &Fetch{
 		Source:      p.Source,
 		Destination: p.Destination,
 		Force:       p.Force,
+		Conditional: p.Conditional,
+		Retries:     p.Retries,
+		RetryDelay:  retryDelay,
 	}
 
 	if p.HashType != nil {
@@ -22,11 +22,13 @@ import (
 	"encoding/hex"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/asteris-llc/converge/helpers/fakerenderer"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/file/fetch"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/net/context"
 )
 
@@ -271,5 +273,57 @@ func TestPreparer(t *testing.T) {
 				})
 			})
 		})
+
+		t.Run("conditional", func(t *testing.T) {
+			t.Run("not http", func(t *testing.T) {
+				prep := fetch.Preparer{
+					Destination: "/tmp/converge.tar.gz",
+					Source:      "ftp://example.com/converge.tar.gz",
+					Conditional: true,
+				}
+
+				_, err := prep.Prepare(context.Background(), &fr)
+				assert.EqualError(t, err, "\"conditional\" requires an http or https \"source\"")
+			})
+
+			t.Run("with hash", func(t *testing.T) {
+				hashType := string(fetch.HashMD5)
+				hash := hex.EncodeToString(md5.New().Sum(nil))
+				prep := fetch.Preparer{
+					Destination: "/tmp/converge.tar.gz",
+					Source:      "https://example.com/converge.tar.gz",
+					HashType:    &hashType,
+					Hash:        &hash,
+					Conditional: true,
+				}
+
+				_, err := prep.Prepare(context.Background(), &fr)
+				assert.EqualError(t, err, "\"conditional\" cannot be used with \"hash\", which already decides whether to fetch")
+			})
+		})
+
+		t.Run("retries", func(t *testing.T) {
+			prep := fetch.Preparer{
+				Destination: "/tmp/converge.tar.gz",
+				Source:      "https://example.com/converge.tar.gz",
+				Retries:     -1,
+			}
+
+			_, err := prep.Prepare(context.Background(), &fr)
+			assert.EqualError(t, err, "\"retries\" cannot be negative")
+		})
+	})
+
+	t.Run("retry delay", func(t *testing.T) {
+		prep := fetch.Preparer{
+			Destination: "/tmp/converge.tar.gz",
+			Source:      "https://example.com/converge.tar.gz",
+			Retries:     3,
+		}
+
+		task, err := prep.Prepare(context.Background(), &fr)
+		require.NoError(t, err)
+		assert.Equal(t, 3, task.(*fetch.Fetch).Retries)
+		assert.Equal(t, time.Second, task.(*fetch.Fetch).RetryDelay)
 	})
 }
@@ -0,0 +1,107 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package filter selects the files a recursive file resource walks over with
+// include and exclude globs
+package filter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Filter matches paths below a root directory. Patterns without a slash match
+// the base name of a path anywhere in the tree, like "*.log". Patterns with a
+// slash match the path relative to the root, like "cache/*".
+type Filter struct {
+	Include []string
+	Exclude []string
+}
+
+// New returns a filter, or an error if any pattern is malformed
+func New(include, exclude []string) (*Filter, error) {
+	for _, pattern := range append(append([]string{}, include...), exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %s", pattern, err)
+		}
+	}
+
+	return &Filter{Include: include, Exclude: exclude}, nil
+}
+
+// Empty is true if the filter matches everything
+func (f *Filter) Empty() bool {
+	return f == nil || (len(f.Include) == 0 && len(f.Exclude) == 0)
+}
+
+// Excluded is true if an exclude pattern matches the path
+func (f *Filter) Excluded(root, path string) bool {
+	return f != nil && matchAny(f.Exclude, root, path)
+}
+
+// Included is true if the path is not excluded and, when there are include
+// patterns, one of them matches the path
+func (f *Filter) Included(root, path string) bool {
+	if f.Excluded(root, path) {
+		return false
+	}
+	return f == nil || len(f.Include) == 0 || matchAny(f.Include, root, path)
+}
+
+// Wrap returns a walk function which calls fn for included paths only.
+// Excluded directories are skipped along with everything below them, but
+// directories which are not included are still walked for included files.
+func (f *Filter) Wrap(root string, fn filepath.WalkFunc) filepath.WalkFunc {
+	return func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fn(path, info, err)
+		}
+
+		if f.Excluded(root, path) {
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !f.Included(root, path) {
+			return nil
+		}
+
+		return fn(path, info, err)
+	}
+}
+
+func matchAny(patterns []string, root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+	base := filepath.Base(path)
+
+	for _, pattern := range patterns {
+		target := base
+		if strings.Contains(pattern, "/") {
+			target = filepath.ToSlash(rel)
+		}
+
+		// patterns are checked by New
+		if ok, _ := filepath.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/asteris-llc/converge/resource/file/filter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	_, err := filter.New([]string{"*.log"}, nil)
+	assert.NoError(t, err)
+
+	_, err = filter.New(nil, []string{"[a-"})
+	assert.EqualError(t, err, `invalid pattern "[a-": syntax error in pattern`)
+}
+
+func TestIncluded(t *testing.T) {
+	t.Parallel()
+
+	f, err := filter.New([]string{"*.conf", "sites/*"}, []string{"*.bak", "cache"})
+	require.NoError(t, err)
+
+	for path, included := range map[string]bool{
+		"/etc/app/app.conf":          true,
+		"/etc/app/conf.d/extra.conf": true,
+		"/etc/app/sites/default":     true,
+		"/etc/app/sites/old.bak":     false,
+		"/etc/app/cache":             false,
+		"/etc/app/README":            false,
+	} {
+		assert.Equal(t, included, f.Included("/etc/app", path), path)
+	}
+
+	var empty *filter.Filter
+	assert.True(t, empty.Empty())
+	assert.True(t, empty.Included("/etc/app", "/etc/app/README"))
+}
+
+func TestWrap(t *testing.T) {
+	t.Parallel()
+
+	root, err := ioutil.TempDir("", "converge-filter")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	for _, name := range []string{"a.conf", "b.txt", "sub/c.conf", "cache/d.conf"} {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, nil, 0644))
+	}
+
+	f, err := filter.New([]string{"*.conf"}, []string{"cache"})
+	require.NoError(t, err)
+
+	var seen []string
+	err = filepath.Walk(root, f.Wrap(root, func(path string, info os.FileInfo, err error) error {
+		rel, _ := filepath.Rel(root, path)
+		seen = append(seen, rel)
+		return err
+	}))
+	require.NoError(t, err)
+
+	sort.Strings(seen)
+	assert.Equal(t, []string{"a.conf", filepath.Join("sub", "c.conf")}, seen)
+}
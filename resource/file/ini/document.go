@@ -0,0 +1,198 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ini
+
+import (
+	"strings"
+)
+
+// lineKind is what a line in an INI file holds
+type lineKind int
+
+const (
+	lineOther lineKind = iota
+	lineSection
+	lineKey
+)
+
+// line is a parsed line of an INI file
+type line struct {
+	kind lineKind
+
+	// name is the section name for lineSection and the key for lineKey
+	name string
+
+	// value is the value for lineKey
+	value string
+}
+
+// parseLine parses a single line, which may end in a newline. Lines without a
+// "=" are keys without a value, as in MySQL option files.
+func parseLine(raw string) line {
+	trimmed := strings.TrimSpace(raw)
+	switch {
+	case trimmed == "", strings.HasPrefix(trimmed, "#"), strings.HasPrefix(trimmed, ";"):
+		return line{kind: lineOther}
+
+	case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+		return line{kind: lineSection, name: strings.TrimSpace(trimmed[1 : len(trimmed)-1])}
+	}
+
+	idx := strings.Index(trimmed, "=")
+	if idx < 0 {
+		return line{kind: lineKey, name: trimmed}
+	}
+	return line{
+		kind:  lineKey,
+		name:  strings.TrimSpace(trimmed[:idx]),
+		value: strings.TrimSpace(trimmed[idx+1:]),
+	}
+}
+
+// document is the lines of an INI file, edited in place so comments, ordering
+// and unrelated keys are kept
+type document struct {
+	lines []string
+}
+
+func parseDocument(content string) *document {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return &document{lines: lines}
+}
+
+func (d *document) String() string {
+	return strings.Join(d.lines, "")
+}
+
+// walk calls fn with the section each line is in. Lines before the first
+// section header are in the section named "".
+func (d *document) walk(fn func(idx int, section string, parsed line)) {
+	section := ""
+	for idx, raw := range d.lines {
+		parsed := parseLine(raw)
+		if parsed.kind == lineSection {
+			section = parsed.name
+		}
+		fn(idx, section, parsed)
+	}
+}
+
+// values are the values of every occurrence of key in section
+func (d *document) values(section, key string) []string {
+	var values []string
+	d.walk(func(_ int, current string, parsed line) {
+		if current == section && parsed.kind == lineKey && parsed.name == key {
+			values = append(values, parsed.value)
+		}
+	})
+	return values
+}
+
+// set sets every occurrence of key in section to value, adding the key after
+// the last key in the section if it isn't there, and the section at the end of
+// the document if it isn't there either. New keys are joined to their values
+// with separator.
+func (d *document) set(section, key, value, separator string) {
+	found := false
+	headerAt, lastKeyAt, firstHeaderAt := -1, -1, -1
+	d.walk(func(idx int, current string, parsed line) {
+		if parsed.kind == lineSection && firstHeaderAt < 0 {
+			firstHeaderAt = idx
+		}
+		if current != section {
+			return
+		}
+		switch parsed.kind {
+		case lineSection:
+			if headerAt < 0 {
+				headerAt = idx
+			}
+		case lineKey:
+			lastKeyAt = idx
+			if parsed.name == key {
+				found = true
+				if parsed.value != value {
+					d.lines[idx] = setValue(d.lines[idx], key, value, separator)
+				}
+			}
+		}
+	})
+	if found {
+		return
+	}
+
+	entry := key + separator + value + "\n"
+	switch {
+	case lastKeyAt >= 0:
+		d.insert(lastKeyAt+1, entry)
+	case headerAt >= 0:
+		d.insert(headerAt+1, entry)
+	case section == "" && firstHeaderAt >= 0:
+		d.insert(firstHeaderAt, entry)
+	case section == "":
+		d.insert(len(d.lines), entry)
+	default:
+		if n := len(d.lines); n > 0 && strings.TrimSpace(d.lines[n-1]) != "" {
+			d.insert(n, "\n")
+		}
+		d.insert(len(d.lines), "["+section+"]\n", entry)
+	}
+}
+
+// remove removes every occurrence of key in section
+func (d *document) remove(section, key string) {
+	var kept []string
+	d.walk(func(idx int, current string, parsed line) {
+		if current == section && parsed.kind == lineKey && parsed.name == key {
+			return
+		}
+		kept = append(kept, d.lines[idx])
+	})
+	d.lines = kept
+}
+
+// insert adds lines at idx, ending the line before them if it has no newline
+func (d *document) insert(idx int, lines ...string) {
+	if idx > 0 && !strings.HasSuffix(d.lines[idx-1], "\n") {
+		d.lines[idx-1] += "\n"
+	}
+
+	updated := make([]string, 0, len(d.lines)+len(lines))
+	updated = append(updated, d.lines[:idx]...)
+	updated = append(updated, lines...)
+	updated = append(updated, d.lines[idx:]...)
+	d.lines = updated
+}
+
+// setValue replaces the value on a key line, keeping its indentation, the
+// spacing around "=" and its line ending
+func setValue(raw, key, value, separator string) string {
+	body := strings.TrimRight(raw, "\r\n")
+	ending := raw[len(body):]
+
+	idx := strings.Index(body, "=")
+	if idx < 0 {
+		indent := body[:len(body)-len(strings.TrimLeft(body, " \t"))]
+		return indent + key + separator + value + ending
+	}
+
+	prefix := body[:idx+1]
+	rest := body[idx+1:]
+	prefix += rest[:len(rest)-len(strings.TrimLeft(rest, " \t"))]
+	return prefix + value + ending
+}
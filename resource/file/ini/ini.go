@@ -0,0 +1,188 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ini
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/command"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/attributes"
+	"golang.org/x/net/context"
+)
+
+// DefaultSeparator joins keys added to a file to their values
+const DefaultSeparator = " = "
+
+// INI sets and removes keys in an INI-style file
+type INI struct {
+	// the file the keys are in
+	Destination string `export:"destination"`
+
+	// the values of keys to set, by "section/key"
+	Settings map[string]string `export:"settings"`
+
+	// the keys to remove, as "section/key"
+	Remove []string `export:"remove"`
+
+	// what joins keys that are added to their values
+	Separator string `export:"separator"`
+
+	// whether the file is created if it doesn't exist
+	Create bool `export:"create"`
+}
+
+// SplitName splits a "section/key" name into its section and key. Names
+// without a "/" are keys before the first section.
+func SplitName(name string) (section, key string) {
+	idx := strings.Index(name, "/")
+	if idx < 0 {
+		return "", name
+	}
+	return name[:idx], name[idx+1:]
+}
+
+// Claims returns the file the keys are in
+func (i *INI) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimPath(i.Destination)}
+}
+
+// Check whether any keys need to be changed
+func (i *INI) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	original, missing, err := i.read()
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+
+	if missing && len(i.Settings) > 0 && !i.Create {
+		// the file may be created by another resource in the same run
+		status.RaiseLevel(resource.StatusMayChange)
+		status.SetWarning(fmt.Sprintf("%s does not exist", i.Destination))
+		return status, nil
+	}
+
+	i.update(status, original)
+	status.RaiseLevelForDiffs()
+	return status, nil
+}
+
+// Apply writes the changed keys to the file
+func (i *INI) Apply(context.Context) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	original, missing, err := i.read()
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+
+	if missing && len(i.Settings) > 0 && !i.Create {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, fmt.Errorf("cannot set keys in %s: it does not exist", i.Destination)
+	}
+
+	updated := i.update(status, original)
+	if !status.HasChanges() {
+		return status, nil
+	}
+
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(i.Destination); err == nil {
+		perm = info.Mode()
+	}
+
+	write := func() error { return ioutil.WriteFile(i.Destination, []byte(updated), perm) }
+	err = write()
+	if os.IsPermission(err) {
+		// the file may be immutable or append-only
+		err = attributes.Unprotected(command.ExecRunner{}, i.Destination, write)
+	}
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+
+	status.RaiseLevelForDiffs()
+	return status, nil
+}
+
+// read reads the file, which may be missing
+func (i *INI) read() (content string, missing bool, err error) {
+	data, err := ioutil.ReadFile(i.Destination)
+	if os.IsNotExist(err) {
+		return "", true, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	return string(data), false, nil
+}
+
+// update returns the content of the file with the keys set and removed,
+// adding a difference to status for each key that changes
+func (i *INI) update(status *resource.Status, original string) string {
+	doc := parseDocument(original)
+
+	names := make([]string, 0, len(i.Settings))
+	for name := range i.Settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		section, key := SplitName(name)
+		value := i.Settings[name]
+
+		values := doc.values(section, key)
+		changed := len(values) == 0
+		for _, current := range values {
+			changed = changed || current != value
+		}
+		if !changed {
+			continue
+		}
+
+		status.AddDifference(name, describe(values), value, "")
+		doc.set(section, key, value, i.Separator)
+	}
+
+	for _, name := range i.Remove {
+		section, key := SplitName(name)
+
+		values := doc.values(section, key)
+		if len(values) == 0 {
+			continue
+		}
+
+		status.AddDifference(name, describe(values), "<absent>", "")
+		doc.remove(section, key)
+	}
+
+	return doc.String()
+}
+
+// describe the values a key has in a file for a difference
+func describe(values []string) string {
+	if len(values) == 0 {
+		return "<absent>"
+	}
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,225 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ini_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/ini"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestINIInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(ini.INI))
+}
+
+func TestSplitName(t *testing.T) {
+	t.Parallel()
+
+	section, key := ini.SplitName("mysqld/max_connections")
+	assert.Equal(t, "mysqld", section)
+	assert.Equal(t, "max_connections", key)
+
+	section, key = ini.SplitName("net.ipv4.ip_forward")
+	assert.Equal(t, "", section)
+	assert.Equal(t, "net.ipv4.ip_forward", key)
+}
+
+func TestINIApply(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-ini")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	apply := func(t *testing.T, name, original string, i *ini.INI) (string, map[string]resource.Diff) {
+		i.Destination = filepath.Join(dir, name)
+		if i.Separator == "" {
+			i.Separator = ini.DefaultSeparator
+		}
+		require.NoError(t, ioutil.WriteFile(i.Destination, []byte(original), 0600))
+
+		status, err := i.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		diffs := status.Diffs()
+
+		applied, err := i.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, status.HasChanges(), applied.HasChanges())
+
+		info, err := os.Stat(i.Destination)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode())
+
+		out, err := ioutil.ReadFile(i.Destination)
+		require.NoError(t, err)
+
+		status, err = i.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+
+		return string(out), diffs
+	}
+
+	t.Run("changes value in place", func(t *testing.T) {
+		out, diffs := apply(
+			t, "in-place",
+			"; comment\n[PHP]\nmemory_limit   =  128M\r\nexpose_php = On\n",
+			&ini.INI{Settings: map[string]string{"PHP/memory_limit": "256M"}},
+		)
+
+		assert.Equal(t, "; comment\n[PHP]\nmemory_limit   =  256M\r\nexpose_php = On\n", out)
+		require.Contains(t, diffs, "PHP/memory_limit")
+		assert.Equal(t, "128M", diffs["PHP/memory_limit"].Original())
+		assert.Equal(t, "256M", diffs["PHP/memory_limit"].Current())
+		assert.Len(t, diffs, 1)
+	})
+
+	t.Run("adds key after last key in section", func(t *testing.T) {
+		out, diffs := apply(
+			t, "add-key",
+			"[client]\nport = 3306\n\n[mysqld]\nskip-name-resolve\n# tuning\n\n[mysqldump]\nquick\n",
+			&ini.INI{Settings: map[string]string{"mysqld/max_connections": "200"}},
+		)
+
+		assert.Equal(t, "[client]\nport = 3306\n\n[mysqld]\nskip-name-resolve\nmax_connections = 200\n# tuning\n\n[mysqldump]\nquick\n", out)
+		assert.Equal(t, "<absent>", diffs["mysqld/max_connections"].Original())
+	})
+
+	t.Run("adds section", func(t *testing.T) {
+		out, _ := apply(
+			t, "add-section",
+			"[a]\nx = 1",
+			&ini.INI{Settings: map[string]string{"b/y": "2"}},
+		)
+
+		assert.Equal(t, "[a]\nx = 1\n\n[b]\ny = 2\n", out)
+	})
+
+	t.Run("keys without a section", func(t *testing.T) {
+		out, _ := apply(
+			t, "sysctl",
+			"# forwarding\nnet.ipv4.ip_forward=0\n",
+			&ini.INI{
+				Settings:  map[string]string{"net.ipv4.ip_forward": "1", "vm.swappiness": "10"},
+				Separator: "=",
+			},
+		)
+
+		assert.Equal(t, "# forwarding\nnet.ipv4.ip_forward=1\nvm.swappiness=10\n", out)
+	})
+
+	t.Run("keys before first section", func(t *testing.T) {
+		out, _ := apply(
+			t, "global",
+			"[a]\nx = 1\n",
+			&ini.INI{Settings: map[string]string{"y": "2"}},
+		)
+
+		assert.Equal(t, "y = 2\n[a]\nx = 1\n", out)
+	})
+
+	t.Run("sets every occurrence", func(t *testing.T) {
+		out, diffs := apply(
+			t, "duplicates",
+			"[a]\nx = 1\nx = 2\n",
+			&ini.INI{Settings: map[string]string{"a/x": "2"}},
+		)
+
+		assert.Equal(t, "[a]\nx = 2\nx = 2\n", out)
+		assert.Equal(t, "1, 2", diffs["a/x"].Original())
+	})
+
+	t.Run("removes", func(t *testing.T) {
+		out, diffs := apply(
+			t, "remove",
+			"x = 0\n[a]\n; x is the thing\nx = 1\ny = 2\n[b]\nx = 3\n",
+			&ini.INI{Remove: []string{"a/x", "a/missing"}},
+		)
+
+		assert.Equal(t, "x = 0\n[a]\n; x is the thing\ny = 2\n[b]\nx = 3\n", out)
+		assert.Equal(t, "1", diffs["a/x"].Original())
+		assert.Equal(t, "<absent>", diffs["a/x"].Current())
+		assert.NotContains(t, diffs, "a/missing")
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		original := "[a]\nx = 1\n"
+		out, diffs := apply(
+			t, "unchanged", original,
+			&ini.INI{Settings: map[string]string{"a/x": "1"}, Remove: []string{"a/y"}},
+		)
+
+		assert.Equal(t, original, out)
+		assert.Empty(t, diffs)
+	})
+}
+
+func TestINIMissingFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-ini")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("without create", func(t *testing.T) {
+		i := &ini.INI{Destination: filepath.Join(dir, "missing"), Settings: map[string]string{"a/x": "1"}, Separator: ini.DefaultSeparator}
+
+		status, err := i.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusMayChange, status.StatusCode())
+
+		_, err = i.Apply(context.Background())
+		assert.Error(t, err)
+	})
+
+	t.Run("with create", func(t *testing.T) {
+		i := &ini.INI{Destination: filepath.Join(dir, "created"), Settings: map[string]string{"a/x": "1"}, Separator: ini.DefaultSeparator, Create: true}
+
+		status, err := i.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+
+		_, err = i.Apply(context.Background())
+		require.NoError(t, err)
+
+		out, err := ioutil.ReadFile(i.Destination)
+		require.NoError(t, err)
+		assert.Equal(t, "[a]\nx = 1\n", string(out))
+	})
+
+	t.Run("only removing", func(t *testing.T) {
+		i := &ini.INI{Destination: filepath.Join(dir, "never"), Remove: []string{"a/x"}, Separator: ini.DefaultSeparator}
+
+		status, err := i.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+
+		_, err = i.Apply(context.Background())
+		require.NoError(t, err)
+
+		_, err = os.Stat(i.Destination)
+		assert.True(t, os.IsNotExist(err))
+	})
+}
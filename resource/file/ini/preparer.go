@@ -0,0 +1,117 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ini
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"golang.org/x/net/context"
+)
+
+// Preparer for INI
+//
+// INI sets and removes individual keys in INI-style files, like `php.ini`,
+// MySQL option files or the files in `/etc/sysctl.d`, leaving comments and
+// other keys alone. Keys are named "section/key", or just "key" for keys
+// before the first section, as in files without sections. Keys that are
+// already in the file keep their place and spacing, and new keys are added
+// after the last key in their section. Plans show a difference for each key
+// that changes.
+type Preparer struct {
+	// Destination is the file the keys are in
+	Destination string `hcl:"destination" required:"true" nonempty:"true"`
+
+	// Settings are the values of keys to set, by "section/key". Keys that are
+	// in a section more than once have every occurrence set.
+	Settings map[string]string `hcl:"settings"`
+
+	// Remove is the keys to remove, as "section/key"
+	Remove []string `hcl:"remove"`
+
+	// Separator joins keys that are added to the file to their values. The
+	// default is " = ".
+	Separator string `hcl:"separator" nonempty:"true"`
+
+	// Create creates the file if it doesn't exist. Otherwise a missing file is
+	// an error when keys are set in it.
+	Create bool `hcl:"create"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	if len(p.Settings) == 0 && len(p.Remove) == 0 {
+		return nil, errors.New("settings or remove must have at least one key")
+	}
+
+	if p.Separator == "" {
+		p.Separator = DefaultSeparator
+	}
+	if !strings.Contains(p.Separator, "=") {
+		return nil, fmt.Errorf("separator %q needs an \"=\"", p.Separator)
+	}
+	if strings.Contains(p.Separator, "\n") {
+		return nil, errors.New("separator must be a single line")
+	}
+
+	for name, value := range p.Settings {
+		if err := validName(name); err != nil {
+			return nil, err
+		}
+		if strings.Contains(value, "\n") {
+			return nil, fmt.Errorf("value of %q must be a single line", name)
+		}
+	}
+
+	for _, name := range p.Remove {
+		if err := validName(name); err != nil {
+			return nil, err
+		}
+		if _, ok := p.Settings[name]; ok {
+			return nil, fmt.Errorf("%q can't be both set and removed", name)
+		}
+	}
+
+	return &INI{
+		Destination: p.Destination,
+		Settings:    p.Settings,
+		Remove:      p.Remove,
+		Separator:   p.Separator,
+		Create:      p.Create,
+	}, nil
+}
+
+// validName checks that a "section/key" name can be found in a file
+func validName(name string) error {
+	section, key := SplitName(name)
+	switch {
+	case strings.TrimSpace(key) != key || key == "":
+		return fmt.Errorf("%q needs a key without surrounding spaces", name)
+	case strings.ContainsAny(key, "=\n"):
+		return fmt.Errorf("key in %q can't contain \"=\" or a newline", name)
+	case strings.TrimSpace(section) != section || strings.ContainsAny(section, "[]\n"):
+		return fmt.Errorf("section in %q can't contain brackets, newlines or surrounding spaces", name)
+	case strings.HasPrefix(key, "#"), strings.HasPrefix(key, ";"), strings.HasPrefix(key, "["):
+		return fmt.Errorf("key in %q can't start with %q", name, key[:1])
+	}
+	return nil
+}
+
+func init() {
+	registry.Register("file.ini", (*Preparer)(nil), (*INI)(nil))
+}
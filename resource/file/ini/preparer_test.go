@@ -0,0 +1,84 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ini_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/ini"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestPreparerInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Resource)(nil), new(ini.Preparer))
+}
+
+func TestPreparerPrepare(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		p := &ini.Preparer{Destination: "/etc/php.ini", Settings: map[string]string{"PHP/memory_limit": "256M"}}
+
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		i, ok := task.(*ini.INI)
+		require.True(t, ok)
+		assert.Equal(t, ini.DefaultSeparator, i.Separator)
+	})
+
+	t.Run("nothing to do", func(t *testing.T) {
+		p := &ini.Preparer{Destination: "/etc/php.ini"}
+
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "settings or remove must have at least one key")
+	})
+
+	t.Run("separator without equals", func(t *testing.T) {
+		p := &ini.Preparer{Destination: "/etc/php.ini", Settings: map[string]string{"a/x": "1"}, Separator: ": "}
+
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, `separator ": " needs an "="`)
+	})
+
+	t.Run("set and removed", func(t *testing.T) {
+		p := &ini.Preparer{Destination: "/etc/php.ini", Settings: map[string]string{"a/x": "1"}, Remove: []string{"a/x"}}
+
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, `"a/x" can't be both set and removed`)
+	})
+
+	t.Run("invalid names", func(t *testing.T) {
+		for _, name := range []string{"a/", "a/x=y", "[a]/x", "a/;x", " a/x"} {
+			p := &ini.Preparer{Destination: "/etc/php.ini", Remove: []string{name}}
+
+			_, err := p.Prepare(context.Background(), fakerenderer.New())
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("multiline value", func(t *testing.T) {
+		p := &ini.Preparer{Destination: "/etc/php.ini", Settings: map[string]string{"a/x": "1\n2"}}
+
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, `value of "a/x" must be a single line`)
+	})
+}
@@ -0,0 +1,183 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// Link makes sure a symbolic or hard link is present on disk
+type Link struct {
+	resource.TaskStatus
+
+	// path of the link
+	Destination string `export:"destination"`
+
+	// path the link points to
+	Target string `export:"target"`
+
+	// if true, the link is a hard link
+	Hard bool `export:"hard"`
+}
+
+// Claims returns the link
+func (l *Link) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimPath(l.Destination)}
+}
+
+// Check if the link exists and points to the target. Symbolic links are
+// compared by the path they contain, and hard links by whether the link and
+// the target are the same file.
+func (l *Link) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+	l.TaskStatus = status
+
+	if l.Hard {
+		target, err := os.Lstat(l.Target)
+		switch {
+		case os.IsNotExist(err):
+			status.RaiseLevel(resource.StatusMayChange)
+			status.AddMessage(fmt.Sprintf("%q does not exist; will re-attempt during apply", l.Target))
+			status.AddDifference(l.Destination, "<absent>", l.describe(), "<absent>")
+			return status, nil
+		case err != nil:
+			return nil, errors.Wrapf(err, "could not stat %q", l.Target)
+		case target.IsDir():
+			status.RaiseLevel(resource.StatusCantChange)
+			status.AddMessage(fmt.Sprintf("%q is a directory, which can't be hard linked", l.Target))
+			return status, nil
+		}
+	}
+
+	dest, err := os.Lstat(l.Destination)
+	switch {
+	case os.IsNotExist(err):
+		status.RaiseLevel(resource.StatusWillChange)
+		status.AddDifference(l.Destination, "<absent>", l.describe(), "<absent>")
+		return status, nil
+	case err != nil:
+		return nil, errors.Wrapf(err, "could not stat %q", l.Destination)
+	case dest.IsDir():
+		status.RaiseLevel(resource.StatusCantChange)
+		status.AddMessage(fmt.Sprintf("%q already exists and is a directory", l.Destination))
+		return status, nil
+	}
+
+	linked, err := l.linked(dest)
+	if err != nil {
+		return nil, err
+	}
+	if linked {
+		status.AddMessage(fmt.Sprintf("%q is linked to %q", l.Destination, l.Target))
+		return status, nil
+	}
+
+	current, err := describeFile(l.Destination, dest)
+	if err != nil {
+		return nil, err
+	}
+
+	status.RaiseLevel(resource.StatusWillChange)
+	status.AddDifference(l.Destination, current, l.describe(), "<absent>")
+	if dest.Mode()&os.ModeSymlink == 0 {
+		// links can be replaced freely, but files have content
+		status.MarkDestructive(fmt.Sprintf("file %s will be replaced by a link", l.Destination))
+	}
+	return status, nil
+}
+
+// Apply creates the link. An existing file or link at the destination is
+// replaced atomically, by creating the new link beside it and renaming it
+// into place.
+func (l *Link) Apply(context.Context) (resource.TaskStatus, error) {
+	tmp := filepath.Join(filepath.Dir(l.Destination), "."+filepath.Base(l.Destination)+".converge-link")
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "could not remove %q", tmp)
+	}
+
+	var err error
+	if l.Hard {
+		err = os.Link(l.Target, tmp)
+	} else {
+		err = os.Symlink(l.Target, tmp)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not link %q to %q", l.Destination, l.Target)
+	}
+
+	if err := os.Rename(tmp, l.Destination); err != nil {
+		os.Remove(tmp)
+		return nil, errors.Wrapf(err, "could not replace %q", l.Destination)
+	}
+
+	status := resource.NewStatus()
+	if l.TaskStatus != nil {
+		// keep the planned differences, so the output shows what was replaced
+		for name, diff := range l.Diffs() {
+			status.Differences[name] = diff
+		}
+	}
+	status.RaiseLevel(resource.StatusWillChange)
+	status.AddMessage(fmt.Sprintf("%q is linked to %q", l.Destination, l.Target))
+
+	return status, nil
+}
+
+// linked is true if the existing destination already is the link
+func (l *Link) linked(dest os.FileInfo) (bool, error) {
+	if !l.Hard {
+		if dest.Mode()&os.ModeSymlink == 0 {
+			return false, nil
+		}
+		target, err := os.Readlink(l.Destination)
+		if err != nil {
+			return false, errors.Wrapf(err, "could not read link %q", l.Destination)
+		}
+		return target == l.Target, nil
+	}
+
+	// hard links share an inode with their target
+	target, err := os.Lstat(l.Target)
+	if err != nil {
+		return false, errors.Wrapf(err, "could not stat %q", l.Target)
+	}
+	return os.SameFile(dest, target), nil
+}
+
+// describe the link for diffs
+func (l *Link) describe() string {
+	if l.Hard {
+		return "hard link to " + l.Target
+	}
+	return "symlink to " + l.Target
+}
+
+// describeFile describes an existing file for diffs
+func describeFile(path string, info os.FileInfo) (string, error) {
+	if info.Mode()&os.ModeSymlink == 0 {
+		return "file", nil
+	}
+	target, err := os.Readlink(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not read link %q", path)
+	}
+	return "symlink to " + target, nil
+}
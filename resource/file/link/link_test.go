@@ -0,0 +1,164 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/link"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestLinkInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(link.Link))
+}
+
+func TestSymlink(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "converge-link")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	target := filepath.Join(tmpDir, "target")
+	require.NoError(t, ioutil.WriteFile(target, []byte("x"), 0644))
+	dest := filepath.Join(tmpDir, "link")
+
+	t.Run("absent", func(t *testing.T) {
+		l := &link.Link{Destination: dest, Target: target}
+		status, err := l.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		if diff := status.Diffs()[dest]; assert.NotNil(t, diff) {
+			assert.Equal(t, "<absent>", diff.Original())
+			assert.Equal(t, "symlink to "+target, diff.Current())
+		}
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		l := &link.Link{Destination: dest, Target: target}
+		_, err := l.Apply(context.Background())
+		require.NoError(t, err)
+
+		linked, err := os.Readlink(dest)
+		require.NoError(t, err)
+		assert.Equal(t, target, linked)
+
+		status, err := l.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("retarget", func(t *testing.T) {
+		other := filepath.Join(tmpDir, "other")
+		l := &link.Link{Destination: dest, Target: other}
+		status, err := l.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		assert.True(t, status.HasChanges())
+		assert.Empty(t, resource.StatusDestructive(status))
+		if diff := status.Diffs()[dest]; assert.NotNil(t, diff) {
+			assert.Equal(t, "symlink to "+target, diff.Original())
+			assert.Equal(t, "symlink to "+other, diff.Current())
+		}
+
+		_, err = l.Apply(context.Background())
+		require.NoError(t, err)
+		linked, err := os.Readlink(dest)
+		require.NoError(t, err)
+		assert.Equal(t, other, linked)
+	})
+
+	t.Run("replaces-file", func(t *testing.T) {
+		file := filepath.Join(tmpDir, "file")
+		require.NoError(t, ioutil.WriteFile(file, []byte("y"), 0644))
+
+		l := &link.Link{Destination: file, Target: target}
+		status, err := l.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, []string{"file " + file + " will be replaced by a link"}, resource.StatusDestructive(status))
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		l := &link.Link{Destination: tmpDir, Target: target}
+		status, err := l.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+	})
+}
+
+func TestHardLink(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "converge-link")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	target := filepath.Join(tmpDir, "target")
+	require.NoError(t, ioutil.WriteFile(target, []byte("x"), 0644))
+	dest := filepath.Join(tmpDir, "link")
+
+	t.Run("missing-target", func(t *testing.T) {
+		l := &link.Link{Destination: dest, Target: filepath.Join(tmpDir, "missing"), Hard: true}
+		status, err := l.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusMayChange, status.StatusCode())
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		// a file with the same content is not the same file
+		require.NoError(t, ioutil.WriteFile(dest, []byte("x"), 0644))
+
+		l := &link.Link{Destination: dest, Target: target, Hard: true}
+		status, err := l.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		if diff := status.Diffs()[dest]; assert.NotNil(t, diff) {
+			assert.Equal(t, "file", diff.Original())
+			assert.Equal(t, "hard link to "+target, diff.Current())
+		}
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		l := &link.Link{Destination: dest, Target: target, Hard: true}
+		_, err := l.Apply(context.Background())
+		require.NoError(t, err)
+
+		destInfo, err := os.Stat(dest)
+		require.NoError(t, err)
+		targetInfo, err := os.Stat(target)
+		require.NoError(t, err)
+		assert.True(t, os.SameFile(destInfo, targetInfo))
+
+		status, err := l.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Equal(t, resource.StatusNoChange, status.StatusCode())
+	})
+
+	t.Run("directory-target", func(t *testing.T) {
+		l := &link.Link{Destination: dest, Target: tmpDir, Hard: true}
+		status, err := l.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+	})
+}
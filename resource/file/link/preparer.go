@@ -0,0 +1,56 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"golang.org/x/net/context"
+)
+
+// Preparer for Link
+//
+// Link makes sure a symbolic link, or a hard link when `hard` is set, is
+// present on disk. A symbolic link is up to date when it contains `target`,
+// and a hard link when it is the same file as `target`, so applying again
+// does nothing. Existing links at `destination` are replaced; replacing a file
+// is a destructive change.
+type Preparer struct {
+	// the location on disk of the link
+	Destination string `hcl:"destination" required:"true" nonempty:"true"`
+
+	// the path the link points to. A relative target of a symbolic link is
+	// relative to the directory of the link, like with `ln -s`, and one of a
+	// hard link is relative to the working directory.
+	Target string `hcl:"target" required:"true" nonempty:"true"`
+
+	// whether to create a hard link instead of a symbolic link. The target of
+	// a hard link must exist when the link is applied, be on the same
+	// filesystem, and not be a directory.
+	Hard bool `hcl:"hard"`
+}
+
+// Prepare the new link
+func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	return &Link{
+		Destination: p.Destination,
+		Target:      p.Target,
+		Hard:        p.Hard,
+	}, nil
+}
+
+func init() {
+	registry.Register("file.link", (*Preparer)(nil), (*Link)(nil))
+}
@@ -17,8 +17,15 @@ package mode
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/asteris-llc/converge/helpers/command"
+	"github.com/asteris-llc/converge/helpers/transform"
 	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/attributes"
+	"github.com/asteris-llc/converge/resource/file/backup"
+	"github.com/asteris-llc/converge/resource/file/filter"
 	"golang.org/x/net/context"
 )
 
@@ -31,10 +38,41 @@ type Mode struct {
 
 	// the mode that the file or directory should be configured with
 	Mode os.FileMode `export:"mode"`
+
+	// if true, and `destination` is a directory, files below it are changed too
+	Recursive bool `export:"recursive"`
+
+	// when recursive, the mode directories should be configured with. If it is
+	// zero, directories are left alone.
+	DirectoryMode os.FileMode `export:"directory_mode"`
+
+	// when recursive, only change files matching one of these globs
+	Include []string `export:"include"`
+
+	// when recursive, skip files and directories matching any of these globs
+	Exclude []string `export:"exclude"`
+
+	// when recursive, report a count of changed files instead of a diff for
+	// each one
+	HideDetails bool
+
+	// whether the file is backed up before its mode is changed
+	Backup bool `export:"backup"`
+
+	// directory backups are made in, if not next to the file
+	BackupDir string `export:"backup_dir"`
+}
+
+// Claims returns the file whose mode is managed
+func (t *Mode) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimPath(t.Destination)}
 }
 
 // Check whether the Destination has the right Mode
 func (t *Mode) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	if t.Recursive {
+		return t.checkTree()
+	}
 	diffs := make(map[string]resource.Diff)
 	stat, err := os.Stat(t.Destination)
 	if os.IsNotExist(err) {
@@ -71,7 +109,27 @@ func (t *Mode) Check(context.Context, resource.Renderer) (resource.TaskStatus, e
 
 // Apply the changes the Mode
 func (t *Mode) Apply(context.Context) (resource.TaskStatus, error) {
-	err := os.Chmod(t.Destination, t.Mode.Perm())
+	if t.Recursive {
+		return t.applyTree()
+	}
+
+	var backedUp string
+	if t.Backup {
+		var err error
+		if backedUp, err = backup.Copy(t.Destination, t.BackupDir, time.Now()); err != nil {
+			return &resource.Status{
+				Level:  resource.StatusFatal,
+				Output: []string{err.Error()},
+			}, err
+		}
+	}
+
+	chmod := func() error { return os.Chmod(t.Destination, t.Mode.Perm()) }
+	err := chmod()
+	if os.IsPermission(err) {
+		// the file may be immutable or append-only
+		err = attributes.Unprotected(command.ExecRunner{}, t.Destination, chmod)
+	}
 
 	if err != nil {
 		return &resource.Status{
@@ -80,7 +138,12 @@ func (t *Mode) Apply(context.Context) (resource.TaskStatus, error) {
 		}, err
 	}
 
-	return t, nil
+	// the final check replaces t.Status, so return a copy of it
+	applied := t.Status
+	if backedUp != "" {
+		applied.AddMessage(fmt.Sprintf("backed up %s to %s", t.Destination, backedUp))
+	}
+	return &applied, nil
 }
 
 // Validate Mode
@@ -91,9 +154,107 @@ func (t *Mode) Validate() error {
 	if !(t.Mode.IsDir() || t.Mode.IsRegular()) {
 		return fmt.Errorf("invalid %q parameter: %q", "mode", t.Mode)
 	}
+	if !(t.DirectoryMode.IsDir() || t.DirectoryMode.IsRegular()) {
+		return fmt.Errorf("invalid %q parameter: %q", "directory_mode", t.DirectoryMode)
+	}
 	return nil
 }
 
+// checkTree checks the mode of every file below Destination
+func (t *Mode) checkTree() (resource.TaskStatus, error) {
+	if _, err := os.Stat(t.Destination); os.IsNotExist(err) {
+		status := &resource.Status{Level: resource.StatusMayChange}
+		status.SetWarning(fmt.Sprintf("%q does not exist", t.Destination))
+		return status, nil
+	}
+
+	diffs, err := t.treeDiffs()
+	if err != nil {
+		return nil, err
+	}
+
+	t.Status = resource.Status{Differences: map[string]resource.Diff{}}
+	t.AddMessage(fmt.Sprintf("%s to change", transform.Count(len(diffs), "file")))
+	t.report(&t.Status, diffs)
+	t.RaiseLevelForDiffs()
+	return t, nil
+}
+
+// applyTree changes the mode of every file below Destination which differs
+func (t *Mode) applyTree() (resource.TaskStatus, error) {
+	diffs, err := t.treeDiffs()
+	if err != nil {
+		return nil, err
+	}
+
+	for path, diff := range diffs {
+		if err := os.Chmod(path, diff.Expected.Perm()); err != nil {
+			return &resource.Status{
+				Level:  resource.StatusFatal,
+				Output: []string{fmt.Sprintf("failed to set mode on %s: %s", path, err)},
+			}, err
+		}
+	}
+
+	status := resource.NewStatus()
+	status.AddMessage(fmt.Sprintf("%s changed", transform.Count(len(diffs), "file")))
+	t.report(status, diffs)
+	status.RaiseLevelForDiffs()
+	return status, nil
+}
+
+// treeDiffs walks Destination for files and directories whose mode differs.
+// Symlinks are skipped, since changing their mode changes their target.
+func (t *Mode) treeDiffs() (map[string]*FileModeDiff, error) {
+	diffs := make(map[string]*FileModeDiff)
+	f := &filter.Filter{Include: t.Include, Exclude: t.Exclude}
+
+	err := filepath.Walk(t.Destination, f.Wrap(t.Destination, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		expected := t.Mode
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			return nil
+		case info.IsDir():
+			if t.DirectoryMode == 0 {
+				return nil
+			}
+			expected = t.DirectoryMode
+		}
+
+		diff := &FileModeDiff{Actual: info.Mode().Perm(), Expected: expected.Perm()}
+		if diff.Changes() {
+			diffs[path] = diff
+		}
+		return nil
+	}))
+
+	return diffs, err
+}
+
+// report adds the differences to a status, or a single summary of them when
+// details are hidden
+func (t *Mode) report(status *resource.Status, diffs map[string]*FileModeDiff) {
+	if !t.HideDetails {
+		for path, diff := range diffs {
+			status.Differences[path] = diff
+		}
+		return
+	}
+
+	if len(diffs) > 0 {
+		status.AddMessage("reporting abridged changes; add \"verbose = true\" to see all changes")
+		summary := fmt.Sprintf("%s on %s", t.Mode, transform.Count(len(diffs), "file"))
+		if t.DirectoryMode != 0 {
+			summary = fmt.Sprintf("%s, directories %s on %s", t.Mode, t.DirectoryMode, transform.Count(len(diffs), "file"))
+		}
+		status.AddDifference(t.Destination, "*", summary, "")
+	}
+}
+
 // FileModeDiff shows a diff of the file modes
 type FileModeDiff struct {
 	Actual   os.FileMode
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/asteris-llc/converge/helpers/fakerenderer"
@@ -65,3 +66,88 @@ func TestApply(t *testing.T) {
 	assert.Contains(t, status.Messages(), fmt.Sprintf("%q's mode is \"-rwxrwxrwx\" expected \"-rwxrwxrwx\"", tmpfile.Name()))
 	assert.False(t, status.HasChanges())
 }
+
+// TestRecursive tests Check() and Apply() for a tree of files
+func TestRecursive(t *testing.T) {
+	t.Parallel()
+
+	root, err := ioutil.TempDir("", "mode_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	for path, perm := range map[string]os.FileMode{
+		"app.conf":       0600,
+		"conf.d/a.conf":  0644,
+		"conf.d/b.conf":  0600,
+		"cache/c.conf":   0600,
+		"conf.d/notes":   0600,
+		"conf.d/old.bak": 0600,
+	} {
+		full := filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0700))
+		require.NoError(t, ioutil.WriteFile(full, nil, perm))
+		require.NoError(t, os.Chmod(full, perm))
+	}
+
+	t.Run("check", func(t *testing.T) {
+		m := &mode.Mode{
+			Destination: root,
+			Mode:        0644,
+			Recursive:   true,
+			Include:     []string{"*.conf"},
+			Exclude:     []string{"cache"},
+		}
+		require.NoError(t, m.Validate())
+
+		status, err := m.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Contains(t, status.Messages(), "2 files to change")
+
+		diffs := status.Diffs()
+		assert.Equal(t, 2, len(diffs))
+		assert.Contains(t, diffs, filepath.Join(root, "app.conf"))
+		assert.Contains(t, diffs, filepath.Join(root, "conf.d", "b.conf"))
+	})
+
+	t.Run("abridged", func(t *testing.T) {
+		m := &mode.Mode{
+			Destination:   root,
+			Mode:          0644,
+			DirectoryMode: 0755,
+			Recursive:     true,
+			Exclude:       []string{"cache"},
+			HideDetails:   true,
+		}
+
+		status, err := m.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Contains(t, status.Messages(), "6 files to change")
+
+		diffs := status.Diffs()
+		require.Equal(t, 1, len(diffs))
+		assert.Equal(t, "-rw-r--r--, directories -rwxr-xr-x on 6 files", diffs[root].Current())
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		m := &mode.Mode{
+			Destination:   root,
+			Mode:          0640,
+			DirectoryMode: 0750,
+			Recursive:     true,
+			Exclude:       []string{"cache"},
+		}
+
+		status, err := m.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Contains(t, status.Messages(), "7 files changed")
+
+		status, err = m.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+
+		info, err := os.Stat(filepath.Join(root, "cache", "c.conf"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+}
@@ -15,16 +15,20 @@
 package mode
 
 import (
+	"errors"
 	"os"
 
 	"github.com/asteris-llc/converge/load/registry"
 	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/filter"
 	"golang.org/x/net/context"
 )
 
 // Preparer for file Mode
 //
-// Mode monitors the mode of a file
+// Mode monitors the mode of a file. If `recursive` is set to true and
+// `destination` is a directory, it also sets the mode of every file below it,
+// and of directories when `directory_mode` is set. Symlinks are ignored.
 type Preparer struct {
 	// Destination specifies which file will be modified by this resource. The
 	// file must exist on the system (for example, having been created with
@@ -33,13 +37,68 @@ type Preparer struct {
 
 	// Mode is the mode of the file, specified in octal.
 	Mode *uint32 `hcl:"mode" base:"8" required:"true"`
+
+	// Recursive sets the mode of files below a directory `destination`
+	Recursive bool `hcl:"recursive"`
+
+	// DirectoryMode is the mode of directories during recursive changes,
+	// specified in octal. Directories are left alone if it is not set.
+	DirectoryMode *uint32 `hcl:"directory_mode" base:"8"`
+
+	// Include limits recursive changes to files and directories matching one
+	// of these globs. A glob without a slash matches names anywhere in the tree,
+	// like "*.conf", and a glob with a slash matches paths relative to
+	// `destination`, like "conf.d/*".
+	Include []string `hcl:"include"`
+
+	// Exclude skips files and directories matching any of these globs during
+	// recursive changes. Nothing below an excluded directory is changed.
+	Exclude []string `hcl:"exclude"`
+
+	// Verbose shows a diff for each file during recursive changes, instead of
+	// a count of the files that change
+	Verbose bool `hcl:"verbose"`
+
+	// Backup copies the file before changing its mode, to
+	// `<destination>.<timestamp>.bak`. The path of the backup is shown in the
+	// apply output. It can't be combined with `recursive`.
+	Backup bool `hcl:"backup"`
+
+	// BackupDir puts backups below this directory instead of next to the file,
+	// at the file's absolute path. Requires `backup`.
+	BackupDir string `hcl:"backup_dir" nonempty:"true"`
 }
 
 // Prepare this resource for use
 func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	if p.Mode == nil {
+		return nil, errors.New("mode is required")
+	}
+	if !p.Recursive && (p.DirectoryMode != nil || len(p.Include) > 0 || len(p.Exclude) > 0) {
+		return nil, errors.New("directory_mode, include, and exclude require recursive")
+	}
+	if p.Backup && p.Recursive {
+		return nil, errors.New("backup is not supported with recursive")
+	}
+	if p.BackupDir != "" && !p.Backup {
+		return nil, errors.New("backup_dir requires backup")
+	}
+	if _, err := filter.New(p.Include, p.Exclude); err != nil {
+		return nil, err
+	}
+
 	modeTask := &Mode{
 		Destination: p.Destination,
 		Mode:        os.FileMode(*p.Mode),
+		Recursive:   p.Recursive,
+		Include:     p.Include,
+		Exclude:     p.Exclude,
+		HideDetails: !p.Verbose,
+		Backup:      p.Backup,
+		BackupDir:   p.BackupDir,
+	}
+	if p.DirectoryMode != nil {
+		modeTask.DirectoryMode = os.FileMode(*p.DirectoryMode)
 	}
 	return modeTask, modeTask.Validate()
 }
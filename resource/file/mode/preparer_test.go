@@ -15,12 +15,14 @@
 package mode_test
 
 import (
+	"os"
 	"testing"
 
 	"github.com/asteris-llc/converge/helpers/fakerenderer"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/file/mode"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/net/context"
 )
 
@@ -40,3 +42,49 @@ func TestValidPreparer(t *testing.T) {
 	_, err := prep.Prepare(context.Background(), &fr)
 	assert.NoError(t, err)
 }
+
+// TestRecursivePreparer tests file mode Prepare() with recursive options
+func TestRecursivePreparer(t *testing.T) {
+	t.Parallel()
+
+	var fileMode, dirMode uint32 = 0644, 0755
+	fr := fakerenderer.FakeRenderer{}
+
+	t.Run("recursive", func(t *testing.T) {
+		prep := mode.Preparer{Destination: "path/to/dir", Mode: &fileMode, DirectoryMode: &dirMode, Recursive: true, Include: []string{"*.conf"}}
+		task, err := prep.Prepare(context.Background(), &fr)
+		require.NoError(t, err)
+
+		m := task.(*mode.Mode)
+		assert.Equal(t, os.FileMode(0755), m.DirectoryMode)
+		assert.Equal(t, []string{"*.conf"}, m.Include)
+		assert.True(t, m.HideDetails)
+	})
+
+	t.Run("not recursive", func(t *testing.T) {
+		prep := mode.Preparer{Destination: "path/to/dir", Mode: &fileMode, Exclude: []string{"cache"}}
+		_, err := prep.Prepare(context.Background(), &fr)
+		assert.EqualError(t, err, "directory_mode, include, and exclude require recursive")
+	})
+
+	t.Run("invalid glob", func(t *testing.T) {
+		prep := mode.Preparer{Destination: "path/to/dir", Mode: &fileMode, Recursive: true, Include: []string{"[a-"}}
+		_, err := prep.Prepare(context.Background(), &fr)
+		assert.EqualError(t, err, `invalid pattern "[a-": syntax error in pattern`)
+	})
+
+	t.Run("backup", func(t *testing.T) {
+		prep := mode.Preparer{Destination: "path/to/dir", Mode: &fileMode, Recursive: true, Backup: true}
+		_, err := prep.Prepare(context.Background(), &fr)
+		assert.EqualError(t, err, "backup is not supported with recursive")
+	})
+}
+
+// TestPreparerWithoutMode tests that a missing mode is an error
+func TestPreparerWithoutMode(t *testing.T) {
+	t.Parallel()
+
+	prep := mode.Preparer{Destination: "path/to/file"}
+	_, err := prep.Prepare(context.Background(), fakerenderer.New())
+	assert.EqualError(t, err, "mode is required")
+}
@@ -287,6 +287,15 @@ func missingMockOS() *MockOS {
 	return failingMockOS(map[string]error{"Stat": os.ErrNotExist})
 }
 
+// unknownOwnerMockOS returns a mock where the user and group don't exist
+func unknownOwnerMockOS(username, groupname string) *MockOS {
+	m := &MockOS{}
+	m.On("Lookup", username).Return((*user.User)(nil), user.UnknownUserError(username))
+	m.On("LookupGroup", groupname).Return((*user.Group)(nil), user.UnknownGroupError(groupname))
+	m.On("Stat", any).Return(fakeStat, nil)
+	return m
+}
+
 func toInt(s string) int {
 	i, _ := strconv.Atoi(s)
 	return i
@@ -21,8 +21,12 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/asteris-llc/converge/helpers/transform"
 	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/backup"
+	"github.com/asteris-llc/converge/resource/file/filter"
 	"golang.org/x/net/context"
 )
 
@@ -61,6 +65,18 @@ type Owner struct {
 	// if true, and `destination` is a directory, apply changes recursively
 	Recursive bool `export:"recursive"`
 
+	// when recursive, only change files matching one of these globs
+	Include []string `export:"include"`
+
+	// when recursive, skip files and directories matching any of these globs
+	Exclude []string `export:"exclude"`
+
+	// whether the file is backed up before its ownership is changed
+	Backup bool `export:"backup"`
+
+	// directory backups are made in, if not next to the file
+	BackupDir string `export:"backup_dir"`
+
 	HideDetails bool
 	executor    OSProxy
 	differences []*OwnershipDiff
@@ -78,6 +94,12 @@ func (o *Owner) SetOSProxy(p OSProxy) *Owner {
 	return o
 }
 
+// Claims returns the file or, when recursive, the directory tree whose
+// ownership is managed
+func (o *Owner) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimPath(o.Destination)}
+}
+
 // Check checks the ownership status of the file
 func (o *Owner) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
 	var err error
@@ -88,6 +110,16 @@ func (o *Owner) Check(context.Context, resource.Renderer) (resource.TaskStatus,
 		return status, nil
 	}
 
+	missing, err := o.resolveIDs()
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		status.AddMessage(fmt.Sprintf("%s: does not exist yet; will re-attempt during apply", strings.Join(missing, ", ")))
+		status.RaiseLevel(resource.StatusMayChange)
+		return status, nil
+	}
+
 	status, err = o.getDiffs(status)
 	if err != nil {
 		return nil, err
@@ -95,21 +127,16 @@ func (o *Owner) Check(context.Context, resource.Renderer) (resource.TaskStatus,
 
 	if o.Recursive {
 		status.AddMessage("Recursive: True")
+		status.AddMessage(fmt.Sprintf("%s to change", transform.Count(len(status.Differences), "file")))
 	}
 
 	status.AddMessage("Checking file ownership at: " + o.Destination)
 
 	if o.HideDetails && o.Recursive && status.HasChanges() {
-		var diffMsg []string
+		count := len(status.Differences)
 		status.AddMessage("reporting abridged changes; add \"verbose = true\" to see all changes")
 		status.Differences = make(map[string]resource.Diff)
-		if o.Username != "" {
-			diffMsg = append(diffMsg, fmt.Sprintf("user: %s (%s)", o.Username, o.UID))
-		}
-		if o.Group != "" {
-			diffMsg = append(diffMsg, fmt.Sprintf("group: %s (%s)", o.Group, o.GID))
-		}
-		status.AddDifference(o.Destination, "*", strings.Join(diffMsg, "; "), "")
+		status.AddDifference(o.Destination, "*", o.summary(count), "")
 	}
 
 	return status, nil
@@ -124,7 +151,8 @@ func (o *Owner) getDiffs(status *resource.Status) (*resource.Status, error) {
 
 	w := &fileWalker{Status: status, NewOwner: newOwner, Executor: o.executor}
 	if o.Recursive {
-		err = o.executor.Walk(o.Destination, w.CheckFile)
+		f := &filter.Filter{Include: o.Include, Exclude: o.Exclude}
+		err = o.executor.Walk(o.Destination, f.Wrap(o.Destination, w.CheckFile))
 	} else {
 		err = w.CheckFile(o.Destination, nil, nil)
 	}
@@ -144,6 +172,14 @@ func (o *Owner) Apply(context.Context) (resource.TaskStatus, error) {
 		return nil, fmt.Errorf("cannot change ownership of non-existent file: %s", o.Destination)
 	}
 
+	missing, err := o.resolveIDs()
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("cannot change ownership of %s: %s does not exist", o.Destination, strings.Join(missing, ", "))
+	}
+
 	if o.differences == nil {
 		status.AddMessage("no previously planned diffs; checking for necessary changes")
 		tmpStatus := resource.NewStatus()
@@ -159,6 +195,15 @@ func (o *Owner) Apply(context.Context) (resource.TaskStatus, error) {
 	if o.Recursive {
 		status.AddMessage("Recursive: True")
 	}
+	if o.Backup && len(o.differences) > 0 {
+		backedUp, err := backup.Copy(o.Destination, o.BackupDir, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if backedUp != "" {
+			status.AddMessage(fmt.Sprintf("backed up %s to %s", o.Destination, backedUp))
+		}
+	}
 	status.AddMessage("Updating permissions on file(s) at: " + o.Destination)
 	for _, diff := range o.differences {
 		if showDetails {
@@ -168,21 +213,63 @@ func (o *Owner) Apply(context.Context) (resource.TaskStatus, error) {
 			return nil, err
 		}
 	}
+	if o.Recursive {
+		status.AddMessage(fmt.Sprintf("%s changed", transform.Count(len(o.differences), "file")))
+	}
 	if !showDetails {
-		var diffMsg []string
-		if o.Username != "" {
-			diffMsg = append(diffMsg, fmt.Sprintf("user: %s (%s)", o.Username, o.UID))
-		}
-		if o.Group != "" {
-			diffMsg = append(diffMsg, fmt.Sprintf("group: %s (%s)", o.Group, o.GID))
-		}
 		status.AddMessage("reporting abridged changes; add \"verbose = true\" to see all changes")
 		status.Differences = make(map[string]resource.Diff)
-		status.AddDifference(o.Destination, "*", strings.Join(diffMsg, "; "), "")
+		status.AddDifference(o.Destination, "*", o.summary(len(o.differences)), "")
 	}
 	return status, nil
 }
 
+// summary describes the new ownership of a number of files, for abridged
+// recursive changes
+func (o *Owner) summary(count int) string {
+	var diffMsg []string
+	if o.Username != "" {
+		diffMsg = append(diffMsg, fmt.Sprintf("user: %s (%s)", o.Username, o.UID))
+	}
+	if o.Group != "" {
+		diffMsg = append(diffMsg, fmt.Sprintf("group: %s (%s)", o.Group, o.GID))
+	}
+	return fmt.Sprintf("%s on %s", strings.Join(diffMsg, "; "), transform.Count(count, "file"))
+}
+
+// resolveIDs looks up the uid and gid of a user or group which was named but
+// did not exist when the task was prepared. It returns the users and groups
+// which still don't exist.
+func (o *Owner) resolveIDs() ([]string, error) {
+	var missing []string
+
+	if o.Username != "" && o.UID == "" {
+		uid, err := uidFromUsername(o.executor, o.Username)
+		switch {
+		case isUnknown(err):
+			missing = append(missing, fmt.Sprintf("user %q", o.Username))
+		case err != nil:
+			return nil, err
+		default:
+			o.UID = uid
+		}
+	}
+
+	if o.Group != "" && o.GID == "" {
+		gid, err := gidFromGroupname(o.executor, o.Group)
+		switch {
+		case isUnknown(err):
+			missing = append(missing, fmt.Sprintf("group %q", o.Group))
+		case err != nil:
+			return nil, err
+		default:
+			o.GID = gid
+		}
+	}
+
+	return missing, nil
+}
+
 func (o *Owner) copyDiffs(status *resource.Status) {
 	for _, diff := range status.Differences {
 		o.differences = append(o.differences, diff.(*OwnershipDiff))
@@ -329,6 +329,34 @@ func TestCheck(t *testing.T) {
 			}
 			assert.Equal(t, len(diffs), len(changingRecords))
 		})
+		t.Run("when-filtered", func(t *testing.T) {
+			t.Parallel()
+			records := []ownershipRecord{
+				makeOwnedFull("root", "user-1", "1", "group-1", "1", true, 0, 0755),
+				makeOwnedFull("root/a.conf", "user-1", "1", "group-1", "1", false, 0, 0644),
+				makeOwnedFull("root/old.conf", "user-1", "1", "group-1", "1", false, 0, 0644),
+				makeOwnedFull("root/b.txt", "user-1", "1", "group-1", "1", false, 0, 0644),
+			}
+			m := newMockOS(records, users, groups, nil, nil)
+			o := (&owner.Owner{
+				Destination: "root",
+				Username:    "user-2",
+				UID:         "2",
+				Recursive:   true,
+				Include:     []string{"*.conf"},
+				Exclude:     []string{"old.conf"},
+				HideDetails: true,
+			}).SetOSProxy(m)
+			status, err := o.Check(context.Background(), fakerenderer.New())
+			require.NoError(t, err)
+			assertIn(t, status.Messages(), "1 file to change")
+			m.AssertNotCalled(t, "GetUID", "root/old.conf")
+			m.AssertNotCalled(t, "GetUID", "root/b.txt")
+
+			diffs := status.Diffs()
+			require.Equal(t, 1, len(diffs))
+			assert.Equal(t, "user: user-2 (2) on 1 file", diffs["root"].Current())
+		})
 	})
 	t.Run("verbose-flag", func(t *testing.T) {
 		t.Parallel()
@@ -442,6 +470,49 @@ func TestCheck(t *testing.T) {
 	})
 }
 
+// TestUnresolvedOwner tests resolving users and groups which did not exist
+// when the task was prepared
+func TestUnresolvedOwner(t *testing.T) {
+	t.Parallel()
+
+	users := []*user.User{fakeUser("1", "1", "user-1"), fakeUser("2", "2", "new-user")}
+	groups := []*user.Group{fakeGroup("1", "group-1"), fakeGroup("2", "new-group")}
+	ownershipRecords := []ownershipRecord{makeOwned("foo", "user-1", "1", "group-1", "1")}
+
+	t.Run("check-when-missing", func(t *testing.T) {
+		t.Parallel()
+
+		m := unknownOwnerMockOS("new-user", "new-group")
+		o := (&owner.Owner{Destination: "foo", Username: "new-user", Group: "new-group"}).SetOSProxy(m)
+		status, err := o.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assertIn(t, status.Messages(), `user "new-user", group "new-group": does not exist yet; will re-attempt during apply`)
+		assert.True(t, status.HasChanges())
+	})
+
+	t.Run("apply-when-missing", func(t *testing.T) {
+		t.Parallel()
+
+		m := unknownOwnerMockOS("new-user", "new-group")
+		o := (&owner.Owner{Destination: "foo", Username: "new-user"}).SetOSProxy(m)
+		_, err := o.Apply(context.Background())
+		assert.EqualError(t, err, `cannot change ownership of foo: user "new-user" does not exist`)
+	})
+
+	t.Run("resolved", func(t *testing.T) {
+		t.Parallel()
+
+		m := newMockOS(ownershipRecords, users, groups, nil, nil)
+		o := (&owner.Owner{Destination: "foo", Username: "new-user", Group: "new-group"}).SetOSProxy(m)
+		status, err := o.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "2", o.UID)
+		assert.Equal(t, "2", o.GID)
+		assertIn(t, status.Messages(), "Updating permissions on file(s) at: foo")
+		m.AssertCalled(t, "Chown", "foo", 2, 2)
+	})
+}
+
 // TestApply tests the behavior of Apply
 func TestApply(t *testing.T) {
 	t.Parallel()
@@ -15,10 +15,12 @@
 package owner
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/asteris-llc/converge/load/registry"
 	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/filter"
 	"golang.org/x/net/context"
 )
 
@@ -27,10 +29,16 @@ import (
 // Owner sets the file and group ownership of a file or directory.  If
 // `recursive` is set to true and `destination` is a directory, then it will
 // also recursively change ownership of all files and subdirectories.  Symlinks
-// are ignored.  If the file or directory does not exist during the plan phase
+// are ignored.  `include` and `exclude` limit a recursive change to part of the
+// tree.  If the file or directory does not exist during the plan phase
 // of application the differences will be calculated during application.
 // Otherwise changes will be limited to the files identified during the plan
 // phase of application.
+//
+// A `user` or `group` which does not exist yet, for example because it is
+// created by a `user.user` or `user.group` resource in the same run, is looked
+// up when ownership is checked or applied. Such resources are applied before
+// the ownership is changed.
 type Preparer struct {
 	// Destination is the location on disk where the content will be rendered.
 	Destination string `hcl:"destination" required:"true" nonempty:"true"`
@@ -39,6 +47,16 @@ type Preparer struct {
 	// recursively.  Symlinks are not followed.
 	Recursive bool `hcl:"recursive"`
 
+	// Include limits recursive changes to files and directories matching one
+	// of these globs. A glob without a slash matches names anywhere in the tree,
+	// like "*.conf", and a glob with a slash matches paths relative to
+	// `destination`, like "conf.d/*".
+	Include []string `hcl:"include"`
+
+	// Exclude skips files and directories matching any of these globs during
+	// recursive changes. Nothing below an excluded directory is changed.
+	Exclude []string `hcl:"exclude"`
+
 	// Username specifies user-owernship by user name
 	Username string `hcl:"user" mutally_exclusive:"user,uid"`
 
@@ -55,6 +73,15 @@ type Preparer struct {
 	// shown for each file to be changed
 	Verbose bool `hcl:"verbose"`
 
+	// Backup copies the file before changing its ownership, to
+	// `<destination>.<timestamp>.bak`. The path of the backup is shown in the
+	// apply output. It can't be combined with `recursive`.
+	Backup bool `hcl:"backup"`
+
+	// BackupDir puts backups below this directory instead of next to the file,
+	// at the file's absolute path. Requires `backup`.
+	BackupDir string `hcl:"backup_dir" nonempty:"true"`
+
 	osProxy OSProxy
 }
 
@@ -63,6 +90,19 @@ func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resou
 	var uidStr string
 	var gidStr string
 
+	if (len(p.Include) > 0 || len(p.Exclude) > 0) && !p.Recursive {
+		return nil, errors.New("include and exclude require recursive")
+	}
+	if p.Backup && p.Recursive {
+		return nil, errors.New("backup is not supported with recursive")
+	}
+	if p.BackupDir != "" && !p.Backup {
+		return nil, errors.New("backup_dir requires backup")
+	}
+	if _, err := filter.New(p.Include, p.Exclude); err != nil {
+		return nil, err
+	}
+
 	if p.osProxy == nil {
 		p.osProxy = &OSExecutor{}
 	}
@@ -75,24 +115,35 @@ func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resou
 		gidStr = strconv.Itoa(*p.GID)
 	}
 
+	// names which can't be found yet are resolved during check and apply
 	user, uid, err := normalizeUser(p.osProxy, p.Username, uidStr)
 	if err != nil {
-		return nil, err
+		if p.Username == "" || !isUnknown(err) {
+			return nil, err
+		}
+		user, uid = p.Username, ""
 	}
 
 	group, gid, err := normalizeGroup(p.osProxy, p.Groupname, gidStr)
 	if err != nil {
-		return nil, err
+		if p.Groupname == "" || !isUnknown(err) {
+			return nil, err
+		}
+		group, gid = p.Groupname, ""
 	}
 
 	return (&Owner{
 		Destination: p.Destination,
 		Recursive:   p.Recursive,
+		Include:     p.Include,
+		Exclude:     p.Exclude,
 		Username:    user,
 		UID:         uid,
 		Group:       group,
 		GID:         gid,
 		HideDetails: !p.Verbose,
+		Backup:      p.Backup,
+		BackupDir:   p.BackupDir,
 	}).SetOSProxy(p.osProxy), nil
 }
 
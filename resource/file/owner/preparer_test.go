@@ -84,4 +84,38 @@ func TestPreparer(t *testing.T) {
 			assert.Equal(t, "1", o.GID)
 		})
 	})
+	t.Run("filters", func(t *testing.T) {
+		m := newMockOS(nil, users, groups, nil, nil)
+		t.Run("when-recursive", func(t *testing.T) {
+			p := (&owner.Preparer{Username: "user-1", Recursive: true, Include: []string{"*.conf"}, Exclude: []string{"cache"}}).SetOSProxy(m)
+			oRes, err := p.Prepare(context.Background(), fakerenderer.New())
+			require.NoError(t, err)
+			o, ok := oRes.(*owner.Owner)
+			require.True(t, ok)
+			assert.Equal(t, []string{"*.conf"}, o.Include)
+			assert.Equal(t, []string{"cache"}, o.Exclude)
+		})
+		t.Run("when-not-recursive", func(t *testing.T) {
+			p := (&owner.Preparer{Username: "user-1", Include: []string{"*.conf"}}).SetOSProxy(m)
+			_, err := p.Prepare(context.Background(), fakerenderer.New())
+			assert.EqualError(t, err, "include and exclude require recursive")
+		})
+		t.Run("when-invalid", func(t *testing.T) {
+			p := (&owner.Preparer{Username: "user-1", Recursive: true, Exclude: []string{"[a-"}}).SetOSProxy(m)
+			_, err := p.Prepare(context.Background(), fakerenderer.New())
+			assert.EqualError(t, err, `invalid pattern "[a-": syntax error in pattern`)
+		})
+	})
+	t.Run("defers-unknown-names", func(t *testing.T) {
+		m := unknownOwnerMockOS("new-user", "new-group")
+		p := (&owner.Preparer{Username: "new-user", Groupname: "new-group"}).SetOSProxy(m)
+		oRes, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		o, ok := oRes.(*owner.Owner)
+		require.True(t, ok)
+		assert.Equal(t, "new-user", o.Username)
+		assert.Equal(t, "", o.UID)
+		assert.Equal(t, "new-group", o.Group)
+		assert.Equal(t, "", o.GID)
+	})
 }
@@ -14,7 +14,10 @@
 
 package owner
 
-import "fmt"
+import (
+	"fmt"
+	"os/user"
+)
 
 func normalizeUser(p OSProxy, username, uid string) (string, string, error) {
 	return normalizeTuple(p, username, uid, usernameFromUID, uidFromUsername)
@@ -69,6 +72,16 @@ func gidFromGroupname(p OSProxy, name string) (string, error) {
 	return u.Gid, nil
 }
 
+// isUnknown is true when a lookup failed because the user or group does not
+// exist
+func isUnknown(err error) bool {
+	switch err.(type) {
+	case user.UnknownUserError, user.UnknownGroupError:
+		return true
+	}
+	return false
+}
+
 func show(i interface{}) string {
 	return fmt.Sprintf("%v", i)
 }
@@ -0,0 +1,70 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selinux
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Context is an SELinux security context, like
+// "system_u:object_r:httpd_sys_content_t:s0"
+type Context struct {
+	User  string
+	Role  string
+	Type  string
+	Range string
+}
+
+// ParseContext parses a context. The range is optional, and may itself contain
+// colons, like "s0-s0:c0.c1023".
+func ParseContext(s string) (Context, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), ":", 4)
+	if len(parts) < 3 {
+		return Context{}, fmt.Errorf("invalid SELinux context %q", s)
+	}
+
+	ctx := Context{User: parts[0], Role: parts[1], Type: parts[2]}
+	if len(parts) == 4 {
+		ctx.Range = parts[3]
+	}
+	return ctx, nil
+}
+
+// String formats the context
+func (c Context) String() string {
+	s := c.User + ":" + c.Role + ":" + c.Type
+	if c.Range != "" {
+		s += ":" + c.Range
+	}
+	return s
+}
+
+// Merge fills the parts of the context which are not set from another
+func (c Context) Merge(other Context) Context {
+	if c.User == "" {
+		c.User = other.User
+	}
+	if c.Role == "" {
+		c.Role = other.Role
+	}
+	if c.Type == "" {
+		c.Type = other.Type
+	}
+	if c.Range == "" {
+		c.Range = other.Range
+	}
+	return c
+}
@@ -0,0 +1,54 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selinux_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/resource/file/selinux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseContext(t *testing.T) {
+	t.Parallel()
+
+	t.Run("with range", func(t *testing.T) {
+		ctx, err := selinux.ParseContext("system_u:object_r:httpd_sys_content_t:s0-s0:c0.c1023\n")
+		require.NoError(t, err)
+		assert.Equal(t, selinux.Context{User: "system_u", Role: "object_r", Type: "httpd_sys_content_t", Range: "s0-s0:c0.c1023"}, ctx)
+		assert.Equal(t, "system_u:object_r:httpd_sys_content_t:s0-s0:c0.c1023", ctx.String())
+	})
+
+	t.Run("without range", func(t *testing.T) {
+		ctx, err := selinux.ParseContext("user_u:object_r:user_home_t")
+		require.NoError(t, err)
+		assert.Equal(t, "user_home_t", ctx.Type)
+		assert.Equal(t, "user_u:object_r:user_home_t", ctx.String())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := selinux.ParseContext("?")
+		assert.EqualError(t, err, `invalid SELinux context "?"`)
+	})
+}
+
+func TestContextMerge(t *testing.T) {
+	t.Parallel()
+
+	current := selinux.Context{User: "unconfined_u", Role: "object_r", Type: "default_t", Range: "s0"}
+	merged := selinux.Context{Type: "httpd_sys_content_t"}.Merge(current)
+	assert.Equal(t, "unconfined_u:object_r:httpd_sys_content_t:s0", merged.String())
+}
@@ -0,0 +1,102 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selinux
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/asteris-llc/converge/helpers/command"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"golang.org/x/net/context"
+)
+
+// Preparer for SELinux
+//
+// SELinux manages the SELinux context of a file or directory, so files can be
+// labeled on enforcing hosts without shelling out. Only the parts of the
+// context which are set are managed. By default the context is set with
+// chcon, which a relabel of the filesystem undoes; set `persistent` to add a
+// local file context rule with `semanage fcontext` and apply it with
+// restorecon instead. The commands used must be installed.
+type Preparer struct {
+	// the file or directory whose context is managed
+	Destination string `hcl:"destination" required:"true" nonempty:"true"`
+
+	// the SELinux user, like "system_u"
+	User string `hcl:"user"`
+
+	// the SELinux role, like "object_r". Roles can't be set persistently.
+	Role string `hcl:"role"`
+
+	// the SELinux type, like "httpd_sys_content_t". Required when persistent.
+	Type string `hcl:"type"`
+
+	// the MLS/MCS range, like "s0"
+	Range string `hcl:"range"`
+
+	// whether to label everything under the destination too
+	Recursive bool `hcl:"recursive"`
+
+	// whether to set the context with a file context rule, which survives a
+	// relabel of the filesystem, instead of with chcon
+	Persistent bool `hcl:"persistent"`
+
+	// the regular expression of the file context rule. Only valid when
+	// persistent. Defaults to the destination, followed by "(/.*)?" when
+	// recursive.
+	Pattern string `hcl:"pattern"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	desired := Context{User: p.User, Role: p.Role, Type: p.Type, Range: p.Range}
+	if desired == (Context{}) {
+		return nil, errors.New("selinux needs a user, role, type, or range")
+	}
+
+	task := New(p.Destination, desired, command.ExecRunner{})
+	task.Recursive = p.Recursive
+
+	if !p.Persistent {
+		if p.Pattern != "" {
+			return nil, errors.New("pattern requires persistent")
+		}
+		return task, nil
+	}
+
+	switch {
+	case p.Type == "":
+		return nil, errors.New("persistent contexts need a type")
+	case p.Role != "":
+		return nil, errors.New("role can't be set persistently")
+	}
+
+	task.Persistent = true
+	task.Pattern = p.Pattern
+	if task.Pattern == "" {
+		task.Pattern = regexp.QuoteMeta(p.Destination)
+		if p.Recursive {
+			task.Pattern += "(/.*)?"
+		}
+	}
+
+	return task, nil
+}
+
+func init() {
+	registry.Register("file.selinux", (*Preparer)(nil), (*SELinux)(nil))
+}
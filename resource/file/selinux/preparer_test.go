@@ -0,0 +1,85 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selinux_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/selinux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestPreparerInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Resource)(nil), new(selinux.Preparer))
+}
+
+func TestPreparerPrepare(t *testing.T) {
+	t.Parallel()
+
+	t.Run("chcon", func(t *testing.T) {
+		p := &selinux.Preparer{Destination: "/srv/www", Type: "httpd_sys_content_t", Recursive: true}
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		s := task.(*selinux.SELinux)
+		assert.False(t, s.Persistent)
+		assert.True(t, s.Recursive)
+		assert.Equal(t, "", s.Pattern)
+	})
+
+	t.Run("default pattern", func(t *testing.T) {
+		p := &selinux.Preparer{Destination: "/srv/example.com", Type: "httpd_sys_content_t", Recursive: true, Persistent: true}
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, `/srv/example\.com(/.*)?`, task.(*selinux.SELinux).Pattern)
+	})
+
+	t.Run("pattern", func(t *testing.T) {
+		p := &selinux.Preparer{Destination: "/srv/www", Type: "httpd_sys_content_t", Persistent: true, Pattern: "/srv/www(/.*)?"}
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, "/srv/www(/.*)?", task.(*selinux.SELinux).Pattern)
+	})
+
+	t.Run("nothing to manage", func(t *testing.T) {
+		p := &selinux.Preparer{Destination: "/srv/www"}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "selinux needs a user, role, type, or range")
+	})
+
+	t.Run("pattern without persistent", func(t *testing.T) {
+		p := &selinux.Preparer{Destination: "/srv/www", Type: "var_t", Pattern: "/srv/www"}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "pattern requires persistent")
+	})
+
+	t.Run("persistent without type", func(t *testing.T) {
+		p := &selinux.Preparer{Destination: "/srv/www", User: "system_u", Persistent: true}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "persistent contexts need a type")
+	})
+
+	t.Run("persistent role", func(t *testing.T) {
+		p := &selinux.Preparer{Destination: "/srv/www", Type: "var_t", Role: "object_r", Persistent: true}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "role can't be set persistently")
+	})
+}
@@ -0,0 +1,335 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selinux
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/command"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// SELinux manages the SELinux context of a file or directory
+type SELinux struct {
+	// the file or directory whose context is managed
+	Destination string `export:"destination"`
+
+	// the SELinux user
+	User string `export:"user"`
+
+	// the SELinux role
+	Role string `export:"role"`
+
+	// the SELinux type
+	Type string `export:"type"`
+
+	// the MLS/MCS range
+	Range string `export:"range"`
+
+	// if true, everything under the destination is labeled too
+	Recursive bool `export:"recursive"`
+
+	// if true, the context is set with a local file context rule and
+	// restorecon, so it survives a relabel
+	Persistent bool `export:"persistent"`
+
+	// the file context rule's regular expression, when persistent
+	Pattern string `export:"pattern"`
+
+	runner command.Runner
+}
+
+// New creates an SELinux task. The parts of the context which are empty are
+// left alone.
+func New(destination string, ctx Context, runner command.Runner) *SELinux {
+	return &SELinux{
+		Destination: destination,
+		User:        ctx.User,
+		Role:        ctx.Role,
+		Type:        ctx.Type,
+		Range:       ctx.Range,
+		runner:      runner,
+	}
+}
+
+// changes are the commands needed to converge the context
+type changes struct {
+	// the semanage fcontext flag to add or modify the rule, if it changes
+	rule string
+
+	// the paths whose context differs
+	relabel []string
+}
+
+// Claims returns the file or directory
+func (s *SELinux) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimPath(s.Destination)}
+}
+
+// Check compares the context of the destination (and everything under it, if
+// recursive) and the file context rule, if persistent, with the desired context
+func (s *SELinux) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	enabled, err := s.enabled()
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		status.AddMessage("SELinux is disabled on this host")
+		status.RaiseLevel(resource.StatusCantChange)
+		return status, nil
+	}
+
+	_, err = os.Lstat(s.Destination)
+	switch {
+	case os.IsNotExist(err):
+		status.AddMessage(fmt.Sprintf("%s: does not exist; will re-attempt during apply", s.Destination))
+		status.RaiseLevel(resource.StatusMayChange)
+		return status, nil
+	case err != nil:
+		return nil, errors.Wrapf(err, "could not stat %q", s.Destination)
+	}
+
+	if _, err := s.diff(status); err != nil {
+		return nil, err
+	}
+
+	status.RaiseLevelForDiffs()
+	return status, nil
+}
+
+// Apply sets the context. When persistent, the file context rule is added or
+// modified and restorecon applies it; otherwise chcon sets the context
+// directly.
+func (s *SELinux) Apply(context.Context) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	c, err := s.diff(status)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Persistent {
+		if c.rule != "" {
+			if err := s.run("semanage", s.semanageArgs(c.rule)...); err != nil {
+				return nil, err
+			}
+		}
+		if c.rule != "" || len(c.relabel) > 0 {
+			if err := s.run("restorecon", s.flags("-F")...); err != nil {
+				return nil, err
+			}
+		}
+	} else if len(c.relabel) > 0 {
+		if err := s.run("chcon", s.chconArgs()...); err != nil {
+			return nil, err
+		}
+	}
+
+	status.RaiseLevelForDiffs()
+	return status, nil
+}
+
+func (s *SELinux) context() Context {
+	return Context{User: s.User, Role: s.Role, Type: s.Type, Range: s.Range}
+}
+
+// matches is true if every part of the desired context which is set is the
+// same in the other
+func (s *SELinux) matches(other Context) bool {
+	return s.context().Merge(other) == other
+}
+
+// diff adds a difference to the status for the rule, if it changes, and for
+// each path whose context changes, and returns the changes to make
+func (s *SELinux) diff(status *resource.Status) (*changes, error) {
+	c := new(changes)
+
+	if s.Persistent {
+		rule, ok, err := s.rule()
+		if err != nil {
+			return nil, err
+		}
+
+		key := "fcontext " + s.Pattern
+		switch {
+		case !ok:
+			status.AddDifference(key, "<absent>", s.ruleString(Context{}), "")
+			c.rule = "-a"
+		case !s.matches(rule):
+			old := rule.String()
+			if rule == (Context{}) {
+				old = "<<None>>"
+			}
+			status.AddDifference(key, old, s.ruleString(rule), "")
+			c.rule = "-m"
+		}
+	}
+
+	labels, err := s.labels()
+	if err != nil {
+		return nil, err
+	}
+	for _, label := range labels {
+		if s.matches(label.context) {
+			continue
+		}
+		status.AddDifference(label.path, label.context.String(), s.context().Merge(label.context).String(), "")
+		c.relabel = append(c.relabel, label.path)
+	}
+
+	return c, nil
+}
+
+// ruleString formats the context a rule will have
+func (s *SELinux) ruleString(existing Context) string {
+	if existing == (Context{}) {
+		existing = Context{User: "system_u", Role: "object_r"}
+	}
+	return s.context().Merge(existing).String()
+}
+
+// enabled runs selinuxenabled, which fails when SELinux is disabled
+func (s *SELinux) enabled() (bool, error) {
+	out, err := s.runner.Run("selinuxenabled")
+	if err == nil {
+		return true, nil
+	}
+	if _, ok := err.(*exec.ExitError); ok {
+		return false, nil
+	}
+	return false, command.Error("selinuxenabled", out, err)
+}
+
+type label struct {
+	path    string
+	context Context
+}
+
+// labels reads the current context of the destination, or of everything under
+// it when recursive. Symlinks are not followed, like chcon -h and restorecon.
+func (s *SELinux) labels() ([]label, error) {
+	if !s.Recursive {
+		out, err := s.runner.Run("stat", "-c", "%C", "--", s.Destination)
+		if err != nil {
+			return nil, command.Error("stat", out, err)
+		}
+		ctx, err := ParseContext(string(out))
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read the context of %q", s.Destination)
+		}
+		return []label{{s.Destination, ctx}}, nil
+	}
+
+	out, err := s.runner.Run("find", s.Destination, "-exec", "stat", "-c", "%C %n", "{}", "+")
+	if err != nil {
+		return nil, command.Error("find", out, err)
+	}
+
+	var labels []label
+	for _, line := range strings.Split(string(out), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("unexpected output from stat: %q", line)
+		}
+		ctx, err := ParseContext(fields[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read the context of %q", fields[1])
+		}
+		labels = append(labels, label{fields[1], ctx})
+	}
+	return labels, nil
+}
+
+// rule finds the local file context rule for the pattern
+func (s *SELinux) rule() (Context, bool, error) {
+	out, err := s.runner.Run("semanage", "fcontext", "-l", "-C")
+	if err != nil {
+		return Context{}, false, command.Error("semanage", out, err)
+	}
+
+	// lines look like
+	// "/srv/www(/.*)?    all files    system_u:object_r:httpd_sys_content_t:s0"
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] != s.Pattern {
+			continue
+		}
+		if strings.Join(fields[1:len(fields)-1], " ") != "all files" {
+			continue
+		}
+
+		ctx, err := ParseContext(fields[len(fields)-1])
+		if err != nil {
+			// "<<None>>" rules keep files from being labeled at all
+			return Context{}, true, nil
+		}
+		return ctx, true, nil
+	}
+	return Context{}, false, nil
+}
+
+func (s *SELinux) semanageArgs(flag string) []string {
+	args := []string{"fcontext", flag, "-t", s.Type}
+	if s.User != "" {
+		args = append(args, "-s", s.User)
+	}
+	if s.Range != "" {
+		args = append(args, "-r", s.Range)
+	}
+	return append(args, s.Pattern)
+}
+
+func (s *SELinux) chconArgs() []string {
+	args := []string{"-h"}
+	if s.User != "" {
+		args = append(args, "-u", s.User)
+	}
+	if s.Role != "" {
+		args = append(args, "-r", s.Role)
+	}
+	if s.Type != "" {
+		args = append(args, "-t", s.Type)
+	}
+	if s.Range != "" {
+		args = append(args, "-l", s.Range)
+	}
+	return s.flags(args...)
+}
+
+// flags adds -R when recursive, and the destination
+func (s *SELinux) flags(args ...string) []string {
+	if s.Recursive {
+		args = append(args, "-R")
+	}
+	return append(args, "--", s.Destination)
+}
+
+func (s *SELinux) run(name string, args ...string) error {
+	out, err := s.runner.Run(name, args...)
+	if err != nil {
+		return command.Error(name, out, err)
+	}
+	return nil
+}
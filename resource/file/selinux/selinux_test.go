@@ -0,0 +1,219 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selinux_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/selinux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// fakeRunner answers queries with fixed output and records the commands which
+// change things
+type fakeRunner struct {
+	disabled bool
+	label    string
+	tree     string
+	rules    string
+	ran      []string
+}
+
+func (f *fakeRunner) Run(name string, args ...string) ([]byte, error) {
+	switch name {
+	case "selinuxenabled":
+		if f.disabled {
+			return nil, exec.Command("false").Run()
+		}
+		return nil, nil
+	case "stat":
+		return []byte(f.label), nil
+	case "find":
+		return []byte(f.tree), nil
+	case "semanage":
+		if args[1] == "-l" {
+			return []byte(f.rules), nil
+		}
+	}
+	f.ran = append(f.ran, strings.Join(append([]string{name}, args...), " "))
+	return nil, nil
+}
+
+const rules = `SELinux fcontext                                   type               Context
+
+/srv/www(/\.*)?                                    all files          system_u:object_r:httpd_sys_content_t:s0
+/srv/app                                           all files          system_u:object_r:var_t:s0
+`
+
+func TestSELinuxCheck(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-selinux")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("no changes", func(t *testing.T) {
+		s := selinux.New(dir, selinux.Context{Type: "var_t"}, &fakeRunner{label: "system_u:object_r:var_t:s0\n"})
+		status, err := s.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		s := selinux.New(dir, selinux.Context{Type: "httpd_sys_content_t"}, &fakeRunner{label: "unconfined_u:object_r:user_home_t:s0\n"})
+		status, err := s.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+
+		diff := status.Diffs()[dir]
+		require.NotNil(t, diff)
+		assert.Equal(t, "unconfined_u:object_r:user_home_t:s0", diff.Original())
+		assert.Equal(t, "unconfined_u:object_r:httpd_sys_content_t:s0", diff.Current())
+	})
+
+	t.Run("recursive", func(t *testing.T) {
+		runner := &fakeRunner{tree: "system_u:object_r:var_t:s0 /srv/app\nsystem_u:object_r:tmp_t:s0 /srv/app/a file\n"}
+		s := selinux.New(dir, selinux.Context{Type: "var_t"}, runner)
+		s.Recursive = true
+
+		status, err := s.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		diffs := status.Diffs()
+		assert.Equal(t, 1, len(diffs))
+		assert.Equal(t, "system_u:object_r:var_t:s0", diffs["/srv/app/a file"].Current())
+	})
+
+	t.Run("missing rule", func(t *testing.T) {
+		s := selinux.New(dir, selinux.Context{Type: "var_t"}, &fakeRunner{label: "system_u:object_r:var_t:s0", rules: rules})
+		s.Persistent = true
+		s.Pattern = "/srv/other"
+
+		status, err := s.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		diff := status.Diffs()["fcontext /srv/other"]
+		require.NotNil(t, diff)
+		assert.Equal(t, "<absent>", diff.Original())
+		assert.Equal(t, "system_u:object_r:var_t", diff.Current())
+	})
+
+	t.Run("changed rule", func(t *testing.T) {
+		s := selinux.New(dir, selinux.Context{Type: "httpd_sys_content_t"}, &fakeRunner{label: "system_u:object_r:httpd_sys_content_t:s0", rules: rules})
+		s.Persistent = true
+		s.Pattern = "/srv/app"
+
+		status, err := s.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		diffs := status.Diffs()
+		assert.Equal(t, 1, len(diffs))
+		assert.Equal(t, "system_u:object_r:var_t:s0", diffs["fcontext /srv/app"].Original())
+		assert.Equal(t, "system_u:object_r:httpd_sys_content_t:s0", diffs["fcontext /srv/app"].Current())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		s := selinux.New(dir, selinux.Context{Type: "var_t"}, &fakeRunner{disabled: true})
+		status, err := s.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		s := selinux.New(filepath.Join(dir, "missing"), selinux.Context{Type: "var_t"}, &fakeRunner{})
+		status, err := s.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusMayChange, status.StatusCode())
+	})
+}
+
+func TestSELinuxApply(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-selinux")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("chcon", func(t *testing.T) {
+		runner := &fakeRunner{label: "system_u:object_r:tmp_t:s0"}
+		s := selinux.New(dir, selinux.Context{Type: "var_t", Range: "s0"}, runner)
+		s.Recursive = true
+		runner.tree = "system_u:object_r:tmp_t:s0 " + dir
+
+		status, err := s.Apply(context.Background())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, []string{"chcon -h -t var_t -l s0 -R -- " + dir}, runner.ran)
+	})
+
+	t.Run("nothing to do", func(t *testing.T) {
+		runner := &fakeRunner{label: "system_u:object_r:var_t:s0"}
+		s := selinux.New(dir, selinux.Context{Type: "var_t"}, runner)
+
+		_, err := s.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, runner.ran)
+	})
+
+	t.Run("add rule", func(t *testing.T) {
+		runner := &fakeRunner{label: "system_u:object_r:tmp_t:s0", rules: rules}
+		s := selinux.New(dir, selinux.Context{User: "system_u", Type: "var_t"}, runner)
+		s.Persistent = true
+		s.Pattern = "/srv/other"
+
+		_, err := s.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Equal(
+			t,
+			[]string{"semanage fcontext -a -t var_t -s system_u /srv/other", "restorecon -F -- " + dir},
+			runner.ran,
+		)
+	})
+
+	t.Run("modify rule", func(t *testing.T) {
+		runner := &fakeRunner{label: "system_u:object_r:httpd_sys_content_t:s0", rules: rules}
+		s := selinux.New(dir, selinux.Context{Type: "httpd_sys_content_t"}, runner)
+		s.Persistent = true
+		s.Pattern = "/srv/app"
+
+		_, err := s.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Equal(
+			t,
+			[]string{"semanage fcontext -m -t httpd_sys_content_t /srv/app", "restorecon -F -- " + dir},
+			runner.ran,
+		)
+	})
+
+	t.Run("relabel only", func(t *testing.T) {
+		runner := &fakeRunner{label: "system_u:object_r:tmp_t:s0", rules: rules}
+		s := selinux.New(dir, selinux.Context{Type: "var_t"}, runner)
+		s.Persistent = true
+		s.Pattern = "/srv/app"
+
+		_, err := s.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"restorecon -F -- " + dir}, runner.ran)
+	})
+}
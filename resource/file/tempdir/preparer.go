@@ -0,0 +1,72 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tempdir
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// Preparer for TempDir
+//
+// TempDir creates a temporary directory which is removed once the run is
+// finished, whether or not it succeeded. The path of the directory is exported
+// as `path`, so other resources can use it with `lookup`.
+type Preparer struct {
+	// Dir is the directory to create the temporary directory in. It defaults
+	// to the system temporary directory.
+	Dir string `hcl:"dir" nonempty:"true"`
+
+	// Prefix is the start of the name of the temporary directory. A random
+	// suffix is added to it.
+	Prefix string `hcl:"prefix" default:"converge-"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	dir := p.Dir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	if !filepath.IsAbs(dir) {
+		return nil, errors.Errorf("\"dir\" must be an absolute path, was %q", dir)
+	}
+
+	if strings.ContainsRune(p.Prefix, os.PathSeparator) {
+		return nil, errors.Errorf("\"prefix\" must not contain a path separator, was %q", p.Prefix)
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, errors.Wrap(err, "could not generate a name for the temporary directory")
+	}
+
+	return &TempDir{
+		Path: filepath.Join(dir, p.Prefix+hex.EncodeToString(suffix)),
+	}, nil
+}
+
+func init() {
+	registry.Register("file.tempdir", (*Preparer)(nil), (*TempDir)(nil))
+}
@@ -0,0 +1,87 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tempdir
+
+import (
+	"os"
+
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// TempDir is a temporary directory which is removed at the end of the run
+type TempDir struct {
+	// the path of the temporary directory
+	Path string `export:"path"`
+
+	created bool
+}
+
+// Claims returns the temporary directory
+func (t *TempDir) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimPath(t.Path)}
+}
+
+// Check if the temporary directory needs to be created
+func (t *TempDir) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	stat, err := os.Stat(t.Path)
+	switch {
+	case os.IsNotExist(err):
+		status.AddDifference("path", "<absent>", t.Path, "")
+		status.RaiseLevelForDiffs()
+	case err != nil:
+		status.RaiseLevel(resource.StatusFatal)
+		return status, errors.Wrapf(err, "could not stat %s", t.Path)
+	case !stat.IsDir():
+		status.RaiseLevel(resource.StatusCantChange)
+		return status, errors.Errorf("%s exists and is not a directory", t.Path)
+	}
+
+	return status, nil
+}
+
+// Apply creates the temporary directory
+func (t *TempDir) Apply(context.Context) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	if err := os.Mkdir(t.Path, 0700); err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, errors.Wrapf(err, "could not create %s", t.Path)
+	}
+	t.created = true
+
+	status.AddMessage("created " + t.Path + "; it will be removed at the end of the run")
+	status.AddDifference("path", "<absent>", t.Path, "")
+
+	return status, nil
+}
+
+// Cleanup removes the temporary directory and everything in it, if it was
+// created by this task
+func (t *TempDir) Cleanup(context.Context) error {
+	if !t.created {
+		return nil
+	}
+
+	if err := os.RemoveAll(t.Path); err != nil {
+		return errors.Wrapf(err, "could not remove %s", t.Path)
+	}
+	t.created = false
+
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tempdir_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/tempdir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// TestTempDirInterface tests that TempDir is properly implemented
+func TestTempDirInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(tempdir.TempDir))
+	assert.Implements(t, (*resource.Cleaner)(nil), new(tempdir.TempDir))
+}
+
+// TestPreparer tests Prepare
+func TestPreparer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		task, err := (&tempdir.Preparer{Prefix: "converge-"}).Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		path := task.(*tempdir.TempDir).Path
+		assert.Equal(t, os.TempDir(), filepath.Dir(path))
+		assert.True(t, strings.HasPrefix(filepath.Base(path), "converge-"))
+	})
+
+	t.Run("unique", func(t *testing.T) {
+		prep := &tempdir.Preparer{Dir: "/var/tmp", Prefix: "build-"}
+
+		fst, err := prep.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		snd, err := prep.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		assert.NotEqual(t, fst.(*tempdir.TempDir).Path, snd.(*tempdir.TempDir).Path)
+	})
+
+	t.Run("relative dir", func(t *testing.T) {
+		_, err := (&tempdir.Preparer{Dir: "tmp"}).Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "\"dir\" must be an absolute path, was \"tmp\"")
+	})
+
+	t.Run("prefix with separator", func(t *testing.T) {
+		_, err := (&tempdir.Preparer{Prefix: "a/b"}).Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "\"prefix\" must not contain a path separator, was \"a/b\"")
+	})
+}
+
+// TestTempDir tests the lifecycle of a temporary directory
+func TestTempDir(t *testing.T) {
+	t.Parallel()
+
+	parent, err := ioutil.TempDir("", "converge-tempdir")
+	require.NoError(t, err)
+	defer os.RemoveAll(parent)
+
+	task, err := (&tempdir.Preparer{Dir: parent, Prefix: "test-"}).Prepare(context.Background(), fakerenderer.New())
+	require.NoError(t, err)
+	dir := task.(*tempdir.TempDir)
+
+	status, err := dir.Check(context.Background(), fakerenderer.New())
+	require.NoError(t, err)
+	assert.True(t, status.HasChanges())
+
+	_, err = dir.Apply(context.Background())
+	require.NoError(t, err)
+
+	stat, err := os.Stat(dir.Path)
+	require.NoError(t, err)
+	assert.True(t, stat.IsDir())
+
+	status, err = dir.Check(context.Background(), fakerenderer.New())
+	require.NoError(t, err)
+	assert.False(t, status.HasChanges())
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir.Path, "file"), []byte("x"), 0600))
+
+	require.NoError(t, dir.Cleanup(context.Background()))
+	_, err = os.Stat(dir.Path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+// TestTempDirCleanupNotCreated tests that cleanup leaves alone directories the
+// task did not create
+func TestTempDirCleanupNotCreated(t *testing.T) {
+	t.Parallel()
+
+	existing, err := ioutil.TempDir("", "converge-tempdir")
+	require.NoError(t, err)
+	defer os.RemoveAll(existing)
+
+	dir := &tempdir.TempDir{Path: existing}
+	require.NoError(t, dir.Cleanup(context.Background()))
+
+	_, err = os.Stat(existing)
+	assert.NoError(t, err)
+}
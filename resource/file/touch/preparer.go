@@ -0,0 +1,56 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package touch
+
+import (
+	"time"
+
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"golang.org/x/net/context"
+)
+
+// Preparer for Touch
+//
+// Touch makes sure a file exists, creating it empty if it doesn't, and
+// optionally pins its modification and access times. Without pinned times an
+// existing file is left alone, so it works as a sentinel; pinning the times
+// to a value that changes, like a release date, is a way to bust caches
+// keyed on them.
+type Preparer struct {
+	// the location on disk of the file
+	Destination string `hcl:"destination" required:"true" nonempty:"true"`
+
+	// the modification time the file should have. If unset, a created file
+	// gets the current time and an existing file keeps its own.
+	Mtime time.Time `hcl:"mtime"`
+
+	// the access time the file should have. If unset, a created file gets the
+	// current time and an existing file keeps its own.
+	Atime time.Time `hcl:"atime"`
+}
+
+// Prepare the new touch
+func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	return &Touch{
+		Destination: p.Destination,
+		Mtime:       p.Mtime,
+		Atime:       p.Atime,
+	}, nil
+}
+
+func init() {
+	registry.Register("file.touch", (*Preparer)(nil), (*Touch)(nil))
+}
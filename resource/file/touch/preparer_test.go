@@ -0,0 +1,47 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package touch_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/touch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestPreparerInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Resource)(nil), new(touch.Preparer))
+}
+
+func TestPreparerPrepare(t *testing.T) {
+	t.Parallel()
+
+	mtime := time.Date(2016, 10, 1, 12, 0, 0, 0, time.UTC)
+	p := &touch.Preparer{Destination: "/var/run/app.ready", Mtime: mtime}
+	task, err := p.Prepare(context.Background(), fakerenderer.New())
+	require.NoError(t, err)
+
+	tch := task.(*touch.Touch)
+	assert.Equal(t, "/var/run/app.ready", tch.Destination)
+	assert.Equal(t, mtime, tch.Mtime)
+	assert.True(t, tch.Atime.IsZero())
+}
@@ -0,0 +1,130 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package touch
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// Touch makes sure a file exists and has the pinned timestamps
+type Touch struct {
+	resource.TaskStatus
+
+	// path of the file
+	Destination string `export:"destination"`
+
+	// the pinned modification time, zero if not pinned
+	Mtime time.Time `export:"mtime"`
+
+	// the pinned access time, zero if not pinned
+	Atime time.Time `export:"atime"`
+}
+
+// Claims returns the file
+func (t *Touch) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimPath(t.Destination)}
+}
+
+// Check if the file exists and has the pinned timestamps
+func (t *Touch) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+	t.TaskStatus = status
+
+	info, err := os.Stat(t.Destination)
+	switch {
+	case os.IsNotExist(err):
+		status.RaiseLevel(resource.StatusWillChange)
+		status.AddDifference(t.Destination, "<absent>", "<present>", "<absent>")
+		if !t.Mtime.IsZero() {
+			status.AddDifference("mtime", "<absent>", formatTime(t.Mtime), "<absent>")
+		}
+		if !t.Atime.IsZero() {
+			status.AddDifference("atime", "<absent>", formatTime(t.Atime), "<absent>")
+		}
+		return status, nil
+	case err != nil:
+		return nil, errors.Wrapf(err, "could not stat %q", t.Destination)
+	case info.IsDir():
+		status.RaiseLevel(resource.StatusCantChange)
+		status.AddMessage(fmt.Sprintf("%q already exists and is a directory", t.Destination))
+		return status, nil
+	}
+
+	if !t.Mtime.IsZero() && !info.ModTime().Equal(t.Mtime) {
+		status.RaiseLevel(resource.StatusWillChange)
+		status.AddDifference("mtime", formatTime(info.ModTime().In(t.Mtime.Location())), formatTime(t.Mtime), "")
+	}
+	if atime := accessTime(info); !t.Atime.IsZero() && !atime.Equal(t.Atime) {
+		status.RaiseLevel(resource.StatusWillChange)
+		status.AddDifference("atime", formatTime(atime.In(t.Atime.Location())), formatTime(t.Atime), "")
+	}
+
+	if !status.HasChanges() {
+		status.AddMessage(fmt.Sprintf("%q exists", t.Destination))
+	}
+	return status, nil
+}
+
+// Apply creates the file if it is missing and sets the pinned timestamps
+func (t *Touch) Apply(context.Context) (resource.TaskStatus, error) {
+	info, err := os.Stat(t.Destination)
+	if os.IsNotExist(err) {
+		// like touch, new files are empty and get the umask applied
+		f, createErr := os.OpenFile(t.Destination, os.O_WRONLY|os.O_CREATE, 0666)
+		if createErr != nil {
+			return nil, errors.Wrapf(createErr, "could not create %q", t.Destination)
+		}
+		f.Close()
+		info, err = os.Stat(t.Destination)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not stat %q", t.Destination)
+	}
+
+	if !t.Mtime.IsZero() || !t.Atime.IsZero() {
+		mtime, atime := info.ModTime(), accessTime(info)
+		if !t.Mtime.IsZero() {
+			mtime = t.Mtime
+		}
+		if !t.Atime.IsZero() {
+			atime = t.Atime
+		}
+		if err := os.Chtimes(t.Destination, atime, mtime); err != nil {
+			return nil, errors.Wrapf(err, "could not set the timestamps of %q", t.Destination)
+		}
+	}
+
+	status := resource.NewStatus()
+	if t.TaskStatus != nil {
+		// keep the planned differences, so the output shows what was changed
+		for name, diff := range t.Diffs() {
+			status.Differences[name] = diff
+		}
+	}
+	status.RaiseLevel(resource.StatusWillChange)
+	status.AddMessage(fmt.Sprintf("%q exists", t.Destination))
+
+	return status, nil
+}
+
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
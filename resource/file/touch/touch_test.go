@@ -0,0 +1,163 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package touch_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/touch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestTouchInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(touch.Touch))
+}
+
+func TestTouch(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "converge-touch")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	dest := filepath.Join(tmpDir, "ready")
+
+	t.Run("absent", func(t *testing.T) {
+		tch := &touch.Touch{Destination: dest}
+		status, err := tch.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		if diff := status.Diffs()[dest]; assert.NotNil(t, diff) {
+			assert.Equal(t, "<absent>", diff.Original())
+			assert.Equal(t, "<present>", diff.Current())
+		}
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		tch := &touch.Touch{Destination: dest}
+		_, err := tch.Apply(context.Background())
+		require.NoError(t, err)
+
+		info, err := os.Stat(dest)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), info.Size())
+
+		status, err := tch.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("existing is left alone", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(dest, []byte("keep"), 0600))
+		old := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+		require.NoError(t, os.Chtimes(dest, old, old))
+
+		tch := &touch.Touch{Destination: dest}
+		status, err := tch.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+
+		_, err = tch.Apply(context.Background())
+		require.NoError(t, err)
+
+		content, err := ioutil.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, "keep", string(content))
+
+		info, err := os.Stat(dest)
+		require.NoError(t, err)
+		assert.True(t, info.ModTime().Equal(old))
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		tch := &touch.Touch{Destination: tmpDir}
+		status, err := tch.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+	})
+}
+
+func TestTouchTimestamps(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "converge-touch")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	dest := filepath.Join(tmpDir, "stamp")
+	mtime := time.Date(2016, 10, 1, 12, 0, 0, 0, time.UTC)
+	atime := time.Date(2016, 10, 2, 12, 0, 0, 0, time.UTC)
+
+	t.Run("absent", func(t *testing.T) {
+		tch := &touch.Touch{Destination: dest, Mtime: mtime}
+		status, err := tch.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		if diff := status.Diffs()["mtime"]; assert.NotNil(t, diff) {
+			assert.Equal(t, "<absent>", diff.Original())
+			assert.Equal(t, "2016-10-01T12:00:00Z", diff.Current())
+		}
+		assert.Nil(t, status.Diffs()["atime"])
+	})
+
+	t.Run("pinned", func(t *testing.T) {
+		tch := &touch.Touch{Destination: dest, Mtime: mtime, Atime: atime}
+		_, err := tch.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		_, err = tch.Apply(context.Background())
+		require.NoError(t, err)
+
+		info, err := os.Stat(dest)
+		require.NoError(t, err)
+		assert.True(t, info.ModTime().Equal(mtime))
+
+		status, err := tch.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("drifted", func(t *testing.T) {
+		later := mtime.Add(time.Hour)
+		require.NoError(t, os.Chtimes(dest, atime, later))
+
+		tch := &touch.Touch{Destination: dest, Mtime: mtime}
+		status, err := tch.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		if diff := status.Diffs()["mtime"]; assert.NotNil(t, diff) {
+			assert.Equal(t, "2016-10-01T13:00:00Z", diff.Original())
+			assert.Equal(t, "2016-10-01T12:00:00Z", diff.Current())
+		}
+
+		_, err = tch.Apply(context.Background())
+		require.NoError(t, err)
+
+		// the access time isn't pinned, so it is kept
+		info, err := os.Stat(dest)
+		require.NoError(t, err)
+		assert.True(t, info.ModTime().Equal(mtime))
+		status, err = (&touch.Touch{Destination: dest, Atime: atime}).Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+}
@@ -0,0 +1,91 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/keystore"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/fetch"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// Preparer for file verify
+//
+// Verify checks that a file matches an expected hash, is signed by a trusted
+// key, or both. When the file does not match, the run fails and anything
+// depending on the verify resource is not applied. Place it between the
+// resource that downloads a file and the resources that use it.
+//
+// Signatures are checked against the keys trusted with `converge key`.
+type Preparer struct {
+	// Destination is the location on disk of the file to verify
+	Destination string `hcl:"destination" required:"true" nonempty:"true"`
+
+	// HashType is the hash function used to generate the checksum hash
+	// Valid types are md5, sha1, sha256, and sha512
+	HashType *string `hcl:"hash_type"`
+
+	// Hash is the expected checksum hash
+	Hash *string `hcl:"hash" nonempty:"true"`
+
+	// Signature is the location on disk of an armored, detached PGP signature
+	// of the file
+	Signature string `hcl:"signature" nonempty:"true"`
+}
+
+// Prepare a new verify task
+func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	if p.HashType != nil && p.Hash == nil {
+		return nil, errors.New("\"hash\" required with use of \"hash_type\"")
+	} else if p.HashType == nil && p.Hash != nil {
+		return nil, errors.New("\"hash_type\" required with use of \"hash\"")
+	}
+
+	if p.Hash == nil && p.Signature == "" {
+		return nil, errors.New("at least one of \"hash\" or \"signature\" is required")
+	}
+
+	verify := &Verify{
+		Destination: p.Destination,
+		Signature:   p.Signature,
+		keystore:    keystore.Default(),
+	}
+
+	if p.Hash != nil {
+		hsh, err := newHash(*p.HashType)
+		if err != nil {
+			return nil, fmt.Errorf("\"hash_type\" must be one of \"%s,%s,%s,%s\"", fetch.HashMD5, fetch.HashSHA1, fetch.HashSHA256, fetch.HashSHA512)
+		}
+
+		verify.HashType = *p.HashType
+		verify.Hash = strings.ToLower(strings.TrimSpace(*p.Hash))
+
+		if len(verify.Hash) != hex.EncodedLen(hsh.Size()) {
+			return nil, fmt.Errorf("\"hash\" is invalid length for %s", verify.HashType)
+		}
+	}
+
+	return verify, nil
+}
+
+func init() {
+	registry.Register("file.verify", (*Preparer)(nil), (*Verify)(nil))
+}
@@ -0,0 +1,102 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/verify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// TestPreparerInterface tests that the Preparer interface is properly implemeted
+func TestPreparerInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Resource)(nil), new(verify.Preparer))
+}
+
+// TestPreparer tests the valid and invalid cases of Prepare
+func TestPreparer(t *testing.T) {
+	t.Parallel()
+
+	var (
+		fr       = fakerenderer.FakeRenderer{}
+		hashType = "sha256"
+		badType  = "crc32"
+		hash     = hex.EncodeToString(sha256.New().Sum(nil))
+		short    = "abc"
+	)
+
+	t.Run("valid", func(t *testing.T) {
+		t.Run("hash", func(t *testing.T) {
+			prep := verify.Preparer{Destination: "/tmp/file", HashType: &hashType, Hash: &hash}
+
+			task, err := prep.Prepare(context.Background(), &fr)
+			require.NoError(t, err)
+			assert.Equal(t, hash, task.(*verify.Verify).Hash)
+		})
+
+		t.Run("signature", func(t *testing.T) {
+			prep := verify.Preparer{Destination: "/tmp/file", Signature: "/tmp/file.asc"}
+
+			_, err := prep.Prepare(context.Background(), &fr)
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Run("nothing to verify", func(t *testing.T) {
+			prep := verify.Preparer{Destination: "/tmp/file"}
+
+			_, err := prep.Prepare(context.Background(), &fr)
+			assert.EqualError(t, err, "at least one of \"hash\" or \"signature\" is required")
+		})
+
+		t.Run("hash without type", func(t *testing.T) {
+			prep := verify.Preparer{Destination: "/tmp/file", Hash: &hash}
+
+			_, err := prep.Prepare(context.Background(), &fr)
+			assert.EqualError(t, err, "\"hash_type\" required with use of \"hash\"")
+		})
+
+		t.Run("type without hash", func(t *testing.T) {
+			prep := verify.Preparer{Destination: "/tmp/file", HashType: &hashType}
+
+			_, err := prep.Prepare(context.Background(), &fr)
+			assert.EqualError(t, err, "\"hash\" required with use of \"hash_type\"")
+		})
+
+		t.Run("unknown hash type", func(t *testing.T) {
+			prep := verify.Preparer{Destination: "/tmp/file", HashType: &badType, Hash: &hash}
+
+			_, err := prep.Prepare(context.Background(), &fr)
+			assert.EqualError(t, err, "\"hash_type\" must be one of \"md5,sha1,sha256,sha512\"")
+		})
+
+		t.Run("hash length", func(t *testing.T) {
+			prep := verify.Preparer{Destination: "/tmp/file", HashType: &hashType, Hash: &short}
+
+			_, err := prep.Prepare(context.Background(), &fr)
+			assert.EqualError(t, err, "\"hash\" is invalid length for sha256")
+		})
+	})
+}
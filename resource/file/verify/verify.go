@@ -0,0 +1,179 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/asteris-llc/converge/keystore"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/fetch"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// Verify checks the hash or signature of a file
+type Verify struct {
+	// the file to verify
+	Destination string `export:"destination"`
+
+	// hash function used to generate the checksum hash
+	HashType string `export:"hash_type"`
+
+	// the expected checksum hash
+	Hash string `export:"hash"`
+
+	// the location of the detached signature of the file
+	Signature string `export:"signature"`
+
+	keystore *keystore.Keystore
+}
+
+// Claims returns the destination file
+func (v *Verify) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimPath(v.Destination)}
+}
+
+// Check verifies the file. A file that is missing or does not match may still
+// be replaced by the resources this one depends on, so failures are reported
+// as changes and only become errors during apply.
+func (v *Verify) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	if _, err := os.Stat(v.Destination); os.IsNotExist(err) {
+		status.AddMessage(fmt.Sprintf("%s: does not exist; will verify during apply", v.Destination))
+		status.RaiseLevel(resource.StatusMayChange)
+		return status, nil
+	}
+
+	if err := v.verify(status); err != nil {
+		status.AddMessage(fmt.Sprintf("%s: %s; apply will fail unless the file is replaced first", v.Destination, err))
+		status.RaiseLevel(resource.StatusWillChange)
+	}
+
+	return status, nil
+}
+
+// Apply verifies the file again, and fails if it does not match
+func (v *Verify) Apply(context.Context) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	if _, err := os.Stat(v.Destination); os.IsNotExist(err) {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, fmt.Errorf("cannot verify non-existent file: %s", v.Destination)
+	}
+
+	if err := v.verify(status); err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, errors.Wrapf(err, "verification of %s failed", v.Destination)
+	}
+
+	return status, nil
+}
+
+// verify checks the hash and signature of the file, adding a difference for
+// each one that doesn't match
+func (v *Verify) verify(status *resource.Status) error {
+	if v.Hash != "" {
+		actual, err := v.checksum()
+		if err != nil {
+			return err
+		}
+
+		if actual != v.Hash {
+			status.AddDifference(v.HashType, actual, v.Hash, "")
+			return errors.New("checksum mismatch")
+		}
+		status.AddMessage(v.HashType + " checksum matches")
+	}
+
+	if v.Signature != "" {
+		if err := v.checkSignature(); err != nil {
+			status.AddDifference("signature", "untrusted", "trusted", "")
+			return errors.Wrap(err, "signature is not trusted")
+		}
+		status.AddMessage("signed by a trusted key")
+	}
+
+	return nil
+}
+
+// checksum returns the hex encoded hash of the file
+func (v *Verify) checksum() (string, error) {
+	hsh, err := newHash(v.HashType)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(v.Destination)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to open file for checksum")
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(hsh, file); err != nil {
+		return "", errors.Wrap(err, "failed to hash")
+	}
+
+	return hex.EncodeToString(hsh.Sum(nil)), nil
+}
+
+// checkSignature checks the detached signature of the file against the
+// trusted keys
+func (v *Verify) checkSignature() error {
+	file, err := os.Open(v.Destination)
+	if err != nil {
+		return errors.Wrap(err, "failed to open file")
+	}
+	defer file.Close()
+
+	signature, err := os.Open(v.Signature)
+	if err != nil {
+		return errors.Wrap(err, "failed to open signature")
+	}
+	defer signature.Close()
+
+	return v.keystore.CheckSignature(file, signature)
+}
+
+// SetKeystore sets the keystore holding the trusted keys
+func (v *Verify) SetKeystore(ks *keystore.Keystore) *Verify {
+	v.keystore = ks
+	return v
+}
+
+// newHash returns a new hash for the hash type
+func newHash(hashType string) (hash.Hash, error) {
+	switch hashType {
+	case string(fetch.HashMD5):
+		return md5.New(), nil
+	case string(fetch.HashSHA1):
+		return sha1.New(), nil
+	case string(fetch.HashSHA256):
+		return sha256.New(), nil
+	case string(fetch.HashSHA512):
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported hash type %q", hashType)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/keystore"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/verify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/net/context"
+)
+
+// TestVerifyInterface tests that Verify is properly implemented
+func TestVerifyInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(verify.Verify))
+}
+
+// TestVerifyHash tests verifying files by hash
+func TestVerifyHash(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-verify")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	content := []byte("verified content")
+	path := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(path, content, 0600))
+
+	sum := sha256.Sum256(content)
+	good := hex.EncodeToString(sum[:])
+	bad := hex.EncodeToString(make([]byte, sha256.Size))
+
+	t.Run("matches", func(t *testing.T) {
+		v := &verify.Verify{Destination: path, HashType: "sha256", Hash: good}
+
+		status, err := v.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Contains(t, status.Messages(), "sha256 checksum matches")
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		v := &verify.Verify{Destination: path, HashType: "sha256", Hash: bad}
+
+		status, err := v.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, good, status.Diffs()["sha256"].Original())
+
+		status, err = v.Apply(context.Background())
+		assert.EqualError(t, err, "verification of "+path+" failed: checksum mismatch")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		v := &verify.Verify{Destination: filepath.Join(dir, "missing"), HashType: "sha256", Hash: good}
+
+		status, err := v.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusMayChange, status.StatusCode())
+
+		_, err = v.Apply(context.Background())
+		assert.Error(t, err)
+	})
+}
+
+// TestVerifySignature tests verifying files by signature
+func TestVerifySignature(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-verify")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ks := keystore.New(filepath.Join(dir, "local"), filepath.Join(dir, "user"), filepath.Join(dir, "system"))
+
+	trusted, err := openpgp.NewEntity("trusted", "", "trusted@example.com", nil)
+	require.NoError(t, err)
+	untrusted, err := openpgp.NewEntity("untrusted", "", "untrusted@example.com", nil)
+	require.NoError(t, err)
+
+	// signs the identities and subkeys so the public key can be serialized
+	require.NoError(t, trusted.SerializePrivate(ioutil.Discard, nil))
+
+	pubkey := new(bytes.Buffer)
+	w, err := armor.Encode(pubkey, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, trusted.Serialize(w))
+	require.NoError(t, w.Close())
+	_, err = ks.StoreTrustedKey(pubkey.Bytes())
+	require.NoError(t, err)
+
+	content := []byte("signed content")
+	path := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(path, content, 0600))
+
+	sign := func(signer *openpgp.Entity, name string) string {
+		sig := new(bytes.Buffer)
+		require.NoError(t, openpgp.ArmoredDetachSign(sig, signer, bytes.NewReader(content), nil))
+		sigPath := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(sigPath, sig.Bytes(), 0600))
+		return sigPath
+	}
+
+	t.Run("trusted", func(t *testing.T) {
+		v := (&verify.Verify{Destination: path, Signature: sign(trusted, "trusted.asc")}).SetKeystore(ks)
+
+		status, err := v.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Contains(t, status.Messages(), "signed by a trusted key")
+	})
+
+	t.Run("untrusted", func(t *testing.T) {
+		v := (&verify.Verify{Destination: path, Signature: sign(untrusted, "untrusted.asc")}).SetKeystore(ks)
+
+		status, err := v.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+
+		_, err = v.Apply(context.Background())
+		assert.Error(t, err)
+	})
+}
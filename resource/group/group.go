@@ -17,6 +17,8 @@ package group
 import (
 	"fmt"
 	"os/user"
+	"sort"
+	"strings"
 
 	"github.com/asteris-llc/converge/resource"
 	"github.com/pkg/errors"
@@ -48,6 +50,21 @@ type Group struct {
 	// the group state
 	State State `export:"state"`
 
+	// if the group is added as a system group
+	IsSystem bool `export:"system"`
+
+	// if files owned by the old gid are moved to the new gid when it changes
+	ForceGIDChange bool `export:"forcegidchange"`
+
+	// the paths searched for files owned by the old gid
+	GIDChangePaths []string `export:"gidchangepaths"`
+
+	// the users who should be members of the group
+	Members []string `export:"members"`
+
+	// if members not in Members are removed from the group
+	StrictMembers bool `export:"strictmembers"`
+
 	system SystemUtils
 }
 
@@ -61,10 +78,16 @@ type ModGroupOptions struct {
 // SystemUtils provides system utilities for group
 type SystemUtils interface {
 	AddGroup(groupName, groupID string) error
+	AddSystemGroup(groupName, groupID string) error
+	FindGroupFiles(paths []string, groupID string) ([]string, error)
+	Chgrp(files []string, groupID string) error
 	DelGroup(groupName string) error
 	ModGroup(groupName string, options *ModGroupOptions) error
 	LookupGroup(groupName string) (*user.Group, error)
 	LookupGroupID(groupID string) (*user.Group, error)
+	LookupGroupMembers(groupName string) ([]string, error)
+	AddGroupMember(groupName, userName string) error
+	DelGroupMember(groupName, userName string) error
 }
 
 // ErrUnsupported is used when a system is not supported
@@ -77,6 +100,16 @@ func NewGroup(system SystemUtils) *Group {
 	}
 }
 
+// Claims returns the user database, which includes groups, and the paths
+// searched for files to move to a new gid
+func (g *Group) Claims() []resource.Claim {
+	claims := []resource.Claim{resource.ClaimUserDB}
+	for _, path := range g.GIDChangePaths {
+		claims = append(claims, resource.ClaimPath(path))
+	}
+	return claims
+}
+
 // Check if a user group exists
 func (g *Group) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
 	var (
@@ -117,6 +150,7 @@ func (g *Group) Check(context.Context, resource.Renderer) (resource.TaskStatus,
 				case nameNotFound:
 					status.Output = append(status.Output, "add group")
 					status.AddDifference("group", string(StateAbsent), fmt.Sprintf("group %s", g.Name), "")
+					status.AddDifference("account", string(StateAbsent), g.accountType(), "")
 				case groupByName != nil:
 					status.Output = append(status.Output, fmt.Sprintf("group add: group %s already exists", g.Name))
 				}
@@ -124,6 +158,8 @@ func (g *Group) Check(context.Context, resource.Renderer) (resource.TaskStatus,
 				_, newNameNotFound := newNameErr.(user.UnknownGroupError)
 
 				switch {
+				case nameNotFound && groupByNewName != nil:
+					status.Output = append(status.Output, fmt.Sprintf("group modify: group %s already renamed to %s", g.Name, g.NewName))
 				case nameNotFound:
 					status.RaiseLevel(resource.StatusCantChange)
 					status.Output = append(status.Output, fmt.Sprintf("group modify: group %s does not exist", g.Name))
@@ -147,6 +183,7 @@ func (g *Group) Check(context.Context, resource.Renderer) (resource.TaskStatus,
 				case nameNotFound && gidNotFound:
 					status.Output = append(status.Output, "add group with gid")
 					status.AddDifference("group", string(StateAbsent), fmt.Sprintf("group %s with gid %s", g.Name, g.GID), "")
+					status.AddDifference("account", string(StateAbsent), g.accountType(), "")
 				case nameNotFound:
 					status.RaiseLevel(resource.StatusCantChange)
 					status.Output = append(status.Output, fmt.Sprintf("group add: gid %s already exists", g.GID))
@@ -154,22 +191,32 @@ func (g *Group) Check(context.Context, resource.Renderer) (resource.TaskStatus,
 				case gidNotFound:
 					status.Output = append(status.Output, "modify group gid")
 					status.AddDifference("group", fmt.Sprintf("group %s with gid %s", g.Name, groupByName.Gid), fmt.Sprintf("group %s with gid %s", g.Name, g.GID), "")
+					if err := g.diffGroupFiles(status, groupByName.Gid); err != nil {
+						return status, err
+					}
 				case groupByName != nil && groupByGid != nil && groupByName.Name != groupByGid.Name || groupByName.Gid != groupByGid.Gid:
 					status.RaiseLevel(resource.StatusCantChange)
 					status.Output = append(status.Output, fmt.Sprintf("group add/modify: group %s and gid %s belong to different groups", g.Name, g.GID))
 					return status, errors.New("cannot add or modify group")
 				case groupByName != nil && groupByGid != nil && *groupByName == *groupByGid:
-					status.RaiseLevel(resource.StatusCantChange)
 					status.Output = append(status.Output, fmt.Sprintf("group add/modify: group %s with gid %s already exists", g.Name, g.GID))
-					return status, errors.New("cannot add or modify group")
 				}
 			case g.NewName != "":
 				_, newNameNotFound := newNameErr.(user.UnknownGroupError)
 
 				switch {
+				case nameNotFound && groupByNewName != nil && groupByNewName.Gid == g.GID:
+					status.Output = append(status.Output, fmt.Sprintf("group modify: group %s already renamed to %s with gid %s", g.Name, g.NewName, g.GID))
+				case nameNotFound:
+					status.RaiseLevel(resource.StatusCantChange)
+					status.Output = append(status.Output, fmt.Sprintf("group modify: group %s does not exist", g.Name))
+					return status, errors.New("cannot modify group")
 				case newNameNotFound && gidNotFound:
 					status.Output = append(status.Output, "modify group name and gid")
 					status.AddDifference("group", fmt.Sprintf("group %s with gid %s", g.Name, groupByName.Gid), fmt.Sprintf("group %s with gid %s", g.NewName, g.GID), "")
+					if err := g.diffGroupFiles(status, groupByName.Gid); err != nil {
+						return status, err
+					}
 				case gidNotFound:
 					status.RaiseLevel(resource.StatusCantChange)
 					status.Output = append(status.Output, fmt.Sprintf("group modify: group %s already exists", g.NewName))
@@ -181,6 +228,26 @@ func (g *Group) Check(context.Context, resource.Renderer) (resource.TaskStatus,
 				}
 			}
 		}
+
+		if g.managesMembers() {
+			// a group that was already renamed has its members under the
+			// new name
+			name := g.Name
+			if nameErr != nil && groupByNewName != nil {
+				name = g.NewName
+			}
+
+			var current []string
+			if nameErr == nil || name == g.NewName {
+				var err error
+				current, err = g.system.LookupGroupMembers(name)
+				if err != nil {
+					status.RaiseLevel(resource.StatusFatal)
+					return status, errors.Wrapf(err, "cannot look up members of group %s", name)
+				}
+			}
+			g.diffMembers(status, current)
+		}
 	case StateAbsent:
 		switch {
 		case g.GID == "":
@@ -230,9 +297,10 @@ func (g *Group) Check(context.Context, resource.Renderer) (resource.TaskStatus,
 // Apply changes for group
 func (g *Group) Apply(context.Context) (resource.TaskStatus, error) {
 	var (
-		groupByGid *user.Group
-		gidErr     error
-		newNameErr error
+		groupByGid     *user.Group
+		gidErr         error
+		groupByNewName *user.Group
+		newNameErr     error
 	)
 
 	// lookup the group by name and lookup the group by gid
@@ -244,7 +312,7 @@ func (g *Group) Apply(context.Context) (resource.TaskStatus, error) {
 		groupByGid, gidErr = g.system.LookupGroupID(g.GID)
 	}
 	if g.NewName != "" {
-		_, newNameErr = g.system.LookupGroup(g.NewName)
+		groupByNewName, newNameErr = g.system.LookupGroup(g.NewName)
 	}
 
 	status := &resource.Status{}
@@ -264,13 +332,15 @@ func (g *Group) Apply(context.Context) (resource.TaskStatus, error) {
 			case g.NewName == "":
 				switch {
 				case nameNotFound:
-					err := g.system.AddGroup(g.Name, g.GID)
+					err := g.addGroup()
 					if err != nil {
 						status.RaiseLevel(resource.StatusFatal)
 						status.Output = append(status.Output, fmt.Sprintf("error adding group %s", g.Name))
 						return status, errors.Wrap(err, "group add")
 					}
 					status.Output = append(status.Output, fmt.Sprintf("added group %s", g.Name))
+				case groupByName != nil && g.managesMembers():
+					// the group exists, so only its members change
 				default:
 					status.RaiseLevel(resource.StatusCantChange)
 					return status, fmt.Errorf("will not attempt add: group %s", g.Name)
@@ -288,6 +358,8 @@ func (g *Group) Apply(context.Context) (resource.TaskStatus, error) {
 						return status, errors.Wrap(err, "group modify")
 					}
 					status.Output = append(status.Output, fmt.Sprintf("modified group %s with new name %s", g.Name, g.NewName))
+				case nameNotFound && groupByNewName != nil && g.managesMembers():
+					// the group was already renamed, so only its members change
 				default:
 					status.RaiseLevel(resource.StatusCantChange)
 					return status, fmt.Errorf("will not attempt modify: group %s", g.Name)
@@ -301,7 +373,7 @@ func (g *Group) Apply(context.Context) (resource.TaskStatus, error) {
 			case g.NewName == "":
 				switch {
 				case nameNotFound && gidNotFound:
-					err := g.system.AddGroup(g.Name, g.GID)
+					err := g.addGroup()
 					if err != nil {
 						status.RaiseLevel(resource.StatusFatal)
 						status.Output = append(status.Output, fmt.Sprintf("error adding group %s with gid %s", g.Name, g.GID))
@@ -317,6 +389,11 @@ func (g *Group) Apply(context.Context) (resource.TaskStatus, error) {
 						return status, errors.Wrap(err, "group modify")
 					}
 					status.Output = append(status.Output, fmt.Sprintf("modified group %s with new gid %s", g.Name, g.GID))
+					if err := g.chgrpGroupFiles(status, groupByName.Gid); err != nil {
+						return status, err
+					}
+				case groupByName != nil && groupByGid != nil && *groupByName == *groupByGid && g.managesMembers():
+					// the group exists with the gid, so only its members change
 				default:
 					status.RaiseLevel(resource.StatusCantChange)
 					return status, fmt.Errorf("will not attempt add/modify: group %s with gid %s", g.Name, g.GID)
@@ -334,12 +411,23 @@ func (g *Group) Apply(context.Context) (resource.TaskStatus, error) {
 						return status, errors.Wrap(err, "group modify")
 					}
 					status.Output = append(status.Output, fmt.Sprintf("modified group %s with new name %s and new gid %s", g.Name, g.NewName, g.GID))
+					if err := g.chgrpGroupFiles(status, groupByName.Gid); err != nil {
+						return status, err
+					}
+				case nameNotFound && groupByNewName != nil && groupByNewName.Gid == g.GID && g.managesMembers():
+					// the group was already renamed, so only its members change
 				default:
 					status.RaiseLevel(resource.StatusCantChange)
 					return status, fmt.Errorf("will not attempt modify: group %s with new name %s and new gid %s", g.Name, g.NewName, g.GID)
 				}
 			}
 		}
+
+		if g.managesMembers() {
+			if err := g.applyMembers(status); err != nil {
+				return status, err
+			}
+		}
 	case StateAbsent:
 		switch {
 		case g.GID == "":
@@ -399,3 +487,165 @@ func SetModGroupOptions(g *Group) *ModGroupOptions {
 
 	return options
 }
+
+// addGroup adds the group, as a system group if configured
+func (g *Group) addGroup() error {
+	if g.IsSystem {
+		return g.system.AddSystemGroup(g.Name, g.GID)
+	}
+	return g.system.AddGroup(g.Name, g.GID)
+}
+
+// accountType is the kind of group added, shown in the plan
+func (g *Group) accountType() string {
+	if g.IsSystem {
+		return "system"
+	}
+	return "regular"
+}
+
+// diffGroupFiles checks for files that would be left owned by the old gid
+// when the gid changes. groupmod does not change the group of existing files,
+// so without ForceGIDChange a warning is added instead.
+func (g *Group) diffGroupFiles(status *resource.Status, oldGID string) error {
+	if !g.ForceGIDChange {
+		status.AddWarning(
+			resource.WarningResource,
+			fmt.Sprintf("files owned by gid %s will not be changed to gid %s; set force_gid_change to change them", oldGID, g.GID),
+		)
+		return nil
+	}
+
+	files, err := g.system.FindGroupFiles(g.GIDChangePaths, oldGID)
+	if err != nil {
+		status.RaiseLevel(resource.StatusCantChange)
+		return errors.Wrapf(err, "cannot find files owned by gid %s", oldGID)
+	}
+
+	if len(files) > 0 {
+		status.AddDifference(
+			fmt.Sprintf("files with gid %s", oldGID),
+			fmt.Sprintf("%d files", len(files)),
+			fmt.Sprintf("gid %s", g.GID),
+			"",
+		)
+	}
+
+	return nil
+}
+
+// chgrpGroupFiles moves files owned by the old gid to the new gid after the
+// gid has changed, if ForceGIDChange is set
+func (g *Group) chgrpGroupFiles(status *resource.Status, oldGID string) error {
+	if !g.ForceGIDChange {
+		return nil
+	}
+
+	files, err := g.system.FindGroupFiles(g.GIDChangePaths, oldGID)
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		status.Output = append(status.Output, fmt.Sprintf("error finding files owned by gid %s", oldGID))
+		return errors.Wrap(err, "group find files")
+	}
+
+	if err := g.system.Chgrp(files, g.GID); err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		status.Output = append(status.Output, fmt.Sprintf("error changing files owned by gid %s to gid %s", oldGID, g.GID))
+		return errors.Wrap(err, "group chgrp")
+	}
+
+	status.Output = append(status.Output, fmt.Sprintf("changed %d files owned by gid %s to gid %s", len(files), oldGID, g.GID))
+	return nil
+}
+
+// managesMembers reports whether the members of the group are configured
+func (g *Group) managesMembers() bool {
+	return len(g.Members) > 0 || g.StrictMembers
+}
+
+// memberChanges compares the current members of the group to the configured
+// members. Members are only removed when StrictMembers is set.
+func (g *Group) memberChanges(current []string) (add, remove []string) {
+	isCurrent := make(map[string]bool, len(current))
+	for _, name := range current {
+		isCurrent[name] = true
+	}
+
+	isWanted := make(map[string]bool, len(g.Members))
+	for _, name := range g.Members {
+		isWanted[name] = true
+		if !isCurrent[name] {
+			add = append(add, name)
+		}
+	}
+
+	if g.StrictMembers {
+		for _, name := range current {
+			if !isWanted[name] {
+				remove = append(remove, name)
+			}
+		}
+	}
+
+	sort.Strings(add)
+	sort.Strings(remove)
+	return add, remove
+}
+
+// diffMembers adds a difference for each member to be added to or removed
+// from the group
+func (g *Group) diffMembers(status *resource.Status, current []string) {
+	add, remove := g.memberChanges(current)
+
+	if len(add) > 0 {
+		status.Output = append(status.Output, fmt.Sprintf("add group members %s", strings.Join(add, ", ")))
+	}
+	for _, name := range add {
+		status.AddDifference(fmt.Sprintf("member %s", name), string(StateAbsent), string(StatePresent), "")
+	}
+
+	if len(remove) > 0 {
+		status.Output = append(status.Output, fmt.Sprintf("remove group members %s", strings.Join(remove, ", ")))
+	}
+	for _, name := range remove {
+		status.AddDifference(fmt.Sprintf("member %s", name), string(StatePresent), string(StateAbsent), "")
+	}
+}
+
+// applyMembers adds and removes members of the group after it has been added
+// or modified
+func (g *Group) applyMembers(status *resource.Status) error {
+	name := g.Name
+	if g.NewName != "" {
+		name = g.NewName
+	}
+
+	current, err := g.system.LookupGroupMembers(name)
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		status.Output = append(status.Output, fmt.Sprintf("error looking up members of group %s", name))
+		return errors.Wrap(err, "group members")
+	}
+
+	add, remove := g.memberChanges(current)
+
+	for _, member := range add {
+		if err := g.system.AddGroupMember(name, member); err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			status.Output = append(status.Output, fmt.Sprintf("error adding %s to group %s", member, name))
+			return errors.Wrap(err, "group add member")
+		}
+		status.Output = append(status.Output, fmt.Sprintf("added %s to group %s", member, name))
+	}
+
+	for _, member := range remove {
+		if err := g.system.DelGroupMember(name, member); err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			status.Output = append(status.Output, fmt.Sprintf("error removing %s from group %s", member, name))
+			return errors.Wrap(err, "group remove member")
+		}
+		status.Output = append(status.Output, fmt.Sprintf("removed %s from group %s", member, name))
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build darwin
+
+package group
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+const (
+	// minSystemGID and maxSystemGID bound the gids picked for system groups
+	// which are added without one. macOS leaves this range to services.
+	minSystemGID = 200
+	maxSystemGID = 400
+)
+
+// AddGroup adds a group
+func (s *System) AddGroup(groupName, groupID string) error {
+	args := []string{"-o", "create"}
+	if groupID != "" {
+		args = append(args, "-i", groupID)
+	}
+	args = append(args, groupName)
+
+	cmd := exec.Command("dseditgroup", args...)
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("dseditgroup: %s", err)
+	}
+	return nil
+}
+
+// AddSystemGroup adds a system group. dseditgroup has no notion of system
+// groups, so when no gid is given one is picked from the range for services.
+func (s *System) AddSystemGroup(groupName, groupID string) error {
+	if groupID == "" {
+		list, err := dscl("-list", "/Groups", "PrimaryGroupID")
+		if err != nil {
+			return err
+		}
+
+		groupID, err = nextFreeID(list, minSystemGID, maxSystemGID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.AddGroup(groupName, groupID)
+}
+
+// DelGroup deletes a group
+func (s *System) DelGroup(groupName string) error {
+	cmd := exec.Command("dseditgroup", "-o", "delete", groupName)
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("dseditgroup: %s", err)
+	}
+	return nil
+}
+
+// ModGroup modifies a group. The gid is changed before the name, since the
+// group record is addressed by its name.
+func (s *System) ModGroup(groupName string, options *ModGroupOptions) error {
+	record := "/Groups/" + groupName
+
+	if options.GID != "" {
+		if _, err := dscl("-create", record, "PrimaryGroupID", options.GID); err != nil {
+			return err
+		}
+	}
+	if options.NewName != "" {
+		if _, err := dscl("-change", record, "RecordName", groupName, options.NewName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LookupGroupMembers looks up the users who have the group as a supplementary
+// group. Users with the group as their primary group are not included.
+func (s *System) LookupGroupMembers(groupName string) ([]string, error) {
+	list, err := dscl("-list", "/Groups", "GroupMembership")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseGroupMembership(list, groupName)
+}
+
+// AddGroupMember adds a user to a group
+func (s *System) AddGroupMember(groupName, userName string) error {
+	cmd := exec.Command("dseditgroup", "-o", "edit", "-a", userName, "-t", "user", groupName)
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("dseditgroup: %s", err)
+	}
+	return nil
+}
+
+// DelGroupMember removes a user from a group
+func (s *System) DelGroupMember(groupName, userName string) error {
+	cmd := exec.Command("dseditgroup", "-o", "edit", "-d", userName, "-t", "user", groupName)
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("dseditgroup: %s", err)
+	}
+	return nil
+}
+
+// dscl runs dscl against the local directory and returns what it printed
+func dscl(args ...string) (string, error) {
+	var out bytes.Buffer
+
+	cmd := exec.Command("dscl", append([]string{"."}, args...)...)
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("dscl: %s", err)
+	}
+	return out.String(), nil
+}
+
+// parseGroupMembership reads the members of a group from the output of
+// `dscl . -list /Groups GroupMembership`, which prints a line for every group
+// with its name followed by its members
+func parseGroupMembership(data, groupName string) ([]string, error) {
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != groupName {
+			continue
+		}
+		return fields[1:], nil
+	}
+
+	return nil, user.UnknownGroupError(groupName)
+}
+
+// nextFreeID picks the lowest id from min to max that isn't used in the output
+// of `dscl . -list <path> <id attribute>`
+func nextFreeID(data string, min, max int) (string, error) {
+	used := map[int]bool{}
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if id, err := strconv.Atoi(fields[1]); err == nil {
+			used[id] = true
+		}
+	}
+
+	for id := min; id <= max; id++ {
+		if !used[id] {
+			return strconv.Itoa(id), nil
+		}
+	}
+	return "", fmt.Errorf("no free id from %d to %d", min, max)
+}
@@ -0,0 +1,53 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package group
+
+import (
+	"os/user"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGroupMembership(t *testing.T) {
+	t.Parallel()
+
+	list := "_amavisd\nadmin                    root alice\nstaff                    root\nwheel\n"
+
+	members, err := parseGroupMembership(list, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"root", "alice"}, members)
+
+	members, err = parseGroupMembership(list, "wheel")
+	require.NoError(t, err)
+	assert.Empty(t, members)
+
+	_, err = parseGroupMembership(list, "missing")
+	assert.Equal(t, user.UnknownGroupError("missing"), err)
+}
+
+func TestNextFreeID(t *testing.T) {
+	t.Parallel()
+
+	list := "_www                     70\ndaemon                   1\nsvc-a                    200\nsvc-b                    201\n"
+
+	id, err := nextFreeID(list, 200, 400)
+	require.NoError(t, err)
+	assert.Equal(t, "202", id)
+
+	_, err = nextFreeID(list, 200, 201)
+	assert.EqualError(t, err, "no free id from 200 to 201")
+}
@@ -12,7 +12,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// +build !linux
+// +build !linux,!darwin
 
 package group
 
@@ -28,6 +28,21 @@ func (s *System) AddGroup(groupName, groupID string) error {
 	return ErrUnsupported
 }
 
+// AddSystemGroup implementation for systems which are not supported
+func (s *System) AddSystemGroup(groupName, groupID string) error {
+	return ErrUnsupported
+}
+
+// FindGroupFiles implementation for systems which are not supported
+func (s *System) FindGroupFiles(paths []string, groupID string) ([]string, error) {
+	return nil, ErrUnsupported
+}
+
+// Chgrp implementation for systems which are not supported
+func (s *System) Chgrp(files []string, groupID string) error {
+	return ErrUnsupported
+}
+
 // DelGroup implementation for systems which are not supported
 func (s *System) DelGroup(groupName string) error {
 	return ErrUnsupported
@@ -47,3 +62,18 @@ func (s *System) LookupGroup(groupName string) (*user.Group, error) {
 func (s *System) LookupGroupID(groupID string) (*user.Group, error) {
 	return nil, ErrUnsupported
 }
+
+// LookupGroupMembers implementation for systems which are not supported
+func (s *System) LookupGroupMembers(groupName string) ([]string, error) {
+	return nil, ErrUnsupported
+}
+
+// AddGroupMember implementation for systems which are not supported
+func (s *System) AddGroupMember(groupName, userName string) error {
+	return ErrUnsupported
+}
+
+// DelGroupMember implementation for systems which are not supported
+func (s *System) DelGroupMember(groupName, userName string) error {
+	return ErrUnsupported
+}
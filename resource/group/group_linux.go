@@ -17,13 +17,17 @@
 package group
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"os/user"
+	"strings"
 )
 
-// System implements SystemUtils
-type System struct{}
+// groupFile is the group database read for group members
+const groupFile = "/etc/group"
 
 // AddGroup adds a group
 func (s *System) AddGroup(groupName, groupID string) error {
@@ -39,6 +43,20 @@ func (s *System) AddGroup(groupName, groupID string) error {
 	return nil
 }
 
+// AddSystemGroup adds a system group
+func (s *System) AddSystemGroup(groupName, groupID string) error {
+	args := []string{"-r", groupName}
+	if groupID != "" {
+		args = append(args, "-g", groupID)
+	}
+	cmd := exec.Command("groupadd", args...)
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("groupadd: %s", err)
+	}
+	return nil
+}
+
 // DelGroup deletes a group
 func (s *System) DelGroup(groupName string) error {
 	cmd := exec.Command("groupdel", groupName)
@@ -66,14 +84,65 @@ func (s *System) ModGroup(groupName string, options *ModGroupOptions) error {
 	return nil
 }
 
-// LookupGroup looks up a group by name
-// If the group cannot be found an error is returned
-func (s *System) LookupGroup(groupName string) (*user.Group, error) {
-	return user.LookupGroup(groupName)
+// LookupGroupMembers looks up the users who have the group as a supplementary
+// group. Users with the group as their primary group are not included.
+func (s *System) LookupGroupMembers(groupName string) ([]string, error) {
+	f, err := os.Open(groupFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return readGroupMembers(f, groupName)
 }
 
-// LookupGroupID looks up a group by gid
-// If the group cannot be found an error is returned
-func (s *System) LookupGroupID(groupID string) (*user.Group, error) {
-	return user.LookupGroupId(groupID)
+// readGroupMembers reads the members of a group from a group database in the
+// format of /etc/group
+func readGroupMembers(r io.Reader, groupName string) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// name:password:gid:members
+		fields := strings.Split(line, ":")
+		if len(fields) != 4 || fields[0] != groupName {
+			continue
+		}
+
+		var members []string
+		for _, member := range strings.Split(fields[3], ",") {
+			if member = strings.TrimSpace(member); member != "" {
+				members = append(members, member)
+			}
+		}
+		return members, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, user.UnknownGroupError(groupName)
+}
+
+// AddGroupMember adds a user to a group
+func (s *System) AddGroupMember(groupName, userName string) error {
+	cmd := exec.Command("gpasswd", "-a", userName, groupName)
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("gpasswd: %s", err)
+	}
+	return nil
+}
+
+// DelGroupMember removes a user from a group
+func (s *System) DelGroupMember(groupName, userName string) error {
+	cmd := exec.Command("gpasswd", "-d", userName, groupName)
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("gpasswd: %s", err)
+	}
+	return nil
 }
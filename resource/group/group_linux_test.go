@@ -0,0 +1,46 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package group
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadGroupMembers(t *testing.T) {
+	t.Parallel()
+
+	db := strings.Join([]string{
+		"root:x:0:",
+		"# comment",
+		"wheel:x:10:alice, bob",
+		"",
+		"admins:x:1500:carol",
+	}, "\n")
+
+	members, err := readGroupMembers(strings.NewReader(db), "wheel")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, members)
+
+	members, err = readGroupMembers(strings.NewReader(db), "root")
+	require.NoError(t, err)
+	assert.Empty(t, members)
+
+	_, err = readGroupMembers(strings.NewReader(db), "missing")
+	assert.EqualError(t, err, "group: unknown group missing")
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/fgrid/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/net/context"
 )
 
@@ -81,6 +82,22 @@ func TestGroupInterface(t *testing.T) {
 	assert.Implements(t, (*resource.Task)(nil), new(group.Group))
 }
 
+// TestClaims tests that the gid change paths are claimed
+func TestClaims(t *testing.T) {
+	t.Parallel()
+
+	g := group.NewGroup(new(MockSystem))
+	assert.Equal(t, []resource.Claim{resource.ClaimUserDB}, g.Claims())
+
+	g.ForceGIDChange = true
+	g.GIDChangePaths = []string{"/srv", "/home/"}
+	assert.Equal(
+		t,
+		[]resource.Claim{resource.ClaimUserDB, resource.ClaimPath("/srv"), resource.ClaimPath("/home")},
+		g.Claims(),
+	)
+}
+
 // TestCheck tests all possible cases Check handles
 func TestCheck(t *testing.T) {
 	t.Parallel()
@@ -123,6 +140,7 @@ func TestCheck(t *testing.T) {
 						assert.Equal(t, "add group", status.Messages()[0])
 						assert.Equal(t, string(group.StateAbsent), status.Diffs()["group"].Original())
 						assert.Equal(t, fmt.Sprintf("group %s", g.Name), status.Diffs()["group"].Current())
+						assert.Equal(t, "regular", status.Diffs()["account"].Current())
 						assert.True(t, status.HasChanges())
 					} else {
 						assert.EqualError(t, err, "group: not supported on this system")
@@ -255,10 +273,10 @@ func TestCheck(t *testing.T) {
 					status, err := g.Check(context.Background(), fakerenderer.New())
 
 					if runtime.GOOS == "linux" {
-						assert.EqualError(t, err, "cannot add or modify group")
-						assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+						assert.NoError(t, err)
+						assert.Equal(t, resource.StatusNoChange, status.StatusCode())
 						assert.Equal(t, fmt.Sprintf("group add/modify: group %s with gid %s already exists", g.Name, g.GID), status.Messages()[0])
-						assert.True(t, status.HasChanges())
+						assert.False(t, status.HasChanges())
 					} else {
 						assert.EqualError(t, err, "group: not supported on this system")
 					}
@@ -981,6 +999,320 @@ func TestSetModGroupOptions(t *testing.T) {
 	})
 }
 
+// TestSystemGroup tests adding a system group
+func TestSystemGroup(t *testing.T) {
+	t.Parallel()
+
+	m := &MockSystem{}
+	g := group.NewGroup(m)
+	g.Name = fakeName
+	g.IsSystem = true
+	g.State = group.StatePresent
+
+	m.On("LookupGroup", g.Name).Return(new(user.Group), user.UnknownGroupError(""))
+	m.On("AddSystemGroup", g.Name, "").Return(nil)
+	status, err := g.Apply(context.Background())
+
+	m.AssertCalled(t, "AddSystemGroup", g.Name, "")
+	m.AssertNotCalled(t, "AddGroup", mock.Anything, mock.Anything)
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("added group %s", g.Name), status.Messages()[0])
+}
+
+// TestSystemGroupCheck tests the plan for adding a system group
+func TestSystemGroupCheck(t *testing.T) {
+	t.Parallel()
+
+	m := &MockSystem{}
+	g := group.NewGroup(m)
+	g.Name = fakeName
+	g.IsSystem = true
+	g.State = group.StatePresent
+
+	m.On("LookupGroup", g.Name).Return(new(user.Group), user.UnknownGroupError(""))
+	status, err := g.Check(context.Background(), fakerenderer.New())
+
+	assert.NoError(t, err)
+	assert.Equal(t, string(group.StateAbsent), status.Diffs()["account"].Original())
+	assert.Equal(t, "system", status.Diffs()["account"].Current())
+}
+
+// TestForceGIDChange tests changing the group of files when the gid changes
+func TestForceGIDChange(t *testing.T) {
+	t.Parallel()
+
+	grp := &user.Group{Name: currName, Gid: currGid}
+	paths := []string{"/srv"}
+	files := []string{"/srv/a", "/srv/b"}
+
+	newGroup := func(m *MockSystem, force bool) *group.Group {
+		g := group.NewGroup(m)
+		g.Name = grp.Name
+		g.GID = fakeGid
+		g.State = group.StatePresent
+		if force {
+			g.ForceGIDChange = true
+			g.GIDChangePaths = paths
+		}
+
+		m.On("LookupGroup", g.Name).Return(grp, nil)
+		m.On("LookupGroupID", g.GID).Return(new(user.Group), user.UnknownGroupIdError(""))
+		return g
+	}
+
+	t.Run("check", func(t *testing.T) {
+		m := &MockSystem{}
+		g := newGroup(m, true)
+		m.On("FindGroupFiles", paths, currGid).Return(files, nil)
+
+		status, err := g.Check(context.Background(), fakerenderer.New())
+
+		assert.NoError(t, err)
+		assert.Equal(t, "2 files", status.Diffs()["files with gid "+currGid].Original())
+		assert.Equal(t, "gid "+fakeGid, status.Diffs()["files with gid "+currGid].Current())
+	})
+
+	t.Run("check without force", func(t *testing.T) {
+		m := &MockSystem{}
+		g := newGroup(m, false)
+
+		status, err := g.Check(context.Background(), fakerenderer.New())
+
+		m.AssertNotCalled(t, "FindGroupFiles", mock.Anything, mock.Anything)
+		assert.NoError(t, err)
+		assert.Contains(t, status.Warning(), fmt.Sprintf("files owned by gid %s will not be changed", currGid))
+	})
+
+	t.Run("check error", func(t *testing.T) {
+		m := &MockSystem{}
+		g := newGroup(m, true)
+		m.On("FindGroupFiles", paths, currGid).Return([]string(nil), fmt.Errorf("permission denied"))
+
+		status, err := g.Check(context.Background(), fakerenderer.New())
+
+		assert.EqualError(t, err, fmt.Sprintf("cannot find files owned by gid %s: permission denied", currGid))
+		assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		m := &MockSystem{}
+		g := newGroup(m, true)
+		options := group.ModGroupOptions{GID: g.GID}
+		m.On("ModGroup", g.Name, &options).Return(nil)
+		m.On("FindGroupFiles", paths, currGid).Return(files, nil)
+		m.On("Chgrp", files, g.GID).Return(nil)
+
+		status, err := g.Apply(context.Background())
+
+		m.AssertCalled(t, "Chgrp", files, g.GID)
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("changed 2 files owned by gid %s to gid %s", currGid, g.GID), status.Messages()[1])
+	})
+
+	t.Run("apply without force", func(t *testing.T) {
+		m := &MockSystem{}
+		g := newGroup(m, false)
+		options := group.ModGroupOptions{GID: g.GID}
+		m.On("ModGroup", g.Name, &options).Return(nil)
+
+		_, err := g.Apply(context.Background())
+
+		m.AssertNotCalled(t, "Chgrp", mock.Anything, mock.Anything)
+		assert.NoError(t, err)
+	})
+
+	t.Run("apply error", func(t *testing.T) {
+		m := &MockSystem{}
+		g := newGroup(m, true)
+		options := group.ModGroupOptions{GID: g.GID}
+		m.On("ModGroup", g.Name, &options).Return(nil)
+		m.On("FindGroupFiles", paths, currGid).Return(files, nil)
+		m.On("Chgrp", files, g.GID).Return(fmt.Errorf("operation not permitted"))
+
+		status, err := g.Apply(context.Background())
+
+		assert.EqualError(t, err, "group chgrp: operation not permitted")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+}
+
+// TestMembers tests managing the members of a group
+func TestMembers(t *testing.T) {
+	t.Parallel()
+
+	grp := &user.Group{Name: "admins", Gid: "1500"}
+
+	newGroup := func(m *MockSystem, strict bool, members ...string) *group.Group {
+		g := group.NewGroup(m)
+		g.Name = grp.Name
+		g.State = group.StatePresent
+		g.Members = members
+		g.StrictMembers = strict
+		return g
+	}
+
+	t.Run("check", func(t *testing.T) {
+		t.Run("append", func(t *testing.T) {
+			m := &MockSystem{}
+			g := newGroup(m, false, "carol", "alice")
+			m.On("LookupGroup", g.Name).Return(grp, nil)
+			m.On("LookupGroupMembers", g.Name).Return([]string{"alice", "bob"}, nil)
+
+			status, err := g.Check(context.Background(), fakerenderer.New())
+
+			require.NoError(t, err)
+			assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+			assert.Equal(t, []string{
+				fmt.Sprintf("group add: group %s already exists", g.Name),
+				"add group members carol",
+			}, status.Messages())
+			assert.Equal(t, "absent", status.Diffs()["member carol"].Original())
+			assert.Equal(t, "present", status.Diffs()["member carol"].Current())
+			assert.NotContains(t, status.Diffs(), "member alice")
+			assert.NotContains(t, status.Diffs(), "member bob")
+		})
+
+		t.Run("strict", func(t *testing.T) {
+			m := &MockSystem{}
+			g := newGroup(m, true, "carol", "alice")
+			m.On("LookupGroup", g.Name).Return(grp, nil)
+			m.On("LookupGroupMembers", g.Name).Return([]string{"alice", "bob"}, nil)
+
+			status, err := g.Check(context.Background(), fakerenderer.New())
+
+			require.NoError(t, err)
+			assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+			assert.Equal(t, "absent", status.Diffs()["member carol"].Original())
+			assert.Equal(t, "present", status.Diffs()["member carol"].Current())
+			assert.Equal(t, "present", status.Diffs()["member bob"].Original())
+			assert.Equal(t, "absent", status.Diffs()["member bob"].Current())
+			assert.Contains(t, status.Messages(), "remove group members bob")
+		})
+
+		t.Run("no changes", func(t *testing.T) {
+			m := &MockSystem{}
+			g := newGroup(m, true, "bob", "alice")
+			m.On("LookupGroup", g.Name).Return(grp, nil)
+			m.On("LookupGroupMembers", g.Name).Return([]string{"alice", "bob"}, nil)
+
+			status, err := g.Check(context.Background(), fakerenderer.New())
+
+			require.NoError(t, err)
+			assert.Equal(t, resource.StatusNoChange, status.StatusCode())
+			assert.False(t, status.HasChanges())
+		})
+
+		t.Run("with gid", func(t *testing.T) {
+			m := &MockSystem{}
+			g := newGroup(m, false, "alice")
+			g.GID = grp.Gid
+			m.On("LookupGroup", g.Name).Return(grp, nil)
+			m.On("LookupGroupID", g.GID).Return(grp, nil)
+			m.On("LookupGroupMembers", g.Name).Return([]string(nil), nil)
+
+			status, err := g.Check(context.Background(), fakerenderer.New())
+
+			require.NoError(t, err)
+			assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+			assert.Equal(t, "present", status.Diffs()["member alice"].Current())
+		})
+
+		t.Run("new group", func(t *testing.T) {
+			m := &MockSystem{}
+			g := newGroup(m, false, "alice")
+			m.On("LookupGroup", g.Name).Return(new(user.Group), user.UnknownGroupError(""))
+
+			status, err := g.Check(context.Background(), fakerenderer.New())
+
+			require.NoError(t, err)
+			m.AssertNotCalled(t, "LookupGroupMembers", g.Name)
+			assert.Equal(t, "present", status.Diffs()["member alice"].Current())
+		})
+
+		t.Run("error looking up members", func(t *testing.T) {
+			m := &MockSystem{}
+			g := newGroup(m, false, "alice")
+			m.On("LookupGroup", g.Name).Return(grp, nil)
+			m.On("LookupGroupMembers", g.Name).Return([]string(nil), fmt.Errorf("permission denied"))
+
+			status, err := g.Check(context.Background(), fakerenderer.New())
+
+			assert.EqualError(t, err, fmt.Sprintf("cannot look up members of group %s: permission denied", g.Name))
+			assert.Equal(t, resource.StatusFatal, status.StatusCode())
+		})
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		t.Run("existing group", func(t *testing.T) {
+			m := &MockSystem{}
+			g := newGroup(m, true, "carol", "alice")
+			m.On("LookupGroup", g.Name).Return(grp, nil)
+			m.On("LookupGroupMembers", g.Name).Return([]string{"alice", "bob"}, nil)
+			m.On("AddGroupMember", g.Name, "carol").Return(nil)
+			m.On("DelGroupMember", g.Name, "bob").Return(nil)
+
+			status, err := g.Apply(context.Background())
+
+			require.NoError(t, err)
+			m.AssertNotCalled(t, "AddGroup", g.Name, g.GID)
+			m.AssertNotCalled(t, "AddGroupMember", g.Name, "alice")
+			assert.Equal(t, []string{
+				fmt.Sprintf("added carol to group %s", g.Name),
+				fmt.Sprintf("removed bob from group %s", g.Name),
+			}, status.Messages())
+		})
+
+		t.Run("new group", func(t *testing.T) {
+			m := &MockSystem{}
+			g := newGroup(m, false, "alice")
+			m.On("LookupGroup", g.Name).Return(new(user.Group), user.UnknownGroupError("")).Once()
+			m.On("AddGroup", g.Name, g.GID).Return(nil)
+			m.On("LookupGroupMembers", g.Name).Return([]string(nil), nil)
+			m.On("AddGroupMember", g.Name, "alice").Return(nil)
+
+			status, err := g.Apply(context.Background())
+
+			require.NoError(t, err)
+			m.AssertCalled(t, "AddGroup", g.Name, g.GID)
+			assert.Equal(t, []string{
+				fmt.Sprintf("added group %s", g.Name),
+				fmt.Sprintf("added alice to group %s", g.Name),
+			}, status.Messages())
+		})
+
+		t.Run("renamed group", func(t *testing.T) {
+			m := &MockSystem{}
+			g := newGroup(m, false, "alice")
+			g.NewName = "operators"
+			options := group.ModGroupOptions{NewName: g.NewName}
+			m.On("LookupGroup", g.Name).Return(grp, nil)
+			m.On("LookupGroup", g.NewName).Return(new(user.Group), user.UnknownGroupError(""))
+			m.On("ModGroup", g.Name, &options).Return(nil)
+			m.On("LookupGroupMembers", g.NewName).Return([]string(nil), nil)
+			m.On("AddGroupMember", g.NewName, "alice").Return(nil)
+
+			_, err := g.Apply(context.Background())
+
+			require.NoError(t, err)
+			m.AssertCalled(t, "AddGroupMember", g.NewName, "alice")
+		})
+
+		t.Run("error adding member", func(t *testing.T) {
+			m := &MockSystem{}
+			g := newGroup(m, false, "alice")
+			m.On("LookupGroup", g.Name).Return(grp, nil)
+			m.On("LookupGroupMembers", g.Name).Return([]string(nil), nil)
+			m.On("AddGroupMember", g.Name, "alice").Return(fmt.Errorf("gpasswd: exit status 3"))
+
+			status, err := g.Apply(context.Background())
+
+			assert.EqualError(t, err, "group add member: gpasswd: exit status 3")
+			assert.Equal(t, resource.StatusFatal, status.StatusCode())
+			assert.Equal(t, fmt.Sprintf("error adding alice to group %s", g.Name), status.Messages()[0])
+		})
+	})
+}
+
 // setGid is used to set a gid that exists but is not a match for
 // the current user group name (currName).
 func setGid() (string, error) {
@@ -1006,6 +1338,88 @@ func setFakeGid() (string, error) {
 	return "", fmt.Errorf("setFakeGid: could not set gid")
 }
 
+// TestAlreadyRenamed tests that a group already renamed to NewName is left
+// alone on later runs
+func TestAlreadyRenamed(t *testing.T) {
+	t.Parallel()
+
+	renamed := &user.Group{Name: fakeName, Gid: currGid}
+
+	newGroup := func(m *MockSystem, gid string) *group.Group {
+		g := group.NewGroup(m)
+		g.Name = currName
+		g.NewName = renamed.Name
+		g.GID = gid
+		g.State = group.StatePresent
+		m.On("LookupGroup", g.Name).Return(new(user.Group), user.UnknownGroupError(""))
+		m.On("LookupGroup", g.NewName).Return(renamed, nil)
+		m.On("LookupGroupID", renamed.Gid).Return(renamed, nil)
+		return g
+	}
+
+	t.Run("check", func(t *testing.T) {
+		m := &MockSystem{}
+		g := newGroup(m, "")
+
+		status, err := g.Check(context.Background(), fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusNoChange, status.StatusCode())
+		assert.False(t, status.HasChanges())
+		assert.Equal(t, fmt.Sprintf("group modify: group %s already renamed to %s", g.Name, g.NewName), status.Messages()[0])
+	})
+
+	t.Run("check with gid", func(t *testing.T) {
+		m := &MockSystem{}
+		g := newGroup(m, renamed.Gid)
+
+		status, err := g.Check(context.Background(), fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusNoChange, status.StatusCode())
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("check with different gid", func(t *testing.T) {
+		m := &MockSystem{}
+		g := newGroup(m, fakeGid)
+		m.On("LookupGroupID", g.GID).Return(new(user.Group), user.UnknownGroupIdError(g.GID))
+
+		status, err := g.Check(context.Background(), fakerenderer.New())
+
+		assert.EqualError(t, err, "cannot modify group")
+		assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+	})
+
+	t.Run("check members", func(t *testing.T) {
+		m := &MockSystem{}
+		g := newGroup(m, "")
+		g.Members = []string{"alice"}
+		m.On("LookupGroupMembers", g.NewName).Return([]string(nil), nil)
+
+		status, err := g.Check(context.Background(), fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		assert.Contains(t, status.Messages(), "add group members alice")
+		m.AssertNotCalled(t, "LookupGroupMembers", g.Name)
+	})
+
+	t.Run("apply members", func(t *testing.T) {
+		m := &MockSystem{}
+		g := newGroup(m, renamed.Gid)
+		g.Members = []string{"alice"}
+		m.On("LookupGroupMembers", g.NewName).Return([]string(nil), nil)
+		m.On("AddGroupMember", g.NewName, "alice").Return(nil)
+
+		_, err := g.Apply(context.Background())
+
+		require.NoError(t, err)
+		m.AssertNotCalled(t, "ModGroup", mock.Anything, mock.Anything)
+		m.AssertCalled(t, "AddGroupMember", g.NewName, "alice")
+	})
+}
+
 // MockSystem for Group
 type MockSystem struct {
 	mock.Mock
@@ -1017,6 +1431,24 @@ func (m *MockSystem) AddGroup(name, gid string) error {
 	return args.Error(0)
 }
 
+// AddSystemGroup for MockSystem
+func (m *MockSystem) AddSystemGroup(name, gid string) error {
+	args := m.Called(name, gid)
+	return args.Error(0)
+}
+
+// FindGroupFiles for MockSystem
+func (m *MockSystem) FindGroupFiles(paths []string, gid string) ([]string, error) {
+	args := m.Called(paths, gid)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// Chgrp for MockSystem
+func (m *MockSystem) Chgrp(files []string, gid string) error {
+	args := m.Called(files, gid)
+	return args.Error(0)
+}
+
 // DelGroup for MockSystem
 func (m *MockSystem) DelGroup(name string) error {
 	args := m.Called(name)
@@ -1040,3 +1472,21 @@ func (m *MockSystem) LookupGroupID(gid string) (*user.Group, error) {
 	args := m.Called(gid)
 	return args.Get(0).(*user.Group), args.Error(1)
 }
+
+// LookupGroupMembers for MockSystem
+func (m *MockSystem) LookupGroupMembers(name string) ([]string, error) {
+	args := m.Called(name)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// AddGroupMember for MockSystem
+func (m *MockSystem) AddGroupMember(name, member string) error {
+	args := m.Called(name, member)
+	return args.Error(0)
+}
+
+// DelGroupMember for MockSystem
+func (m *MockSystem) DelGroupMember(name, member string) error {
+	args := m.Called(name, member)
+	return args.Error(0)
+}
@@ -0,0 +1,100 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux darwin
+
+package group
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// System implements SystemUtils
+type System struct{}
+
+// LookupGroup looks up a group by name
+// If the group cannot be found an error is returned
+func (s *System) LookupGroup(groupName string) (*user.Group, error) {
+	return user.LookupGroup(groupName)
+}
+
+// LookupGroupID looks up a group by gid
+// If the group cannot be found an error is returned
+func (s *System) LookupGroupID(groupID string) (*user.Group, error) {
+	return user.LookupGroupId(groupID)
+}
+
+// FindGroupFiles finds the files under paths that are owned by a gid. Like
+// `find -xdev`, the search does not cross into other filesystems.
+func (s *System) FindGroupFiles(paths []string, groupID string) ([]string, error) {
+	gid, err := strconv.ParseUint(groupID, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gid %q", groupID)
+	}
+
+	var files []string
+	for _, root := range paths {
+		rootInfo, err := os.Lstat(root)
+		if err != nil {
+			return nil, err
+		}
+		rootDev := rootInfo.Sys().(*syscall.Stat_t).Dev
+
+		err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			stat := info.Sys().(*syscall.Stat_t)
+			if stat.Dev != rootDev {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if uint64(stat.Gid) == gid {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// Chgrp changes the group of files, without following symlinks
+func (s *System) Chgrp(files []string, groupID string) error {
+	gid, err := strconv.Atoi(groupID)
+	if err != nil {
+		return fmt.Errorf("invalid gid %q", groupID)
+	}
+
+	for _, file := range files {
+		// -1 leaves the owner unchanged
+		if err := os.Lchown(file, -1, gid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
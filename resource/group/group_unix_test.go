@@ -0,0 +1,60 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux darwin
+
+package group
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindGroupFiles(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-group")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "sub", "file"), nil, 0644))
+	require.NoError(t, os.Symlink("/nonexistent", filepath.Join(dir, "link")))
+
+	gid := strconv.Itoa(os.Getgid())
+	s := new(System)
+
+	files, err := s.FindGroupFiles([]string{dir}, gid)
+	require.NoError(t, err)
+	sort.Strings(files)
+	assert.Equal(t, []string{
+		dir,
+		filepath.Join(dir, "link"),
+		filepath.Join(dir, "sub"),
+		filepath.Join(dir, "sub", "file"),
+	}, files)
+
+	// changing the group does not follow the dangling symlink
+	assert.NoError(t, s.Chgrp(files, gid))
+
+	_, err = s.FindGroupFiles([]string{filepath.Join(dir, "missing")}, gid)
+	assert.Error(t, err)
+}
@@ -17,6 +17,7 @@ package group
 import (
 	"fmt"
 	"math"
+	"path/filepath"
 
 	"github.com/asteris-llc/converge/load/registry"
 	"github.com/asteris-llc/converge/resource"
@@ -34,9 +35,36 @@ type Preparer struct {
 	Name string `hcl:"name" required:"true" nonempty:"true"`
 
 	// NewName is used when modifying a group.
-	// The group Name will be changed to NewName.
+	// The group Name will be changed to NewName. If the group already exists
+	// under NewName and Name is gone, no rename is attempted.
 	NewName string `hcl:"new_name" nonempty:"true"`
 
+	// System when set to true adds the group as a system group. It has no
+	// effect on a group that already exists.
+	System bool `hcl:"system"`
+
+	// ForceGIDChange when set to true changes the group of files owned by the
+	// old GID to the new GID when the GID of the group changes. groupmod does
+	// not do this, which leaves those files owned by a GID without a group.
+	// Only files under GIDChangePaths are changed.
+	ForceGIDChange bool `hcl:"force_gid_change"`
+
+	// GIDChangePaths are the absolute paths searched for files owned by the old
+	// GID when ForceGIDChange is set. The search does not cross into other
+	// filesystems.
+	GIDChangePaths []string `hcl:"gid_change_paths"`
+
+	// Members are the users who should be members of the group. Users missing
+	// from the group are added to it. Members can't be used when State is
+	// absent.
+	Members []string `hcl:"members"`
+
+	// StrictMembers when set to true removes users who are not in Members from
+	// the group, so its members are exactly Members. Setting StrictMembers
+	// without Members removes all members from the group. By default, other
+	// members are left in the group.
+	StrictMembers bool `hcl:"strict_members"`
+
 	// State is whether the group should be present.
 	// The default value is present.
 	State State `hcl:"state" valid_values:"present,absent"`
@@ -49,14 +77,53 @@ func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resou
 		return nil, fmt.Errorf("group \"gid\" parameter out of range")
 	}
 
+	if p.ForceGIDChange && len(p.GIDChangePaths) == 0 {
+		return nil, fmt.Errorf("group \"gid_change_paths\" parameter required with \"force_gid_change\" parameter")
+	}
+
+	if len(p.GIDChangePaths) > 0 && !p.ForceGIDChange {
+		return nil, fmt.Errorf("group \"force_gid_change\" parameter required with \"gid_change_paths\" parameter")
+	}
+
+	for _, path := range p.GIDChangePaths {
+		if !filepath.IsAbs(path) {
+			return nil, fmt.Errorf("group \"gid_change_paths\" parameter must contain absolute paths, got %q", path)
+		}
+	}
+
 	if p.State == "" {
 		p.State = StatePresent
 	}
 
+	if p.State == StateAbsent {
+		if len(p.Members) > 0 {
+			return nil, fmt.Errorf("group \"members\" parameter cannot be used with \"state\" absent")
+		}
+		if p.StrictMembers {
+			return nil, fmt.Errorf("group \"strict_members\" parameter cannot be used with \"state\" absent")
+		}
+	}
+
+	seen := make(map[string]bool, len(p.Members))
+	for _, member := range p.Members {
+		if member == "" {
+			return nil, fmt.Errorf("group \"members\" parameter cannot contain an empty name")
+		}
+		if seen[member] {
+			return nil, fmt.Errorf("group \"members\" parameter contains %q more than once", member)
+		}
+		seen[member] = true
+	}
+
 	grp := NewGroup(new(System))
 	grp.Name = p.Name
 	grp.NewName = p.NewName
 	grp.State = p.State
+	grp.IsSystem = p.System
+	grp.ForceGIDChange = p.ForceGIDChange
+	grp.GIDChangePaths = p.GIDChangePaths
+	grp.Members = p.Members
+	grp.StrictMembers = p.StrictMembers
 
 	if p.GID != nil {
 		grp.GID = fmt.Sprintf("%v", *p.GID)
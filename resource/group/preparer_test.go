@@ -51,6 +51,16 @@ func TestPrepare(t *testing.T) {
 			assert.NoError(t, err)
 		})
 
+		t.Run("members", func(t *testing.T) {
+			p := group.Preparer{Name: "test", Members: []string{"alice", "bob"}, StrictMembers: true}
+			task, err := p.Prepare(context.Background(), &fr)
+
+			assert.NoError(t, err)
+			grp := task.(*group.Group)
+			assert.Equal(t, []string{"alice", "bob"}, grp.Members)
+			assert.True(t, grp.StrictMembers)
+		})
+
 		t.Run("no new_name parameter", func(t *testing.T) {
 			p := group.Preparer{GID: &testGID, Name: "test", State: group.StatePresent}
 			_, err := p.Prepare(context.Background(), &fr)
@@ -85,6 +95,20 @@ func TestPrepare(t *testing.T) {
 
 			assert.NoError(t, err)
 		})
+
+		t.Run("system", func(t *testing.T) {
+			p := group.Preparer{Name: "test", System: true}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.NoError(t, err)
+		})
+
+		t.Run("force_gid_change with gid_change_paths", func(t *testing.T) {
+			p := group.Preparer{GID: &testGID, Name: "test", ForceGIDChange: true, GIDChangePaths: []string{"/srv", "/home"}}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.NoError(t, err)
+		})
 	})
 
 	t.Run("invalid", func(t *testing.T) {
@@ -94,5 +118,54 @@ func TestPrepare(t *testing.T) {
 
 			assert.EqualError(t, err, fmt.Sprintf("group \"gid\" parameter out of range"))
 		})
+
+		t.Run("force_gid_change without gid_change_paths", func(t *testing.T) {
+			p := group.Preparer{GID: &testGID, Name: "test", ForceGIDChange: true}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, "group \"gid_change_paths\" parameter required with \"force_gid_change\" parameter")
+		})
+
+		t.Run("gid_change_paths without force_gid_change", func(t *testing.T) {
+			p := group.Preparer{GID: &testGID, Name: "test", GIDChangePaths: []string{"/srv"}}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, "group \"force_gid_change\" parameter required with \"gid_change_paths\" parameter")
+		})
+
+		t.Run("relative gid_change_paths", func(t *testing.T) {
+			p := group.Preparer{GID: &testGID, Name: "test", ForceGIDChange: true, GIDChangePaths: []string{"srv"}}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, "group \"gid_change_paths\" parameter must contain absolute paths, got \"srv\"")
+		})
+
+		t.Run("members with state absent", func(t *testing.T) {
+			p := group.Preparer{Name: "test", Members: []string{"alice"}, State: group.StateAbsent}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, "group \"members\" parameter cannot be used with \"state\" absent")
+		})
+
+		t.Run("strict_members with state absent", func(t *testing.T) {
+			p := group.Preparer{Name: "test", StrictMembers: true, State: group.StateAbsent}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, "group \"strict_members\" parameter cannot be used with \"state\" absent")
+		})
+
+		t.Run("duplicate members", func(t *testing.T) {
+			p := group.Preparer{Name: "test", Members: []string{"alice", "bob", "alice"}}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, "group \"members\" parameter contains \"alice\" more than once")
+		})
+
+		t.Run("empty member", func(t *testing.T) {
+			p := group.Preparer{Name: "test", Members: []string{""}}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, "group \"members\" parameter cannot contain an empty name")
+		})
 	})
 }
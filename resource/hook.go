@@ -0,0 +1,108 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import "golang.org/x/net/context"
+
+// OperationKind is the task method a Hook is called around
+type OperationKind string
+
+const (
+	// OperationCheck is a call to Check
+	OperationCheck OperationKind = "check"
+
+	// OperationApply is a call to Apply
+	OperationApply OperationKind = "apply"
+)
+
+// Operation describes a single Check or Apply of a node
+type Operation struct {
+	Kind OperationKind
+
+	// ID is the ID of the node in the graph
+	ID string
+
+	// Metadata is a copy of the node's metadata
+	Metadata map[string]interface{}
+
+	Task Task
+}
+
+// Hook is called around every Check and Apply of a task. Hooks let
+// integrations like metrics, tracing, auditing, and rate limiting observe or
+// hold back operations without being built into the plan and apply engines.
+type Hook interface {
+	// Before is called before the operation. The returned context is used for
+	// the operation and passed to After. Returning an error skips the
+	// operation, and the error is reported as its result.
+	Before(ctx context.Context, op *Operation) (context.Context, error)
+
+	// After is called once the operation finishes, with its status and error.
+	// It is called even if the operation was skipped by a later hook, but not
+	// if this hook's Before returned an error.
+	After(ctx context.Context, op *Operation, status TaskStatus, err error)
+}
+
+type hooksKey struct{}
+
+// WithHooks returns a context carrying hooks to be called around operations,
+// after any hooks already in ctx
+func WithHooks(ctx context.Context, hooks ...Hook) context.Context {
+	existing, _ := ctx.Value(hooksKey{}).([]Hook)
+
+	combined := make([]Hook, 0, len(existing)+len(hooks))
+	combined = append(combined, existing...)
+	combined = append(combined, hooks...)
+
+	return context.WithValue(ctx, hooksKey{}, combined)
+}
+
+// RunHooks calls fn for an operation, calling the hooks in ctx around it.
+// Before is called on each hook in order and After in the reverse order, so
+// the first hook wraps all the others.
+func RunHooks(ctx context.Context, op *Operation, fn func(context.Context) (TaskStatus, error)) (TaskStatus, error) {
+	hooks, _ := ctx.Value(hooksKey{}).([]Hook)
+	if len(hooks) == 0 {
+		return fn(ctx)
+	}
+
+	var (
+		status TaskStatus
+		err    error
+		ctxs   = make([]context.Context, 0, len(hooks))
+	)
+
+	for _, hook := range hooks {
+		var hookCtx context.Context
+		hookCtx, err = hook.Before(ctx, op)
+		if err != nil {
+			break
+		}
+		if hookCtx != nil {
+			ctx = hookCtx
+		}
+		ctxs = append(ctxs, ctx)
+	}
+
+	if err == nil {
+		status, err = fn(ctx)
+	}
+
+	for i := len(ctxs) - 1; i >= 0; i-- {
+		hooks[i].After(ctxs[i], op, status, err)
+	}
+
+	return status, err
+}
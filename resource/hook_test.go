@@ -0,0 +1,112 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type hookKey string
+
+// recordingHook records the calls made to it in a shared log
+type recordingHook struct {
+	name      string
+	log       *[]string
+	beforeErr error
+}
+
+func (h *recordingHook) Before(ctx context.Context, op *resource.Operation) (context.Context, error) {
+	*h.log = append(*h.log, h.name+" before "+string(op.Kind))
+	if h.beforeErr != nil {
+		return nil, h.beforeErr
+	}
+	return context.WithValue(ctx, hookKey(h.name), true), nil
+}
+
+func (h *recordingHook) After(ctx context.Context, op *resource.Operation, status resource.TaskStatus, err error) {
+	entry := h.name + " after"
+	if ctx.Value(hookKey(h.name)) == nil {
+		entry += " without context"
+	}
+	if err != nil {
+		entry += ": " + err.Error()
+	}
+	*h.log = append(*h.log, entry)
+}
+
+func TestRunHooks(t *testing.T) {
+	t.Parallel()
+
+	op := &resource.Operation{Kind: resource.OperationCheck, ID: "root/task"}
+
+	t.Run("no hooks", func(t *testing.T) {
+		status, err := resource.RunHooks(context.Background(), op, func(context.Context) (resource.TaskStatus, error) {
+			return &resource.Status{Level: resource.StatusWillChange}, nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+	})
+
+	t.Run("order", func(t *testing.T) {
+		var log []string
+		ctx := resource.WithHooks(context.Background(), &recordingHook{name: "outer", log: &log})
+		ctx = resource.WithHooks(ctx, &recordingHook{name: "inner", log: &log})
+
+		_, err := resource.RunHooks(ctx, op, func(ctx context.Context) (resource.TaskStatus, error) {
+			assert.NotNil(t, ctx.Value(hookKey("outer")))
+			assert.NotNil(t, ctx.Value(hookKey("inner")))
+			log = append(log, "check")
+			return &resource.Status{}, errors.New("failed")
+		})
+
+		assert.EqualError(t, err, "failed")
+		assert.Equal(t, []string{
+			"outer before check",
+			"inner before check",
+			"check",
+			"inner after: failed",
+			"outer after: failed",
+		}, log)
+	})
+
+	t.Run("before error", func(t *testing.T) {
+		var log []string
+		ctx := resource.WithHooks(
+			context.Background(),
+			&recordingHook{name: "outer", log: &log},
+			&recordingHook{name: "limit", log: &log, beforeErr: errors.New("rate limited")},
+			&recordingHook{name: "inner", log: &log},
+		)
+
+		status, err := resource.RunHooks(ctx, op, func(context.Context) (resource.TaskStatus, error) {
+			log = append(log, "check")
+			return &resource.Status{}, nil
+		})
+
+		assert.EqualError(t, err, "rate limited")
+		assert.Nil(t, status)
+		assert.Equal(t, []string{
+			"outer before check",
+			"limit before check",
+			"outer after: rate limited",
+		}, log)
+	})
+}
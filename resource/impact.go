@@ -0,0 +1,89 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import "fmt"
+
+// Impact classifies how disruptive a planned change is, so that reviews can
+// focus on the dangerous parts of a plan. Impacts are ordered from least to
+// most disruptive.
+type Impact uint32
+
+const (
+	// ImpactNone means nothing will change
+	ImpactNone Impact = iota
+
+	// ImpactLow is a change that doesn't interrupt anything running on the
+	// system. This is the default for any change.
+	ImpactLow
+
+	// ImpactServiceRestart is a change that restarts or stops a service
+	ImpactServiceRestart
+
+	// ImpactReboot is a change that requires the system to be rebooted
+	ImpactReboot
+
+	// ImpactDestructive is a change that can't be undone. Statuses marked
+	// destructive always have this impact.
+	ImpactDestructive
+)
+
+var impactNames = []string{"none", "low", "service-restart", "reboot", "destructive"}
+
+func (i Impact) String() string {
+	if int(i) < len(impactNames) {
+		return impactNames[i]
+	}
+	return "invalid impact"
+}
+
+// ParseImpact parses the name of an impact, as returned by String
+func ParseImpact(name string) (Impact, error) {
+	for i, candidate := range impactNames {
+		if candidate == name {
+			return Impact(i), nil
+		}
+	}
+	return ImpactNone, fmt.Errorf("%q is not a valid impact", name)
+}
+
+// ImpactReporter is implemented by statuses that classify the impact of their
+// planned changes
+type ImpactReporter interface {
+	Impact() Impact
+}
+
+// StatusImpact gets the impact of a status's planned changes. Statuses that
+// don't classify their changes have ImpactLow if they have changes, or
+// ImpactDestructive if they are destructive.
+func StatusImpact(status TaskStatus) Impact {
+	if status == nil {
+		return ImpactNone
+	}
+
+	if reporter, ok := status.(ImpactReporter); ok {
+		return reporter.Impact()
+	}
+
+	if len(StatusDestructive(status)) > 0 {
+		return ImpactDestructive
+	}
+
+	if status.HasChanges() {
+		return ImpactLow
+	}
+
+	return ImpactNone
+}
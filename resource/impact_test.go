@@ -0,0 +1,83 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImpactString(t *testing.T) {
+	t.Parallel()
+
+	for _, impact := range []resource.Impact{
+		resource.ImpactNone,
+		resource.ImpactLow,
+		resource.ImpactServiceRestart,
+		resource.ImpactReboot,
+		resource.ImpactDestructive,
+	} {
+		parsed, err := resource.ParseImpact(impact.String())
+		require.NoError(t, err)
+		assert.Equal(t, impact, parsed)
+	}
+
+	assert.Equal(t, "service-restart", resource.ImpactServiceRestart.String())
+
+	_, err := resource.ParseImpact("catastrophic")
+	assert.EqualError(t, err, `"catastrophic" is not a valid impact`)
+}
+
+func TestStatusImpact(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no changes", func(t *testing.T) {
+		status := resource.NewStatus()
+		status.RaiseImpact(resource.ImpactReboot)
+
+		assert.Equal(t, resource.ImpactNone, resource.StatusImpact(status))
+	})
+
+	t.Run("default", func(t *testing.T) {
+		status := resource.NewStatus()
+		status.RaiseLevel(resource.StatusWillChange)
+
+		assert.Equal(t, resource.ImpactLow, resource.StatusImpact(status))
+	})
+
+	t.Run("raised", func(t *testing.T) {
+		status := resource.NewStatus()
+		status.RaiseLevel(resource.StatusWillChange)
+		status.RaiseImpact(resource.ImpactReboot)
+		status.RaiseImpact(resource.ImpactServiceRestart)
+
+		assert.Equal(t, resource.ImpactReboot, resource.StatusImpact(status))
+	})
+
+	t.Run("destructive", func(t *testing.T) {
+		status := resource.NewStatus()
+		status.RaiseLevel(resource.StatusWillChange)
+		status.MarkDestructive("user will be deleted")
+
+		assert.Equal(t, resource.ImpactDestructive, resource.StatusImpact(status))
+	})
+
+	t.Run("nil status", func(t *testing.T) {
+		assert.Equal(t, resource.ImpactNone, resource.StatusImpact(nil))
+	})
+}
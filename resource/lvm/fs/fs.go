@@ -136,6 +136,7 @@ func (r *resourceFS) checkBlkid(status *resource.Status) error {
 	} else if fs == "" {
 		r.needMkfs = true
 		status.AddDifference("format", "<unformatted>", r.mount.Type, "")
+		status.MarkDestructive(fmt.Sprintf("%s will be formatted as %s", r.mount.What, r.mount.Type))
 	} else {
 		return fmt.Errorf("%s already contain other filesystem with different type %s", r.mount.What, fs)
 	}
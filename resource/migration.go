@@ -0,0 +1,117 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// Migration upgrades a field written for an older version of a resource's
+// schema, so modules keep working while they are updated. Each migration that
+// applies to a node is reported as a deprecation warning.
+type Migration struct {
+	// Field is the name of the field as it was written
+	Field string
+
+	// RenamedTo is the current name of the field. If it is empty, the field
+	// keeps its name.
+	RenamedTo string
+
+	// Convert changes an old value into one the resource accepts. It gets the
+	// value as it was written, before any templates in it are rendered, and
+	// the migration only applies if the value it returns is different.
+	Convert func(value interface{}) (interface{}, error)
+
+	// Message explains the deprecation. A message naming the old and new forms
+	// is used if it is empty.
+	Message string
+}
+
+// Migrator is implemented by resources whose fields have been renamed or have
+// changed their values. Preparer applies the migrations before reading fields.
+type Migrator interface {
+	Migrations() []Migration
+}
+
+// migrate applies the migrations of the destination to the source, recording
+// a deprecation warning for each one that applied. Migrating the same source
+// again applies nothing.
+func (p *Preparer) migrate() error {
+	migrator, ok := p.Destination.(Migrator)
+	if !ok {
+		return nil
+	}
+
+	for _, migration := range migrator.Migrations() {
+		value, ok := p.Source[migration.Field]
+		if !ok {
+			continue
+		}
+
+		name := migration.Field
+		var messages []string
+
+		if migration.RenamedTo != "" && migration.RenamedTo != name {
+			if _, set := p.Source[migration.RenamedTo]; set {
+				return p.atFieldPosition(name, fmt.Errorf("%q and %q are both set, but %q is the deprecated name of %q", name, migration.RenamedTo, name, migration.RenamedTo))
+			}
+
+			delete(p.Source, name)
+			p.Source[migration.RenamedTo] = value
+			if pos, ok := p.FieldPositions[name]; ok {
+				p.FieldPositions[migration.RenamedTo] = pos
+			}
+
+			messages = append(messages, fmt.Sprintf("%q is deprecated, use %q instead", name, migration.RenamedTo))
+			name = migration.RenamedTo
+		}
+
+		if migration.Convert != nil {
+			converted, err := migration.Convert(value)
+			if err != nil {
+				return p.atFieldPosition(name, errors.Wrapf(err, "could not migrate %q", name))
+			}
+			if !reflect.DeepEqual(converted, value) {
+				p.Source[name] = converted
+				messages = append(messages, fmt.Sprintf("%s = %#v is deprecated, use %#v instead", name, value, converted))
+			}
+		}
+
+		if len(messages) == 0 {
+			continue
+		}
+		if migration.Message != "" {
+			messages = []string{migration.Message}
+		}
+
+		for _, message := range messages {
+			if pos, ok := p.FieldPositions[name]; ok {
+				message = pos + ": " + message
+			}
+			p.deprecations = append(p.deprecations, Warning{Kind: WarningDeprecation, Message: message})
+		}
+	}
+
+	return nil
+}
+
+// Warnings returns a deprecation warning for each migration applied while
+// preparing
+func (p *Preparer) Warnings() []Warning {
+	return p.deprecations
+}
@@ -0,0 +1,136 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/helpers/faketask"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// migratingResource renamed "destination" to "dest" and changed the value
+// "started" of "state" to "running"
+type migratingResource struct {
+	Dest  string `hcl:"dest"`
+	State string `hcl:"state"`
+}
+
+func (m *migratingResource) Prepare(context.Context, resource.Renderer) (resource.Task, error) {
+	return faketask.NoOp(), nil
+}
+
+func (m *migratingResource) Migrations() []resource.Migration {
+	return []resource.Migration{
+		{Field: "destination", RenamedTo: "dest"},
+		{
+			Field: "state",
+			Convert: func(value interface{}) (interface{}, error) {
+				switch value {
+				case "started":
+					return "running", nil
+				case "broken":
+					return nil, errors.New("no longer supported")
+				}
+				return value, nil
+			},
+		},
+		{Field: "path", RenamedTo: "dest", Message: "path was renamed to dest in 0.5.0"},
+	}
+}
+
+func TestPreparerMigrations(t *testing.T) {
+	t.Parallel()
+
+	t.Run("rename", func(t *testing.T) {
+		res := new(migratingResource)
+		prep := resource.NewPreparerWithSource(res, map[string]interface{}{"destination": "/tmp/x"})
+		prep.FieldPositions = map[string]string{"destination": "test.hcl:2:3"}
+
+		_, err := prep.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, "/tmp/x", res.Dest)
+		assert.Equal(
+			t,
+			[]resource.Warning{{Kind: resource.WarningDeprecation, Message: `test.hcl:2:3: "destination" is deprecated, use "dest" instead`}},
+			prep.Warnings(),
+		)
+
+		// preparing again doesn't warn again
+		_, err = prep.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, 1, len(prep.Warnings()))
+	})
+
+	t.Run("convert", func(t *testing.T) {
+		res := new(migratingResource)
+		prep := resource.NewPreparerWithSource(res, map[string]interface{}{"state": "started"})
+
+		_, err := prep.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, "running", res.State)
+		assert.Equal(
+			t,
+			[]resource.Warning{{Kind: resource.WarningDeprecation, Message: `state = "started" is deprecated, use "running" instead`}},
+			prep.Warnings(),
+		)
+	})
+
+	t.Run("current", func(t *testing.T) {
+		res := new(migratingResource)
+		prep := resource.NewPreparerWithSource(res, map[string]interface{}{"dest": "/tmp/x", "state": "running"})
+
+		_, err := prep.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Empty(t, prep.Warnings())
+	})
+
+	t.Run("message", func(t *testing.T) {
+		res := new(migratingResource)
+		prep := resource.NewPreparerWithSource(res, map[string]interface{}{"path": "/tmp/x"})
+
+		_, err := prep.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, "/tmp/x", res.Dest)
+		assert.Equal(t, "path was renamed to dest in 0.5.0", prep.Warnings()[0].Message)
+	})
+
+	t.Run("both names", func(t *testing.T) {
+		prep := resource.NewPreparerWithSource(new(migratingResource), map[string]interface{}{"destination": "/tmp/x", "dest": "/tmp/y"})
+
+		_, err := prep.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, `"destination" and "dest" are both set, but "destination" is the deprecated name of "dest"`)
+	})
+
+	t.Run("convert error", func(t *testing.T) {
+		prep := resource.NewPreparerWithSource(new(migratingResource), map[string]interface{}{"state": "broken"})
+
+		_, err := prep.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, `could not migrate "state": no longer supported`)
+	})
+
+	t.Run("no migrations", func(t *testing.T) {
+		prep := resource.NewPreparerWithSource(noOpResource{}, map[string]interface{}{})
+
+		_, err := prep.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Empty(t, prep.Warnings())
+	})
+}
@@ -42,6 +42,16 @@ func (m *Module) Apply(context.Context) (resource.TaskStatus, error) {
 	return m, nil
 }
 
+// IsModule reports whether a node's value, or the task it wraps, is a module
+func IsModule(val interface{}) bool {
+	task, ok := resource.ResolveTask(val)
+	if !ok {
+		return false
+	}
+	_, ok = task.(*Module)
+	return ok
+}
+
 // String is the final value of thie Module
 func (m *Module) String() string {
 	var lines []string
@@ -0,0 +1,196 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mount
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// FstabPath is the file filesystems are persisted in
+	FstabPath = "/etc/fstab"
+
+	// fstabMode is the mode of a new fstab
+	fstabMode os.FileMode = 0644
+)
+
+// Entry is a filesystem mounted on a mountpoint, as listed in fstab or the
+// mount table
+type Entry struct {
+	Device     string
+	Mountpoint string
+	Fstype     string
+	Options    []string
+	Dump       int
+	Pass       int
+}
+
+// String formats the entry as a line of fstab
+func (e *Entry) String() string {
+	return fmt.Sprintf(
+		"%s %s %s %s %d %d",
+		escape(e.Device),
+		escape(e.Mountpoint),
+		e.Fstype,
+		strings.Join(e.Options, ","),
+		e.Dump,
+		e.Pass,
+	)
+}
+
+// formatEntry formats an entry for a diff, with a missing entry as <absent>
+func formatEntry(e *Entry) string {
+	if e == nil {
+		return "<absent>"
+	}
+	return e.String()
+}
+
+// fstabLine is a line of fstab. Lines that aren't entries, like comments, are
+// kept as they are.
+type fstabLine struct {
+	text  string
+	entry *Entry
+}
+
+// parseEntry parses the whitespace separated fields of an fstab or mount table
+// line. The dump and pass fields may be left out.
+func parseEntry(text string) (*Entry, bool) {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return nil, false
+	}
+
+	fields := strings.Fields(trimmed)
+	if len(fields) < 4 || len(fields) > 6 {
+		return nil, false
+	}
+
+	entry := &Entry{
+		Device:     unescape(fields[0]),
+		Mountpoint: unescape(fields[1]),
+		Fstype:     fields[2],
+		Options:    strings.Split(fields[3], ","),
+	}
+
+	for i, dest := range []*int{&entry.Dump, &entry.Pass} {
+		if len(fields) <= 4+i {
+			break
+		}
+		n, err := strconv.Atoi(fields[4+i])
+		if err != nil {
+			return nil, false
+		}
+		*dest = n
+	}
+
+	return entry, true
+}
+
+// readFstab reads an fstab, along with its mode. A missing file has no lines.
+func readFstab(path string) ([]fstabLine, os.FileMode, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, fstabMode, nil
+	} else if err != nil {
+		return nil, 0, errors.Wrapf(err, "cannot read %s", path)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "cannot read %s", path)
+	}
+
+	var lines []fstabLine
+	if len(content) > 0 {
+		for _, text := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+			entry, _ := parseEntry(text)
+			lines = append(lines, fstabLine{text: text, entry: entry})
+		}
+	}
+
+	return lines, info.Mode().Perm(), nil
+}
+
+// findEntry returns the entry for a mountpoint. When there is more than one,
+// the first is used.
+func findEntry(lines []fstabLine, mountpoint string) *Entry {
+	for _, l := range lines {
+		if l.entry != nil && filepath.Clean(l.entry.Mountpoint) == mountpoint {
+			return l.entry
+		}
+	}
+	return nil
+}
+
+// updateFstab returns the lines of fstab with the entries for a mountpoint
+// replaced by desired, or removed when desired is nil. The new entry takes
+// the place of the first existing one, or goes at the end of the file.
+func updateFstab(lines []fstabLine, mountpoint string, desired *Entry) []string {
+	var out []string
+	inserted := desired == nil
+	for _, l := range lines {
+		if l.entry != nil && filepath.Clean(l.entry.Mountpoint) == mountpoint {
+			if !inserted {
+				out = append(out, desired.String())
+				inserted = true
+			}
+			continue
+		}
+		out = append(out, l.text)
+	}
+	if !inserted {
+		out = append(out, desired.String())
+	}
+	return out
+}
+
+// escape encodes the characters fstab and the mount table can't contain in a
+// field as octal escapes
+func escape(field string) string {
+	var out []byte
+	for i := 0; i < len(field); i++ {
+		switch c := field[i]; c {
+		case ' ', '\t', '\n', '\\', '#':
+			out = append(out, []byte(fmt.Sprintf("\\%03o", c))...)
+		default:
+			out = append(out, c)
+		}
+	}
+	return string(out)
+}
+
+// unescape decodes the octal escapes in a field of fstab or the mount table
+func unescape(field string) string {
+	var out []byte
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if n, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				out = append(out, byte(n))
+				i += 3
+				continue
+			}
+		}
+		out = append(out, field[i])
+	}
+	return string(out)
+}
@@ -0,0 +1,83 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mount
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEntry(t *testing.T) {
+	t.Parallel()
+
+	t.Run("full", func(t *testing.T) {
+		entry, ok := parseEntry("UUID=abc  /srv/my\\040data\text4 noatime,nofail 0 2")
+		require.True(t, ok)
+		assert.Equal(t, &Entry{
+			Device:     "UUID=abc",
+			Mountpoint: "/srv/my data",
+			Fstype:     "ext4",
+			Options:    []string{"noatime", "nofail"},
+			Pass:       2,
+		}, entry)
+		assert.Equal(t, "UUID=abc /srv/my\\040data ext4 noatime,nofail 0 2", entry.String())
+	})
+
+	t.Run("without dump and pass", func(t *testing.T) {
+		entry, ok := parseEntry("tmpfs /tmp tmpfs rw")
+		require.True(t, ok)
+		assert.Equal(t, 0, entry.Pass)
+	})
+
+	for _, text := range []string{"", "  # comment", "/dev/sda1 /", "/dev/sda1 / ext4 rw zero 1"} {
+		t.Run("not an entry: "+text, func(t *testing.T) {
+			_, ok := parseEntry(text)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestUpdateFstab(t *testing.T) {
+	t.Parallel()
+
+	var lines []fstabLine
+	for _, text := range []string{"# root", "/dev/sda1 / ext4 defaults 0 1", "/dev/sdb1 /srv ext4 defaults 0 2", "/dev/sdc1 /srv/ xfs defaults 0 2"} {
+		entry, _ := parseEntry(text)
+		lines = append(lines, fstabLine{text: text, entry: entry})
+	}
+
+	desired := &Entry{Device: "/dev/sdd1", Mountpoint: "/srv", Fstype: "xfs", Options: []string{"noatime"}}
+
+	t.Run("replace", func(t *testing.T) {
+		assert.Equal(
+			t,
+			[]string{"# root", "/dev/sda1 / ext4 defaults 0 1", "/dev/sdd1 /srv xfs noatime 0 0"},
+			updateFstab(lines, "/srv", desired),
+		)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		assert.Equal(t, []string{"# root", "/dev/sda1 / ext4 defaults 0 1"}, updateFstab(lines, "/srv", nil))
+	})
+
+	t.Run("append", func(t *testing.T) {
+		desired := &Entry{Device: "tmpfs", Mountpoint: "/run/x", Fstype: "tmpfs", Options: []string{"size=10m"}}
+		out := updateFstab(lines, "/run/x", desired)
+		require.Len(t, out, 5)
+		assert.Equal(t, "tmpfs /run/x tmpfs size=10m 0 0", out[4])
+	})
+}
@@ -0,0 +1,351 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mount
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/atomicfile"
+	"github.com/asteris-llc/converge/resource"
+	"golang.org/x/net/context"
+)
+
+// State type for Mount
+type State string
+
+const (
+	// StateMounted indicates the filesystem should be mounted and in fstab
+	StateMounted State = "mounted"
+
+	// StateUnmounted indicates the filesystem should be in fstab but not
+	// mounted
+	StateUnmounted State = "unmounted"
+
+	// StateAbsent indicates the filesystem should be neither mounted nor in
+	// fstab
+	StateAbsent State = "absent"
+)
+
+// fstabOnly are the options that only mean something in fstab, and aren't
+// shown in the mount table
+var fstabOnly = map[string]bool{
+	"defaults": true,
+	"auto":     true,
+	"noauto":   true,
+	"nofail":   true,
+	"user":     true,
+	"nouser":   true,
+	"users":    true,
+	"owner":    true,
+	"group":    true,
+	"_netdev":  true,
+}
+
+// clearingOptions are the flags the mount table shows when they're set, and
+// the options which clear them. The first is passed on a remount when a flag
+// is no longer configured.
+var clearingOptions = map[string][]string{
+	"nodev":      {"dev"},
+	"nosuid":     {"suid"},
+	"noexec":     {"exec"},
+	"noatime":    {"atime", "relatime", "strictatime"},
+	"nodiratime": {"diratime"},
+	"sync":       {"async"},
+	"mand":       {"nomand"},
+	"lazytime":   {"nolazytime"},
+}
+
+// impliedOptions are the flags set by fstab-only options
+var impliedOptions = map[string][]string{
+	"user":  {"noexec", "nosuid", "nodev"},
+	"users": {"noexec", "nosuid", "nodev"},
+	"owner": {"nosuid", "nodev"},
+	"group": {"nosuid", "nodev"},
+}
+
+// Mount manages a mounted filesystem and its fstab entry
+type Mount struct {
+	// the mounted device
+	Device string `export:"device"`
+
+	// the directory the device is mounted on
+	Mountpoint string `export:"mountpoint"`
+
+	// the type of the filesystem
+	Fstype string `export:"fstype"`
+
+	// the mount options
+	Options []string `export:"options"`
+
+	// the dump field of the fstab entry
+	Dump int `export:"dump"`
+
+	// the pass field of the fstab entry
+	Pass int `export:"pass"`
+
+	// whether the filesystem should be mounted
+	State State `export:"state"`
+
+	fstab  string
+	system SystemUtils
+}
+
+// SystemUtils mounts and unmounts filesystems
+type SystemUtils interface {
+	// Mounted returns what is mounted on a mountpoint, or nil if nothing is
+	Mounted(mountpoint string) (*Entry, error)
+	Mount(entry *Entry) error
+	Remount(entry *Entry) error
+	Unmount(mountpoint string) error
+
+	// SameDevice tests whether two names refer to the same device, such as a
+	// UUID and the device node it belongs to
+	SameDevice(a, b string) bool
+}
+
+// ErrUnsupported is used when a system is not supported
+var ErrUnsupported = fmt.Errorf("mount: not supported on this system")
+
+// plan is what needs to change to get to the configured state
+type plan struct {
+	lines     []fstabLine
+	mode      os.FileMode
+	current   *Entry
+	desired   *Entry
+	mounted   *Entry
+	unmount   bool
+	mount     bool
+	remount   bool
+	fstabDiff bool
+
+	// cleared are the options which clear flags that are no longer configured
+	cleared []string
+}
+
+// Claims returns fstab and the mountpoint
+func (m *Mount) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimPath(m.fstab), resource.ClaimPath(m.Mountpoint)}
+}
+
+// Check whether the filesystem is mounted and persisted as configured
+func (m *Mount) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	p, err := m.plan()
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+
+	if p.fstabDiff {
+		status.AddDifference("fstab", formatEntry(p.current), formatEntry(p.desired), "")
+	}
+
+	switch {
+	case p.mounted == nil && p.mount:
+		status.AddDifference("state", string(StateUnmounted), string(StateMounted), "")
+
+	case p.mounted != nil && p.unmount && !p.mount:
+		status.AddDifference("state", string(StateMounted), string(StateUnmounted), "")
+		status.RaiseImpact(resource.ImpactServiceRestart)
+
+	case p.mounted != nil:
+		if !m.system.SameDevice(p.mounted.Device, m.Device) {
+			status.AddDifference("device", p.mounted.Device, m.Device, "")
+		}
+		if m.Fstype != "auto" && p.mounted.Fstype != m.Fstype {
+			status.AddDifference("fstype", p.mounted.Fstype, m.Fstype, "")
+		}
+		if p.remount || p.mount {
+			if len(missingOptions(m.Options, p.mounted.Options)) > 0 || len(p.cleared) > 0 {
+				status.AddDifference("options", strings.Join(p.mounted.Options, ","), strings.Join(m.Options, ","), "")
+			}
+			status.RaiseImpact(resource.ImpactServiceRestart)
+		}
+	}
+
+	status.RaiseLevelForDiffs()
+	return status, nil
+}
+
+// Apply mounts or unmounts the filesystem and updates fstab
+func (m *Mount) Apply(context.Context) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	p, err := m.plan()
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+
+	if p.fstabDiff {
+		if err := atomicfile.WriteLines(m.fstab, updateFstab(p.lines, m.Mountpoint, p.desired), p.mode); err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			return status, err
+		}
+		if p.desired == nil {
+			status.AddMessage(fmt.Sprintf("removed %s from %s", m.Mountpoint, m.fstab))
+		} else {
+			status.AddMessage(fmt.Sprintf("set %s in %s", m.Mountpoint, m.fstab))
+		}
+	}
+
+	if p.unmount {
+		if err := m.system.Unmount(m.Mountpoint); err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			return status, err
+		}
+		status.AddMessage(fmt.Sprintf("unmounted %s", m.Mountpoint))
+	}
+
+	entry := m.entry()
+	switch {
+	case p.mount:
+		if err := m.system.Mount(entry); err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			return status, err
+		}
+		status.AddMessage(fmt.Sprintf("mounted %s on %s", m.Device, m.Mountpoint))
+
+	case p.remount:
+		entry.Options = append(append([]string{}, m.Options...), p.cleared...)
+		if err := m.system.Remount(entry); err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			return status, err
+		}
+		status.AddMessage(fmt.Sprintf("remounted %s with %s", m.Mountpoint, strings.Join(entry.Options, ",")))
+	}
+
+	return status, nil
+}
+
+// plan compares fstab and the mount table with the configuration
+func (m *Mount) plan() (*plan, error) {
+	lines, mode, err := readFstab(m.fstab)
+	if err != nil {
+		return nil, err
+	}
+
+	mounted, err := m.system.Mounted(m.Mountpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &plan{
+		lines:   lines,
+		mode:    mode,
+		current: findEntry(lines, m.Mountpoint),
+		mounted: mounted,
+	}
+	if m.State != StateAbsent {
+		p.desired = m.entry()
+	}
+	p.fstabDiff = !sameEntry(p.current, p.desired)
+
+	switch {
+	case m.State != StateMounted:
+		p.unmount = mounted != nil
+
+	case mounted == nil:
+		p.mount = true
+
+	case !m.system.SameDevice(mounted.Device, m.Device) || (m.Fstype != "auto" && mounted.Fstype != m.Fstype):
+		// a different filesystem is mounted here, so it has to be replaced
+		p.unmount, p.mount = true, true
+
+	default:
+		p.cleared = clearedOptions(m.Options, mounted.Options)
+		p.remount = len(missingOptions(m.Options, mounted.Options)) > 0 || len(p.cleared) > 0
+	}
+
+	return p, nil
+}
+
+// entry is the configured fstab entry
+func (m *Mount) entry() *Entry {
+	return &Entry{
+		Device:     m.Device,
+		Mountpoint: m.Mountpoint,
+		Fstype:     m.Fstype,
+		Options:    m.Options,
+		Dump:       m.Dump,
+		Pass:       m.Pass,
+	}
+}
+
+// sameEntry tests whether two fstab entries are the same, ignoring spacing
+func sameEntry(a, b *Entry) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.String() == b.String()
+}
+
+// missingOptions returns the configured options that aren't in the mount
+// table. A filesystem is read-write unless it's configured to be read-only.
+func missingOptions(desired, mounted []string) []string {
+	have := map[string]bool{}
+	for _, opt := range mounted {
+		have[opt] = true
+	}
+
+	var missing []string
+	access := "rw"
+	for _, opt := range desired {
+		switch {
+		case opt == "ro" || opt == "rw":
+			access = opt
+		case fstabOnly[opt], strings.HasPrefix(opt, "x-"), strings.HasPrefix(opt, "comment="):
+		case !have[opt]:
+			missing = append(missing, opt)
+		}
+	}
+	if !have[access] {
+		missing = append(missing, access)
+	}
+
+	return missing
+}
+
+// clearedOptions returns the options which clear flags the mount has but the
+// configuration doesn't, like dev for a filesystem mounted nodev. Only the
+// flags in clearingOptions are known; other options that are removed stay set
+// until the filesystem is mounted again.
+func clearedOptions(desired, mounted []string) []string {
+	set := map[string]bool{}
+	for _, opt := range desired {
+		for _, implied := range impliedOptions[opt] {
+			set[implied] = true
+		}
+		for flag, clearing := range clearingOptions {
+			for _, clear := range clearing {
+				if opt == clear {
+					delete(set, flag)
+				}
+			}
+		}
+		set[opt] = true
+	}
+
+	var cleared []string
+	for _, opt := range mounted {
+		if clearing, ok := clearingOptions[opt]; ok && !set[opt] {
+			cleared = append(cleared, clearing[0])
+		}
+	}
+	return cleared
+}
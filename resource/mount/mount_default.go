@@ -0,0 +1,45 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !linux
+
+package mount
+
+// System implements SystemUtils
+type System struct{}
+
+// Mounted implementation for systems which are not supported
+func (s *System) Mounted(mountpoint string) (*Entry, error) {
+	return nil, ErrUnsupported
+}
+
+// Mount implementation for systems which are not supported
+func (s *System) Mount(entry *Entry) error {
+	return ErrUnsupported
+}
+
+// Remount implementation for systems which are not supported
+func (s *System) Remount(entry *Entry) error {
+	return ErrUnsupported
+}
+
+// Unmount implementation for systems which are not supported
+func (s *System) Unmount(mountpoint string) error {
+	return ErrUnsupported
+}
+
+// SameDevice implementation for systems which are not supported
+func (s *System) SameDevice(a, b string) bool {
+	return a == b
+}
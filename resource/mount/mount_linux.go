@@ -0,0 +1,110 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package mount
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// mountTable lists the filesystems mounted in this process' namespace
+const mountTable = "/proc/self/mounts"
+
+// deviceLinks are the directories the udev links for device tags are in
+var deviceLinks = map[string]string{
+	"UUID":      "/dev/disk/by-uuid",
+	"LABEL":     "/dev/disk/by-label",
+	"PARTUUID":  "/dev/disk/by-partuuid",
+	"PARTLABEL": "/dev/disk/by-partlabel",
+}
+
+// System implements SystemUtils
+type System struct{}
+
+// Mounted returns the filesystem mounted on a mountpoint. When several are
+// mounted on it, the last one hides the others and is returned.
+func (s *System) Mounted(mountpoint string) (*Entry, error) {
+	content, err := ioutil.ReadFile(mountTable)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", mountTable)
+	}
+
+	var mounted *Entry
+	for _, text := range strings.Split(string(content), "\n") {
+		if entry, ok := parseEntry(text); ok && entry.Mountpoint == mountpoint {
+			mounted = entry
+		}
+	}
+	return mounted, nil
+}
+
+// Mount mounts a filesystem
+func (s *System) Mount(entry *Entry) error {
+	return run("mount", "-t", entry.Fstype, "-o", strings.Join(entry.Options, ","), entry.Device, entry.Mountpoint)
+}
+
+// Remount changes the options of a mounted filesystem
+func (s *System) Remount(entry *Entry) error {
+	return run("mount", "-o", "remount,"+strings.Join(entry.Options, ","), entry.Mountpoint)
+}
+
+// Unmount unmounts the filesystem on a mountpoint
+func (s *System) Unmount(mountpoint string) error {
+	return run("umount", mountpoint)
+}
+
+// SameDevice tests whether two names refer to the same device, following
+// device tags like UUID= and symlinks like the ones in /dev/mapper
+func (s *System) SameDevice(a, b string) bool {
+	return a == b || resolveDevice(a) == resolveDevice(b)
+}
+
+// resolveDevice finds the device node a name refers to. Names that aren't
+// paths, like NFS exports, are returned as they are.
+func resolveDevice(name string) string {
+	if parts := strings.SplitN(name, "=", 2); len(parts) == 2 {
+		if dir, ok := deviceLinks[parts[0]]; ok {
+			name = filepath.Join(dir, parts[1])
+		}
+	}
+
+	if !filepath.IsAbs(name) {
+		return name
+	}
+	if resolved, err := filepath.EvalSymlinks(name); err == nil {
+		return resolved
+	}
+	return name
+}
+
+// run runs a command, including its output in the error when it fails
+func run(name string, args ...string) error {
+	var out bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %s: %s", name, err, strings.TrimSpace(out.String()))
+	}
+	return nil
+}
@@ -0,0 +1,386 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mount
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// fakeSystem is a mount table with a single mountpoint
+type fakeSystem struct {
+	mounted *Entry
+	calls   []string
+	err     error
+}
+
+func (f *fakeSystem) Mounted(string) (*Entry, error) { return f.mounted, f.err }
+
+func (f *fakeSystem) Mount(entry *Entry) error {
+	f.calls = append(f.calls, "mount "+entry.Device)
+	f.mounted = entry
+	return nil
+}
+
+func (f *fakeSystem) Remount(entry *Entry) error {
+	f.calls = append(f.calls, "remount")
+	f.mounted.Options = entry.Options
+	return nil
+}
+
+func (f *fakeSystem) Unmount(string) error {
+	f.calls = append(f.calls, "unmount")
+	f.mounted = nil
+	return nil
+}
+
+func (f *fakeSystem) SameDevice(a, b string) bool {
+	return a == b || (a == "/dev/sdb1" && b == "UUID=abc")
+}
+
+// newTestMount creates a Mount of /dev/sdb1 on /srv, with an fstab of the
+// given content in a temporary directory. Call the returned func to remove it.
+func newTestMount(t *testing.T, content string, state State, mounted *Entry) (*Mount, *fakeSystem, func()) {
+	dir, err := ioutil.TempDir("", "converge-mount")
+	require.NoError(t, err)
+	cleanup := func() { os.RemoveAll(dir) }
+
+	path := filepath.Join(dir, "fstab")
+	if content != "" {
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0640))
+	}
+
+	system := &fakeSystem{mounted: mounted}
+	return &Mount{
+		Device:     "/dev/sdb1",
+		Mountpoint: "/srv",
+		Fstype:     "ext4",
+		Options:    []string{"noatime", "nofail"},
+		Pass:       2,
+		State:      state,
+		fstab:      path,
+		system:     system,
+	}, system, cleanup
+}
+
+func TestMountCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("not mounted", func(t *testing.T) {
+		m, _, cleanup := newTestMount(t, "", StateMounted, nil)
+		defer cleanup()
+
+		status, err := m.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "<absent>", status.Diffs()["fstab"].Original())
+		assert.Equal(t, "/dev/sdb1 /srv ext4 noatime,nofail 0 2", status.Diffs()["fstab"].Current())
+		assert.Equal(t, "unmounted", status.Diffs()["state"].Original())
+		assert.Equal(t, "mounted", status.Diffs()["state"].Current())
+	})
+
+	t.Run("mounted as configured", func(t *testing.T) {
+		m, _, cleanup := newTestMount(
+			t,
+			"# data\n/dev/sdb1\t/srv\text4\tnoatime,nofail\t0\t2\n",
+			StateMounted,
+			&Entry{Device: "/dev/sdb1", Mountpoint: "/srv", Fstype: "ext4", Options: []string{"rw", "noatime", "data=ordered"}},
+		)
+		defer cleanup()
+
+		status, err := m.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("device tag", func(t *testing.T) {
+		m, _, cleanup := newTestMount(
+			t,
+			"UUID=abc /srv ext4 noatime,nofail 0 2\n",
+			StateMounted,
+			&Entry{Device: "/dev/sdb1", Mountpoint: "/srv", Fstype: "ext4", Options: []string{"rw", "noatime"}},
+		)
+		defer cleanup()
+		m.Device = "UUID=abc"
+
+		status, err := m.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("options changed", func(t *testing.T) {
+		m, _, cleanup := newTestMount(
+			t,
+			"/dev/sdb1 /srv ext4 defaults 0 2\n",
+			StateMounted,
+			&Entry{Device: "/dev/sdb1", Mountpoint: "/srv", Fstype: "ext4", Options: []string{"rw", "relatime"}},
+		)
+		defer cleanup()
+
+		status, err := m.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		assert.Equal(t, "/dev/sdb1 /srv ext4 defaults 0 2", status.Diffs()["fstab"].Original())
+		assert.Equal(t, "rw,relatime", status.Diffs()["options"].Original())
+		assert.Equal(t, "noatime,nofail", status.Diffs()["options"].Current())
+		assert.NotContains(t, status.Diffs(), "device")
+		assert.Equal(t, resource.ImpactServiceRestart, resource.StatusImpact(status))
+	})
+
+	t.Run("flag removed", func(t *testing.T) {
+		m, _, cleanup := newTestMount(
+			t,
+			"/dev/sdb1 /srv ext4 noatime,nofail 0 2\n",
+			StateMounted,
+			&Entry{Device: "/dev/sdb1", Mountpoint: "/srv", Fstype: "ext4", Options: []string{"rw", "nodev", "noatime"}},
+		)
+		defer cleanup()
+
+		status, err := m.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		assert.Equal(t, "rw,nodev,noatime", status.Diffs()["options"].Original())
+		assert.Equal(t, "noatime,nofail", status.Diffs()["options"].Current())
+	})
+
+	t.Run("read-only", func(t *testing.T) {
+		m, _, cleanup := newTestMount(
+			t,
+			"",
+			StateMounted,
+			&Entry{Device: "/dev/sdb1", Mountpoint: "/srv", Fstype: "ext4", Options: []string{"rw", "noatime"}},
+		)
+		defer cleanup()
+		m.Options = []string{"ro", "noatime"}
+
+		status, err := m.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		assert.Equal(t, "ro,noatime", status.Diffs()["options"].Current())
+	})
+
+	t.Run("other device", func(t *testing.T) {
+		m, _, cleanup := newTestMount(
+			t,
+			"",
+			StateMounted,
+			&Entry{Device: "/dev/sdc1", Mountpoint: "/srv", Fstype: "xfs", Options: []string{"rw", "noatime"}},
+		)
+		defer cleanup()
+
+		status, err := m.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		assert.Equal(t, "/dev/sdc1", status.Diffs()["device"].Original())
+		assert.Equal(t, "/dev/sdb1", status.Diffs()["device"].Current())
+		assert.Equal(t, "xfs", status.Diffs()["fstype"].Original())
+		assert.Equal(t, "ext4", status.Diffs()["fstype"].Current())
+	})
+
+	t.Run("unmounted", func(t *testing.T) {
+		m, _, cleanup := newTestMount(
+			t,
+			"/dev/sdb1 /srv ext4 noatime,nofail 0 2\n",
+			StateUnmounted,
+			&Entry{Device: "/dev/sdb1", Mountpoint: "/srv", Fstype: "ext4", Options: []string{"rw", "noatime"}},
+		)
+		defer cleanup()
+
+		status, err := m.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		assert.NotContains(t, status.Diffs(), "fstab")
+		assert.Equal(t, "mounted", status.Diffs()["state"].Original())
+		assert.Equal(t, "unmounted", status.Diffs()["state"].Current())
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		m, _, cleanup := newTestMount(t, "/dev/sdb1 /srv ext4 noatime,nofail 0 2\n", StateAbsent, nil)
+		defer cleanup()
+
+		status, err := m.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		assert.Equal(t, "<absent>", status.Diffs()["fstab"].Current())
+		assert.NotContains(t, status.Diffs(), "state")
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		m, system, cleanup := newTestMount(t, "", StateMounted, nil)
+		defer cleanup()
+		system.err = ErrUnsupported
+
+		status, err := m.Check(context.Background(), fakerenderer.New())
+		assert.Equal(t, ErrUnsupported, err)
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+}
+
+func TestMountApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("mount", func(t *testing.T) {
+		m, system, cleanup := newTestMount(t, "/dev/sda1 / ext4 defaults 0 1\n", StateMounted, nil)
+		defer cleanup()
+
+		_, err := m.Apply(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"mount /dev/sdb1"}, system.calls)
+		content, err := ioutil.ReadFile(m.fstab)
+		require.NoError(t, err)
+		assert.Equal(t, "/dev/sda1 / ext4 defaults 0 1\n/dev/sdb1 /srv ext4 noatime,nofail 0 2\n", string(content))
+
+		info, err := os.Stat(m.fstab)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	})
+
+	t.Run("remount", func(t *testing.T) {
+		m, system, cleanup := newTestMount(
+			t,
+			"/dev/sdb1 /srv ext4 noatime,nofail 0 2\n",
+			StateMounted,
+			&Entry{Device: "/dev/sdb1", Mountpoint: "/srv", Fstype: "ext4", Options: []string{"rw", "relatime"}},
+		)
+		defer cleanup()
+
+		status, err := m.Apply(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"remount"}, system.calls)
+		assert.Equal(t, []string{"remounted /srv with noatime,nofail"}, status.Messages())
+	})
+
+	t.Run("remount clears removed flags", func(t *testing.T) {
+		m, system, cleanup := newTestMount(
+			t,
+			"/dev/sdb1 /srv ext4 noatime,nofail 0 2\n",
+			StateMounted,
+			&Entry{Device: "/dev/sdb1", Mountpoint: "/srv", Fstype: "ext4", Options: []string{"rw", "nosuid", "noexec", "noatime"}},
+		)
+		defer cleanup()
+
+		status, err := m.Apply(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"remount"}, system.calls)
+		assert.Equal(t, []string{"noatime", "nofail", "suid", "exec"}, system.mounted.Options)
+		assert.Equal(t, []string{"remounted /srv with noatime,nofail,suid,exec"}, status.Messages())
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		m, system, cleanup := newTestMount(
+			t,
+			"",
+			StateMounted,
+			&Entry{Device: "/dev/sdc1", Mountpoint: "/srv", Fstype: "ext4", Options: []string{"rw", "noatime"}},
+		)
+		defer cleanup()
+
+		_, err := m.Apply(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"unmount", "mount /dev/sdb1"}, system.calls)
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		m, system, cleanup := newTestMount(
+			t,
+			"# data\n/dev/sdb1 /srv ext4 noatime 0 2\n",
+			StateAbsent,
+			&Entry{Device: "/dev/sdb1", Mountpoint: "/srv", Fstype: "ext4", Options: []string{"rw", "noatime"}},
+		)
+		defer cleanup()
+
+		status, err := m.Apply(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"unmount"}, system.calls)
+		assert.Equal(t, []string{"removed /srv from " + m.fstab, "unmounted /srv"}, status.Messages())
+		content, err := ioutil.ReadFile(m.fstab)
+		require.NoError(t, err)
+		assert.Equal(t, "# data\n", string(content))
+	})
+
+	t.Run("mount error", func(t *testing.T) {
+		m, _, cleanup := newTestMount(t, "", StateMounted, nil)
+		defer cleanup()
+		m.system = &failingSystem{fakeSystem{}}
+
+		status, err := m.Apply(context.Background())
+		assert.EqualError(t, err, "mount: special device /dev/sdb1 does not exist")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+}
+
+// failingSystem fails to mount anything
+type failingSystem struct {
+	fakeSystem
+}
+
+func (f *failingSystem) Mount(entry *Entry) error {
+	return errors.New("mount: special device " + entry.Device + " does not exist")
+}
+
+func TestClearedOptions(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		desired []string
+		mounted []string
+		cleared []string
+	}{
+		"still configured": {
+			desired: []string{"nodev", "noatime"},
+			mounted: []string{"rw", "nodev", "noatime"},
+		},
+		"removed": {
+			desired: []string{"noatime"},
+			mounted: []string{"rw", "nodev", "nosuid", "noatime"},
+			cleared: []string{"dev", "suid"},
+		},
+		"implied by user": {
+			desired: []string{"user"},
+			mounted: []string{"rw", "nosuid", "nodev", "noexec"},
+		},
+		"implied flag overridden": {
+			desired: []string{"user", "exec"},
+			mounted: []string{"rw", "nosuid", "nodev", "noexec"},
+			cleared: []string{"exec"},
+		},
+		"unknown options": {
+			desired: []string{"defaults"},
+			mounted: []string{"rw", "data=ordered"},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.cleared, clearedOptions(tc.desired, tc.mounted))
+		})
+	}
+}
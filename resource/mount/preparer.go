@@ -0,0 +1,108 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mount
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"golang.org/x/net/context"
+)
+
+// Preparer for Mount
+//
+// Mount makes sure a filesystem is mounted at a mountpoint with the given
+// options, and that it is persisted in `/etc/fstab` so it comes back after a
+// reboot. When the options of a mounted filesystem change it is remounted, and
+// when the device or type change it is unmounted and mounted again. Only
+// Linux is supported.
+type Preparer struct {
+	// Device is the filesystem to mount, like `/dev/sdb1`, `UUID=...` or
+	// `server:/export`. It is required unless the state is absent.
+	Device string `hcl:"device"`
+
+	// Mountpoint is the directory the filesystem is mounted on. It must be an
+	// absolute path to an existing directory.
+	Mountpoint string `hcl:"mountpoint" required:"true" nonempty:"true"`
+
+	// Fstype is the type of the filesystem, like `ext4` or `nfs`
+	Fstype string `hcl:"fstype" default:"auto"`
+
+	// Options are the mount options. Options are compared with the ones in
+	// the mount table, so options with values should be written the way the
+	// mount table shows them. Options which only mean something in
+	// `/etc/fstab`, like `noauto` and `nofail`, are left out of the
+	// comparison. When a flag like `nodev`, `nosuid`, `noexec`, or `noatime`
+	// is removed, the filesystem is remounted with the option that clears it.
+	// Other removed options stay in effect until the filesystem is mounted
+	// again.
+	Options []string `hcl:"options"`
+
+	// Dump is the fifth field of the fstab entry, used by dump(8)
+	Dump int `hcl:"dump"`
+
+	// Pass is the sixth field of the fstab entry, the order fsck(8) checks
+	// filesystems in at boot. It is 0 to skip the check, 1 for the root
+	// filesystem and 2 for the others.
+	Pass int `hcl:"pass"`
+
+	// State is whether the filesystem should be mounted. When unmounted it is
+	// kept in `/etc/fstab` but unmounted now, and when absent it is unmounted
+	// and removed from `/etc/fstab`.
+	State State `hcl:"state" valid_values:"mounted,unmounted,absent"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	if p.State == "" {
+		p.State = StateMounted
+	}
+
+	if !filepath.IsAbs(p.Mountpoint) {
+		return nil, fmt.Errorf("mount \"mountpoint\" parameter must be an absolute path, got %q", p.Mountpoint)
+	}
+
+	if p.Pass < 0 || p.Pass > 2 {
+		return nil, fmt.Errorf("mount \"pass\" parameter must be 0, 1 or 2, got %d", p.Pass)
+	}
+
+	if p.State != StateAbsent && p.Device == "" {
+		return nil, errors.New("mount requires \"device\" parameter unless state is absent")
+	}
+
+	options := p.Options
+	if len(options) == 0 {
+		options = []string{"defaults"}
+	}
+
+	return &Mount{
+		Device:     p.Device,
+		Mountpoint: filepath.Clean(p.Mountpoint),
+		Fstype:     p.Fstype,
+		Options:    options,
+		Dump:       p.Dump,
+		Pass:       p.Pass,
+		State:      p.State,
+		fstab:      FstabPath,
+		system:     new(System),
+	}, nil
+}
+
+func init() {
+	registry.Register("mount", (*Preparer)(nil), (*Mount)(nil))
+}
@@ -0,0 +1,76 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mount_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/mount"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestPreparerInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Resource)(nil), new(mount.Preparer))
+}
+
+func TestPreparerPrepare(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		p := mount.Preparer{Device: "/dev/sdb1", Mountpoint: "/srv/data/", Fstype: "ext4"}
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		m := task.(*mount.Mount)
+		assert.Equal(t, "/srv/data", m.Mountpoint)
+		assert.Equal(t, []string{"defaults"}, m.Options)
+		assert.Equal(t, mount.StateMounted, m.State)
+		assert.Equal(
+			t,
+			[]resource.Claim{resource.ClaimPath(mount.FstabPath), resource.ClaimPath("/srv/data")},
+			m.Claims(),
+		)
+	})
+
+	t.Run("absent without device", func(t *testing.T) {
+		p := mount.Preparer{Mountpoint: "/srv/data", State: mount.StateAbsent}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.NoError(t, err)
+	})
+
+	t.Run("no device", func(t *testing.T) {
+		p := mount.Preparer{Mountpoint: "/srv/data"}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "mount requires \"device\" parameter unless state is absent")
+	})
+
+	t.Run("relative mountpoint", func(t *testing.T) {
+		p := mount.Preparer{Device: "/dev/sdb1", Mountpoint: "srv/data"}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "mount \"mountpoint\" parameter must be an absolute path, got \"srv/data\"")
+	})
+
+	t.Run("invalid pass", func(t *testing.T) {
+		p := mount.Preparer{Device: "/dev/sdb1", Mountpoint: "/srv/data", Pass: 3}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "mount \"pass\" parameter must be 0, 1 or 2, got 3")
+	})
+}
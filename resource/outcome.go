@@ -0,0 +1,45 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+// Outcome is how applying a node turned out, judged by checking it again after
+// it was applied
+type Outcome string
+
+const (
+	// OutcomeNone is the outcome of nodes which were not applied
+	OutcomeNone Outcome = ""
+
+	// OutcomeChanged nodes were applied and had no changes left afterwards, so
+	// they changed as planned
+	OutcomeChanged Outcome = "changed"
+
+	// OutcomeDrifted nodes were applied without an error, but still had changes
+	// afterwards
+	OutcomeDrifted Outcome = "drifted"
+
+	// OutcomeFailed nodes returned an error when applied
+	OutcomeFailed Outcome = "failed"
+)
+
+// OutcomeReporter is implemented by results which report how applying turned
+// out
+type OutcomeReporter interface {
+	Outcome() Outcome
+
+	// RemainingChanges are the changes found when checking the node after it
+	// was applied
+	RemainingChanges() map[string]Diff
+}
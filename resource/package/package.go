@@ -92,6 +92,12 @@ func GetExitCode(err error) (uint32, error) {
 	return uint32(status.ExitStatus()), nil
 }
 
+// Claims returns the package database. Package managers lock their databases,
+// so packages can't be installed or removed concurrently.
+func (p *Package) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimPackageDB}
+}
+
 // Check if the package has to be 'present', or 'absent'
 func (p *Package) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
 	status := resource.NewStatus()
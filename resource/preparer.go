@@ -33,6 +33,9 @@ var (
 	timeType     = reflect.TypeOf(time.Time{})
 )
 
+// SpecialFields can be set on every resource in addition to its own fields
+var SpecialFields = []string{"depends", "group", "allow_destructive", "platforms", "change_window", "transaction"}
+
 const (
 	// longForm layout for time parsing
 	longForm = "2006-01-02T15:04:05"
@@ -45,6 +48,14 @@ const (
 type Preparer struct {
 	Source      map[string]interface{}
 	Destination Resource
+
+	// Position is where the node was declared, and FieldPositions is where
+	// each field in Source was set, both as file:line:column. Errors from
+	// Prepare are reported at these positions when they're known.
+	Position       string
+	FieldPositions map[string]string
+
+	deprecations []Warning
 }
 
 // NewPreparer wraps a given resource in this preparer
@@ -78,6 +89,10 @@ func (p *Preparer) Prepare(ctx context.Context, r Renderer) (Task, error) {
 		return nil, errors.New("Preparer can only wrap structs")
 	}
 
+	if err := p.migrate(); err != nil {
+		return nil, err
+	}
+
 	if err := p.validateExtra(typ); err != nil {
 		return nil, err
 	}
@@ -90,7 +105,7 @@ func (p *Preparer) Prepare(ctx context.Context, r Renderer) (Task, error) {
 
 		val, err := p.getValueForField(r, field)
 		if err != nil {
-			return nil, err
+			return nil, p.atFieldPosition(p.getFieldName(field), err)
 		}
 
 		fieldValue := value.Field(i)
@@ -109,7 +124,28 @@ func (p *Preparer) Prepare(ctx context.Context, r Renderer) (Task, error) {
 		return nil, errors.New("unwrapped was not a Resource")
 	}
 
-	return resource.Prepare(ctx, r)
+	task, err := SafePrepare(ctx, resource, r)
+	if err != nil {
+		return task, p.atPosition(err)
+	}
+	return task, nil
+}
+
+// atPosition reports an error at the position of the node, if known
+func (p *Preparer) atPosition(err error) error {
+	if p.Position == "" {
+		return err
+	}
+	return errors.Wrap(err, p.Position)
+}
+
+// atFieldPosition reports an error at the position of a field, falling back to
+// the position of the node
+func (p *Preparer) atFieldPosition(name string, err error) error {
+	if pos, ok := p.FieldPositions[name]; ok {
+		return errors.Wrap(err, pos)
+	}
+	return p.atPosition(err)
 }
 
 func (p *Preparer) validateExtra(typ reflect.Type) error {
@@ -122,9 +158,9 @@ func (p *Preparer) validateExtra(typ reflect.Type) error {
 		fieldNames[p.getFieldName(typ.Field(i))] = struct{}{}
 	}
 
-	// add special fields
-	fieldNames["depends"] = struct{}{}
-	fieldNames["group"] = struct{}{}
+	for _, field := range SpecialFields {
+		fieldNames[field] = struct{}{}
+	}
 
 	var err error
 	for key := range p.Source {
@@ -147,7 +183,7 @@ func (p *Preparer) validateExtra(typ reflect.Type) error {
 
 		err = multierror.Append(
 			err,
-			fmt.Errorf("I don't have a field named %q.%s", key, msg),
+			p.atFieldPosition(key, fmt.Errorf("I don't have a field named %q.%s", key, msg)),
 		)
 	}
 
@@ -387,9 +423,14 @@ func (p *Preparer) convertDuration(typ reflect.Type, r Renderer, name string, va
 		return reflect.ValueOf(dur), nil
 
 	case reflect.String:
-		dur, err := time.ParseDuration(val.(string))
+		rendered, err := p.renderScalar(r, name, val.(string))
+		if err != nil {
+			return reflect.Zero(typ), err
+		}
+
+		dur, err := time.ParseDuration(rendered)
 		if err != nil {
-			return reflect.Zero(typ), errors.Wrapf(err, "could not convert %s to duration", val)
+			return reflect.Zero(typ), errors.Wrapf(err, "could not convert %s to duration", rendered)
 		}
 		return reflect.ValueOf(dur), nil
 
@@ -409,19 +450,29 @@ func (p *Preparer) convertTime(typ reflect.Type, r Renderer, name string, val in
 		return reflect.Zero(typ), nil
 	}
 
+	str, ok := val.(string)
+	if !ok {
+		return reflect.Zero(typ), fmt.Errorf("cannot handle time conversion of %v", reflect.ValueOf(val).Kind())
+	}
+
 	switch typ {
 	case timeType:
+		rendered, err := p.renderScalar(r, name, str)
+		if err != nil {
+			return reflect.Zero(typ), err
+		}
+
 		// parse the time with the zone provided
-		zoneTime, ztErr := time.Parse(time.RFC3339, val.(string))
+		zoneTime, ztErr := time.Parse(time.RFC3339, rendered)
 		if ztErr != nil {
 			// obtain the system timezone
 			zone := time.FixedZone(time.Now().In(time.Local).Zone())
 
 			// parse the time with the system zone
-			longTime, ltErr := time.ParseInLocation(longForm, val.(string), zone)
+			longTime, ltErr := time.ParseInLocation(longForm, rendered, zone)
 			if ltErr != nil {
 				// parse the time as a date
-				shortTime, stErr := time.ParseInLocation(shortForm, val.(string), zone)
+				shortTime, stErr := time.ParseInLocation(shortForm, rendered, zone)
 				if stErr != nil {
 					errs := fmt.Errorf("could not convert %s to time.Time any of:\n1. %v\n2. %v\n3. %v\n", name, ztErr, ltErr, stErr)
 					return reflect.Zero(typ), errs
@@ -443,8 +494,11 @@ func (p *Preparer) convertTime(typ reflect.Type, r Renderer, name string, val in
 // convertBool converts a value to bool using the following rules:
 //
 // - bool values are used without conversion
+// - string values are rendered first, so they can be templates
 // - string values for truth are any capitalization of "t" or "true"
-// - any other string value is false
+// - string values for falsehood are any capitalization of "f" or "false", or
+//   an empty string
+// - any other string value is an error
 func (p *Preparer) convertBool(r Renderer, name string, val interface{}) (reflect.Value, error) {
 	if val == nil {
 		return reflect.ValueOf(false), nil
@@ -455,17 +509,20 @@ func (p *Preparer) convertBool(r Renderer, name string, val interface{}) (reflec
 		return reflect.ValueOf(val), nil
 
 	case string:
-		boolish, err := r.Render(name, t)
+		boolish, err := p.renderScalar(r, name, t)
 		if err != nil {
-			return reflect.ValueOf(false), errors.Wrapf(err, "error rendering field %s", name)
+			return reflect.ValueOf(false), err
 		}
 
 		switch strings.ToLower(boolish) {
 		case "t", "true":
 			return reflect.ValueOf(true), nil
 
-		default:
+		case "f", "false", "":
 			return reflect.ValueOf(false), nil
+
+		default:
+			return reflect.ValueOf(false), fmt.Errorf("could not convert %q to bool, it must be true or false", boolish)
 		}
 
 	default:
@@ -485,9 +542,9 @@ func (p *Preparer) convertNumber(typ reflect.Type, r Renderer, name string, val
 	)
 	switch t := val.(type) {
 	case string:
-		num, err = r.Render(name, t)
+		num, err = p.renderScalar(r, name, t)
 		if err != nil {
-			return reflect.Zero(typ), errors.Wrapf(err, "error rendering field %s", name)
+			return reflect.Zero(typ), err
 		}
 
 	default:
@@ -569,6 +626,17 @@ func (p *Preparer) convertNumber(typ reflect.Type, r Renderer, name string, val
 	return reflect.Zero(typ), fmt.Errorf("can't parse a number from %s", typ.Kind())
 }
 
+// renderScalar renders a string given for a field that isn't a string, so
+// numbers, booleans, durations and times can be templates too. The whitespace
+// templates tend to leave around the value is trimmed before it is parsed.
+func (p *Preparer) renderScalar(r Renderer, name, val string) (string, error) {
+	rendered, err := r.Render(name, val)
+	if err != nil {
+		return "", errors.Wrapf(err, "error rendering field %s", name)
+	}
+	return strings.TrimSpace(rendered), nil
+}
+
 // convertString converts and renders any strings given it
 func (p *Preparer) convertString(r Renderer, name string, val interface{}) (reflect.Value, error) {
 	if val == nil {
@@ -15,9 +15,11 @@
 package resource_test
 
 import (
+	"bytes"
 	"fmt"
 	"reflect"
 	"testing"
+	"text/template"
 	"time"
 
 	"github.com/asteris-llc/converge/helpers/fakerenderer"
@@ -241,24 +243,41 @@ func TestPreparerPrepare(t *testing.T) {
 		truthTable := []struct {
 			val   interface{}
 			truth bool
+			err   string
 		}{
 			// true - any casing of "true" or "t", or the boolean value
-			{true, true},
-			{"true", true},
-			{"TRUE", true},
-			{"t", true},
-			{"T", true},
+			{true, true, ""},
+			{"true", true, ""},
+			{"TRUE", true, ""},
+			{"t", true, ""},
+			{"T", true, ""},
 
 			// false
-			{false, false},
-			{"false", false},
-			{"FALSE", false},
-			{"f", false},
-			{"F", false},
-			{"bananas", false}, // or anything other string except as defined above
+			{false, false, ""},
+			{"false", false, ""},
+			{"FALSE", false, ""},
+			{"f", false, ""},
+			{"F", false, ""},
+			{"", false, ""},
+
+			// any other string is an error
+			{"bananas", false, `could not convert "bananas" to bool, it must be true or false`},
 		}
 
 		for _, pair := range truthTable {
+			if pair.err != "" {
+				t.Run(fmt.Sprintf("%T-%v", pair.val, pair.val), func(t *testing.T) {
+					prep := &resource.Preparer{
+						Source:      map[string]interface{}{"bool": pair.val},
+						Destination: new(testPreparerTarget),
+					}
+
+					_, err := prep.Prepare(context.Background(), fakerenderer.New())
+					assert.EqualError(t, err, pair.err)
+				})
+				continue
+			}
+
 			t.Run(fmt.Sprintf("%T-%v", pair.val, pair.val), func(t *testing.T) {
 				target := newWithField(t, "bool", pair.val)
 				assert.Equal(t, pair.truth, target.Bool)
@@ -332,6 +351,50 @@ func TestPreparerPrepare(t *testing.T) {
 		})
 	}
 
+	// fields that aren't strings can be templates, and are converted after
+	// rendering
+	t.Run("templates", func(t *testing.T) {
+		renderer := &paramRenderer{params: map[string]string{
+			"replicas": "3",
+			"enabled":  "true",
+			"timeout":  "30s",
+			"release":  "2016-10-01T12:00:00Z",
+		}}
+
+		prep := &resource.Preparer{
+			Source: map[string]interface{}{
+				"int":      `{{param "replicas"}}`,
+				"bool":     `{{param "enabled"}}`,
+				"duration": `{{param "timeout"}}`,
+				"time":     `{{param "release"}}`,
+				"uint8":    "\n  {{param \"replicas\"}}\n",
+			},
+			Destination: new(testPreparerTarget),
+		}
+
+		task, err := prep.Prepare(context.Background(), renderer)
+		require.NoError(t, err)
+
+		target := task.(*testPreparerTarget)
+		assert.Equal(t, 3, target.Int)
+		assert.True(t, target.Bool)
+		assert.Equal(t, 30*time.Second, target.Duration)
+		assert.Equal(t, "2016-10-01 12:00:00 +0000 UTC", target.Time.String())
+		assert.Equal(t, uint8(3), target.Uint8)
+
+		t.Run("invalid", func(t *testing.T) {
+			prep := &resource.Preparer{
+				Source:      map[string]interface{}{"int": `{{param "enabled"}}`},
+				Destination: new(testPreparerTarget),
+			}
+
+			_, err := prep.Prepare(context.Background(), renderer)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), "could not convert true to int")
+			}
+		})
+	})
+
 	// pointers
 	t.Run("pointers", func(t *testing.T) {
 		val := "test"
@@ -426,6 +489,66 @@ func TestPreparerPrepare(t *testing.T) {
 			assert.EqualError(t, err, `only one of "a" or "b" can be set`)
 		})
 	})
+
+	// errors are reported where the node and its fields were declared
+	t.Run("positions", func(t *testing.T) {
+		t.Run("field", func(t *testing.T) {
+			prep := &resource.Preparer{
+				Source:         map[string]interface{}{"nonempty": ""},
+				Destination:    new(testNonemptyTarget),
+				Position:       "web.hcl:1:1",
+				FieldPositions: map[string]string{"nonempty": "web.hcl:2:14"},
+			}
+
+			_, err := prep.Prepare(context.Background(), fakerenderer.New())
+			assert.EqualError(t, err, `web.hcl:2:14: "nonempty" must be nonempty`)
+		})
+
+		t.Run("unset field", func(t *testing.T) {
+			prep := &resource.Preparer{
+				Source:      map[string]interface{}{},
+				Destination: new(testRequiredTarget),
+				Position:    "web.hcl:1:1",
+			}
+
+			_, err := prep.Prepare(context.Background(), fakerenderer.New())
+			assert.EqualError(t, err, `web.hcl:1:1: "required" is required`)
+		})
+
+		t.Run("unknown field", func(t *testing.T) {
+			prep := &resource.Preparer{
+				Source:         map[string]interface{}{"required": "a", "x": "b"},
+				Destination:    new(testRequiredTarget),
+				Position:       "web.hcl:1:1",
+				FieldPositions: map[string]string{"required": "web.hcl:2:14", "x": "web.hcl:3:7"},
+			}
+
+			_, err := prep.Prepare(context.Background(), fakerenderer.New())
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), `web.hcl:3:7: I don't have a field named "x".`)
+			}
+		})
+	})
+}
+
+// paramRenderer renders templates with a param function that looks values up
+// in params
+type paramRenderer struct {
+	fakerenderer.FakeRenderer
+	params map[string]string
+}
+
+func (pr *paramRenderer) Render(name, content string) (string, error) {
+	tmpl, err := template.New(name).Funcs(template.FuncMap{
+		"param": func(key string) string { return pr.params[key] },
+	}).Parse(content)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	err = tmpl.Execute(&out, nil)
+	return out.String(), err
 }
 
 // testAlias is a type alias... can we deserialize those?
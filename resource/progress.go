@@ -0,0 +1,81 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// Progress records the output of a running task, so that the last line of it
+// can be reported while the task is still running. It is an io.Writer, and is
+// safe to write to and read from at the same time.
+type Progress struct {
+	lock    sync.Mutex
+	last    string
+	partial []byte
+}
+
+// Write records output, keeping only the last line of it
+func (p *Progress) Write(data []byte) (int, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.partial = append(p.partial, data...)
+	for {
+		i := bytes.IndexByte(p.partial, '\n')
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimSpace(string(p.partial[:i])); line != "" {
+			p.last = line
+		}
+		p.partial = p.partial[i+1:]
+	}
+
+	return len(data), nil
+}
+
+// Last returns the last line of output, including a line that hasn't been
+// finished yet
+func (p *Progress) Last() string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if line := strings.TrimSpace(string(p.partial)); line != "" {
+		return line
+	}
+	return p.last
+}
+
+type progressKey struct{}
+
+// WithProgress returns a context carrying a Progress, for the task running
+// with it to write its output to
+func WithProgress(ctx context.Context, progress *Progress) context.Context {
+	return context.WithValue(ctx, progressKey{}, progress)
+}
+
+// ProgressFrom returns the Progress in ctx, or nil if there isn't one
+func ProgressFrom(ctx context.Context) *Progress {
+	if ctx == nil {
+		return nil
+	}
+	progress, _ := ctx.Value(progressKey{}).(*Progress)
+	return progress
+}
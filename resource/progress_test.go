@@ -0,0 +1,50 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestProgress(t *testing.T) {
+	t.Parallel()
+
+	t.Run("last line", func(t *testing.T) {
+		p := new(resource.Progress)
+		fmt.Fprint(p, "downloading\nunpacking\n\n")
+		assert.Equal(t, "unpacking", p.Last())
+	})
+
+	t.Run("partial line", func(t *testing.T) {
+		p := new(resource.Progress)
+		fmt.Fprint(p, "downloading\n50%")
+		assert.Equal(t, "50%", p.Last())
+
+		fmt.Fprint(p, " done\n")
+		assert.Equal(t, "50% done", p.Last())
+	})
+
+	t.Run("context", func(t *testing.T) {
+		assert.Nil(t, resource.ProgressFrom(context.Background()))
+
+		p := new(resource.Progress)
+		assert.Equal(t, p, resource.ProgressFrom(resource.WithProgress(context.Background(), p)))
+	})
+}
@@ -0,0 +1,99 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import "fmt"
+
+// OriginKind categorizes where a rendered value came from
+type OriginKind string
+
+const (
+	// OriginParam is a value from a param, through param, paramList, or
+	// paramMap
+	OriginParam OriginKind = "param"
+
+	// OriginLookup is a value exported by another node
+	OriginLookup OriginKind = "lookup"
+
+	// OriginEnv is a value from the environment
+	OriginEnv OriginKind = "env"
+
+	// OriginPlatform is a fact about the platform converge is running on
+	OriginPlatform OriginKind = "platform"
+)
+
+// Origin is one of the sources that contributed to the value of a rendered
+// field
+type Origin struct {
+	// Field is the field the value was rendered into
+	Field string `json:"field"`
+
+	// Kind is the kind of source
+	Kind OriginKind `json:"kind"`
+
+	// Name is the name of the source: the param, lookup, or environment
+	// variable
+	Name string `json:"name"`
+
+	// Node is the node the value came from, for params and lookups
+	Node string `json:"node,omitempty"`
+
+	// Value is the value of the source, as it was rendered
+	Value string `json:"value"`
+}
+
+func (o Origin) String() string {
+	out := fmt.Sprintf("%s %s = %q", o.Kind, o.Name, o.Value)
+	if o.Node != "" {
+		out += " (from " + o.Node + ")"
+	}
+	return out
+}
+
+// ProvenanceReporter is implemented by statuses that report where the values
+// of the node's rendered fields came from
+type ProvenanceReporter interface {
+	Provenance() []Origin
+}
+
+// RedactedValue replaces the values of origins rendered into sensitive fields
+const RedactedValue = "<sensitive>"
+
+// SensitiveReporter is implemented by tasks with fields whose values must not
+// be shown, like passwords. The origins of those fields are still recorded,
+// but without their values.
+type SensitiveReporter interface {
+	SensitiveFields() []string
+}
+
+// Redact replaces the values of the origins rendered into any of the fields
+func Redact(origins []Origin, fields []string) []Origin {
+	if len(fields) == 0 {
+		return origins
+	}
+	sensitive := map[string]bool{}
+	for _, field := range fields {
+		sensitive[field] = true
+	}
+
+	out := make([]Origin, len(origins))
+	for i, origin := range origins {
+		if sensitive[origin.Field] {
+			origin.Value = RedactedValue
+		}
+		out[i] = origin
+	}
+	return out
+}
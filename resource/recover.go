@@ -0,0 +1,66 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"golang.org/x/net/context"
+)
+
+// PanicError is returned when a task panics during Check or Apply, or a
+// resource panics during Prepare. The panic is contained to the node the task
+// belongs to, and the stack is kept so the failure can be traced back to the
+// resource implementation.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v\n\n%s", p.Value, p.Stack)
+}
+
+// SafeCheck calls Check on the task, converting a panic into a *PanicError
+func SafeCheck(ctx context.Context, task Task, r Renderer) (status TaskStatus, err error) {
+	defer recoverTask(&status, &err)
+	return task.Check(ctx, r)
+}
+
+// SafeApply calls Apply on the task, converting a panic into a *PanicError
+func SafeApply(ctx context.Context, task Task) (status TaskStatus, err error) {
+	defer recoverTask(&status, &err)
+	return task.Apply(ctx)
+}
+
+// SafePrepare calls Prepare on the resource, converting a panic into a
+// *PanicError
+func SafePrepare(ctx context.Context, res Resource, r Renderer) (task Task, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			task = nil
+			err = &PanicError{Value: rec, Stack: debug.Stack()}
+		}
+	}()
+	return res.Prepare(ctx, r)
+}
+
+func recoverTask(status *TaskStatus, err *error) {
+	if r := recover(); r != nil {
+		*status = nil
+		*err = &PanicError{Value: r, Stack: debug.Stack()}
+	}
+}
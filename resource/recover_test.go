@@ -0,0 +1,103 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/helpers/faketask"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestSafeCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("normal", func(t *testing.T) {
+		status, err := resource.SafeCheck(context.Background(), faketask.NoOp(), fakerenderer.New())
+		assert.NoError(t, err)
+		assert.NotNil(t, status)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		status, err := resource.SafeCheck(context.Background(), faketask.Panic(), fakerenderer.New())
+		assert.Nil(t, status)
+		require.IsType(t, &resource.PanicError{}, err)
+		assert.Equal(t, "task panicked", err.(*resource.PanicError).Value)
+		assert.Contains(t, err.Error(), "panic: task panicked")
+	})
+}
+
+func TestSafeApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("normal", func(t *testing.T) {
+		status, err := resource.SafeApply(context.Background(), faketask.NoOp())
+		assert.NoError(t, err)
+		assert.NotNil(t, status)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		status, err := resource.SafeApply(context.Background(), faketask.Panic())
+		assert.Nil(t, status)
+		require.IsType(t, &resource.PanicError{}, err)
+		assert.NotEmpty(t, err.(*resource.PanicError).Stack)
+	})
+}
+
+// noOpResource prepares a task which does nothing
+type noOpResource struct{}
+
+func (noOpResource) Prepare(context.Context, resource.Renderer) (resource.Task, error) {
+	return faketask.NoOp(), nil
+}
+
+// panicResource panics when it is prepared
+type panicResource struct{}
+
+func (panicResource) Prepare(context.Context, resource.Renderer) (resource.Task, error) {
+	panic("resource panicked")
+}
+
+func TestSafePrepare(t *testing.T) {
+	t.Parallel()
+
+	t.Run("normal", func(t *testing.T) {
+		task, err := resource.SafePrepare(context.Background(), noOpResource{}, fakerenderer.New())
+		assert.NoError(t, err)
+		assert.NotNil(t, task)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		task, err := resource.SafePrepare(context.Background(), panicResource{}, fakerenderer.New())
+		assert.Nil(t, task)
+		require.IsType(t, &resource.PanicError{}, err)
+		assert.Equal(t, "resource panicked", err.(*resource.PanicError).Value)
+	})
+
+	t.Run("preparer", func(t *testing.T) {
+		prep := resource.NewPreparer(panicResource{})
+		prep.Position = "test.hcl:1:1"
+
+		_, err := prep.Prepare(context.Background(), fakerenderer.New())
+		require.Error(t, err)
+		assert.IsType(t, &resource.PanicError{}, errors.Cause(err))
+		assert.Contains(t, err.Error(), "test.hcl:1:1: panic: resource panicked")
+	})
+}
@@ -32,6 +32,44 @@ type Task interface {
 	Apply(context.Context) (TaskStatus, error)
 }
 
+// Cleaner is implemented by tasks which create system objects that only last
+// for the run, like temporary directories. Cleanup is called once every node
+// has been applied, whether or not the run succeeded.
+type Cleaner interface {
+	Cleanup(context.Context) error
+}
+
+// Rollbacker is implemented by tasks which can undo what Apply changed. When a
+// member of a transaction fails, Rollback is called on the members applied
+// before it, in reverse order.
+type Rollbacker interface {
+	Rollback(context.Context) error
+}
+
+// Reapplier is implemented by tasks like service restarts, whose effect
+// depends on what the other members of their transaction changed. Instead of
+// being undone when the transaction fails, they are applied again after the
+// other members are rolled back, so a restarted service picks up the restored
+// configuration.
+type Reapplier interface {
+	Reapply(context.Context) error
+}
+
+// ComputedReporter is implemented by tasks with exported fields whose values
+// are only known once the task has been applied, like the output of a command.
+// Lookups of these fields are rendered at apply time when the task has
+// changes. A field also covers the fields exported under it, so "status"
+// covers "status.stdout".
+type ComputedReporter interface {
+	ComputedFields() []string
+}
+
+// RollbackReporter is implemented by results that describe how the
+// transaction they failed in was rolled back
+type RollbackReporter interface {
+	Rollbacks() []string
+}
+
 // Resource adds metadata about the executed tasks
 type Resource interface {
 	Prepare(context.Context, Renderer) (Task, error)
@@ -45,6 +83,19 @@ type Renderer interface {
 	GetID() string
 	Value() (value Value, present bool)
 	Render(name, content string) (string, error)
+
+	// Source is the URL of the module the node was loaded from
+	Source() string
+
+	// SourceDir is the URL of the directory containing the module
+	SourceDir() string
+
+	// ResolvePath resolves a location relative to the module, returning a URL
+	ResolvePath(loc string) (string, error)
+
+	// LocalPath resolves a location relative to the module, returning a path
+	// on the local filesystem
+	LocalPath(loc string) (string, error)
 }
 
 // TaskWrapper provides an implementation of render.Tasker for tasks
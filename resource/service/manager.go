@@ -0,0 +1,274 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/command"
+	"github.com/asteris-llc/converge/render/extensions/platform"
+)
+
+// NewManager returns the manager for an init system
+func NewManager(initSystem string, runner command.Runner) (Manager, error) {
+	switch initSystem {
+	case platform.InitSystemd:
+		return &Systemd{runner}, nil
+	case platform.InitLaunchd:
+		return &Launchd{runner}, nil
+	case platform.InitOpenRC:
+		return &OpenRC{Runner: runner}, nil
+	case platform.InitSysVInit:
+		return &SysVInit{
+			Runner:    runner,
+			RCDir:     "/etc",
+			Service:   installed("service"),
+			UpdateRCD: installed("update-rc.d"),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported init system %q", initSystem)
+	}
+}
+
+// installed is true if a command is on the PATH
+func installed(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// succeeded distinguishes commands that ran and failed, which answer a
+// question with "no", from commands that could not be run at all
+func succeeded(out []byte, err error) (bool, error) {
+	if err == nil {
+		return true, nil
+	}
+	if _, ok := err.(*exec.ExitError); ok {
+		return false, nil
+	}
+	return false, err
+}
+
+// run runs a command that must succeed
+func run(runner command.Runner, name string, args ...string) error {
+	out, err := runner.Run(name, args...)
+	return command.Error(name, out, err)
+}
+
+// Systemd manages services with systemctl
+type Systemd struct{ command.Runner }
+
+// IsRunning is true if the unit is active
+func (s *Systemd) IsRunning(name string) (bool, error) {
+	return succeeded(s.Run("systemctl", "is-active", "--quiet", name))
+}
+
+// IsEnabled is true if the unit is enabled
+func (s *Systemd) IsEnabled(name string) (bool, error) {
+	return succeeded(s.Run("systemctl", "is-enabled", "--quiet", name))
+}
+
+// Start starts the unit
+func (s *Systemd) Start(name string) error { return run(s, "systemctl", "start", name) }
+
+// Stop stops the unit
+func (s *Systemd) Stop(name string) error { return run(s, "systemctl", "stop", name) }
+
+// Enable enables the unit
+func (s *Systemd) Enable(name string) error { return run(s, "systemctl", "enable", name) }
+
+// Disable disables the unit
+func (s *Systemd) Disable(name string) error { return run(s, "systemctl", "disable", name) }
+
+// Launchd manages system daemons with launchctl. Services are named by their
+// label, and must already be loaded from their plist.
+type Launchd struct{ command.Runner }
+
+var launchdPID = regexp.MustCompile(`"PID" = \d+;`)
+
+// IsRunning is true if the daemon has a process
+func (l *Launchd) IsRunning(name string) (bool, error) {
+	out, err := l.Run("launchctl", "list", name)
+	if ok, err := succeeded(out, err); !ok || err != nil {
+		return false, err
+	}
+	return launchdPID.Match(out), nil
+}
+
+// IsEnabled is true unless the daemon is in the system's disabled list
+func (l *Launchd) IsEnabled(name string) (bool, error) {
+	out, err := l.Run("launchctl", "print-disabled", "system")
+	if err != nil {
+		return false, err
+	}
+
+	disabled := regexp.MustCompile(`"` + regexp.QuoteMeta(name) + `" => (true|disabled)`)
+	return !disabled.Match(out), nil
+}
+
+// Start starts the daemon
+func (l *Launchd) Start(name string) error {
+	return run(l, "launchctl", "kickstart", "system/"+name)
+}
+
+// Stop stops the daemon
+func (l *Launchd) Stop(name string) error {
+	return run(l, "launchctl", "kill", "SIGTERM", "system/"+name)
+}
+
+// Enable enables the daemon
+func (l *Launchd) Enable(name string) error {
+	return run(l, "launchctl", "enable", "system/"+name)
+}
+
+// Disable disables the daemon
+func (l *Launchd) Disable(name string) error {
+	return run(l, "launchctl", "disable", "system/"+name)
+}
+
+// OpenRC manages services with rc-service and rc-update. Services are enabled
+// in the default runlevel unless another is set.
+type OpenRC struct {
+	command.Runner
+
+	// Runlevel is the runlevel services are enabled in
+	Runlevel string
+}
+
+func (o *OpenRC) runlevel() string {
+	if o.Runlevel == "" {
+		return "default"
+	}
+	return o.Runlevel
+}
+
+// IsRunning is true if the service is started
+func (o *OpenRC) IsRunning(name string) (bool, error) {
+	return succeeded(o.Run("rc-service", name, "status"))
+}
+
+// IsEnabled is true if the service is in the runlevel
+func (o *OpenRC) IsEnabled(name string) (bool, error) {
+	out, err := o.Run("rc-update", "show", o.runlevel())
+	if err != nil {
+		return false, err
+	}
+
+	// lines look like "             sshd | default"
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.SplitN(line, "|", 2)
+		if len(fields) == 2 && strings.TrimSpace(fields[0]) == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Start starts the service
+func (o *OpenRC) Start(name string) error { return run(o, "rc-service", name, "start") }
+
+// Stop stops the service
+func (o *OpenRC) Stop(name string) error { return run(o, "rc-service", name, "stop") }
+
+// Enable adds the service to the runlevel
+func (o *OpenRC) Enable(name string) error {
+	return run(o, "rc-update", "add", name, o.runlevel())
+}
+
+// Disable removes the service from the runlevel
+func (o *OpenRC) Disable(name string) error {
+	return run(o, "rc-update", "del", name, o.runlevel())
+}
+
+// SysVInit manages init scripts with service, and update-rc.d or chkconfig,
+// whichever is installed
+type SysVInit struct {
+	command.Runner
+
+	// RCDir contains init.d and the rc?.d directories
+	RCDir string
+
+	// Service is true if the service command is installed. Without it, init
+	// scripts are run directly from init.d.
+	Service bool
+
+	// UpdateRCD is true on Debian-like systems, which use update-rc.d instead
+	// of chkconfig
+	UpdateRCD bool
+}
+
+// script is the command which runs an action of the service's init script
+func (s *SysVInit) script(name, action string) (string, []string) {
+	if s.Service {
+		return "service", []string{name, action}
+	}
+	return filepath.Join(s.RCDir, "init.d", name), []string{action}
+}
+
+// IsRunning is true if the init script reports the service as running
+func (s *SysVInit) IsRunning(name string) (bool, error) {
+	script, args := s.script(name, "status")
+	return succeeded(s.Run(script, args...))
+}
+
+// IsEnabled is true if the service starts in any of the multi-user runlevels
+func (s *SysVInit) IsEnabled(name string) (bool, error) {
+	for _, level := range []string{"2", "3", "4", "5"} {
+		matches, err := filepath.Glob(filepath.Join(s.RCDir, "rc"+level+".d", "S[0-9][0-9]"+name))
+		if err != nil {
+			return false, err
+		}
+		if len(matches) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Start starts the service
+func (s *SysVInit) Start(name string) error {
+	script, args := s.script(name, "start")
+	return run(s, script, args...)
+}
+
+// Stop stops the service
+func (s *SysVInit) Stop(name string) error {
+	script, args := s.script(name, "stop")
+	return run(s, script, args...)
+}
+
+// Enable makes the service start at boot. With update-rc.d, defaults only
+// creates links that are missing, so enable is run too to turn the stop links
+// left by an earlier disable back into start links.
+func (s *SysVInit) Enable(name string) error {
+	if s.UpdateRCD {
+		if err := run(s, "update-rc.d", name, "defaults"); err != nil {
+			return err
+		}
+		return run(s, "update-rc.d", name, "enable")
+	}
+	return run(s, "chkconfig", name, "on")
+}
+
+// Disable keeps the service from starting at boot
+func (s *SysVInit) Disable(name string) error {
+	if s.UpdateRCD {
+		return run(s, "update-rc.d", name, "disable")
+	}
+	return run(s, "chkconfig", name, "off")
+}
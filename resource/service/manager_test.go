@@ -0,0 +1,240 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/resource/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRunner answers commands from a script and records them
+type fakeRunner struct {
+	outputs map[string]string
+	failing map[string]bool
+	ran     []string
+}
+
+func newFakeRunner() *fakeRunner {
+	return &fakeRunner{outputs: map[string]string{}, failing: map[string]bool{}}
+}
+
+func (f *fakeRunner) Run(name string, args ...string) ([]byte, error) {
+	cmd := strings.Join(append([]string{name}, args...), " ")
+	f.ran = append(f.ran, cmd)
+	if f.failing[cmd] {
+		return []byte(f.outputs[cmd]), exitError()
+	}
+	return []byte(f.outputs[cmd]), nil
+}
+
+// exitError is a real *exec.ExitError, as returned by commands that fail
+func exitError() error {
+	return exec.Command("sh", "-c", "exit 3").Run()
+}
+
+func TestNewManager(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"systemd", "launchd", "openrc", "sysvinit"} {
+		_, err := service.NewManager(name, newFakeRunner())
+		assert.NoError(t, err, name)
+	}
+
+	_, err := service.NewManager("upstart", newFakeRunner())
+	assert.EqualError(t, err, `unsupported init system "upstart"`)
+}
+
+func TestSystemd(t *testing.T) {
+	t.Parallel()
+
+	runner := newFakeRunner()
+	runner.failing["systemctl is-enabled --quiet nginx"] = true
+	mgr := &service.Systemd{Runner: runner}
+
+	running, err := mgr.IsRunning("nginx")
+	require.NoError(t, err)
+	assert.True(t, running)
+
+	enabled, err := mgr.IsEnabled("nginx")
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	require.NoError(t, mgr.Enable("nginx"))
+	require.NoError(t, mgr.Start("nginx"))
+	assert.Equal(t, []string{
+		"systemctl is-active --quiet nginx",
+		"systemctl is-enabled --quiet nginx",
+		"systemctl enable nginx",
+		"systemctl start nginx",
+	}, runner.ran)
+
+	t.Run("failure", func(t *testing.T) {
+		runner := newFakeRunner()
+		runner.failing["systemctl start nginx"] = true
+		runner.outputs["systemctl start nginx"] = "Unit nginx.service not found.\n"
+
+		err := (&service.Systemd{Runner: runner}).Start("nginx")
+		assert.EqualError(t, err, "systemctl: exit status 3: Unit nginx.service not found.")
+	})
+
+	t.Run("missing systemctl", func(t *testing.T) {
+		_, err := (&service.Systemd{Runner: errRunner{}}).IsRunning("nginx")
+		assert.Error(t, err)
+	})
+}
+
+func TestLaunchd(t *testing.T) {
+	t.Parallel()
+
+	runner := newFakeRunner()
+	runner.outputs["launchctl list com.example.web"] = "{\n\t\"Label\" = \"com.example.web\";\n\t\"PID\" = 412;\n};\n"
+	runner.failing["launchctl list com.example.worker"] = true
+	runner.outputs["launchctl print-disabled system"] = "disabled services = {\n\t\"com.example.web\" => false\n\t\"com.example.worker\" => true\n}\n"
+	mgr := &service.Launchd{Runner: runner}
+
+	running, err := mgr.IsRunning("com.example.web")
+	require.NoError(t, err)
+	assert.True(t, running)
+
+	running, err = mgr.IsRunning("com.example.worker")
+	require.NoError(t, err)
+	assert.False(t, running)
+
+	enabled, err := mgr.IsEnabled("com.example.web")
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	enabled, err = mgr.IsEnabled("com.example.worker")
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	require.NoError(t, mgr.Start("com.example.worker"))
+	assert.Equal(t, "launchctl kickstart system/com.example.worker", runner.ran[len(runner.ran)-1])
+}
+
+func TestOpenRC(t *testing.T) {
+	t.Parallel()
+
+	runner := newFakeRunner()
+	runner.failing["rc-service nginx status"] = true
+	runner.outputs["rc-update show default"] = "             sshd | default\n            nginx | default\n"
+	mgr := &service.OpenRC{Runner: runner}
+
+	running, err := mgr.IsRunning("nginx")
+	require.NoError(t, err)
+	assert.False(t, running)
+
+	enabled, err := mgr.IsEnabled("nginx")
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	enabled, err = mgr.IsEnabled("ngin")
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	require.NoError(t, mgr.Disable("nginx"))
+	assert.Equal(t, "rc-update del nginx default", runner.ran[len(runner.ran)-1])
+
+	mgr.Runlevel = "boot"
+	require.NoError(t, mgr.Enable("nginx"))
+	assert.Equal(t, "rc-update add nginx boot", runner.ran[len(runner.ran)-1])
+
+	_, err = mgr.IsEnabled("nginx")
+	require.NoError(t, err)
+	assert.Equal(t, "rc-update show boot", runner.ran[len(runner.ran)-1])
+}
+
+func TestSysVInit(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-service-rc")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "rc3.d"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "rc3.d", "S20nginx"), nil, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "rc3.d", "K80cron"), nil, 0755))
+
+	runner := newFakeRunner()
+	mgr := &service.SysVInit{Runner: runner, RCDir: dir}
+
+	enabled, err := mgr.IsEnabled("nginx")
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	enabled, err = mgr.IsEnabled("cron")
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	require.NoError(t, mgr.Enable("cron"))
+	assert.Equal(t, "chkconfig cron on", runner.ran[len(runner.ran)-1])
+
+	mgr.UpdateRCD = true
+	require.NoError(t, mgr.Disable("nginx"))
+	assert.Equal(t, "update-rc.d nginx disable", runner.ran[len(runner.ran)-1])
+
+	t.Run("update-rc.d enable", func(t *testing.T) {
+		runner := newFakeRunner()
+		mgr := &service.SysVInit{Runner: runner, RCDir: dir, UpdateRCD: true}
+
+		require.NoError(t, mgr.Enable("cron"))
+		assert.Equal(t, []string{"update-rc.d cron defaults", "update-rc.d cron enable"}, runner.ran)
+	})
+
+	t.Run("service", func(t *testing.T) {
+		runner := newFakeRunner()
+		runner.failing["service nginx status"] = true
+		mgr := &service.SysVInit{Runner: runner, RCDir: dir, Service: true}
+
+		running, err := mgr.IsRunning("nginx")
+		require.NoError(t, err)
+		assert.False(t, running)
+
+		require.NoError(t, mgr.Start("nginx"))
+		assert.Equal(t, "service nginx start", runner.ran[len(runner.ran)-1])
+	})
+
+	t.Run("init script", func(t *testing.T) {
+		runner := newFakeRunner()
+		script := filepath.Join(dir, "init.d", "nginx")
+		runner.outputs[script+" stop"] = "nginx is not running"
+		runner.failing[script+" stop"] = true
+		mgr := &service.SysVInit{Runner: runner, RCDir: dir}
+
+		running, err := mgr.IsRunning("nginx")
+		require.NoError(t, err)
+		assert.True(t, running)
+		assert.Equal(t, script+" status", runner.ran[0])
+
+		err = mgr.Stop("nginx")
+		assert.EqualError(t, err, script+": exit status 3: nginx is not running")
+	})
+}
+
+// errRunner can't run anything
+type errRunner struct{}
+
+func (errRunner) Run(string, ...string) ([]byte, error) {
+	return nil, errors.New("executable file not found in $PATH")
+}
@@ -0,0 +1,85 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/asteris-llc/converge/helpers/command"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/render/extensions/platform"
+	"github.com/asteris-llc/converge/resource"
+	"golang.org/x/net/context"
+)
+
+// Preparer for Service
+//
+// Service starts, stops, enables, and disables services with whichever init
+// system the host uses: systemd, launchd, OpenRC, or SysV init. Use it in
+// modules that run on several platforms; `systemd.unit.state` offers more
+// control on hosts that run systemd.
+type Preparer struct {
+	// the name of the service. For launchd this is the label of a daemon that
+	// is already loaded.
+	Name string `hcl:"name" required:"true" nonempty:"true"`
+
+	// whether the service should be running or stopped. If no state is
+	// specified, it is left alone.
+	State State `hcl:"state" valid_values:"running,stopped"`
+
+	// whether the service should start at boot. If enabled is not specified,
+	// it is left alone.
+	Enabled *bool `hcl:"enabled"`
+
+	// the init system to use, instead of the one detected on the host. It is
+	// detected the same way as `platform.InitSystem`.
+	Backend string `hcl:"backend" valid_values:"systemd,launchd,openrc,sysvinit"`
+
+	// the OpenRC runlevel to enable the service in. Only valid with OpenRC.
+	Runlevel string `hcl:"runlevel" nonempty:"true" default:"default"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	if p.State == "" && p.Enabled == nil {
+		return nil, errors.New("service needs a state, enabled, or both")
+	}
+
+	backend := p.Backend
+	if backend == "" {
+		backend = platform.DetectInitSystem()
+		if backend == "" {
+			return nil, errors.New("could not detect the init system, set backend")
+		}
+	}
+
+	manager, err := NewManager(backend, command.ExecRunner{})
+	if err != nil {
+		return nil, err
+	}
+
+	if openrc, ok := manager.(*OpenRC); ok {
+		openrc.Runlevel = p.Runlevel
+	} else if p.Runlevel != "" && p.Runlevel != "default" {
+		return nil, fmt.Errorf("runlevel is only supported by openrc, not %s", backend)
+	}
+
+	return New(p.Name, backend, p.State, p.Enabled, manager), nil
+}
+
+func init() {
+	registry.Register("service", (*Preparer)(nil), (*Service)(nil))
+}
@@ -0,0 +1,69 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestPreparerInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Resource)(nil), new(service.Preparer))
+}
+
+func TestPreparerPrepare(t *testing.T) {
+	t.Parallel()
+
+	t.Run("backend", func(t *testing.T) {
+		p := &service.Preparer{Name: "nginx", State: service.StateRunning, Backend: "openrc"}
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		svc := task.(*service.Service)
+		assert.Equal(t, "openrc", svc.Backend)
+		assert.Equal(t, service.StateRunning, svc.State)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		p := &service.Preparer{Name: "nginx", Enabled: boolPtr(true), Backend: "systemd"}
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, task.(*service.Service).Enabled)
+	})
+
+	t.Run("runlevel", func(t *testing.T) {
+		p := &service.Preparer{Name: "nginx", Enabled: boolPtr(true), Backend: "openrc", Runlevel: "boot"}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.NoError(t, err)
+
+		p.Backend = "systemd"
+		_, err = p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "runlevel is only supported by openrc, not systemd")
+	})
+
+	t.Run("nothing to manage", func(t *testing.T) {
+		p := &service.Preparer{Name: "nginx", Backend: "systemd"}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "service needs a state, enabled, or both")
+	})
+}
@@ -0,0 +1,178 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/asteris-llc/converge/resource"
+	"golang.org/x/net/context"
+)
+
+// State of a service
+type State string
+
+const (
+	// StateRunning indicates the service should be running
+	StateRunning State = "running"
+
+	// StateStopped indicates the service should be stopped
+	StateStopped State = "stopped"
+)
+
+// Manager controls services through an init system
+type Manager interface {
+	// IsRunning is true if the service is running
+	IsRunning(name string) (bool, error)
+
+	// IsEnabled is true if the service starts at boot
+	IsEnabled(name string) (bool, error)
+
+	Start(name string) error
+	Stop(name string) error
+	Enable(name string) error
+	Disable(name string) error
+}
+
+// Service starts, stops, enables, and disables services with whichever init
+// system manages them
+type Service struct {
+	// the name of the service
+	Name string `export:"name"`
+
+	// the init system managing the service
+	Backend string `export:"backend"`
+
+	// the desired state of the service, if it is managed
+	State State `export:"state"`
+
+	// whether the service should start at boot, if it is managed
+	Enabled bool `export:"enabled"`
+
+	manageEnabled bool
+	manager       Manager
+}
+
+// New returns a service managed by manager. A nil enabled leaves enabling the
+// service alone.
+func New(name, backend string, state State, enabled *bool, manager Manager) *Service {
+	svc := &Service{
+		Name:    name,
+		Backend: backend,
+		State:   state,
+		manager: manager,
+	}
+	if enabled != nil {
+		svc.Enabled = *enabled
+		svc.manageEnabled = true
+	}
+	return svc
+}
+
+// Check if the service is in the desired state
+func (s *Service) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	running, enabled, err := s.current()
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+
+	s.diff(status, running, enabled)
+	return status, nil
+}
+
+// Apply the desired state to the service. It is enabled before being started
+// and stopped before being disabled.
+func (s *Service) Apply(context.Context) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	running, enabled, err := s.current()
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+	s.diff(status, running, enabled)
+
+	var steps []func() error
+	if s.manageEnabled && s.Enabled && !enabled {
+		steps = append(steps, s.step(status, "enabled", s.manager.Enable))
+	}
+	if s.State == StateRunning && !running {
+		steps = append(steps, s.step(status, "started", s.manager.Start))
+	}
+	if s.State == StateStopped && running {
+		steps = append(steps, s.step(status, "stopped", s.manager.Stop))
+	}
+	if s.manageEnabled && !s.Enabled && enabled {
+		steps = append(steps, s.step(status, "disabled", s.manager.Disable))
+	}
+
+	for _, step := range steps {
+		if err := step(); err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			return status, err
+		}
+	}
+
+	return status, nil
+}
+
+// step wraps a manager action, reporting it when it succeeds
+func (s *Service) step(status *resource.Status, done string, action func(string) error) func() error {
+	return func() error {
+		if err := action(s.Name); err != nil {
+			return fmt.Errorf("%s could not be %s by %s: %s", s.Name, done, s.Backend, err)
+		}
+		status.AddMessage(fmt.Sprintf("%s %s", done, s.Name))
+		return nil
+	}
+}
+
+// current reads the state of the service, skipping what isn't managed
+func (s *Service) current() (running, enabled bool, err error) {
+	if s.State != "" {
+		if running, err = s.manager.IsRunning(s.Name); err != nil {
+			return false, false, err
+		}
+	}
+	if s.manageEnabled {
+		if enabled, err = s.manager.IsEnabled(s.Name); err != nil {
+			return false, false, err
+		}
+	}
+	return running, enabled, nil
+}
+
+// diff records how the service differs from its desired state
+func (s *Service) diff(status *resource.Status, running, enabled bool) {
+	if s.State != "" {
+		current := StateStopped
+		if running {
+			current = StateRunning
+		}
+		if current != s.State {
+			status.AddDifference("state", string(current), string(s.State), "")
+			status.RaiseLevel(resource.StatusWillChange)
+		}
+	}
+
+	if s.manageEnabled && enabled != s.Enabled {
+		status.AddDifference("enabled", strconv.FormatBool(enabled), strconv.FormatBool(s.Enabled), "")
+		status.RaiseLevel(resource.StatusWillChange)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// fakeManager tracks services in memory
+type fakeManager struct {
+	running map[string]bool
+	enabled map[string]bool
+	calls   []string
+	fail    error
+}
+
+func newFakeManager() *fakeManager {
+	return &fakeManager{running: map[string]bool{}, enabled: map[string]bool{}}
+}
+
+func (f *fakeManager) IsRunning(name string) (bool, error) { return f.running[name], f.fail }
+func (f *fakeManager) IsEnabled(name string) (bool, error) { return f.enabled[name], f.fail }
+
+func (f *fakeManager) Start(name string) error   { return f.call("start", name, f.running, true) }
+func (f *fakeManager) Stop(name string) error    { return f.call("stop", name, f.running, false) }
+func (f *fakeManager) Enable(name string) error  { return f.call("enable", name, f.enabled, true) }
+func (f *fakeManager) Disable(name string) error { return f.call("disable", name, f.enabled, false) }
+
+func (f *fakeManager) call(action, name string, state map[string]bool, value bool) error {
+	f.calls = append(f.calls, action+" "+name)
+	state[name] = value
+	return nil
+}
+
+func TestServiceInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(service.Service))
+}
+
+func TestServiceCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("stopped and disabled", func(t *testing.T) {
+		mgr := newFakeManager()
+		svc := service.New("nginx", "fake", service.StateRunning, boolPtr(true), mgr)
+
+		status, err := svc.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		assert.Equal(t, "stopped", status.Diffs()["state"].Original())
+		assert.Equal(t, "running", status.Diffs()["state"].Current())
+		assert.Equal(t, "false", status.Diffs()["enabled"].Original())
+		assert.Equal(t, "true", status.Diffs()["enabled"].Current())
+	})
+
+	t.Run("in state", func(t *testing.T) {
+		mgr := newFakeManager()
+		mgr.running["nginx"] = true
+		svc := service.New("nginx", "fake", service.StateRunning, nil, mgr)
+
+		status, err := svc.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusNoChange, status.StatusCode())
+	})
+
+	t.Run("only enabled", func(t *testing.T) {
+		mgr := newFakeManager()
+		mgr.enabled["nginx"] = true
+		svc := service.New("nginx", "fake", "", boolPtr(false), mgr)
+
+		status, err := svc.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		_, ok := status.Diffs()["state"]
+		assert.False(t, ok)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		mgr := newFakeManager()
+		mgr.fail = errors.New("no systemctl")
+		svc := service.New("nginx", "fake", service.StateRunning, nil, mgr)
+
+		_, err := svc.Check(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "no systemctl")
+	})
+}
+
+func TestServiceApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("start and enable", func(t *testing.T) {
+		mgr := newFakeManager()
+		svc := service.New("nginx", "fake", service.StateRunning, boolPtr(true), mgr)
+
+		status, err := svc.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"enable nginx", "start nginx"}, mgr.calls)
+		assert.Equal(t, []string{"enabled nginx", "started nginx"}, status.Messages())
+	})
+
+	t.Run("stop and disable", func(t *testing.T) {
+		mgr := newFakeManager()
+		mgr.running["nginx"] = true
+		mgr.enabled["nginx"] = true
+		svc := service.New("nginx", "fake", service.StateStopped, boolPtr(false), mgr)
+
+		_, err := svc.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"stop nginx", "disable nginx"}, mgr.calls)
+	})
+
+	t.Run("nothing to do", func(t *testing.T) {
+		mgr := newFakeManager()
+		mgr.running["nginx"] = true
+		svc := service.New("nginx", "fake", service.StateRunning, nil, mgr)
+
+		_, err := svc.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, mgr.calls)
+	})
+}
+
+func boolPtr(b bool) *bool { return &b }
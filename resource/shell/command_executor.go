@@ -15,6 +15,7 @@
 package shell
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
@@ -23,7 +24,9 @@ import (
 	"time"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/resource"
 	"github.com/pkg/errors"
+	"golang.org/x/net/context"
 )
 
 // NB: Known Bug with timed script execution:
@@ -41,9 +44,14 @@ import (
 // directly, so that if the script execution timed out we would still have a
 // reference to those buffers.
 var (
-	ErrTimedOut = errors.New("execution timed out")
+	ErrTimedOut    = errors.New("execution timed out")
+	ErrInterrupted = errors.New("execution interrupted")
 )
 
+// DefaultGracePeriod is how long an interrupted command is given to exit after
+// being asked to terminate, before it is killed
+const DefaultGracePeriod = 10 * time.Second
+
 // A CommandExecutor supports running a script and returning the results wrapped
 // in a *CommandResults structure.
 type CommandExecutor interface {
@@ -57,8 +65,18 @@ type CommandGenerator struct {
 	Dir         string
 	Env         []string
 	Timeout     *time.Duration
+	GracePeriod time.Duration
+	Umask       *uint32
+
+	// Stdin is piped to the script. When it is set the interpreter reads the
+	// script from scriptPath instead of from stdin.
+	Stdin string
 }
 
+// scriptPath is where the interpreter finds the script when stdin is taken by
+// data. It is the first of the command's extra files.
+const scriptPath = "/dev/fd/3"
+
 // Run will generate a new command and run it with optional timeout parameters
 func (cmd *CommandGenerator) Run(script string) (*CommandResults, error) {
 	ctx, err := cmd.start()
@@ -68,6 +86,44 @@ func (cmd *CommandGenerator) Run(script string) (*CommandResults, error) {
 	return ctx.Run(script, cmd.Timeout)
 }
 
+// RunContext is Run, but stops the command when ctx is cancelled. The command
+// is asked to terminate, and killed if it is still running after the grace
+// period. Whatever results were collected are returned with ErrInterrupted.
+func (cmd *CommandGenerator) RunContext(ctx context.Context, script string) (*CommandResults, error) {
+	c, err := cmd.start()
+	if err != nil {
+		return nil, err
+	}
+	if progress := resource.ProgressFrom(ctx); progress != nil {
+		c.Progress = progress
+	}
+
+	done := make(chan commandOutcome, 1)
+	go func() {
+		results, err := c.Run(script, cmd.Timeout)
+		done <- commandOutcome{results, err}
+	}()
+
+	select {
+	case outcome := <-done:
+		return outcome.Results, outcome.Err
+	case <-ctx.Done():
+	}
+
+	outcome := c.terminate(done, cmd.gracePeriod())
+	if outcome.Err != nil && outcome.Err != ErrTimedOut {
+		return outcome.Results, errors.Wrap(ErrInterrupted, outcome.Err.Error())
+	}
+	return outcome.Results, ErrInterrupted
+}
+
+func (cmd *CommandGenerator) gracePeriod() time.Duration {
+	if cmd.GracePeriod <= 0 {
+		return DefaultGracePeriod
+	}
+	return cmd.GracePeriod
+}
+
 func (cmd *CommandGenerator) start() (*commandIOContext, error) {
 	command := newCommand(cmd)
 	stdin, stdout, stderr, err := cmdGetPipes(command)
@@ -76,9 +132,17 @@ func (cmd *CommandGenerator) start() (*commandIOContext, error) {
 		Stdin:   stdin,
 		Stdout:  stdout,
 		Stderr:  stderr,
+		Data:    cmd.Stdin,
+		started: make(chan struct{}),
 	}, err
 }
 
+// commandOutcome carries the return values of a command run in the background
+type commandOutcome struct {
+	Results *CommandResults
+	Err     error
+}
+
 // commandIOContext provides the context for a command that includes it's stdin,
 // stdout, and stderr pipes along with the underlying command.
 type commandIOContext struct {
@@ -86,6 +150,16 @@ type commandIOContext struct {
 	Stdin   io.WriteCloser
 	Stdout  io.ReadCloser
 	Stderr  io.ReadCloser
+
+	// piped to stdin in place of the script, which is then written to
+	// scriptPath
+	Data string
+
+	// closed once the command has started
+	started chan struct{}
+
+	// receives a copy of stdout and stderr as they are read, if set
+	Progress io.Writer
 }
 
 // Run wraps exec and timeoutExec, executing the script with or without a
@@ -137,23 +211,60 @@ func (c *commandIOContext) exec(script string) (results *CommandResults, err err
 		}
 	}
 
+	input := script
+	var scriptIn, scriptOut *os.File
+	if c.Data != "" {
+		scriptIn, scriptOut, err = os.Pipe()
+		if err != nil {
+			err = errors.Wrap(err, "failed to get script pipe")
+			return
+		}
+		defer scriptIn.Close()
+		defer scriptOut.Close()
+
+		c.Command.ExtraFiles = []*os.File{scriptIn}
+		input = c.Data
+	}
+
 	if err = c.Command.Start(); err != nil {
 		return
 	}
-	if _, err = c.Stdin.Write([]byte(script)); err != nil {
+	if c.started != nil {
+		close(c.started)
+	}
+	if scriptOut != nil {
+		// the interpreter has its own copy of the read end
+		scriptIn.Close()
+
+		// the script is written alongside the data, since the interpreter may
+		// start reading stdin before it has read all of the script
+		go func() {
+			if _, err := scriptOut.Write([]byte(script)); err != nil {
+				log.WithField("module", "shell").WithError(err).Warn("cannot write script")
+			}
+			scriptOut.Close()
+		}()
+	}
+	if _, err = c.Stdin.Write([]byte(input)); err != nil {
 		return
 	}
 	if err = c.Stdin.Close(); err != nil {
 		return
 	}
 
-	if data, readErr := ioutil.ReadAll(c.Stdout); readErr == nil {
+	var stdout, stderr io.Reader = c.Stdout, c.Stderr
+	if c.Progress != nil {
+		stdout = io.TeeReader(stdout, c.Progress)
+		stderr = io.TeeReader(stderr, c.Progress)
+	}
+
+	if data, readErr := ioutil.ReadAll(stdout); readErr == nil {
 		results.Stdout = string(data)
 	} else {
 		log.WithField("module", "shell").Warn("cannot read stdout from script")
 	}
 
-	if data, readErr := ioutil.ReadAll(c.Stderr); readErr == nil {
+	if data, readErr := ioutil.ReadAll(stderr); readErr == nil {
 		results.Stderr = string(data)
 	} else {
 		log.WithField("module", "shell").Warn("cannot read stdout from script")
@@ -177,20 +288,64 @@ func (c *commandIOContext) exec(script string) (results *CommandResults, err err
 	return
 }
 
+// terminate stops a command started in the background, first with SIGTERM and
+// then, after the grace period, with SIGKILL. It returns the outcome of the
+// command once it has exited.
+func (c *commandIOContext) terminate(done <-chan commandOutcome, grace time.Duration) commandOutcome {
+	logger := log.WithField("module", "shell")
+
+	select {
+	case outcome := <-done:
+		return outcome
+	case <-c.started:
+	}
+
+	logger.WithField("pid", c.Command.Process.Pid).Info("terminating interrupted command")
+	if err := signalCommand(c.Command, syscall.SIGTERM); err != nil {
+		logger.WithError(err).Debug("could not terminate command")
+	}
+
+	select {
+	case outcome := <-done:
+		return outcome
+	case <-time.After(grace):
+	}
+
+	logger.WithField("pid", c.Command.Process.Pid).Warn("killing command that did not exit within the grace period")
+	if err := signalCommand(c.Command, syscall.SIGKILL); err != nil {
+		logger.WithError(err).Debug("could not kill command")
+	}
+
+	return <-done
+}
+
 func newCommand(cmd *CommandGenerator) *exec.Cmd {
-	var command *exec.Cmd
-	if cmd.Interpreter == "" {
-		if len(cmd.Flags) > 0 {
+	interpreter, flags := cmd.Interpreter, cmd.Flags
+	if interpreter == "" {
+		interpreter = defaultInterpreter
+		if len(flags) > 0 {
 			log.WithField("module", "shell").WithField("interpreter", "/bin/sh").Debug("passing flags to default interpreter")
-			command = exec.Command(defaultInterpreter, cmd.Flags...)
 		} else {
-			command = exec.Command(defaultInterpreter, defaultExecFlags...)
+			flags = defaultExecFlags
 		}
+	}
+
+	var command *exec.Cmd
+	if cmd.Umask != nil {
+		// the umask is set in a wrapping shell, since setting it in converge
+		// would affect every task running at the same time
+		wrapper := fmt.Sprintf(`umask %03o && exec "$0" "$@"`, *cmd.Umask)
+		command = exec.Command(defaultInterpreter, append([]string{"-c", wrapper, interpreter}, flags...)...)
 	} else {
-		command = exec.Command(cmd.Interpreter, cmd.Flags...)
+		command = exec.Command(interpreter, flags...)
+	}
+
+	if cmd.Stdin != "" {
+		command.Args = append(command.Args, scriptPath)
 	}
 
 	command.Dir = cmd.Dir
+	setProcessGroup(command)
 	if len(cmd.Env) > 0 {
 		env := os.Environ()
 		env = append(env, cmd.Env...)
@@ -21,9 +21,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/shell"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
 )
 
 func Test_Run_WhenScriptTimesOut_ReturnsTimeoutError(t *testing.T) {
@@ -67,6 +69,25 @@ exit 7`
 	assert.Equal(t, "stderr", result.Stderr)
 }
 
+func Test_Run_PipesStdinToScript(t *testing.T) {
+	script := `read line; echo -n "got $line"`
+	generator := &shell.CommandGenerator{Interpreter: "/bin/sh", Stdin: "data\n"}
+	result, err := generator.Run(script)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), result.ExitStatus)
+	assert.Equal(t, "got data", result.Stdout)
+}
+
+func Test_Run_PipesStdinToLargeScript(t *testing.T) {
+	// larger than a pipe buffer, so the data is read while the script is
+	// still being written
+	script := "cat\n" + strings.Repeat("true\n", 100000)
+	generator := &shell.CommandGenerator{Interpreter: "/bin/sh", Stdin: "data"}
+	result, err := generator.Run(script)
+	require.NoError(t, err)
+	assert.Equal(t, "data", result.Stdout)
+}
+
 func Test_Run_RunsWithSpecifiedInterpreter(t *testing.T) {
 	script := "echo -n 'foo'"
 	generator := &shell.CommandGenerator{Interpreter: "/bin/bash"}
@@ -90,6 +111,24 @@ func Test_Run_RunsInDir(t *testing.T) {
 	assert.Equal(t, tmpdir, pwd)
 }
 
+func Test_Run_RunsWithUmask(t *testing.T) {
+	umask := uint32(027)
+
+	t.Run("default interpreter", func(t *testing.T) {
+		generator := &shell.CommandGenerator{Umask: &umask}
+		result, err := generator.Run("umask")
+		assert.NoError(t, err)
+		assert.Equal(t, "0027", strings.TrimSpace(result.Stdout))
+	})
+
+	t.Run("custom interpreter", func(t *testing.T) {
+		generator := &shell.CommandGenerator{Interpreter: "/bin/bash", Flags: []string{"-e"}, Umask: &umask}
+		result, err := generator.Run("echo -n $- | grep -q e && umask")
+		assert.NoError(t, err)
+		assert.Equal(t, "0027", strings.TrimSpace(result.Stdout))
+	})
+}
+
 func Test_Run_RunsWithEnv(t *testing.T) {
 	script := "echo -n \"Role: $ROLE, Version: $VERSION\""
 	generator := &shell.CommandGenerator{
@@ -100,3 +139,51 @@ func Test_Run_RunsWithEnv(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "Role: test, Version: 0.1", result.Stdout)
 }
+
+func Test_RunContext_WhenNotCancelled_RunsScript(t *testing.T) {
+	script := "echo -n out"
+	generator := &shell.CommandGenerator{Interpreter: "/bin/sh"}
+	result, err := generator.RunContext(context.Background(), script)
+	assert.NoError(t, err)
+	assert.Equal(t, "out", result.Stdout)
+}
+
+func Test_RunContext_WithProgress_RecordsOutput(t *testing.T) {
+	script := "echo downloading; echo unpacking >&2"
+	generator := &shell.CommandGenerator{Interpreter: "/bin/sh"}
+
+	progress := new(resource.Progress)
+	result, err := generator.RunContext(resource.WithProgress(context.Background(), progress), script)
+	require.NoError(t, err)
+	assert.Equal(t, "downloading\n", result.Stdout)
+	assert.Equal(t, "unpacking", progress.Last())
+}
+
+func Test_RunContext_WhenCancelled_TerminatesScript(t *testing.T) {
+	script := "sleep 100"
+	generator := &shell.CommandGenerator{Interpreter: "/bin/sh"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := generator.RunContext(ctx, script)
+	assert.Equal(t, shell.ErrInterrupted, err)
+	assert.True(t, time.Since(start) < shell.DefaultGracePeriod, "script was not terminated")
+}
+
+func Test_RunContext_WhenTerminateIgnored_KillsScriptAfterGracePeriod(t *testing.T) {
+	script := "trap '' TERM; sleep 100"
+	generator := &shell.CommandGenerator{
+		Interpreter: "/bin/sh",
+		GracePeriod: 100 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := generator.RunContext(ctx, script)
+	assert.Equal(t, shell.ErrInterrupted, err)
+	assert.True(t, time.Since(start) < 5*time.Second, "script was not killed")
+}
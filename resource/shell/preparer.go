@@ -21,11 +21,13 @@ import (
 	"io"
 	"io/ioutil"
 	"os/exec"
+	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/fetch"
 	"github.com/asteris-llc/converge/helpers/transform"
 	"github.com/asteris-llc/converge/load/registry"
 	"github.com/asteris-llc/converge/resource"
@@ -57,12 +59,37 @@ type Preparer struct {
 	// the script to run to check if a resource needs to be changed. It should
 	// exit with exit code 0 if the resource does not need to be changed, and
 	// 1 (or above) otherwise.
-	Check string `hcl:"check" nonempty:"true"`
+	Check string `hcl:"check" mutually_exclusive:"check,check_script" nonempty:"true"`
+
+	// a file containing the check script, used instead of `check` to keep long
+	// scripts out of HCL strings. The path is resolved relative to the module
+	// and the contents are rendered as a template. The path and a hash of the
+	// rendered script are shown with the task's messages.
+	CheckScript string `hcl:"check_script" mutually_exclusive:"check,check_script" nonempty:"true"`
+
+	// a mapping of check exit codes to what they mean, one of `ok`,
+	// `will-change`, or `error`. For example, `grep` exits with 1 when
+	// nothing matched, which might mean the resource will change, and with 2
+	// on errors. Exit codes that aren't mapped mean `ok` for 0 and
+	// `will-change` for anything else.
+	CheckExitCodes map[string]string `hcl:"check_exit_codes"`
 
 	// the script to run to apply the resource. Normal shell exit code
 	// expectations apply (that is, exit code 0 for success, 1 or above for
 	// failure.)
-	Apply string `hcl:"apply" nonempty:"true"`
+	Apply string `hcl:"apply" mutually_exclusive:"apply,apply_script" nonempty:"true"`
+
+	// a file containing the apply script, used instead of `apply`. It is
+	// loaded and rendered the same way as `check_script`.
+	ApplyScript string `hcl:"apply_script" mutually_exclusive:"apply,apply_script" nonempty:"true"`
+
+	// the script to run to undo `apply` when a later member of the task's
+	// `transaction` fails. It only runs if the task was applied.
+	Rollback string `hcl:"rollback" mutually_exclusive:"rollback,rollback_script"`
+
+	// a file containing the rollback script, used instead of `rollback`. It is
+	// loaded and rendered the same way as `check_script`.
+	RollbackScript string `hcl:"rollback_script" mutually_exclusive:"rollback,rollback_script"`
 
 	// the amount of time the command will wait before halting forcefully.
 	Timeout *time.Duration `hcl:"timeout"`
@@ -70,8 +97,29 @@ type Preparer struct {
 	// the working directory this command should be run in
 	Dir string `hcl:"dir"`
 
+	// create the working directory, and any missing parents, before applying
+	// if it does not exist
+	CreateDir bool `hcl:"create_dir"`
+
+	// the umask the scripts are run with, specified in octal. The umask of
+	// converge is used by default.
+	Umask *uint32 `hcl:"umask" base:"8"`
+
 	// any environment variables that should be passed to the command
 	Env map[string]string `hcl:"env"`
+
+	// data piped to the check and apply scripts, for interpreters that read
+	// their input from stdin like `psql` or `kubectl apply -f -`. The
+	// interpreter is given the script as a path (`/dev/fd/3`) instead, so it
+	// must accept a script file as its last argument. A hash of the data is
+	// shown with the task's messages.
+	Stdin string `hcl:"stdin"`
+
+	// how disruptive running `apply` is, used to group changes in plan
+	// summaries. Changes are considered `low` impact by default. Setting this to
+	// `destructive` means the task is refused during apply unless destructive
+	// changes are allowed.
+	Impact string `hcl:"impact" valid_values:"low,service-restart,reboot,destructive"`
 }
 
 // Prepare a new shell task
@@ -83,23 +131,122 @@ func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resou
 		},
 	)
 
+	if p.CreateDir && p.Dir == "" {
+		return nil, errors.New("\"create_dir\" requires \"dir\"")
+	}
+
+	if p.Umask != nil && *p.Umask > 0777 {
+		return nil, fmt.Errorf("\"umask\" must be between 0000 and 0777, was %04o", *p.Umask)
+	}
+
 	generator := &CommandGenerator{
 		Interpreter: p.Interpreter,
 		Flags:       p.ExecFlags,
 		Dir:         p.Dir,
 		Env:         env,
 		Timeout:     p.Timeout,
+		Umask:       p.Umask,
+		Stdin:       p.Stdin,
 	}
 
 	shell := &Shell{
-		CmdGenerator: generator,
-		CheckStmt:    p.Check,
-		ApplyStmt:    p.Apply,
-		Dir:          p.Dir,
-		Env:          env,
+		CmdGenerator:   generator,
+		CheckStmt:      p.Check,
+		CheckScript:    p.CheckScript,
+		ApplyStmt:      p.Apply,
+		ApplyScript:    p.ApplyScript,
+		RollbackStmt:   p.Rollback,
+		RollbackScript: p.RollbackScript,
+		Dir:            p.Dir,
+		CreateDir:      p.CreateDir,
+		Env:            env,
+		Stdin:          p.Stdin,
+		ChangeImpact:   resource.ImpactLow,
+	}
+
+	if len(p.CheckExitCodes) > 0 {
+		codes, err := parseExitCodes(p.CheckExitCodes)
+		if err != nil {
+			return nil, err
+		}
+		shell.CheckExitCodes = codes
+	}
+
+	if p.Impact != "" {
+		impact, err := resource.ParseImpact(p.Impact)
+		if err != nil {
+			return nil, err
+		}
+		shell.ChangeImpact = impact
+	}
+
+	if p.CheckScript != "" {
+		stmt, err := loadScript(ctx, render, "check_script", p.CheckScript)
+		if err != nil {
+			return nil, err
+		}
+		shell.CheckStmt = stmt
+	}
+
+	if p.ApplyScript != "" {
+		stmt, err := loadScript(ctx, render, "apply_script", p.ApplyScript)
+		if err != nil {
+			return nil, err
+		}
+		shell.ApplyStmt = stmt
+	}
+
+	if p.RollbackScript != "" {
+		stmt, err := loadScript(ctx, render, "rollback_script", p.RollbackScript)
+		if err != nil {
+			return nil, err
+		}
+		shell.RollbackStmt = stmt
+	}
+
+	return shell, checkSyntax(p.Interpreter, p.CheckFlags, shell.CheckStmt)
+}
+
+// exitCodeLevels are the statuses check exit codes can be mapped to
+var exitCodeLevels = map[string]resource.StatusLevel{
+	"ok":          resource.StatusNoChange,
+	"will-change": resource.StatusWillChange,
+	"error":       resource.StatusFatal,
+}
+
+// parseExitCodes converts the check_exit_codes mapping to statuses
+func parseExitCodes(in map[string]string) (map[uint32]resource.StatusLevel, error) {
+	out := make(map[uint32]resource.StatusLevel, len(in))
+	for code, meaning := range in {
+		parsed, err := strconv.ParseUint(code, 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("\"check_exit_codes\" key %q is not an exit code", code)
+		}
+
+		level, ok := exitCodeLevels[meaning]
+		if !ok {
+			return nil, fmt.Errorf("\"check_exit_codes\" value %q for %s must be one of ok, will-change, error", meaning, code)
+		}
+
+		out[uint32(parsed)] = level
+	}
+	return out, nil
+}
+
+// loadScript fetches a script file, resolved relative to the module being
+// rendered when the renderer knows it, and renders its contents as a template
+func loadScript(ctx context.Context, render resource.Renderer, name, loc string) (string, error) {
+	url, err := render.ResolvePath(loc)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not resolve %q", name)
+	}
+
+	content, err := fetch.Any(ctx, url)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not load %q from %s", name, url)
 	}
 
-	return shell, checkSyntax(p.Interpreter, p.CheckFlags, p.Check)
+	return render.Render(name, string(content))
 }
 
 func checkSyntax(interpreter string, flags []string, script string) error {
@@ -15,12 +15,16 @@
 package shell_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/asteris-llc/converge/helpers/fakerenderer"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/shell"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/net/context"
 )
 
@@ -57,6 +61,115 @@ func Test_Prepare_ReturnsError_WhenSyntaxError(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func Test_Prepare_ReturnsError_WhenCreateDirWithoutDir(t *testing.T) {
+	t.Parallel()
+	p := shPreparer("true")
+	p.Dir = ""
+	p.CreateDir = true
+	_, err := p.Prepare(context.Background(), fakerenderer.New())
+	assert.EqualError(t, err, "\"create_dir\" requires \"dir\"")
+}
+
+func Test_Prepare_ReturnsError_WhenUmaskOutOfRange(t *testing.T) {
+	t.Parallel()
+	umask := uint32(01000)
+	p := shPreparer("true")
+	p.Umask = &umask
+	_, err := p.Prepare(context.Background(), fakerenderer.New())
+	assert.EqualError(t, err, "\"umask\" must be between 0000 and 0777, was 1000")
+}
+
+func Test_Prepare_ParsesCheckExitCodes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		p := shPreparer("true")
+		p.CheckExitCodes = map[string]string{"1": "will-change", "2": "error", "3": "ok"}
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(
+			t,
+			map[uint32]resource.StatusLevel{
+				1: resource.StatusWillChange,
+				2: resource.StatusFatal,
+				3: resource.StatusNoChange,
+			},
+			task.(*shell.Shell).CheckExitCodes,
+		)
+	})
+
+	t.Run("invalid code", func(t *testing.T) {
+		p := shPreparer("true")
+		p.CheckExitCodes = map[string]string{"256": "ok"}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "\"check_exit_codes\" key \"256\" is not an exit code")
+	})
+
+	t.Run("invalid meaning", func(t *testing.T) {
+		p := shPreparer("true")
+		p.CheckExitCodes = map[string]string{"1": "changed"}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "\"check_exit_codes\" value \"changed\" for 1 must be one of ok, will-change, error")
+	})
+}
+
+func Test_Prepare_SetsImpact(t *testing.T) {
+	t.Parallel()
+
+	t.Run("default", func(t *testing.T) {
+		p := shPreparer("true")
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.ImpactLow, task.(*shell.Shell).ChangeImpact)
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		p := shPreparer("true")
+		p.Impact = "service-restart"
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.ImpactServiceRestart, task.(*shell.Shell).ChangeImpact)
+	})
+}
+
+func Test_Prepare_LoadsScriptsRelativeToModule(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "converge-shell-script")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "check.sh"), []byte("test -f /tmp"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "apply.sh"), []byte("touch /tmp"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "rollback.sh"), []byte("rm /tmp"), 0644))
+
+	p := shPreparer("")
+	p.Check = ""
+	p.CheckScript = "check.sh"
+	p.ApplyScript = "apply.sh"
+	p.RollbackScript = "rollback.sh"
+
+	r := fakerenderer.NewWithSource("file://" + filepath.Join(dir, "main.hcl"))
+	task, err := p.Prepare(context.Background(), r)
+	require.NoError(t, err)
+
+	sh, ok := task.(*shell.Shell)
+	require.True(t, ok)
+	assert.Equal(t, "test -f /tmp", sh.CheckStmt)
+	assert.Equal(t, "touch /tmp", sh.ApplyStmt)
+	assert.Equal(t, "check.sh", sh.CheckScript)
+	assert.Equal(t, "apply.sh", sh.ApplyScript)
+	assert.Equal(t, "rm /tmp", sh.RollbackStmt)
+	assert.Equal(t, "rollback.sh", sh.RollbackScript)
+}
+
+func Test_Prepare_ReturnsError_WhenScriptMissing(t *testing.T) {
+	t.Parallel()
+	p := shPreparer("")
+	p.CheckScript = "/nonexistent/converge/check.sh"
+	_, err := p.Prepare(context.Background(), fakerenderer.New())
+	assert.Error(t, err)
+}
+
 func shPreparer(script string) *shell.Preparer {
 	syntaxFlag := []string{"-n"}
 	return &shell.Preparer{
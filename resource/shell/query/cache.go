@@ -0,0 +1,224 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/resource/shell"
+	"golang.org/x/net/context"
+)
+
+// cacheEntry is a cached query result
+type cacheEntry struct {
+	Expires    time.Time `json:"expires"`
+	ExitStatus uint32    `json:"exit_status"`
+	Stdout     string    `json:"stdout"`
+	Stderr     string    `json:"stderr"`
+	Stdin      string    `json:"stdin"`
+}
+
+func (e *cacheEntry) results() *shell.CommandResults {
+	return &shell.CommandResults{
+		ExitStatus: e.ExitStatus,
+		Stdout:     e.Stdout,
+		Stderr:     e.Stderr,
+		Stdin:      e.Stdin,
+	}
+}
+
+var (
+	memoryCache   = map[string]*cacheEntry{}
+	memoryCacheMu sync.Mutex
+)
+
+// remember keeps entry in memory under key, dropping any entries that have
+// expired so the cache only holds results that can still be used
+func remember(key string, entry *cacheEntry, now time.Time) {
+	memoryCacheMu.Lock()
+	defer memoryCacheMu.Unlock()
+
+	for k, e := range memoryCache {
+		if !now.Before(e.Expires) {
+			delete(memoryCache, k)
+		}
+	}
+
+	memoryCache[key] = entry
+}
+
+// cachingExecutor runs a query through another executor, and reuses its
+// successful results until they expire. Results are kept in memory, so they
+// are shared by the plan and apply phases, and optionally on disk, so they
+// are shared across runs.
+type cachingExecutor struct {
+	executor shell.CommandExecutor
+	key      string
+	ttl      time.Duration
+	dir      string // where results are persisted; not persisted if empty
+}
+
+// Run runs the script unless there is a cached result
+func (c *cachingExecutor) Run(script string) (*shell.CommandResults, error) {
+	return c.RunContext(nil, script)
+}
+
+// RunContext runs the script unless there is a cached result, stopping it if
+// ctx is cancelled when the executor supports it
+func (c *cachingExecutor) RunContext(ctx context.Context, script string) (*shell.CommandResults, error) {
+	if entry, ok := c.lookup(); ok {
+		return entry.results(), nil
+	}
+
+	type contextRunner interface {
+		RunContext(context.Context, string) (*shell.CommandResults, error)
+	}
+
+	var (
+		results *shell.CommandResults
+		err     error
+	)
+	if runner, ok := c.executor.(contextRunner); ok && ctx != nil {
+		results, err = runner.RunContext(ctx, script)
+	} else {
+		results, err = c.executor.Run(script)
+	}
+
+	// only successful queries are cached, so failures are retried
+	if err == nil && results != nil && results.ExitStatus == 0 {
+		c.store(&cacheEntry{
+			Expires:    time.Now().Add(c.ttl),
+			ExitStatus: results.ExitStatus,
+			Stdout:     results.Stdout,
+			Stderr:     results.Stderr,
+			Stdin:      results.Stdin,
+		})
+	}
+
+	return results, err
+}
+
+func (c *cachingExecutor) lookup() (*cacheEntry, bool) {
+	now := time.Now()
+
+	memoryCacheMu.Lock()
+	entry, ok := memoryCache[c.key]
+	if ok && !now.Before(entry.Expires) {
+		delete(memoryCache, c.key)
+		ok = false
+	}
+	memoryCacheMu.Unlock()
+
+	if ok {
+		return entry, true
+	}
+
+	if c.dir == "" {
+		return nil, false
+	}
+
+	data, err := ioutil.ReadFile(c.path())
+	if err != nil {
+		return nil, false
+	}
+
+	entry = new(cacheEntry)
+	if err := json.Unmarshal(data, entry); err != nil || !now.Before(entry.Expires) {
+		return nil, false
+	}
+
+	remember(c.key, entry, now)
+
+	return entry, true
+}
+
+func (c *cachingExecutor) store(entry *cacheEntry) {
+	remember(c.key, entry, time.Now())
+
+	if c.dir == "" {
+		return
+	}
+
+	if err := c.persist(entry); err != nil {
+		log.WithField("module", "query").WithError(err).Debug("could not persist query result")
+	}
+}
+
+// persist writes the entry to disk atomically, readable only by the owner
+func (c *cachingExecutor) persist(entry *cacheEntry) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(c.dir, c.key+".tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path())
+}
+
+func (c *cachingExecutor) path() string {
+	return filepath.Join(c.dir, c.key+".json")
+}
+
+// cacheKey identifies a query by everything that affects its result
+func cacheKey(p *Preparer) string {
+	env := make([]string, 0, len(p.Env))
+	for k, v := range p.Env {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+
+	hsh := sha256.New()
+	fmt.Fprintf(hsh, "%q\n%q\n%q\n%q\n%q\n", p.Interpreter, p.ExecFlags, p.Dir, env, p.Query)
+	return hex.EncodeToString(hsh.Sum(nil))
+}
+
+// defaultCacheDir is where query results are persisted across runs
+func defaultCacheDir() (string, error) {
+	usr, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(usr.HomeDir, ".converge/cache/query"), nil
+}
@@ -0,0 +1,141 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/resource/shell"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingExecutor returns the number of times it has been run
+type countingExecutor struct {
+	runs       int
+	exitStatus uint32
+}
+
+func (c *countingExecutor) Run(script string) (*shell.CommandResults, error) {
+	c.runs++
+	return &shell.CommandResults{
+		ExitStatus: c.exitStatus,
+		Stdout:     strconv.Itoa(c.runs),
+		Stdin:      script,
+	}, nil
+}
+
+// TestCachingExecutor tests caching query results
+func TestCachingExecutor(t *testing.T) {
+	t.Run("reuses results", func(t *testing.T) {
+		inner := new(countingExecutor)
+		c := &cachingExecutor{executor: inner, key: t.Name(), ttl: time.Hour}
+
+		fst, err := c.Run("query")
+		require.NoError(t, err)
+		snd, err := c.Run("query")
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, inner.runs)
+		assert.Equal(t, "1", fst.Stdout)
+		assert.Equal(t, "1", snd.Stdout)
+		assert.False(t, fst == snd, "cached results should not be shared")
+	})
+
+	t.Run("expires", func(t *testing.T) {
+		inner := new(countingExecutor)
+		c := &cachingExecutor{executor: inner, key: t.Name(), ttl: time.Nanosecond}
+
+		_, err := c.Run("query")
+		require.NoError(t, err)
+		time.Sleep(time.Millisecond)
+		result, err := c.Run("query")
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, inner.runs)
+		assert.Equal(t, "2", result.Stdout)
+	})
+
+	t.Run("evicts expired results", func(t *testing.T) {
+		stale := &cachingExecutor{executor: new(countingExecutor), key: t.Name() + "/stale", ttl: time.Nanosecond}
+		fresh := &cachingExecutor{executor: new(countingExecutor), key: t.Name() + "/fresh", ttl: time.Hour}
+
+		_, err := stale.Run("query")
+		require.NoError(t, err)
+		time.Sleep(time.Millisecond)
+		_, err = fresh.Run("query")
+		require.NoError(t, err)
+
+		memoryCacheMu.Lock()
+		_, staleKept := memoryCache[stale.key]
+		_, freshKept := memoryCache[fresh.key]
+		memoryCacheMu.Unlock()
+
+		assert.False(t, staleKept, "expired results should be dropped")
+		assert.True(t, freshKept)
+	})
+
+	t.Run("does not cache failures", func(t *testing.T) {
+		inner := &countingExecutor{exitStatus: 1}
+		c := &cachingExecutor{executor: inner, key: t.Name(), ttl: time.Hour}
+
+		_, err := c.Run("query")
+		require.NoError(t, err)
+		_, err = c.Run("query")
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, inner.runs)
+	})
+
+	t.Run("persists", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "converge-query-cache")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		inner := new(countingExecutor)
+		c := &cachingExecutor{executor: inner, key: "persisted", ttl: time.Hour, dir: dir}
+
+		_, err = c.Run("query")
+		require.NoError(t, err)
+
+		stat, err := os.Stat(c.path())
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+
+		// a new run starts with an empty memory cache
+		memoryCacheMu.Lock()
+		delete(memoryCache, "persisted")
+		memoryCacheMu.Unlock()
+
+		result, err := c.Run("query")
+		require.NoError(t, err)
+		assert.Equal(t, 1, inner.runs)
+		assert.Equal(t, "1", result.Stdout)
+	})
+}
+
+// TestCacheKey tests that keys depend on everything that affects a query
+func TestCacheKey(t *testing.T) {
+	base := &Preparer{Query: "echo a", Env: map[string]string{"A": "1", "B": "2"}}
+
+	assert.Equal(t, cacheKey(base), cacheKey(&Preparer{Query: "echo a", Env: map[string]string{"B": "2", "A": "1"}}))
+	assert.NotEqual(t, cacheKey(base), cacheKey(&Preparer{Query: "echo b", Env: base.Env}))
+	assert.NotEqual(t, cacheKey(base), cacheKey(&Preparer{Query: "echo a", Env: base.Env, Dir: "/tmp"}))
+	assert.NotEqual(t, cacheKey(base), cacheKey(&Preparer{Query: "echo a"}))
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/asteris-llc/converge/load/registry"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/shell"
+	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 )
 
@@ -33,6 +34,15 @@ type Preparer struct {
 	Timeout     *time.Duration    `hcl:"timeout"`
 	Dir         string            `hcl:"dir"`
 	Env         map[string]string `hcl:"env"`
+
+	// CacheTTL is how long a successful result of the query is reused
+	// instead of running the query again. Results are shared by the plan and
+	// apply phases. Queries are not cached by default.
+	CacheTTL *time.Duration `hcl:"cache_ttl"`
+
+	// CachePersist keeps cached results on disk, in ~/.converge/cache/query,
+	// so they are reused across runs until they expire
+	CachePersist bool `hcl:"cache_persist"`
 }
 
 // Prepare creates a new query type
@@ -58,6 +68,25 @@ func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resou
 		return &Query{}, fmt.Errorf("expected *shell.Shell but got %T", task)
 	}
 
+	if p.CacheTTL != nil && *p.CacheTTL > 0 {
+		executor := &cachingExecutor{
+			executor: shell.CmdGenerator,
+			key:      cacheKey(p),
+			ttl:      *p.CacheTTL,
+		}
+
+		if p.CachePersist {
+			executor.dir, err = defaultCacheDir()
+			if err != nil {
+				return &Query{}, errors.Wrap(err, "could not find the query cache directory")
+			}
+		}
+
+		shell.CmdGenerator = executor
+	} else if p.CachePersist {
+		return &Query{}, errors.New("\"cache_persist\" requires \"cache_ttl\"")
+	}
+
 	return &Query{Shell: shell}, nil
 }
 
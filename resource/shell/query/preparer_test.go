@@ -16,10 +16,14 @@ package query_test
 
 import (
 	"testing"
+	"time"
 
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/shell/query"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
 )
 
 // TestPreparerImplementsResourceInterface tests that the Preparer interface
@@ -28,3 +32,43 @@ func TestPreparerImplementsResourceInterface(t *testing.T) {
 	t.Parallel()
 	assert.Implements(t, (*resource.Resource)(nil), new(query.Preparer))
 }
+
+// TestPreparerCache tests that queries with a cache_ttl share results between
+// prepared tasks
+func TestPreparerCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("shared", func(t *testing.T) {
+		ttl := time.Hour
+		p := &query.Preparer{Query: "echo $$", CacheTTL: &ttl}
+
+		fst := checkQuery(t, p)
+		snd := checkQuery(t, p)
+		assert.Equal(t, fst, snd)
+	})
+
+	t.Run("not cached", func(t *testing.T) {
+		p := &query.Preparer{Query: "echo $$"}
+
+		fst := checkQuery(t, p)
+		snd := checkQuery(t, p)
+		assert.NotEqual(t, fst, snd)
+	})
+
+	t.Run("persist without ttl", func(t *testing.T) {
+		p := &query.Preparer{Query: "true", CachePersist: true}
+
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "\"cache_persist\" requires \"cache_ttl\"")
+	})
+}
+
+func checkQuery(t *testing.T, p *query.Preparer) string {
+	task, err := p.Prepare(context.Background(), fakerenderer.New())
+	require.NoError(t, err)
+
+	_, err = task.Check(context.Background(), fakerenderer.New())
+	require.NoError(t, err)
+
+	return task.(*query.Query).Status.Stdout
+}
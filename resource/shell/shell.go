@@ -15,10 +15,13 @@
 package shell
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 )
 
@@ -29,15 +32,40 @@ type Shell struct {
 	// the check statement
 	CheckStmt string `export:"check"`
 
+	// the file the check statement was loaded from, if any
+	CheckScript string `export:"checkscript"`
+
 	// the apply statement
 	ApplyStmt string `export:"apply"`
 
+	// the file the apply statement was loaded from, if any
+	ApplyScript string `export:"applyscript"`
+
+	// the rollback statement
+	RollbackStmt string `export:"rollback"`
+
+	// the file the rollback statement was loaded from, if any
+	RollbackScript string `export:"rollbackscript"`
+
 	// the working directory of the task
 	Dir string `export:"dir"`
 
+	// whether the working directory is created before applying
+	CreateDir bool `export:"createdir"`
+
 	// environment variables configured for the task
 	Env []string `export:"env"`
 
+	// the data piped to the check and apply scripts
+	Stdin string
+
+	// the statuses that check exit codes map to. Unmapped codes mean no change
+	// for 0 and will change for anything else.
+	CheckExitCodes map[uint32]resource.StatusLevel
+
+	// how disruptive applying the task is
+	ChangeImpact resource.Impact
+
 	// the status of the task
 	Status *CommandResults `re-export-as:"status"`
 
@@ -55,7 +83,7 @@ type Shell struct {
 // Check passes through to shell.Shell.Check() and then sets the health status
 func (s *Shell) Check(ctx context.Context, r resource.Renderer) (resource.TaskStatus, error) {
 	s.renderer = r
-	results, err := s.CmdGenerator.Run(s.CheckStmt)
+	results, err := s.run(ctx, s.CheckStmt)
 	if err != nil {
 		return nil, err
 	}
@@ -65,6 +93,9 @@ func (s *Shell) Check(ctx context.Context, r resource.Renderer) (resource.TaskSt
 	if s.CheckStatus == nil {
 		s.CheckStatus = results
 	}
+	if level, ok := s.CheckExitCodes[results.ExitStatus]; ok && level == resource.StatusFatal {
+		return s, fmt.Errorf("check exited with status %d", results.ExitStatus)
+	}
 	return s, nil
 }
 
@@ -76,6 +107,12 @@ func (s *Shell) ExportedFields() resource.FieldMap {
 	return s.exportedFields
 }
 
+// ComputedFields returns the status, since the results of the apply script are
+// only known once it has run
+func (s *Shell) ComputedFields() []string {
+	return []string{"status"}
+}
+
 // UpdateExportedFields is a nop
 func (s *Shell) UpdateExportedFields(resource.Task) error {
 	fields, err := resource.LookupMapFromStruct(s)
@@ -87,17 +124,54 @@ func (s *Shell) UpdateExportedFields(resource.Task) error {
 }
 
 // Apply is a NOP for health checks
-func (s *Shell) Apply(context.Context) (resource.TaskStatus, error) {
+func (s *Shell) Apply(ctx context.Context) (resource.TaskStatus, error) {
 	if cg, ok := s.CmdGenerator.(*CommandGenerator); ok {
 		s.CmdGenerator = cg
 	}
-	results, err := s.CmdGenerator.Run(s.ApplyStmt)
+	if s.CreateDir && s.Dir != "" {
+		if err := os.MkdirAll(s.Dir, 0755); err != nil {
+			return s, errors.Wrapf(err, "could not create working directory %s", s.Dir)
+		}
+	}
+	results, err := s.run(ctx, s.ApplyStmt)
 	if err == nil {
 		s.Status = s.Status.Cons("apply", results)
 	}
 	return s, err
 }
 
+// Rollback runs the rollback statement to undo Apply
+func (s *Shell) Rollback(ctx context.Context) error {
+	if s.RollbackStmt == "" {
+		return errors.New("no rollback script is set")
+	}
+
+	results, err := s.run(ctx, s.RollbackStmt)
+	if err != nil {
+		return err
+	}
+	if results.ExitStatus != 0 {
+		if stderr := strings.TrimSpace(results.Stderr); stderr != "" {
+			return fmt.Errorf("rollback exited with status %d: %s", results.ExitStatus, stderr)
+		}
+		return fmt.Errorf("rollback exited with status %d", results.ExitStatus)
+	}
+	return nil
+}
+
+// run executes a script, stopping it if ctx is cancelled when the executor
+// supports it
+func (s *Shell) run(ctx context.Context, script string) (*CommandResults, error) {
+	type contextRunner interface {
+		RunContext(context.Context, string) (*CommandResults, error)
+	}
+
+	if runner, ok := s.CmdGenerator.(contextRunner); ok && ctx != nil {
+		return runner.RunContext(ctx, script)
+	}
+	return s.CmdGenerator.Run(script)
+}
+
 // resource.TaskStatus functions
 
 // Value provides a value for the shell, which is the stdout data from the last
@@ -107,17 +181,36 @@ func (s *Shell) Value() string {
 }
 
 // Diffs is required to implement resource.TaskStatus but there is no mechanism
-// for defining diffs for shell operations, so returns a nil map.
+// for defining diffs for shell operations, so returns a nil map. What a task
+// changes is only known to its check script.
 func (s *Shell) Diffs() map[string]resource.Diff {
 	return nil
 }
 
+// scriptMessage describes a script file by its path and the hash of its
+// rendered contents
+func scriptMessage(name, path, stmt string) string {
+	return fmt.Sprintf("%s (%s, sha256:%x)", name, path, sha256.Sum256([]byte(stmt)))
+}
+
 // StatusCode returns the status code of the most recently executed command
 func (s *Shell) StatusCode() resource.StatusLevel {
 	if s.Status == nil {
 		return resource.StatusFatal
 	}
 
+	return s.level()
+}
+
+// level interprets the exit status of the most recently executed command,
+// looking up the exit status of check in CheckExitCodes first
+func (s *Shell) level() resource.StatusLevel {
+	if s.Status.Operation == "check" {
+		if level, ok := s.CheckExitCodes[s.Status.ExitStatus]; ok {
+			return level
+		}
+	}
+
 	if s.Status.ExitStatus == 0 {
 		return resource.StatusNoChange
 	}
@@ -140,6 +233,18 @@ func (s *Shell) Messages() (messages []string) {
 		messages = append(messages, fmt.Sprintf("env (%s)", strings.Join(s.Env, " ")))
 	}
 
+	if s.CheckScript != "" {
+		messages = append(messages, scriptMessage("check_script", s.CheckScript, s.CheckStmt))
+	}
+
+	if s.ApplyScript != "" {
+		messages = append(messages, scriptMessage("apply_script", s.ApplyScript, s.ApplyStmt))
+	}
+
+	if s.Stdin != "" {
+		messages = append(messages, fmt.Sprintf("stdin (sha256:%x)", sha256.Sum256([]byte(s.Stdin))))
+	}
+
 	messages = append(messages, s.Status.Reverse().UniqOp().SummarizeAll()...)
 	return
 }
@@ -150,7 +255,41 @@ func (s *Shell) HasChanges() bool {
 	if s.Status == nil {
 		return false
 	}
-	return (s.Status.ExitStatus != 0)
+	return s.level() == resource.StatusWillChange
+}
+
+// Impact returns how disruptive applying the task will be, or none if there is
+// nothing to apply
+func (s *Shell) Impact() resource.Impact {
+	if !s.HasChanges() {
+		return resource.ImpactNone
+	}
+	if s.ChangeImpact == resource.ImpactNone {
+		return resource.ImpactLow
+	}
+	return s.ChangeImpact
+}
+
+// Destructive describes why applying the task is destructive, if it was
+// configured with a destructive impact
+func (s *Shell) Destructive() []string {
+	if s.Impact() != resource.ImpactDestructive {
+		return nil
+	}
+	return []string{"task is marked as destructive"}
+}
+
+// Usage returns the combined resource usage of the apply commands run by this
+// task. Checks are left out, since they run whether or not anything changes.
+func (s *Shell) Usage() *resource.Usage {
+	var usage *resource.Usage
+	for cur := s.Status; cur != nil; cur = cur.ResultsContext.Next {
+		if cur.Operation != "apply" {
+			continue
+		}
+		usage = usage.Add(resource.UsageFromProcessState(cur.State))
+	}
+	return usage
 }
 
 // healthcheck.Check functions
@@ -16,6 +16,10 @@ package shell_test
 
 import (
 	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 
 	"github.com/asteris-llc/converge/healthcheck"
@@ -25,6 +29,7 @@ import (
 	"github.com/asteris-llc/converge/resource/shell"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/net/context"
 )
 
@@ -34,6 +39,7 @@ func Test_Shell_ImplementsTaskInterface(t *testing.T) {
 	t.Parallel()
 	assert.Implements(t, (*resource.Task)(nil), new(shell.Shell))
 	assert.Implements(t, (*healthcheck.Check)(nil), new(shell.Shell))
+	assert.Implements(t, (*resource.Rollbacker)(nil), new(shell.Shell))
 }
 
 // Check
@@ -114,6 +120,61 @@ func Test_Apply_CallsRunWithApplyStatement(t *testing.T) {
 	m.AssertCalled(t, "Run", statement)
 }
 
+func Test_Apply_CreatesDir(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "test-shell-create-dir")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(tmpdir)) }()
+
+	dir := filepath.Join(tmpdir, "a", "b")
+	m := defaultExecutor()
+	sh := &shell.Shell{ApplyStmt: "true", CmdGenerator: m, Dir: dir, CreateDir: true}
+
+	_, err = sh.Apply(context.Background())
+	require.NoError(t, err)
+
+	stat, err := os.Stat(dir)
+	require.NoError(t, err)
+	assert.True(t, stat.IsDir())
+}
+
+func Test_Apply_WithoutCreateDir_DoesNotCreateDir(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "test-shell-create-dir")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(tmpdir)) }()
+
+	dir := filepath.Join(tmpdir, "a")
+	m := defaultExecutor()
+	sh := &shell.Shell{ApplyStmt: "true", CmdGenerator: m, Dir: dir}
+
+	sh.Apply(context.Background())
+
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+// Rollback
+
+func Test_Rollback_CallsRunWithRollbackStatement(t *testing.T) {
+	statement := "test statement"
+	m := defaultExecutor()
+	sh := &shell.Shell{RollbackStmt: statement, CmdGenerator: m}
+	assert.NoError(t, sh.Rollback(context.Background()))
+	m.AssertCalled(t, "Run", statement)
+}
+
+func Test_Rollback_WithoutStatement_ReturnsError(t *testing.T) {
+	m := defaultExecutor()
+	sh := testShell(m)
+	assert.EqualError(t, sh.Rollback(context.Background()), "no rollback script is set")
+	m.AssertNotCalled(t, "Run", any)
+}
+
+func Test_Rollback_WhenExitStatusNonZero_ReturnsError(t *testing.T) {
+	m := resultExecutor(&shell.CommandResults{ExitStatus: 2, Stderr: "nope\n"})
+	sh := &shell.Shell{RollbackStmt: "false", CmdGenerator: m}
+	assert.EqualError(t, sh.Rollback(context.Background()), "rollback exited with status 2: nope")
+}
+
 // Value
 
 func Test_Value_ReturnsStdoutOfMostRecentStatus(t *testing.T) {
@@ -124,6 +185,19 @@ func Test_Value_ReturnsStdoutOfMostRecentStatus(t *testing.T) {
 	assert.Equal(t, expected, sh.Value())
 }
 
+func Test_Usage_CountsOnlyApply(t *testing.T) {
+	cmd := exec.Command("true")
+	require.NoError(t, cmd.Run())
+
+	var status *shell.CommandResults
+	status = status.Cons("check", &shell.CommandResults{State: cmd.ProcessState})
+	sh := &shell.Shell{Status: status}
+	assert.Nil(t, sh.Usage())
+
+	sh.Status = sh.Status.Cons("apply", &shell.CommandResults{State: cmd.ProcessState})
+	assert.Equal(t, resource.UsageFromProcessState(cmd.ProcessState), sh.Usage())
+}
+
 // Diffs
 
 func Test_Diffs_ReturnsEmptyMap(t *testing.T) {
@@ -131,6 +205,13 @@ func Test_Diffs_ReturnsEmptyMap(t *testing.T) {
 	assert.Equal(t, 0, len(sh.Diffs()))
 }
 
+func Test_Diffs_IgnoresScripts(t *testing.T) {
+	sh := defaultTestShell()
+	sh.CheckStmt = "true"
+	sh.CheckScript = "check.sh"
+	assert.Equal(t, 0, len(sh.Diffs()))
+}
+
 // StatusCode
 
 func Test_StatusCode_WhenNoStatus_ReturnsFatal(t *testing.T) {
@@ -151,6 +232,35 @@ func TestStatusCodeWhenMultipleStatusReturnsMostRecentStatus(t *testing.T) {
 	assert.Equal(t, resource.StatusWillChange, sh.StatusCode())
 }
 
+func Test_StatusCode_UsesCheckExitCodes(t *testing.T) {
+	codes := map[uint32]resource.StatusLevel{
+		1: resource.StatusWillChange,
+		3: resource.StatusNoChange,
+	}
+
+	sh := &shell.Shell{Status: ranAs("check", 3), CheckExitCodes: codes}
+	assert.Equal(t, resource.StatusNoChange, sh.StatusCode())
+	assert.False(t, sh.HasChanges())
+
+	// unmapped codes keep their default meaning
+	sh = &shell.Shell{Status: ranAs("check", 4), CheckExitCodes: codes}
+	assert.Equal(t, resource.StatusWillChange, sh.StatusCode())
+	assert.True(t, sh.HasChanges())
+
+	// apply isn't affected
+	sh = &shell.Shell{Status: ranAs("apply", 3), CheckExitCodes: codes}
+	assert.Equal(t, resource.StatusWillChange, sh.StatusCode())
+}
+
+func Test_Check_WhenExitCodeMapsToError_ReturnsError(t *testing.T) {
+	m := &MockExecutor{}
+	m.On("Run", any).Return(&shell.CommandResults{ExitStatus: 2}, nil)
+	sh := testShell(m)
+	sh.CheckExitCodes = map[uint32]resource.StatusLevel{2: resource.StatusFatal}
+	_, err := sh.Check(context.Background(), fakerenderer.New())
+	assert.EqualError(t, err, "check exited with status 2")
+}
+
 // TestStatusCodeWhenExitStatusZero verifies that StatusCode returns
 // StatusNoChanges when a shell command has a zero exit code
 func TestStatusCodeWhenExitStatusZero(t *testing.T) {
@@ -167,6 +277,38 @@ func TestStatusCodeWhenExitStatusNonZero(t *testing.T) {
 	assert.Equal(t, resource.StatusWillChange, sh.StatusCode())
 }
 
+func Test_Messages_Includes_StdinHash(t *testing.T) {
+	sh := &shell.Shell{Stdin: "data", Status: &shell.CommandResults{}}
+	assert.Equal(t, 0, len(sh.Diffs()))
+	assert.Contains(t, sh.Messages(), "stdin (sha256:3a6eb0790f39ac87c94f3856b2dd2c5d110e6811602261a9a923d3bb23adc8b7)")
+}
+
+func Test_Impact_WhenNoChanges_ReturnsNone(t *testing.T) {
+	sh := &shell.Shell{
+		Status:       &shell.CommandResults{ExitStatus: 0},
+		ChangeImpact: resource.ImpactReboot,
+	}
+	assert.Equal(t, resource.ImpactNone, sh.Impact())
+	assert.Nil(t, sh.Destructive())
+}
+
+func Test_Impact_WhenChanges_ReturnsConfiguredImpact(t *testing.T) {
+	sh := &shell.Shell{
+		Status:       &shell.CommandResults{ExitStatus: 1},
+		ChangeImpact: resource.ImpactReboot,
+	}
+	assert.Equal(t, resource.ImpactReboot, sh.Impact())
+	assert.Nil(t, sh.Destructive())
+}
+
+func Test_Destructive_WhenImpactDestructive_ReturnsReason(t *testing.T) {
+	sh := &shell.Shell{
+		Status:       &shell.CommandResults{ExitStatus: 1},
+		ChangeImpact: resource.ImpactDestructive,
+	}
+	assert.Equal(t, []string{"task is marked as destructive"}, sh.Destructive())
+}
+
 // Shell context
 
 func Test_Messages_Includes_Dir(t *testing.T) {
@@ -176,6 +318,14 @@ func Test_Messages_Includes_Dir(t *testing.T) {
 	assert.Contains(t, sh.Messages(), "dir (/tmp/testing)")
 }
 
+func Test_Messages_Includes_ScriptHashes(t *testing.T) {
+	sh := defaultTestShell()
+	sh.CheckStmt = "true"
+	sh.CheckScript = "check.sh"
+	sh.Check(context.Background(), fakerenderer.New())
+	assert.Contains(t, sh.Messages(), "check_script (check.sh, sha256:b5bea41b6c623f7c09f1bf24dcae58ebab3c0cdd90ad966bc43a45b44867e12b)")
+}
+
 func Test_Messages_Includes_Env(t *testing.T) {
 	sh := defaultTestShell()
 	sh.Env = []string{"VAR=test", "ANOTHER_VAR=test2"}
@@ -185,6 +335,11 @@ func Test_Messages_Includes_Env(t *testing.T) {
 
 // Test Utils
 
+func ranAs(op string, exitStatus uint32) *shell.CommandResults {
+	var results *shell.CommandResults
+	return results.Cons(op, &shell.CommandResults{ExitStatus: exitStatus})
+}
+
 func testShell(c shell.CommandExecutor) *shell.Shell {
 	return &shell.Shell{CmdGenerator: c}
 }
@@ -17,6 +17,7 @@ package resource
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 // StatusLevel will be used as a level in Status. It indicates if a resource
@@ -129,6 +130,10 @@ type Status struct {
 
 	error       error
 	warning     string
+	warnings    []Warning
+	destructive []string
+	impact      Impact
+	usage       *Usage
 	failingDeps []badDep
 }
 
@@ -182,9 +187,33 @@ func (t *Status) SetError(err error) {
 	t.error = err
 }
 
-// Warning returns the warning message, if set.
+// AddWarning adds a categorized warning to the status
+func (t *Status) AddWarning(kind WarningKind, message string) {
+	t.warnings = append(t.warnings, Warning{Kind: kind, Message: message})
+}
+
+// Warning returns the warning message, if set. Categorized warnings are
+// joined together if no message was set directly.
 func (t *Status) Warning() string {
-	return t.warning
+	if t.warning != "" || len(t.warnings) == 0 {
+		return t.warning
+	}
+
+	messages := make([]string, len(t.warnings))
+	for i, warning := range t.warnings {
+		messages[i] = warning.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Warnings returns all warnings on the status. A warning set with SetWarning
+// is reported as WarningResource.
+func (t *Status) Warnings() []Warning {
+	var out []Warning
+	if t.warning != "" {
+		out = append(out, Warning{Kind: WarningResource, Message: t.warning})
+	}
+	return append(out, t.warnings...)
 }
 
 // Error returns an error, if set. If the level is StatusCantChange or
@@ -204,6 +233,48 @@ func (t *Status) Error() error {
 	return t.error
 }
 
+// MarkDestructive flags the planned changes as destructive, giving the reason
+// they can't be undone
+func (t *Status) MarkDestructive(reason string) {
+	t.destructive = append(t.destructive, reason)
+}
+
+// Destructive returns the reasons the planned changes are destructive, if any
+func (t *Status) Destructive() []string {
+	return t.destructive
+}
+
+// RaiseImpact raises the impact of the planned changes to the given impact
+func (t *Status) RaiseImpact(impact Impact) {
+	if impact > t.impact {
+		t.impact = impact
+	}
+}
+
+// Impact returns how disruptive the planned changes are. Changes are
+// ImpactLow unless raised, and ImpactDestructive if marked destructive.
+func (t *Status) Impact() Impact {
+	switch {
+	case !t.HasChanges():
+		return ImpactNone
+	case len(t.destructive) > 0:
+		return ImpactDestructive
+	case t.impact == ImpactNone:
+		return ImpactLow
+	}
+	return t.impact
+}
+
+// AddUsage records resources consumed while producing this status
+func (t *Status) AddUsage(usage *Usage) {
+	t.usage = t.usage.Add(usage)
+}
+
+// Usage returns the resources consumed while producing this status, if known
+func (t *Status) Usage() *Usage {
+	return t.usage
+}
+
 // Diffs returns the internal differences
 func (t *Status) Diffs() map[string]Diff {
 	return t.Differences
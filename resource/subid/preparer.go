@@ -0,0 +1,101 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subid
+
+import (
+	"errors"
+	"fmt"
+	"os/user"
+
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"golang.org/x/net/context"
+)
+
+// Preparer for SubID
+//
+// SubID manages the ranges of subordinate user and group IDs assigned to a
+// user in `/etc/subuid` and `/etc/subgid`, as used by rootless containers.
+// A user can have several ranges in each file. Entries for the user, by name
+// or by uid, are replaced with the configured ranges, and entries for other
+// users are left as they are. A range overlapping one assigned to someone else
+// can't be applied.
+type Preparer struct {
+	// User is the name of the user whose ranges are managed
+	User string `hcl:"user" required:"true" nonempty:"true"`
+
+	// UIDs are the subordinate uid ranges of the user, in the form
+	// "start:count". `/etc/subuid` is left alone when no ranges are given.
+	UIDs []string `hcl:"uids"`
+
+	// GIDs are the subordinate gid ranges of the user, in the form
+	// "start:count". `/etc/subgid` is left alone when no ranges are given.
+	GIDs []string `hcl:"gids"`
+
+	// State is whether the ranges should be present. When absent, every range
+	// of the user is removed from both files, and no ranges may be given.
+	State State `hcl:"state" valid_values:"present,absent"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	subid := &SubID{
+		Username: p.User,
+		UIDs:     p.UIDs,
+		GIDs:     p.GIDs,
+		State:    p.State,
+		lookup:   user.Lookup,
+	}
+
+	if p.State == StateAbsent {
+		if len(p.UIDs) > 0 || len(p.GIDs) > 0 {
+			return nil, errors.New("user.subid \"uids\" and \"gids\" parameters cannot be used when state is absent")
+		}
+		subid.files = []*idFile{{path: SubUIDPath}, {path: SubGIDPath}}
+		return subid, nil
+	}
+
+	if len(p.UIDs) == 0 && len(p.GIDs) == 0 {
+		return nil, errors.New("user.subid requires \"uids\" or \"gids\" parameter when state is present")
+	}
+
+	for _, ids := range []struct {
+		name   string
+		ranges []string
+		path   string
+	}{
+		{"uids", p.UIDs, SubUIDPath},
+		{"gids", p.GIDs, SubGIDPath},
+	} {
+		if len(ids.ranges) == 0 {
+			continue
+		}
+		ranges, err := parseRanges(ids.ranges)
+		if err != nil {
+			return nil, fmt.Errorf("user.subid \"%s\" parameter: %s", ids.name, err)
+		}
+		subid.files = append(subid.files, &idFile{path: ids.path, ranges: ranges})
+	}
+
+	return subid, nil
+}
+
+func init() {
+	registry.Register("user.subid", (*Preparer)(nil), (*SubID)(nil))
+}
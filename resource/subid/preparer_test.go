@@ -0,0 +1,90 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subid_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/subid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestPreparerInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Resource)(nil), new(subid.Preparer))
+}
+
+func TestPreparerPrepare(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		p := subid.Preparer{
+			User: "alice",
+			UIDs: []string{"100000:65536", "300000:65536"},
+		}
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		s := task.(*subid.SubID)
+		assert.Equal(t, "alice", s.Username)
+		assert.Equal(t, subid.StatePresent, s.State)
+		assert.Equal(t, []resource.Claim{resource.ClaimPath(subid.SubUIDPath)}, s.Claims())
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		p := subid.Preparer{User: "alice", State: subid.StateAbsent}
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		assert.Equal(
+			t,
+			[]resource.Claim{resource.ClaimPath(subid.SubUIDPath), resource.ClaimPath(subid.SubGIDPath)},
+			task.(*subid.SubID).Claims(),
+		)
+	})
+
+	t.Run("absent with ranges", func(t *testing.T) {
+		p := subid.Preparer{User: "alice", GIDs: []string{"100000:65536"}, State: subid.StateAbsent}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+
+		assert.EqualError(t, err, "user.subid \"uids\" and \"gids\" parameters cannot be used when state is absent")
+	})
+
+	t.Run("no ranges", func(t *testing.T) {
+		p := subid.Preparer{User: "alice"}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+
+		assert.EqualError(t, err, "user.subid requires \"uids\" or \"gids\" parameter when state is present")
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		p := subid.Preparer{User: "alice", GIDs: []string{"100000"}}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+
+		assert.EqualError(t, err, "user.subid \"gids\" parameter: range \"100000\" must be in the form start:count")
+	})
+
+	t.Run("overlapping ranges", func(t *testing.T) {
+		p := subid.Preparer{User: "alice", UIDs: []string{"100000:65536", "150000:100"}}
+		_, err := p.Prepare(context.Background(), fakerenderer.New())
+
+		assert.EqualError(t, err, "user.subid \"uids\" parameter: range \"150000:100\" overlaps \"100000:65536\"")
+	})
+}
@@ -0,0 +1,116 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subid
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Range is a block of subordinate IDs, starting at Start and Count IDs long
+type Range struct {
+	Start uint32
+	Count uint32
+}
+
+// ParseRange parses a range in the form "start:count", the way it's written
+// in /etc/subuid and /etc/subgid
+func ParseRange(in string) (Range, error) {
+	parts := strings.Split(in, ":")
+	if len(parts) != 2 {
+		return Range{}, fmt.Errorf("range %q must be in the form start:count", in)
+	}
+
+	start, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 32)
+	if err != nil {
+		return Range{}, fmt.Errorf("range %q has an invalid start %q", in, parts[0])
+	}
+	count, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 32)
+	if err != nil || count == 0 {
+		return Range{}, fmt.Errorf("range %q has an invalid count %q", in, parts[1])
+	}
+
+	r := Range{Start: uint32(start), Count: uint32(count)}
+	if r.end() > math.MaxUint32 {
+		// the maximum id on linux is MaxUint32 - 1
+		return Range{}, fmt.Errorf("range %q out of range", in)
+	}
+
+	return r, nil
+}
+
+// String formats the range the way ParseRange reads it
+func (r Range) String() string {
+	return fmt.Sprintf("%d:%d", r.Start, r.Count)
+}
+
+// Overlaps is true when the ranges share any IDs
+func (r Range) Overlaps(other Range) bool {
+	return uint64(r.Start) < other.end() && uint64(other.Start) < r.end()
+}
+
+// end is the first ID after the range
+func (r Range) end() uint64 {
+	return uint64(r.Start) + uint64(r.Count)
+}
+
+// parseRanges parses a list of ranges, none of which may overlap
+func parseRanges(in []string) ([]Range, error) {
+	ranges := make([]Range, len(in))
+	for i, s := range in {
+		r, err := ParseRange(s)
+		if err != nil {
+			return nil, err
+		}
+		for _, prev := range ranges[:i] {
+			if r.Overlaps(prev) {
+				return nil, fmt.Errorf("range %q overlaps %q", r, prev)
+			}
+		}
+		ranges[i] = r
+	}
+	return ranges, nil
+}
+
+// formatRanges formats ranges for differences, sorted so that the same ranges
+// in a different order format the same
+func formatRanges(ranges []Range) string {
+	if len(ranges) == 0 {
+		return "<none>"
+	}
+
+	out := make([]string, len(ranges))
+	for i, r := range sortRanges(ranges) {
+		out[i] = r.String()
+	}
+	return strings.Join(out, ", ")
+}
+
+// sortRanges returns a copy of ranges sorted by start
+func sortRanges(ranges []Range) []Range {
+	sorted := make([]Range, len(ranges))
+	copy(sorted, ranges)
+	sort.Sort(byStart(sorted))
+	return sorted
+}
+
+type byStart []Range
+
+func (b byStart) Len() int           { return len(b) }
+func (b byStart) Less(i, j int) bool { return b[i].Start < b[j].Start }
+func (b byStart) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
@@ -0,0 +1,59 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subid_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/resource/subid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRange(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		r, err := subid.ParseRange("100000:65536")
+		require.NoError(t, err)
+		assert.Equal(t, subid.Range{Start: 100000, Count: 65536}, r)
+		assert.Equal(t, "100000:65536", r.String())
+	})
+
+	for in, msg := range map[string]string{
+		"100000":          `range "100000" must be in the form start:count`,
+		"x:65536":         `range "x:65536" has an invalid start "x"`,
+		"100000:0":        `range "100000:0" has an invalid count "0"`,
+		"4294967295:1":    `range "4294967295:1" out of range`,
+		"4294967000:1000": `range "4294967000:1000" out of range`,
+	} {
+		in, msg := in, msg
+		t.Run(in, func(t *testing.T) {
+			_, err := subid.ParseRange(in)
+			assert.EqualError(t, err, msg)
+		})
+	}
+}
+
+func TestRangeOverlaps(t *testing.T) {
+	t.Parallel()
+
+	r := subid.Range{Start: 100000, Count: 65536}
+
+	assert.True(t, r.Overlaps(subid.Range{Start: 165535, Count: 1}))
+	assert.True(t, r.Overlaps(subid.Range{Start: 1, Count: 100000 + 1}))
+	assert.False(t, r.Overlaps(subid.Range{Start: 165536, Count: 65536}))
+	assert.False(t, r.Overlaps(subid.Range{Start: 1, Count: 99999}))
+}
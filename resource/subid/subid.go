@@ -0,0 +1,267 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subid
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/atomicfile"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// State type for SubID
+type State string
+
+const (
+	// StatePresent indicates the ranges should be assigned to the user
+	StatePresent State = "present"
+
+	// StateAbsent indicates the user should have no ranges
+	StateAbsent State = "absent"
+)
+
+const (
+	// SubUIDPath is the file assigning subordinate user IDs
+	SubUIDPath = "/etc/subuid"
+
+	// SubGIDPath is the file assigning subordinate group IDs
+	SubGIDPath = "/etc/subgid"
+
+	// fileMode is the mode of a new subordinate ID file
+	fileMode os.FileMode = 0644
+)
+
+// SubID manages the subordinate user and group ID ranges of a user
+type SubID struct {
+	// the user whose ranges are managed
+	Username string `export:"user"`
+
+	// the configured subordinate uid ranges, as start:count
+	UIDs []string `export:"uids"`
+
+	// the configured subordinate gid ranges, as start:count
+	GIDs []string `export:"gids"`
+
+	// whether the ranges should be present
+	State State `export:"state"`
+
+	files  []*idFile
+	lookup func(string) (*user.User, error)
+}
+
+// idFile is a subordinate ID file and the ranges the user should have in it
+type idFile struct {
+	path   string
+	ranges []Range
+}
+
+// line is a line of a subordinate ID file. Lines that aren't entries, like
+// comments, are kept as they are.
+type line struct {
+	text  string
+	entry bool
+	owner string
+	rng   Range
+}
+
+// Claims returns the managed subordinate ID files
+func (s *SubID) Claims() []resource.Claim {
+	claims := make([]resource.Claim, len(s.files))
+	for i, f := range s.files {
+		claims[i] = resource.ClaimPath(f.path)
+	}
+	return claims
+}
+
+// Check whether the user has the configured ranges
+func (s *SubID) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+	owners := s.owners()
+
+	for _, f := range s.files {
+		lines, _, err := readLines(f.path)
+		if err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			return status, err
+		}
+
+		if err := s.diff(status, f, lines, owners); err != nil {
+			status.RaiseLevel(resource.StatusCantChange)
+			status.AddMessage(err.Error())
+			return status, err
+		}
+	}
+
+	status.RaiseLevelForDiffs()
+	return status, nil
+}
+
+// Apply assigns or removes the ranges
+func (s *SubID) Apply(context.Context) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+	owners := s.owners()
+
+	for _, f := range s.files {
+		lines, mode, err := readLines(f.path)
+		if err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			return status, err
+		}
+
+		if err := s.diff(status, f, lines, owners); err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			return status, err
+		}
+		if formatRanges(owned(lines, owners)) == formatRanges(f.ranges) {
+			continue
+		}
+
+		if err := atomicfile.WriteLines(f.path, s.update(f, lines, owners), mode); err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			return status, err
+		}
+
+		if len(f.ranges) == 0 {
+			status.AddMessage(fmt.Sprintf("removed ranges for %s from %s", s.Username, f.path))
+		} else {
+			status.AddMessage(fmt.Sprintf("set ranges for %s in %s to %s", s.Username, f.path, formatRanges(f.ranges)))
+		}
+	}
+
+	return status, nil
+}
+
+// owners are the names entries of the user can have: the user name, and the
+// uid if the user exists
+func (s *SubID) owners() map[string]bool {
+	owners := map[string]bool{s.Username: true}
+	if usr, err := s.lookup(s.Username); err == nil {
+		owners[usr.Uid] = true
+	}
+	return owners
+}
+
+// diff adds the difference between the user's ranges in the file and the
+// configured ranges to status. It returns an error if a configured range
+// overlaps a range of someone else.
+func (s *SubID) diff(status *resource.Status, f *idFile, lines []line, owners map[string]bool) error {
+	for _, r := range f.ranges {
+		for _, l := range lines {
+			if l.entry && !owners[l.owner] && r.Overlaps(l.rng) {
+				return fmt.Errorf("%s: range %s overlaps %s of %s", f.path, r, l.rng, l.owner)
+			}
+		}
+	}
+
+	current := formatRanges(owned(lines, owners))
+	if desired := formatRanges(f.ranges); current != desired {
+		status.AddDifference(f.path, current, desired, "")
+	}
+	return nil
+}
+
+// update returns the content of the file with the user's entries replaced by
+// the configured ranges. The new entries take the place of the first existing
+// one, or go at the end of the file.
+func (s *SubID) update(f *idFile, lines []line, owners map[string]bool) []string {
+	entries := make([]string, len(f.ranges))
+	for i, r := range f.ranges {
+		entries[i] = fmt.Sprintf("%s:%d:%d", s.Username, r.Start, r.Count)
+	}
+
+	var out []string
+	inserted := false
+	for _, l := range lines {
+		if l.entry && owners[l.owner] {
+			if !inserted {
+				out = append(out, entries...)
+				inserted = true
+			}
+			continue
+		}
+		out = append(out, l.text)
+	}
+	if !inserted {
+		out = append(out, entries...)
+	}
+
+	return out
+}
+
+// owned returns the ranges of entries belonging to owners
+func owned(lines []line, owners map[string]bool) []Range {
+	var ranges []Range
+	for _, l := range lines {
+		if l.entry && owners[l.owner] {
+			ranges = append(ranges, l.rng)
+		}
+	}
+	return ranges
+}
+
+// parseLine parses a line of a subordinate ID file, in the form
+// owner:start:count
+func parseLine(text string) line {
+	l := line{text: text}
+
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return l
+	}
+
+	parts := strings.SplitN(trimmed, ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return l
+	}
+
+	rng, err := ParseRange(parts[1])
+	if err != nil {
+		return l
+	}
+
+	l.entry, l.owner, l.rng = true, parts[0], rng
+	return l
+}
+
+// readLines reads a subordinate ID file, along with its mode. A missing file
+// has no lines.
+func readLines(path string) ([]line, os.FileMode, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, fileMode, nil
+	} else if err != nil {
+		return nil, 0, errors.Wrapf(err, "cannot read %s", path)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "cannot read %s", path)
+	}
+
+	var lines []line
+	if len(content) > 0 {
+		for _, text := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+			lines = append(lines, parseLine(text))
+		}
+	}
+
+	return lines, info.Mode().Perm(), nil
+}
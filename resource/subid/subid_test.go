@@ -0,0 +1,207 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subid
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// lookupAlice knows only alice, uid 1000
+func lookupAlice(name string) (*user.User, error) {
+	if name != "alice" {
+		return nil, user.UnknownUserError(name)
+	}
+	return &user.User{Username: "alice", Uid: "1000"}, nil
+}
+
+func TestSubIDCheck(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		content  string
+		state    State
+		ranges   []Range
+		original string
+		current  string
+		err      string
+	}{
+		"missing file": {
+			state:    StatePresent,
+			ranges:   []Range{{100000, 65536}},
+			original: "<none>",
+			current:  "100000:65536",
+		},
+		"same ranges in another order": {
+			content: "alice:300000:65536\nbob:200000:65536\n1000:100000:65536\n",
+			state:   StatePresent,
+			ranges:  []Range{{100000, 65536}, {300000, 65536}},
+		},
+		"different ranges": {
+			content:  "alice:100000:65536\n",
+			state:    StatePresent,
+			ranges:   []Range{{100000, 65536}, {300000, 65536}},
+			original: "100000:65536",
+			current:  "100000:65536, 300000:65536",
+		},
+		"overlaps another user": {
+			content: "bob:150000:65536\n",
+			state:   StatePresent,
+			ranges:  []Range{{100000, 65536}},
+			err:     "range 100000:65536 overlaps 150000:65536 of bob",
+		},
+		"absent": {
+			content:  "alice:100000:65536\n",
+			state:    StateAbsent,
+			original: "100000:65536",
+			current:  "<none>",
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "converge-subid")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "subuid")
+			if tc.content != "" {
+				require.NoError(t, ioutil.WriteFile(path, []byte(tc.content), 0640))
+			}
+			s := &SubID{
+				Username: "alice",
+				State:    tc.state,
+				files:    []*idFile{{path: path, ranges: tc.ranges}},
+				lookup:   lookupAlice,
+			}
+
+			status, err := s.Check(context.Background(), fakerenderer.New())
+			if tc.err != "" {
+				assert.EqualError(t, err, path+": "+tc.err)
+				assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+				return
+			}
+			require.NoError(t, err)
+
+			if tc.current == "" {
+				assert.False(t, status.HasChanges())
+				return
+			}
+			assert.True(t, status.HasChanges())
+			assert.Equal(t, tc.original, status.Diffs()[path].Original())
+			assert.Equal(t, tc.current, status.Diffs()[path].Current())
+		})
+	}
+}
+
+func TestSubIDApply(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		content  string
+		state    State
+		ranges   []Range
+		messages []string
+		written  string
+		mode     os.FileMode
+		err      bool
+	}{
+		"missing file": {
+			state:    StatePresent,
+			ranges:   []Range{{100000, 65536}},
+			messages: []string{"set ranges for alice in %s to 100000:65536"},
+			written:  "alice:100000:65536\n",
+			mode:     fileMode,
+		},
+		"replaces entries in place": {
+			content:  "# subordinate ids\nbob:200000:65536\n1000:100000:65536\ncarol:400000:65536\nalice:500000:1\n",
+			state:    StatePresent,
+			ranges:   []Range{{100000, 65536}, {300000, 65536}},
+			messages: []string{"set ranges for alice in %s to 100000:65536, 300000:65536"},
+			written:  "# subordinate ids\nbob:200000:65536\nalice:100000:65536\nalice:300000:65536\ncarol:400000:65536\n",
+			mode:     0640, // the mode of an existing file is kept
+		},
+		"no changes": {
+			content: "alice:100000:65536\n",
+			state:   StatePresent,
+			ranges:  []Range{{100000, 65536}},
+			written: "alice:100000:65536\n",
+			mode:    0640,
+		},
+		"absent": {
+			content:  "alice:100000:65536\nbob:200000:65536\n",
+			state:    StateAbsent,
+			messages: []string{"removed ranges for alice from %s"},
+			written:  "bob:200000:65536\n",
+			mode:     0640,
+		},
+		"overlaps another user": {
+			content: "bob:150000:65536\n",
+			state:   StatePresent,
+			ranges:  []Range{{100000, 65536}},
+			written: "bob:150000:65536\n",
+			mode:    0640,
+			err:     true,
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "converge-subid")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "subuid")
+			if tc.content != "" {
+				require.NoError(t, ioutil.WriteFile(path, []byte(tc.content), 0640))
+			}
+			s := &SubID{
+				Username: "alice",
+				State:    tc.state,
+				files:    []*idFile{{path: path, ranges: tc.ranges}},
+				lookup:   lookupAlice,
+			}
+
+			status, err := s.Apply(context.Background())
+			if tc.err {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+
+				var messages []string
+				for _, msg := range tc.messages {
+					messages = append(messages, fmt.Sprintf(msg, path))
+				}
+				assert.Equal(t, messages, status.Messages())
+			}
+
+			content, err := ioutil.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tc.written, string(content))
+
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.Equal(t, tc.mode, info.Mode().Perm())
+		})
+	}
+}
@@ -186,6 +186,14 @@ func (r *Resource) Apply(ctx context.Context) (resource.TaskStatus, error) {
 	}
 }
 
+// Reapply implements resource.Reapplier. When the unit's transaction fails,
+// it is restarted, reloaded or signalled again after the other members are
+// rolled back, so it runs with the restored configuration.
+func (r *Resource) Reapply(ctx context.Context) error {
+	_, err := r.Apply(ctx)
+	return err
+}
+
 func (r *Resource) runCheck() (resource.TaskStatus, error) {
 	status := resource.NewStatus()
 	u, err := r.systemdExecutor.QueryUnit(r.Name, false)
@@ -208,10 +216,13 @@ func (r *Resource) runCheck() (resource.TaskStatus, error) {
 		status.RaiseLevel(resource.StatusWillChange)
 		status.AddMessage("Restarting unit")
 		status.AddDifference("state", u.ActiveState, "restarted", "")
+		status.RaiseImpact(resource.ImpactServiceRestart)
 	case "running":
 		r.shouldStart(u, status)
 	case "stopped":
-		r.shouldStop(u, status)
+		if r.shouldStop(u, status) {
+			status.RaiseImpact(resource.ImpactServiceRestart)
+		}
 	}
 	r.hasRun = true
 	return status, nil
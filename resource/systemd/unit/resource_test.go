@@ -21,6 +21,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/context"
@@ -227,6 +228,7 @@ func TestCheck(t *testing.T) {
 			status, err := r.Check(context.Background(), fakerenderer.New())
 			require.NoError(t, err)
 			assert.True(t, status.HasChanges())
+			assert.Equal(t, resource.ImpactServiceRestart, resource.StatusImpact(status))
 		}
 	})
 }
@@ -620,6 +622,36 @@ func TestApply(t *testing.T) {
 	})
 }
 
+// TestReapply runs a test
+func TestReapply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("restarts-again", func(t *testing.T) {
+		t.Parallel()
+		u := &Unit{ActiveState: "failed"}
+		r := &Resource{State: "restarted"}
+		e := &ExecutorMock{}
+		e.On("RestartUnit", any).Return(nil)
+		e.On("QueryUnit", any, any).Return(u, nil)
+		r.systemdExecutor = e
+		_, err := r.Apply(context.Background())
+		require.NoError(t, err)
+		require.NoError(t, r.Reapply(context.Background()))
+		e.AssertNumberOfCalls(t, "RestartUnit", 2)
+	})
+
+	t.Run("restart-returns-error", func(t *testing.T) {
+		t.Parallel()
+		expected := errors.New("error1")
+		r := &Resource{State: "restarted"}
+		e := &ExecutorMock{}
+		e.On("RestartUnit", any).Return(expected)
+		e.On("QueryUnit", any, any).Return(&Unit{ActiveState: "failed"}, nil)
+		r.systemdExecutor = e
+		assert.Equal(t, expected, r.Reapply(context.Background()))
+	})
+}
+
 // TestCheckAfterApply runs a test
 func TestCheckAfterApply(t *testing.T) {
 	t.Parallel()
@@ -0,0 +1,36 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import "golang.org/x/net/context"
+
+type transactionKey struct{}
+
+// WithTransaction returns a context for applying a member of the named
+// transaction
+func WithTransaction(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, transactionKey{}, name)
+}
+
+// TransactionFrom returns the name of the transaction the task applied with
+// ctx is a member of, or an empty string if it isn't in one. Rollbackers only
+// need to keep what they replace when applied in a transaction.
+func TransactionFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	name, _ := ctx.Value(transactionKey{}).(string)
+	return name
+}
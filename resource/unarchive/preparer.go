@@ -31,7 +31,8 @@ import (
 //
 // Unarchive renders unarchive data
 type Preparer struct {
-	// Source to unarchive - must exist locally
+	// Source to unarchive, as a local path or a URL. tar, tar.gz, tar.bz2 and
+	// zip archives can be unarchived.
 	Source string `hcl:"source" required:"true" nonempty:"true"`
 
 	// Destination for the unarchive - must be a directory
@@ -50,6 +51,24 @@ type Preparer struct {
 	// 1. no checksum is provided
 	// 2. the checksum of the existing file differs from the checksum provided
 	Force bool `hcl:"force"`
+
+	// StripComponents removes this many leading directories from the paths of
+	// files in the archive, like tar's --strip-components. Files that are no
+	// deeper than that are skipped.
+	StripComponents int `hcl:"strip_components"`
+
+	// Creates is a path that the archive creates, relative to the destination
+	// unless it is absolute. When it exists, the archive is not fetched or
+	// unarchived again, which keeps applies from unarchiving on every run.
+	Creates string `hcl:"creates" nonempty:"true"`
+
+	// User owns the unarchived files. They keep the user that unarchived them
+	// by default.
+	User string `hcl:"user" nonempty:"true"`
+
+	// Group owns the unarchived files. They keep the group of the user that
+	// unarchived them by default.
+	Group string `hcl:"group" nonempty:"true"`
 }
 
 // Prepare a new task
@@ -89,10 +108,18 @@ func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resou
 		}
 	}
 
+	if p.StripComponents < 0 {
+		return nil, errors.New("\"strip_components\" cannot be negative")
+	}
+
 	unarchive := &Unarchive{
-		Source:      p.Source,
-		Destination: p.Destination,
-		Force:       p.Force,
+		Source:          p.Source,
+		Destination:     p.Destination,
+		Force:           p.Force,
+		StripComponents: p.StripComponents,
+		Creates:         p.Creates,
+		User:            p.User,
+		Group:           p.Group,
 	}
 
 	if p.HashType != nil {
@@ -83,6 +83,26 @@ func TestPreparer(t *testing.T) {
 			assert.NoError(t, err)
 		})
 
+		t.Run("strip_components and creates", func(t *testing.T) {
+			p := &Preparer{
+				Source:          "https://example.com/app.tar.gz",
+				Destination:     "/tmp/test",
+				StripComponents: 1,
+				Creates:         "bin/app",
+				User:            "app",
+				Group:           "app",
+			}
+
+			task, err := p.Prepare(context.Background(), &fr)
+			require.NoError(t, err)
+
+			u := task.(*Unarchive)
+			assert.Equal(t, 1, u.StripComponents)
+			assert.Equal(t, "bin/app", u.Creates)
+			assert.Equal(t, "app", u.User)
+			assert.Equal(t, "app", u.Group)
+		})
+
 		t.Run("hashtype", func(t *testing.T) {
 			p := &Preparer{
 				Source:      srcFile.Name(),
@@ -191,6 +211,16 @@ func TestPreparer(t *testing.T) {
 			})
 		})
 
+		t.Run("strip_components", func(t *testing.T) {
+			p := &Preparer{
+				Source:          "/tmp/test.zip",
+				Destination:     "/tmp/test",
+				StripComponents: -1,
+			}
+			_, err := p.Prepare(context.Background(), &fr)
+			assert.EqualError(t, err, "\"strip_components\" cannot be negative")
+		})
+
 		t.Run("checksum", func(t *testing.T) {
 			t.Run("hashtype and hash", func(t *testing.T) {
 				t.Run("only hashtype", func(t *testing.T) {
@@ -24,8 +24,11 @@ import (
 	"hash"
 	"io"
 	"io/ioutil"
+	"net/url"
 	"os"
+	"os/user"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 
@@ -73,6 +76,22 @@ type Unarchive struct {
 	// destination if it already exists
 	Force bool `export:"force"`
 
+	// the number of leading path components removed from files in the archive
+	StripComponents int `export:"strip_components"`
+
+	// a path that, when it exists, means the archive has already been
+	// unarchived
+	Creates string `export:"creates"`
+
+	// the user that owns the unarchived files
+	User string `export:"user"`
+
+	// the group that owns the unarchived files
+	Group string `export:"group"`
+
+	// the ids the unarchived files are given, or -1 to leave them alone
+	uid, gid int
+
 	// fetch is used to fetch the file to be unarchived
 	fetch fetch.Fetch
 
@@ -104,6 +123,11 @@ type response struct {
 	err    error
 }
 
+// Claims returns the destination directory
+func (u *Unarchive) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimPath(u.Destination)}
+}
+
 // Check if changes are needed for unarchive
 func (u *Unarchive) Check(ctx context.Context, r resource.Renderer) (resource.TaskStatus, error) {
 	ch := make(chan response, 1)
@@ -151,6 +175,9 @@ func (u *Unarchive) checkWithContext(ctx context.Context, r resource.Renderer) (
 	if err != nil {
 		return status, err
 	}
+	if !status.HasChanges() {
+		return status, nil
+	}
 
 	fetchStatus, err := u.fetch.Check(ctx, r)
 	if err != nil {
@@ -171,6 +198,15 @@ func (u *Unarchive) applyWithContext(ctx context.Context) (resource.TaskStatus,
 	if err != nil {
 		return status, err
 	}
+	if !status.HasChanges() {
+		return status, nil
+	}
+
+	u.uid, u.gid, err = lookupOwner(u.User, u.Group)
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
 
 	err = u.setFetchLoc()
 	if err != nil {
@@ -216,15 +252,30 @@ func (u *Unarchive) applyWithContext(ctx context.Context) (resource.TaskStatus,
 	status.AddMessage(fmt.Sprintf("completed fetch and unarchive %q", u.Source))
 	u.hasApplied = true
 
+	if u.Creates != "" {
+		if _, err := os.Stat(u.createsPath()); os.IsNotExist(err) {
+			status.SetWarning(fmt.Sprintf("%q was not in the archive, so it will be unarchived again next time", u.createsPath()))
+		}
+	}
+
 	return status, nil
 }
 
 // Diff evaluates the differences for unarchive
 func (u *Unarchive) diff(status *resource.Status) error {
-	_, err := os.Stat(u.Source)
-	if os.IsNotExist(err) {
-		status.RaiseLevel(resource.StatusCantChange)
-		return errors.Wrap(err, "cannot unarchive")
+	if u.Creates != "" {
+		if _, err := os.Stat(u.createsPath()); err == nil {
+			status.AddMessage(fmt.Sprintf("%q exists", u.createsPath()))
+			return nil
+		}
+	}
+
+	if path, ok := localPath(u.Source); ok {
+		_, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			status.RaiseLevel(resource.StatusCantChange)
+			return errors.Wrap(err, "cannot unarchive")
+		}
 	}
 
 	stat, err := os.Stat(u.Destination)
@@ -349,9 +400,16 @@ func (u *Unarchive) evaluateDuplicates() error {
 	// for each item in filesA, determine if it also exists in filesB
 	// compare the checksums for the files - if mismatch, return an error
 	for _, fA := range filesA {
+		fileA, ok := u.relativeName(fA, dirA)
+		if !ok {
+			continue
+		}
+
 		for _, fB := range filesB {
-			fileA := strings.TrimPrefix(fA, dirA)
-			fileB := strings.TrimPrefix(fB, dirB)
+			fileB, ok := u.relativeName(fB, dirB)
+			if !ok || fileA != fileB {
+				continue
+			}
 
 			faStat, err := os.Stat(fA)
 			if err != nil {
@@ -407,7 +465,10 @@ func (u *Unarchive) copyToFinalDest() error {
 // readWrite handles reading a file and either creates a directory or writes the
 // file to the final unarchive destination
 func (u *Unarchive) readWrite(file string) error {
-	fileName := strings.TrimPrefix(file, u.fetchDir.Name())
+	fileName, ok := u.relativeName(file, u.fetchDir.Name())
+	if !ok {
+		return nil
+	}
 
 	fStat, err := os.Stat(file)
 	if err != nil {
@@ -419,7 +480,7 @@ func (u *Unarchive) readWrite(file string) error {
 			err = os.Mkdir(u.destDir.Name()+fileName, fStat.Mode().Perm())
 			if err != nil {
 				if !os.IsNotExist(err) {
-					return nil
+					return u.chown(u.destDir.Name() + fileName)
 				}
 				return err
 			}
@@ -428,11 +489,85 @@ func (u *Unarchive) readWrite(file string) error {
 				return err
 			}
 		}
+		return u.chown(u.destDir.Name() + fileName)
 	}
 
 	return nil
 }
 
+// relativeName is the name of a file relative to the directory it was found
+// in. Files unarchived to the fetch directory have StripComponents leading
+// directories removed from their names, and are skipped when there are none
+// left.
+func (u *Unarchive) relativeName(path, dir string) (string, bool) {
+	name := strings.TrimPrefix(path, dir)
+	if u.fetchDir == nil || dir != u.fetchDir.Name() || u.StripComponents == 0 || name == "" {
+		return name, true
+	}
+
+	parts := strings.Split(strings.Trim(name, string(filepath.Separator)), string(filepath.Separator))
+	if len(parts) <= u.StripComponents {
+		return "", false
+	}
+	return string(filepath.Separator) + filepath.Join(parts[u.StripComponents:]...), true
+}
+
+// chown gives an unarchived file to the user and group, if they were set
+func (u *Unarchive) chown(path string) error {
+	if u.User == "" && u.Group == "" {
+		return nil
+	}
+	return os.Lchown(path, u.uid, u.gid)
+}
+
+// createsPath is where Creates is, relative to the destination
+func (u *Unarchive) createsPath() string {
+	if filepath.IsAbs(u.Creates) {
+		return u.Creates
+	}
+	return filepath.Join(u.Destination, u.Creates)
+}
+
+// localPath is the path of a source on this system, if it isn't a URL
+func localPath(source string) (string, bool) {
+	parsed, err := url.Parse(source)
+	switch {
+	case err != nil, parsed.Scheme == "":
+		return source, true
+	case parsed.Scheme == "file":
+		return parsed.Path, true
+	}
+	return "", false
+}
+
+// lookupOwner finds the ids of a user and group by name, with -1 for either
+// one that is not set
+func lookupOwner(username, groupname string) (uid, gid int, err error) {
+	uid, gid = -1, -1
+
+	if username != "" {
+		usr, err := user.Lookup(username)
+		if err != nil {
+			return uid, gid, errors.Wrapf(err, "cannot find user %q", username)
+		}
+		if uid, err = strconv.Atoi(usr.Uid); err != nil {
+			return uid, gid, errors.Wrapf(err, "user %q has a non-numeric id", username)
+		}
+	}
+
+	if groupname != "" {
+		grp, err := user.LookupGroup(groupname)
+		if err != nil {
+			return uid, gid, errors.Wrapf(err, "cannot find group %q", groupname)
+		}
+		if gid, err = strconv.Atoi(grp.Gid); err != nil {
+			return uid, gid, errors.Wrapf(err, "group %q has a non-numeric id", groupname)
+		}
+	}
+
+	return uid, gid, nil
+}
+
 func (u *Unarchive) copyFile(from, to string) error {
 	src, err := os.Open(from)
 	if err != nil {
@@ -447,7 +582,7 @@ func (u *Unarchive) copyFile(from, to string) error {
 
 	defer src.Close()
 
-	dst, err := os.OpenFile(u.destDir.Name()+to, os.O_CREATE|os.O_RDWR, srcInfo.Mode().Perm())
+	dst, err := os.OpenFile(u.destDir.Name()+to, os.O_CREATE|os.O_RDWR|os.O_TRUNC, srcInfo.Mode().Perm())
 	if err != nil {
 		return err
 	}
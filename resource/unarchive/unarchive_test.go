@@ -435,6 +435,55 @@ func TestApply(t *testing.T) {
 			assert.Equal(t, u.Source, status.Diffs()["unarchive"].Original())
 			assert.Equal(t, u.Destination, status.Diffs()["unarchive"].Current())
 		})
+
+		t.Run("strip components and creates", func(t *testing.T) {
+			workDir, err := ioutil.TempDir("", "unarchive_strip")
+			require.NoError(t, err)
+			defer os.RemoveAll(workDir)
+
+			// the archive has "app/bin/run" in it
+			archiveDir := filepath.Join(workDir, "app")
+			require.NoError(t, os.MkdirAll(filepath.Join(archiveDir, "bin"), 0755))
+			require.NoError(t, ioutil.WriteFile(filepath.Join(archiveDir, "bin", "run"), []byte("#!/bin/sh\n"), 0755))
+			zipFile := filepath.Join(workDir, "app.zip")
+			require.NoError(t, zipFiles(archiveDir, zipFile))
+
+			destDir := filepath.Join(workDir, "dest")
+			require.NoError(t, os.Mkdir(destDir, 0755))
+
+			fetchLoc, err := ioutil.TempDir("", "tmpFetchDir")
+			require.NoError(t, err)
+
+			u := &Unarchive{
+				Source:          zipFile,
+				Destination:     destDir,
+				StripComponents: 1,
+				Creates:         "bin/run",
+				fetchLoc:        fetchLoc,
+			}
+			u.fetch = fetch.Fetch{
+				Source:      u.Source,
+				Destination: u.fetchLoc,
+				Unarchive:   true,
+			}
+
+			_, err = u.Apply(context.Background())
+			require.NoError(t, err)
+
+			content, err := ioutil.ReadFile(filepath.Join(destDir, "bin", "run"))
+			require.NoError(t, err)
+			assert.Equal(t, "#!/bin/sh\n", string(content))
+
+			_, err = os.Stat(filepath.Join(destDir, "app"))
+			assert.True(t, os.IsNotExist(err))
+
+			// a later run finds what the archive creates
+			again := &Unarchive{Source: u.Source, Destination: u.Destination, StripComponents: 1, Creates: u.Creates}
+			status, err := again.Check(context.Background(), fakerenderer.New())
+			require.NoError(t, err)
+			assert.False(t, status.HasChanges())
+			assert.Equal(t, fmt.Sprintf("%q exists", filepath.Join(destDir, "bin", "run")), status.Messages()[0])
+		})
 	})
 
 	t.Run("context", func(t *testing.T) {
@@ -475,6 +524,33 @@ func TestDiff(t *testing.T) {
 		assert.True(t, status.HasChanges())
 	})
 
+	t.Run("source is a url", func(t *testing.T) {
+		u := &Unarchive{
+			Source:      "https://example.com/app.tar.gz",
+			Destination: "/tmp",
+		}
+		status := resource.NewStatus()
+
+		err := u.diff(status)
+
+		assert.NoError(t, err)
+		assert.True(t, status.HasChanges())
+	})
+
+	t.Run("creates exists", func(t *testing.T) {
+		u := &Unarchive{
+			Source:      "https://example.com/app.tar.gz",
+			Destination: "/tmp",
+			Creates:     src.Name(),
+		}
+		status := resource.NewStatus()
+
+		err := u.diff(status)
+
+		assert.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
 	t.Run("destination is not directory", func(t *testing.T) {
 		u := &Unarchive{
 			Source:      src.Name(),
@@ -1335,6 +1411,43 @@ func TestSetFetchLoc(t *testing.T) {
 
 // setupSetDirsAndContents performs some setup required to test
 // SetDirsAndContents
+// TestRelativeName tests that leading directories are stripped from unarchived
+// files
+func TestRelativeName(t *testing.T) {
+	t.Parallel()
+
+	fetchDir, err := os.Open(os.TempDir())
+	require.NoError(t, err)
+	defer fetchDir.Close()
+
+	u := &Unarchive{StripComponents: 1, fetchDir: fetchDir}
+	dir := fetchDir.Name()
+
+	name, ok := u.relativeName(filepath.Join(dir, "app", "bin", "run"), dir)
+	assert.True(t, ok)
+	assert.Equal(t, "/bin/run", name)
+
+	_, ok = u.relativeName(filepath.Join(dir, "app"), dir)
+	assert.False(t, ok)
+
+	name, ok = u.relativeName("/srv/app/bin", "/srv")
+	assert.True(t, ok)
+	assert.Equal(t, "/app/bin", name)
+}
+
+// TestLookupOwner tests looking up the ids of unarchived files
+func TestLookupOwner(t *testing.T) {
+	t.Parallel()
+
+	uid, gid, err := lookupOwner("", "")
+	require.NoError(t, err)
+	assert.Equal(t, -1, uid)
+	assert.Equal(t, -1, gid)
+
+	_, _, err = lookupOwner("converge-no-such-user", "")
+	assert.Error(t, err)
+}
+
 func setupSetDirsAndContents(u *Unarchive, nested bool) error {
 
 	err := u.setFetchLoc()
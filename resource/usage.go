@@ -0,0 +1,77 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"os"
+	"time"
+)
+
+// Usage records the system resources consumed on behalf of a single node
+// during application. Not every platform can report every field; fields which
+// could not be measured are left at zero.
+type Usage struct {
+	// CPUTime is the combined user and system CPU time of any subprocesses
+	CPUTime time.Duration `json:"cpuTime"`
+
+	// PeakRSS is the largest resident set size, in bytes, of any subprocess
+	PeakRSS int64 `json:"peakRSS"`
+
+	// BytesWritten is the number of bytes written to disk
+	BytesWritten int64 `json:"bytesWritten"`
+}
+
+// UsageReporter is implemented by task statuses which can report the
+// resources they consumed
+type UsageReporter interface {
+	Usage() *Usage
+}
+
+// Add combines two usage records. CPU time and bytes written are summed, while
+// the peak RSS is the larger of the two. Either side may be nil.
+func (u *Usage) Add(other *Usage) *Usage {
+	if u == nil {
+		return other
+	}
+	if other == nil {
+		return u
+	}
+
+	out := &Usage{
+		CPUTime:      u.CPUTime + other.CPUTime,
+		PeakRSS:      u.PeakRSS,
+		BytesWritten: u.BytesWritten + other.BytesWritten,
+	}
+	if other.PeakRSS > out.PeakRSS {
+		out.PeakRSS = other.PeakRSS
+	}
+
+	return out
+}
+
+// UsageFromProcessState extracts usage information from an exited process. It
+// returns nil if the process state is not available.
+func UsageFromProcessState(state *os.ProcessState) *Usage {
+	if state == nil {
+		return nil
+	}
+
+	usage := &Usage{
+		CPUTime: state.UserTime() + state.SystemTime(),
+	}
+	addPlatformUsage(usage, state)
+
+	return usage
+}
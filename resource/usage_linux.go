@@ -0,0 +1,38 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package resource
+
+import (
+	"os"
+	"syscall"
+)
+
+// blockSize is the unit used by the kernel when reporting block I/O in rusage
+const blockSize = 512
+
+// addPlatformUsage fills in peak RSS and bytes written from the rusage
+// information recorded by the kernel
+func addPlatformUsage(usage *Usage, state *os.ProcessState) {
+	rusage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || rusage == nil {
+		return
+	}
+
+	// Maxrss is reported in kilobytes on Linux
+	usage.PeakRSS = rusage.Maxrss * 1024
+	usage.BytesWritten = rusage.Oublock * blockSize
+}
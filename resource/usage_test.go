@@ -0,0 +1,85 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUsageAdd tests combining usage records
+func TestUsageAdd(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil receiver", func(t *testing.T) {
+		var usage *resource.Usage
+		other := &resource.Usage{BytesWritten: 10}
+
+		assert.Equal(t, other, usage.Add(other))
+	})
+
+	t.Run("nil argument", func(t *testing.T) {
+		usage := &resource.Usage{BytesWritten: 10}
+
+		assert.Equal(t, usage, usage.Add(nil))
+	})
+
+	t.Run("combines", func(t *testing.T) {
+		a := &resource.Usage{CPUTime: time.Second, PeakRSS: 100, BytesWritten: 10}
+		b := &resource.Usage{CPUTime: 2 * time.Second, PeakRSS: 50, BytesWritten: 5}
+
+		assert.Equal(
+			t,
+			&resource.Usage{CPUTime: 3 * time.Second, PeakRSS: 100, BytesWritten: 15},
+			a.Add(b),
+		)
+	})
+}
+
+// TestUsageFromProcessState tests extracting usage from a process
+func TestUsageFromProcessState(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil", func(t *testing.T) {
+		assert.Nil(t, resource.UsageFromProcessState(nil))
+	})
+
+	t.Run("exited process", func(t *testing.T) {
+		cmd := exec.Command("true")
+		require.NoError(t, cmd.Run())
+
+		usage := resource.UsageFromProcessState(cmd.ProcessState)
+		require.NotNil(t, usage)
+		assert.True(t, usage.CPUTime >= 0)
+	})
+}
+
+// TestStatusUsage tests recording usage on a status
+func TestStatusUsage(t *testing.T) {
+	t.Parallel()
+
+	status := resource.NewStatus()
+	assert.Nil(t, status.Usage())
+
+	status.AddUsage(&resource.Usage{BytesWritten: 1})
+	status.AddUsage(&resource.Usage{BytesWritten: 2})
+
+	assert.Equal(t, int64(3), status.Usage().BytesWritten)
+}
@@ -0,0 +1,120 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package user
+
+import (
+	"fmt"
+	"math"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+// IDRange is an inclusive range of user or group IDs
+type IDRange struct {
+	Min uint32
+	Max uint32
+}
+
+// ParseIDRange parses a range in the form "min-max"
+func ParseIDRange(in string) (*IDRange, error) {
+	parts := strings.Split(in, "-")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("range %q must be in the form min-max", in)
+	}
+
+	var bounds [2]uint32
+	for i, part := range parts {
+		bound, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("range %q has an invalid bound %q", in, part)
+		}
+		bounds[i] = uint32(bound)
+	}
+
+	r := &IDRange{Min: bounds[0], Max: bounds[1]}
+	if r.Min > r.Max {
+		return nil, fmt.Errorf("range %q starts after it ends", in)
+	}
+	if r.Max == math.MaxUint32 {
+		// the maximum id on linux is MaxUint32 - 1
+		return nil, fmt.Errorf("range %q out of range", in)
+	}
+
+	return r, nil
+}
+
+// Contains is true when id is a number within the range
+func (r *IDRange) Contains(id string) bool {
+	n, err := strconv.ParseUint(id, 10, 32)
+	return err == nil && uint32(n) >= r.Min && uint32(n) <= r.Max
+}
+
+// Within is true when the range is inside outer
+func (r *IDRange) Within(outer *IDRange) bool {
+	return r.Min >= outer.Min && r.Max <= outer.Max
+}
+
+// String returns the range in the form it is parsed from
+func (r *IDRange) String() string {
+	return fmt.Sprintf("%d-%d", r.Min, r.Max)
+}
+
+// next returns the lowest ID in the range for which used returns false
+func (r *IDRange) next(used func(string) (bool, error)) (string, error) {
+	for n := uint64(r.Min); n <= uint64(r.Max); n++ {
+		id := strconv.FormatUint(n, 10)
+
+		taken, err := used(id)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return id, nil
+		}
+	}
+
+	return "", fmt.Errorf("no free id in range %s", r)
+}
+
+// nextUID returns the lowest unused uid in the range
+func (r *IDRange) nextUID(system SystemUtils) (string, error) {
+	return r.next(func(id string) (bool, error) {
+		_, err := system.LookupID(id)
+		switch err.(type) {
+		case nil:
+			return true, nil
+		case user.UnknownUserIdError:
+			return false, nil
+		default:
+			return false, err
+		}
+	})
+}
+
+// nextGID returns the lowest unused gid in the range
+func (r *IDRange) nextGID(system SystemUtils) (string, error) {
+	return r.next(func(id string) (bool, error) {
+		_, err := system.LookupGroupID(id)
+		switch err.(type) {
+		case nil:
+			return true, nil
+		case user.UnknownGroupIdError:
+			return false, nil
+		default:
+			return false, err
+		}
+	})
+}
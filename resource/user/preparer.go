@@ -17,6 +17,8 @@ package user
 import (
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/asteris-llc/converge/load/registry"
@@ -38,7 +40,13 @@ type Preparer struct {
 	NewUsername string `hcl:"new_username" nonempty:"true"`
 
 	// UID is the user ID.
-	UID *uint32 `hcl:"uid"`
+	// Only one of UID or UIDRange may be indicated.
+	UID *uint32 `hcl:"uid" mutually_exclusive:"uid,uid_range"`
+
+	// UIDRange is a range of user IDs, in the form "min-max", to pick the UID
+	// from when adding the user. The lowest free ID in the range is used. The
+	// picked UID, or the UID of an existing user, is exported as uid.
+	UIDRange string `hcl:"uid_range" mutually_exclusive:"uid,uid_range" nonempty:"true"`
 
 	// GroupName is the primary group for user and must already exist.
 	// Only one of GID or Groupname may be indicated.
@@ -48,6 +56,27 @@ type Preparer struct {
 	// Only one of GID or Groupname may be indicated.
 	GID *uint32 `hcl:"gid" mutually_exclusive:"gid,groupname"`
 
+	// GIDRange is a range of group IDs, in the form "min-max", to pick the GID
+	// of the group created for the user from when adding the user. The lowest
+	// free ID in the range is used. The picked GID, or the GID of an existing
+	// user, is exported as gid. GIDRange cannot be used with GroupName or GID.
+	GIDRange string `hcl:"gid_range" mutually_exclusive:"gid,groupname,gid_range" nonempty:"true"`
+
+	// UIDMin and UIDMax are the inclusive bounds the UID of the user must be
+	// within, and are set together. A given UID or UIDRange must be inside
+	// them. Without either, the UID of a new user is picked from these bounds
+	// like with UIDRange. An existing user whose UID is outside them cannot be
+	// modified unless UID moves them inside. If nothing about the user would
+	// change, they are left alone with a policy warning.
+	UIDMin *uint32 `hcl:"uid_min"`
+	UIDMax *uint32 `hcl:"uid_max"`
+
+	// GIDMin and GIDMax are the inclusive bounds for the GID of the user's
+	// primary group, like UIDMin and UIDMax. They cannot be used with
+	// GroupName.
+	GIDMin *uint32 `hcl:"gid_min"`
+	GIDMax *uint32 `hcl:"gid_max"`
+
 	// Name is the user description.
 	// This field can be indicated when adding or modifying a user.
 	Name string `hcl:"name" nonempty:"true"`
@@ -55,12 +84,18 @@ type Preparer struct {
 	// CreateHome when set to true will create the home directory for the user.
 	// The files and directories contained in the skeleton directory (which can be
 	// defined with the SkelDir option) will be copied to the home directory.
-	CreateHome bool `hcl:"create_home"`
+	// For an existing user, the home directory is created if it is missing and
+	// its owner is corrected if it is not owned by the user. When set to false,
+	// no home directory is created when adding the user, even if the system
+	// default (CREATE_HOME in /etc/login.defs) is to create one. When not set,
+	// the system default is used. Only Linux creates home directories by
+	// default, so false has no further effect on other platforms.
+	CreateHome *bool `hcl:"create_home"`
 
 	// SkelDir contains files and directories to be copied in the user's home
-	// directory when adding a user. If not set, the skeleton directory is defined
-	// by the SKEL variable in /etc/default/useradd or, by default, /etc/skel.
-	// SkelDir is only valid is CreatHome is specified.
+	// directory when it is created. If not set, the skeleton directory is
+	// defined by the SKEL variable in /etc/default/useradd or, by default,
+	// /etc/skel. SkelDir is only valid is CreatHome is specified.
 	SkelDir string `hcl:"skel_dir" nonempty:"true"`
 
 	// HomeDir is the name of the user's login directory. If not set, the home
@@ -69,8 +104,9 @@ type Preparer struct {
 	// This field can be indicated when adding or modifying a user.
 	HomeDir string `hcl:"home_dir" nonempty:"true"`
 
-	// MoveDir is used to move the contents of HomeDir when modifying a user.
-	// HomeDir must also be indicated if MoveDir is set to true.
+	// MoveDir is used to move the contents of the current home directory to
+	// HomeDir when modifying a user. The moved contents are then owned by the
+	// user. HomeDir must also be indicated if MoveDir is set to true.
 	MoveDir bool `hcl:"move_dir"`
 
 	// Expiry is the date on which the user account will be disabled. The date is
@@ -79,9 +115,72 @@ type Preparer struct {
 	// string (no expiry) will be used by default.
 	Expiry time.Time `hcl:"expiry"`
 
-	// State is whether the user should be present.
+	// Disabled when set to true prevents the user from logging in. The login
+	// shell is set to nologin and the password is locked in a single step.
+	// Disabled is only valid when State is present.
+	Disabled bool `hcl:"disabled"`
+
+	// Shell is the login shell of the user, for example /usr/sbin/nologin for
+	// service accounts. It must be listed in /etc/shells unless SkipShellCheck
+	// is set. Shell cannot be used with Disabled, which sets the shell itself.
+	Shell string `hcl:"shell" nonempty:"true"`
+
+	// SkipShellCheck when set to true allows a Shell that is not listed in
+	// /etc/shells.
+	SkipShellCheck bool `hcl:"skip_shell_check"`
+
+	// Groups are the supplementary groups of the user, which must already
+	// exist. The user is removed from any other supplementary groups unless
+	// Append is set. Supplementary groups are not managed when Groups is empty.
+	Groups []string `hcl:"groups"`
+
+	// Append when set to true only adds the user to Groups, keeping their
+	// other supplementary groups.
+	Append bool `hcl:"append"`
+
+	// Password is the hashed password of the user, in the format used by
+	// /etc/shadow (for example from `mkpasswd -m sha-512`). It is set when the
+	// user is added and reconciled on every run. The hash is never shown in
+	// plan output, only whether it will change.
+	Password string `hcl:"password" nonempty:"true"`
+
+	// MinDays is the minimum number of days between password changes. 0 lets
+	// the user change their password at any time.
+	MinDays *int `hcl:"min_days"`
+
+	// MaxDays is the maximum number of days a password stays valid. -1 removes
+	// the limit.
+	MaxDays *int `hcl:"max_days"`
+
+	// WarnDays is the number of days before the password expires that the user
+	// is warned.
+	WarnDays *int `hcl:"warn_days"`
+
+	// System when set to true adds the user as a system account, with a UID
+	// (and the GID of the user's own group) from the system range. It has no
+	// effect on a user that already exists.
+	System bool `hcl:"system"`
+
+	// LocalOnly when set to true only manages users in /etc/passwd. Users that
+	// only come from a directory service like SSSD or LDAP are looked up like
+	// any other user, but can't be managed by local tools, so they are left
+	// alone when State is absent and cannot be added or modified otherwise.
+	// LocalOnly should not be used on macOS, where local users are not kept in
+	// /etc/passwd.
+	LocalOnly bool `hcl:"local_only"`
+
+	// RemoveAll when set to true removes the user's home directory and mail
+	// spool along with the user. It is only valid when State is absent. On
+	// macOS, only a home directory in /Users is removed; the user is not
+	// deleted if their home is somewhere else.
+	RemoveAll bool `hcl:"remove_all"`
+
+	// State is whether the user should be present. The locked and unlocked
+	// states also ensure the user is present, and lock or unlock their password
+	// so that it can or cannot be used to log in. A user can only be unlocked
+	// when they have a password.
 	// The default value is present.
-	State State `hcl:"state" valid_values:"present,absent"`
+	State State `hcl:"state" valid_values:"present,absent,locked,unlocked"`
 }
 
 // Prepare a new task
@@ -96,7 +195,62 @@ func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resou
 		return nil, fmt.Errorf("user \"gid\" parameter out of range")
 	}
 
-	if p.SkelDir != "" && !p.CreateHome {
+	var uidRange, gidRange *IDRange
+	if p.UIDRange != "" {
+		var err error
+		uidRange, err = ParseIDRange(p.UIDRange)
+		if err != nil {
+			return nil, fmt.Errorf("user \"uid_range\" parameter: %s", err)
+		}
+	}
+
+	if p.GIDRange != "" {
+		var err error
+		gidRange, err = ParseIDRange(p.GIDRange)
+		if err != nil {
+			return nil, fmt.Errorf("user \"gid_range\" parameter: %s", err)
+		}
+	}
+
+	uidPolicy, err := policyRange("uid", p.UIDMin, p.UIDMax)
+	if err != nil {
+		return nil, err
+	}
+	if uidPolicy != nil {
+		switch {
+		case p.UID != nil && !uidPolicy.Contains(fmt.Sprint(*p.UID)):
+			return nil, fmt.Errorf("user \"uid\" parameter must be within \"uid_min\" and \"uid_max\"")
+		case uidRange != nil && !uidRange.Within(uidPolicy):
+			return nil, fmt.Errorf("user \"uid_range\" parameter must be within \"uid_min\" and \"uid_max\"")
+		case p.UID == nil && uidRange == nil:
+			uidRange = uidPolicy
+		}
+	}
+
+	gidPolicy, err := policyRange("gid", p.GIDMin, p.GIDMax)
+	if err != nil {
+		return nil, err
+	}
+	if gidPolicy != nil {
+		switch {
+		case p.GroupName != "":
+			return nil, fmt.Errorf("user \"gid_min\" and \"gid_max\" parameters cannot be used with \"groupname\" parameter")
+		case p.GID != nil && !gidPolicy.Contains(fmt.Sprint(*p.GID)):
+			return nil, fmt.Errorf("user \"gid\" parameter must be within \"gid_min\" and \"gid_max\"")
+		case gidRange != nil && !gidRange.Within(gidPolicy):
+			return nil, fmt.Errorf("user \"gid_range\" parameter must be within \"gid_min\" and \"gid_max\"")
+		case p.GID == nil && gidRange == nil:
+			gidRange = gidPolicy
+		}
+	}
+
+	createHome := p.CreateHome != nil && *p.CreateHome
+
+	if p.SkelDir != "" && p.CreateHome != nil && !createHome {
+		return nil, fmt.Errorf("user \"skel_dir\" parameter requires \"create_home\" to be true")
+	}
+
+	if p.SkelDir != "" && !createHome {
 		return nil, fmt.Errorf("user \"create_home\" parameter required with \"skel_dir\" parameter")
 	}
 
@@ -108,17 +262,95 @@ func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resou
 		p.State = StatePresent
 	}
 
+	if p.Disabled && p.State == StateAbsent {
+		return nil, fmt.Errorf("user \"disabled\" parameter is only valid with \"state\" present")
+	}
+
+	if p.Disabled && p.State == StateUnlocked {
+		return nil, fmt.Errorf("user \"disabled\" parameter cannot be used with \"state\" unlocked")
+	}
+
+	if p.Shell != "" {
+		if p.State == StateAbsent {
+			return nil, fmt.Errorf("user \"shell\" parameter is only valid with \"state\" present")
+		}
+		if p.Disabled {
+			return nil, fmt.Errorf("user \"shell\" parameter cannot be used with \"disabled\" parameter")
+		}
+		if !p.SkipShellCheck {
+			if err := checkShell(shellsFile, p.Shell); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if p.Append && len(p.Groups) == 0 {
+		return nil, fmt.Errorf("user \"groups\" parameter required with \"append\" parameter")
+	}
+
+	if len(p.Groups) > 0 && p.State == StateAbsent {
+		return nil, fmt.Errorf("user \"groups\" parameter is only valid with \"state\" present")
+	}
+
+	if p.MinDays != nil || p.MaxDays != nil || p.WarnDays != nil {
+		if p.State == StateAbsent {
+			return nil, fmt.Errorf("user password aging parameters are only valid with \"state\" present")
+		}
+		if p.MinDays != nil && *p.MinDays < 0 {
+			return nil, fmt.Errorf("user \"min_days\" parameter must not be negative")
+		}
+		if p.MaxDays != nil && *p.MaxDays < -1 {
+			return nil, fmt.Errorf("user \"max_days\" parameter must be -1 or more")
+		}
+		if p.WarnDays != nil && *p.WarnDays < 0 {
+			return nil, fmt.Errorf("user \"warn_days\" parameter must not be negative")
+		}
+		if p.MinDays != nil && p.MaxDays != nil && *p.MaxDays != -1 && *p.MinDays > *p.MaxDays {
+			return nil, fmt.Errorf("user \"min_days\" parameter cannot be greater than \"max_days\" parameter")
+		}
+	}
+
+	if p.System && p.State == StateAbsent {
+		return nil, fmt.Errorf("user \"system\" parameter is only valid with \"state\" present")
+	}
+
+	if p.RemoveAll && p.State != StateAbsent {
+		return nil, fmt.Errorf("user \"remove_all\" parameter is only valid with \"state\" absent")
+	}
+
+	if p.Password != "" {
+		if p.State == StateAbsent {
+			return nil, fmt.Errorf("user \"password\" parameter is only valid with \"state\" present")
+		}
+		if strings.ContainsAny(p.Password, ":\n") {
+			return nil, fmt.Errorf("user \"password\" parameter must be a password hash")
+		}
+	}
+
 	usr := NewUser(new(System))
 	usr.Username = p.Username
 	usr.NewUsername = p.NewUsername
 	usr.GroupName = p.GroupName
 	usr.Name = p.Name
-	usr.CreateHome = p.CreateHome
+	usr.CreateHome = createHome
+	usr.NoCreateHome = p.CreateHome != nil && !*p.CreateHome
 	usr.SkelDir = p.SkelDir
 	usr.HomeDir = p.HomeDir
 	usr.MoveDir = p.MoveDir
 	usr.State = p.State
 	usr.Expiry = p.Expiry
+	usr.Disabled = p.Disabled
+	usr.Password = p.Password
+	usr.Groups = p.Groups
+	usr.Shell = p.Shell
+	usr.Append = p.Append
+	usr.System = p.System
+	usr.UIDRange = uidRange
+	usr.GIDRange = gidRange
+	usr.UIDPolicy = uidPolicy
+	usr.GIDPolicy = gidPolicy
+	usr.LocalOnly = p.LocalOnly
+	usr.RemoveAll = p.RemoveAll
 
 	if p.UID != nil {
 		usr.UID = fmt.Sprintf("%v", *p.UID)
@@ -128,9 +360,38 @@ func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resou
 		usr.GID = fmt.Sprintf("%v", *p.GID)
 	}
 
+	if p.MinDays != nil {
+		usr.MinDays = strconv.Itoa(*p.MinDays)
+	}
+
+	if p.MaxDays != nil {
+		usr.MaxDays = strconv.Itoa(*p.MaxDays)
+	}
+
+	if p.WarnDays != nil {
+		usr.WarnDays = strconv.Itoa(*p.WarnDays)
+	}
+
 	return usr, nil
 }
 
 func init() {
 	registry.Register("user.user", (*Preparer)(nil), (*User)(nil))
 }
+
+// policyRange builds the range for the min and max parameters of an id. Both
+// or neither must be set.
+func policyRange(id string, min, max *uint32) (*IDRange, error) {
+	switch {
+	case min == nil && max == nil:
+		return nil, nil
+	case min == nil || max == nil:
+		return nil, fmt.Errorf("user \"%s_min\" and \"%s_max\" parameters must be set together", id, id)
+	case *min > *max:
+		return nil, fmt.Errorf("user \"%s_min\" parameter cannot be greater than \"%s_max\" parameter", id, id)
+	case *max == math.MaxUint32:
+		// the maximum id on linux is MaxUint32 - 1
+		return nil, fmt.Errorf("user \"%s_max\" parameter out of range", id)
+	}
+	return &IDRange{Min: *min, Max: *max}, nil
+}
@@ -44,6 +44,8 @@ func TestPrepare(t *testing.T) {
 	var maxID = uint32(math.MaxUint32 - 1)
 	var minID uint32
 	var testID uint32 = 123
+	createHome := true
+	noCreateHome := false
 
 	t.Run("valid", func(t *testing.T) {
 		t.Run("no uid", func(t *testing.T) {
@@ -103,14 +105,14 @@ func TestPrepare(t *testing.T) {
 		})
 
 		t.Run("create_home and skel_dir parameters", func(t *testing.T) {
-			p := user.Preparer{UID: &testID, GID: &testID, Username: "test", CreateHome: true, SkelDir: "/etc/skel", HomeDir: "tmp", State: user.StateAbsent}
+			p := user.Preparer{UID: &testID, GID: &testID, Username: "test", CreateHome: &createHome, SkelDir: "/etc/skel", HomeDir: "tmp", State: user.StateAbsent}
 			_, err := p.Prepare(context.Background(), &fr)
 
 			assert.NoError(t, err)
 		})
 
 		t.Run("create_home parameter", func(t *testing.T) {
-			p := user.Preparer{UID: &testID, GID: &testID, Username: "test", CreateHome: true, HomeDir: "tmp", State: user.StateAbsent}
+			p := user.Preparer{UID: &testID, GID: &testID, Username: "test", CreateHome: &createHome, HomeDir: "tmp", State: user.StateAbsent}
 			_, err := p.Prepare(context.Background(), &fr)
 
 			assert.NoError(t, err)
@@ -144,6 +146,34 @@ func TestPrepare(t *testing.T) {
 			assert.NoError(t, err)
 		})
 
+		t.Run("disabled", func(t *testing.T) {
+			p := user.Preparer{Username: "test", Disabled: true}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.NoError(t, err)
+		})
+
+		t.Run("disabled with state locked", func(t *testing.T) {
+			p := user.Preparer{Username: "test", Disabled: true, State: user.StateLocked}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.NoError(t, err)
+		})
+
+		t.Run("groups with state unlocked", func(t *testing.T) {
+			p := user.Preparer{Username: "test", Groups: []string{"wheel"}, State: user.StateUnlocked}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.NoError(t, err)
+		})
+
+		t.Run("shell not listed with skip_shell_check", func(t *testing.T) {
+			p := user.Preparer{Username: "test", Shell: "/nonexistent/shell", SkipShellCheck: true}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.NoError(t, err)
+		})
+
 		t.Run("expiry", func(t *testing.T) {
 			zone := time.FixedZone(time.Now().In(time.Local).Zone())
 			expiry, err := time.ParseInLocation(user.ShortForm, "1996-12-12", zone)
@@ -154,6 +184,46 @@ func TestPrepare(t *testing.T) {
 
 			assert.NoError(t, err)
 		})
+
+		t.Run("local_only", func(t *testing.T) {
+			p := user.Preparer{Username: "test", LocalOnly: true}
+			task, err := p.Prepare(context.Background(), &fr)
+
+			require.NoError(t, err)
+			assert.True(t, task.(*user.User).LocalOnly)
+		})
+
+		t.Run("uid_min and uid_max", func(t *testing.T) {
+			min, max := uint32(1000), uint32(1999)
+			p := user.Preparer{Username: "test", UIDMin: &min, UIDMax: &max, GIDMin: &min, GIDMax: &max}
+			task, err := p.Prepare(context.Background(), &fr)
+
+			require.NoError(t, err)
+			usr := task.(*user.User)
+			policy := &user.IDRange{Min: min, Max: max}
+			assert.Equal(t, policy, usr.UIDPolicy)
+			assert.Equal(t, policy, usr.UIDRange)
+			assert.Equal(t, policy, usr.GIDPolicy)
+			assert.Equal(t, policy, usr.GIDRange)
+		})
+
+		t.Run("uid_min and uid_max with uid", func(t *testing.T) {
+			min, max, uid := uint32(1000), uint32(1999), uint32(1500)
+			p := user.Preparer{Username: "test", UID: &uid, UIDMin: &min, UIDMax: &max}
+			task, err := p.Prepare(context.Background(), &fr)
+
+			require.NoError(t, err)
+			assert.Nil(t, task.(*user.User).UIDRange)
+			assert.Equal(t, "1500", task.(*user.User).UID)
+		})
+
+		t.Run("remove_all", func(t *testing.T) {
+			p := user.Preparer{Username: "test", State: user.StateAbsent, RemoveAll: true}
+			task, err := p.Prepare(context.Background(), &fr)
+
+			require.NoError(t, err)
+			assert.True(t, task.(*user.User).RemoveAll)
+		})
 	})
 
 	t.Run("invalid", func(t *testing.T) {
@@ -171,6 +241,66 @@ func TestPrepare(t *testing.T) {
 			assert.EqualError(t, err, fmt.Sprintf("user \"gid\" parameter out of range"))
 		})
 
+		t.Run("id policy", func(t *testing.T) {
+			min, max, low := uint32(1000), uint32(1999), uint32(500)
+			for name, tc := range map[string]struct {
+				p   user.Preparer
+				err string
+			}{
+				"uid_min without uid_max": {
+					user.Preparer{Username: "test", UIDMin: &min},
+					`user "uid_min" and "uid_max" parameters must be set together`,
+				},
+				"gid_max without gid_min": {
+					user.Preparer{Username: "test", GIDMax: &max},
+					`user "gid_min" and "gid_max" parameters must be set together`,
+				},
+				"uid_min over uid_max": {
+					user.Preparer{Username: "test", UIDMin: &max, UIDMax: &min},
+					`user "uid_min" parameter cannot be greater than "uid_max" parameter`,
+				},
+				"uid_max out of range": {
+					user.Preparer{Username: "test", UIDMin: &min, UIDMax: &invalidID},
+					`user "uid_max" parameter out of range`,
+				},
+				"uid outside": {
+					user.Preparer{Username: "test", UID: &low, UIDMin: &min, UIDMax: &max},
+					`user "uid" parameter must be within "uid_min" and "uid_max"`,
+				},
+				"uid_range outside": {
+					user.Preparer{Username: "test", UIDRange: "500-1500", UIDMin: &min, UIDMax: &max},
+					`user "uid_range" parameter must be within "uid_min" and "uid_max"`,
+				},
+				"gid outside": {
+					user.Preparer{Username: "test", GID: &low, GIDMin: &min, GIDMax: &max},
+					`user "gid" parameter must be within "gid_min" and "gid_max"`,
+				},
+				"gid_min with groupname": {
+					user.Preparer{Username: "test", GroupName: "test", GIDMin: &min, GIDMax: &max},
+					`user "gid_min" and "gid_max" parameters cannot be used with "groupname" parameter`,
+				},
+			} {
+				t.Run(name, func(t *testing.T) {
+					_, err := tc.p.Prepare(context.Background(), &fr)
+					assert.EqualError(t, err, tc.err)
+				})
+			}
+		})
+
+		t.Run("remove_all without state absent", func(t *testing.T) {
+			p := user.Preparer{Username: "test", RemoveAll: true}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, "user \"remove_all\" parameter is only valid with \"state\" absent")
+		})
+
+		t.Run("create_home false with skel_dir", func(t *testing.T) {
+			p := user.Preparer{Username: "test", CreateHome: &noCreateHome, SkelDir: "/etc/skel"}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, "user \"skel_dir\" parameter requires \"create_home\" to be true")
+		})
+
 		t.Run("no create_home with skel_dir", func(t *testing.T) {
 			p := user.Preparer{UID: &testID, GID: &testID, Username: "test", SkelDir: "/etc/skel", HomeDir: "tmp", State: user.StateAbsent}
 			_, err := p.Prepare(context.Background(), &fr)
@@ -184,5 +314,93 @@ func TestPrepare(t *testing.T) {
 
 			assert.EqualError(t, err, fmt.Sprintf("user \"home_dir\" parameter required with \"move_dir\" parameter"))
 		})
+
+		t.Run("disabled with state absent", func(t *testing.T) {
+			p := user.Preparer{Username: "test", Disabled: true, State: user.StateAbsent}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, fmt.Sprintf("user \"disabled\" parameter is only valid with \"state\" present"))
+		})
+
+		t.Run("disabled with state unlocked", func(t *testing.T) {
+			p := user.Preparer{Username: "test", Disabled: true, State: user.StateUnlocked}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, fmt.Sprintf("user \"disabled\" parameter cannot be used with \"state\" unlocked"))
+		})
+
+		t.Run("shell with disabled", func(t *testing.T) {
+			p := user.Preparer{Username: "test", Shell: "/bin/sh", Disabled: true}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, fmt.Sprintf("user \"shell\" parameter cannot be used with \"disabled\" parameter"))
+		})
+
+		t.Run("append without groups", func(t *testing.T) {
+			p := user.Preparer{Username: "test", Append: true}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, fmt.Sprintf("user \"groups\" parameter required with \"append\" parameter"))
+		})
+
+		t.Run("groups with state absent", func(t *testing.T) {
+			p := user.Preparer{Username: "test", Groups: []string{"wheel"}, State: user.StateAbsent}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, fmt.Sprintf("user \"groups\" parameter is only valid with \"state\" present"))
+		})
+
+		t.Run("password aging with state absent", func(t *testing.T) {
+			days := 90
+			p := user.Preparer{Username: "test", MaxDays: &days, State: user.StateAbsent}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, fmt.Sprintf("user password aging parameters are only valid with \"state\" present"))
+		})
+
+		t.Run("negative min_days", func(t *testing.T) {
+			days := -1
+			p := user.Preparer{Username: "test", MinDays: &days}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, fmt.Sprintf("user \"min_days\" parameter must not be negative"))
+		})
+
+		t.Run("max_days below -1", func(t *testing.T) {
+			days := -2
+			p := user.Preparer{Username: "test", MaxDays: &days}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, fmt.Sprintf("user \"max_days\" parameter must be -1 or more"))
+		})
+
+		t.Run("min_days greater than max_days", func(t *testing.T) {
+			min, max := 30, 7
+			p := user.Preparer{Username: "test", MinDays: &min, MaxDays: &max}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, fmt.Sprintf("user \"min_days\" parameter cannot be greater than \"max_days\" parameter"))
+		})
+
+		t.Run("system with state absent", func(t *testing.T) {
+			p := user.Preparer{Username: "test", System: true, State: user.StateAbsent}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, fmt.Sprintf("user \"system\" parameter is only valid with \"state\" present"))
+		})
+
+		t.Run("password with state absent", func(t *testing.T) {
+			p := user.Preparer{Username: "test", Password: "$6$salt$hash", State: user.StateAbsent}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, fmt.Sprintf("user \"password\" parameter is only valid with \"state\" present"))
+		})
+
+		t.Run("password not a hash", func(t *testing.T) {
+			p := user.Preparer{Username: "test", Password: "test:0:99999"}
+			_, err := p.Prepare(context.Background(), &fr)
+
+			assert.EqualError(t, err, fmt.Sprintf("user \"password\" parameter must be a password hash"))
+		})
 	})
 }
@@ -0,0 +1,114 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package user
+
+import (
+	"bufio"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PasswdFile is the passwd file of the users local tools like useradd manage
+const PasswdFile = "/etc/passwd"
+
+// Provider looks up users in a user database. A user that isn't in the
+// database is reported with user.UnknownUserError.
+type Provider interface {
+	Lookup(userName string) (*user.User, error)
+}
+
+// Location is where a user is defined
+type Location string
+
+const (
+	// LocationNone is used for a user that doesn't exist
+	LocationNone Location = "none"
+
+	// LocationLocal is used for a user in the local passwd file
+	LocationLocal Location = "local"
+
+	// LocationDirectory is used for a user that is only known to the name
+	// service, for example from SSSD or LDAP
+	LocationDirectory Location = "directory"
+)
+
+// Locate finds where a user is defined. Users in the local provider are local,
+// and users that are only found by the name service provider come from a
+// directory service.
+func Locate(userName string, local, nameService Provider) (Location, error) {
+	_, err := local.Lookup(userName)
+	switch err.(type) {
+	case nil:
+		return LocationLocal, nil
+	case user.UnknownUserError:
+	default:
+		return LocationNone, err
+	}
+
+	_, err = nameService.Lookup(userName)
+	switch err.(type) {
+	case nil:
+		return LocationDirectory, nil
+	case user.UnknownUserError:
+		return LocationNone, nil
+	default:
+		return LocationNone, err
+	}
+}
+
+// PasswdProvider looks up users in a passwd file, without going through the
+// name service
+type PasswdProvider struct {
+	Path string
+}
+
+// Lookup looks up a user by name in the passwd file
+func (p *PasswdProvider) Lookup(userName string) (*user.User, error) {
+	f, err := os.Open(p.Path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read passwd file")
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// name:password:uid:gid:gecos:home:shell
+		fields := strings.Split(line, ":")
+		if len(fields) != 7 || fields[0] != userName {
+			continue
+		}
+
+		return &user.User{
+			Username: fields[0],
+			Uid:      fields[2],
+			Gid:      fields[3],
+			Name:     strings.SplitN(fields[4], ",", 2)[0],
+			HomeDir:  fields[5],
+		}, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "could not read passwd file")
+	}
+
+	return nil, user.UnknownUserError(userName)
+}
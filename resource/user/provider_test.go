@@ -0,0 +1,89 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package user_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	osuser "os/user"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/resource/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswdProvider(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "converge-passwd")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	path := filepath.Join(tmp, "passwd")
+	require.NoError(t, ioutil.WriteFile(path, []byte(
+		"# local users\nroot:x:0:0:root:/root:/bin/bash\ntest:x:1000:1000:Test User,,,:/home/test:/bin/sh\n+@netgroup::::::\n",
+	), 0644))
+
+	p := &user.PasswdProvider{Path: path}
+
+	t.Run("found", func(t *testing.T) {
+		usr, err := p.Lookup("test")
+		require.NoError(t, err)
+		assert.Equal(t, &osuser.User{Username: "test", Uid: "1000", Gid: "1000", Name: "Test User", HomeDir: "/home/test"}, usr)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := p.Lookup("ldap")
+		assert.Equal(t, osuser.UnknownUserError("ldap"), err)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := (&user.PasswdProvider{Path: filepath.Join(tmp, "missing")}).Lookup("test")
+		assert.Error(t, err)
+	})
+}
+
+func TestLocate(t *testing.T) {
+	t.Parallel()
+
+	local := &fakeProvider{users: map[string]*osuser.User{"local": {Username: "local"}}}
+	all := &fakeProvider{users: map[string]*osuser.User{"local": {Username: "local"}, "ldap": {Username: "ldap"}}}
+
+	for name, expected := range map[string]user.Location{
+		"local":   user.LocationLocal,
+		"ldap":    user.LocationDirectory,
+		"missing": user.LocationNone,
+	} {
+		location, err := user.Locate(name, local, all)
+		require.NoError(t, err)
+		assert.Equal(t, expected, location, name)
+	}
+
+	t.Run("error", func(t *testing.T) {
+		_, err := user.Locate("ldap", local, &errorProvider{})
+		assert.EqualError(t, err, "name service unavailable")
+	})
+}
+
+// errorProvider is a user database that can't be read
+type errorProvider struct{}
+
+// Lookup fails
+func (*errorProvider) Lookup(string) (*osuser.User, error) {
+	return nil, errors.New("name service unavailable")
+}
@@ -0,0 +1,446 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package user
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// commandRunner runs a command and returns what it printed. It is replaced in
+// tests so the pw backend can be checked without a FreeBSD host.
+type commandRunner interface {
+	Run(name string, args ...string) (string, error)
+}
+
+// execRunner runs commands on the host
+type execRunner struct{}
+
+// Run runs a command with exec.Command
+func (execRunner) Run(name string, args ...string) (string, error) {
+	var out bytes.Buffer
+
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrap(err, name)
+	}
+	return out.String(), nil
+}
+
+const (
+	// pwLockPrefix is put in front of the password hash of a locked user by
+	// `pw lock`
+	pwLockPrefix = "*LOCKED*"
+
+	// pwNoPassword is the password field of a user without a password
+	pwNoPassword = "*"
+
+	// system uids are picked from this range when a system user is added
+	// without one, pw itself starts at 1000
+	pwMinSystemUID, pwMaxSystemUID = 100, 999
+)
+
+// errPwAging is returned when password aging would be looked up or set.
+// master.passwd only has a password change date, not the days shadow keeps.
+var errPwAging = errors.New("password aging is not supported on freebsd")
+
+// pwUser is an entry of /etc/master.passwd, as printed by `pw usershow`:
+// name:password:uid:gid:class:change:expire:gecos:home:shell
+type pwUser struct {
+	Name     string
+	Password string
+	UID      string
+	GID      string
+	Expire   int64
+	Comment  string
+	Home     string
+	Shell    string
+}
+
+// pwSystem manages users with pw(8), the user database tool on FreeBSD
+type pwSystem struct {
+	run commandRunner
+}
+
+// AddUser adds a user with `pw useradd`
+func (p *pwSystem) AddUser(userName string, options *AddUserOptions) error {
+	args := []string{"useradd", "-n", userName}
+
+	uid := options.UID
+	if uid == "" && options.System {
+		all, err := p.run.Run("pw", "usershow", "-a")
+		if err != nil {
+			return err
+		}
+		uid, err = pwFreeUID(all, pwMinSystemUID, pwMaxSystemUID)
+		if err != nil {
+			return err
+		}
+	}
+	if uid != "" {
+		args = append(args, "-u", uid)
+	}
+
+	switch {
+	case options.PrivateGID != "":
+		// pw only creates the user's own group when it can pick the gid, so
+		// the group is added first
+		if _, err := p.run.Run("pw", "groupadd", "-n", userName, "-g", options.PrivateGID); err != nil {
+			return err
+		}
+		args = append(args, "-g", userName)
+	case options.Group != "":
+		args = append(args, "-g", options.Group)
+	}
+	if options.Comment != "" {
+		args = append(args, "-c", options.Comment)
+	}
+	if options.Directory != "" {
+		args = append(args, "-d", options.Directory)
+	}
+	if options.CreateHome {
+		args = append(args, "-m")
+		if options.SkelDir != "" {
+			args = append(args, "-k", options.SkelDir)
+		}
+	}
+	if options.Expiry != "" {
+		expiry, err := pwExpiry(options.Expiry)
+		if err != nil {
+			return err
+		}
+		args = append(args, "-e", expiry)
+	}
+	if options.Shell != "" {
+		args = append(args, "-s", options.Shell)
+	}
+	if len(options.Groups) > 0 {
+		args = append(args, "-G", strings.Join(options.Groups, ","))
+	}
+
+	if _, err := p.run.Run("pw", args...); err != nil {
+		return err
+	}
+
+	return p.setPassword(userName, options.Password, options.Lock)
+}
+
+// DelUser deletes a user. Like userdel, the home directory is left alone
+// unless RemoveAll is set, in which case pw removes it and the mail spool.
+func (p *pwSystem) DelUser(userName string, options *DelUserOptions) error {
+	args := []string{"userdel", "-n", userName}
+	if options.RemoveAll {
+		args = append(args, "-r")
+	}
+	_, err := p.run.Run("pw", args...)
+	return err
+}
+
+// ModUser modifies a user with `pw usermod`. The user is renamed along with
+// the other changes, so the password and groups are changed on the new name.
+func (p *pwSystem) ModUser(userName string, options *ModUserOptions) error {
+	args := []string{"usermod", "-n", userName}
+	base := len(args)
+	if options.Username != "" {
+		args = append(args, "-l", options.Username)
+	}
+	if options.UID != "" {
+		args = append(args, "-u", options.UID)
+	}
+	if options.Group != "" {
+		args = append(args, "-g", options.Group)
+	}
+	if options.Comment != "" {
+		args = append(args, "-c", options.Comment)
+	}
+	if options.Directory != "" {
+		// pw usermod changes the home directory without moving it
+		if options.MoveDir {
+			if err := p.moveHome(userName, options.Directory); err != nil {
+				return err
+			}
+		}
+		args = append(args, "-d", options.Directory)
+	}
+	if options.Expiry != "" {
+		expiry, err := pwExpiry(options.Expiry)
+		if err != nil {
+			return err
+		}
+		args = append(args, "-e", expiry)
+	}
+	if options.Shell != "" {
+		args = append(args, "-s", options.Shell)
+	}
+	if len(options.Groups) > 0 && !options.AppendGroups {
+		args = append(args, "-G", strings.Join(options.Groups, ","))
+	}
+
+	if len(args) > base {
+		if _, err := p.run.Run("pw", args...); err != nil {
+			return err
+		}
+	}
+	if options.Username != "" {
+		userName = options.Username
+	}
+
+	if options.AppendGroups {
+		for _, group := range options.Groups {
+			if _, err := p.run.Run("pw", "groupmod", "-n", group, "-m", userName); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch {
+	case options.Password != "":
+		return p.setPassword(userName, options.Password, options.Lock)
+	case options.Lock:
+		_, err := p.run.Run("pw", "lock", userName)
+		return err
+	case options.Unlock:
+		_, err := p.run.Run("pw", "unlock", userName)
+		return err
+	}
+	return nil
+}
+
+// LookupUserExpiry looks up a user's expiry. An expire field of 0 never
+// expires, which is shown as the max time.
+func (p *pwSystem) LookupUserExpiry(userName string) (time.Time, error) {
+	entry, err := p.show(userName)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	zone := time.FixedZone(time.Now().In(time.Local).Zone())
+	if entry.Expire == 0 {
+		return time.ParseInLocation(ShortForm, MaxTime, zone)
+	}
+	return time.Unix(entry.Expire, 0).In(zone), nil
+}
+
+// LookupUserLogin looks up a user's login shell and whether their password is
+// locked
+func (p *pwSystem) LookupUserLogin(userName string) (*Login, error) {
+	entry, err := p.show(userName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Login{
+		Shell:  entry.Shell,
+		Locked: strings.HasPrefix(entry.Password, pwLockPrefix),
+	}, nil
+}
+
+// LookupUserShell looks up a user's login shell
+func (p *pwSystem) LookupUserShell(userName string) (string, error) {
+	entry, err := p.show(userName)
+	if err != nil {
+		return "", err
+	}
+
+	return entry.Shell, nil
+}
+
+// LookupUserComment looks up the whole GECOS field of a user
+func (p *pwSystem) LookupUserComment(userName string) (string, error) {
+	entry, err := p.show(userName)
+	if err != nil {
+		return "", err
+	}
+
+	return entry.Comment, nil
+}
+
+// LookupUserPassword looks up the hashed password of a user in the form of a
+// shadow entry, so the lock marker is "!" and a user without a password has an
+// empty hash
+func (p *pwSystem) LookupUserPassword(userName string) (string, error) {
+	entry, err := p.show(userName)
+	if err != nil {
+		return "", err
+	}
+
+	return pwShadowPassword(entry.Password), nil
+}
+
+// LookupUserGroups looks up the supplementary groups of a user in the group
+// database
+func (p *pwSystem) LookupUserGroups(userName string) ([]string, error) {
+	groups, err := p.run.Run("pw", "groupshow", "-a")
+	if err != nil {
+		return nil, err
+	}
+
+	return pwParseGroups(groups, userName)
+}
+
+// LookupUserAging is not supported on freebsd
+func (p *pwSystem) LookupUserAging(userName string) (*Aging, error) {
+	return nil, errPwAging
+}
+
+// SetUserAging is not supported on freebsd
+func (p *pwSystem) SetUserAging(userName string, aging *Aging) error {
+	return errPwAging
+}
+
+// show looks up the master.passwd entry of a user
+func (p *pwSystem) show(userName string) (*pwUser, error) {
+	out, err := p.run.Run("pw", "usershow", "-n", userName)
+	if err != nil {
+		return nil, err
+	}
+
+	return pwParseUser(out)
+}
+
+// setPassword sets the password hash of a user with chpass, since pw only
+// reads hashes from a file descriptor. A user added locked without a password
+// is locked with `pw lock`.
+func (p *pwSystem) setPassword(userName, password string, lock bool) error {
+	switch {
+	case password != "":
+		if lock {
+			password = pwLockPrefix + password
+		}
+		_, err := p.run.Run("chpass", "-p", password, userName)
+		return err
+	case lock:
+		_, err := p.run.Run("pw", "lock", userName)
+		return err
+	}
+	return nil
+}
+
+// moveHome moves the user's current home directory to dir, like usermod -m
+func (p *pwSystem) moveHome(userName, dir string) error {
+	entry, err := p.show(userName)
+	if err != nil {
+		return err
+	}
+
+	if entry.Home == "" || entry.Home == dir {
+		return nil
+	}
+	_, err = p.run.Run("mv", entry.Home, dir)
+	return err
+}
+
+// pwParseUser parses an entry of /etc/master.passwd
+func pwParseUser(data string) (*pwUser, error) {
+	fields := strings.Split(strings.TrimSpace(data), ":")
+	if len(fields) != 10 {
+		return nil, errors.New("could not parse master.passwd entry for user")
+	}
+
+	expire, err := strconv.ParseInt(fields[6], 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse account expiry")
+	}
+
+	return &pwUser{
+		Name:     fields[0],
+		Password: fields[1],
+		UID:      fields[2],
+		GID:      fields[3],
+		Expire:   expire,
+		Comment:  fields[7],
+		Home:     fields[8],
+		Shell:    fields[9],
+	}, nil
+}
+
+// pwShadowPassword converts a password field of master.passwd to the form of
+// a shadow entry
+func pwShadowPassword(password string) string {
+	var locked bool
+	if strings.HasPrefix(password, pwLockPrefix) {
+		locked = true
+		password = strings.TrimPrefix(password, pwLockPrefix)
+	}
+	if password == pwNoPassword {
+		password = ""
+	}
+	if locked {
+		return "!" + password
+	}
+	return password
+}
+
+// pwParseGroups finds the groups a user is a member of in the group database,
+// as printed by `pw groupshow -a`
+func pwParseGroups(data, userName string) ([]string, error) {
+	var groups []string
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		if len(fields) != 4 {
+			return nil, errors.New("could not parse group entry")
+		}
+
+		for _, member := range strings.Split(fields[3], ",") {
+			if member == userName {
+				groups = append(groups, fields[0])
+				break
+			}
+		}
+	}
+	return groups, nil
+}
+
+// pwFreeUID picks the lowest uid from min to max that isn't used by an entry
+// printed by `pw usershow -a`
+func pwFreeUID(data string, min, max int) (string, error) {
+	used := map[int]bool{}
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 {
+			continue
+		}
+		if id, err := strconv.Atoi(fields[2]); err == nil {
+			used[id] = true
+		}
+	}
+
+	for id := min; id <= max; id++ {
+		if !used[id] {
+			return strconv.Itoa(id), nil
+		}
+	}
+	return "", fmt.Errorf("no free uid from %d to %d", min, max)
+}
+
+// pwExpiry converts an expiry date to the dd-mm-yyyy form pw takes
+func pwExpiry(expiry string) (string, error) {
+	date, err := time.Parse(ShortForm, expiry)
+	if err != nil {
+		return "", errors.Wrap(err, "could not parse expiry")
+	}
+	return date.Format("02-01-2006"), nil
+}
@@ -0,0 +1,302 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package user
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRunner records the commands it's asked to run and prints canned output
+type fakeRunner struct {
+	out  map[string]string
+	errs map[string]error
+	ran  []string
+}
+
+func (f *fakeRunner) Run(name string, args ...string) (string, error) {
+	line := strings.Join(append([]string{name}, args...), " ")
+	f.ran = append(f.ran, line)
+	return f.out[line], f.errs[line]
+}
+
+const pwTestEntry = "test:$6$salt$hash:1001:1001::0:0:Test User:/home/test:/bin/sh\n"
+
+func TestPwAddUser(t *testing.T) {
+	t.Parallel()
+
+	t.Run("options", func(t *testing.T) {
+		run := &fakeRunner{}
+		p := &pwSystem{run: run}
+
+		require.NoError(t, p.AddUser("test", &AddUserOptions{
+			UID:        "1001",
+			Group:      "staff",
+			Comment:    "Test User",
+			CreateHome: true,
+			SkelDir:    "/tmp/skel",
+			Directory:  "/home/test",
+			Expiry:     "2030-03-15",
+			Shell:      "/bin/sh",
+			Groups:     []string{"wheel", "operator"},
+		}))
+		assert.Equal(t, []string{
+			"pw useradd -n test -u 1001 -g staff -c Test User -d /home/test -m -k /tmp/skel -e 15-03-2030 -s /bin/sh -G wheel,operator",
+		}, run.ran)
+	})
+
+	t.Run("system", func(t *testing.T) {
+		run := &fakeRunner{out: map[string]string{
+			"pw usershow -a": "root:*:0:0::0:0:Charlie &:/root:/bin/sh\ndaemon:*:1:1::0:0:Owner of many system processes:/root:/usr/sbin/nologin\nfoo:*:100:100::0:0::/nonexistent:/usr/sbin/nologin\n",
+		}}
+		p := &pwSystem{run: run}
+
+		require.NoError(t, p.AddUser("test", &AddUserOptions{System: true}))
+		assert.Equal(t, []string{"pw usershow -a", "pw useradd -n test -u 101"}, run.ran)
+	})
+
+	t.Run("private group", func(t *testing.T) {
+		run := &fakeRunner{}
+		p := &pwSystem{run: run}
+
+		require.NoError(t, p.AddUser("test", &AddUserOptions{PrivateGID: "2000"}))
+		assert.Equal(t, []string{"pw groupadd -n test -g 2000", "pw useradd -n test -g test"}, run.ran)
+	})
+
+	t.Run("password", func(t *testing.T) {
+		run := &fakeRunner{}
+		p := &pwSystem{run: run}
+
+		require.NoError(t, p.AddUser("test", &AddUserOptions{Password: "$6$hash", Lock: true}))
+		assert.Equal(t, []string{"pw useradd -n test", "chpass -p *LOCKED*$6$hash test"}, run.ran)
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		run := &fakeRunner{}
+		p := &pwSystem{run: run}
+
+		require.NoError(t, p.AddUser("test", &AddUserOptions{Lock: true}))
+		assert.Equal(t, []string{"pw useradd -n test", "pw lock test"}, run.ran)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		run := &fakeRunner{errs: map[string]error{"pw useradd -n test": errors.New("pw: user 'test' already exists")}}
+		p := &pwSystem{run: run}
+
+		assert.EqualError(t, p.AddUser("test", &AddUserOptions{Lock: true}), "pw: user 'test' already exists")
+		assert.Equal(t, []string{"pw useradd -n test"}, run.ran)
+	})
+}
+
+func TestPwDelUser(t *testing.T) {
+	t.Parallel()
+
+	run := &fakeRunner{}
+	p := &pwSystem{run: run}
+
+	require.NoError(t, p.DelUser("test", &DelUserOptions{}))
+	assert.Equal(t, []string{"pw userdel -n test"}, run.ran)
+
+	run = &fakeRunner{}
+	p = &pwSystem{run: run}
+
+	require.NoError(t, p.DelUser("test", &DelUserOptions{RemoveAll: true}))
+	assert.Equal(t, []string{"pw userdel -n test -r"}, run.ran)
+}
+
+func TestPwModUser(t *testing.T) {
+	t.Parallel()
+
+	t.Run("options", func(t *testing.T) {
+		run := &fakeRunner{}
+		p := &pwSystem{run: run}
+
+		require.NoError(t, p.ModUser("test", &ModUserOptions{
+			UID:     "1002",
+			Group:   "staff",
+			Comment: "Test User",
+			Expiry:  "2030-03-15",
+			Shell:   "/bin/csh",
+			Groups:  []string{"wheel"},
+		}))
+		assert.Equal(t, []string{
+			"pw usermod -n test -u 1002 -g staff -c Test User -e 15-03-2030 -s /bin/csh -G wheel",
+		}, run.ran)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		run := &fakeRunner{}
+		p := &pwSystem{run: run}
+
+		require.NoError(t, p.ModUser("test", &ModUserOptions{Username: "renamed", Lock: true}))
+		assert.Equal(t, []string{"pw usermod -n test -l renamed", "pw lock renamed"}, run.ran)
+	})
+
+	t.Run("move home", func(t *testing.T) {
+		run := &fakeRunner{out: map[string]string{"pw usershow -n test": pwTestEntry}}
+		p := &pwSystem{run: run}
+
+		require.NoError(t, p.ModUser("test", &ModUserOptions{Directory: "/usr/home/test", MoveDir: true}))
+		assert.Equal(t, []string{
+			"pw usershow -n test",
+			"mv /home/test /usr/home/test",
+			"pw usermod -n test -d /usr/home/test",
+		}, run.ran)
+	})
+
+	t.Run("append groups", func(t *testing.T) {
+		run := &fakeRunner{}
+		p := &pwSystem{run: run}
+
+		require.NoError(t, p.ModUser("test", &ModUserOptions{Groups: []string{"wheel", "operator"}, AppendGroups: true}))
+		assert.Equal(t, []string{"pw groupmod -n wheel -m test", "pw groupmod -n operator -m test"}, run.ran)
+	})
+
+	t.Run("password", func(t *testing.T) {
+		run := &fakeRunner{}
+		p := &pwSystem{run: run}
+
+		require.NoError(t, p.ModUser("test", &ModUserOptions{Password: "$6$hash"}))
+		assert.Equal(t, []string{"chpass -p $6$hash test"}, run.ran)
+	})
+
+	t.Run("unlock", func(t *testing.T) {
+		run := &fakeRunner{}
+		p := &pwSystem{run: run}
+
+		require.NoError(t, p.ModUser("test", &ModUserOptions{Unlock: true}))
+		assert.Equal(t, []string{"pw unlock test"}, run.ran)
+	})
+}
+
+func TestPwLookup(t *testing.T) {
+	t.Parallel()
+
+	locked := "test:*LOCKED*$6$salt$hash:1001:1001::0:1899849600:Test User:/home/test:/usr/sbin/nologin\n"
+
+	t.Run("expiry", func(t *testing.T) {
+		p := &pwSystem{run: &fakeRunner{out: map[string]string{"pw usershow -n test": locked}}}
+
+		expiry, err := p.LookupUserExpiry("test")
+		require.NoError(t, err)
+		assert.Equal(t, time.Unix(1899849600, 0).Unix(), expiry.Unix())
+
+		p = &pwSystem{run: &fakeRunner{out: map[string]string{"pw usershow -n test": pwTestEntry}}}
+		expiry, err = p.LookupUserExpiry("test")
+		require.NoError(t, err)
+		assert.Equal(t, MaxTime, expiry.Format(ShortForm))
+	})
+
+	t.Run("login", func(t *testing.T) {
+		p := &pwSystem{run: &fakeRunner{out: map[string]string{"pw usershow -n test": locked}}}
+
+		login, err := p.LookupUserLogin("test")
+		require.NoError(t, err)
+		assert.Equal(t, &Login{Shell: "/usr/sbin/nologin", Locked: true}, login)
+	})
+
+	t.Run("shell", func(t *testing.T) {
+		p := &pwSystem{run: &fakeRunner{out: map[string]string{"pw usershow -n test": locked}}}
+
+		shell, err := p.LookupUserShell("test")
+		require.NoError(t, err)
+		assert.Equal(t, "/usr/sbin/nologin", shell)
+	})
+
+	t.Run("comment", func(t *testing.T) {
+		p := &pwSystem{run: &fakeRunner{out: map[string]string{"pw usershow -n test": pwTestEntry}}}
+
+		comment, err := p.LookupUserComment("test")
+		require.NoError(t, err)
+		assert.Equal(t, "Test User", comment)
+	})
+
+	t.Run("password", func(t *testing.T) {
+		p := &pwSystem{run: &fakeRunner{out: map[string]string{"pw usershow -n test": locked}}}
+
+		password, err := p.LookupUserPassword("test")
+		require.NoError(t, err)
+		assert.Equal(t, "!$6$salt$hash", password)
+	})
+
+	t.Run("groups", func(t *testing.T) {
+		p := &pwSystem{run: &fakeRunner{out: map[string]string{
+			"pw groupshow -a": "wheel:*:0:root,test\noperator:*:5:root\nstaff:*:20:test\n",
+		}}}
+
+		groups, err := p.LookupUserGroups("test")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"wheel", "staff"}, groups)
+	})
+
+	t.Run("missing user", func(t *testing.T) {
+		p := &pwSystem{run: &fakeRunner{errs: map[string]error{"pw usershow -n test": errors.New("pw: no such user `test'")}}}
+
+		_, err := p.LookupUserLogin("test")
+		assert.Error(t, err)
+	})
+
+	t.Run("aging", func(t *testing.T) {
+		p := &pwSystem{run: &fakeRunner{}}
+
+		_, err := p.LookupUserAging("test")
+		assert.Equal(t, errPwAging, err)
+		assert.Equal(t, errPwAging, p.SetUserAging("test", &Aging{MaxDays: "90"}))
+	})
+}
+
+func TestPwParseUser(t *testing.T) {
+	t.Parallel()
+
+	entry, err := pwParseUser(pwTestEntry)
+	require.NoError(t, err)
+	assert.Equal(t, &pwUser{
+		Name:     "test",
+		Password: "$6$salt$hash",
+		UID:      "1001",
+		GID:      "1001",
+		Comment:  "Test User",
+		Home:     "/home/test",
+		Shell:    "/bin/sh",
+	}, entry)
+
+	_, err = pwParseUser("test:x:1001:1001:Test User:/home/test:/bin/sh")
+	assert.Error(t, err)
+}
+
+func TestPwShadowPassword(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "$6$hash", pwShadowPassword("$6$hash"))
+	assert.Equal(t, "!$6$hash", pwShadowPassword("*LOCKED*$6$hash"))
+	assert.Equal(t, "", pwShadowPassword("*"))
+	assert.Equal(t, "!", pwShadowPassword("*LOCKED**"))
+}
+
+func TestPwFreeUID(t *testing.T) {
+	t.Parallel()
+
+	uid, err := pwFreeUID("a:*:100:100::0:0::/:/bin/sh\nb:*:102:102::0:0::/:/bin/sh\n", 100, 102)
+	require.NoError(t, err)
+	assert.Equal(t, "101", uid)
+
+	_, err = pwFreeUID("a:*:100:100::0:0::/:/bin/sh\n", 100, 100)
+	assert.Error(t, err)
+}
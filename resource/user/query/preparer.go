@@ -0,0 +1,50 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/user"
+	"golang.org/x/net/context"
+)
+
+// Preparer for user.query
+//
+// Query looks up a user account without changing it, and exports its uid,
+// gid, home directory and login shell, so other resources can use the values
+// on the system with `lookup`. The account is looked up when checking, and a
+// query never has changes.
+type Preparer struct {
+	// Username is the name of the user to look up
+	Username string `hcl:"username" required:"true" nonempty:"true"`
+
+	// Optional makes a missing user not an error. The query exports
+	// `exists` as false and leaves the other fields empty instead.
+	Optional bool `hcl:"optional"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	return &Query{
+		Username: p.Username,
+		Optional: p.Optional,
+		system:   new(user.System),
+	}, nil
+}
+
+func init() {
+	registry.Register("user.query", (*Preparer)(nil), (*Query)(nil))
+}
@@ -0,0 +1,114 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"errors"
+	"fmt"
+	"os/user"
+
+	"github.com/asteris-llc/converge/resource"
+	"golang.org/x/net/context"
+)
+
+// System looks up the parts of a user account
+type System interface {
+	Lookup(userName string) (*user.User, error)
+	LookupGroupID(groupID string) (*user.Group, error)
+	LookupUserShell(userName string) (string, error)
+	LookupUserGroups(userName string) ([]string, error)
+}
+
+// Query looks up a user account
+type Query struct {
+	// the name of the user
+	Username string `export:"username"`
+
+	// whether the user exists
+	Exists bool `export:"exists"`
+
+	// the uid of the user
+	UID string `export:"uid"`
+
+	// the gid of the user's primary group
+	GID string `export:"gid"`
+
+	// the name of the user's primary group
+	GroupName string `export:"groupname"`
+
+	// the user's full name
+	Name string `export:"name"`
+
+	// the user's home directory
+	HomeDir string `export:"homedir"`
+
+	// the user's login shell
+	Shell string `export:"shell"`
+
+	// the user's supplementary groups
+	Groups []string `export:"groups"`
+
+	// whether a missing user is not an error
+	Optional bool `export:"optional"`
+
+	system System
+}
+
+// Check looks up the user. It never has changes.
+func (q *Query) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	usr, err := q.system.Lookup(q.Username)
+	if err != nil {
+		if _, ok := err.(user.UnknownUserError); ok && q.Optional {
+			q.Exists = false
+			status.AddMessage(fmt.Sprintf("user %s does not exist", q.Username))
+			return status, nil
+		}
+		status.RaiseLevel(resource.StatusFatal)
+		return status, fmt.Errorf("user query: %s", err)
+	}
+
+	q.Exists = true
+	q.UID = usr.Uid
+	q.GID = usr.Gid
+	q.Name = usr.Name
+	q.HomeDir = usr.HomeDir
+
+	if group, err := q.system.LookupGroupID(usr.Gid); err == nil {
+		q.GroupName = group.Name
+	} else if _, ok := err.(user.UnknownGroupIdError); !ok {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, fmt.Errorf("user query: %s", err)
+	}
+
+	if q.Shell, err = q.system.LookupUserShell(q.Username); err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, fmt.Errorf("user query: shell of %s: %s", q.Username, err)
+	}
+
+	if q.Groups, err = q.system.LookupUserGroups(q.Username); err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, fmt.Errorf("user query: groups of %s: %s", q.Username, err)
+	}
+
+	return status, nil
+}
+
+// Apply is a nop for queries. Because they never have changes this should
+// never be executed.
+func (q *Query) Apply(context.Context) (resource.TaskStatus, error) {
+	return nil, errors.New("user query apply called but it should never have changes")
+}
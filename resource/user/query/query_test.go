@@ -0,0 +1,114 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"errors"
+	"os/user"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// fakeSystem has a single user, alice
+type fakeSystem struct {
+	shellErr error
+}
+
+func (f *fakeSystem) Lookup(userName string) (*user.User, error) {
+	if userName != "alice" {
+		return nil, user.UnknownUserError(userName)
+	}
+	return &user.User{Username: "alice", Uid: "1000", Gid: "1000", Name: "Alice", HomeDir: "/home/alice"}, nil
+}
+
+func (f *fakeSystem) LookupGroupID(groupID string) (*user.Group, error) {
+	if groupID != "1000" {
+		return nil, user.UnknownGroupIdError(groupID)
+	}
+	return &user.Group{Gid: "1000", Name: "staff"}, nil
+}
+
+func (f *fakeSystem) LookupUserShell(userName string) (string, error) {
+	return "/bin/bash", f.shellErr
+}
+
+func (f *fakeSystem) LookupUserGroups(userName string) ([]string, error) {
+	return []string{"wheel", "docker"}, nil
+}
+
+func TestQueryInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(Query))
+}
+
+func TestQueryCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("exists", func(t *testing.T) {
+		q := &Query{Username: "alice", system: &fakeSystem{}}
+
+		status, err := q.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+
+		assert.True(t, q.Exists)
+		assert.Equal(t, "1000", q.UID)
+		assert.Equal(t, "1000", q.GID)
+		assert.Equal(t, "staff", q.GroupName)
+		assert.Equal(t, "Alice", q.Name)
+		assert.Equal(t, "/home/alice", q.HomeDir)
+		assert.Equal(t, "/bin/bash", q.Shell)
+		assert.Equal(t, []string{"wheel", "docker"}, q.Groups)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		q := &Query{Username: "bob", system: &fakeSystem{}}
+
+		status, err := q.Check(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "user query: user: unknown user bob")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+
+	t.Run("missing optional", func(t *testing.T) {
+		q := &Query{Username: "bob", Optional: true, system: &fakeSystem{}}
+
+		status, err := q.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.False(t, q.Exists)
+		assert.Equal(t, "", q.UID)
+		assert.Equal(t, []string{"user bob does not exist"}, status.Messages())
+	})
+
+	t.Run("lookup error", func(t *testing.T) {
+		q := &Query{Username: "alice", system: &fakeSystem{shellErr: errors.New("getent: exit status 2")}}
+
+		_, err := q.Check(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "user query: shell of alice: getent: exit status 2")
+	})
+}
+
+func TestQueryApply(t *testing.T) {
+	t.Parallel()
+
+	_, err := new(Query).Apply(context.Background())
+	assert.Error(t, err)
+}
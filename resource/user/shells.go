@@ -0,0 +1,43 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package user
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// shellsFile lists the valid login shells. It is a variable so tests can
+// check shells against a fixture instead of the host's list.
+var shellsFile = "/etc/shells"
+
+// checkShell makes sure a login shell is listed in the shells file
+func checkShell(file, shell string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return errors.Wrapf(err, "user \"shell\" parameter could not be checked")
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == shell {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("user \"shell\" parameter %s is not listed in %s, set \"skip_shell_check\" to use it anyway", shell, file)
+}
@@ -0,0 +1,65 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package user
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// TestShellCheck tests that login shells are checked against the shells file
+func TestShellCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "converge-user-shells")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	shells := filepath.Join(dir, "shells")
+	require.NoError(t, ioutil.WriteFile(shells, []byte("# valid login shells\n/bin/sh\n  /usr/local/bin/fish  \n"), 0644))
+
+	defer func(orig string) { shellsFile = orig }(shellsFile)
+	shellsFile = shells
+
+	for name, tc := range map[string]struct {
+		shell string
+		err   string
+	}{
+		"listed":            {shell: "/bin/sh"},
+		"listed with space": {shell: "/usr/local/bin/fish"},
+		"not listed":        {shell: "/bin/zsh", err: "user \"shell\" parameter /bin/zsh is not listed in " + shells + ", set \"skip_shell_check\" to use it anyway"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := Preparer{Username: "test", Shell: tc.shell}
+			_, err := p.Prepare(context.Background(), fakerenderer.New())
+
+			if tc.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.err)
+			}
+		})
+	}
+
+	t.Run("missing shells file", func(t *testing.T) {
+		err := checkShell(filepath.Join(dir, "missing"), "/bin/sh")
+		assert.Error(t, err)
+	})
+}
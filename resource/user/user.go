@@ -17,6 +17,9 @@ package user
 import (
 	"fmt"
 	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/asteris-llc/converge/resource"
@@ -34,13 +37,32 @@ const (
 	// StateAbsent indicates the user should be absent
 	StateAbsent State = "absent"
 
+	// StateLocked indicates the user should be present with a locked password
+	StateLocked State = "locked"
+
+	// StateUnlocked indicates the user should be present with an unlocked
+	// password
+	StateUnlocked State = "unlocked"
+
 	// ShortForm layout for time parsing
 	ShortForm = "2006-01-02"
 
 	// MaxTime is the max representable time
 	MaxTime = "2038-01-19"
+
+	// MailDir is the directory holding user mail spools
+	MailDir = "/var/mail"
 )
 
+// present is true for the states in which the user exists
+func (s State) present() bool {
+	switch s {
+	case StatePresent, StateLocked, StateUnlocked:
+		return true
+	}
+	return false
+}
+
 // User manages user users
 type User struct {
 
@@ -65,6 +87,11 @@ type User struct {
 	// if the home directory should be created
 	CreateHome bool `export:"createhome"`
 
+	// if the home directory should not be created when adding the user, even
+	// if the system default is to create one. Only useradd on Linux creates
+	// one by default, so this is ignored on other platforms.
+	NoCreateHome bool `export:"nocreatehome"`
+
 	// the path to the skeleton directory
 	SkelDir string `export:"skeldir"`
 
@@ -77,9 +104,61 @@ type User struct {
 	// the date the user account will be disabled
 	Expiry time.Time `export:"expiry"`
 
+	// if the account should be unable to log in
+	Disabled bool `export:"disabled"`
+
+	// the login shell of the user
+	Shell string `export:"shell"`
+
+	// the supplementary groups of the user
+	Groups []string `export:"groups"`
+
+	// if the user is added to Groups while keeping their other supplementary
+	// groups
+	Append bool `export:"append"`
+
+	// the hashed password of the user. It is not exported so the hash never
+	// shows up in plan output.
+	Password string
+
+	// if the user is added as a system account
+	System bool `export:"system"`
+
+	// the password aging of the user, in days. Empty fields are not managed.
+	MinDays  string `export:"min_days"`
+	MaxDays  string `export:"max_days"`
+	WarnDays string `export:"warn_days"`
+
 	// configured the user state
 	State State `export:"state"`
 
+	// if the home directory and mail spool are removed along with the user
+	RemoveAll bool `export:"remove_all"`
+
+	// the range to pick a uid from when adding the user
+	UIDRange *IDRange
+
+	// the range to pick the gid of the user's own group from when adding the
+	// user
+	GIDRange *IDRange
+
+	// the ranges the uid and gid of the user must be in. An existing user with
+	// ids outside them is not modified unless the ids are changed to ones
+	// inside.
+	UIDPolicy *IDRange
+	GIDPolicy *IDRange
+
+	// if only users in the local passwd file are managed, leaving users that
+	// come from a directory service alone
+	LocalOnly bool `export:"local_only"`
+
+	// the user database local tools manage, which LocalOnly looks users up in.
+	// The passwd file is used when it is nil.
+	Local Provider
+
+	// the gid picked from GIDRange for the group added with the user
+	privateGID string
+
 	system SystemUtils
 }
 
@@ -93,6 +172,20 @@ type AddUserOptions struct {
 	SkelDir    string
 	Directory  string
 	Expiry     string
+	Shell      string
+	Lock       bool
+	Password   string
+	Groups     []string
+	System     bool
+
+	// NoCreateHome keeps useradd from creating the home directory
+	NoCreateHome bool
+
+	// PrivateGID is the gid of the group created for the user
+	PrivateGID string
+
+	// Aging is set with chage after the user is added
+	Aging *Aging
 }
 
 // ModUserOptions are the options specified in the configuration to be used
@@ -105,14 +198,99 @@ type ModUserOptions struct {
 	Directory string
 	MoveDir   bool
 	Expiry    string
+	Shell     string
+	Lock      bool
+	Unlock    bool
+	Password  string
+
+	// Groups are the supplementary groups the user is set to, or added to when
+	// AppendGroups is true
+	Groups       []string
+	AppendGroups bool
+
+	// The home directory at HomeDir is created, or has its owner corrected,
+	// after the user is modified. These are not usermod options. A home
+	// directory moved with MoveDir has the owner of its contents corrected.
+	HomeDir    string
+	CreateHome bool
+	SkelDir    string
+	ChownHome  bool
+
+	// Aging is set with chage after the user is modified
+	Aging *Aging
+}
+
+// DelUserOptions are the options specified in the configuration to be used
+// when deleting a user
+type DelUserOptions struct {
+	// RemoveAll removes the home directory and mail spool of the user
+	RemoveAll bool
+}
+
+// usermod is true when there are changes for the modify command
+func (o *ModUserOptions) usermod() bool {
+	return o.Username != "" || o.UID != "" || o.Group != "" || o.Comment != "" ||
+		o.Directory != "" || o.Expiry != "" || o.Shell != "" || o.Lock ||
+		o.Unlock || o.Password != "" || len(o.Groups) > 0
+}
+
+// Aging is the password aging of a user, in days, as stored in /etc/shadow.
+// Empty fields are unset.
+type Aging struct {
+	MinDays  string
+	MaxDays  string
+	WarnDays string
+}
+
+// Home describes an existing home directory
+type Home struct {
+	Exists bool
+	IsDir  bool
+	UID    string
+	GID    string
+}
+
+// Login is the login state of an existing user
+type Login struct {
+	// Shell is the user's login shell
+	Shell string
+
+	// Locked is true when the user's password is locked
+	Locked bool
+}
+
+// HasNologinShell is true when the login shell refuses interactive logins
+func (l *Login) HasNologinShell() bool {
+	switch filepath.Base(l.Shell) {
+	case "nologin", "false":
+		return true
+	}
+	return false
+}
+
+// CanLogin is true when the user can log in either with a password or through
+// an interactive shell
+func (l *Login) CanLogin() bool {
+	return !l.Locked || !l.HasNologinShell()
 }
 
 // SystemUtils provides system utilities for user
 type SystemUtils interface {
 	AddUser(userName string, options *AddUserOptions) error
-	DelUser(userName string) error
+	DelUser(userName string, options *DelUserOptions) error
 	ModUser(userName string, options *ModUserOptions) error
 	LookupUserExpiry(userName string) (time.Time, error)
+	LookupUserLogin(userName string) (*Login, error)
+	LookupUserShell(userName string) (string, error)
+	LookupUserComment(userName string) (string, error)
+	LookupUserPassword(userName string) (string, error)
+	LookupUserGroups(userName string) ([]string, error)
+	LookupUserAging(userName string) (*Aging, error)
+	SetUserAging(userName string, aging *Aging) error
+	LookupHome(dir string) (*Home, error)
+	CreateHome(dir, skelDir, uid, gid string) error
+	ChownHome(dir, uid, gid string) error
+	ChownHomeTree(dir, uid, gid string) error
 	Lookup(userName string) (*user.User, error)
 	LookupID(userID string) (*user.User, error)
 	LookupGroup(groupName string) (*user.Group, error)
@@ -129,6 +307,16 @@ func NewUser(system SystemUtils) *User {
 	}
 }
 
+// Claims returns the user database
+func (u *User) Claims() []resource.Claim {
+	return []resource.Claim{resource.ClaimUserDB}
+}
+
+// SensitiveFields returns the password, so it isn't shown in provenance
+func (u *User) SensitiveFields() []string {
+	return []string{"password"}
+}
+
 // Check if a user user exists
 func (u *User) Check(context.Context, resource.Renderer) (resource.TaskStatus, error) {
 	// lookup the user by name
@@ -145,8 +333,26 @@ func (u *User) Check(context.Context, resource.Renderer) (resource.TaskStatus, e
 
 	_, nameNotFound := nameErr.(user.UnknownUserError)
 
+	if u.LocalOnly && userByName != nil {
+		location, err := Locate(u.Username, u.localProvider(), u.system)
+		if err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			return status, errors.Wrapf(err, "cannot locate user %s", u.Username)
+		}
+		if location == LocationDirectory {
+			return status, u.diffDirectoryUser(status)
+		}
+	}
+
+	if u.State.present() {
+		if err := u.allocateIDs(userByName, nameNotFound); err != nil {
+			status.RaiseLevel(resource.StatusCantChange)
+			return status, errors.Wrapf(err, "cannot add user %s", u.Username)
+		}
+	}
+
 	switch u.State {
-	case StatePresent:
+	case StatePresent, StateLocked, StateUnlocked:
 		switch {
 		case nameNotFound:
 			_, err := u.DiffAdd(status)
@@ -197,8 +403,26 @@ func (u *User) Apply(context.Context) (resource.TaskStatus, error) {
 
 	_, nameNotFound := nameErr.(user.UnknownUserError)
 
+	if u.LocalOnly && userByName != nil {
+		location, err := Locate(u.Username, u.localProvider(), u.system)
+		if err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			return status, errors.Wrapf(err, "cannot locate user %s", u.Username)
+		}
+		if location == LocationDirectory {
+			return status, u.diffDirectoryUser(status)
+		}
+	}
+
+	if u.State.present() {
+		if err := u.allocateIDs(userByName, nameNotFound); err != nil {
+			status.RaiseLevel(resource.StatusCantChange)
+			return status, errors.Wrapf(err, "will not attempt to add user %s", u.Username)
+		}
+	}
+
 	switch u.State {
-	case StatePresent:
+	case StatePresent, StateLocked, StateUnlocked:
 		switch {
 		case nameNotFound:
 			options, err := u.DiffAdd(status)
@@ -213,6 +437,13 @@ func (u *User) Apply(context.Context) (resource.TaskStatus, error) {
 					return status, errors.Wrap(err, "user add")
 				}
 				status.AddMessage(fmt.Sprintf("added user %s", u.Username))
+				if err := u.applyAging(status, u.Username, options.Aging); err != nil {
+					return status, err
+				}
+				if u.privateGID != "" {
+					// export the gid now that the group exists
+					u.GID = u.privateGID
+				}
 				if u.CreateHome {
 					u.createHomeDiffs(status)
 				}
@@ -223,13 +454,25 @@ func (u *User) Apply(context.Context) (resource.TaskStatus, error) {
 				return status, errors.Wrapf(err, "will not attempt to modify user %s", u.Username)
 			}
 			if resource.AnyChanges(status.Differences) {
-				err = u.system.ModUser(u.Username, options)
-				if err != nil {
-					status.RaiseLevel(resource.StatusFatal)
-					status.AddMessage(fmt.Sprintf("error modifying user %s", u.Username))
-					return status, errors.Wrap(err, "user modify")
+				if options.usermod() {
+					err = u.system.ModUser(u.Username, options)
+					if err != nil {
+						status.RaiseLevel(resource.StatusFatal)
+						status.AddMessage(fmt.Sprintf("error modifying user %s", u.Username))
+						return status, errors.Wrap(err, "user modify")
+					}
+					status.AddMessage(fmt.Sprintf("modified user %s", u.Username))
+				}
+				name := u.Username
+				if options.Username != "" {
+					name = options.Username
+				}
+				if err := u.applyAging(status, name, options.Aging); err != nil {
+					return status, err
+				}
+				if err := u.applyHome(status, options); err != nil {
+					return status, err
 				}
-				status.AddMessage(fmt.Sprintf("modified user %s", u.Username))
 			}
 		}
 	case StateAbsent:
@@ -238,7 +481,7 @@ func (u *User) Apply(context.Context) (resource.TaskStatus, error) {
 			return status, errors.Wrapf(err, "will not attempt to delete user %s", u.Username)
 		}
 		if resource.AnyChanges(status.Differences) {
-			err = u.system.DelUser(u.Username)
+			err = u.system.DelUser(u.Username, &DelUserOptions{RemoveAll: u.RemoveAll})
 			if err != nil {
 				status.RaiseLevel(resource.StatusFatal)
 				status.AddMessage(fmt.Sprintf("error deleting user %s", u.Username))
@@ -270,6 +513,9 @@ func (u *User) DiffAdd(status *resource.Status) (*AddUserOptions, error) {
 	}
 	status.AddDifference("username", fmt.Sprintf("<%s>", string(StateAbsent)), u.Username, "")
 
+	options.System = u.System
+	status.AddDifference("account", fmt.Sprintf("<%s>", string(StateAbsent)), u.accountType(), "")
+
 	if u.UID != "" {
 		usr, err := user.LookupId(u.UID)
 		_, uidNotFound := err.(user.UnknownUserIdError)
@@ -284,6 +530,9 @@ func (u *User) DiffAdd(status *resource.Status) (*AddUserOptions, error) {
 	}
 
 	switch {
+	case u.privateGID != "":
+		options.PrivateGID = u.privateGID
+		status.AddDifference("gid", fmt.Sprintf("<%s>", string(StateAbsent)), u.privateGID, "")
 	case u.GroupName != "":
 		grp, err := user.LookupGroup(u.GroupName)
 		if err != nil {
@@ -320,6 +569,8 @@ func (u *User) DiffAdd(status *resource.Status) (*AddUserOptions, error) {
 			options.SkelDir = u.SkelDir
 			status.AddDifference("skel_dir contents", u.SkelDir, dirDiff, "")
 		}
+	} else if u.NoCreateHome {
+		options.NoCreateHome = true
 	}
 
 	if u.HomeDir != "" {
@@ -332,11 +583,60 @@ func (u *User) DiffAdd(status *resource.Status) (*AddUserOptions, error) {
 		status.AddDifference("expiry", "<default expiry>", options.Expiry, "")
 	}
 
+	if u.Shell != "" {
+		options.Shell = u.Shell
+		status.AddDifference("shell", "<default shell>", u.Shell, "")
+	}
+
+	if u.managesAging() {
+		options.Aging = &Aging{MinDays: u.MinDays, MaxDays: u.MaxDays, WarnDays: u.WarnDays}
+		u.diffAging(status, &Aging{MinDays: "<default>", MaxDays: "<default>", WarnDays: "<default>"})
+	}
+
+	if u.Password != "" {
+		options.Password = u.Password
+		status.AddDifference("password_hash", fmt.Sprintf("<%s>", string(StateAbsent)), "<set>", "")
+	}
+
+	if len(u.Groups) > 0 {
+		if err := u.checkGroups(status); err != nil {
+			return nil, err
+		}
+		options.Groups = u.Groups
+		status.AddDifference("groups", fmt.Sprintf("<%s>", string(StateAbsent)), strings.Join(u.Groups, ","), "")
+	}
+
+	if u.Disabled {
+		options.Shell = NologinShell()
+		status.AddDifference("shell", "<default shell>", options.Shell, "")
+	}
+
+	switch {
+	case u.Disabled || u.State == StateLocked:
+		options.Lock = true
+		status.AddDifference("password", fmt.Sprintf("<%s>", string(StateAbsent)), "locked", "")
+	case u.State == StateUnlocked:
+		// a user added without a password starts out locked
+		if u.Password == "" {
+			status.RaiseLevel(resource.StatusCantChange)
+			return nil, fmt.Errorf("user %s cannot be unlocked without a password", u.Username)
+		}
+		status.AddDifference("password", fmt.Sprintf("<%s>", string(StateAbsent)), "unlocked", "")
+	}
+
 	status.RaiseLevelForDiffs()
 
 	return options, nil
 }
 
+// accountType is the kind of account added, shown in the plan
+func (u *User) accountType() string {
+	if u.System {
+		return "system"
+	}
+	return "regular"
+}
+
 // DiffDel checks for differences between the current and desired state for the
 // user to be deleted indicated by the User fields.
 func (u *User) DiffDel(status *resource.Status, userByName *user.User, nameNotFound bool) error {
@@ -363,7 +663,82 @@ func (u *User) DiffDel(status *resource.Status, userByName *user.User, nameNotFo
 		}
 	}
 
+	if u.RemoveAll {
+		if err := u.diffRemoveAll(status, userByName); err != nil {
+			return err
+		}
+	}
+
 	status.RaiseLevelForDiffs()
+	if status.HasChanges() {
+		status.MarkDestructive(fmt.Sprintf("user %s will be deleted", u.Username))
+	}
+
+	return nil
+}
+
+// diffRemoveAll adds differences for the home directory and mail spool that
+// are removed along with the user, when they exist
+func (u *User) diffRemoveAll(status *resource.Status, userByName *user.User) error {
+	if !resource.AnyChanges(status.Differences) {
+		return nil
+	}
+
+	for _, path := range []struct {
+		name string
+		dir  string
+	}{
+		{"home_dir", userByName.HomeDir},
+		{"mail_spool", filepath.Join(MailDir, userByName.Username)},
+	} {
+		if path.dir == "" || path.dir == "/" {
+			continue
+		}
+		existing, err := u.system.LookupHome(path.dir)
+		if err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			return errors.Wrapf(err, "cannot look up %s %s", path.name, path.dir)
+		}
+		if existing.Exists {
+			status.AddDifference(path.name, path.dir, fmt.Sprintf("<%s>", string(StateAbsent)), "")
+		}
+	}
+
+	return nil
+}
+
+// diffIDPolicy checks that the uid and gid the user will have are inside the
+// allowed ranges. A user outside them is not modified: when there are changes
+// to make it fails, adding a difference showing the range, and otherwise the
+// user is left alone with a policy warning.
+func (u *User) diffIDPolicy(status *resource.Status, currUser *user.User) error {
+	for _, id := range []struct {
+		name    string
+		current string
+		desired string
+		policy  *IDRange
+	}{
+		{"uid", currUser.Uid, u.UID, u.UIDPolicy},
+		{"gid", currUser.Gid, u.GID, u.GIDPolicy},
+	} {
+		if id.policy == nil {
+			continue
+		}
+		desired := id.desired
+		if desired == "" {
+			desired = id.current
+		}
+		if !id.policy.Contains(desired) {
+			err := fmt.Errorf("%s %s is outside the allowed range %s", id.name, desired, id.policy)
+			if !resource.AnyChanges(status.Differences) {
+				status.AddWarning(resource.WarningPolicy, err.Error())
+				return nil
+			}
+			status.AddDifference(id.name, id.current, fmt.Sprintf("<%s>", id.policy), "")
+			status.RaiseLevel(resource.StatusCantChange)
+			return err
+		}
+	}
 
 	return nil
 }
@@ -425,9 +800,20 @@ func (u *User) DiffMod(status *resource.Status, currUser *user.User) (*ModUserOp
 	}
 
 	if u.Name != "" {
-		if currUser.Name != u.Name {
+		// the looked up name stops at the first comma of the GECOS field, so
+		// the whole field is read when the desired comment has more than one
+		// part
+		currComment := currUser.Name
+		if strings.Contains(u.Name, ",") {
+			comment, err := u.system.LookupUserComment(u.Username)
+			if err != nil {
+				return nil, fmt.Errorf("could not acquire current comment for %s: %s", u.Username, err)
+			}
+			currComment = comment
+		}
+		if currComment != u.Name {
 			options.Comment = u.Name
-			status.AddDifference("comment", currUser.Name, u.Name, "")
+			status.AddDifference("comment", currComment, u.Name, "")
 		}
 	}
 
@@ -458,11 +844,289 @@ func (u *User) DiffMod(status *resource.Status, currUser *user.User) (*ModUserOp
 		}
 	}
 
+	if u.CreateHome && !options.MoveDir {
+		// moving the home directory creates it at the new location, otherwise
+		// the directory the user will have after the modify must exist
+		home := currUser.HomeDir
+		if options.Directory != "" {
+			home = options.Directory
+		}
+
+		err := u.diffHome(status, options, currUser, home)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if u.Shell != "" {
+		login, err := u.system.LookupUserLogin(u.Username)
+		if err != nil {
+			return nil, fmt.Errorf("could not acquire current login shell for %s: %s", u.Username, err)
+		}
+		if login.Shell != u.Shell {
+			options.Shell = u.Shell
+			status.AddDifference("shell", login.Shell, u.Shell, "")
+		}
+	}
+
+	if len(u.Groups) > 0 {
+		if err := u.diffGroups(status, options); err != nil {
+			return nil, err
+		}
+	}
+
+	if u.managesAging() {
+		current, err := u.system.LookupUserAging(u.Username)
+		if err != nil {
+			return nil, fmt.Errorf("could not acquire current password aging for %s: %s", u.Username, err)
+		}
+		if u.diffAging(status, current) {
+			options.Aging = &Aging{MinDays: u.MinDays, MaxDays: u.MaxDays, WarnDays: u.WarnDays}
+		}
+	}
+
+	if u.Password != "" {
+		current, err := u.system.LookupUserPassword(u.Username)
+		if err != nil {
+			return nil, fmt.Errorf("could not acquire current password for %s: %s", u.Username, err)
+		}
+
+		// a locked password keeps its hash behind a leading "!", and the
+		// hashes themselves are never shown
+		if strings.TrimPrefix(current, "!") != u.Password {
+			options.Password = u.Password
+			status.AddDifference("password_hash", "<hidden>", "<changed>", "")
+		}
+	}
+
+	if u.Disabled {
+		login, err := u.system.LookupUserLogin(u.Username)
+		if err != nil {
+			return nil, fmt.Errorf("could not acquire current login state for %s: %s", u.Username, err)
+		}
+
+		// the shell and the lock are changed in a single modify command, so the
+		// account is never left half disabled
+		if !login.HasNologinShell() {
+			options.Shell = NologinShell()
+			status.AddDifference("shell", login.Shell, options.Shell, "")
+		}
+		if !login.Locked {
+			options.Lock = true
+			status.AddDifference("password", "unlocked", "locked", "")
+		}
+
+		// setting a new password unlocks it, so it is locked again
+		if options.Password != "" {
+			options.Lock = true
+		}
+
+		if login.CanLogin() {
+			status.AddMessage(fmt.Sprintf("user %s can currently log in", u.Username))
+		} else {
+			status.AddMessage(fmt.Sprintf("user %s cannot log in", u.Username))
+		}
+	}
+
+	if u.State == StateLocked || u.State == StateUnlocked {
+		if err := u.diffLock(status, options); err != nil {
+			return nil, err
+		}
+	}
+
+	// the policy is checked once the other differences are known, since a
+	// user outside it is only refused when there is something to change
+	if err := u.diffIDPolicy(status, currUser); err != nil {
+		return nil, err
+	}
+
 	status.RaiseLevelForDiffs()
 
 	return options, nil
 }
 
+// diffLock checks whether the password of an existing user is locked as the
+// state requires, by looking for the lock marker in their shadow entry
+func (u *User) diffLock(status *resource.Status, options *ModUserOptions) error {
+	current, err := u.system.LookupUserPassword(u.Username)
+	if err != nil {
+		return fmt.Errorf("could not acquire current password for %s: %s", u.Username, err)
+	}
+	locked := passwordLocked(current)
+
+	switch u.State {
+	case StateLocked:
+		if !locked {
+			options.Lock = true
+			status.AddDifference("password", "unlocked", "locked", "")
+		}
+
+		// setting a new password unlocks it, so it is locked again
+		if options.Password != "" {
+			options.Lock = true
+		}
+	case StateUnlocked:
+		if !locked {
+			return nil
+		}
+
+		// setting a new password unlocks it without usermod -U
+		if options.Password == "" {
+			if strings.TrimPrefix(current, "!") == "" {
+				status.RaiseLevel(resource.StatusCantChange)
+				return fmt.Errorf("user %s cannot be unlocked without a password", u.Username)
+			}
+			options.Unlock = true
+		}
+		status.AddDifference("password", "locked", "unlocked", "")
+	}
+
+	return nil
+}
+
+// localProvider is the user database LocalOnly looks users up in
+func (u *User) localProvider() Provider {
+	if u.Local != nil {
+		return u.Local
+	}
+	return &PasswdProvider{Path: PasswdFile}
+}
+
+// diffDirectoryUser handles a user that LocalOnly keeps from being managed,
+// because they only come from a directory service. They are already absent
+// locally, and can't be added or modified either, since local tools like
+// useradd and usermod find the directory user and refuse.
+func (u *User) diffDirectoryUser(status *resource.Status) error {
+	if u.State == StateAbsent {
+		status.AddMessage(fmt.Sprintf("user %s only comes from a directory service and is left alone", u.Username))
+		return nil
+	}
+
+	status.RaiseLevel(resource.StatusCantChange)
+	return fmt.Errorf("user %s comes from a directory service and cannot be managed locally", u.Username)
+}
+
+// passwordLocked is true when a password hash from the shadow file carries
+// the lock marker
+func passwordLocked(hash string) bool {
+	return strings.HasPrefix(hash, "!")
+}
+
+// checkGroups makes sure every supplementary group exists
+func (u *User) checkGroups(status *resource.Status) error {
+	for _, name := range u.Groups {
+		if _, err := u.system.LookupGroup(name); err != nil {
+			status.RaiseLevel(resource.StatusCantChange)
+			return fmt.Errorf("group %s does not exist", name)
+		}
+	}
+	return nil
+}
+
+// diffGroups compares the supplementary groups of an existing user with
+// Groups. Missing groups are added, and groups that aren't in Groups are
+// removed unless Append is set. The options to change the groups are set.
+func (u *User) diffGroups(status *resource.Status, options *ModUserOptions) error {
+	if err := u.checkGroups(status); err != nil {
+		return err
+	}
+
+	current, err := u.system.LookupUserGroups(u.Username)
+	if err != nil {
+		return fmt.Errorf("could not acquire current groups for %s: %s", u.Username, err)
+	}
+
+	isCurrent := make(map[string]bool, len(current))
+	for _, name := range current {
+		isCurrent[name] = true
+	}
+	isDesired := make(map[string]bool, len(u.Groups))
+	for _, name := range u.Groups {
+		isDesired[name] = true
+	}
+
+	var missing, extra []string
+	for _, name := range u.Groups {
+		if !isCurrent[name] {
+			missing = append(missing, name)
+		}
+	}
+	if !u.Append {
+		for _, name := range current {
+			if !isDesired[name] {
+				extra = append(extra, name)
+			}
+		}
+	}
+
+	if len(missing) == 0 && len(extra) == 0 {
+		return nil
+	}
+
+	var desired []string
+	if u.Append {
+		options.Groups = missing
+		options.AppendGroups = true
+		desired = append(append(desired, current...), missing...)
+	} else {
+		options.Groups = u.Groups
+		desired = append(desired, u.Groups...)
+	}
+
+	sort.Strings(current)
+	sort.Strings(desired)
+	status.AddDifference("groups", strings.Join(current, ","), strings.Join(desired, ","), "")
+
+	return nil
+}
+
+// managesAging is true when any password aging field is set
+func (u *User) managesAging() bool {
+	return u.MinDays != "" || u.MaxDays != "" || u.WarnDays != ""
+}
+
+// diffAging adds a difference for each managed password aging field that
+// differs from the current aging, and reports whether there were any
+func (u *User) diffAging(status *resource.Status, current *Aging) bool {
+	fields := []struct {
+		name             string
+		current, desired string
+	}{
+		{"min_days", current.MinDays, u.MinDays},
+		{"max_days", current.MaxDays, u.MaxDays},
+		{"warn_days", current.WarnDays, u.WarnDays},
+	}
+
+	var changed bool
+	for _, field := range fields {
+		if field.desired == "" || field.desired == field.current {
+			continue
+		}
+		original := field.current
+		if original == "" {
+			original = "<none>"
+		}
+		status.AddDifference(field.name, original, field.desired, "")
+		changed = true
+	}
+	return changed
+}
+
+// applyAging sets the password aging of the user, if there are changes
+func (u *User) applyAging(status *resource.Status, userName string, aging *Aging) error {
+	if aging == nil {
+		return nil
+	}
+
+	if err := u.system.SetUserAging(userName, aging); err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		status.AddMessage(fmt.Sprintf("error setting password aging for user %s", userName))
+		return errors.Wrap(err, "user aging")
+	}
+	status.AddMessage(fmt.Sprintf("set password aging for user %s", userName))
+	return nil
+}
+
 // createHomeDiffs calls AddDifference for create_home and skel_dir after
 // adding a user. The actual value of home_dir is accessed so the differences
 // can be updated to no longer show <default home>.
@@ -476,3 +1140,126 @@ func (u *User) createHomeDiffs(status *resource.Status) {
 		}
 	}
 }
+
+// diffHome checks that the home directory of an existing user exists and is
+// owned by the user. The options to create it or correct its owner are set.
+func (u *User) diffHome(status *resource.Status, options *ModUserOptions, currUser *user.User, home string) error {
+	if home == "" {
+		status.RaiseLevel(resource.StatusCantChange)
+		return fmt.Errorf("user %s has no home directory", u.Username)
+	}
+
+	stat, err := u.system.LookupHome(home)
+	if err != nil {
+		return fmt.Errorf("could not acquire home directory %s: %s", home, err)
+	}
+
+	switch {
+	case !stat.Exists:
+		options.HomeDir = home
+		options.CreateHome = true
+		options.SkelDir = u.SkelDir
+		status.AddDifference("create_home", fmt.Sprintf("<%s>", string(StateAbsent)), home, "")
+		if u.SkelDir != "" {
+			status.AddDifference("skel_dir contents", u.SkelDir, home, "")
+		}
+	case !stat.IsDir:
+		status.RaiseLevel(resource.StatusCantChange)
+		return fmt.Errorf("home directory %s is not a directory", home)
+	case stat.UID != currUser.Uid || stat.GID != currUser.Gid:
+		options.HomeDir = home
+		options.ChownHome = true
+		status.AddDifference(
+			"home_dir owner",
+			fmt.Sprintf("%s:%s", stat.UID, stat.GID),
+			fmt.Sprintf("%s:%s", currUser.Uid, currUser.Gid),
+			"",
+		)
+	}
+
+	return nil
+}
+
+// applyHome creates the home directory of an existing user or corrects its
+// owner. The user is looked up again since the modify may have changed their
+// name, uid or group.
+func (u *User) applyHome(status *resource.Status, options *ModUserOptions) error {
+	if !options.CreateHome && !options.ChownHome && !options.MoveDir {
+		return nil
+	}
+
+	name := u.Username
+	if options.Username != "" {
+		name = options.Username
+	}
+
+	usr, err := u.system.Lookup(name)
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return errors.Wrapf(err, "could not look up user %s", name)
+	}
+
+	switch {
+	case options.CreateHome:
+		if err := u.system.CreateHome(options.HomeDir, options.SkelDir, usr.Uid, usr.Gid); err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			status.AddMessage(fmt.Sprintf("error creating home directory %s", options.HomeDir))
+			return errors.Wrap(err, "create home")
+		}
+		status.AddMessage(fmt.Sprintf("created home directory %s", options.HomeDir))
+	case options.ChownHome:
+		if err := u.system.ChownHome(options.HomeDir, usr.Uid, usr.Gid); err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			status.AddMessage(fmt.Sprintf("error changing owner of home directory %s", options.HomeDir))
+			return errors.Wrap(err, "chown home")
+		}
+		status.AddMessage(fmt.Sprintf("changed owner of home directory %s", options.HomeDir))
+	case options.MoveDir:
+		// usermod moves the contents as they are, which may include files
+		// that belonged to someone else or to the user's old uid
+		if err := u.system.ChownHomeTree(options.Directory, usr.Uid, usr.Gid); err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			status.AddMessage(fmt.Sprintf("error changing owner of moved home directory %s", options.Directory))
+			return errors.Wrap(err, "chown home")
+		}
+		status.AddMessage(fmt.Sprintf("changed owner of moved home directory %s", options.Directory))
+	}
+
+	return nil
+}
+
+// allocateIDs picks the uid and gid for the user from the configured ranges.
+// An existing user keeps their ids, which are exported so dependent resources
+// can use them. A new user gets the lowest free ids in the ranges.
+func (u *User) allocateIDs(userByName *user.User, nameNotFound bool) error {
+	u.privateGID = ""
+
+	if !nameNotFound && userByName != nil {
+		if u.UIDRange != nil {
+			u.UID = userByName.Uid
+		}
+		if u.GIDRange != nil {
+			u.GID = userByName.Gid
+		}
+		return nil
+	}
+
+	if u.UIDRange != nil {
+		uid, err := u.UIDRange.nextUID(u.system)
+		if err != nil {
+			return errors.Wrap(err, "could not allocate uid")
+		}
+		u.UID = uid
+	}
+
+	if u.GIDRange != nil {
+		gid, err := u.GIDRange.nextGID(u.system)
+		if err != nil {
+			return errors.Wrap(err, "could not allocate gid")
+		}
+		u.GID = ""
+		u.privateGID = gid
+	}
+
+	return nil
+}
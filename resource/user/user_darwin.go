@@ -0,0 +1,534 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build darwin
+
+package user
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultSkelDir is the template new home directories are created from
+const defaultSkelDir = "/System/Library/User Template/English.lproj"
+
+const (
+	// defaultShell and defaultGID are used when a user is added without a
+	// shell or group, like System Preferences does
+	defaultShell = "/bin/zsh"
+	defaultGID   = "20"
+
+	// uids are picked from these ranges when a user is added without one
+	minUID, maxUID             = 501, 60000
+	minSystemUID, maxSystemUID = 200, 400
+
+	// disabledAuthority marks a user whose password is locked
+	disabledAuthority = ";DisabledUser;"
+
+	// shadowHashAuthority marks a user who has a password
+	shadowHashAuthority = ";ShadowHash;"
+
+	// homesDir holds the home directories that are removed with their users
+	homesDir = "/Users"
+)
+
+// errPasswordHash is returned when a password hash would be set. The local
+// directory keeps passwords in its own format, so crypt hashes can't be used.
+var errPasswordHash = errors.New("setting a password hash is not supported on darwin")
+
+// errExpiry is returned when an account expiry would be set
+var errExpiry = errors.New("setting an account expiry is not supported on darwin")
+
+// errAging is returned when password aging would be looked up or set
+var errAging = errors.New("password aging is not supported on darwin")
+
+// AddUser adds a user by creating its record in the local directory
+func (s *System) AddUser(userName string, options *AddUserOptions) error {
+	if options.Password != "" {
+		return errPasswordHash
+	}
+	if options.Expiry != "" {
+		return errExpiry
+	}
+
+	uid := options.UID
+	if uid == "" {
+		list, err := dscl("-list", "/Users", "UniqueID")
+		if err != nil {
+			return err
+		}
+
+		min, max := minUID, maxUID
+		if options.System {
+			min, max = minSystemUID, maxSystemUID
+		}
+		uid, err = nextFreeID(list, min, max)
+		if err != nil {
+			return err
+		}
+	}
+
+	gid := defaultGID
+	switch {
+	case options.PrivateGID != "":
+		cmd := exec.Command("dseditgroup", "-o", "create", "-i", options.PrivateGID, userName)
+		if err := cmd.Run(); err != nil {
+			return errors.Wrap(err, "dseditgroup")
+		}
+		gid = options.PrivateGID
+	case options.Group != "":
+		var err error
+		gid, err = groupID(options.Group)
+		if err != nil {
+			return err
+		}
+	}
+
+	home := options.Directory
+	if home == "" {
+		home = "/Users/" + userName
+		if options.System {
+			home = "/var/empty"
+		}
+	}
+
+	shell := options.Shell
+	if shell == "" {
+		shell = defaultShell
+	}
+
+	record := "/Users/" + userName
+	attrs := [][]string{
+		{"UniqueID", uid},
+		{"PrimaryGroupID", gid},
+		{"UserShell", shell},
+		{"NFSHomeDirectory", home},
+	}
+	if options.Comment != "" {
+		attrs = append(attrs, []string{"RealName", options.Comment})
+	}
+	if options.System {
+		attrs = append(attrs, []string{"IsHidden", "1"})
+	}
+
+	if _, err := dscl("-create", record); err != nil {
+		return err
+	}
+	for _, attr := range attrs {
+		if _, err := dscl("-create", record, attr[0], attr[1]); err != nil {
+			return err
+		}
+	}
+
+	if options.Lock {
+		if _, err := dscl("-append", record, "AuthenticationAuthority", disabledAuthority); err != nil {
+			return err
+		}
+	}
+
+	for _, group := range options.Groups {
+		if err := editGroup(group, "-a", userName); err != nil {
+			return err
+		}
+	}
+
+	if options.CreateHome {
+		return s.CreateHome(home, options.SkelDir, uid, gid)
+	}
+	return nil
+}
+
+// DelUser deletes a user. Like userdel, the home directory is left alone
+// unless RemoveAll is set, in which case it is removed with the mail spool
+// after the user record is deleted.
+func (s *System) DelUser(userName string, options *DelUserOptions) error {
+	var home string
+	if options.RemoveAll {
+		usr, err := user.Lookup(userName)
+		if err != nil {
+			return errors.Wrap(err, "user lookup")
+		}
+		home = usr.HomeDir
+		if home != "" && !removableHome(home) {
+			return fmt.Errorf("not removing the home directory %s of %s, which is not in %s", home, userName, homesDir)
+		}
+	}
+
+	if _, err := dscl("-delete", "/Users/"+userName); err != nil {
+		return err
+	}
+
+	if !options.RemoveAll {
+		return nil
+	}
+	if home != "" {
+		if err := os.RemoveAll(home); err != nil {
+			return errors.Wrap(err, "remove home directory")
+		}
+	}
+	if err := os.Remove(filepath.Join(MailDir, userName)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "remove mail spool")
+	}
+	return nil
+}
+
+// removableHome is true if a home directory can be removed with its user.
+// Only a user's own directory in /Users can be, so removing a system account
+// whose home is shared, like /var/empty, leaves it alone.
+func removableHome(home string) bool {
+	rel, err := filepath.Rel(homesDir, filepath.Clean(home))
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != "Shared" && !strings.HasPrefix(rel, "..") && !strings.Contains(rel, string(filepath.Separator))
+}
+
+// ModUser modifies a user. The user is renamed first, so the other changes are
+// made to the renamed record.
+func (s *System) ModUser(userName string, options *ModUserOptions) error {
+	if options.Password != "" {
+		return errPasswordHash
+	}
+	if options.Expiry != "" {
+		return errExpiry
+	}
+
+	record := "/Users/" + userName
+	if options.Username != "" {
+		if _, err := dscl("-change", record, "RecordName", userName, options.Username); err != nil {
+			return err
+		}
+		userName = options.Username
+		record = "/Users/" + userName
+	}
+
+	var attrs [][]string
+	if options.UID != "" {
+		attrs = append(attrs, []string{"UniqueID", options.UID})
+	}
+	if options.Group != "" {
+		gid, err := groupID(options.Group)
+		if err != nil {
+			return err
+		}
+		attrs = append(attrs, []string{"PrimaryGroupID", gid})
+	}
+	if options.Comment != "" {
+		attrs = append(attrs, []string{"RealName", options.Comment})
+	}
+	if options.Directory != "" {
+		if options.MoveDir {
+			if err := moveHome(record, options.Directory); err != nil {
+				return err
+			}
+		}
+		attrs = append(attrs, []string{"NFSHomeDirectory", options.Directory})
+	}
+	if options.Shell != "" {
+		attrs = append(attrs, []string{"UserShell", options.Shell})
+	}
+
+	for _, attr := range attrs {
+		if _, err := dscl("-create", record, attr[0], attr[1]); err != nil {
+			return err
+		}
+	}
+
+	if len(options.Groups) > 0 {
+		if err := s.setGroups(userName, options.Groups, options.AppendGroups); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case options.Lock:
+		_, err := dscl("-append", record, "AuthenticationAuthority", disabledAuthority)
+		return err
+	case options.Unlock:
+		_, err := dscl("-delete", record, "AuthenticationAuthority", disabledAuthority)
+		return err
+	}
+	return nil
+}
+
+// LookupUserExpiry looks up a user's expiry. Accounts in the local directory
+// don't expire, so the expiry is always the max time.
+func (s *System) LookupUserExpiry(userName string) (time.Time, error) {
+	zone := time.FixedZone(time.Now().In(time.Local).Zone())
+	return time.ParseInLocation(ShortForm, MaxTime, zone)
+}
+
+// LookupUserLogin looks up a user's login shell and whether their password is
+// locked
+func (s *System) LookupUserLogin(userName string) (*Login, error) {
+	attrs, err := readUser(userName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Login{
+		Shell:  attrs["UserShell"],
+		Locked: strings.Contains(attrs["AuthenticationAuthority"], disabledAuthority),
+	}, nil
+}
+
+// LookupUserShell looks up a user's login shell
+func (s *System) LookupUserShell(userName string) (string, error) {
+	attrs, err := readUser(userName)
+	if err != nil {
+		return "", err
+	}
+
+	return attrs["UserShell"], nil
+}
+
+// LookupUserComment looks up the full name of a user
+func (s *System) LookupUserComment(userName string) (string, error) {
+	attrs, err := readUser(userName)
+	if err != nil {
+		return "", err
+	}
+
+	return attrs["RealName"], nil
+}
+
+// LookupUserPassword looks up the password of a user in the form of a shadow
+// entry. The hash itself can't be read, so a password is shown as "*", behind
+// the lock marker "!" if it's locked.
+func (s *System) LookupUserPassword(userName string) (string, error) {
+	attrs, err := readUser(userName)
+	if err != nil {
+		return "", err
+	}
+
+	var password string
+	authority := attrs["AuthenticationAuthority"]
+	if strings.Contains(authority, shadowHashAuthority) {
+		password = "*"
+	}
+	if strings.Contains(authority, disabledAuthority) {
+		password = "!" + password
+	}
+	return password, nil
+}
+
+// LookupUserGroups looks up the supplementary groups of a user in the local
+// directory
+func (s *System) LookupUserGroups(userName string) ([]string, error) {
+	list, err := dscl("-list", "/Groups", "GroupMembership")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseForMembership(list, userName), nil
+}
+
+// LookupUserAging is not supported on darwin
+func (s *System) LookupUserAging(userName string) (*Aging, error) {
+	return nil, errAging
+}
+
+// SetUserAging is not supported on darwin
+func (s *System) SetUserAging(userName string, aging *Aging) error {
+	return errAging
+}
+
+// NologinShell returns the path of the shell used for users who may not log
+// in
+func NologinShell() string {
+	return "/usr/bin/false"
+}
+
+// setGroups sets the supplementary groups of a user, or adds the user to them
+// when add is set
+func (s *System) setGroups(userName string, groups []string, add bool) error {
+	desired := map[string]bool{}
+	for _, group := range groups {
+		desired[group] = true
+	}
+
+	current := map[string]bool{}
+	if !add {
+		names, err := s.LookupUserGroups(userName)
+		if err != nil {
+			return err
+		}
+		for _, group := range names {
+			current[group] = true
+			if !desired[group] {
+				if err := editGroup(group, "-d", userName); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, group := range groups {
+		if current[group] {
+			continue
+		}
+		if err := editGroup(group, "-a", userName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveHome moves the contents of the user's current home directory to dir,
+// like usermod -m
+func moveHome(record, dir string) error {
+	attrs, err := dscl("-read", record)
+	if err != nil {
+		return err
+	}
+
+	current := parseRecord(attrs)["NFSHomeDirectory"]
+	if current == "" || current == dir {
+		return nil
+	}
+	if _, err := os.Stat(current); os.IsNotExist(err) {
+		return nil
+	}
+
+	return errors.Wrap(os.Rename(current, dir), "move home")
+}
+
+// editGroup adds ("-a") or removes ("-d") a user from a group
+func editGroup(group, op, userName string) error {
+	cmd := exec.Command("dseditgroup", "-o", "edit", op, userName, "-t", "user", group)
+	if err := cmd.Run(); err != nil {
+		return errors.Wrap(err, "dseditgroup")
+	}
+	return nil
+}
+
+// groupID returns the gid of a group given by name or gid
+func groupID(group string) (string, error) {
+	if _, err := strconv.Atoi(group); err == nil {
+		return group, nil
+	}
+
+	grp, err := user.LookupGroup(group)
+	if err != nil {
+		return "", err
+	}
+	return grp.Gid, nil
+}
+
+// readUser reads the attributes of a user's record in the local directory
+func readUser(userName string) (map[string]string, error) {
+	out, err := dscl("-read", "/Users/"+userName)
+	if err != nil {
+		return nil, err
+	}
+	return parseRecord(out), nil
+}
+
+// dscl runs dscl against the local directory and returns what it printed
+func dscl(args ...string) (string, error) {
+	var out bytes.Buffer
+
+	cmd := exec.Command("dscl", append([]string{"."}, args...)...)
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrap(err, "dscl")
+	}
+	return out.String(), nil
+}
+
+// parseRecord reads the attributes of a record, as printed by
+// `dscl . -read <record>`. Values are printed after the attribute name, or on
+// the following indented lines when they contain spaces.
+func parseRecord(data string) map[string]string {
+	attrs := map[string]string{}
+
+	var key string
+	for _, line := range strings.Split(data, "\n") {
+		if strings.HasPrefix(line, " ") {
+			if key != "" {
+				value := strings.TrimSpace(line)
+				if attrs[key] != "" {
+					value = attrs[key] + "\n" + value
+				}
+				attrs[key] = value
+			}
+			continue
+		}
+
+		switch idx := strings.Index(line, ": "); {
+		case idx >= 0:
+			key = line[:idx]
+			attrs[key] = strings.TrimSpace(line[idx+2:])
+		case strings.HasSuffix(line, ":"):
+			key = strings.TrimSuffix(line, ":")
+			attrs[key] = ""
+		default:
+			key = ""
+		}
+	}
+	return attrs
+}
+
+// parseForMembership finds the groups a user is a member of, from the output
+// of `dscl . -list /Groups GroupMembership`, which prints a line for every
+// group with its name followed by its members
+func parseForMembership(data, userName string) []string {
+	var groups []string
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		for _, member := range fields[1:] {
+			if member == userName {
+				groups = append(groups, fields[0])
+				break
+			}
+		}
+	}
+	return groups
+}
+
+// nextFreeID picks the lowest id from min to max that isn't used in the output
+// of `dscl . -list <path> <id attribute>`
+func nextFreeID(data string, min, max int) (string, error) {
+	used := map[int]bool{}
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if id, err := strconv.Atoi(fields[1]); err == nil {
+			used[id] = true
+		}
+	}
+
+	for id := min; id <= max; id++ {
+		if !used[id] {
+			return strconv.Itoa(id), nil
+		}
+	}
+	return "", fmt.Errorf("no free id from %d to %d", min, max)
+}
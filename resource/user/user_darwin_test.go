@@ -0,0 +1,83 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecord(t *testing.T) {
+	t.Parallel()
+
+	record := "AuthenticationAuthority: ;ShadowHash;HASHLIST:<SALTED-SHA512-PBKDF2> ;DisabledUser;\n" +
+		"NFSHomeDirectory: /Users/test\n" +
+		"RealName:\n Test User\n" +
+		"dsAttrTypeNative:IsHidden: 1\n" +
+		"UserShell: /bin/zsh\n"
+
+	assert.Equal(t, map[string]string{
+		"AuthenticationAuthority":   ";ShadowHash;HASHLIST:<SALTED-SHA512-PBKDF2> ;DisabledUser;",
+		"NFSHomeDirectory":          "/Users/test",
+		"RealName":                  "Test User",
+		"dsAttrTypeNative:IsHidden": "1",
+		"UserShell":                 "/bin/zsh",
+	}, parseRecord(record))
+}
+
+func TestParseForMembership(t *testing.T) {
+	t.Parallel()
+
+	list := "admin                    root test\nstaff                    root\nwheel\ndevelopers               test\n"
+
+	assert.Equal(t, []string{"admin", "developers"}, parseForMembership(list, "test"))
+	assert.Empty(t, parseForMembership(list, "other"))
+}
+
+func TestRemovableHome(t *testing.T) {
+	t.Parallel()
+
+	for home, removable := range map[string]bool{
+		"/Users/test":         true,
+		"/Users/test/":        true,
+		"/Users":              false,
+		"/Users/Shared":       false,
+		"/Users/test/nested":  false,
+		"/Users/../var/empty": false,
+		"/var/empty":          false,
+		"/":                   false,
+	} {
+		assert.Equal(t, removable, removableHome(home), home)
+	}
+}
+
+func TestNextFreeID(t *testing.T) {
+	t.Parallel()
+
+	list := "_www                     70\nroot                     0\ntest                     501\n"
+
+	id, err := nextFreeID(list, minUID, maxUID)
+	require.NoError(t, err)
+	assert.Equal(t, "502", id)
+
+	id, err = nextFreeID(list, minSystemUID, maxSystemUID)
+	require.NoError(t, err)
+	assert.Equal(t, "200", id)
+
+	_, err = nextFreeID(list, 501, 501)
+	assert.EqualError(t, err, "no free id from 501 to 501")
+}
@@ -12,7 +12,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// +build !linux
+// +build !linux,!darwin,!freebsd
 
 package user
 
@@ -30,7 +30,7 @@ func (s *System) AddUser(userName string, options *AddUserOptions) error {
 }
 
 // DelUser implementation for systems which are not supported
-func (s *System) DelUser(userName string) error {
+func (s *System) DelUser(userName string, options *DelUserOptions) error {
 	return ErrUnsupported
 }
 
@@ -44,6 +44,67 @@ func (s *System) LookupUserExpiry(userName string) (time.Time, error) {
 	return time.Time{}, ErrUnsupported
 }
 
+// LookupUserLogin implementation for systems which are not supported
+func (s *System) LookupUserLogin(userName string) (*Login, error) {
+	return nil, ErrUnsupported
+}
+
+// LookupUserShell implementation for systems which are not supported
+func (s *System) LookupUserShell(userName string) (string, error) {
+	return "", ErrUnsupported
+}
+
+// LookupUserComment implementation for systems which are not supported
+func (s *System) LookupUserComment(userName string) (string, error) {
+	return "", ErrUnsupported
+}
+
+// LookupUserPassword implementation for systems which are not supported
+func (s *System) LookupUserPassword(userName string) (string, error) {
+	return "", ErrUnsupported
+}
+
+// LookupUserGroups implementation for systems which are not supported
+func (s *System) LookupUserGroups(userName string) ([]string, error) {
+	return nil, ErrUnsupported
+}
+
+// LookupUserAging implementation for systems which are not supported
+func (s *System) LookupUserAging(userName string) (*Aging, error) {
+	return nil, ErrUnsupported
+}
+
+// SetUserAging implementation for systems which are not supported
+func (s *System) SetUserAging(userName string, aging *Aging) error {
+	return ErrUnsupported
+}
+
+// LookupHome implementation for systems which are not supported
+func (s *System) LookupHome(dir string) (*Home, error) {
+	return nil, ErrUnsupported
+}
+
+// CreateHome implementation for systems which are not supported
+func (s *System) CreateHome(dir, skelDir, uid, gid string) error {
+	return ErrUnsupported
+}
+
+// ChownHome implementation for systems which are not supported
+func (s *System) ChownHome(dir, uid, gid string) error {
+	return ErrUnsupported
+}
+
+// ChownHomeTree implementation for systems which are not supported
+func (s *System) ChownHomeTree(dir, uid, gid string) error {
+	return ErrUnsupported
+}
+
+// NologinShell returns the path of the shell used for users who may not log
+// in
+func NologinShell() string {
+	return "/sbin/nologin"
+}
+
 // Lookup implementation for systems which are not supported
 func (s *System) Lookup(userName string) (*user.User, error) {
 	return nil, ErrUnsupported
@@ -0,0 +1,87 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build freebsd
+
+package user
+
+import "time"
+
+// defaultSkelDir is the skeleton directory pw uses by default
+const defaultSkelDir = "/usr/share/skel"
+
+// pw is the backend users are managed with on freebsd
+var pw = &pwSystem{run: execRunner{}}
+
+// AddUser adds a user
+func (s *System) AddUser(userName string, options *AddUserOptions) error {
+	return pw.AddUser(userName, options)
+}
+
+// DelUser deletes a user
+func (s *System) DelUser(userName string, options *DelUserOptions) error {
+	return pw.DelUser(userName, options)
+}
+
+// ModUser modifies a user
+func (s *System) ModUser(userName string, options *ModUserOptions) error {
+	return pw.ModUser(userName, options)
+}
+
+// LookupUserExpiry looks up a user's expiry
+func (s *System) LookupUserExpiry(userName string) (time.Time, error) {
+	return pw.LookupUserExpiry(userName)
+}
+
+// LookupUserLogin looks up a user's login shell and whether their password is
+// locked
+func (s *System) LookupUserLogin(userName string) (*Login, error) {
+	return pw.LookupUserLogin(userName)
+}
+
+// LookupUserShell looks up a user's login shell
+func (s *System) LookupUserShell(userName string) (string, error) {
+	return pw.LookupUserShell(userName)
+}
+
+// LookupUserComment looks up the whole GECOS field of a user
+func (s *System) LookupUserComment(userName string) (string, error) {
+	return pw.LookupUserComment(userName)
+}
+
+// LookupUserPassword looks up the hashed password of a user
+func (s *System) LookupUserPassword(userName string) (string, error) {
+	return pw.LookupUserPassword(userName)
+}
+
+// LookupUserGroups looks up the supplementary groups of a user
+func (s *System) LookupUserGroups(userName string) ([]string, error) {
+	return pw.LookupUserGroups(userName)
+}
+
+// LookupUserAging is not supported on freebsd
+func (s *System) LookupUserAging(userName string) (*Aging, error) {
+	return pw.LookupUserAging(userName)
+}
+
+// SetUserAging is not supported on freebsd
+func (s *System) SetUserAging(userName string, aging *Aging) error {
+	return pw.SetUserAging(userName, aging)
+}
+
+// NologinShell returns the path of the shell used for users who may not log
+// in
+func NologinShell() string {
+	return "/usr/sbin/nologin"
+}
@@ -19,18 +19,21 @@ package user
 import (
 	"bytes"
 	"github.com/pkg/errors"
+	"os"
 	"os/exec"
-	"os/user"
 	"strings"
 	"time"
 )
 
-// System implements SystemUtils
-type System struct{}
+// defaultSkelDir is the skeleton directory useradd uses by default
+const defaultSkelDir = "/etc/skel"
 
 // AddUser adds a user
 func (s *System) AddUser(userName string, options *AddUserOptions) error {
 	args := []string{userName}
+	if options.System {
+		args = append(args, "-r")
+	}
 	if options.UID != "" {
 		args = append(args, "-u", options.UID)
 	}
@@ -45,6 +48,8 @@ func (s *System) AddUser(userName string, options *AddUserOptions) error {
 		if options.SkelDir != "" {
 			args = append(args, "-k", options.SkelDir)
 		}
+	} else if options.NoCreateHome {
+		args = append(args, "-M")
 	}
 	if options.Directory != "" {
 		args = append(args, "-d", options.Directory)
@@ -52,6 +57,29 @@ func (s *System) AddUser(userName string, options *AddUserOptions) error {
 	if options.Expiry != "" {
 		args = append(args, "-e", options.Expiry)
 	}
+	if options.Shell != "" {
+		args = append(args, "-s", options.Shell)
+	}
+	if options.Password != "" || options.Lock {
+		args = append(args, "-p", passwordArg(options.Password, options.Lock))
+	}
+	if len(options.Groups) > 0 {
+		args = append(args, "-G", strings.Join(options.Groups, ","))
+	}
+	if options.PrivateGID != "" {
+		// useradd picks the gid of the user's own group from GID_MIN to
+		// GID_MAX, or SYS_GID_MIN to SYS_GID_MAX for system accounts, so
+		// narrowing them to one id picks exactly that id
+		prefix := ""
+		if options.System {
+			prefix = "SYS_"
+		}
+		args = append(args,
+			"-U",
+			"-K", prefix+"GID_MIN="+options.PrivateGID,
+			"-K", prefix+"GID_MAX="+options.PrivateGID,
+		)
+	}
 
 	cmd := exec.Command("useradd", args...)
 	err := cmd.Run()
@@ -62,8 +90,13 @@ func (s *System) AddUser(userName string, options *AddUserOptions) error {
 }
 
 // DelUser deletes a user
-func (s *System) DelUser(userName string) error {
-	cmd := exec.Command("userdel", userName)
+func (s *System) DelUser(userName string, options *DelUserOptions) error {
+	var args []string
+	if options.RemoveAll {
+		args = append(args, "-r")
+	}
+	args = append(args, userName)
+	cmd := exec.Command("userdel", args...)
 	err := cmd.Run()
 	if err != nil {
 		return errors.Wrap(err, "userdel")
@@ -95,6 +128,24 @@ func (s *System) ModUser(userName string, options *ModUserOptions) error {
 	if options.Expiry != "" {
 		args = append(args, "-e", options.Expiry)
 	}
+	if options.Shell != "" {
+		args = append(args, "-s", options.Shell)
+	}
+	if len(options.Groups) > 0 {
+		args = append(args, "-G", strings.Join(options.Groups, ","))
+		if options.AppendGroups {
+			args = append(args, "-a")
+		}
+	}
+	switch {
+	case options.Password != "":
+		// usermod cannot set and lock a password at once
+		args = append(args, "-p", passwordArg(options.Password, options.Lock))
+	case options.Lock:
+		args = append(args, "-L")
+	case options.Unlock:
+		args = append(args, "-U")
+	}
 
 	cmd := exec.Command("usermod", args...)
 	err := cmd.Run()
@@ -124,28 +175,133 @@ func (s *System) LookupUserExpiry(userName string) (time.Time, error) {
 	return expiry, nil
 }
 
-// Lookup looks up a user by name
-// If the user cannot be found an error is returned
-func (s *System) Lookup(userName string) (*user.User, error) {
-	return user.Lookup(userName)
+// LookupUserLogin looks up a user's login shell and whether their password is
+// locked
+func (s *System) LookupUserLogin(userName string) (*Login, error) {
+	var passwd, status bytes.Buffer
+
+	cmd := exec.Command("getent", "passwd", userName)
+	cmd.Stdout = &passwd
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrap(err, "getent")
+	}
+
+	cmd = exec.Command("passwd", "-S", userName)
+	cmd.Stdout = &status
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrap(err, "passwd")
+	}
+
+	shell, err := parseForShell(passwd.String())
+	if err != nil {
+		return nil, err
+	}
+
+	locked, err := parseForLocked(status.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Login{Shell: shell, Locked: locked}, nil
+}
+
+// LookupUserShell looks up a user's login shell. Unlike LookupUserLogin, it
+// only reads the passwd entry, so it doesn't need to run as root.
+func (s *System) LookupUserShell(userName string) (string, error) {
+	var passwd bytes.Buffer
+
+	cmd := exec.Command("getent", "passwd", userName)
+	cmd.Stdout = &passwd
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrap(err, "getent")
+	}
+
+	return parseForShell(passwd.String())
+}
+
+// LookupUserComment looks up the whole GECOS field of a user's passwd entry
+func (s *System) LookupUserComment(userName string) (string, error) {
+	var passwd bytes.Buffer
+
+	cmd := exec.Command("getent", "passwd", userName)
+	cmd.Stdout = &passwd
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrap(err, "getent")
+	}
+
+	return parseForComment(passwd.String())
+}
+
+// LookupUserPassword looks up the hashed password of a user
+func (s *System) LookupUserPassword(userName string) (string, error) {
+	var shadow bytes.Buffer
+
+	cmd := exec.Command("getent", "shadow", userName)
+	cmd.Stdout = &shadow
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrap(err, "getent")
+	}
+
+	return parseForPassword(shadow.String())
 }
 
-// LookupID looks up a user by uid
-// If the user cannot be found an error is returned
-func (s *System) LookupID(userID string) (*user.User, error) {
-	return user.LookupId(userID)
+// LookupUserAging looks up the password aging of a user
+func (s *System) LookupUserAging(userName string) (*Aging, error) {
+	var shadow bytes.Buffer
+
+	cmd := exec.Command("getent", "shadow", userName)
+	cmd.Stdout = &shadow
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrap(err, "getent")
+	}
+
+	return parseForAging(shadow.String())
+}
+
+// SetUserAging sets the password aging of a user
+func (s *System) SetUserAging(userName string, aging *Aging) error {
+	var args []string
+	if aging.MinDays != "" {
+		args = append(args, "-m", aging.MinDays)
+	}
+	if aging.MaxDays != "" {
+		args = append(args, "-M", aging.MaxDays)
+	}
+	if aging.WarnDays != "" {
+		args = append(args, "-W", aging.WarnDays)
+	}
+	args = append(args, userName)
+
+	cmd := exec.Command("chage", args...)
+	if err := cmd.Run(); err != nil {
+		return errors.Wrap(err, "chage")
+	}
+	return nil
 }
 
-// LookupGroup looks up a group by name
-// If the group cannot be found an error is returned
-func (s *System) LookupGroup(groupName string) (*user.Group, error) {
-	return user.LookupGroup(groupName)
+// LookupUserGroups looks up the supplementary groups of a user in the group
+// database
+func (s *System) LookupUserGroups(userName string) ([]string, error) {
+	var groups bytes.Buffer
+
+	cmd := exec.Command("getent", "group")
+	cmd.Stdout = &groups
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrap(err, "getent")
+	}
+
+	return parseForGroups(groups.String(), userName)
 }
 
-// LookupGroupID looks up a group by gid
-// If the group cannot be found an error is returned
-func (s *System) LookupGroupID(groupID string) (*user.Group, error) {
-	return user.LookupGroupId(groupID)
+// NologinShell returns the path of the shell used for users who may not log
+// in
+func NologinShell() string {
+	for _, shell := range []string{"/usr/sbin/nologin", "/sbin/nologin"} {
+		if _, err := os.Stat(shell); err == nil {
+			return shell
+		}
+	}
+	return "/bin/false"
 }
 
 // parseForExpiry takes a string and extracts the account expiration date and
@@ -170,3 +326,99 @@ func parseForExpiry(data string) (time.Time, error) {
 
 	return time.Time{}, errors.New("could not parse expiry data for current user")
 }
+
+// parseForShell extracts the login shell from a passwd entry, as printed by
+// `getent passwd <username>`
+func parseForShell(data string) (string, error) {
+	fields := strings.Split(strings.TrimSpace(data), ":")
+	if len(fields) != 7 {
+		return "", errors.New("could not parse passwd entry for user")
+	}
+
+	return fields[6], nil
+}
+
+// parseForComment extracts the GECOS field from a passwd entry, as printed by
+// `getent passwd <username>`
+func parseForComment(data string) (string, error) {
+	fields := strings.Split(strings.TrimSpace(data), ":")
+	if len(fields) != 7 {
+		return "", errors.New("could not parse passwd entry for user")
+	}
+
+	return fields[4], nil
+}
+
+// parseForPassword extracts the hashed password from a shadow entry, as printed
+// by `getent shadow <username>`
+func parseForPassword(data string) (string, error) {
+	fields := strings.Split(strings.TrimSpace(data), ":")
+	if len(fields) != 9 {
+		return "", errors.New("could not parse shadow entry for user")
+	}
+
+	return fields[1], nil
+}
+
+// parseForAging extracts the password aging from a shadow entry, as printed by
+// `getent shadow <username>`. An empty maximum is shown as -1, which is how
+// chage is told to clear it.
+func parseForAging(data string) (*Aging, error) {
+	fields := strings.Split(strings.TrimSpace(data), ":")
+	if len(fields) != 9 {
+		return nil, errors.New("could not parse shadow entry for user")
+	}
+
+	aging := &Aging{MinDays: fields[3], MaxDays: fields[4], WarnDays: fields[5]}
+	if aging.MaxDays == "" {
+		aging.MaxDays = "-1"
+	}
+	return aging, nil
+}
+
+// parseForGroups finds the groups a user is a member of in the group database,
+// as printed by `getent group`
+func parseForGroups(data, userName string) ([]string, error) {
+	var groups []string
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		if len(fields) != 4 {
+			return nil, errors.New("could not parse group entry")
+		}
+
+		for _, member := range strings.Split(fields[3], ",") {
+			if member == userName {
+				groups = append(groups, fields[0])
+				break
+			}
+		}
+	}
+	return groups, nil
+}
+
+// passwordArg is the value of the password option for useradd and usermod. A
+// locked password is the hash behind a leading "!", and useradd has no lock
+// option, so a user added locked without a password starts with just "!".
+func passwordArg(password string, lock bool) string {
+	if lock {
+		return "!" + password
+	}
+	return password
+}
+
+// parseForLocked determines whether a password is locked from the output of
+// the `passwd -S <username>` command. The second field is the password status,
+// which starts with L when the password is locked.
+func parseForLocked(data string) (bool, error) {
+	fields := strings.Fields(data)
+	if len(fields) < 2 {
+		return false, errors.New("could not parse password status for user")
+	}
+
+	return strings.HasPrefix(fields[1], "L"), nil
+}
+
@@ -0,0 +1,109 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseForShell(t *testing.T) {
+	t.Parallel()
+
+	shell, err := parseForShell("root:x:0:0:root:/root:/bin/bash\n")
+	require.NoError(t, err)
+	assert.Equal(t, "/bin/bash", shell)
+
+	_, err = parseForShell("")
+	assert.Error(t, err)
+}
+
+func TestParseForComment(t *testing.T) {
+	t.Parallel()
+
+	comment, err := parseForComment("test:x:1000:1000:Test User,Room 1,555-0100:/home/test:/bin/sh\n")
+	require.NoError(t, err)
+	assert.Equal(t, "Test User,Room 1,555-0100", comment)
+
+	_, err = parseForComment("")
+	assert.Error(t, err)
+}
+
+func TestParseForPassword(t *testing.T) {
+	t.Parallel()
+
+	password, err := parseForPassword("test:$6$salt$hash:17000:0:99999:7:::\n")
+	require.NoError(t, err)
+	assert.Equal(t, "$6$salt$hash", password)
+
+	_, err = parseForPassword("test:x:1000:1000::/home/test:/bin/sh\n")
+	assert.Error(t, err)
+}
+
+func TestParseForAging(t *testing.T) {
+	t.Parallel()
+
+	aging, err := parseForAging("test:$6$salt$hash:17000:1:90:7:::\n")
+	require.NoError(t, err)
+	assert.Equal(t, &Aging{MinDays: "1", MaxDays: "90", WarnDays: "7"}, aging)
+
+	aging, err = parseForAging("test:!:17000::::::\n")
+	require.NoError(t, err)
+	assert.Equal(t, &Aging{MaxDays: "-1"}, aging)
+
+	_, err = parseForAging("test:x:1000:1000::/home/test:/bin/sh\n")
+	assert.Error(t, err)
+}
+
+func TestParseForGroups(t *testing.T) {
+	t.Parallel()
+
+	groups, err := parseForGroups("root:x:0:\nwheel:x:10:admin,test\ndocker:x:999:test\ntesters:x:1001:admin\n", "test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"wheel", "docker"}, groups)
+
+	_, err = parseForGroups("wheel:x:10\n", "test")
+	assert.Error(t, err)
+}
+
+func TestPasswordArg(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "$6$salt$hash", passwordArg("$6$salt$hash", false))
+	assert.Equal(t, "!$6$salt$hash", passwordArg("$6$salt$hash", true))
+	assert.Equal(t, "!", passwordArg("", true))
+}
+
+func TestParseForLocked(t *testing.T) {
+	t.Parallel()
+
+	for out, locked := range map[string]bool{
+		"test L 2016-12-12 0 99999 7 -1\n":                                true,
+		"test LK 2016-12-12 0 99999 7 -1 (Password locked.)\n":            true,
+		"test P 2016-12-12 0 99999 7 -1\n":                                false,
+		"test PS 2016-12-12 0 99999 7 -1 (Password set, SHA512 crypt.)\n": false,
+		"test NP 2016-12-12 0 99999 7 -1\n":                               false,
+	} {
+		actual, err := parseForLocked(out)
+		require.NoError(t, err)
+		assert.Equal(t, locked, actual, out)
+	}
+
+	_, err := parseForLocked("test")
+	assert.Error(t, err)
+}
+
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"math"
 	os "os/user"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -428,6 +429,193 @@ func TestApply(t *testing.T) {
 				assert.Equal(t, fmt.Sprintf("modified user %s", u.Username), status.Messages()[0])
 			})
 
+			t.Run("create home", func(t *testing.T) {
+				usr := &os.User{
+					Username: currUsername,
+					Uid:      "1000",
+					Gid:      "1000",
+					HomeDir:  "/home/test",
+				}
+				m := &MockSystem{}
+				u := user.NewUser(m)
+				u.Username = usr.Username
+				u.CreateHome = true
+				u.State = user.StatePresent
+
+				m.On("Lookup", u.Username).Return(usr, nil)
+				m.On("LookupHome", usr.HomeDir).Return(&user.Home{}, nil)
+				m.On("CreateHome", usr.HomeDir, "", usr.Uid, usr.Gid).Return(nil)
+				status, err := u.Apply(context.Background())
+
+				m.AssertNotCalled(t, "ModUser", mock.Anything, mock.Anything)
+				m.AssertCalled(t, "CreateHome", usr.HomeDir, "", usr.Uid, usr.Gid)
+				assert.NoError(t, err)
+				assert.Equal(t, []string{fmt.Sprintf("created home directory %s", usr.HomeDir)}, status.Messages())
+			})
+
+			t.Run("error creating home", func(t *testing.T) {
+				usr := &os.User{
+					Username: currUsername,
+					Uid:      "1000",
+					Gid:      "1000",
+					HomeDir:  "/home/test",
+				}
+				m := &MockSystem{}
+				u := user.NewUser(m)
+				u.Username = usr.Username
+				u.CreateHome = true
+				u.State = user.StatePresent
+
+				m.On("Lookup", u.Username).Return(usr, nil)
+				m.On("LookupHome", usr.HomeDir).Return(&user.Home{}, nil)
+				m.On("CreateHome", usr.HomeDir, "", usr.Uid, usr.Gid).Return(fmt.Errorf("permission denied"))
+				status, err := u.Apply(context.Background())
+
+				assert.EqualError(t, err, "create home: permission denied")
+				assert.Equal(t, resource.StatusFatal, status.StatusCode())
+			})
+
+			t.Run("chown home", func(t *testing.T) {
+				usr := &os.User{
+					Username: currUsername,
+					Uid:      "1000",
+					Gid:      "1000",
+					HomeDir:  "/home/test",
+				}
+				m := &MockSystem{}
+				u := user.NewUser(m)
+				u.Username = usr.Username
+				u.CreateHome = true
+				u.State = user.StatePresent
+
+				m.On("Lookup", u.Username).Return(usr, nil)
+				m.On("LookupHome", usr.HomeDir).Return(&user.Home{Exists: true, IsDir: true, UID: "0", GID: "0"}, nil)
+				m.On("ChownHome", usr.HomeDir, usr.Uid, usr.Gid).Return(nil)
+				status, err := u.Apply(context.Background())
+
+				m.AssertCalled(t, "ChownHome", usr.HomeDir, usr.Uid, usr.Gid)
+				assert.NoError(t, err)
+				assert.Equal(t, []string{fmt.Sprintf("changed owner of home directory %s", usr.HomeDir)}, status.Messages())
+			})
+
+			t.Run("move home", func(t *testing.T) {
+				usr := &os.User{
+					Username: currUsername,
+					Uid:      "1000",
+					Gid:      "1000",
+					HomeDir:  "/home/test",
+				}
+				m := &MockSystem{}
+				u := user.NewUser(m)
+				u.Username = usr.Username
+				u.HomeDir = "/home/moved"
+				u.MoveDir = true
+				u.State = user.StatePresent
+				options := user.ModUserOptions{Directory: u.HomeDir, MoveDir: true}
+
+				m.On("Lookup", u.Username).Return(usr, nil)
+				m.On("ModUser", u.Username, &options).Return(nil)
+				m.On("ChownHomeTree", u.HomeDir, usr.Uid, usr.Gid).Return(nil)
+				status, err := u.Apply(context.Background())
+
+				m.AssertCalled(t, "ModUser", u.Username, &options)
+				m.AssertCalled(t, "ChownHomeTree", u.HomeDir, usr.Uid, usr.Gid)
+				assert.NoError(t, err)
+				assert.Equal(t, []string{
+					fmt.Sprintf("modified user %s", u.Username),
+					fmt.Sprintf("changed owner of moved home directory %s", u.HomeDir),
+				}, status.Messages())
+			})
+
+			t.Run("error changing owner of moved home", func(t *testing.T) {
+				usr := &os.User{
+					Username: currUsername,
+					Uid:      "1000",
+					Gid:      "1000",
+					HomeDir:  "/home/test",
+				}
+				m := &MockSystem{}
+				u := user.NewUser(m)
+				u.Username = usr.Username
+				u.HomeDir = "/home/moved"
+				u.MoveDir = true
+				u.State = user.StatePresent
+
+				m.On("Lookup", u.Username).Return(usr, nil)
+				m.On("ModUser", u.Username, mock.Anything).Return(nil)
+				m.On("ChownHomeTree", u.HomeDir, usr.Uid, usr.Gid).Return(fmt.Errorf("operation not permitted"))
+				status, err := u.Apply(context.Background())
+
+				assert.EqualError(t, err, "chown home: operation not permitted")
+				assert.Equal(t, resource.StatusFatal, status.StatusCode())
+			})
+
+			t.Run("disable user", func(t *testing.T) {
+				usr := &os.User{
+					Username: currUsername,
+				}
+				m := &MockSystem{}
+				u := user.NewUser(m)
+				u.Username = usr.Username
+				u.Disabled = true
+				u.State = user.StatePresent
+				options := user.ModUserOptions{Shell: user.NologinShell(), Lock: true}
+
+				m.On("Lookup", u.Username).Return(usr, nil)
+				m.On("LookupUserLogin", u.Username).Return(&user.Login{Shell: "/bin/sh"}, nil)
+				m.On("ModUser", u.Username, &options).Return(nil)
+				status, err := u.Apply(context.Background())
+
+				// the shell and lock change together
+				m.AssertNumberOfCalls(t, "ModUser", 1)
+				m.AssertCalled(t, "ModUser", u.Username, &options)
+				assert.NoError(t, err)
+				assert.Contains(t, status.Messages(), fmt.Sprintf("modified user %s", u.Username))
+			})
+
+			t.Run("unlock user", func(t *testing.T) {
+				usr := &os.User{
+					Username: currUsername,
+				}
+				m := &MockSystem{}
+				u := user.NewUser(m)
+				u.Username = usr.Username
+				u.State = user.StateUnlocked
+				options := user.ModUserOptions{Unlock: true}
+
+				m.On("Lookup", u.Username).Return(usr, nil)
+				m.On("LookupUserPassword", u.Username).Return("!$6$salt$hash", nil)
+				m.On("ModUser", u.Username, &options).Return(nil)
+				status, err := u.Apply(context.Background())
+
+				m.AssertCalled(t, "ModUser", u.Username, &options)
+				assert.NoError(t, err)
+				assert.Contains(t, status.Messages(), fmt.Sprintf("modified user %s", u.Username))
+			})
+
+			t.Run("set password aging", func(t *testing.T) {
+				usr := &os.User{
+					Username: currUsername,
+				}
+				m := &MockSystem{}
+				u := user.NewUser(m)
+				u.Username = usr.Username
+				u.MaxDays = "90"
+				u.State = user.StatePresent
+				aging := &user.Aging{MaxDays: "90"}
+
+				m.On("Lookup", u.Username).Return(usr, nil)
+				m.On("LookupUserAging", u.Username).Return(&user.Aging{MaxDays: "-1"}, nil)
+				m.On("SetUserAging", u.Username, aging).Return(nil)
+				status, err := u.Apply(context.Background())
+
+				// aging is not a usermod option
+				m.AssertNotCalled(t, "ModUser", mock.Anything, mock.Anything)
+				m.AssertCalled(t, "SetUserAging", u.Username, aging)
+				assert.NoError(t, err)
+				assert.Contains(t, status.Messages(), fmt.Sprintf("set password aging for user %s", u.Username))
+			})
+
 			t.Run("will not attempt to modify", func(t *testing.T) {
 				usr := &os.User{
 					Username: currUsername,
@@ -487,10 +675,10 @@ func TestApply(t *testing.T) {
 			u.State = user.StateAbsent
 
 			m.On("Lookup", u.Username).Return(usr, nil)
-			m.On("DelUser", u.Username).Return(nil)
+			m.On("DelUser", u.Username, &user.DelUserOptions{}).Return(nil)
 			status, err := u.Apply(context.Background())
 
-			m.AssertCalled(t, "DelUser", u.Username)
+			m.AssertCalled(t, "DelUser", u.Username, &user.DelUserOptions{})
 			assert.NoError(t, err)
 			assert.Equal(t, fmt.Sprintf("deleted user %s", u.Username), status.Messages()[0])
 			assert.Equal(t, u.Username, status.Diffs()["user"].Original())
@@ -498,6 +686,30 @@ func TestApply(t *testing.T) {
 			assert.True(t, status.HasChanges())
 		})
 
+		t.Run("delete user with remove_all", func(t *testing.T) {
+			usr := &os.User{
+				Username: fakeUsername,
+				HomeDir:  "/home/" + fakeUsername,
+			}
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = usr.Username
+			u.State = user.StateAbsent
+			u.RemoveAll = true
+			options := user.DelUserOptions{RemoveAll: true}
+
+			m.On("Lookup", u.Username).Return(usr, nil)
+			m.On("LookupHome", usr.HomeDir).Return(&user.Home{Exists: true, IsDir: true}, nil)
+			m.On("LookupHome", filepath.Join(user.MailDir, usr.Username)).Return(&user.Home{}, nil)
+			m.On("DelUser", u.Username, &options).Return(nil)
+			status, err := u.Apply(context.Background())
+
+			m.AssertCalled(t, "DelUser", u.Username, &options)
+			assert.NoError(t, err)
+			assert.Equal(t, usr.HomeDir, status.Diffs()["home_dir"].Original())
+			assert.NotContains(t, status.Diffs(), "mail_spool")
+		})
+
 		t.Run("no delete-error deleting user", func(t *testing.T) {
 			usr := &os.User{
 				Username: fakeUsername,
@@ -508,10 +720,10 @@ func TestApply(t *testing.T) {
 			u.State = user.StateAbsent
 
 			m.On("Lookup", u.Username).Return(usr, nil)
-			m.On("DelUser", u.Username).Return(fmt.Errorf(""))
+			m.On("DelUser", u.Username, &user.DelUserOptions{}).Return(fmt.Errorf(""))
 			status, err := u.Apply(context.Background())
 
-			m.AssertCalled(t, "DelUser", u.Username)
+			m.AssertCalled(t, "DelUser", u.Username, &user.DelUserOptions{})
 			assert.EqualError(t, err, "user delete: ")
 			assert.Equal(t, resource.StatusFatal, status.StatusCode())
 			assert.Equal(t, fmt.Sprintf("error deleting user %s", u.Username), status.Messages()[0])
@@ -527,10 +739,10 @@ func TestApply(t *testing.T) {
 			u.State = user.StateAbsent
 
 			m.On("Lookup", u.Username).Return(usr, os.UnknownUserError(""))
-			m.On("DelUser", u.Username).Return(nil)
+			m.On("DelUser", u.Username, &user.DelUserOptions{}).Return(nil)
 			status, err := u.Apply(context.Background())
 
-			m.AssertNotCalled(t, "DelUser", u.Username)
+			m.AssertNotCalled(t, "DelUser", u.Username, &user.DelUserOptions{})
 			assert.NoError(t, err)
 			assert.Equal(t, resource.StatusNoChange, status.StatusCode())
 		})
@@ -551,11 +763,11 @@ func TestApply(t *testing.T) {
 		m.On("Lookup", u.Username).Return(usr, nil)
 		m.On("LookupID", u.UID).Return(usr, nil)
 		m.On("AddUser", u.Username, &options).Return(nil)
-		m.On("DelUser", u.Username).Return(nil)
+		m.On("DelUser", u.Username, &user.DelUserOptions{}).Return(nil)
 		status, err := u.Apply(context.Background())
 
 		m.AssertNotCalled(t, "AddUser", u.Username, &options)
-		m.AssertNotCalled(t, "DelUser", u.Username)
+		m.AssertNotCalled(t, "DelUser", u.Username, &user.DelUserOptions{})
 		assert.EqualError(t, err, fmt.Sprintf("user: unrecognized state %s", u.State))
 		assert.Equal(t, resource.StatusFatal, status.StatusCode())
 	})
@@ -616,6 +828,175 @@ func TestDiffAdd(t *testing.T) {
 		assert.Equal(t, expiryString, status.Diffs()["expiry"].Current())
 	})
 
+	t.Run("disabled", func(t *testing.T) {
+		u := user.NewUser(new(user.System))
+		u.Username = fakeUsername
+		u.Disabled = true
+		status := resource.NewStatus()
+
+		expected := &user.AddUserOptions{
+			Shell: user.NologinShell(),
+			Lock:  true,
+		}
+
+		options, err := u.DiffAdd(status)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, options)
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		assert.Equal(t, "<default shell>", status.Diffs()["shell"].Original())
+		assert.Equal(t, user.NologinShell(), status.Diffs()["shell"].Current())
+		assert.Equal(t, fmt.Sprintf("<%s>", string(user.StateAbsent)), status.Diffs()["password"].Original())
+		assert.Equal(t, "locked", status.Diffs()["password"].Current())
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		u := user.NewUser(new(user.System))
+		u.Username = fakeUsername
+		u.State = user.StateLocked
+		status := resource.NewStatus()
+
+		options, err := u.DiffAdd(status)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &user.AddUserOptions{Lock: true}, options)
+		assert.Equal(t, fmt.Sprintf("<%s>", string(user.StateAbsent)), status.Diffs()["password"].Original())
+		assert.Equal(t, "locked", status.Diffs()["password"].Current())
+	})
+
+	t.Run("unlocked", func(t *testing.T) {
+		u := user.NewUser(new(user.System))
+		u.Username = fakeUsername
+		u.Password = "$6$salt$hash"
+		u.State = user.StateUnlocked
+		status := resource.NewStatus()
+
+		options, err := u.DiffAdd(status)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &user.AddUserOptions{Password: u.Password}, options)
+		assert.Equal(t, fmt.Sprintf("<%s>", string(user.StateAbsent)), status.Diffs()["password"].Original())
+		assert.Equal(t, "unlocked", status.Diffs()["password"].Current())
+	})
+
+	t.Run("unlocked without password", func(t *testing.T) {
+		u := user.NewUser(new(user.System))
+		u.Username = fakeUsername
+		u.State = user.StateUnlocked
+		status := resource.NewStatus()
+
+		_, err := u.DiffAdd(status)
+
+		assert.EqualError(t, err, fmt.Sprintf("user %s cannot be unlocked without a password", u.Username))
+		assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+	})
+
+	t.Run("password", func(t *testing.T) {
+		u := user.NewUser(new(user.System))
+		u.Username = fakeUsername
+		u.Password = "$6$salt$hash"
+		status := resource.NewStatus()
+
+		options, err := u.DiffAdd(status)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &user.AddUserOptions{Password: u.Password}, options)
+		assert.Equal(t, fmt.Sprintf("<%s>", string(user.StateAbsent)), status.Diffs()["password_hash"].Original())
+		assert.Equal(t, "<set>", status.Diffs()["password_hash"].Current())
+	})
+
+	t.Run("password aging", func(t *testing.T) {
+		u := user.NewUser(new(user.System))
+		u.Username = fakeUsername
+		u.MaxDays = "90"
+		u.WarnDays = "7"
+		status := resource.NewStatus()
+
+		options, err := u.DiffAdd(status)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &user.Aging{MaxDays: "90", WarnDays: "7"}, options.Aging)
+		assert.Equal(t, "<default>", status.Diffs()["max_days"].Original())
+		assert.Equal(t, "90", status.Diffs()["max_days"].Current())
+		assert.Equal(t, "7", status.Diffs()["warn_days"].Current())
+		_, ok := status.Diffs()["min_days"]
+		assert.False(t, ok)
+	})
+
+	t.Run("regular account", func(t *testing.T) {
+		u := user.NewUser(new(user.System))
+		u.Username = fakeUsername
+		status := resource.NewStatus()
+
+		options, err := u.DiffAdd(status)
+
+		assert.NoError(t, err)
+		assert.False(t, options.System)
+		assert.Equal(t, fmt.Sprintf("<%s>", string(user.StateAbsent)), status.Diffs()["account"].Original())
+		assert.Equal(t, "regular", status.Diffs()["account"].Current())
+	})
+
+	t.Run("system account", func(t *testing.T) {
+		u := user.NewUser(new(user.System))
+		u.Username = fakeUsername
+		u.System = true
+		status := resource.NewStatus()
+
+		options, err := u.DiffAdd(status)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &user.AddUserOptions{System: true}, options)
+		assert.Equal(t, "system", status.Diffs()["account"].Current())
+	})
+
+	t.Run("shell", func(t *testing.T) {
+		u := user.NewUser(new(user.System))
+		u.Username = fakeUsername
+		u.Shell = "/bin/sh"
+		status := resource.NewStatus()
+
+		options, err := u.DiffAdd(status)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &user.AddUserOptions{Shell: u.Shell}, options)
+		assert.Equal(t, "<default shell>", status.Diffs()["shell"].Original())
+		assert.Equal(t, u.Shell, status.Diffs()["shell"].Current())
+	})
+
+	t.Run("groups", func(t *testing.T) {
+		t.Run("groups exist", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = fakeUsername
+			u.Groups = []string{"wheel", "docker"}
+			status := resource.NewStatus()
+
+			m.On("LookupGroup", "wheel").Return(&os.Group{Name: "wheel"}, nil)
+			m.On("LookupGroup", "docker").Return(&os.Group{Name: "docker"}, nil)
+
+			options, err := u.DiffAdd(status)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &user.AddUserOptions{Groups: u.Groups}, options)
+			assert.Equal(t, "wheel,docker", status.Diffs()["groups"].Current())
+		})
+
+		t.Run("error-group not found", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = fakeUsername
+			u.Groups = []string{"missing"}
+			status := resource.NewStatus()
+
+			m.On("LookupGroup", "missing").Return((*os.Group)(nil), os.UnknownGroupError("missing"))
+
+			_, err := u.DiffAdd(status)
+
+			assert.EqualError(t, err, "group missing does not exist")
+			assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+		})
+	})
+
 	t.Run("username", func(t *testing.T) {
 		t.Run("group exists-provide groupname", func(t *testing.T) {
 			u := user.NewUser(new(user.System))
@@ -886,6 +1267,26 @@ func TestDiffAdd(t *testing.T) {
 			assert.Equal(t, u.Username, status.Diffs()["username"].Current())
 		})
 
+		t.Run("create_home false", func(t *testing.T) {
+			u := user.NewUser(new(user.System))
+			u.Username = fakeUsername
+			u.NoCreateHome = true
+			u.HomeDir = "/tmp/test"
+			status := resource.NewStatus()
+
+			expected := &user.AddUserOptions{
+				NoCreateHome: true,
+				Directory:    u.HomeDir,
+			}
+
+			options, err := u.DiffAdd(status)
+
+			assert.NoError(t, err)
+			assert.Equal(t, expected, options)
+			_, ok := status.Diffs()["create_home"]
+			assert.False(t, ok)
+		})
+
 		t.Run("home_dir without create_home", func(t *testing.T) {
 			u := user.NewUser(new(user.System))
 			u.Username = fakeUsername
@@ -964,11 +1365,68 @@ func TestDiffDel(t *testing.T) {
 		assert.True(t, status.HasChanges())
 	})
 
-	t.Run("uid provided", func(t *testing.T) {
-		t.Run("user does not exist", func(t *testing.T) {
-			u := user.NewUser(new(user.System))
-			u.Username = fakeUsername
-			u.UID = fakeUID
+	t.Run("remove_all", func(t *testing.T) {
+		usr := os.User{Username: fakeUsername, HomeDir: "/home/" + fakeUsername}
+		spool := filepath.Join(user.MailDir, usr.Username)
+
+		t.Run("existing home and spool", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = usr.Username
+			u.State = user.StateAbsent
+			u.RemoveAll = true
+			status := resource.NewStatus()
+
+			m.On("LookupHome", usr.HomeDir).Return(&user.Home{Exists: true, IsDir: true}, nil)
+			m.On("LookupHome", spool).Return(&user.Home{Exists: true}, nil)
+
+			err := u.DiffDel(status, &usr, false)
+
+			require.NoError(t, err)
+			assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+			assert.Equal(t, usr.HomeDir, status.Diffs()["home_dir"].Original())
+			assert.Equal(t, fmt.Sprintf("<%s>", string(user.StateAbsent)), status.Diffs()["home_dir"].Current())
+			assert.Equal(t, spool, status.Diffs()["mail_spool"].Original())
+			assert.Equal(t, fmt.Sprintf("<%s>", string(user.StateAbsent)), status.Diffs()["mail_spool"].Current())
+		})
+
+		t.Run("user does not exist", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = usr.Username
+			u.State = user.StateAbsent
+			u.RemoveAll = true
+			status := resource.NewStatus()
+
+			err := u.DiffDel(status, &usr, true)
+
+			require.NoError(t, err)
+			assert.False(t, status.HasChanges())
+			m.AssertNotCalled(t, "LookupHome", mock.Anything)
+		})
+
+		t.Run("lookup error", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = usr.Username
+			u.State = user.StateAbsent
+			u.RemoveAll = true
+			status := resource.NewStatus()
+
+			m.On("LookupHome", usr.HomeDir).Return((*user.Home)(nil), fmt.Errorf("permission denied"))
+
+			err := u.DiffDel(status, &usr, false)
+
+			assert.EqualError(t, err, fmt.Sprintf("cannot look up home_dir %s: permission denied", usr.HomeDir))
+			assert.Equal(t, resource.StatusFatal, status.StatusCode())
+		})
+	})
+
+	t.Run("uid provided", func(t *testing.T) {
+		t.Run("user does not exist", func(t *testing.T) {
+			u := user.NewUser(new(user.System))
+			u.Username = fakeUsername
+			u.UID = fakeUID
 			u.State = user.StateAbsent
 			status := resource.NewStatus()
 
@@ -1296,6 +1754,55 @@ func TestDiffMod(t *testing.T) {
 		assert.Equal(t, u.Name, status.Diffs()["comment"].Current())
 	})
 
+	t.Run("comment with several parts", func(t *testing.T) {
+		t.Run("no change", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.Name = "Test User,Room 1"
+			status := resource.NewStatus()
+
+			m.On("LookupUserComment", u.Username).Return("Test User,Room 1", nil)
+
+			options, err := u.DiffMod(status, &os.User{Name: "Test User"})
+
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{}, options)
+			assert.False(t, status.HasChanges())
+		})
+
+		t.Run("changed", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.Name = "Test User,Room 2"
+			status := resource.NewStatus()
+
+			m.On("LookupUserComment", u.Username).Return("Test User,Room 1", nil)
+
+			options, err := u.DiffMod(status, &os.User{Name: "Test User"})
+
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{Comment: u.Name}, options)
+			assert.Equal(t, "Test User,Room 1", status.Diffs()["comment"].Original())
+			assert.Equal(t, u.Name, status.Diffs()["comment"].Current())
+		})
+
+		t.Run("error", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.Name = "Test User,Room 2"
+			status := resource.NewStatus()
+
+			m.On("LookupUserComment", u.Username).Return("", fmt.Errorf("getent failed"))
+
+			_, err := u.DiffMod(status, &os.User{Name: "Test User"})
+
+			assert.EqualError(t, err, fmt.Sprintf("could not acquire current comment for %s: getent failed", u.Username))
+		})
+	})
+
 	t.Run("directory", func(t *testing.T) {
 		u := user.NewUser(new(user.System))
 		u.Username = currUsername
@@ -1377,147 +1884,1140 @@ func TestDiffMod(t *testing.T) {
 		})
 	})
 
-	t.Run("no options", func(t *testing.T) {
-		u := user.NewUser(new(user.System))
-		u.Username = currUsername
-		status := resource.NewStatus()
+	t.Run("create_home", func(t *testing.T) {
+		homeUser := &os.User{Username: currUsername, Uid: "1000", Gid: "1000", HomeDir: "/home/test"}
 
-		expected := &user.ModUserOptions{}
+		t.Run("home exists", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = homeUser.Username
+			u.CreateHome = true
+			status := resource.NewStatus()
 
-		options, err := u.DiffMod(status, currUser)
+			m.On("LookupHome", homeUser.HomeDir).Return(&user.Home{Exists: true, IsDir: true, UID: "1000", GID: "1000"}, nil)
 
-		assert.NoError(t, err)
-		assert.Equal(t, expected, options)
-		assert.Equal(t, resource.StatusNoChange, status.StatusCode())
-		assert.False(t, status.HasChanges())
-	})
-}
+			options, err := u.DiffMod(status, homeUser)
 
-// setUid is used to find a uid that exists, but is not
-// a match for the current user name (currUsername).
-func setUid() (string, error) {
-	for i := 0; i <= maxUID; i++ {
-		uid := strconv.Itoa(i)
-		user, err := os.LookupId(uid)
-		if err == nil && user.Username != currUsername {
-			return uid, nil
-		}
-	}
-	return "", fmt.Errorf("setUid: could not set uid")
-}
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{}, options)
+			assert.False(t, status.HasChanges())
+		})
 
-// setFakeUid is used to set a uid that does not exist.
-func setFakeUid() (string, error) {
-	for i := minUID; i <= maxUID; i++ {
-		uid := strconv.Itoa(i)
-		_, err := os.LookupId(uid)
-		if err != nil {
-			return uid, nil
-		}
-	}
-	return "", fmt.Errorf("setFakeUid: could not set uid")
-}
+		t.Run("home missing", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = homeUser.Username
+			u.CreateHome = true
+			u.SkelDir = "/tmp/skel"
+			status := resource.NewStatus()
 
-// setGid is used to find a gid that exists, but is not
-// the gid for the current user.
-func setGid() (string, error) {
-	for i := 0; i <= maxGID; i++ {
-		gid := strconv.Itoa(i)
-		_, err := os.LookupGroupId(gid)
-		if err == nil && gid != currGID {
-			return gid, nil
-		}
-	}
-	return "", fmt.Errorf("setGid: could not set gid")
-}
+			expected := &user.ModUserOptions{
+				HomeDir:    homeUser.HomeDir,
+				CreateHome: true,
+				SkelDir:    u.SkelDir,
+			}
 
-// setFakeGid is used to set a gid that does not exist.
-func setFakeGid() (string, error) {
-	for i := minGID; i <= maxGID; i++ {
-		gid := strconv.Itoa(i)
-		_, err := os.LookupGroupId(gid)
-		if err != nil {
-			return gid, nil
-		}
-	}
-	return "", fmt.Errorf("setFakeGid: could not set gid")
-}
+			m.On("LookupHome", homeUser.HomeDir).Return(&user.Home{}, nil)
 
-// MockSystem is a mock implementation of user.System
-type MockSystem struct {
-	mock.Mock
-}
+			options, err := u.DiffMod(status, homeUser)
 
-// AddUser adds a user
-func (m *MockSystem) AddUser(name string, options *user.AddUserOptions) error {
-	args := m.Called(name, options)
-	return args.Error(0)
-}
+			assert.NoError(t, err)
+			assert.Equal(t, expected, options)
+			assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+			assert.Equal(t, fmt.Sprintf("<%s>", string(user.StateAbsent)), status.Diffs()["create_home"].Original())
+			assert.Equal(t, homeUser.HomeDir, status.Diffs()["create_home"].Current())
+			assert.Equal(t, u.SkelDir, status.Diffs()["skel_dir contents"].Original())
+		})
 
-// DelUser deletes a user
-func (m *MockSystem) DelUser(name string) error {
-	args := m.Called(name)
-	return args.Error(0)
-}
+		t.Run("new home_dir", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = homeUser.Username
+			u.CreateHome = true
+			u.HomeDir = "/tmp/test"
+			status := resource.NewStatus()
 
-// ModUser modifies a user
-func (m *MockSystem) ModUser(name string, options *user.ModUserOptions) error {
-	args := m.Called(name, options)
-	return args.Error(0)
-}
+			m.On("LookupHome", u.HomeDir).Return(&user.Home{}, nil)
 
-// LookupUserExpiry looks up a user's expiry
-func (m *MockSystem) LookupUserExpiry(name string) (time.Time, error) {
-	args := m.Called(name)
-	return args.Get(0).(time.Time), args.Error(1)
-}
+			options, err := u.DiffMod(status, homeUser)
 
-// Lookup looks up a user by name
-func (m *MockSystem) Lookup(name string) (*os.User, error) {
-	args := m.Called(name)
-	return args.Get(0).(*os.User), args.Error(1)
-}
+			assert.NoError(t, err)
+			assert.Equal(t, u.HomeDir, options.Directory)
+			assert.Equal(t, u.HomeDir, options.HomeDir)
+			assert.True(t, options.CreateHome)
+		})
 
-// LookupID looks up a user by ID
-func (m *MockSystem) LookupID(uid string) (*os.User, error) {
-	args := m.Called(uid)
-	return args.Get(0).(*os.User), args.Error(1)
-}
+		t.Run("move_dir", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = homeUser.Username
+			u.CreateHome = true
+			u.HomeDir = "/tmp/test"
+			u.MoveDir = true
+			status := resource.NewStatus()
 
-// LookupGroup looks up a group by name
-func (m *MockSystem) LookupGroup(name string) (*os.Group, error) {
-	args := m.Called(name)
-	return args.Get(0).(*os.Group), args.Error(1)
-}
+			options, err := u.DiffMod(status, homeUser)
 
-// LookupGroupID looks up a group by ID
-func (m *MockSystem) LookupGroupID(gid string) (*os.Group, error) {
-	args := m.Called(gid)
-	return args.Get(0).(*os.Group), args.Error(1)
-}
+			m.AssertNotCalled(t, "LookupHome", mock.Anything)
+			assert.NoError(t, err)
+			assert.False(t, options.CreateHome)
+		})
 
-// MockSystem2 is a mock implementation of user.System, with a special
-// implementation of Lookup
-type MockSystem2 struct {
-	mock.Mock
-	MockSystem
-	userBeforeAdd bool
-}
+		t.Run("wrong owner", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = homeUser.Username
+			u.CreateHome = true
+			status := resource.NewStatus()
 
-// Lookup looks up a user by name
-// Based on the value of userBeforeAdd, a user either before the add or after
-// the add is returned
-func (m *MockSystem2) Lookup(name string) (*os.User, error) {
-	args := m.Called(name)
-	if m.userBeforeAdd {
-		m.userBeforeAdd = false
-		return args.Get(0).(*os.User), args.Error(2)
-	}
-	return args.Get(1).(*os.User), args.Error(2)
-}
+			expected := &user.ModUserOptions{
+				HomeDir:   homeUser.HomeDir,
+				ChownHome: true,
+			}
 
-// AddUser adds a user
-func (m *MockSystem2) AddUser(name string, options *user.AddUserOptions) error {
-	args := m.Called(name, options)
-	return args.Error(0)
+			m.On("LookupHome", homeUser.HomeDir).Return(&user.Home{Exists: true, IsDir: true, UID: "0", GID: "0"}, nil)
+
+			options, err := u.DiffMod(status, homeUser)
+
+			assert.NoError(t, err)
+			assert.Equal(t, expected, options)
+			assert.Equal(t, "0:0", status.Diffs()["home_dir owner"].Original())
+			assert.Equal(t, "1000:1000", status.Diffs()["home_dir owner"].Current())
+		})
+
+		t.Run("error-not a directory", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = homeUser.Username
+			u.CreateHome = true
+			status := resource.NewStatus()
+
+			m.On("LookupHome", homeUser.HomeDir).Return(&user.Home{Exists: true}, nil)
+
+			_, err := u.DiffMod(status, homeUser)
+
+			assert.EqualError(t, err, fmt.Sprintf("home directory %s is not a directory", homeUser.HomeDir))
+			assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+		})
+	})
+
+	t.Run("shell", func(t *testing.T) {
+		t.Run("unchanged", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.Shell = "/usr/sbin/nologin"
+			status := resource.NewStatus()
+
+			m.On("LookupUserLogin", u.Username).Return(&user.Login{Shell: "/usr/sbin/nologin"}, nil)
+
+			options, err := u.DiffMod(status, currUser)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{}, options)
+			assert.False(t, status.HasChanges())
+		})
+
+		t.Run("changed", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.Shell = "/usr/sbin/nologin"
+			status := resource.NewStatus()
+
+			m.On("LookupUserLogin", u.Username).Return(&user.Login{Shell: "/bin/bash"}, nil)
+
+			options, err := u.DiffMod(status, currUser)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{Shell: u.Shell}, options)
+			assert.Equal(t, "/bin/bash", status.Diffs()["shell"].Original())
+			assert.Equal(t, u.Shell, status.Diffs()["shell"].Current())
+		})
+	})
+
+	t.Run("groups", func(t *testing.T) {
+		groupsUser := func(m *MockSystem, current []string, groups ...string) *user.User {
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.Groups = groups
+			for _, name := range groups {
+				m.On("LookupGroup", name).Return(&os.Group{Name: name}, nil)
+			}
+			m.On("LookupUserGroups", u.Username).Return(current, nil)
+			return u
+		}
+
+		t.Run("unchanged", func(t *testing.T) {
+			m := &MockSystem{}
+			u := groupsUser(m, []string{"docker", "wheel"}, "wheel", "docker")
+			status := resource.NewStatus()
+
+			options, err := u.DiffMod(status, currUser)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{}, options)
+			assert.False(t, status.HasChanges())
+		})
+
+		t.Run("replaced", func(t *testing.T) {
+			m := &MockSystem{}
+			u := groupsUser(m, []string{"audio", "wheel"}, "wheel", "docker")
+			status := resource.NewStatus()
+
+			options, err := u.DiffMod(status, currUser)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{Groups: []string{"wheel", "docker"}}, options)
+			assert.Equal(t, "audio,wheel", status.Diffs()["groups"].Original())
+			assert.Equal(t, "docker,wheel", status.Diffs()["groups"].Current())
+		})
+
+		t.Run("appended", func(t *testing.T) {
+			m := &MockSystem{}
+			u := groupsUser(m, []string{"audio", "wheel"}, "wheel", "docker")
+			u.Append = true
+			status := resource.NewStatus()
+
+			options, err := u.DiffMod(status, currUser)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{Groups: []string{"docker"}, AppendGroups: true}, options)
+			assert.Equal(t, "audio,wheel", status.Diffs()["groups"].Original())
+			assert.Equal(t, "audio,docker,wheel", status.Diffs()["groups"].Current())
+		})
+
+		t.Run("appended-unchanged", func(t *testing.T) {
+			m := &MockSystem{}
+			u := groupsUser(m, []string{"audio", "wheel"}, "wheel")
+			u.Append = true
+			status := resource.NewStatus()
+
+			options, err := u.DiffMod(status, currUser)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{}, options)
+			assert.False(t, status.HasChanges())
+		})
+
+		t.Run("error", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.Groups = []string{"wheel"}
+			status := resource.NewStatus()
+
+			m.On("LookupGroup", "wheel").Return(&os.Group{Name: "wheel"}, nil)
+			m.On("LookupUserGroups", u.Username).Return([]string(nil), fmt.Errorf("getent failed"))
+
+			_, err := u.DiffMod(status, currUser)
+
+			assert.EqualError(t, err, fmt.Sprintf("could not acquire current groups for %s: getent failed", u.Username))
+		})
+	})
+
+	t.Run("password aging", func(t *testing.T) {
+		t.Run("unchanged", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.MaxDays = "90"
+			status := resource.NewStatus()
+
+			m.On("LookupUserAging", u.Username).Return(&user.Aging{MinDays: "0", MaxDays: "90", WarnDays: "7"}, nil)
+
+			options, err := u.DiffMod(status, currUser)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{}, options)
+			assert.False(t, status.HasChanges())
+		})
+
+		t.Run("changed", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.MinDays = "1"
+			u.MaxDays = "90"
+			u.WarnDays = "7"
+			status := resource.NewStatus()
+
+			m.On("LookupUserAging", u.Username).Return(&user.Aging{MinDays: "", MaxDays: "99999", WarnDays: "7"}, nil)
+
+			options, err := u.DiffMod(status, currUser)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{Aging: &user.Aging{MinDays: "1", MaxDays: "90", WarnDays: "7"}}, options)
+			assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+			assert.Equal(t, "<none>", status.Diffs()["min_days"].Original())
+			assert.Equal(t, "1", status.Diffs()["min_days"].Current())
+			assert.Equal(t, "99999", status.Diffs()["max_days"].Original())
+			assert.Equal(t, "90", status.Diffs()["max_days"].Current())
+			_, ok := status.Diffs()["warn_days"]
+			assert.False(t, ok)
+		})
+
+		t.Run("error", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.MaxDays = "90"
+			status := resource.NewStatus()
+
+			m.On("LookupUserAging", u.Username).Return((*user.Aging)(nil), fmt.Errorf("getent failed"))
+
+			_, err := u.DiffMod(status, currUser)
+
+			assert.EqualError(t, err, fmt.Sprintf("could not acquire current password aging for %s: getent failed", u.Username))
+		})
+	})
+
+	t.Run("password", func(t *testing.T) {
+		t.Run("unchanged", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.Password = "$6$salt$hash"
+			status := resource.NewStatus()
+
+			m.On("LookupUserPassword", u.Username).Return("$6$salt$hash", nil)
+
+			options, err := u.DiffMod(status, currUser)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{}, options)
+			assert.False(t, status.HasChanges())
+		})
+
+		t.Run("unchanged when locked", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.Password = "$6$salt$hash"
+			status := resource.NewStatus()
+
+			m.On("LookupUserPassword", u.Username).Return("!$6$salt$hash", nil)
+
+			options, err := u.DiffMod(status, currUser)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{}, options)
+			assert.False(t, status.HasChanges())
+		})
+
+		t.Run("changed", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.Password = "$6$salt$hash"
+			status := resource.NewStatus()
+
+			m.On("LookupUserPassword", u.Username).Return("$6$salt$old", nil)
+
+			options, err := u.DiffMod(status, currUser)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{Password: u.Password}, options)
+			assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+
+			// neither hash is shown
+			assert.Equal(t, "<hidden>", status.Diffs()["password_hash"].Original())
+			assert.Equal(t, "<changed>", status.Diffs()["password_hash"].Current())
+		})
+
+		t.Run("changed when disabled", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.Password = "$6$salt$hash"
+			u.Disabled = true
+			status := resource.NewStatus()
+
+			m.On("LookupUserPassword", u.Username).Return("!$6$salt$old", nil)
+			m.On("LookupUserLogin", u.Username).Return(&user.Login{Shell: "/sbin/nologin", Locked: true}, nil)
+
+			options, err := u.DiffMod(status, currUser)
+
+			// the new password stays locked
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{Password: u.Password, Lock: true}, options)
+		})
+
+		t.Run("error", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.Password = "$6$salt$hash"
+			status := resource.NewStatus()
+
+			m.On("LookupUserPassword", u.Username).Return("", fmt.Errorf("getent failed"))
+
+			_, err := u.DiffMod(status, currUser)
+
+			assert.EqualError(t, err, fmt.Sprintf("could not acquire current password for %s: getent failed", u.Username))
+		})
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Run("can log in", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.Disabled = true
+			status := resource.NewStatus()
+
+			expected := &user.ModUserOptions{
+				Shell: user.NologinShell(),
+				Lock:  true,
+			}
+
+			m.On("LookupUserLogin", u.Username).Return(&user.Login{Shell: "/bin/bash"}, nil)
+
+			options, err := u.DiffMod(status, currUser)
+
+			assert.NoError(t, err)
+			assert.Equal(t, expected, options)
+			assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+			assert.Equal(t, "/bin/bash", status.Diffs()["shell"].Original())
+			assert.Equal(t, user.NologinShell(), status.Diffs()["shell"].Current())
+			assert.Equal(t, "unlocked", status.Diffs()["password"].Original())
+			assert.Equal(t, "locked", status.Diffs()["password"].Current())
+			assert.Equal(t, []string{fmt.Sprintf("user %s can currently log in", u.Username)}, status.Messages())
+		})
+
+		t.Run("password locked", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.Disabled = true
+			status := resource.NewStatus()
+
+			expected := &user.ModUserOptions{Shell: user.NologinShell()}
+
+			m.On("LookupUserLogin", u.Username).Return(&user.Login{Shell: "/bin/bash", Locked: true}, nil)
+
+			options, err := u.DiffMod(status, currUser)
+
+			assert.NoError(t, err)
+			assert.Equal(t, expected, options)
+			assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+			assert.Nil(t, status.Diffs()["password"])
+			assert.Equal(t, []string{fmt.Sprintf("user %s can currently log in", u.Username)}, status.Messages())
+		})
+
+		t.Run("already disabled", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.Disabled = true
+			status := resource.NewStatus()
+
+			m.On("LookupUserLogin", u.Username).Return(&user.Login{Shell: "/sbin/nologin", Locked: true}, nil)
+
+			options, err := u.DiffMod(status, currUser)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{}, options)
+			assert.Equal(t, resource.StatusNoChange, status.StatusCode())
+			assert.False(t, status.HasChanges())
+			assert.Equal(t, []string{fmt.Sprintf("user %s cannot log in", u.Username)}, status.Messages())
+		})
+
+		t.Run("error looking up login", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.Disabled = true
+			status := resource.NewStatus()
+
+			m.On("LookupUserLogin", u.Username).Return((*user.Login)(nil), fmt.Errorf("passwd: exit status 1"))
+
+			_, err := u.DiffMod(status, currUser)
+
+			assert.EqualError(t, err, fmt.Sprintf("could not acquire current login state for %s: passwd: exit status 1", u.Username))
+		})
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		t.Run("lock", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.State = user.StateLocked
+			status := resource.NewStatus()
+
+			m.On("LookupUserPassword", u.Username).Return("$6$salt$hash", nil)
+
+			options, err := u.DiffMod(status, currUser)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{Lock: true}, options)
+			assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+			assert.Equal(t, "unlocked", status.Diffs()["password"].Original())
+			assert.Equal(t, "locked", status.Diffs()["password"].Current())
+		})
+
+		t.Run("already locked", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.State = user.StateLocked
+			status := resource.NewStatus()
+
+			m.On("LookupUserPassword", u.Username).Return("!$6$salt$hash", nil)
+
+			options, err := u.DiffMod(status, currUser)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{}, options)
+			assert.False(t, status.HasChanges())
+		})
+
+		t.Run("new password", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.Password = "$6$salt$hash"
+			u.State = user.StateLocked
+			status := resource.NewStatus()
+
+			m.On("LookupUserPassword", u.Username).Return("!$6$salt$old", nil)
+
+			options, err := u.DiffMod(status, currUser)
+
+			// the new password stays locked
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{Password: u.Password, Lock: true}, options)
+			assert.Nil(t, status.Diffs()["password"])
+		})
+	})
+
+	t.Run("unlocked", func(t *testing.T) {
+		t.Run("unlock", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.State = user.StateUnlocked
+			status := resource.NewStatus()
+
+			m.On("LookupUserPassword", u.Username).Return("!$6$salt$hash", nil)
+
+			options, err := u.DiffMod(status, currUser)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{Unlock: true}, options)
+			assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+			assert.Equal(t, "locked", status.Diffs()["password"].Original())
+			assert.Equal(t, "unlocked", status.Diffs()["password"].Current())
+		})
+
+		t.Run("already unlocked", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.State = user.StateUnlocked
+			status := resource.NewStatus()
+
+			m.On("LookupUserPassword", u.Username).Return("$6$salt$hash", nil)
+
+			options, err := u.DiffMod(status, currUser)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{}, options)
+			assert.False(t, status.HasChanges())
+		})
+
+		t.Run("new password", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.Password = "$6$salt$hash"
+			u.State = user.StateUnlocked
+			status := resource.NewStatus()
+
+			m.On("LookupUserPassword", u.Username).Return("!$6$salt$old", nil)
+
+			options, err := u.DiffMod(status, currUser)
+
+			// setting the password unlocks it
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ModUserOptions{Password: u.Password}, options)
+			assert.Equal(t, "unlocked", status.Diffs()["password"].Current())
+		})
+
+		t.Run("no password", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.State = user.StateUnlocked
+			status := resource.NewStatus()
+
+			m.On("LookupUserPassword", u.Username).Return("!", nil)
+
+			_, err := u.DiffMod(status, currUser)
+
+			assert.EqualError(t, err, fmt.Sprintf("user %s cannot be unlocked without a password", u.Username))
+			assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+		})
+
+		t.Run("error", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = currUsername
+			u.State = user.StateUnlocked
+			status := resource.NewStatus()
+
+			m.On("LookupUserPassword", u.Username).Return("", fmt.Errorf("getent failed"))
+
+			_, err := u.DiffMod(status, currUser)
+
+			assert.EqualError(t, err, fmt.Sprintf("could not acquire current password for %s: getent failed", u.Username))
+		})
+	})
+
+	t.Run("no options", func(t *testing.T) {
+		u := user.NewUser(new(user.System))
+		u.Username = currUsername
+		status := resource.NewStatus()
+
+		expected := &user.ModUserOptions{}
+
+		options, err := u.DiffMod(status, currUser)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, options)
+		assert.Equal(t, resource.StatusNoChange, status.StatusCode())
+		assert.False(t, status.HasChanges())
+	})
+}
+
+// TestLogin tests when a login state allows logging in
+func TestLogin(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, (&user.Login{Shell: "/bin/bash"}).CanLogin())
+	assert.True(t, (&user.Login{Shell: "/bin/bash", Locked: true}).CanLogin())
+	assert.True(t, (&user.Login{Shell: "/usr/sbin/nologin"}).CanLogin())
+	assert.False(t, (&user.Login{Shell: "/usr/sbin/nologin", Locked: true}).CanLogin())
+	assert.False(t, (&user.Login{Shell: "/bin/false", Locked: true}).CanLogin())
+}
+
+// TestIDRanges tests picking ids from uid_range and gid_range
+func TestIDRanges(t *testing.T) {
+	t.Parallel()
+
+	uidRange, err := user.ParseIDRange(fmt.Sprintf("%s-%s", currUID, fakeUID))
+	require.NoError(t, err)
+	gidRange, err := user.ParseIDRange(fmt.Sprintf("%s-%s", currGID, fakeGID))
+	require.NoError(t, err)
+
+	t.Run("new user", func(t *testing.T) {
+		free, err := user.ParseIDRange(fmt.Sprintf("%s-%s", fakeUID, fakeUID))
+		require.NoError(t, err)
+		freeGroup, err := user.ParseIDRange(fmt.Sprintf("%s-%s", fakeGID, fakeGID))
+		require.NoError(t, err)
+
+		m := &MockSystem{}
+		u := user.NewUser(m)
+		u.Username = fakeUsername
+		u.State = user.StatePresent
+		u.UIDRange = free
+		u.GIDRange = freeGroup
+
+		m.On("Lookup", u.Username).Return((*os.User)(nil), os.UnknownUserError(u.Username))
+		m.On("LookupID", fakeUID).Return((*os.User)(nil), os.UnknownUserIdError(0))
+		m.On("LookupGroupID", fakeGID).Return((*os.Group)(nil), os.UnknownGroupIdError(fakeGID))
+
+		status, err := u.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		assert.Equal(t, fakeUID, u.UID)
+		assert.Equal(t, fakeUID, status.Diffs()["uid"].Current())
+		assert.Equal(t, fakeGID, status.Diffs()["gid"].Current())
+		assert.Equal(t, "", u.GID, "the gid is exported once the group exists")
+	})
+
+	t.Run("apply exports gid", func(t *testing.T) {
+		free, err := user.ParseIDRange(fmt.Sprintf("%s-%s", fakeUID, fakeUID))
+		require.NoError(t, err)
+		freeGroup, err := user.ParseIDRange(fmt.Sprintf("%s-%s", fakeGID, fakeGID))
+		require.NoError(t, err)
+
+		m := &MockSystem{}
+		u := user.NewUser(m)
+		u.Username = fakeUsername
+		u.State = user.StatePresent
+		u.UIDRange = free
+		u.GIDRange = freeGroup
+		options := user.AddUserOptions{UID: fakeUID, PrivateGID: fakeGID}
+
+		m.On("Lookup", u.Username).Return((*os.User)(nil), os.UnknownUserError(u.Username))
+		m.On("LookupID", fakeUID).Return((*os.User)(nil), os.UnknownUserIdError(0))
+		m.On("LookupGroupID", fakeGID).Return((*os.Group)(nil), os.UnknownGroupIdError(fakeGID))
+		m.On("AddUser", u.Username, &options).Return(nil)
+
+		_, err = u.Apply(context.Background())
+		require.NoError(t, err)
+
+		m.AssertCalled(t, "AddUser", u.Username, &options)
+		assert.Equal(t, fakeUID, u.UID)
+		assert.Equal(t, fakeGID, u.GID)
+	})
+
+	t.Run("existing user", func(t *testing.T) {
+		m := &MockSystem{}
+		u := user.NewUser(m)
+		u.Username = currUsername
+		u.State = user.StatePresent
+		u.UIDRange = uidRange
+		u.GIDRange = gidRange
+
+		m.On("Lookup", u.Username).Return(currUser, nil)
+
+		status, err := u.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		m.AssertNotCalled(t, "LookupID", mock.Anything)
+		assert.Equal(t, currUID, u.UID)
+		assert.Equal(t, currGID, u.GID)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("range exhausted", func(t *testing.T) {
+		full, err := user.ParseIDRange(fmt.Sprintf("%s-%s", currUID, currUID))
+		require.NoError(t, err)
+
+		m := &MockSystem{}
+		u := user.NewUser(m)
+		u.Username = fakeUsername
+		u.State = user.StatePresent
+		u.UIDRange = full
+
+		m.On("Lookup", u.Username).Return((*os.User)(nil), os.UnknownUserError(u.Username))
+		m.On("LookupID", currUID).Return(currUser, nil)
+
+		status, err := u.Check(context.Background(), fakerenderer.New())
+
+		assert.EqualError(t, err, fmt.Sprintf("cannot add user %s: could not allocate uid: no free id in range %s-%s", u.Username, currUID, currUID))
+		assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+	})
+}
+
+// TestIDPolicy tests refusing to manage users with ids outside uid_min and
+// uid_max or gid_min and gid_max
+func TestIDPolicy(t *testing.T) {
+	t.Parallel()
+
+	// the policy starts above the current ids, so the groups the user is in
+	// exist
+	uid, err := strconv.ParseUint(currUID, 10, 32)
+	require.NoError(t, err)
+	gid, err := strconv.ParseUint(currGID, 10, 32)
+	require.NoError(t, err)
+	above := uint32(uid)
+	if uint32(gid) > above {
+		above = uint32(gid)
+	}
+	policy := &user.IDRange{Min: above + 1, Max: above + 1000}
+	outside := &os.User{Username: "policy", Uid: currUID, Gid: currGID, HomeDir: "/home/policy"}
+
+	t.Run("uid outside", func(t *testing.T) {
+		m := &MockSystem{}
+		u := user.NewUser(m)
+		u.Username = outside.Username
+		u.State = user.StatePresent
+		u.UIDRange = policy
+		u.UIDPolicy = policy
+		u.HomeDir = "/srv/policy"
+
+		m.On("Lookup", u.Username).Return(outside, nil)
+
+		status, err := u.Check(context.Background(), fakerenderer.New())
+
+		assert.EqualError(t, err, fmt.Sprintf("cannot modify user policy: uid %s is outside the allowed range %s", currUID, policy))
+		assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+		assert.Equal(t, currUID, status.Diffs()["uid"].Original())
+		assert.Equal(t, fmt.Sprintf("<%s>", policy), status.Diffs()["uid"].Current())
+	})
+
+	t.Run("outside without changes", func(t *testing.T) {
+		m := &MockSystem{}
+		u := user.NewUser(m)
+		u.Username = outside.Username
+		u.State = user.StatePresent
+		u.UIDRange = policy
+		u.UIDPolicy = policy
+
+		m.On("Lookup", u.Username).Return(outside, nil)
+
+		status, err := u.Check(context.Background(), fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Equal(
+			t,
+			[]resource.Warning{{Kind: resource.WarningPolicy, Message: fmt.Sprintf("uid %s is outside the allowed range %s", currUID, policy)}},
+			resource.StatusWarnings(status),
+		)
+	})
+
+	t.Run("gid outside", func(t *testing.T) {
+		m := &MockSystem{}
+		u := user.NewUser(m)
+		u.Username = outside.Username
+		u.State = user.StatePresent
+		u.GIDRange = policy
+		u.GIDPolicy = policy
+		u.HomeDir = "/srv/policy"
+
+		m.On("Lookup", u.Username).Return(outside, nil)
+
+		status, err := u.Apply(context.Background())
+
+		assert.EqualError(t, err, fmt.Sprintf("will not attempt to modify user policy: gid %s is outside the allowed range %s", currGID, policy))
+		assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+		m.AssertNotCalled(t, "ModUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("inside", func(t *testing.T) {
+		inside, err := user.ParseIDRange(fmt.Sprintf("%s-%s", currUID, currUID))
+		require.NoError(t, err)
+		insideGroup, err := user.ParseIDRange(fmt.Sprintf("%s-%s", currGID, currGID))
+		require.NoError(t, err)
+
+		m := &MockSystem{}
+		u := user.NewUser(m)
+		u.Username = currUsername
+		u.State = user.StatePresent
+		u.UIDRange = inside
+		u.UIDPolicy = inside
+		u.GIDRange = insideGroup
+		u.GIDPolicy = insideGroup
+
+		m.On("Lookup", u.Username).Return(currUser, nil)
+
+		status, err := u.Check(context.Background(), fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+}
+
+// TestLocalOnly tests leaving users from a directory service alone
+func TestLocalOnly(t *testing.T) {
+	t.Parallel()
+
+	local := &fakeProvider{users: map[string]*os.User{"local": {Username: "local"}}}
+
+	t.Run("directory user absent", func(t *testing.T) {
+		m := &MockSystem{}
+		u := user.NewUser(m)
+		u.Username = "ldap"
+		u.State = user.StateAbsent
+		u.LocalOnly = true
+		u.Local = local
+
+		m.On("Lookup", u.Username).Return(&os.User{Username: u.Username}, nil)
+
+		status, err := u.Apply(context.Background())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Equal(t, "user ldap only comes from a directory service and is left alone", status.Messages()[0])
+		m.AssertNotCalled(t, "DelUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("directory user present", func(t *testing.T) {
+		m := &MockSystem{}
+		u := user.NewUser(m)
+		u.Username = "ldap"
+		u.State = user.StatePresent
+		u.LocalOnly = true
+		u.Local = local
+
+		m.On("Lookup", u.Username).Return(&os.User{Username: u.Username}, nil)
+
+		status, err := u.Check(context.Background(), fakerenderer.New())
+		assert.EqualError(t, err, "user ldap comes from a directory service and cannot be managed locally")
+		assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+	})
+
+	t.Run("directory user without local_only", func(t *testing.T) {
+		m := &MockSystem{}
+		u := user.NewUser(m)
+		u.Username = "ldap"
+		u.State = user.StateAbsent
+		u.Local = local
+
+		m.On("Lookup", u.Username).Return(&os.User{Username: u.Username}, nil)
+
+		status, err := u.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+	})
+
+	t.Run("local user", func(t *testing.T) {
+		m := &MockSystem{}
+		u := user.NewUser(m)
+		u.Username = "local"
+		u.State = user.StateAbsent
+		u.LocalOnly = true
+		u.Local = local
+
+		m.On("Lookup", u.Username).Return(&os.User{Username: u.Username}, nil)
+
+		status, err := u.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "delete user", status.Messages()[0])
+	})
+
+	t.Run("missing user", func(t *testing.T) {
+		m := &MockSystem{}
+		u := user.NewUser(m)
+		u.Username = "missing"
+		u.State = user.StateAbsent
+		u.LocalOnly = true
+		u.Local = local
+
+		m.On("Lookup", u.Username).Return((*os.User)(nil), os.UnknownUserError(u.Username))
+
+		status, err := u.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+}
+
+// TestParseIDRange tests parsing uid and gid ranges
+func TestParseIDRange(t *testing.T) {
+	t.Parallel()
+
+	r, err := user.ParseIDRange("2000-2999")
+	require.NoError(t, err)
+	assert.Equal(t, &user.IDRange{Min: 2000, Max: 2999}, r)
+	assert.Equal(t, "2000-2999", r.String())
+	assert.True(t, r.Contains("2000"))
+	assert.True(t, r.Contains("2999"))
+	assert.False(t, r.Contains("3000"))
+	assert.False(t, r.Contains("x"))
+	assert.True(t, r.Within(&user.IDRange{Min: 1000, Max: 2999}))
+	assert.False(t, r.Within(&user.IDRange{Min: 2500, Max: 3999}))
+
+	for in, msg := range map[string]string{
+		"2000":         `range "2000" must be in the form min-max`,
+		"2000-x":       `range "2000-x" has an invalid bound "x"`,
+		"3000-2000":    `range "3000-2000" starts after it ends`,
+		"0-4294967295": `range "0-4294967295" out of range`,
+		"-1-2":         `range "-1-2" must be in the form min-max`,
+	} {
+		_, err := user.ParseIDRange(in)
+		assert.EqualError(t, err, msg, in)
+	}
+}
+
+// setUid is used to find a uid that exists, but is not
+// a match for the current user name (currUsername).
+func setUid() (string, error) {
+	for i := 0; i <= maxUID; i++ {
+		uid := strconv.Itoa(i)
+		user, err := os.LookupId(uid)
+		if err == nil && user.Username != currUsername {
+			return uid, nil
+		}
+	}
+	return "", fmt.Errorf("setUid: could not set uid")
+}
+
+// setFakeUid is used to set a uid that does not exist.
+func setFakeUid() (string, error) {
+	for i := minUID; i <= maxUID; i++ {
+		uid := strconv.Itoa(i)
+		_, err := os.LookupId(uid)
+		if err != nil {
+			return uid, nil
+		}
+	}
+	return "", fmt.Errorf("setFakeUid: could not set uid")
+}
+
+// setGid is used to find a gid that exists, but is not
+// the gid for the current user.
+func setGid() (string, error) {
+	for i := 0; i <= maxGID; i++ {
+		gid := strconv.Itoa(i)
+		_, err := os.LookupGroupId(gid)
+		if err == nil && gid != currGID {
+			return gid, nil
+		}
+	}
+	return "", fmt.Errorf("setGid: could not set gid")
+}
+
+// setFakeGid is used to set a gid that does not exist.
+func setFakeGid() (string, error) {
+	for i := minGID; i <= maxGID; i++ {
+		gid := strconv.Itoa(i)
+		_, err := os.LookupGroupId(gid)
+		if err != nil {
+			return gid, nil
+		}
+	}
+	return "", fmt.Errorf("setFakeGid: could not set gid")
+}
+
+// MockSystem is a mock implementation of user.System
+type MockSystem struct {
+	mock.Mock
+}
+
+// AddUser adds a user
+func (m *MockSystem) AddUser(name string, options *user.AddUserOptions) error {
+	args := m.Called(name, options)
+	return args.Error(0)
+}
+
+// DelUser deletes a user
+func (m *MockSystem) DelUser(name string, options *user.DelUserOptions) error {
+	args := m.Called(name, options)
+	return args.Error(0)
+}
+
+// ModUser modifies a user
+func (m *MockSystem) ModUser(name string, options *user.ModUserOptions) error {
+	args := m.Called(name, options)
+	return args.Error(0)
+}
+
+// LookupUserExpiry looks up a user's expiry
+func (m *MockSystem) LookupUserExpiry(name string) (time.Time, error) {
+	args := m.Called(name)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+// LookupUserLogin looks up a user's login state
+func (m *MockSystem) LookupUserLogin(name string) (*user.Login, error) {
+	args := m.Called(name)
+	return args.Get(0).(*user.Login), args.Error(1)
+}
+
+// LookupUserShell looks up a user's login shell
+func (m *MockSystem) LookupUserShell(name string) (string, error) {
+	args := m.Called(name)
+	return args.String(0), args.Error(1)
+}
+
+// LookupUserGroups looks up the supplementary groups of a user
+func (m *MockSystem) LookupUserGroups(name string) ([]string, error) {
+	args := m.Called(name)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// LookupUserPassword looks up the hashed password of a user
+func (m *MockSystem) LookupUserPassword(name string) (string, error) {
+	args := m.Called(name)
+	return args.String(0), args.Error(1)
+}
+
+// LookupUserAging looks up the password aging of a user
+func (m *MockSystem) LookupUserAging(name string) (*user.Aging, error) {
+	args := m.Called(name)
+	return args.Get(0).(*user.Aging), args.Error(1)
+}
+
+// SetUserAging sets the password aging of a user
+func (m *MockSystem) SetUserAging(name string, aging *user.Aging) error {
+	args := m.Called(name, aging)
+	return args.Error(0)
+}
+
+// LookupUserComment looks up the whole comment of a user
+func (m *MockSystem) LookupUserComment(name string) (string, error) {
+	args := m.Called(name)
+	return args.String(0), args.Error(1)
+}
+
+// LookupHome looks up a home directory
+func (m *MockSystem) LookupHome(dir string) (*user.Home, error) {
+	args := m.Called(dir)
+	return args.Get(0).(*user.Home), args.Error(1)
+}
+
+// CreateHome creates a home directory
+func (m *MockSystem) CreateHome(dir, skelDir, uid, gid string) error {
+	args := m.Called(dir, skelDir, uid, gid)
+	return args.Error(0)
+}
+
+// ChownHome changes the owner of a home directory
+func (m *MockSystem) ChownHome(dir, uid, gid string) error {
+	args := m.Called(dir, uid, gid)
+	return args.Error(0)
+}
+
+// ChownHomeTree changes the owner of a home directory and its contents
+func (m *MockSystem) ChownHomeTree(dir, uid, gid string) error {
+	args := m.Called(dir, uid, gid)
+	return args.Error(0)
+}
+
+// Lookup looks up a user by name
+func (m *MockSystem) Lookup(name string) (*os.User, error) {
+	args := m.Called(name)
+	return args.Get(0).(*os.User), args.Error(1)
+}
+
+// LookupID looks up a user by ID
+func (m *MockSystem) LookupID(uid string) (*os.User, error) {
+	args := m.Called(uid)
+	return args.Get(0).(*os.User), args.Error(1)
+}
+
+// LookupGroup looks up a group by name
+func (m *MockSystem) LookupGroup(name string) (*os.Group, error) {
+	args := m.Called(name)
+	return args.Get(0).(*os.Group), args.Error(1)
+}
+
+// LookupGroupID looks up a group by ID
+func (m *MockSystem) LookupGroupID(gid string) (*os.Group, error) {
+	args := m.Called(gid)
+	return args.Get(0).(*os.Group), args.Error(1)
+}
+
+// MockSystem2 is a mock implementation of user.System, with a special
+// implementation of Lookup
+type MockSystem2 struct {
+	mock.Mock
+	MockSystem
+	userBeforeAdd bool
+}
+
+// Lookup looks up a user by name
+// Based on the value of userBeforeAdd, a user either before the add or after
+// the add is returned
+func (m *MockSystem2) Lookup(name string) (*os.User, error) {
+	args := m.Called(name)
+	if m.userBeforeAdd {
+		m.userBeforeAdd = false
+		return args.Get(0).(*os.User), args.Error(2)
+	}
+	return args.Get(1).(*os.User), args.Error(2)
+}
+
+// AddUser adds a user
+func (m *MockSystem2) AddUser(name string, options *user.AddUserOptions) error {
+	args := m.Called(name, options)
+	return args.Error(0)
+}
+
+// fakeProvider is a user database with a fixed set of users
+type fakeProvider struct {
+	users map[string]*os.User
+}
+
+// Lookup looks up a user by name
+func (f *fakeProvider) Lookup(userName string) (*os.User, error) {
+	if usr, ok := f.users[userName]; ok {
+		return usr, nil
+	}
+	return nil, os.UnknownUserError(userName)
 }
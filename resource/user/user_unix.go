@@ -0,0 +1,202 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux darwin freebsd
+
+package user
+
+import (
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// System implements SystemUtils
+type System struct{}
+
+// LookupHome looks up a home directory and its owner
+func (s *System) LookupHome(dir string) (*Home, error) {
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return &Home{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	home := &Home{Exists: true, IsDir: info.IsDir()}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		home.UID = strconv.FormatUint(uint64(stat.Uid), 10)
+		home.GID = strconv.FormatUint(uint64(stat.Gid), 10)
+	}
+
+	return home, nil
+}
+
+// CreateHome creates a home directory owned by the given uid and gid, and
+// copies the contents of the skeleton directory into it like useradd does
+func (s *System) CreateHome(dir, skelDir, uid, gid string) error {
+	owner, group, err := parseOwner(uid, gid)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return errors.Wrap(err, "create home parent")
+	}
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return errors.Wrap(err, "create home")
+	}
+	if err := os.Chown(dir, owner, group); err != nil {
+		return errors.Wrap(err, "chown home")
+	}
+
+	if skelDir == "" {
+		if _, err := os.Stat(defaultSkelDir); err != nil {
+			return nil
+		}
+		skelDir = defaultSkelDir
+	}
+
+	return errors.Wrap(copySkel(skelDir, dir, owner, group), "copy skeleton")
+}
+
+// ChownHome changes the owner of a home directory. The contents of the
+// directory are left alone.
+func (s *System) ChownHome(dir, uid, gid string) error {
+	owner, group, err := parseOwner(uid, gid)
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrap(os.Chown(dir, owner, group), "chown home")
+}
+
+// ChownHomeTree changes the owner of a home directory and everything in it.
+// Symlinks are changed themselves rather than what they point to.
+func (s *System) ChownHomeTree(dir, uid, gid string) error {
+	owner, group, err := parseOwner(uid, gid)
+	if err != nil {
+		return err
+	}
+
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return errors.Wrap(os.Lchown(path, owner, group), "chown home")
+	})
+}
+
+// Lookup looks up a user by name
+// If the user cannot be found an error is returned
+func (s *System) Lookup(userName string) (*user.User, error) {
+	return user.Lookup(userName)
+}
+
+// LookupID looks up a user by uid
+// If the user cannot be found an error is returned
+func (s *System) LookupID(userID string) (*user.User, error) {
+	return user.LookupId(userID)
+}
+
+// LookupGroup looks up a group by name
+// If the group cannot be found an error is returned
+func (s *System) LookupGroup(groupName string) (*user.Group, error) {
+	return user.LookupGroup(groupName)
+}
+
+// LookupGroupID looks up a group by gid
+// If the group cannot be found an error is returned
+func (s *System) LookupGroupID(groupID string) (*user.Group, error) {
+	return user.LookupGroupId(groupID)
+}
+
+// parseOwner converts a uid and gid to their numeric values
+func parseOwner(uid, gid string) (int, int, error) {
+	owner, err := strconv.Atoi(uid)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "invalid uid %q", uid)
+	}
+
+	group, err := strconv.Atoi(gid)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "invalid gid %q", gid)
+	}
+
+	return owner, group, nil
+}
+
+// copySkel copies the contents of a skeleton directory into a home directory,
+// giving everything to the owner of the home directory
+func copySkel(skelDir, dir string, owner, group int) error {
+	return filepath.Walk(skelDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(skelDir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		dest := filepath.Join(dir, rel)
+
+		switch {
+		case info.IsDir():
+			if err := os.Mkdir(dest, info.Mode().Perm()); err != nil {
+				return err
+			}
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(target, dest); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			if err := copyFile(path, dest, info.Mode().Perm()); err != nil {
+				return err
+			}
+		default:
+			// like useradd, skip devices, sockets and pipes
+			return nil
+		}
+
+		return os.Lchown(dest, owner, group)
+	})
+}
+
+func copyFile(src, dest string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
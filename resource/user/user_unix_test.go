@@ -0,0 +1,98 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux darwin freebsd
+
+package user
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateHome(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "converge-user")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	skel := filepath.Join(tmp, "skel")
+	require.NoError(t, os.MkdirAll(filepath.Join(skel, ".config"), 0750))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(skel, ".profile"), []byte("PATH=/bin"), 0644))
+	require.NoError(t, os.Symlink(".profile", filepath.Join(skel, ".bash_profile")))
+
+	uid := strconv.Itoa(os.Getuid())
+	gid := strconv.Itoa(os.Getgid())
+	home := filepath.Join(tmp, "home", "test")
+
+	s := new(System)
+	require.NoError(t, s.CreateHome(home, skel, uid, gid))
+
+	stat, err := s.LookupHome(home)
+	require.NoError(t, err)
+	assert.Equal(t, &Home{Exists: true, IsDir: true, UID: uid, GID: gid}, stat)
+
+	content, err := ioutil.ReadFile(filepath.Join(home, ".profile"))
+	require.NoError(t, err)
+	assert.Equal(t, "PATH=/bin", string(content))
+
+	target, err := os.Readlink(filepath.Join(home, ".bash_profile"))
+	require.NoError(t, err)
+	assert.Equal(t, ".profile", target)
+
+	info, err := os.Stat(filepath.Join(home, ".config"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), info.Mode().Perm())
+
+	// an existing home is never overwritten
+	assert.Error(t, s.CreateHome(home, skel, uid, gid))
+}
+
+func TestLookupHomeMissing(t *testing.T) {
+	t.Parallel()
+
+	stat, err := new(System).LookupHome("/nonexistent/converge/home")
+	require.NoError(t, err)
+	assert.False(t, stat.Exists)
+}
+
+func TestChownHomeTree(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "converge-user")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tmp, ".config"), 0750))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmp, ".config", "app"), []byte("x"), 0644))
+	require.NoError(t, os.Symlink("missing", filepath.Join(tmp, ".dangling")))
+
+	uid := strconv.Itoa(os.Getuid())
+	gid := strconv.Itoa(os.Getgid())
+
+	s := new(System)
+	require.NoError(t, s.ChownHomeTree(tmp, uid, gid))
+
+	// dangling symlinks are changed without following them
+	assert.NoError(t, s.ChownHomeTree(filepath.Join(tmp, ".dangling"), uid, gid))
+
+	assert.Error(t, s.ChownHomeTree(filepath.Join(tmp, "nonexistent"), uid, gid))
+}
@@ -0,0 +1,196 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// DefaultAddress is the Consul agent used when neither the address parameter
+// nor CONSUL_HTTP_ADDR is set
+const DefaultAddress = "127.0.0.1:8500"
+
+// KVPair is an entry in the Consul KV store
+type KVPair struct {
+	Key string
+
+	// Value is base64-encoded by Consul and decoded by encoding/json
+	Value []byte
+
+	// Session holds the lock on the key, if any
+	Session string
+}
+
+// Client is the part of the Consul HTTP API used to wait on keys and locks
+type Client struct {
+	Address string
+	Token   string
+	HTTP    *http.Client
+}
+
+// NewClient returns a client for the agent at address, falling back to
+// CONSUL_HTTP_ADDR and then DefaultAddress. The token falls back to
+// CONSUL_HTTP_TOKEN.
+func NewClient(address, token string) *Client {
+	if address == "" {
+		address = os.Getenv("CONSUL_HTTP_ADDR")
+	}
+	if address == "" {
+		address = DefaultAddress
+	}
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+
+	if token == "" {
+		token = os.Getenv("CONSUL_HTTP_TOKEN")
+	}
+
+	return &Client{
+		Address: strings.TrimRight(address, "/"),
+		Token:   token,
+		HTTP:    http.DefaultClient,
+	}
+}
+
+// Get returns the entry for a key, or nil if the key doesn't exist
+func (c *Client) Get(ctx context.Context, key string) (*KVPair, error) {
+	resp, err := c.do(ctx, "GET", "/v1/kv/"+strings.TrimLeft(key, "/"), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var pairs []*KVPair
+	if err := json.NewDecoder(resp.Body).Decode(&pairs); err != nil {
+		return nil, errors.Wrapf(err, "could not read key %q", key)
+	}
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	return pairs[0], nil
+}
+
+// CreateSession creates a session that releases its locks if it isn't renewed
+// within ttl
+func (c *Client) CreateSession(ctx context.Context, name string, ttl time.Duration) (string, error) {
+	body, err := json.Marshal(map[string]string{
+		"Name":      name,
+		"TTL":       ttl.String(),
+		"Behavior":  "release",
+		"LockDelay": "0s",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.do(ctx, "PUT", "/v1/session/create", nil, body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return "", err
+	}
+
+	var out struct{ ID string }
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", errors.Wrap(err, "could not read session")
+	}
+	return out.ID, nil
+}
+
+// DestroySession destroys a session, releasing its locks
+func (c *Client) DestroySession(ctx context.Context, id string) error {
+	resp, err := c.do(ctx, "PUT", "/v1/session/destroy/"+id, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp)
+}
+
+// Acquire tries to lock a key for a session, setting its value. It is false
+// if another session holds the lock.
+func (c *Client) Acquire(ctx context.Context, key, session string, value []byte) (bool, error) {
+	query := url.Values{"acquire": {session}}
+	resp, err := c.do(ctx, "PUT", "/v1/kv/"+strings.TrimLeft(key, "/"), query, value)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return false, err
+	}
+
+	var acquired bool
+	if err := json.NewDecoder(resp.Body).Decode(&acquired); err != nil {
+		return false, errors.Wrapf(err, "could not read lock result for %q", key)
+	}
+	return acquired, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body []byte) (*http.Response, error) {
+	target := c.Address + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("X-Consul-Token", c.Token)
+	}
+
+	resp, err := ctxhttp.Do(ctx, c.HTTP, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not reach consul")
+	}
+	return resp, nil
+}
+
+// checkResponse turns responses other than 200 into errors
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("consul: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+}
@@ -0,0 +1,117 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// fakeConsul serves the parts of the Consul API the wait resources use
+type fakeConsul struct {
+	*httptest.Server
+
+	lock     sync.Mutex
+	values   map[string]string
+	holders  map[string]string
+	sessions map[string]bool
+	created  int
+}
+
+func newFakeConsul() *fakeConsul {
+	f := &fakeConsul{
+		values:   map[string]string{},
+		holders:  map[string]string{},
+		sessions: map[string]bool{},
+	}
+	f.Server = httptest.NewServer(f)
+	return f
+}
+
+func (f *fakeConsul) set(key, value, session string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.values[key] = value
+	if session != "" {
+		f.sessions[session] = true
+		f.holders[key] = session
+	}
+}
+
+func (f *fakeConsul) liveSessions() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return len(f.sessions)
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	switch {
+	case r.Method == "PUT" && r.URL.Path == "/v1/session/create":
+		f.created++
+		id := fmt.Sprintf("session-%d", f.created)
+		f.sessions[id] = true
+		json.NewEncoder(w).Encode(map[string]string{"ID": id})
+
+	case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/v1/session/destroy/"):
+		id := strings.TrimPrefix(r.URL.Path, "/v1/session/destroy/")
+		delete(f.sessions, id)
+		for key, holder := range f.holders {
+			if holder == id {
+				delete(f.holders, key)
+			}
+		}
+		w.Write([]byte("true"))
+
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/v1/kv/"):
+		key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+		value, ok := f.values[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"Key": key, "Value": []byte(value), "Session": f.holders[key]},
+		})
+
+	case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/v1/kv/"):
+		key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+		session := r.URL.Query().Get("acquire")
+		if !f.sessions[session] {
+			http.Error(w, "invalid session", http.StatusInternalServerError)
+			return
+		}
+		if holder, ok := f.holders[key]; ok && holder != session {
+			w.Write([]byte("false"))
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		f.values[key] = string(body)
+		f.holders[key] = session
+		w.Write([]byte("true"))
+
+	default:
+		http.NotFound(w, r)
+	}
+}
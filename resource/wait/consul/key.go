@@ -0,0 +1,104 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul
+
+import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/wait"
+	"golang.org/x/net/context"
+)
+
+// Key waits for a Consul key to have a value
+type Key struct {
+	*wait.Retrier
+
+	// the key to wait on
+	Key string `export:"key"`
+
+	// the value to wait for. When empty, the key only has to exist.
+	Value string `export:"value"`
+
+	// the value of the key when it was last checked
+	Current string `export:"current"`
+
+	client *Client
+}
+
+// Check if the key has the value
+func (k *Key) Check(ctx context.Context, _ resource.Renderer) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	ok, err := k.matches(ctx)
+	switch {
+	case err != nil:
+		status.RaiseLevel(resource.StatusWillChange)
+		status.AddMessage(fmt.Sprintf("Failed to read %s: %s", k.Key, err))
+	case !ok:
+		status.RaiseLevel(resource.StatusWillChange)
+		status.AddMessage(k.waitingFor())
+	}
+
+	if k.RetryCount > 0 {
+		if ok {
+			status.AddMessage(fmt.Sprintf("Passed after %d retries (%v)", k.RetryCount, k.Duration))
+		} else {
+			status.AddMessage(fmt.Sprintf("Failed after %d retries (%v)", k.RetryCount, k.Duration))
+		}
+	}
+
+	return status, nil
+}
+
+// Apply retries the check until it passes or returns max failure threshold
+func (k *Key) Apply(ctx context.Context) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	_, err := k.RetryUntil(func() (bool, error) {
+		ok, err := k.matches(ctx)
+		if err != nil {
+			// consul may just not be reachable yet, so keep waiting
+			log.WithField("module", "wait.consul_key").WithError(err).Debug("could not read key")
+			return false, nil
+		}
+		return ok, nil
+	})
+
+	return status, err
+}
+
+// matches reads the key and reports whether it has the value
+func (k *Key) matches(ctx context.Context) (bool, error) {
+	pair, err := k.client.Get(ctx, k.Key)
+	if err != nil {
+		return false, err
+	}
+	if pair == nil {
+		k.Current = ""
+		return false, nil
+	}
+
+	k.Current = string(pair.Value)
+	return k.Value == "" || k.Current == k.Value, nil
+}
+
+func (k *Key) waitingFor() string {
+	if k.Value == "" {
+		return fmt.Sprintf("%s does not exist", k.Key)
+	}
+	return fmt.Sprintf("%s is %q, waiting for %q", k.Key, k.Current, k.Value)
+}
@@ -0,0 +1,119 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/wait/consul"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// TestKeyInterface tests that Key is properly implemented
+func TestKeyInterface(t *testing.T) {
+	t.Parallel()
+	assert.Implements(t, (*resource.Task)(nil), new(consul.Key))
+}
+
+// TestKey tests waiting for a key
+func TestKey(t *testing.T) {
+	t.Parallel()
+	defer logging.HideLogs(t)()
+
+	fake := newFakeConsul()
+	defer fake.Close()
+
+	interval, retries := time.Millisecond, 3
+	prepare := func(key, value string) *consul.Key {
+		p := &consul.KeyPreparer{Key: key, Value: value, Address: fake.URL, Interval: &interval, MaxRetry: &retries}
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		return task.(*consul.Key)
+	}
+
+	fake.set("deploy/schema", "pending", "")
+
+	t.Run("waiting", func(t *testing.T) {
+		key := prepare("deploy/schema", "migrated")
+
+		status, err := key.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		assert.Equal(t, []string{`deploy/schema is "pending", waiting for "migrated"`}, status.Messages())
+		assert.Equal(t, "pending", key.Current)
+
+		_, err = key.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 3, key.RetryCount)
+
+		status, err = key.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		assert.Contains(t, status.Messages()[1], "Failed after 3 retries")
+	})
+
+	t.Run("has value", func(t *testing.T) {
+		key := prepare("deploy/schema", "pending")
+
+		status, err := key.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusNoChange, status.StatusCode())
+	})
+
+	t.Run("exists", func(t *testing.T) {
+		status, err := prepare("deploy/schema", "").Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusNoChange, status.StatusCode())
+
+		status, err = prepare("deploy/missing", "").Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		assert.Equal(t, []string{"deploy/missing does not exist"}, status.Messages())
+	})
+
+	t.Run("becomes ready", func(t *testing.T) {
+		key := prepare("deploy/ready", "yes")
+
+		go func() {
+			time.Sleep(time.Millisecond)
+			fake.set("deploy/ready", "yes", "")
+		}()
+
+		key.MaxRetry = 1000
+		_, err := key.Apply(context.Background())
+		require.NoError(t, err)
+
+		status, err := key.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusNoChange, status.StatusCode())
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		p := &consul.KeyPreparer{Key: "deploy/schema", Address: "127.0.0.1:1"}
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		status, err := task.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		assert.Contains(t, status.Messages()[0], "Failed to read deploy/schema")
+	})
+}
@@ -0,0 +1,122 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/wait"
+	"golang.org/x/net/context"
+)
+
+// Lock waits to acquire a Consul lock and holds it, so only one host at a
+// time gets past it. The lock is released when the hold period runs out,
+// since nothing renews the session behind it.
+type Lock struct {
+	*wait.Retrier
+
+	// the key used as the lock
+	Key string `export:"key"`
+
+	// identifies this host as the holder of the lock
+	Holder string `export:"holder"`
+
+	// how long the lock is held once acquired
+	Hold time.Duration `export:"hold"`
+
+	// the holder of the lock when it was last checked
+	HeldBy string `export:"held_by"`
+
+	// the session holding the lock, once acquired
+	Session string `export:"session"`
+
+	client *Client
+}
+
+// Check if this host holds the lock
+func (l *Lock) Check(ctx context.Context, _ resource.Renderer) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	held, err := l.held(ctx)
+	switch {
+	case err != nil:
+		status.RaiseLevel(resource.StatusWillChange)
+		status.AddMessage(fmt.Sprintf("Failed to read %s: %s", l.Key, err))
+	case held:
+		status.AddMessage(fmt.Sprintf("%s is held by %s", l.Key, l.Holder))
+	case l.HeldBy != "":
+		status.RaiseLevel(resource.StatusWillChange)
+		status.AddMessage(fmt.Sprintf("%s is held by %s, waiting for it to be released", l.Key, l.HeldBy))
+	default:
+		status.RaiseLevel(resource.StatusWillChange)
+		status.AddMessage(fmt.Sprintf("%s is free", l.Key))
+	}
+
+	if l.RetryCount > 0 && !held {
+		status.AddMessage(fmt.Sprintf("Failed after %d retries (%v)", l.RetryCount, l.Duration))
+	}
+
+	return status, nil
+}
+
+// Apply retries acquiring the lock until it's acquired or returns max failure
+// threshold
+func (l *Lock) Apply(ctx context.Context) (resource.TaskStatus, error) {
+	status := resource.NewStatus()
+
+	session, err := l.client.CreateSession(ctx, fmt.Sprintf("converge: %s for %s", l.Key, l.Holder), l.Hold)
+	if err != nil {
+		return status, err
+	}
+
+	acquired, err := l.RetryUntil(func() (bool, error) {
+		ok, err := l.client.Acquire(ctx, l.Key, session, []byte(l.Holder))
+		if err != nil {
+			log.WithField("module", "wait.lock").WithError(err).Debug("could not acquire lock")
+			return false, nil
+		}
+		return ok, nil
+	})
+
+	if err != nil || !acquired {
+		if derr := l.client.DestroySession(ctx, session); derr != nil {
+			log.WithField("module", "wait.lock").WithError(derr).Warn("could not destroy session")
+		}
+		return status, err
+	}
+
+	l.Session = session
+	status.AddMessage(fmt.Sprintf("Acquired %s until %s", l.Key, time.Now().Add(l.Hold).Format(time.RFC3339)))
+	return status, nil
+}
+
+// held reads the lock and reports whether this host holds it
+func (l *Lock) held(ctx context.Context) (bool, error) {
+	pair, err := l.client.Get(ctx, l.Key)
+	if err != nil {
+		return false, err
+	}
+
+	l.HeldBy = ""
+	if pair == nil || pair.Session == "" {
+		return false, nil
+	}
+
+	l.HeldBy = string(pair.Value)
+	return pair.Session == l.Session || l.HeldBy == l.Holder, nil
+}
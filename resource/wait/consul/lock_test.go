@@ -0,0 +1,98 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/wait/consul"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// TestLockInterface tests that Lock is properly implemented
+func TestLockInterface(t *testing.T) {
+	t.Parallel()
+	assert.Implements(t, (*resource.Task)(nil), new(consul.Lock))
+}
+
+// TestLock tests acquiring a lock
+func TestLock(t *testing.T) {
+	t.Parallel()
+	defer logging.HideLogs(t)()
+
+	fake := newFakeConsul()
+	defer fake.Close()
+
+	interval, retries := time.Millisecond, 3
+	prepare := func(key, holder string) *consul.Lock {
+		p := &consul.LockPreparer{Key: key, Holder: holder, Address: fake.URL, Interval: &interval, MaxRetry: &retries}
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		return task.(*consul.Lock)
+	}
+
+	t.Run("free", func(t *testing.T) {
+		lock := prepare("locks/free", "web1")
+
+		status, err := lock.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		assert.Equal(t, []string{"locks/free is free"}, status.Messages())
+
+		_, err = lock.Apply(context.Background())
+		require.NoError(t, err)
+		assert.NotEmpty(t, lock.Session)
+
+		status, err = lock.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusNoChange, status.StatusCode())
+		assert.Equal(t, "web1", lock.HeldBy)
+
+		t.Run("same holder", func(t *testing.T) {
+			status, err := prepare("locks/free", "web1").Check(context.Background(), fakerenderer.New())
+			require.NoError(t, err)
+			assert.Equal(t, resource.StatusNoChange, status.StatusCode())
+		})
+	})
+
+	t.Run("held", func(t *testing.T) {
+		fake.set("locks/held", "db1", "other")
+		before := fake.liveSessions()
+
+		lock := prepare("locks/held", "db2")
+
+		status, err := lock.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		assert.Equal(t, []string{"locks/held is held by db1, waiting for it to be released"}, status.Messages())
+
+		_, err = lock.Apply(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "", lock.Session)
+		assert.Equal(t, 3, lock.RetryCount)
+		assert.Equal(t, before, fake.liveSessions(), "the session should be destroyed")
+
+		status, err = lock.Check(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		assert.Contains(t, status.Messages()[1], "Failed after 3 retries")
+	})
+}
@@ -0,0 +1,147 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/wait"
+	"golang.org/x/net/context"
+)
+
+const (
+	// DefaultHold is how long a lock is held if no hold is specified
+	DefaultHold = 15 * time.Minute
+
+	// MinHold and MaxHold are the session TTLs Consul allows
+	MinHold = 10 * time.Second
+	MaxHold = 24 * time.Hour
+)
+
+// KeyPreparer handles wait.consul_key tasks
+type KeyPreparer struct {
+	// the key to wait on
+	Key string `hcl:"key" required:"true" nonempty:"true"`
+
+	// the value to wait for. If no value is specified, the key only has to
+	// exist.
+	Value string `hcl:"value"`
+
+	// the address of the Consul agent. If no address is specified,
+	// CONSUL_HTTP_ADDR is used, and then 127.0.0.1:8500.
+	Address string `hcl:"address"`
+
+	// the ACL token to use. If no token is specified, CONSUL_HTTP_TOKEN is
+	// used.
+	Token string `hcl:"token"`
+
+	// the amount of time to wait in between checks. If the interval is not
+	// specified, it will default to 5 seconds.
+	Interval *time.Duration `hcl:"interval"`
+
+	// the amount of time to wait before running the first check and after a
+	// successful check. If no grace period is specified, no grace period will be
+	// taken into account.
+	GracePeriod *time.Duration `hcl:"grace_period"`
+
+	// the maximum number of attempts before the wait fails. If the maximum number
+	// of retries is not set, it will default to 5.
+	MaxRetry *int `hcl:"max_retry"`
+}
+
+// Prepare creates a new wait.consul_key type
+func (p *KeyPreparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	return &Key{
+		Key:     p.Key,
+		Value:   p.Value,
+		Retrier: wait.PrepareRetrier(p.Interval, p.GracePeriod, p.MaxRetry),
+		client:  NewClient(p.Address, p.Token),
+	}, nil
+}
+
+// LockPreparer handles wait.lock tasks
+type LockPreparer struct {
+	// the key to use as the lock. Every host that should take turns needs to use
+	// the same key.
+	Key string `hcl:"key" required:"true" nonempty:"true"`
+
+	// how long the lock is held once acquired. Nothing renews the lock, so it is
+	// released when this runs out, even if the run is still going. Consul allows
+	// between 10 seconds and 24 hours. If no hold is specified, it will default
+	// to 15 minutes.
+	Hold *time.Duration `hcl:"hold"`
+
+	// identifies this host as the holder of the lock. If no holder is specified,
+	// the hostname is used.
+	Holder string `hcl:"holder"`
+
+	// the address of the Consul agent. If no address is specified,
+	// CONSUL_HTTP_ADDR is used, and then 127.0.0.1:8500.
+	Address string `hcl:"address"`
+
+	// the ACL token to use. If no token is specified, CONSUL_HTTP_TOKEN is
+	// used.
+	Token string `hcl:"token"`
+
+	// the amount of time to wait in between attempts to acquire the lock. If the
+	// interval is not specified, it will default to 5 seconds.
+	Interval *time.Duration `hcl:"interval"`
+
+	// the amount of time to wait before the first attempt and after acquiring
+	// the lock. If no grace period is specified, no grace period will be taken
+	// into account.
+	GracePeriod *time.Duration `hcl:"grace_period"`
+
+	// the maximum number of attempts before the wait fails. If the maximum number
+	// of retries is not set, it will default to 5.
+	MaxRetry *int `hcl:"max_retry"`
+}
+
+// Prepare creates a new wait.lock type
+func (p *LockPreparer) Prepare(ctx context.Context, render resource.Renderer) (resource.Task, error) {
+	hold := DefaultHold
+	if p.Hold != nil {
+		hold = *p.Hold
+	}
+	if hold < MinHold || hold > MaxHold {
+		return nil, fmt.Errorf("hold must be between %s and %s", MinHold, MaxHold)
+	}
+
+	holder := p.Holder
+	if holder == "" {
+		var err error
+		if holder, err = os.Hostname(); err != nil {
+			return nil, errors.New("holder is required when the hostname can't be determined")
+		}
+	}
+
+	return &Lock{
+		Key:     p.Key,
+		Holder:  holder,
+		Hold:    hold,
+		Retrier: wait.PrepareRetrier(p.Interval, p.GracePeriod, p.MaxRetry),
+		client:  NewClient(p.Address, p.Token),
+	}, nil
+}
+
+func init() {
+	registry.Register("wait.consul_key", (*KeyPreparer)(nil), (*Key)(nil))
+	registry.Register("wait.lock", (*LockPreparer)(nil), (*Lock)(nil))
+}
@@ -0,0 +1,72 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/wait/consul"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+// TestPreparerInterface tests that the preparers are properly implemented
+func TestPreparerInterface(t *testing.T) {
+	t.Parallel()
+	assert.Implements(t, (*resource.Resource)(nil), new(consul.KeyPreparer))
+	assert.Implements(t, (*resource.Resource)(nil), new(consul.LockPreparer))
+}
+
+// TestLockPreparerPrepare tests that Prepare initializes a Lock correctly
+func TestLockPreparerPrepare(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		p := &consul.LockPreparer{Key: "locks/x"}
+		task, err := p.Prepare(context.Background(), fakerenderer.New())
+		require.NoError(t, err)
+
+		lock := task.(*consul.Lock)
+		hostname, _ := os.Hostname()
+		assert.Equal(t, hostname, lock.Holder)
+		assert.Equal(t, consul.DefaultHold, lock.Hold)
+		assert.NotNil(t, lock.Retrier)
+	})
+
+	t.Run("hold out of range", func(t *testing.T) {
+		for _, hold := range []time.Duration{time.Second, 25 * time.Hour} {
+			p := &consul.LockPreparer{Key: "locks/x", Hold: &hold}
+			_, err := p.Prepare(context.Background(), fakerenderer.New())
+			assert.EqualError(t, err, "hold must be between 10s and 24h0m0s")
+		}
+	})
+}
+
+// TestNewClient tests how the Consul address and token are found
+func TestNewClient(t *testing.T) {
+	t.Parallel()
+
+	client := consul.NewClient("consul.example.com:8500", "secret")
+	assert.Equal(t, "http://consul.example.com:8500", client.Address)
+	assert.Equal(t, "secret", client.Token)
+
+	client = consul.NewClient("https://consul.example.com/", "")
+	assert.Equal(t, "https://consul.example.com", client.Address)
+}
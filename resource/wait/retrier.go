@@ -44,6 +44,8 @@ type RetryFunc func() (bool, error)
 func (r *Retrier) RetryUntil(retryFunc RetryFunc) (bool, error) {
 	startTime := time.Now()
 
+	time.Sleep(r.GracePeriod)
+
 	for {
 		ok, err := retryFunc()
 		if err != nil {
@@ -56,7 +58,7 @@ func (r *Retrier) RetryUntil(retryFunc RetryFunc) (bool, error) {
 			if r.RetryCount >= r.MaxRetry {
 				return false, nil
 			}
-			time.Sleep(r.GracePeriod)
+			time.Sleep(r.Interval)
 		} else {
 			break
 		}
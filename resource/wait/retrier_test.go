@@ -65,6 +65,31 @@ func TestRetryUntil(t *testing.T) {
 		assert.Equal(t, 3, r.RetryCount)
 	})
 
+	t.Run("waits interval between attempts", func(t *testing.T) {
+		r := &wait.Retrier{
+			Interval: 20 * time.Millisecond,
+			MaxRetry: 3,
+		}
+		start := time.Now()
+		r.RetryUntil(func() (bool, error) { return false, nil })
+		assert.True(t, time.Since(start) >= 40*time.Millisecond)
+	})
+
+	t.Run("waits grace period before first attempt", func(t *testing.T) {
+		r := &wait.Retrier{
+			GracePeriod: 50 * time.Millisecond,
+			Interval:    time.Millisecond,
+			MaxRetry:    1,
+		}
+		var first time.Duration
+		start := time.Now()
+		r.RetryUntil(func() (bool, error) {
+			first = time.Since(start)
+			return true, nil
+		})
+		assert.True(t, first >= 50*time.Millisecond)
+	})
+
 	t.Run("sets duration", func(t *testing.T) {
 		r := &wait.Retrier{
 			Interval: 100 * time.Millisecond,
@@ -0,0 +1,80 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import "fmt"
+
+// WarningKind categorizes a Warning
+type WarningKind string
+
+const (
+	// WarningResource is a warning reported by a resource while checking or
+	// applying
+	WarningResource WarningKind = "resource"
+
+	// WarningDeprecation indicates use of a deprecated feature
+	WarningDeprecation WarningKind = "deprecation"
+
+	// WarningSkipped indicates that a node was not run
+	WarningSkipped WarningKind = "skipped"
+
+	// WarningUnusedParam indicates a parameter that no module consumed
+	WarningUnusedParam WarningKind = "unused-param"
+
+	// WarningPolicy indicates a policy that failed without being enforced
+	WarningPolicy WarningKind = "policy"
+
+	// WarningQuarantined indicates that a node was not run because it, or the
+	// host, is quarantined
+	WarningQuarantined WarningKind = "quarantined"
+
+	// WarningDeferred indicates that a node's changes were not applied because
+	// its change window was closed
+	WarningDeferred WarningKind = "deferred"
+)
+
+// Warning is a problem that doesn't stop execution but should be reported to
+// the user
+type Warning struct {
+	Kind    WarningKind `json:"kind"`
+	Message string      `json:"message"`
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s", w.Kind, w.Message)
+}
+
+// WarningReporter is implemented by statuses that report categorized warnings
+type WarningReporter interface {
+	Warnings() []Warning
+}
+
+// StatusWarnings gets the warnings from a TaskStatus. Statuses that only
+// report a single warning message get it categorized as WarningResource.
+func StatusWarnings(status TaskStatus) []Warning {
+	if status == nil {
+		return nil
+	}
+
+	if reporter, ok := status.(WarningReporter); ok {
+		return reporter.Warnings()
+	}
+
+	if warning := status.Warning(); warning != "" {
+		return []Warning{{Kind: WarningResource, Message: warning}}
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusWarnings(t *testing.T) {
+	t.Parallel()
+
+	t.Run("none", func(t *testing.T) {
+		status := resource.NewStatus()
+
+		assert.Equal(t, "", status.Warning())
+		assert.Empty(t, status.Warnings())
+		assert.Empty(t, resource.StatusWarnings(status))
+	})
+
+	t.Run("set", func(t *testing.T) {
+		status := resource.NewStatus()
+		status.SetWarning("careful")
+
+		assert.Equal(t, "careful", status.Warning())
+		assert.Equal(
+			t,
+			[]resource.Warning{{Kind: resource.WarningResource, Message: "careful"}},
+			status.Warnings(),
+		)
+	})
+
+	t.Run("added", func(t *testing.T) {
+		status := resource.NewStatus()
+		status.AddWarning(resource.WarningDeprecation, "x is deprecated")
+		status.AddWarning(resource.WarningPolicy, "y is not allowed")
+
+		assert.Equal(t, "x is deprecated; y is not allowed", status.Warning())
+		assert.Equal(
+			t,
+			[]resource.Warning{
+				{Kind: resource.WarningDeprecation, Message: "x is deprecated"},
+				{Kind: resource.WarningPolicy, Message: "y is not allowed"},
+			},
+			resource.StatusWarnings(status),
+		)
+	})
+
+	t.Run("nil status", func(t *testing.T) {
+		assert.Nil(t, resource.StatusWarnings(nil))
+	})
+}
@@ -0,0 +1,102 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// downloadPrefix is where the REST gateway serves modules and the binary
+	downloadPrefix = "/api/v1/resources/"
+
+	// minCompressSize is the smallest download worth compressing
+	minCompressSize = 1024
+)
+
+// DownloadHandler serves module and binary downloads so they can be compressed
+// and resumed. The gateway's response is buffered and served with an ETag, so
+// range requests can pick up an interrupted download where it stopped. Whole
+// downloads are gzipped for clients that accept it.
+func DownloadHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || !strings.HasPrefix(r.URL.Path, downloadPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		buf := newBufferedResponse()
+		next.ServeHTTP(buf, r)
+
+		for key, values := range buf.header {
+			w.Header()[key] = values
+		}
+
+		if buf.code != http.StatusOK {
+			w.WriteHeader(buf.code)
+			w.Write(buf.body.Bytes())
+			return
+		}
+
+		sum := sha256.Sum256(buf.body.Bytes())
+		w.Header().Set("ETag", fmt.Sprintf(`"%x"`, sum))
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Add("Vary", "Accept-Encoding")
+		w.Header().Del("Content-Length")
+
+		if r.Header.Get("Range") == "" && acceptsGzip(r) && buf.body.Len() >= minCompressSize {
+			w.Header().Set("Content-Encoding", "gzip")
+
+			gz := gzip.NewWriter(w)
+			gz.Write(buf.body.Bytes())
+			gz.Close()
+			return
+		}
+
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(buf.body.Bytes()))
+	})
+}
+
+// acceptsGzip checks whether the client accepts gzipped responses
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		encoding = strings.TrimSpace(strings.SplitN(encoding, ";", 2)[0])
+		if encoding == "gzip" {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedResponse holds a response in memory
+type bufferedResponse struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func newBufferedResponse() *bufferedResponse {
+	return &bufferedResponse{header: http.Header{}, code: http.StatusOK}
+}
+
+func (b *bufferedResponse) Header() http.Header         { return b.header }
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+func (b *bufferedResponse) WriteHeader(code int)        { b.code = code }
@@ -0,0 +1,131 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc_test
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadHandler(t *testing.T) {
+	t.Parallel()
+
+	module := strings.Repeat("task x { check = \"true\" }\n", 100)
+
+	server := httptest.NewServer(rpc.DownloadHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "missing.hcl") {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(module))
+	})))
+	defer server.Close()
+
+	get := func(t *testing.T, path string, header map[string]string) *http.Response {
+		req, err := http.NewRequest("GET", server.URL+path, nil)
+		require.NoError(t, err)
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+
+		// the transport is told not to decompress, so the encoding can be seen
+		resp, err := (&http.Transport{DisableCompression: true}).RoundTrip(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("compressed", func(t *testing.T) {
+		resp := get(t, "/api/v1/resources/modules/x.hcl", map[string]string{"Accept-Encoding": "gzip"})
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+		assert.Equal(t, "bytes", resp.Header.Get("Accept-Ranges"))
+		assert.NotEmpty(t, resp.Header.Get("ETag"))
+
+		gz, err := gzip.NewReader(resp.Body)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(gz)
+		require.NoError(t, err)
+		assert.Equal(t, module, string(body))
+	})
+
+	t.Run("uncompressed", func(t *testing.T) {
+		resp := get(t, "/api/v1/resources/modules/x.hcl", nil)
+		defer resp.Body.Close()
+
+		assert.Empty(t, resp.Header.Get("Content-Encoding"))
+		assert.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, module, string(body))
+	})
+
+	t.Run("range", func(t *testing.T) {
+		full := get(t, "/api/v1/resources/modules/x.hcl", nil)
+		full.Body.Close()
+
+		resp := get(t, "/api/v1/resources/modules/x.hcl", map[string]string{
+			"Accept-Encoding": "gzip",
+			"Range":           "bytes=10-",
+			"If-Range":        full.Header.Get("ETag"),
+		})
+		defer resp.Body.Close()
+
+		// ranges are never compressed, so offsets are in the original content
+		assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Content-Encoding"))
+
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, module[10:], string(body))
+	})
+
+	t.Run("changed since", func(t *testing.T) {
+		resp := get(t, "/api/v1/resources/modules/x.hcl", map[string]string{
+			"Range":    "bytes=10-",
+			"If-Range": `"stale"`,
+		})
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		resp := get(t, "/api/v1/resources/modules/missing.hcl", map[string]string{"Accept-Encoding": "gzip"})
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("ETag"))
+	})
+
+	t.Run("other paths", func(t *testing.T) {
+		resp := get(t, "/api/v1/info", map[string]string{"Accept-Encoding": "gzip"})
+		defer resp.Body.Close()
+
+		assert.Empty(t, resp.Header.Get("Content-Encoding"))
+		assert.Empty(t, resp.Header.Get("ETag"))
+	})
+}
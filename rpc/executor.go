@@ -16,27 +16,89 @@ package rpc
 
 import (
 	"encoding/json"
+	"os"
+	"sync"
+	"time"
 
 	"google.golang.org/grpc/metadata"
 
 	"github.com/asteris-llc/converge/apply"
+	"github.com/asteris-llc/converge/artifact"
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/healthcheck"
+	"github.com/asteris-llc/converge/journal"
 	"github.com/asteris-llc/converge/plan"
 	"github.com/asteris-llc/converge/prettyprinters/human"
+	"github.com/asteris-llc/converge/publish"
+	"github.com/asteris-llc/converge/render"
 	"github.com/asteris-llc/converge/rpc/pb"
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 )
 
-type executor struct{}
+type executor struct {
+	loadConfig
+
+	// journalDir is where apply journals are written. Journaling is disabled
+	// when it is empty.
+	journalDir string
+
+	// publisher receives a summary of every apply, if set
+	publisher publish.Publisher
+
+	// artifactProxy is the artifact cache downloads go through, if set
+	artifactProxy string
+
+	// artifactProxyToken is the token of the artifact cache, if it needs one
+	artifactProxyToken string
+
+	// shutdown is cancelled when the server is shutting down
+	shutdown context.Context
+
+	// running tracks executions in flight
+	running *sync.WaitGroup
+}
 
 type statusResponseStream interface {
 	Send(*pb.StatusResponse) error
 	SendHeader(metadata.MD) error
 }
 
+// begin sets up an execution. It returns a context that is cancelled when
+// either the request is cancelled or the server shuts down, and the execution
+// counts as running until done is called. Executions stop scheduling new work
+// when the context is cancelled, and report what they managed to do.
+func (e *executor) begin(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	if e.artifactProxy != "" {
+		ctx = artifact.WithProxy(ctx, e.artifactProxy)
+		if e.artifactProxyToken != "" {
+			ctx = artifact.WithProxyToken(ctx, NewJWTAuth(e.artifactProxyToken).New)
+		}
+	}
+	if e.running != nil {
+		e.running.Add(1)
+	}
+
+	if e.shutdown != nil {
+		go func() {
+			select {
+			case <-e.shutdown.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	return ctx, func() {
+		cancel()
+		if e.running != nil {
+			e.running.Done()
+		}
+	}
+}
+
 func (e *executor) edgeMeta(ctx context.Context, g *graph.Graph) (metadata.MD, error) {
 	logger := getLogger(ctx).WithField("function", "executor.edgeMeta")
 
@@ -68,9 +130,18 @@ func (e *executor) sendMeta(ctx context.Context, g *graph.Graph, stream statusRe
 }
 
 func (e *executor) stageNotifier(stage pb.StatusResponse_Stage, stream statusResponseStream) *graph.Notifier {
+	// nodes run in parallel, but a stream can only be sent to from one
+	// goroutine at a time
+	var lock sync.Mutex
+	send := func(response *pb.StatusResponse) error {
+		lock.Lock()
+		defer lock.Unlock()
+		return stream.Send(response)
+	}
+
 	return &graph.Notifier{
 		Pre: func(meta *node.Node) error {
-			return stream.Send(&pb.StatusResponse{
+			return send(&pb.StatusResponse{
 				Id:    meta.ID, // TODO: deprecated, remove in 0.4.0
 				Stage: stage,
 				Run:   pb.StatusResponse_STARTED,
@@ -85,7 +156,19 @@ func (e *executor) stageNotifier(stage pb.StatusResponse_Stage, stream statusRes
 				pb.StatusResponse_FINISHED,
 			)
 
-			return stream.Send(response)
+			return send(response)
+		},
+		Heartbeat: func(meta *node.Node, elapsed time.Duration, output string) error {
+			return send(&pb.StatusResponse{
+				Id:    meta.ID, // TODO: deprecated, remove in 0.4.0
+				Stage: stage,
+				Run:   pb.StatusResponse_RUNNING,
+				Meta:  pb.MetaFromNode(meta),
+				Heartbeat: &pb.StatusResponse_Heartbeat{
+					Elapsed: int64(elapsed),
+					Output:  output,
+				},
+			})
 		},
 	}
 }
@@ -99,10 +182,13 @@ func (e *executor) sendPlan(ctx context.Context, stream statusResponseStream, in
 }
 
 func (e *executor) Plan(in *pb.LoadRequest, stream pb.Executor_PlanServer) error {
-	logger, ctx := setIDLogger(stream.Context())
+	ctx, done := e.begin(stream.Context())
+	defer done()
+
+	logger, ctx := setIDLogger(ctx)
 	logger = logger.WithField("function", "executor.Plan")
 
-	loaded, err := in.Load(ctx)
+	loaded, err := e.load(ctx, in)
 	if err != nil {
 		return err
 	}
@@ -111,6 +197,9 @@ func (e *executor) Plan(in *pb.LoadRequest, stream pb.Executor_PlanServer) error
 		return err
 	}
 
+	ctx = withParallelism(ctx, in)
+	ctx = withStrictRender(ctx, in)
+
 	// send the plan
 	_, err = e.sendPlan(ctx, stream, loaded)
 	if err != nil {
@@ -130,10 +219,13 @@ func (e *executor) sendHealthCheck(ctx context.Context, stream statusResponseStr
 }
 
 func (e *executor) HealthCheck(in *pb.LoadRequest, stream pb.Executor_HealthCheckServer) error {
-	logger, ctx := setIDLogger(stream.Context())
+	ctx, done := e.begin(stream.Context())
+	defer done()
+
+	logger, ctx := setIDLogger(ctx)
 	logger = logger.WithField("function", "executor.Plan")
 
-	loaded, err := in.Load(ctx)
+	loaded, err := e.load(ctx, in)
 	if err != nil {
 		return err
 	}
@@ -142,6 +234,9 @@ func (e *executor) HealthCheck(in *pb.LoadRequest, stream pb.Executor_HealthChec
 		return err
 	}
 
+	ctx = withParallelism(ctx, in)
+	ctx = withStrictRender(ctx, in)
+
 	// send the plan
 	planned, err := e.sendPlan(ctx, stream, loaded)
 	if err != nil {
@@ -158,19 +253,96 @@ func (e *executor) HealthCheck(in *pb.LoadRequest, stream pb.Executor_HealthChec
 	return nil
 }
 
-func (e *executor) sendApply(ctx context.Context, stream statusResponseStream, in *graph.Graph) (*graph.Graph, error) {
-	out, err := apply.WithNotify(ctx, in, e.stageNotifier(pb.StatusResponse_APPLY, stream))
+func (e *executor) sendApply(ctx context.Context, stream statusResponseStream, in *graph.Graph, heartbeat time.Duration, j *journal.Journal) (*graph.Graph, error) {
+	notify := e.stageNotifier(pb.StatusResponse_APPLY, stream)
+	notify.HeartbeatInterval = heartbeat
+	if j != nil {
+		notify = j.Notifier(notify)
+	}
+
+	out, err := apply.WithNotify(ctx, in, notify)
 	if err != nil && err != apply.ErrTreeContainsErrors {
 		return nil, err
 	}
 	return out, nil
 }
 
+// startJournal starts an apply journal if journaling is enabled. Failing to
+// start a journal is not fatal to the run.
+func (e *executor) startJournal(ctx context.Context, location string) *journal.Journal {
+	if e.journalDir == "" {
+		return nil
+	}
+
+	logger := getLogger(ctx).WithField("function", "executor.startJournal")
+
+	j, err := journal.Create(e.journalDir, location)
+	if err != nil {
+		logger.WithError(err).Warn("could not start journal, continuing without one")
+		return nil
+	}
+
+	logger.WithField("journal", j.Path).Debug("journaling apply")
+	return j
+}
+
+// publishTimeout bounds how long a run waits for its summary to be published
+const publishTimeout = 10 * time.Second
+
+// publish publishes a summary of an apply if publishing is enabled. Failing to
+// publish is not fatal to the run.
+func (e *executor) publish(ctx context.Context, location string, applied *graph.Graph, started time.Time, runErr error) {
+	if e.publisher == nil {
+		return
+	}
+
+	logger := getLogger(ctx).WithField("function", "executor.publish")
+
+	host, err := os.Hostname()
+	if err != nil {
+		logger.WithError(err).Warn("could not determine hostname, not publishing summary")
+		return
+	}
+
+	// the run's context may already be cancelled, but what happened should
+	// still be published
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	summary := publish.Summarize(host, location, applied, started, runErr)
+	if err := e.publisher.Publish(ctx, summary); err != nil {
+		logger.WithError(err).Warn("could not publish summary")
+		return
+	}
+
+	logger.WithField("changed", summary.Changed).WithField("failed", summary.Failed).Debug("published summary")
+}
+
+// withParallelism limits how many nodes are executed at once, if requested
+func withParallelism(ctx context.Context, in *pb.LoadRequest) context.Context {
+	if in.Parallelism <= 0 {
+		return ctx
+	}
+	return graph.WithParallelism(ctx, int(in.Parallelism))
+}
+
+// withStrictRender renders templates strictly during planning too, if
+// requested
+func withStrictRender(ctx context.Context, in *pb.LoadRequest) context.Context {
+	if !in.StrictRender {
+		return ctx
+	}
+	return render.WithStrict(ctx, true)
+}
+
 func (e *executor) Apply(in *pb.LoadRequest, stream pb.Executor_ApplyServer) error {
-	logger, ctx := setIDLogger(stream.Context())
+	ctx, done := e.begin(stream.Context())
+	defer done()
+
+	logger, ctx := setIDLogger(ctx)
 	logger = logger.WithField("function", "executor.Apply")
 
-	loaded, err := in.Load(ctx)
+	loaded, err := e.load(ctx, in)
 	if err != nil {
 		return err
 	}
@@ -179,7 +351,26 @@ func (e *executor) Apply(in *pb.LoadRequest, stream pb.Executor_ApplyServer) err
 		return err
 	}
 
-	_, err = e.sendApply(ctx, stream, loaded)
+	ctx = withParallelism(ctx, in)
+	ctx = withStrictRender(ctx, in)
+
+	if in.AllowDestructive {
+		ctx = apply.WithAllowDestructive(ctx)
+	}
+
+	j := e.startJournal(ctx, in.Location)
+
+	started := time.Now()
+	applied, err := e.sendApply(ctx, stream, loaded, time.Duration(in.Heartbeat)*time.Second, j)
+
+	if j != nil {
+		if jerr := j.Finish(err); jerr != nil {
+			logger.WithError(jerr).WithField("journal", j.Path).Warn("could not finish journal")
+		}
+	}
+
+	e.publish(ctx, in.Location, applied, started, err)
+
 	if err != nil {
 		return errors.Wrapf(err, "applying %s", in.Location)
 	}
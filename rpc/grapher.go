@@ -25,14 +25,16 @@ import (
 	"github.com/pkg/errors"
 )
 
-type grapher struct{}
+type grapher struct {
+	loadConfig
+}
 
 // Graph returns the information about a graph
 func (g *grapher) Graph(in *pb.LoadRequest, stream pb.Grapher_GraphServer) error {
 	logger, ctx := setIDLogger(stream.Context())
 	logger = logger.WithField("function", "grapher.Graph")
 
-	loaded, err := in.Load(ctx)
+	loaded, err := g.load(ctx, in)
 	if err != nil {
 		logger.WithError(err).Error("loading failed")
 		return errors.Wrap(err, "loading failed")
@@ -73,13 +75,14 @@ func (g *grapher) Graph(in *pb.LoadRequest, stream pb.Grapher_GraphServer) error
 	}
 
 	for _, edge := range loaded.Edges() {
-		err = stream.Send(
-			pb.NewGraphComponent(&pb.GraphComponent_Edge{
-				Source:     edge.Source,
-				Dest:       edge.Dest,
-				Attributes: edge.Attributes,
-			}),
-		)
+		component := &pb.GraphComponent_Edge{
+			Source:     edge.Source,
+			Dest:       edge.Dest,
+			Attributes: edge.Attributes,
+		}
+		component.SetReasons(edge.Reasons)
+
+		err = stream.Send(pb.NewGraphComponent(component))
 		if err != nil {
 			logger.WithError(err).WithField("edge", edge).Error("failed to send edge")
 			return errors.Wrapf(err, "failed to send %s", edge)
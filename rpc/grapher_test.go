@@ -58,7 +58,7 @@ func TestGrapherGraph(t *testing.T) {
 			t,
 			err,
 			fmt.Sprintf(
-				"loading failed: loading %s: loading failed: file://%s: open %s: no such file or directory",
+				"loading failed: [CONVERGE-LOAD-001] loading %s: loading failed: file://%s: open %s: no such file or directory",
 				filename,
 				filename,
 				filename,
@@ -80,7 +80,7 @@ func (gc *GrapherClient) Graph(ctx context.Context, loc *pb.LoadRequest, opts ..
 			if parent {
 				g.ConnectParent(edge.Source, edge.Dest)
 			} else {
-				g.Connect(edge.Source, edge.Dest)
+				g.ConnectBecause(edge.Source, edge.Dest, edge.EdgeReasons()...)
 			}
 		}
 	}
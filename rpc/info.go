@@ -15,6 +15,7 @@
 package rpc
 
 import (
+	"github.com/asteris-llc/converge/rpc/pb"
 	"github.com/golang/protobuf/ptypes/empty"
 	"golang.org/x/net/context"
 )
@@ -24,3 +25,7 @@ type infoServer struct{}
 func (i *infoServer) Ping(context.Context, *empty.Empty) (*empty.Empty, error) {
 	return new(empty.Empty), nil
 }
+
+func (i *infoServer) ResourceSchemas(context.Context, *empty.Empty) (*pb.ResourceSchemasResponse, error) {
+	return ResourceSchemas()
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/asteris-llc/converge/rpc/pb"
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestInfoServer tests the operation of InfoServer
@@ -37,4 +38,42 @@ func TestInfoServer(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, res, new(empty.Empty))
 	})
+
+	t.Run("resource schemas", func(t *testing.T) {
+		res, err := server.ResourceSchemas(context.Background(), new(empty.Empty))
+		require.NoError(t, err)
+
+		schemas := map[string]*pb.ResourceSchema{}
+		for _, schema := range res.Resources {
+			schemas[schema.Kind] = schema
+		}
+
+		require.Contains(t, schemas, "task")
+		check := findSchemaField(schemas["task"].Fields, "check")
+		require.NotNil(t, check)
+		assert.Equal(t, "string", check.Type)
+		assert.True(t, check.Nonempty)
+		assert.Equal(t, []string{"check", "check_script"}, check.MutuallyExclusive)
+
+		require.Contains(t, schemas, "user.user")
+		username := findSchemaField(schemas["user.user"].Fields, "username")
+		require.NotNil(t, username)
+		assert.True(t, username.Required)
+
+		state := findSchemaField(schemas["user.user"].Fields, "state")
+		require.NotNil(t, state)
+		assert.Equal(t, "string", state.Type)
+		assert.Equal(t, []string{"present", "absent", "locked", "unlocked"}, state.ValidValues)
+
+		assert.NotNil(t, findSchemaField(schemas["user.user"].Exports, "username"))
+	})
+}
+
+func findSchemaField(fields []*pb.ResourceSchema_Field, name string) *pb.ResourceSchema_Field {
+	for _, field := range fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
 }
@@ -49,3 +49,12 @@ func (i *InfoClient) Ping(ctx context.Context) error {
 	_, err := i.client.Ping(ctx, new(empty.Empty))
 	return err
 }
+
+// ResourceSchemas gets the schemas of the resources the server can run
+func (i *InfoClient) ResourceSchemas(ctx context.Context) ([]*pb.ResourceSchema, error) {
+	resp, err := i.client.ResourceSchemas(ctx, new(empty.Empty))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Resources, nil
+}
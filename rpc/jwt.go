@@ -146,7 +146,7 @@ func (j *JWTAuth) Protect(next http.Handler) http.Handler {
 			token = query
 		} else if bearer := r.Header.Get("Authorization"); strings.HasPrefix(bearer, "BEARER ") {
 			token = strings.TrimLeft(bearer, "BEARER ")
-		} else if cookie, err := r.Cookie("jwt"); err != nil && cookie.Value != "" {
+		} else if cookie, err := r.Cookie("jwt"); err == nil && cookie.Value != "" {
 			token = cookie.Value
 		}
 
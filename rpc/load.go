@@ -0,0 +1,95 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"os"
+
+	"github.com/asteris-llc/converge/changewindow"
+	"github.com/asteris-llc/converge/errcode"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/snapshot"
+	"github.com/asteris-llc/converge/quarantine"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// loadConfig is the server configuration used when loading requests
+type loadConfig struct {
+	// dumpGraphDir is where graph snapshots are written after each load
+	// phase. Nothing is written when it is empty.
+	dumpGraphDir string
+
+	// quarantineFile lists hosts and resources to skip. Nothing is
+	// quarantined when it is empty.
+	quarantineFile string
+
+	// changeWindows are the change windows nodes can refer to by name
+	changeWindows map[string]string
+}
+
+// load loads the request, writing graph snapshots after each phase if
+// configured to. Resources quarantined for this host are added to the ones in
+// the request. Error codes are included in the message of any error.
+func (c loadConfig) load(ctx context.Context, in *pb.LoadRequest) (*graph.Graph, error) {
+	if c.dumpGraphDir != "" {
+		ctx = snapshot.WithDumper(ctx, snapshot.NewDumper(c.dumpGraphDir, in.Location, in.Parameters))
+	}
+
+	if c.quarantineFile != "" {
+		if err := addQuarantine(in, c.quarantineFile); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx = changewindow.WithNamed(ctx, c.changeWindows)
+
+	loaded, err := in.Load(ctx)
+
+	// the code would otherwise be lost when the error is sent to the client
+	return loaded, errcode.WithMessage(err)
+}
+
+// addQuarantine merges the patterns quarantined for this host in filename into
+// the request. The file is read on every load so entries take effect without
+// restarting the server.
+func addQuarantine(in *pb.LoadRequest, filename string) error {
+	list, err := quarantine.Load(filename)
+	if err != nil {
+		return err
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		return errors.Wrap(err, "could not get hostname for quarantine")
+	}
+
+	patterns := list.Resources(host)
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	if in.Quarantine == nil {
+		in.Quarantine = map[string]string{}
+	}
+	for pattern, reason := range patterns {
+		if _, ok := in.Quarantine[pattern]; !ok {
+			in.Quarantine[pattern] = reason
+		}
+	}
+
+	return nil
+}
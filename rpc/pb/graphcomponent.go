@@ -14,6 +14,8 @@
 
 package pb
 
+import "github.com/asteris-llc/converge/graph"
+
 // NewGraphComponent wraps the given component in a GraphComponent wrapper. If
 // the argument is not something that can be wrapped, GraphComponent will not
 // contain anything.
@@ -29,3 +31,28 @@ func NewGraphComponent(component interface{}) *GraphComponent {
 
 	return container
 }
+
+// SetReasons records why the edge was created, sending the text and kind of
+// each reason side by side
+func (e *GraphComponent_Edge) SetReasons(reasons []graph.Reason) {
+	e.Reasons = make([]string, len(reasons))
+	e.ReasonKinds = make([]string, len(reasons))
+	for idx, reason := range reasons {
+		e.Reasons[idx] = reason.Text
+		e.ReasonKinds[idx] = string(reason.Kind)
+	}
+}
+
+// EdgeReasons gets why the edge was created. Reasons sent without a kind are
+// of unknown kind.
+func (e *GraphComponent_Edge) EdgeReasons() []graph.Reason {
+	var reasons []graph.Reason
+	for idx, text := range e.Reasons {
+		kind := graph.ReasonUnknown
+		if idx < len(e.ReasonKinds) && e.ReasonKinds[idx] != "" {
+			kind = graph.ReasonKind(e.ReasonKinds[idx])
+		}
+		reasons = append(reasons, graph.Reason{Kind: kind, Text: text})
+	}
+	return reasons
+}
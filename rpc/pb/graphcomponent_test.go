@@ -0,0 +1,50 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pb
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphComponentEdgeReasons(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trip", func(t *testing.T) {
+		reasons := []graph.Reason{
+			{Kind: graph.ReasonParam, Text: `uses param "x"`},
+			{Kind: graph.ReasonLookup, Text: `looks up "task.a.status"`},
+		}
+
+		edge := new(GraphComponent_Edge)
+		edge.SetReasons(reasons)
+
+		assert.Equal(t, []string{`uses param "x"`, `looks up "task.a.status"`}, edge.Reasons)
+		assert.Equal(t, []string{"param", "lookup"}, edge.ReasonKinds)
+		assert.Equal(t, reasons, edge.EdgeReasons())
+	})
+
+	t.Run("without kinds", func(t *testing.T) {
+		edge := &GraphComponent_Edge{Reasons: []string{`uses param "x"`}}
+
+		assert.Equal(
+			t,
+			[]graph.Reason{{Kind: graph.ReasonUnknown, Text: `uses param "x"`}},
+			edge.EdgeReasons(),
+		)
+	})
+}
@@ -16,8 +16,10 @@ package pb
 
 import (
 	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/snapshot"
 	"github.com/asteris-llc/converge/helpers/logging"
 	"github.com/asteris-llc/converge/load"
+	"github.com/asteris-llc/converge/quarantine"
 	"github.com/asteris-llc/converge/render"
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
@@ -27,12 +29,28 @@ import (
 func (lr *LoadRequest) Load(ctx context.Context) (*graph.Graph, error) {
 	logger := logging.GetLogger(ctx).WithField("location", lr.Location)
 
+	if lr.StrictRender {
+		ctx = render.WithStrict(ctx, true)
+	}
+
+	if lr.StrictRefs {
+		ctx = load.WithStrictRefs(ctx, true)
+	}
+
 	loaded, err := load.Load(ctx, lr.Location, lr.Verify)
 	if err != nil {
 		logger.WithError(err).Error("could not load")
 		return nil, errors.Wrapf(err, "loading %s", lr.Location)
 	}
 
+	if len(lr.Quarantine) > 0 {
+		loaded, err = quarantine.Mark(ctx, loaded, lr.Quarantine)
+		if err != nil {
+			logger.WithError(err).Error("could not quarantine")
+			return nil, errors.Wrapf(err, "quarantining %s", lr.Location)
+		}
+	}
+
 	values := render.Values{}
 	for k, v := range lr.Parameters {
 		values[k] = v
@@ -42,6 +60,7 @@ func (lr *LoadRequest) Load(ctx context.Context) (*graph.Graph, error) {
 		logger.WithError(err).Error("could not render")
 		return nil, errors.Wrapf(err, "rendering %s", lr.Location)
 	}
+	snapshot.Dump(ctx, snapshot.PhaseRender, rendered)
 
 	merged, err := graph.MergeDuplicates(ctx, rendered, graph.SkipModuleAndParams)
 	if err != nil {
@@ -49,5 +68,10 @@ func (lr *LoadRequest) Load(ctx context.Context) (*graph.Graph, error) {
 		return nil, errors.Wrapf(err, "merging %s", lr.Location)
 	}
 
+	if len(lr.Only) > 0 {
+		logger.WithField("only", lr.Only).Debug("running only some nodes")
+		merged = graph.Subgraph(merged, lr.Only)
+	}
+
 	return merged, nil
 }
@@ -16,7 +16,9 @@ package pb
 
 import (
 	"errors"
+	"time"
 
+	"github.com/asteris-llc/converge/errcode"
 	"github.com/asteris-llc/converge/prettyprinters/human"
 	"github.com/asteris-llc/converge/resource"
 )
@@ -41,6 +43,10 @@ func (sr *StatusResponse_Details) ToPrintable() human.Printable {
 	// set up error
 	if sr.Error != "" {
 		psr.error = errors.New(sr.Error)
+
+		if sr.ErrorCode != "" {
+			psr.error = &errcode.Error{Code: errcode.Code(sr.ErrorCode), Err: psr.error}
+		}
 	}
 
 	// set up warning
@@ -48,15 +54,76 @@ func (sr *StatusResponse_Details) ToPrintable() human.Printable {
 		psr.warning = sr.Warning
 	}
 
+	// set up categorized warnings, falling back to the single warning for
+	// servers that don't send them
+	for _, warning := range sr.GetWarnings() {
+		psr.warnings = append(psr.warnings, resource.Warning{
+			Kind:    resource.WarningKind(warning.Kind),
+			Message: warning.Message,
+		})
+	}
+	if len(psr.warnings) == 0 && psr.warning != "" {
+		psr.warnings = []resource.Warning{{Kind: resource.WarningResource, Message: psr.warning}}
+	}
+
+	psr.destructive = sr.GetDestructive()
+	psr.rollbacks = sr.GetRollbacks()
+
+	for _, origin := range sr.GetProvenance() {
+		psr.provenance = append(psr.provenance, resource.Origin{
+			Field: origin.Field,
+			Kind:  resource.OriginKind(origin.Kind),
+			Name:  origin.Name,
+			Node:  origin.Node,
+			Value: origin.Value,
+		})
+	}
+
+	// set up impact, working it out from the other details for servers that
+	// don't send it
+	if impact, err := resource.ParseImpact(sr.GetImpact()); err == nil {
+		psr.impact = impact
+	} else if len(psr.destructive) > 0 {
+		psr.impact = resource.ImpactDestructive
+	} else if psr.hasChanges {
+		psr.impact = resource.ImpactLow
+	}
+
+	// set up outcome
+	psr.outcome = resource.Outcome(sr.GetOutcome())
+	if remaining := sr.GetRemainingChanges(); len(remaining) > 0 {
+		psr.remaining = map[string]resource.Diff{}
+		for k, v := range remaining {
+			psr.remaining[k] = v.ToPrintable()
+		}
+	}
+
+	// set up usage
+	if usage := sr.GetUsage(); usage != nil {
+		psr.usage = &resource.Usage{
+			CPUTime:      time.Duration(usage.CpuTime),
+			PeakRSS:      usage.PeakRSS,
+			BytesWritten: usage.BytesWritten,
+		}
+	}
+
 	return psr
 }
 
 type printableStatusResponse struct {
-	changes    map[string]resource.Diff
-	messages   []string
-	hasChanges bool
-	error      error
-	warning    string
+	changes     map[string]resource.Diff
+	messages    []string
+	hasChanges  bool
+	error       error
+	warning     string
+	warnings    []resource.Warning
+	destructive []string
+	impact      resource.Impact
+	outcome     resource.Outcome
+	remaining   map[string]resource.Diff
+	usage       *resource.Usage
+	provenance  []resource.Origin
+	rollbacks   []string
 }
 
 func (psr *printableStatusResponse) Changes() map[string]resource.Diff { return psr.changes }
@@ -64,6 +131,18 @@ func (psr *printableStatusResponse) Messages() []string                { return
 func (psr *printableStatusResponse) HasChanges() bool                  { return psr.hasChanges }
 func (psr *printableStatusResponse) Error() error                      { return psr.error }
 func (psr *printableStatusResponse) Warning() string                   { return psr.warning }
+func (psr *printableStatusResponse) Warnings() []resource.Warning      { return psr.warnings }
+func (psr *printableStatusResponse) Destructive() []string             { return psr.destructive }
+func (psr *printableStatusResponse) Impact() resource.Impact           { return psr.impact }
+func (psr *printableStatusResponse) Outcome() resource.Outcome         { return psr.outcome }
+func (psr *printableStatusResponse) RemainingChanges() map[string]resource.Diff {
+	return psr.remaining
+}
+func (psr *printableStatusResponse) Usage() *resource.Usage { return psr.usage }
+func (psr *printableStatusResponse) Provenance() []resource.Origin {
+	return psr.provenance
+}
+func (psr *printableStatusResponse) Rollbacks() []string { return psr.rollbacks }
 
 // ToPrintable returns a view that can be used in a human printer
 func (d *DiffResponse) ToPrintable() resource.Diff {
@@ -71,6 +150,7 @@ func (d *DiffResponse) ToPrintable() resource.Diff {
 		original: d.Original,
 		current:  d.Current,
 		changes:  d.Changes,
+		unified:  d.Unified,
 	}
 }
 
@@ -78,8 +158,10 @@ type printableDiff struct {
 	original string
 	current  string
 	changes  bool
+	unified  string
 }
 
 func (pd *printableDiff) Original() string { return pd.original }
 func (pd *printableDiff) Current() string  { return pd.current }
 func (pd *printableDiff) Changes() bool    { return pd.changes }
+func (pd *printableDiff) Unified() string  { return pd.unified }
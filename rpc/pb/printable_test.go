@@ -33,3 +33,118 @@ func TestPrintableDiffSatisfiesInterface(t *testing.T) {
 
 	assert.Implements(t, (*resource.Diff)(nil), new(printableDiff))
 }
+
+func TestPrintableStatusResponseUsage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("absent", func(t *testing.T) {
+		details := &StatusResponse_Details{}
+
+		reporter, ok := details.ToPrintable().(resource.UsageReporter)
+		assert.True(t, ok)
+		assert.Nil(t, reporter.Usage())
+	})
+
+	t.Run("present", func(t *testing.T) {
+		details := &StatusResponse_Details{
+			Usage: &UsageResponse{CpuTime: 1000, PeakRSS: 2048, BytesWritten: 512},
+		}
+
+		reporter, ok := details.ToPrintable().(resource.UsageReporter)
+		assert.True(t, ok)
+		assert.Equal(
+			t,
+			&resource.Usage{CPUTime: 1000, PeakRSS: 2048, BytesWritten: 512},
+			reporter.Usage(),
+		)
+	})
+}
+
+func TestPrintableStatusResponseWarnings(t *testing.T) {
+	t.Parallel()
+
+	t.Run("categorized", func(t *testing.T) {
+		details := &StatusResponse_Details{
+			Warning:  "x is deprecated",
+			Warnings: []*WarningResponse{{Kind: "deprecation", Message: "x is deprecated"}},
+		}
+
+		reporter, ok := details.ToPrintable().(resource.WarningReporter)
+		assert.True(t, ok)
+		assert.Equal(
+			t,
+			[]resource.Warning{{Kind: resource.WarningDeprecation, Message: "x is deprecated"}},
+			reporter.Warnings(),
+		)
+	})
+
+	t.Run("single warning", func(t *testing.T) {
+		details := &StatusResponse_Details{Warning: "careful"}
+
+		reporter, ok := details.ToPrintable().(resource.WarningReporter)
+		assert.True(t, ok)
+		assert.Equal(
+			t,
+			[]resource.Warning{{Kind: resource.WarningResource, Message: "careful"}},
+			reporter.Warnings(),
+		)
+	})
+}
+
+func TestPrintableStatusResponseDestructive(t *testing.T) {
+	t.Parallel()
+
+	details := &StatusResponse_Details{Destructive: []string{"user x will be deleted"}}
+
+	reporter, ok := details.ToPrintable().(resource.DestructiveReporter)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"user x will be deleted"}, reporter.Destructive())
+}
+
+func TestPrintableStatusResponseRollbacks(t *testing.T) {
+	t.Parallel()
+
+	details := &StatusResponse_Details{Rollbacks: []string{"rolled back root/config"}}
+
+	reporter, ok := details.ToPrintable().(resource.RollbackReporter)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"rolled back root/config"}, reporter.Rollbacks())
+}
+
+func TestPrintableStatusResponseImpact(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sent", func(t *testing.T) {
+		details := &StatusResponse_Details{Impact: "reboot"}
+
+		reporter, ok := details.ToPrintable().(resource.ImpactReporter)
+		assert.True(t, ok)
+		assert.Equal(t, resource.ImpactReboot, reporter.Impact())
+	})
+
+	t.Run("derived", func(t *testing.T) {
+		details := &StatusResponse_Details{Destructive: []string{"user x will be deleted"}}
+
+		reporter, ok := details.ToPrintable().(resource.ImpactReporter)
+		assert.True(t, ok)
+		assert.Equal(t, resource.ImpactDestructive, reporter.Impact())
+	})
+}
+
+func TestPrintableStatusResponseOutcome(t *testing.T) {
+	t.Parallel()
+
+	details := &StatusResponse_Details{
+		Outcome: "drifted",
+		RemainingChanges: map[string]*DiffResponse{
+			"mode": {Original: "0644", Current: "0600", Changes: true},
+		},
+	}
+
+	reporter, ok := details.ToPrintable().(resource.OutcomeReporter)
+	assert.True(t, ok)
+	assert.Equal(t, resource.OutcomeDrifted, reporter.Outcome())
+	if assert.Contains(t, reporter.RemainingChanges(), "mode") {
+		assert.Equal(t, "0600", reporter.RemainingChanges()["mode"].Current())
+	}
+}
@@ -13,7 +13,12 @@ It has these top-level messages:
 	ContentResponse
 	StatusResponse
 	DiffResponse
+	UsageResponse
+	WarningResponse
+	ProvenanceResponse
 	GraphComponent
+	ResourceSchema
+	ResourceSchemasResponse
 */
 package pb
 
@@ -71,17 +76,21 @@ const (
 	StatusResponse_UNSPECIFIED_RUN StatusResponse_Run = 0
 	StatusResponse_STARTED         StatusResponse_Run = 1
 	StatusResponse_FINISHED        StatusResponse_Run = 2
+	// the node is still running
+	StatusResponse_RUNNING StatusResponse_Run = 3
 )
 
 var StatusResponse_Run_name = map[int32]string{
 	0: "UNSPECIFIED_RUN",
 	1: "STARTED",
 	2: "FINISHED",
+	3: "RUNNING",
 }
 var StatusResponse_Run_value = map[string]int32{
 	"UNSPECIFIED_RUN": 0,
 	"STARTED":         1,
 	"FINISHED":        2,
+	"RUNNING":         3,
 }
 
 func (x StatusResponse_Run) String() string {
@@ -93,6 +102,25 @@ type LoadRequest struct {
 	Location   string            `protobuf:"bytes,1,opt,name=location" json:"location,omitempty"`
 	Parameters map[string]string `protobuf:"bytes,2,rep,name=parameters" json:"parameters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	Verify     bool              `protobuf:"varint,3,opt,name=verify" json:"verify,omitempty"`
+	// apply destructive changes even on nodes without `allow_destructive = true`
+	AllowDestructive bool `protobuf:"varint,4,opt,name=allowDestructive" json:"allowDestructive,omitempty"`
+	// the most nodes to check or apply at once. Zero means no limit.
+	Parallelism int32 `protobuf:"varint,5,opt,name=parallelism" json:"parallelism,omitempty"`
+	// only run the nodes with these IDs, and what they need to run. Empty runs
+	// every node.
+	Only []string `protobuf:"bytes,6,rep,name=only" json:"only,omitempty"`
+	// skip the nodes matching these patterns, reporting them as quarantined.
+	// Values are the reasons for quarantining.
+	Quarantine map[string]string `protobuf:"bytes,7,rep,name=quarantine" json:"quarantine,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// make templates referring to missing values errors, instead of rendering
+	// "<no value>"
+	StrictRender bool `protobuf:"varint,8,opt,name=strictRender" json:"strictRender,omitempty"`
+	// seconds a node runs before heartbeats are sent for it, and between
+	// heartbeats. Zero means no heartbeats.
+	Heartbeat int32 `protobuf:"varint,9,opt,name=heartbeat" json:"heartbeat,omitempty"`
+	// make references that could resolve to more than one node errors, instead
+	// of resolving them to the first candidate
+	StrictRefs bool `protobuf:"varint,10,opt,name=strictRefs" json:"strictRefs,omitempty"`
 }
 
 func (m *LoadRequest) Reset()                    { *m = LoadRequest{} }
@@ -121,6 +149,55 @@ func (m *LoadRequest) GetVerify() bool {
 	return false
 }
 
+func (m *LoadRequest) GetAllowDestructive() bool {
+	if m != nil {
+		return m.AllowDestructive
+	}
+	return false
+}
+
+func (m *LoadRequest) GetParallelism() int32 {
+	if m != nil {
+		return m.Parallelism
+	}
+	return 0
+}
+
+func (m *LoadRequest) GetOnly() []string {
+	if m != nil {
+		return m.Only
+	}
+	return nil
+}
+
+func (m *LoadRequest) GetQuarantine() map[string]string {
+	if m != nil {
+		return m.Quarantine
+	}
+	return nil
+}
+
+func (m *LoadRequest) GetStrictRender() bool {
+	if m != nil {
+		return m.StrictRender
+	}
+	return false
+}
+
+func (m *LoadRequest) GetHeartbeat() int32 {
+	if m != nil {
+		return m.Heartbeat
+	}
+	return 0
+}
+
+func (m *LoadRequest) GetStrictRefs() bool {
+	if m != nil {
+		return m.StrictRefs
+	}
+	return false
+}
+
 type ContentResponse struct {
 	Content string `protobuf:"bytes,1,opt,name=content" json:"content,omitempty"`
 }
@@ -140,11 +217,12 @@ func (m *ContentResponse) GetContent() string {
 type StatusResponse struct {
 	// TODO: preserve for compat but will stop working in 0.4.0. This has moved to
 	// meta.id
-	Id      string                  `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
-	Stage   StatusResponse_Stage    `protobuf:"varint,2,opt,name=stage,enum=pb.StatusResponse_Stage" json:"stage,omitempty"`
-	Run     StatusResponse_Run      `protobuf:"varint,3,opt,name=run,enum=pb.StatusResponse_Run" json:"run,omitempty"`
-	Details *StatusResponse_Details `protobuf:"bytes,4,opt,name=details" json:"details,omitempty"`
-	Meta    *StatusResponse_Meta    `protobuf:"bytes,5,opt,name=meta" json:"meta,omitempty"`
+	Id        string                    `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	Stage     StatusResponse_Stage      `protobuf:"varint,2,opt,name=stage,enum=pb.StatusResponse_Stage" json:"stage,omitempty"`
+	Run       StatusResponse_Run        `protobuf:"varint,3,opt,name=run,enum=pb.StatusResponse_Run" json:"run,omitempty"`
+	Details   *StatusResponse_Details   `protobuf:"bytes,4,opt,name=details" json:"details,omitempty"`
+	Meta      *StatusResponse_Meta      `protobuf:"bytes,5,opt,name=meta" json:"meta,omitempty"`
+	Heartbeat *StatusResponse_Heartbeat `protobuf:"bytes,6,opt,name=heartbeat" json:"heartbeat,omitempty"`
 }
 
 func (m *StatusResponse) Reset()                    { *m = StatusResponse{} }
@@ -187,6 +265,13 @@ func (m *StatusResponse) GetMeta() *StatusResponse_Meta {
 	return nil
 }
 
+func (m *StatusResponse) GetHeartbeat() *StatusResponse_Heartbeat {
+	if m != nil {
+		return m.Heartbeat
+	}
+	return nil
+}
+
 // the informational message, if present
 type StatusResponse_Details struct {
 	Messages   []string                 `protobuf:"bytes,1,rep,name=messages" json:"messages,omitempty"`
@@ -194,6 +279,21 @@ type StatusResponse_Details struct {
 	HasChanges bool                     `protobuf:"varint,3,opt,name=hasChanges" json:"hasChanges,omitempty"`
 	Error      string                   `protobuf:"bytes,4,opt,name=error" json:"error,omitempty"`
 	Warning    string                   `protobuf:"bytes,5,opt,name=warning" json:"warning,omitempty"`
+	Usage      *UsageResponse           `protobuf:"bytes,6,opt,name=usage" json:"usage,omitempty"`
+	Warnings   []*WarningResponse       `protobuf:"bytes,7,rep,name=warnings" json:"warnings,omitempty"`
+	ErrorCode  string                   `protobuf:"bytes,8,opt,name=errorCode" json:"errorCode,omitempty"`
+	// why the node's changes are destructive, if they are
+	Destructive []string `protobuf:"bytes,9,rep,name=destructive" json:"destructive,omitempty"`
+	// how disruptive the node's changes are, like "low" or "reboot"
+	Impact string `protobuf:"bytes,10,opt,name=impact" json:"impact,omitempty"`
+	// how applying the node turned out, like "changed" or "drifted"
+	Outcome string `protobuf:"bytes,11,opt,name=outcome" json:"outcome,omitempty"`
+	// the changes found when checking the node again after applying it
+	RemainingChanges map[string]*DiffResponse `protobuf:"bytes,12,rep,name=remainingChanges" json:"remainingChanges,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// where the values rendered into the node's fields came from
+	Provenance []*ProvenanceResponse `protobuf:"bytes,13,rep,name=provenance" json:"provenance,omitempty"`
+	// how the transaction the node failed in was rolled back
+	Rollbacks []string `protobuf:"bytes,14,rep,name=rollbacks" json:"rollbacks,omitempty"`
 }
 
 func (m *StatusResponse_Details) Reset()                    { *m = StatusResponse_Details{} }
@@ -229,6 +329,76 @@ func (m *StatusResponse_Details) GetError() string {
 	return ""
 }
 
+func (m *StatusResponse_Details) GetWarning() string {
+	if m != nil {
+		return m.Warning
+	}
+	return ""
+}
+
+func (m *StatusResponse_Details) GetUsage() *UsageResponse {
+	if m != nil {
+		return m.Usage
+	}
+	return nil
+}
+
+func (m *StatusResponse_Details) GetWarnings() []*WarningResponse {
+	if m != nil {
+		return m.Warnings
+	}
+	return nil
+}
+
+func (m *StatusResponse_Details) GetErrorCode() string {
+	if m != nil {
+		return m.ErrorCode
+	}
+	return ""
+}
+
+func (m *StatusResponse_Details) GetDestructive() []string {
+	if m != nil {
+		return m.Destructive
+	}
+	return nil
+}
+
+func (m *StatusResponse_Details) GetImpact() string {
+	if m != nil {
+		return m.Impact
+	}
+	return ""
+}
+
+func (m *StatusResponse_Details) GetOutcome() string {
+	if m != nil {
+		return m.Outcome
+	}
+	return ""
+}
+
+func (m *StatusResponse_Details) GetRemainingChanges() map[string]*DiffResponse {
+	if m != nil {
+		return m.RemainingChanges
+	}
+	return nil
+}
+
+func (m *StatusResponse_Details) GetProvenance() []*ProvenanceResponse {
+	if m != nil {
+		return m.Provenance
+	}
+	return nil
+}
+
+func (m *StatusResponse_Details) GetRollbacks() []string {
+	if m != nil {
+		return m.Rollbacks
+	}
+	return nil
+}
+
 type StatusResponse_Meta struct {
 	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
 }
@@ -245,10 +415,39 @@ func (m *StatusResponse_Meta) GetId() string {
 	return ""
 }
 
+// how a node that is still running is getting along
+type StatusResponse_Heartbeat struct {
+	// how long the node has been running, in nanoseconds
+	Elapsed int64 `protobuf:"varint,1,opt,name=elapsed" json:"elapsed,omitempty"`
+	// the last line of output from the node, if it has any
+	Output string `protobuf:"bytes,2,opt,name=output" json:"output,omitempty"`
+}
+
+func (m *StatusResponse_Heartbeat) Reset()                    { *m = StatusResponse_Heartbeat{} }
+func (m *StatusResponse_Heartbeat) String() string            { return proto.CompactTextString(m) }
+func (*StatusResponse_Heartbeat) ProtoMessage()               {}
+func (*StatusResponse_Heartbeat) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2, 2} }
+
+func (m *StatusResponse_Heartbeat) GetElapsed() int64 {
+	if m != nil {
+		return m.Elapsed
+	}
+	return 0
+}
+
+func (m *StatusResponse_Heartbeat) GetOutput() string {
+	if m != nil {
+		return m.Output
+	}
+	return ""
+}
+
 type DiffResponse struct {
 	Original string `protobuf:"bytes,1,opt,name=original" json:"original,omitempty"`
 	Current  string `protobuf:"bytes,2,opt,name=current" json:"current,omitempty"`
 	Changes  bool   `protobuf:"varint,3,opt,name=changes" json:"changes,omitempty"`
+	// a unified diff of the change, for diffs that can produce one
+	Unified string `protobuf:"bytes,4,opt,name=unified" json:"unified,omitempty"`
 }
 
 func (m *DiffResponse) Reset()                    { *m = DiffResponse{} }
@@ -277,6 +476,130 @@ func (m *DiffResponse) GetChanges() bool {
 	return false
 }
 
+func (m *DiffResponse) GetUnified() string {
+	if m != nil {
+		return m.Unified
+	}
+	return ""
+}
+
+// resources consumed by a node during application
+type UsageResponse struct {
+	// CPU time of spawned subprocesses, in nanoseconds
+	CpuTime int64 `protobuf:"varint,1,opt,name=cpuTime" json:"cpuTime,omitempty"`
+	// peak resident set size of spawned subprocesses, in bytes
+	PeakRSS int64 `protobuf:"varint,2,opt,name=peakRSS" json:"peakRSS,omitempty"`
+	// bytes written to disk
+	BytesWritten int64 `protobuf:"varint,3,opt,name=bytesWritten" json:"bytesWritten,omitempty"`
+}
+
+func (m *UsageResponse) Reset()                    { *m = UsageResponse{} }
+func (m *UsageResponse) String() string            { return proto.CompactTextString(m) }
+func (*UsageResponse) ProtoMessage()               {}
+func (*UsageResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *UsageResponse) GetCpuTime() int64 {
+	if m != nil {
+		return m.CpuTime
+	}
+	return 0
+}
+
+func (m *UsageResponse) GetPeakRSS() int64 {
+	if m != nil {
+		return m.PeakRSS
+	}
+	return 0
+}
+
+func (m *UsageResponse) GetBytesWritten() int64 {
+	if m != nil {
+		return m.BytesWritten
+	}
+	return 0
+}
+
+// a warning raised by a node
+type WarningResponse struct {
+	// the category of the warning, like "deprecation" or "skipped"
+	Kind string `protobuf:"bytes,1,opt,name=kind" json:"kind,omitempty"`
+	// the warning message
+	Message string `protobuf:"bytes,2,opt,name=message" json:"message,omitempty"`
+}
+
+func (m *WarningResponse) Reset()                    { *m = WarningResponse{} }
+func (m *WarningResponse) String() string            { return proto.CompactTextString(m) }
+func (*WarningResponse) ProtoMessage()               {}
+func (*WarningResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *WarningResponse) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *WarningResponse) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+// a source that contributed to the value of a rendered field
+type ProvenanceResponse struct {
+	// the field the value was rendered into
+	Field string `protobuf:"bytes,1,opt,name=field" json:"field,omitempty"`
+	// the kind of source, like "param", "lookup", "env", or "platform"
+	Kind string `protobuf:"bytes,2,opt,name=kind" json:"kind,omitempty"`
+	// the name of the param, lookup, or environment variable
+	Name string `protobuf:"bytes,3,opt,name=name" json:"name,omitempty"`
+	// the node the value came from, for params and lookups
+	Node string `protobuf:"bytes,4,opt,name=node" json:"node,omitempty"`
+	// the value of the source, as it was rendered
+	Value string `protobuf:"bytes,5,opt,name=value" json:"value,omitempty"`
+}
+
+func (m *ProvenanceResponse) Reset()                    { *m = ProvenanceResponse{} }
+func (m *ProvenanceResponse) String() string            { return proto.CompactTextString(m) }
+func (*ProvenanceResponse) ProtoMessage()               {}
+func (*ProvenanceResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *ProvenanceResponse) GetField() string {
+	if m != nil {
+		return m.Field
+	}
+	return ""
+}
+
+func (m *ProvenanceResponse) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *ProvenanceResponse) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ProvenanceResponse) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
+func (m *ProvenanceResponse) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
 type GraphComponent struct {
 	// Types that are valid to be assigned to Component:
 	//	*GraphComponent_Vertex_
@@ -287,7 +610,7 @@ type GraphComponent struct {
 func (m *GraphComponent) Reset()                    { *m = GraphComponent{} }
 func (m *GraphComponent) String() string            { return proto.CompactTextString(m) }
 func (*GraphComponent) ProtoMessage()               {}
-func (*GraphComponent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+func (*GraphComponent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
 
 type isGraphComponent_Component interface {
 	isGraphComponent_Component()
@@ -410,7 +733,7 @@ type GraphComponent_Vertex struct {
 func (m *GraphComponent_Vertex) Reset()                    { *m = GraphComponent_Vertex{} }
 func (m *GraphComponent_Vertex) String() string            { return proto.CompactTextString(m) }
 func (*GraphComponent_Vertex) ProtoMessage()               {}
-func (*GraphComponent_Vertex) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4, 0} }
+func (*GraphComponent_Vertex) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7, 0} }
 
 func (m *GraphComponent_Vertex) GetId() string {
 	if m != nil {
@@ -437,12 +760,17 @@ type GraphComponent_Edge struct {
 	Source     string   `protobuf:"bytes,1,opt,name=source" json:"source,omitempty"`
 	Dest       string   `protobuf:"bytes,2,opt,name=dest" json:"dest,omitempty"`
 	Attributes []string `protobuf:"bytes,3,rep,name=attributes" json:"attributes,omitempty"`
+	// why the source depends on the dest, for edges made by the loader
+	Reasons []string `protobuf:"bytes,4,rep,name=reasons" json:"reasons,omitempty"`
+	// the kind of each of the reasons, like "param" or "lookup", in the same
+	// order
+	ReasonKinds []string `protobuf:"bytes,5,rep,name=reason_kinds,json=reasonKinds" json:"reason_kinds,omitempty"`
 }
 
 func (m *GraphComponent_Edge) Reset()                    { *m = GraphComponent_Edge{} }
 func (m *GraphComponent_Edge) String() string            { return proto.CompactTextString(m) }
 func (*GraphComponent_Edge) ProtoMessage()               {}
-func (*GraphComponent_Edge) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4, 1} }
+func (*GraphComponent_Edge) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7, 1} }
 
 func (m *GraphComponent_Edge) GetSource() string {
 	if m != nil {
@@ -465,16 +793,149 @@ func (m *GraphComponent_Edge) GetAttributes() []string {
 	return nil
 }
 
+func (m *GraphComponent_Edge) GetReasons() []string {
+	if m != nil {
+		return m.Reasons
+	}
+	return nil
+}
+
+func (m *GraphComponent_Edge) GetReasonKinds() []string {
+	if m != nil {
+		return m.ReasonKinds
+	}
+	return nil
+}
+
+// the fields of a resource, as used in the Converge DSL
+type ResourceSchema struct {
+	// the kind of resource, specified as the type used to create it in the
+	// Converge DSL
+	Kind string `protobuf:"bytes,1,opt,name=kind" json:"kind,omitempty"`
+	// the fields that can be set on the resource
+	Fields []*ResourceSchema_Field `protobuf:"bytes,2,rep,name=fields" json:"fields,omitempty"`
+	// the fields the resource exports for lookups
+	Exports []*ResourceSchema_Field `protobuf:"bytes,3,rep,name=exports" json:"exports,omitempty"`
+}
+
+func (m *ResourceSchema) Reset()                    { *m = ResourceSchema{} }
+func (m *ResourceSchema) String() string            { return proto.CompactTextString(m) }
+func (*ResourceSchema) ProtoMessage()               {}
+func (*ResourceSchema) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *ResourceSchema) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *ResourceSchema) GetFields() []*ResourceSchema_Field {
+	if m != nil {
+		return m.Fields
+	}
+	return nil
+}
+
+func (m *ResourceSchema) GetExports() []*ResourceSchema_Field {
+	if m != nil {
+		return m.Exports
+	}
+	return nil
+}
+
+type ResourceSchema_Field struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// the type of the field, like "string" or "[]string"
+	Type     string `protobuf:"bytes,2,opt,name=type" json:"type,omitempty"`
+	Required bool   `protobuf:"varint,3,opt,name=required" json:"required,omitempty"`
+	Nonempty bool   `protobuf:"varint,4,opt,name=nonempty" json:"nonempty,omitempty"`
+	// the only values the field accepts, if limited
+	ValidValues []string `protobuf:"bytes,5,rep,name=validValues" json:"validValues,omitempty"`
+	// the fields that cannot be set along with this one, including itself
+	MutuallyExclusive []string `protobuf:"bytes,6,rep,name=mutuallyExclusive" json:"mutuallyExclusive,omitempty"`
+}
+
+func (m *ResourceSchema_Field) Reset()                    { *m = ResourceSchema_Field{} }
+func (m *ResourceSchema_Field) String() string            { return proto.CompactTextString(m) }
+func (*ResourceSchema_Field) ProtoMessage()               {}
+func (*ResourceSchema_Field) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8, 0} }
+
+func (m *ResourceSchema_Field) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ResourceSchema_Field) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *ResourceSchema_Field) GetRequired() bool {
+	if m != nil {
+		return m.Required
+	}
+	return false
+}
+
+func (m *ResourceSchema_Field) GetNonempty() bool {
+	if m != nil {
+		return m.Nonempty
+	}
+	return false
+}
+
+func (m *ResourceSchema_Field) GetValidValues() []string {
+	if m != nil {
+		return m.ValidValues
+	}
+	return nil
+}
+
+func (m *ResourceSchema_Field) GetMutuallyExclusive() []string {
+	if m != nil {
+		return m.MutuallyExclusive
+	}
+	return nil
+}
+
+type ResourceSchemasResponse struct {
+	Resources []*ResourceSchema `protobuf:"bytes,1,rep,name=resources" json:"resources,omitempty"`
+}
+
+func (m *ResourceSchemasResponse) Reset()                    { *m = ResourceSchemasResponse{} }
+func (m *ResourceSchemasResponse) String() string            { return proto.CompactTextString(m) }
+func (*ResourceSchemasResponse) ProtoMessage()               {}
+func (*ResourceSchemasResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *ResourceSchemasResponse) GetResources() []*ResourceSchema {
+	if m != nil {
+		return m.Resources
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*LoadRequest)(nil), "pb.LoadRequest")
 	proto.RegisterType((*ContentResponse)(nil), "pb.ContentResponse")
 	proto.RegisterType((*StatusResponse)(nil), "pb.StatusResponse")
 	proto.RegisterType((*StatusResponse_Details)(nil), "pb.StatusResponse.Details")
 	proto.RegisterType((*StatusResponse_Meta)(nil), "pb.StatusResponse.Meta")
+	proto.RegisterType((*StatusResponse_Heartbeat)(nil), "pb.StatusResponse.Heartbeat")
 	proto.RegisterType((*DiffResponse)(nil), "pb.DiffResponse")
+	proto.RegisterType((*UsageResponse)(nil), "pb.UsageResponse")
+	proto.RegisterType((*WarningResponse)(nil), "pb.WarningResponse")
+	proto.RegisterType((*ProvenanceResponse)(nil), "pb.ProvenanceResponse")
 	proto.RegisterType((*GraphComponent)(nil), "pb.GraphComponent")
 	proto.RegisterType((*GraphComponent_Vertex)(nil), "pb.GraphComponent.Vertex")
 	proto.RegisterType((*GraphComponent_Edge)(nil), "pb.GraphComponent.Edge")
+	proto.RegisterType((*ResourceSchema)(nil), "pb.ResourceSchema")
+	proto.RegisterType((*ResourceSchema_Field)(nil), "pb.ResourceSchema.Field")
+	proto.RegisterType((*ResourceSchemasResponse)(nil), "pb.ResourceSchemasResponse")
 	proto.RegisterEnum("pb.StatusResponse_Stage", StatusResponse_Stage_name, StatusResponse_Stage_value)
 	proto.RegisterEnum("pb.StatusResponse_Run", StatusResponse_Run_name, StatusResponse_Run_value)
 }
@@ -900,6 +1361,8 @@ var _Grapher_serviceDesc = grpc.ServiceDesc{
 
 type InfoClient interface {
 	Ping(ctx context.Context, in *google_protobuf1.Empty, opts ...grpc.CallOption) (*google_protobuf1.Empty, error)
+	// ResourceSchemas describes the resources this server can run
+	ResourceSchemas(ctx context.Context, in *google_protobuf1.Empty, opts ...grpc.CallOption) (*ResourceSchemasResponse, error)
 }
 
 type infoClient struct {
@@ -919,10 +1382,21 @@ func (c *infoClient) Ping(ctx context.Context, in *google_protobuf1.Empty, opts
 	return out, nil
 }
 
+func (c *infoClient) ResourceSchemas(ctx context.Context, in *google_protobuf1.Empty, opts ...grpc.CallOption) (*ResourceSchemasResponse, error) {
+	out := new(ResourceSchemasResponse)
+	err := grpc.Invoke(ctx, "/pb.Info/ResourceSchemas", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Info service
 
 type InfoServer interface {
 	Ping(context.Context, *google_protobuf1.Empty) (*google_protobuf1.Empty, error)
+	// ResourceSchemas describes the resources this server can run
+	ResourceSchemas(context.Context, *google_protobuf1.Empty) (*ResourceSchemasResponse, error)
 }
 
 func RegisterInfoServer(s *grpc.Server, srv InfoServer) {
@@ -947,6 +1421,24 @@ func _Info_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Info_ResourceSchemas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf1.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InfoServer).ResourceSchemas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Info/ResourceSchemas",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InfoServer).ResourceSchemas(ctx, req.(*google_protobuf1.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Info_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "pb.Info",
 	HandlerType: (*InfoServer)(nil),
@@ -955,73 +1447,117 @@ var _Info_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Ping",
 			Handler:    _Info_Ping_Handler,
 		},
+		{
+			MethodName: "ResourceSchemas",
+			Handler:    _Info_ResourceSchemas_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
-	Metadata: fileDescriptor0,
+	Metadata: "root.proto",
 }
 
 func init() { proto.RegisterFile("root.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 957 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x8c, 0x55, 0x4f, 0x6f, 0xe3, 0x44,
-	0x14, 0xaf, 0x1d, 0xa7, 0x69, 0x5e, 0xa2, 0x34, 0xcc, 0x76, 0xbb, 0x5e, 0x2f, 0x62, 0x23, 0x1f,
-	0x76, 0x4b, 0x57, 0x38, 0x90, 0x82, 0x84, 0x56, 0x5a, 0xa1, 0xb4, 0x4d, 0x9b, 0x48, 0xd9, 0x28,
-	0x9a, 0x74, 0x91, 0xf8, 0x23, 0xd0, 0x24, 0x99, 0x38, 0x56, 0x9d, 0x19, 0x33, 0x1e, 0x97, 0x8d,
-	0x10, 0x17, 0x0e, 0x1c, 0xb8, 0x72, 0xe6, 0xc3, 0xf0, 0x05, 0xb8, 0xf0, 0x15, 0x38, 0xf3, 0x19,
-	0xd0, 0x8c, 0xed, 0x92, 0xa6, 0x89, 0xb4, 0x37, 0xff, 0x66, 0x7e, 0xef, 0xf7, 0xde, 0xfc, 0xde,
-	0xf3, 0x0c, 0x80, 0xe0, 0x5c, 0x7a, 0x91, 0xe0, 0x92, 0x23, 0x33, 0x1a, 0x3b, 0xef, 0xfb, 0x9c,
-	0xfb, 0x21, 0x6d, 0x92, 0x28, 0x68, 0x12, 0xc6, 0xb8, 0x24, 0x32, 0xe0, 0x2c, 0x4e, 0x19, 0xce,
-	0x93, 0x6c, 0x57, 0xa3, 0x71, 0x32, 0x6b, 0xd2, 0x45, 0x24, 0x97, 0xe9, 0xa6, 0xfb, 0xa7, 0x01,
-	0x95, 0x3e, 0x27, 0x53, 0x4c, 0x7f, 0x48, 0x68, 0x2c, 0x91, 0x03, 0x7b, 0x21, 0x9f, 0xe8, 0x78,
-	0xdb, 0x68, 0x18, 0x47, 0x65, 0x7c, 0x8b, 0xd1, 0x17, 0x00, 0x11, 0x11, 0x64, 0x41, 0x25, 0x15,
-	0xb1, 0x6d, 0x36, 0x0a, 0x47, 0x95, 0xd6, 0x53, 0x2f, 0x1a, 0x7b, 0x2b, 0x02, 0xde, 0xf0, 0x96,
-	0xd1, 0x61, 0x52, 0x2c, 0xf1, 0x4a, 0x08, 0x3a, 0x84, 0xdd, 0x1b, 0x2a, 0x82, 0xd9, 0xd2, 0x2e,
-	0x34, 0x8c, 0xa3, 0x3d, 0x9c, 0x21, 0xe7, 0x15, 0xec, 0xaf, 0x85, 0xa1, 0x3a, 0x14, 0xae, 0xe9,
-	0x32, 0x2b, 0x41, 0x7d, 0xa2, 0x03, 0x28, 0xde, 0x90, 0x30, 0xa1, 0xb6, 0xa9, 0xd7, 0x52, 0xf0,
-	0xd2, 0xfc, 0xdc, 0x70, 0x5f, 0xc0, 0xfe, 0x19, 0x67, 0x92, 0x32, 0x89, 0x69, 0x1c, 0x71, 0x16,
-	0x53, 0x64, 0x43, 0x69, 0x92, 0x2e, 0x65, 0x12, 0x39, 0x74, 0xff, 0xb5, 0xa0, 0x36, 0x92, 0x44,
-	0x26, 0xf1, 0x2d, 0x19, 0x81, 0x19, 0x4c, 0x53, 0xde, 0xa9, 0x69, 0x1b, 0xd8, 0x0c, 0xa6, 0xc8,
-	0x83, 0x62, 0x2c, 0x89, 0x9f, 0x66, 0xab, 0xb5, 0x6c, 0x75, 0xcc, 0xbb, 0x61, 0x0a, 0xfa, 0x14,
-	0xa7, 0x34, 0x74, 0x04, 0x05, 0x91, 0x30, 0x7d, 0xae, 0x5a, 0xeb, 0x70, 0x03, 0x1b, 0x27, 0x0c,
-	0x2b, 0x0a, 0xfa, 0x14, 0x4a, 0x53, 0x2a, 0x49, 0x10, 0xc6, 0xb6, 0xd5, 0x30, 0x8e, 0x2a, 0x2d,
-	0x67, 0x03, 0xfb, 0x3c, 0x65, 0xe0, 0x9c, 0x8a, 0x5e, 0x80, 0xb5, 0xa0, 0x92, 0xd8, 0x45, 0x1d,
-	0xf2, 0x68, 0x43, 0xc8, 0x6b, 0x2a, 0x09, 0xd6, 0x24, 0xe7, 0x57, 0x13, 0x4a, 0x99, 0x82, 0x6a,
-	0xe8, 0x82, 0xc6, 0x31, 0xf1, 0x69, 0x6c, 0x1b, 0x8d, 0x82, 0x6a, 0x68, 0x8e, 0x51, 0x1b, 0x4a,
-	0x93, 0x39, 0x61, 0x6a, 0x2b, 0xed, 0xe6, 0xf3, 0xed, 0xa5, 0x78, 0x67, 0x29, 0x33, 0xed, 0x6a,
-	0x1e, 0x87, 0x3e, 0x00, 0x98, 0x93, 0x38, 0xdb, 0xcb, 0xda, 0xba, 0xb2, 0xa2, 0xba, 0x46, 0x85,
-	0xe0, 0x42, 0x9f, 0xb5, 0x8c, 0x53, 0xa0, 0xda, 0xf3, 0x23, 0x11, 0x2c, 0x60, 0xbe, 0x3e, 0x50,
-	0x19, 0xe7, 0xd0, 0xe9, 0x43, 0x75, 0x35, 0xd1, 0x86, 0x39, 0x78, 0xb6, 0x3a, 0x07, 0x95, 0x56,
-	0x5d, 0x95, 0x7c, 0x1e, 0xcc, 0x66, 0x79, 0xc1, 0x2b, 0x93, 0xe1, 0x1c, 0x82, 0xa5, 0x6c, 0x41,
-	0xb5, 0xff, 0x3b, 0xac, 0xba, 0xeb, 0x9e, 0x40, 0x51, 0x77, 0x0f, 0x3d, 0x84, 0xf7, 0xde, 0x0c,
-	0x46, 0xc3, 0xce, 0x59, 0xef, 0xa2, 0xd7, 0x39, 0xff, 0x7e, 0x74, 0xd5, 0xbe, 0xec, 0xd4, 0x77,
-	0xd0, 0x1e, 0x58, 0xc3, 0x7e, 0x7b, 0x50, 0x37, 0x50, 0x19, 0x8a, 0xed, 0xe1, 0xb0, 0xff, 0x55,
-	0xdd, 0x74, 0x3f, 0x83, 0x02, 0x4e, 0x18, 0x7a, 0x00, 0xfb, 0xab, 0x21, 0xf8, 0xcd, 0xa0, 0xbe,
-	0x83, 0x2a, 0x50, 0x1a, 0x5d, 0xb5, 0xf1, 0x55, 0xe7, 0xbc, 0x6e, 0xa0, 0x2a, 0xec, 0x5d, 0xf4,
-	0x06, 0xbd, 0x51, 0xb7, 0x73, 0x5e, 0x37, 0xdd, 0xef, 0xa0, 0xba, 0x5a, 0x9e, 0x6a, 0x08, 0x17,
-	0x81, 0x1f, 0x30, 0x12, 0xe6, 0x7f, 0x58, 0x8e, 0xf5, 0xd8, 0x26, 0x42, 0xa8, 0xb1, 0x35, 0xb3,
-	0xb1, 0x4d, 0xa1, 0xde, 0xb9, 0x63, 0x72, 0x0e, 0xdd, 0x3f, 0x4c, 0xa8, 0x5d, 0x0a, 0x12, 0xcd,
-	0xcf, 0xf8, 0x22, 0xe2, 0x4c, 0x91, 0x4f, 0xf4, 0x7f, 0x26, 0xe9, 0x5b, 0x9d, 0xa0, 0xd2, 0x7a,
-	0xac, 0x3c, 0xba, 0xcb, 0xf1, 0xbe, 0xd4, 0x84, 0xee, 0x0e, 0xce, 0xa8, 0xe8, 0x23, 0xb0, 0xe8,
-	0xd4, 0xcf, 0x6d, 0x7d, 0xb4, 0x21, 0xa4, 0x33, 0xf5, 0x69, 0x77, 0x07, 0x6b, 0x9a, 0x73, 0x01,
-	0xbb, 0xa9, 0xc4, 0xba, 0xb9, 0x08, 0x81, 0x75, 0x1d, 0xb0, 0x69, 0x76, 0x02, 0xfd, 0xad, 0xca,
-	0xcf, 0x87, 0x5e, 0x95, 0x5f, 0xbd, 0x1d, 0x6c, 0x07, 0x83, 0xa5, 0x74, 0xd5, 0xdd, 0x10, 0xf3,
-	0x44, 0x4c, 0x68, 0xa6, 0x94, 0x21, 0xa5, 0x36, 0xa5, 0x71, 0xee, 0x87, 0xfe, 0x56, 0x43, 0x47,
-	0xa4, 0x14, 0xc1, 0x38, 0x91, 0xda, 0x0f, 0x35, 0xd5, 0x2b, 0x2b, 0xa7, 0x15, 0x28, 0x4f, 0xf2,
-	0xaa, 0x5b, 0xbf, 0x99, 0xb0, 0xd7, 0x79, 0x4b, 0x27, 0x89, 0xe4, 0x02, 0x7d, 0x0b, 0x95, 0x2e,
-	0x25, 0xa1, 0x9c, 0x9f, 0xcd, 0xe9, 0xe4, 0x1a, 0xed, 0xaf, 0xdd, 0x5e, 0x0e, 0xba, 0xff, 0x03,
-	0xb8, 0xcf, 0x7e, 0xf9, 0xfb, 0x9f, 0xdf, 0xcd, 0x86, 0xfb, 0x44, 0xdf, 0xaf, 0x37, 0x9f, 0x34,
-	0x17, 0x64, 0x32, 0x0f, 0x18, 0x6d, 0xce, 0xb5, 0xd2, 0x44, 0x29, 0xbd, 0x34, 0x8e, 0x3f, 0x36,
-	0xd0, 0x00, 0xac, 0x61, 0x48, 0xd8, 0xbb, 0xc9, 0x3e, 0xd5, 0xb2, 0x8f, 0xdd, 0x83, 0x75, 0xd9,
-	0x28, 0x24, 0x2c, 0xd5, 0x1b, 0x42, 0xb1, 0x1d, 0x45, 0xe1, 0xf2, 0xdd, 0x04, 0x1b, 0x5a, 0xd0,
-	0x71, 0x1f, 0xae, 0x0b, 0x12, 0xa5, 0xa1, 0x15, 0x5b, 0x7f, 0x19, 0x50, 0xc5, 0x34, 0xb5, 0xb6,
-	0xcb, 0x63, 0x89, 0xbe, 0x86, 0xf2, 0x25, 0x95, 0xa7, 0x01, 0x23, 0x62, 0x89, 0x0e, 0xbd, 0xf4,
-	0xa9, 0xf0, 0xf2, 0xa7, 0xc2, 0xeb, 0xa8, 0xa7, 0xc2, 0x79, 0xa0, 0xb2, 0xad, 0x5d, 0xb1, 0x79,
-	0x3a, 0x64, 0xe7, 0xe9, 0x44, 0xa6, 0x1b, 0x37, 0xc7, 0xa9, 0xdc, 0x58, 0x6b, 0xbf, 0xe6, 0xd3,
-	0x24, 0xa4, 0xf7, 0x8f, 0xb0, 0x51, 0xb4, 0xa9, 0x45, 0x3f, 0x44, 0xcf, 0xef, 0x8b, 0x2e, 0xb4,
-	0x4e, 0xdc, 0xfc, 0x29, 0x7f, 0x8f, 0x5e, 0x1d, 0x1f, 0xff, 0xdc, 0xfa, 0x06, 0x4a, 0x7a, 0x4a,
-	0xa9, 0x50, 0x6e, 0xe9, 0xcf, 0x2d, 0x6e, 0xdd, 0x1d, 0xe6, 0xed, 0x6e, 0xf9, 0x8a, 0x97, 0xba,
-	0x75, 0x05, 0x56, 0x8f, 0xcd, 0x38, 0xea, 0x83, 0x35, 0x0c, 0x98, 0xbf, 0xd5, 0x9f, 0x2d, 0xeb,
-	0xee, 0x81, 0xce, 0x51, 0x43, 0xd5, 0x3c, 0x47, 0x14, 0x30, 0x7f, 0xbc, 0xab, 0x59, 0x27, 0xff,
-	0x05, 0x00, 0x00, 0xff, 0xff, 0x5b, 0x23, 0xe4, 0xb4, 0xc6, 0x07, 0x00, 0x00,
+	// 1600 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xa4, 0x57, 0xcd, 0x6e, 0x23, 0xc7,
+	0x11, 0x5e, 0xfe, 0x89, 0x9a, 0x22, 0x2d, 0x71, 0xdb, 0xfb, 0x33, 0xe6, 0x2e, 0x62, 0x65, 0x0e,
+	0x5e, 0x65, 0x9d, 0x90, 0x1b, 0x6e, 0x10, 0x04, 0x0b, 0x2c, 0x0c, 0xad, 0x44, 0xad, 0x04, 0xcb,
+	0x02, 0xd3, 0x92, 0x6c, 0x24, 0x31, 0xb0, 0x68, 0x0e, 0x9b, 0xe4, 0x40, 0xc3, 0xe9, 0xd9, 0x9e,
+	0x1e, 0x59, 0x44, 0x92, 0x4b, 0x80, 0x5c, 0x72, 0xc9, 0x21, 0xcf, 0x12, 0xe4, 0x05, 0x72, 0x4d,
+	0x2e, 0x39, 0x06, 0xb9, 0xe5, 0x41, 0x82, 0xea, 0x9e, 0x1e, 0x0e, 0x7f, 0x64, 0x38, 0xf0, 0x6d,
+	0xaa, 0xfa, 0xab, 0xaf, 0xaa, 0xab, 0xab, 0xaa, 0x7b, 0x00, 0xa4, 0x10, 0xaa, 0x13, 0x4b, 0xa1,
+	0x04, 0x29, 0xc7, 0xc3, 0xf6, 0xd3, 0x89, 0x10, 0x93, 0x90, 0x77, 0x59, 0x1c, 0x74, 0x59, 0x14,
+	0x09, 0xc5, 0x54, 0x20, 0xa2, 0xc4, 0x20, 0xda, 0x4f, 0xb2, 0x55, 0x2d, 0x0d, 0xd3, 0x71, 0x97,
+	0xcf, 0x62, 0x35, 0x37, 0x8b, 0xde, 0x1f, 0xab, 0xd0, 0x38, 0x13, 0x6c, 0x44, 0xf9, 0xfb, 0x94,
+	0x27, 0x8a, 0xb4, 0x61, 0x3b, 0x14, 0xbe, 0xb6, 0x77, 0x4b, 0x7b, 0xa5, 0x7d, 0x87, 0xe6, 0x32,
+	0xf9, 0x0c, 0x20, 0x66, 0x92, 0xcd, 0xb8, 0xe2, 0x32, 0x71, 0xcb, 0x7b, 0x95, 0xfd, 0x46, 0xef,
+	0xe3, 0x4e, 0x3c, 0xec, 0x14, 0x08, 0x3a, 0x83, 0x1c, 0xd1, 0x8f, 0x94, 0x9c, 0xd3, 0x82, 0x09,
+	0x79, 0x04, 0x5b, 0x37, 0x5c, 0x06, 0xe3, 0xb9, 0x5b, 0xd9, 0x2b, 0xed, 0x6f, 0xd3, 0x4c, 0x22,
+	0xcf, 0xa1, 0xc5, 0xc2, 0x50, 0x7c, 0x73, 0xc4, 0x13, 0x25, 0x53, 0x5f, 0x05, 0x37, 0xdc, 0xad,
+	0x6a, 0xc4, 0x9a, 0x9e, 0xec, 0x41, 0x03, 0x19, 0xc3, 0x90, 0x87, 0x41, 0x32, 0x73, 0x6b, 0x7b,
+	0xa5, 0xfd, 0x1a, 0x2d, 0xaa, 0x08, 0x81, 0xaa, 0x88, 0xc2, 0xb9, 0xbb, 0xb5, 0x57, 0xd9, 0x77,
+	0xa8, 0xfe, 0xc6, 0xd0, 0xdf, 0xa7, 0x4c, 0xb2, 0x48, 0x05, 0x11, 0x77, 0xeb, 0x9b, 0x43, 0xff,
+	0x65, 0x8e, 0xc8, 0x42, 0x5f, 0x98, 0x10, 0x0f, 0x9a, 0x89, 0x92, 0x81, 0xaf, 0x28, 0x8f, 0x46,
+	0x5c, 0xba, 0xdb, 0x3a, 0xbc, 0x25, 0x1d, 0x79, 0x0a, 0xce, 0x94, 0x33, 0xa9, 0x86, 0x9c, 0x29,
+	0xd7, 0xd1, 0x81, 0x2d, 0x14, 0xe4, 0x07, 0x00, 0x16, 0x3d, 0x4e, 0x5c, 0xd0, 0xf6, 0x05, 0x4d,
+	0xfb, 0x35, 0xec, 0xae, 0xe4, 0x8e, 0xb4, 0xa0, 0x72, 0xcd, 0xe7, 0xd9, 0x39, 0xe0, 0x27, 0x79,
+	0x00, 0xb5, 0x1b, 0x16, 0xa6, 0xdc, 0x2d, 0x6b, 0x9d, 0x11, 0x5e, 0x95, 0x7f, 0x51, 0x42, 0xf3,
+	0x95, 0xf8, 0xff, 0x1f, 0x73, 0xef, 0x53, 0xd8, 0x3d, 0x14, 0x91, 0xe2, 0x91, 0xa2, 0x3c, 0x89,
+	0x45, 0x94, 0x70, 0xe2, 0x42, 0xdd, 0x37, 0xaa, 0x8c, 0xc2, 0x8a, 0xde, 0x3f, 0x1c, 0xd8, 0xb9,
+	0x50, 0x4c, 0xa5, 0x49, 0x0e, 0x26, 0x50, 0x0e, 0x46, 0x06, 0xf7, 0xa6, 0xec, 0x96, 0x68, 0x39,
+	0x18, 0x91, 0x0e, 0xd4, 0x12, 0xc5, 0x26, 0xc6, 0xdb, 0x4e, 0xcf, 0xc5, 0x7c, 0x2f, 0x9b, 0xa1,
+	0x38, 0xe1, 0xd4, 0xc0, 0xc8, 0x3e, 0x54, 0x64, 0x1a, 0xe9, 0xda, 0xd8, 0xe9, 0x3d, 0xda, 0x80,
+	0xa6, 0x69, 0x44, 0x11, 0x42, 0x7e, 0x06, 0xf5, 0x11, 0x57, 0x2c, 0x08, 0x13, 0x5d, 0x27, 0x8d,
+	0x5e, 0x7b, 0x03, 0xfa, 0xc8, 0x20, 0xa8, 0x85, 0x92, 0x4f, 0xa1, 0x3a, 0xe3, 0x8a, 0xe9, 0x9a,
+	0x69, 0xf4, 0x1e, 0x6f, 0x30, 0xf9, 0x82, 0x2b, 0x46, 0x35, 0x88, 0xbc, 0x2a, 0x1e, 0xe6, 0x96,
+	0xb6, 0x78, 0xba, 0xc1, 0xe2, 0xc4, 0x62, 0x0a, 0x47, 0xdd, 0xfe, 0x4f, 0x0d, 0xea, 0x99, 0x77,
+	0x6c, 0xa8, 0x19, 0x4f, 0x12, 0x36, 0xe1, 0x89, 0x5b, 0xd2, 0x15, 0x99, 0xcb, 0xe4, 0x00, 0xea,
+	0xfe, 0x94, 0x45, 0xb8, 0x64, 0xba, 0xe9, 0xd9, 0xdd, 0xdb, 0xe8, 0x1c, 0x1a, 0xa4, 0x29, 0x4d,
+	0x6b, 0x87, 0x55, 0x35, 0x65, 0x49, 0xb6, 0x96, 0xb5, 0x55, 0x41, 0x83, 0x27, 0xce, 0xa5, 0x14,
+	0x52, 0xe7, 0xc9, 0xa1, 0x46, 0xc0, 0xa3, 0xfd, 0x86, 0xc9, 0x28, 0x88, 0x26, 0x3a, 0x19, 0x0e,
+	0xb5, 0x22, 0x79, 0x06, 0xb5, 0x14, 0x83, 0xcb, 0xb6, 0x7c, 0x1f, 0x03, 0xba, 0x42, 0x85, 0x8d,
+	0x87, 0x9a, 0x75, 0xd2, 0x85, 0xed, 0xcc, 0x26, 0xc9, 0xfa, 0xe9, 0x43, 0xc4, 0x7e, 0x65, 0x74,
+	0x39, 0x3a, 0x07, 0x61, 0x77, 0x68, 0xe7, 0x87, 0x62, 0xc4, 0x75, 0xfb, 0x38, 0x74, 0xa1, 0xc0,
+	0xb6, 0x1e, 0x15, 0xba, 0xdf, 0xd1, 0x99, 0x2a, 0xaa, 0x70, 0x78, 0x04, 0xb3, 0x98, 0xf9, 0x4a,
+	0xf7, 0x8e, 0x43, 0x33, 0x09, 0xf7, 0x22, 0x52, 0xe5, 0x8b, 0x19, 0x77, 0x1b, 0x66, 0x2f, 0x99,
+	0x48, 0xbe, 0x86, 0x96, 0xe4, 0x33, 0x16, 0xa0, 0x7f, 0x9b, 0xa1, 0xa6, 0x0e, 0xf5, 0xc5, 0xb7,
+	0xe4, 0x99, 0xae, 0x98, 0x98, 0x84, 0xaf, 0x31, 0x91, 0x9f, 0x03, 0xc4, 0x52, 0xdc, 0xf0, 0x88,
+	0x45, 0x3e, 0x77, 0x3f, 0xd0, 0xbc, 0xba, 0x68, 0x07, 0xb9, 0x36, 0xcf, 0x42, 0x01, 0x89, 0x79,
+	0x90, 0x22, 0x0c, 0x87, 0xcc, 0xbf, 0x4e, 0xdc, 0x1d, 0xbd, 0xcf, 0x85, 0xa2, 0x7d, 0x06, 0xcd,
+	0xa2, 0xdf, 0x0d, 0x3d, 0xfc, 0x49, 0xb1, 0x87, 0x1b, 0xbd, 0x16, 0xba, 0x3c, 0x0a, 0xc6, 0xe3,
+	0xc5, 0x01, 0x2d, 0x86, 0xc2, 0x15, 0x3c, 0xdc, 0xb8, 0x9d, 0xef, 0x49, 0xfb, 0x08, 0xaa, 0xd8,
+	0x29, 0x64, 0x67, 0xd1, 0xf4, 0xd8, 0xf0, 0xed, 0xd7, 0xe0, 0xe4, 0xfd, 0x80, 0xe7, 0xc2, 0x43,
+	0x16, 0x27, 0xdc, 0x20, 0x2a, 0xd4, 0x8a, 0x78, 0x92, 0x22, 0x55, 0x71, 0xaa, 0xb2, 0x31, 0x94,
+	0x49, 0xde, 0x4b, 0xa8, 0xe9, 0x79, 0x40, 0x1e, 0xc2, 0xfd, 0xab, 0xf3, 0x8b, 0x41, 0xff, 0xf0,
+	0xf4, 0xf8, 0xb4, 0x7f, 0xf4, 0xee, 0xe2, 0xf2, 0xe0, 0x6d, 0xbf, 0x75, 0x8f, 0x6c, 0x43, 0x75,
+	0x70, 0x76, 0x70, 0xde, 0x2a, 0x11, 0x07, 0x6a, 0x07, 0x83, 0xc1, 0xd9, 0xaf, 0x5a, 0x65, 0xef,
+	0x0d, 0x54, 0x68, 0x1a, 0x91, 0x0f, 0x61, 0xb7, 0x68, 0x42, 0xaf, 0xce, 0x5b, 0xf7, 0x48, 0x03,
+	0xea, 0x17, 0x97, 0x07, 0xf4, 0xb2, 0x7f, 0xd4, 0x2a, 0x91, 0x26, 0x6c, 0x1f, 0x9f, 0x9e, 0x9f,
+	0x5e, 0x9c, 0xf4, 0x8f, 0x5a, 0x65, 0x5c, 0xa2, 0x57, 0xe7, 0xe7, 0xa7, 0xe7, 0x6f, 0x5b, 0x15,
+	0xef, 0x16, 0x9a, 0xc5, 0xad, 0x62, 0xcf, 0x0a, 0x19, 0x4c, 0x82, 0x88, 0x85, 0xf6, 0x12, 0xb4,
+	0xb2, 0x9e, 0x8a, 0xa9, 0x94, 0x38, 0x15, 0xcb, 0xd9, 0x54, 0x34, 0xa2, 0x5e, 0x59, 0xea, 0xc3,
+	0xbc, 0x49, 0x5d, 0xa8, 0xa7, 0x51, 0x30, 0x0e, 0xf8, 0x28, 0x6b, 0x43, 0x2b, 0x7a, 0x13, 0xf8,
+	0x60, 0xa9, 0xbb, 0x34, 0x49, 0x9c, 0x5e, 0x06, 0x33, 0x6e, 0xb3, 0x96, 0x89, 0xb8, 0x12, 0x73,
+	0x76, 0x4d, 0x2f, 0x2e, 0xb4, 0xe3, 0x0a, 0xb5, 0x22, 0xde, 0x4d, 0xc3, 0xb9, 0xe2, 0xc9, 0x57,
+	0x32, 0x50, 0x8a, 0x9b, 0x01, 0x5a, 0xa1, 0x4b, 0x3a, 0xef, 0x33, 0xd8, 0x5d, 0x69, 0x4d, 0xbc,
+	0x27, 0xaf, 0x83, 0xc8, 0x9e, 0x9f, 0xfe, 0x46, 0x27, 0xd9, 0x74, 0xb2, 0xbb, 0xcb, 0x44, 0xef,
+	0x77, 0x40, 0xd6, 0x0b, 0x1b, 0xc7, 0xcb, 0x38, 0xe0, 0xa1, 0x25, 0x31, 0x42, 0xce, 0x5c, 0x2e,
+	0x30, 0x13, 0xa8, 0x46, 0x6c, 0xc6, 0x75, 0x70, 0x0e, 0xd5, 0xdf, 0x5a, 0x87, 0xd3, 0xa0, 0x9a,
+	0xe9, 0x70, 0x10, 0xe4, 0x57, 0x54, 0xad, 0x70, 0x45, 0x79, 0xff, 0x2e, 0xc3, 0xce, 0x5b, 0xc9,
+	0xe2, 0xe9, 0xa1, 0x98, 0xc5, 0x22, 0xc2, 0x74, 0xbf, 0xd4, 0x8f, 0x09, 0xc5, 0x6f, 0xb5, 0xef,
+	0x46, 0xef, 0x23, 0xac, 0xd8, 0x65, 0x4c, 0xe7, 0x4b, 0x0d, 0x38, 0xb9, 0x47, 0x33, 0x28, 0xf9,
+	0x09, 0x54, 0xf9, 0x68, 0x62, 0x8b, 0xfc, 0xf1, 0x06, 0x93, 0xfe, 0x68, 0xc2, 0x4f, 0xee, 0x51,
+	0x0d, 0x6b, 0x1f, 0xc3, 0x96, 0xa1, 0x58, 0x2d, 0xf5, 0x8d, 0x5b, 0x74, 0x17, 0xb7, 0x12, 0xee,
+	0xb2, 0x99, 0xdf, 0x3c, 0xed, 0x3f, 0x97, 0xa0, 0x8a, 0xc4, 0x58, 0xfa, 0x89, 0x48, 0xa5, 0xcf,
+	0x33, 0xaa, 0x4c, 0x42, 0x3a, 0x9c, 0x75, 0x96, 0x0e, 0xbf, 0x71, 0xb4, 0x33, 0xa5, 0x64, 0x30,
+	0x4c, 0x95, 0x2e, 0x29, 0x9c, 0x14, 0x05, 0x0d, 0xba, 0x93, 0x9c, 0x25, 0x22, 0xc2, 0x4b, 0x10,
+	0x17, 0xad, 0x48, 0x7e, 0x08, 0x4d, 0xf3, 0xf9, 0x0e, 0xe3, 0x4a, 0xdc, 0x9a, 0x99, 0xa6, 0x46,
+	0xf7, 0x39, 0xaa, 0xde, 0x34, 0xc0, 0xf1, 0xed, 0x9e, 0xbd, 0xbf, 0x97, 0x61, 0x87, 0x72, 0x13,
+	0xca, 0x85, 0x3f, 0xe5, 0x33, 0xb6, 0xb1, 0x38, 0x5e, 0xc0, 0x96, 0x3e, 0x5f, 0x7b, 0x5b, 0xe9,
+	0x0b, 0x7d, 0xd9, 0xae, 0x73, 0x8c, 0x00, 0x9a, 0xe1, 0x48, 0x0f, 0xea, 0xfc, 0x36, 0x16, 0x52,
+	0x99, 0xf8, 0xbf, 0xcd, 0xc4, 0x02, 0xdb, 0x7f, 0x2b, 0x41, 0xed, 0xd8, 0x96, 0x91, 0x2e, 0x99,
+	0xd2, 0x72, 0xc9, 0xa8, 0x79, 0x6c, 0xab, 0x53, 0x7f, 0x63, 0xbb, 0x4a, 0xfe, 0x3e, 0x0d, 0x24,
+	0x1f, 0x65, 0x9d, 0x97, 0xcb, 0xb8, 0x16, 0x89, 0x48, 0xbf, 0x78, 0xb3, 0x27, 0x65, 0x2e, 0xe3,
+	0x9d, 0x73, 0xc3, 0xc2, 0x60, 0xf4, 0x25, 0x96, 0x58, 0x9e, 0xa5, 0x82, 0x8a, 0xfc, 0x18, 0xee,
+	0xcf, 0x52, 0x95, 0xb2, 0x30, 0x9c, 0xf7, 0x6f, 0xfd, 0x30, 0x4d, 0xf0, 0x6e, 0x32, 0xef, 0xca,
+	0xf5, 0x05, 0xef, 0x73, 0x78, 0xbc, 0xbc, 0xb5, 0xc5, 0xf3, 0xe8, 0x05, 0x38, 0x32, 0x5b, 0x32,
+	0xcf, 0x80, 0x46, 0x8f, 0xac, 0xa7, 0x82, 0x2e, 0x40, 0xbd, 0x3f, 0x95, 0x61, 0xbb, 0x7f, 0xcb,
+	0xfd, 0x54, 0x09, 0x49, 0xbe, 0x86, 0xc6, 0x09, 0x67, 0xa1, 0x9a, 0x1e, 0x4e, 0xb9, 0x7f, 0x4d,
+	0x76, 0x57, 0x5e, 0xae, 0x6d, 0xb2, 0x7e, 0x9f, 0x79, 0x9f, 0xfc, 0xe1, 0x5f, 0xff, 0xfd, 0x4b,
+	0x79, 0xcf, 0x7b, 0xa2, 0x7f, 0x0b, 0x6e, 0x7e, 0xda, 0x9d, 0x31, 0x7f, 0x1a, 0x44, 0xbc, 0x3b,
+	0xd5, 0x4c, 0x3e, 0x32, 0xbd, 0x2a, 0x3d, 0x7f, 0x51, 0x22, 0xe7, 0x50, 0x1d, 0x84, 0x2c, 0xfa,
+	0x6e, 0xb4, 0x1f, 0x6b, 0xda, 0x8f, 0xbc, 0x07, 0xab, 0xb4, 0x71, 0xc8, 0x22, 0xc3, 0x37, 0x80,
+	0xda, 0x41, 0x1c, 0x87, 0xf3, 0xef, 0x46, 0xb8, 0xa7, 0x09, 0xdb, 0xde, 0xc3, 0x55, 0x42, 0x86,
+	0x1c, 0x9a, 0xb1, 0xf7, 0xcf, 0x12, 0x34, 0x6d, 0xaa, 0x4e, 0x44, 0xa2, 0xc8, 0xaf, 0xc1, 0x79,
+	0xcb, 0xd5, 0x9b, 0x20, 0x62, 0x72, 0x4e, 0x1e, 0x75, 0xcc, 0x1f, 0x4e, 0xc7, 0xfe, 0xe1, 0x74,
+	0xfa, 0x78, 0xbe, 0x6d, 0xfd, 0x20, 0x59, 0x79, 0xd5, 0x5a, 0x77, 0xc4, 0xb5, 0xee, 0xf2, 0x94,
+	0x77, 0x87, 0x86, 0x6e, 0xa8, 0xb9, 0xbf, 0x10, 0xa3, 0x34, 0xe4, 0xeb, 0x5b, 0xd8, 0x48, 0xda,
+	0xd5, 0xa4, 0x3f, 0x22, 0xcf, 0xd6, 0x49, 0x67, 0x9a, 0x27, 0xe9, 0xfe, 0xd6, 0xfe, 0x46, 0xbd,
+	0x7e, 0xfe, 0xfc, 0xf7, 0xbd, 0xdf, 0x40, 0x5d, 0xcf, 0x1d, 0x2e, 0x31, 0x5b, 0xfa, 0xf3, 0x8e,
+	0x6c, 0x2d, 0x8f, 0xa7, 0xbb, 0xb3, 0x35, 0x41, 0x9c, 0xc9, 0xd6, 0x5f, 0x4b, 0x50, 0x3d, 0x8d,
+	0xc6, 0x82, 0x9c, 0x41, 0x75, 0x80, 0x8f, 0xba, 0xbb, 0x12, 0x74, 0x87, 0xde, 0x7b, 0xa0, 0x9d,
+	0xec, 0x90, 0xa6, 0x75, 0x12, 0x23, 0xcb, 0x3b, 0xd8, 0x5d, 0x29, 0xef, 0x3b, 0x89, 0x9f, 0xac,
+	0xd7, 0xf6, 0xe2, 0xc0, 0x1f, 0x6b, 0xf6, 0xfb, 0x64, 0xd7, 0xb2, 0x27, 0x06, 0x30, 0xdc, 0xd2,
+	0x2c, 0x2f, 0xff, 0x17, 0x00, 0x00, 0xff, 0xff, 0x53, 0x76, 0x71, 0xc9, 0xdf, 0x0e, 0x00, 0x00,
 }
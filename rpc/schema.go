@@ -0,0 +1,155 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/pkg/errors"
+)
+
+// ResourceSchemas describes every resource in the registry, by the fields its
+// preparer accepts and the fields the created resource exports
+func ResourceSchemas() (*pb.ResourceSchemasResponse, error) {
+	resp := new(pb.ResourceSchemasResponse)
+
+	for _, name := range registry.Names() {
+		preparer, ok := registry.NewByName(name)
+		if !ok {
+			continue
+		}
+
+		schema := &pb.ResourceSchema{
+			Kind:   name,
+			Fields: preparerFields(reflect.TypeOf(preparer)),
+		}
+
+		for _, created := range registry.CreatedTypes(name) {
+			exports, err := exportedFields(created)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not get exported fields for %q", name)
+			}
+			schema.Exports = append(schema.Exports, exports...)
+		}
+
+		resp.Resources = append(resp.Resources, schema)
+	}
+
+	return resp, nil
+}
+
+// preparerFields lists the fields that can be set in HCL, as read from the
+// struct tags used by resource.Preparer
+func preparerFields(t reflect.Type) []*pb.ResourceSchema_Field {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []*pb.ResourceSchema_Field
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, ok := field.Tag.Lookup("hcl")
+		if !ok {
+			continue
+		}
+
+		fields = append(fields, &pb.ResourceSchema_Field{
+			Name:              name,
+			Type:              fieldType(field.Type),
+			Required:          field.Tag.Get("required") == "true",
+			Nonempty:          field.Tag.Get("nonempty") == "true",
+			ValidValues:       splitTag(field.Tag.Get("valid_values")),
+			MutuallyExclusive: splitTag(field.Tag.Get("mutually_exclusive")),
+		})
+	}
+
+	return fields
+}
+
+// exportedFields lists the fields a created resource makes available for
+// lookups
+func exportedFields(t reflect.Type) ([]*pb.ResourceSchema_Field, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	exported, err := resource.ExportedFields(newFilled(t).Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]*pb.ResourceSchema_Field, len(exported))
+	for i, field := range exported {
+		fields[i] = &pb.ResourceSchema_Field{
+			Name: field.ReferenceName,
+			Type: fieldType(field.Value.Type()),
+		}
+	}
+
+	return fields, nil
+}
+
+// newFilled allocates a new value of the given struct type, including any
+// embedded struct pointers, since the field extractor can't look through nil
+// embedded values
+func newFilled(t reflect.Type) reflect.Value {
+	val := reflect.New(t)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct && field.PkgPath == "" {
+			val.Elem().Field(i).Set(newFilled(field.Type.Elem()))
+		}
+	}
+	return val
+}
+
+// fieldType names a type the way it would be written in Go, except that named
+// types from this project are shown as the basic type they're based on
+func fieldType(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Slice:
+		return "[]" + fieldType(t.Elem())
+	case reflect.Map:
+		return "map[" + fieldType(t.Key()) + "]" + fieldType(t.Elem())
+	}
+
+	if strings.HasPrefix(t.PkgPath(), "github.com/asteris-llc/converge") && t.Kind() != reflect.Struct && t.Kind() != reflect.Interface {
+		return t.Kind().String()
+	}
+
+	return t.String()
+}
+
+func splitTag(tag string) []string {
+	if tag == "" {
+		return nil
+	}
+	return strings.Split(tag, ",")
+}
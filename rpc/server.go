@@ -19,10 +19,14 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"sync"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 
+	"github.com/asteris-llc/converge/artifact"
 	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/publish"
 	"github.com/asteris-llc/converge/rpc/pb"
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
 	"github.com/pkg/errors"
@@ -38,14 +42,67 @@ type Server struct {
 	// Serving
 	ResourceRoot         string
 	EnableBinaryDownload bool
+
+	// Debugging
+	DumpGraphDir string
+
+	// Journaling
+	JournalDir string
+
+	// Quarantine
+	QuarantineFile string
+
+	// ChangeWindows are the change windows nodes can refer to by name
+	ChangeWindows map[string]string
+
+	// PublishURL is where apply summaries are published. Publishing is
+	// disabled when it is empty.
+	PublishURL string
+
+	// ArtifactCacheDir is where artifacts are cached for agents. The cache is
+	// disabled when it is empty.
+	ArtifactCacheDir string
+
+	// ArtifactCacheTTL is how long cached artifacts are served before they
+	// are downloaded again. They are kept until removed when it is zero.
+	ArtifactCacheTTL time.Duration
+
+	// ArtifactCacheHosts are the upstream hosts the artifact cache fetches
+	// from. Artifacts from any other host are refused.
+	ArtifactCacheHosts []string
+
+	// ArtifactProxy is the artifact cache downloads go through. Downloads go
+	// straight to their source when it is empty.
+	ArtifactProxy string
+
+	// ArtifactProxyToken is the RPC token of the server with the artifact
+	// cache, if it requires one
+	ArtifactProxyToken string
+
+	// executions in flight
+	running sync.WaitGroup
 }
 
 // newGRPC constructs all GRPC servers and handlers
-func (s *Server) newGRPC() (*grpc.Server, error) {
+func (s *Server) newGRPC(ctx context.Context) (*grpc.Server, error) {
 	server := grpc.NewServer(s.Security.Server()...)
 
-	pb.RegisterExecutorServer(server, &executor{})
-	pb.RegisterGrapherServer(server, &grapher{})
+	loading := loadConfig{
+		dumpGraphDir:   s.DumpGraphDir,
+		quarantineFile: s.QuarantineFile,
+		changeWindows:  s.ChangeWindows,
+	}
+
+	var publisher publish.Publisher
+	if s.PublishURL != "" {
+		var err error
+		if publisher, err = publish.New(s.PublishURL); err != nil {
+			return nil, err
+		}
+	}
+
+	pb.RegisterExecutorServer(server, &executor{loadConfig: loading, journalDir: s.JournalDir, publisher: publisher, artifactProxy: s.ArtifactProxy, artifactProxyToken: s.ArtifactProxyToken, shutdown: ctx, running: &s.running})
+	pb.RegisterGrapherServer(server, &grapher{loadConfig: loading})
 	pb.RegisterResourceHostServer(
 		server,
 		&resourceHost{
@@ -84,7 +141,15 @@ func (s *Server) newREST(ctx context.Context, addr *url.URL) (*http.Server, erro
 		return nil, errors.Wrap(err, "could not register info server")
 	}
 
-	handler := http.Handler(mux)
+	handler := DownloadHandler(mux)
+
+	if s.ArtifactCacheDir != "" {
+		cache, err := artifact.New(s.ArtifactCacheDir, s.ArtifactCacheTTL, s.ArtifactCacheHosts)
+		if err != nil {
+			return nil, err
+		}
+		handler = cache.Handler(handler)
+	}
 
 	if s.Security.Token != "" {
 		handler = NewJWTAuth(s.Security.Token).Protect(handler)
@@ -146,7 +211,7 @@ func (s *Server) Listen(ctx context.Context, addr *url.URL) error {
 	// cleanup purposes. In most of these cases, receiving an error means we're
 	// already cleaned up so we just need to check which error it is.
 	wg.Go(func() error {
-		grpcSrv, err := s.newGRPC()
+		grpcSrv, err := s.newGRPC(ctx)
 		if err != nil {
 			return errors.Wrap(err, "failed to create grpc server")
 		}
@@ -202,6 +267,13 @@ func (s *Server) Listen(ctx context.Context, addr *url.URL) error {
 	return wg.Wait()
 }
 
+// Wait blocks until all in-flight executions have finished. Executions stop
+// scheduling new work and terminate running tasks when the context passed to
+// Listen is cancelled, so this returns promptly after shutdown.
+func (s *Server) Wait() {
+	s.running.Wait()
+}
+
 // IsClosedNetworkConnErr detects if an error is the use of a close network connection
 func IsClosedNetworkConnErr(err error) bool {
 	opErr, ok := err.(*net.OpError)
@@ -15,8 +15,11 @@
 package rpc
 
 import (
+	"github.com/asteris-llc/converge/errcode"
 	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/prettyprinters/human"
+	"github.com/asteris-llc/converge/render"
+	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/rpc/pb"
 )
 
@@ -37,15 +40,87 @@ func statusResponseFromPrintable(meta *node.Node, p human.Printable, stage pb.St
 
 	if err := p.Error(); err != nil {
 		resp.Details.Error = err.Error()
+
+		if code, ok := errcode.Of(err); ok {
+			resp.Details.ErrorCode = string(code)
+		}
 	}
 
-	for key, diff := range p.Changes() {
-		resp.Details.Changes[key] = &pb.DiffResponse{
-			Original: diff.Original(),
-			Current:  diff.Current(),
-			Changes:  diff.Changes(),
+	if reporter, ok := p.(resource.UsageReporter); ok {
+		if usage := reporter.Usage(); usage != nil {
+			resp.Details.Usage = &pb.UsageResponse{
+				CpuTime:      int64(usage.CPUTime),
+				PeakRSS:      usage.PeakRSS,
+				BytesWritten: usage.BytesWritten,
+			}
 		}
 	}
 
+	var warnings []resource.Warning
+	if prepared, ok := meta.LookupMetadata(node.MetaWarnings); ok {
+		deprecations, _ := prepared.([]resource.Warning)
+		warnings = append(warnings, deprecations...)
+	}
+	if reporter, ok := p.(resource.WarningReporter); ok {
+		warnings = append(warnings, reporter.Warnings()...)
+	}
+	for _, warning := range warnings {
+		resp.Details.Warnings = append(resp.Details.Warnings, &pb.WarningResponse{
+			Kind:    string(warning.Kind),
+			Message: warning.Message,
+		})
+	}
+
+	for _, origin := range render.Provenance(meta) {
+		resp.Details.Provenance = append(resp.Details.Provenance, &pb.ProvenanceResponse{
+			Field: origin.Field,
+			Kind:  string(origin.Kind),
+			Name:  origin.Name,
+			Node:  origin.Node,
+			Value: origin.Value,
+		})
+	}
+
+	if reporter, ok := p.(resource.DestructiveReporter); ok {
+		resp.Details.Destructive = reporter.Destructive()
+	}
+
+	if reporter, ok := p.(resource.ImpactReporter); ok {
+		resp.Details.Impact = reporter.Impact().String()
+	}
+
+	if reporter, ok := p.(resource.RollbackReporter); ok {
+		resp.Details.Rollbacks = reporter.Rollbacks()
+	}
+
+	if reporter, ok := p.(resource.OutcomeReporter); ok {
+		resp.Details.Outcome = string(reporter.Outcome())
+
+		if remaining := reporter.RemainingChanges(); len(remaining) > 0 {
+			resp.Details.RemainingChanges = map[string]*pb.DiffResponse{}
+			for key, diff := range remaining {
+				resp.Details.RemainingChanges[key] = diffResponse(diff)
+			}
+		}
+	}
+
+	for key, diff := range p.Changes() {
+		resp.Details.Changes[key] = diffResponse(diff)
+	}
+
+	return resp
+}
+
+func diffResponse(diff resource.Diff) *pb.DiffResponse {
+	resp := &pb.DiffResponse{
+		Original: diff.Original(),
+		Current:  diff.Current(),
+		Changes:  diff.Changes(),
+	}
+
+	if unified, ok := diff.(resource.UnifiedDiffer); ok {
+		resp.Unified = unified.Unified()
+	}
+
 	return resp
 }
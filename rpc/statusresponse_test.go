@@ -0,0 +1,47 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/plan"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStatusResponseWarnings tests that warnings raised while preparing a node
+// are reported along with the warnings from its status
+func TestStatusResponseWarnings(t *testing.T) {
+	status := resource.NewStatus()
+	status.AddWarning(resource.WarningResource, "from check")
+
+	meta := node.New("root/file.content.x", nil)
+	meta.AddMetadata(node.MetaWarnings, []resource.Warning{
+		{Kind: resource.WarningDeprecation, Message: `"path" is deprecated, use "destination" instead`},
+	})
+
+	resp := statusResponseFromPrintable(meta, &plan.Result{Status: status}, pb.StatusResponse_PLAN, pb.StatusResponse_FINISHED)
+	assert.Equal(
+		t,
+		[]*pb.WarningResponse{
+			{Kind: "deprecation", Message: `"path" is deprecated, use "destination" instead`},
+			{Kind: "resource", Message: "from check"},
+		},
+		resp.Details.Warnings,
+	)
+}
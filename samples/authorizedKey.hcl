@@ -0,0 +1,5 @@
+# authorize a deploy key for a user, only allowing it to run a single command
+user.authorized_key "deploy" {
+  user = "deploy"
+  key  = "command=\"/usr/local/bin/deploy\",no-pty ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHb0Kq0qfMmMUSC6ZwwqHdkEmJuQCrfMJyHwjsZlXmzM deploy@ci"
+}
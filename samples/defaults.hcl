@@ -0,0 +1,32 @@
+/* Defaults set field values for every resource of a type in a module, so they
+don't have to be repeated. A block named after a group of types, like "file",
+applies to each type in the group, and fields set on a resource always win. */
+
+defaults "task" {
+  interpreter = "/bin/bash"
+  check_flags = ["-n"]
+  timeout     = "60s"
+}
+
+defaults "file" {
+  destination = "/tmp/converge-defaults"
+}
+
+task "create" {
+  check = "test -d /tmp/converge-defaults"
+  apply = "mkdir -p /tmp/converge-defaults"
+}
+
+task "slow" {
+  check   = "test -f /tmp/converge-defaults/slow"
+  apply   = "sleep 1 && touch /tmp/converge-defaults/slow"
+  timeout = "5m"
+
+  depends = ["task.create"]
+}
+
+file.mode "mode" {
+  mode = "0750"
+
+  depends = ["task.create"]
+}
@@ -0,0 +1,17 @@
+file.directory "shared" {
+  destination = "/srv/shared"
+}
+
+file.acl "shared" {
+  destination = "/srv/shared"
+  purge       = true
+
+  entries = [
+    "group:developers:rwx",
+    "user:deploy:r-x",
+    "default:group:developers:rwx",
+    "default:user:deploy:r-x",
+  ]
+
+  depends = ["file.directory.shared"]
+}
@@ -0,0 +1,11 @@
+file.content "resolv" {
+  destination = "/etc/resolv.conf"
+  content     = "nameserver 10.0.0.2\n"
+}
+
+file.attributes "resolv" {
+  destination = "/etc/resolv.conf"
+  immutable   = true
+
+  depends = ["file.content.resolv"]
+}
@@ -0,0 +1,10 @@
+file.block "internal" {
+  destination = "/tmp/hosts"
+  marker      = "# {mark} internal hosts"
+  create      = true
+
+  block = <<EOF
+10.0.0.10 db.internal
+10.0.0.11 cache.internal
+EOF
+}
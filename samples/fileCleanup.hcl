@@ -0,0 +1,14 @@
+file.cleanup "old-logs" {
+  directory         = "logs"
+  include           = ["*.log", "*.log.gz"]
+  older_than        = "168h"
+  larger_than       = "1K"
+  recursive         = true
+  allow_destructive = true
+}
+
+file.cleanup "unmanaged" {
+  directory = "conf.d"
+  include   = ["*.conf"]
+  exclude   = ["app.conf"]
+}
@@ -0,0 +1,8 @@
+param "hostname" {
+  default = "localhost"
+}
+
+file.content "motd" {
+  destination = "motd.txt"
+  source      = "templates/motd.tmpl"
+}
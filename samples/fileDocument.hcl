@@ -0,0 +1,18 @@
+file.document "docker-daemon" {
+  destination = "/tmp/daemon.json"
+  create      = true
+
+  merge_patch = <<EOF
+{
+  "log-driver": "json-file",
+  "log-opts": {"max-size": "10m"}
+}
+EOF
+
+  set {
+    "log-opts.max-file" = "3"
+    "live-restore"      = "true"
+  }
+
+  delete = ["debug"]
+}
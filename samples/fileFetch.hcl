@@ -5,3 +5,12 @@ file.fetch "consul.zip" {
   hash_type   = "sha256"
   hash        = "abdf0e1856292468e2c9971420d73b805e93888e006c76324ae39416edcf0627"
 }
+
+# fetch again whenever the file changes on the server
+file.fetch "consul-checksums" {
+  source      = "https://releases.hashicorp.com/consul/0.6.4/consul_0.6.4_SHA256SUMS"
+  destination = "/tmp/consul_SHA256SUMS"
+  conditional = true
+  retries     = 3
+  retry_delay = "2s"
+}
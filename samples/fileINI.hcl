@@ -0,0 +1,12 @@
+file.ini "php" {
+  destination = "/tmp/php.ini"
+  create      = true
+
+  settings {
+    "PHP/memory_limit"        = "256M"
+    "PHP/upload_max_filesize" = "64M"
+    "Date/date.timezone"      = "UTC"
+  }
+
+  remove = ["PHP/expose_php"]
+}
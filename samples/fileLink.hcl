@@ -0,0 +1,17 @@
+file.content "config" {
+  destination = "app.conf"
+  content     = "port = 8080\n"
+}
+
+file.link "current" {
+  destination = "current.conf"
+  target      = "app.conf"
+  depends     = ["file.content.config"]
+}
+
+file.link "backup" {
+  destination = "app.conf.bak"
+  target      = "app.conf"
+  hard        = true
+  depends     = ["file.content.config"]
+}
@@ -0,0 +1,18 @@
+param "root" {
+  default = "app"
+}
+
+file.directory "root" {
+  destination = "{{param `root`}}/conf.d"
+  create_all  = true
+}
+
+file.mode "tree" {
+  destination    = "{{param `root`}}"
+  mode           = 0640
+  directory_mode = 0750
+  recursive      = true
+  exclude        = ["cache"]
+
+  depends = ["file.directory.root"]
+}
@@ -0,0 +1,12 @@
+file.directory "www" {
+  destination = "/srv/www"
+}
+
+file.selinux "www" {
+  destination = "/srv/www"
+  type        = "httpd_sys_content_t"
+  recursive   = true
+  persistent  = true
+
+  depends = ["file.directory.www"]
+}
@@ -0,0 +1,15 @@
+# build in a temporary directory which is removed at the end of the run
+file.tempdir "build" {
+  prefix = "build-"
+}
+
+file.content "main" {
+  destination = "{{lookup `file.tempdir.build.path`}}/main.c"
+  content     = "int main() { return 0; }\n"
+}
+
+task "compile" {
+  check   = "test -x /usr/local/bin/example"
+  apply   = "cc -o /usr/local/bin/example {{lookup `file.content.main.destination`}}"
+  depends = ["file.content.main"]
+}
@@ -0,0 +1,8 @@
+file.touch "ready" {
+  destination = "ready"
+}
+
+file.touch "release" {
+  destination = "release.stamp"
+  mtime       = "2016-10-01T12:00:00Z"
+}
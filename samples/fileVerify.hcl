@@ -0,0 +1,17 @@
+# verify a fetched file before using it
+file.fetch "consul.zip" {
+  source      = "https://releases.hashicorp.com/consul/0.6.4/consul_0.6.4_linux_amd64.zip"
+  destination = "/tmp/consul.zip"
+}
+
+file.verify "consul.zip" {
+  destination = "{{lookup `file.fetch.consul.zip.destination`}}"
+  hash_type   = "sha256"
+  hash        = "abdf0e1856292468e2c9971420d73b805e93888e006c76324ae39416edcf0627"
+}
+
+unarchive "consul.zip" {
+  source      = "/tmp/consul.zip"
+  destination = "/tmp/consul"
+  depends     = ["file.verify.consul.zip"]
+}
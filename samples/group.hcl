@@ -1,4 +1,4 @@
-# create a group, only works on linux
+# create a group, works on linux and macOS
 user.group "group" {
   name = "test"
 }
@@ -0,0 +1,7 @@
+# create a group with exactly these members, works on linux and macOS
+user.group "admins" {
+  name           = "admins"
+  gid            = 1500
+  members        = ["alice", "bob"]
+  strict_members = true
+}
@@ -0,0 +1,11 @@
+# mount a small tmpfs for scratch space, and keep it in /etc/fstab
+file.directory "scratch" {
+  destination = "/mnt/converge-scratch"
+}
+
+mount "scratch" {
+  device     = "tmpfs"
+  mountpoint = "{{lookup `file.directory.scratch.destination`}}"
+  fstype     = "tmpfs"
+  options    = ["nosuid", "nodev", "size=16384k"]
+}
@@ -0,0 +1,14 @@
+/* Overrides replace fields of a resource in a module this one calls, named by
+the path to the resource. The values are rendered in the called module, so
+params and lookups refer to its nodes. */
+
+module "basic.hcl" "basic" {
+  params = {
+    filename = "override.txt"
+  }
+}
+
+override "module.basic/task.render" {
+  interpreter = "/bin/bash"
+  apply       = "echo '{{param `message`}}' | tee {{param `filename`}}"
+}
@@ -0,0 +1,13 @@
+# nodes can be limited to some platforms, as "os" or "os/arch". On other hosts
+# they're skipped instead of checked or applied.
+file.content "linux" {
+  destination = "platforms-linux.txt"
+  content     = "only written on linux"
+  platforms   = ["linux"]
+}
+
+file.content "darwin" {
+  destination = "platforms-darwin.txt"
+  content     = "only written on 64-bit intel macs"
+  platforms   = ["darwin/amd64"]
+}
@@ -6,3 +6,9 @@ file.content "hostname-data" {
   destination = "hostname.txt"
   content     = "{{lookup `task.query.hostname.status.stdout`}}"
 }
+
+# expensive queries can be cached, so they only run once for plan and apply
+task.query "uptime" {
+  query     = "uptime"
+  cache_ttl = "5m"
+}
@@ -0,0 +1,5 @@
+service "cron" {
+  name    = "cron"
+  state   = "running"
+  enabled = true
+}
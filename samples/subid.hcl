@@ -0,0 +1,6 @@
+# give a user subordinate ids for rootless containers
+user.subid "podman" {
+  user = "podman"
+  uids = ["100000:65536"]
+  gids = ["100000:65536"]
+}
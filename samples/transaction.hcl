@@ -0,0 +1,20 @@
+file.content "config" {
+  destination = "/tmp/converge-transaction.conf"
+  content     = "workers = 4"
+  transaction = "service"
+}
+
+task "reload" {
+  check       = "test -f /tmp/converge-transaction.reloaded"
+  apply       = "touch /tmp/converge-transaction.reloaded"
+  rollback    = "rm -f /tmp/converge-transaction.reloaded"
+  transaction = "service"
+  depends     = ["file.content.config"]
+}
+
+task "verify" {
+  check       = "test -f /tmp/converge-transaction.healthy"
+  apply       = "test -f /tmp/converge-transaction.healthy"
+  transaction = "service"
+  depends     = ["task.reload"]
+}
@@ -20,6 +20,7 @@ file.fetch "consul.zip" {
 unarchive "consul.zip" {
   source      = "{{param `zip`}}"
   destination = "{{param `destination`}}"
+  creates     = "consul"
 
   depends = ["file.directory.consul", "file.fetch.consul.zip"]
 }
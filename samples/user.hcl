@@ -1,4 +1,4 @@
-# create a user, only works on linux
+# create a user, works on linux, macOS and FreeBSD
 user.user "user" {
   username = "test"
 }
@@ -0,0 +1,15 @@
+# look up a user and give a file in its home directory to it
+user.query "deploy" {
+  username = "deploy"
+}
+
+file.content "profile" {
+  destination = "{{lookup `user.query.deploy.homedir`}}/.profile"
+  content     = "export PATH=$HOME/bin:$PATH\n"
+}
+
+file.owner "profile" {
+  destination = "{{lookup `file.content.profile.destination`}}"
+  user        = "{{lookup `user.query.deploy.username`}}"
+  group       = "{{lookup `user.query.deploy.groupname`}}"
+}
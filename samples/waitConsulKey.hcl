@@ -0,0 +1,6 @@
+wait.consul_key "schema" {
+  key       = "deploy/app/schema"
+  value     = "migrated"
+  interval  = "10s"
+  max_retry = 60
+}
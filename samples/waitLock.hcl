@@ -0,0 +1,12 @@
+wait.lock "migrate" {
+  key       = "locks/app/migrate"
+  hold      = "30m"
+  interval  = "10s"
+  max_retry = 60
+}
+
+task "migrate" {
+  check   = "app-migrate --status"
+  apply   = "app-migrate"
+  depends = ["wait.lock.migrate"]
+}